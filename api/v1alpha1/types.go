@@ -1,10 +1,13 @@
 package v1alpha1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // ExposureType enumerates how a Workspace's Service should be exposed.
@@ -47,6 +50,104 @@ type WorkspaceExposure struct {
 	TLSIssuer string `json:"tlsIssuer,omitempty"`
 }
 
+// WorkspaceIngress overrides the Ingress the operator creates when exposure.type is Ingress.
+type WorkspaceIngress struct {
+	// Host defaults to <workspace>.<exposure.domain>.
+	// +optional
+	Host string `json:"host,omitempty"`
+	// Path defaults to "/".
+	// +optional
+	Path string `json:"path,omitempty"`
+	// PathType defaults to Prefix.
+	// +kubebuilder:validation:Enum=Prefix;Exact;ImplementationSpecific
+	// +optional
+	PathType networkingv1.PathType `json:"pathType,omitempty"`
+	// Annotations are merged over DefaultIngressAnnotations; a key set here wins.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// DefaultIngressAnnotations returns the nginx annotations every workspace Ingress starts
+// from: websockets enabled and long proxy timeouts for IDE sessions.
+func DefaultIngressAnnotations() map[string]string {
+	return map[string]string{
+		"nginx.ingress.kubernetes.io/enable-websocket":   "true",
+		"nginx.ingress.kubernetes.io/proxy-read-timeout": "3600",
+		"nginx.ingress.kubernetes.io/proxy-send-timeout": "3600",
+		"nginx.ingress.kubernetes.io/backend-protocol":   "HTTP",
+	}
+}
+
+// AnnotationCodeServerAuth set to "none" starts a code-server workspace without password
+// auth (dev clusters only). Otherwise the operator generates a password, see
+// CredentialsSecretName.
+const AnnotationCodeServerAuth = "guildnet.io/code-server-auth"
+
+// CredentialsSecretKey is the key holding the generated password in the credentials Secret.
+const CredentialsSecretKey = "password"
+
+// CredentialsSecretName is the Secret (owned by the Workspace) holding the password the
+// operator generates for code-server workspaces that set no PASSWORD themselves.
+func CredentialsSecretName(workspace string) string { return workspace + "-credentials" }
+
+// WorkspaceStrategy configures how the Workspace Deployment rolls out changes.
+type WorkspaceStrategy struct {
+	// Type is RollingUpdate (default) or Recreate.
+	// +kubebuilder:validation:Enum=RollingUpdate;Recreate
+	// +optional
+	Type appsv1.DeploymentStrategyType `json:"type,omitempty"`
+	// MaxSurge for RollingUpdate (absolute number or percentage). Defaults to 25%.
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// MaxUnavailable for RollingUpdate (absolute number or percentage). Defaults to 25%.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// WorkspaceAutoscale configures a HorizontalPodAutoscaler for the Workspace Deployment.
+// Requires metrics-server in the cluster; without it the HPA exists but cannot scale.
+type WorkspaceAutoscale struct {
+	// MinReplicas defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper bound. Required.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetCPUUtilization is the average CPU utilization percentage to target. Defaults to 80.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	TargetCPUUtilization *int32 `json:"targetCPUUtilization,omitempty"`
+}
+
+// RunMode selects what the operator runs for a Workspace.
+// +kubebuilder:validation:Enum=Service;Job
+type RunMode string
+
+const (
+	// RunModeService (default) runs a long-lived Deployment behind a Service.
+	RunModeService RunMode = "Service"
+	// RunModeJob runs the workspace once to completion as a Kubernetes Job.
+	RunModeJob RunMode = "Job"
+)
+
+// WorkspaceJob tunes the Job created for runMode Job.
+type WorkspaceJob struct {
+	// RestartPolicy for the job pods: Never (default) or OnFailure.
+	// +kubebuilder:validation:Enum=Never;OnFailure
+	// +optional
+	RestartPolicy corev1.RestartPolicy `json:"restartPolicy,omitempty"`
+	// BackoffLimit is the number of retries before the job is marked failed. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// ActiveDeadlineSeconds bounds the job's total run time.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
 // WorkspaceSpec defines the desired state of a Workspace.
 type WorkspaceSpec struct {
 	// Image is the container image to run. Required.
@@ -55,6 +156,9 @@ type WorkspaceSpec struct {
 	// Env is a list of extra environment variables.
 	// +optional
 	Env []corev1.EnvVar `json:"env,omitempty"`
+	// EnvFrom populates environment variables from ConfigMaps or Secrets in the workspace namespace.
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
 	// Ports exposed by the primary container.
 	// +optional
 	Ports []WorkspacePort `json:"ports,omitempty"`
@@ -67,15 +171,64 @@ type WorkspaceSpec struct {
 	// Notes is free-form text.
 	// +optional
 	Notes string `json:"notes,omitempty"`
+	// HostAliases are extra /etc/hosts entries injected into the pod.
+	// +optional
+	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty"`
+	// DNSPolicy overrides the pod DNS policy (e.g. None when DNSConfig fully specifies resolvers).
+	// +optional
+	DNSPolicy corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+	// DNSConfig adds nameservers, searches and resolver options to the pod.
+	// +optional
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+	// Strategy overrides the Deployment update strategy (defaults to RollingUpdate 25%/25%).
+	// +optional
+	Strategy *WorkspaceStrategy `json:"strategy,omitempty"`
+	// Autoscale, when set, hands replica management to an HPA owned by the Workspace.
+	// +optional
+	Autoscale *WorkspaceAutoscale `json:"autoscale,omitempty"`
+	// ServiceAccountName runs the pod as this service account (namespace default when empty).
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// AutomountServiceAccountToken mounts the service account token into the pod.
+	// Defaults to false so a compromised workspace cannot reach the API server.
+	// +optional
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+	// PriorityClassName sets pod scheduling priority. A class missing from the cluster
+	// is reported in status and left off the pod rather than failing the reconcile.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// MinAvailable, when set, makes the operator maintain a PodDisruptionBudget owned by
+	// the Workspace (only while it runs more than one replica). Exclusive with MaxUnavailable.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the PodDisruptionBudget alternative to MinAvailable.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// Ingress customizes host, path and annotations of the Ingress created for
+	// exposure.type Ingress.
+	// +optional
+	Ingress *WorkspaceIngress `json:"ingress,omitempty"`
+	// RunMode is Service (default, long-running) or Job (run to completion; no Service,
+	// Ingress, HPA or PodDisruptionBudget is created).
+	// +optional
+	RunMode RunMode `json:"runMode,omitempty"`
+	// Job tunes the Job created for runMode Job.
+	// +optional
+	Job *WorkspaceJob `json:"job,omitempty"`
+	// Resources for the workspace container. Requests and limits left unset are filled
+	// from the cluster defaults (workspace_default_requests/limits in cluster settings).
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // WorkspacePhase is a coarse phase indicator.
-// +kubebuilder:validation:Enum=Pending;Running;Failed;Terminating
+// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed;Terminating
 type WorkspacePhase string
 
 const (
 	PhasePending     WorkspacePhase = "Pending"
 	PhaseRunning     WorkspacePhase = "Running"
+	PhaseSucceeded   WorkspacePhase = "Succeeded"
 	PhaseFailed      WorkspacePhase = "Failed"
 	PhaseTerminating WorkspacePhase = "Terminating"
 )
@@ -88,6 +241,11 @@ type WorkspaceStatus struct {
 	// ReadyReplicas mirrors the underlying Deployment.
 	// +optional
 	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// CurrentReplicas and DesiredReplicas mirror the HPA when autoscaling is enabled.
+	// +optional
+	CurrentReplicas int32 `json:"currentReplicas,omitempty"`
+	// +optional
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
 	// ServiceDNS is the computed service DNS name.
 	// +optional
 	ServiceDNS string `json:"serviceDNS,omitempty"`
@@ -106,6 +264,10 @@ type WorkspaceStatus struct {
 	// LastError holds a brief error string from last reconcile attempt.
 	// +optional
 	LastError string `json:"lastError,omitempty"`
+	// EffectiveResources are the container resources applied after merging cluster
+	// defaults into spec.resources.
+	// +optional
+	EffectiveResources *corev1.ResourceRequirements `json:"effectiveResources,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -193,7 +355,15 @@ func (in *Workspace) DeepCopyInto(out *Workspace) {
 	out.Spec = WorkspaceSpec{Image: in.Spec.Image, PresetsRef: in.Spec.PresetsRef, Notes: in.Spec.Notes}
 	if in.Spec.Env != nil {
 		out.Spec.Env = make([]corev1.EnvVar, len(in.Spec.Env))
-		copy(out.Spec.Env, in.Spec.Env)
+		for i := range in.Spec.Env {
+			in.Spec.Env[i].DeepCopyInto(&out.Spec.Env[i])
+		}
+	}
+	if in.Spec.EnvFrom != nil {
+		out.Spec.EnvFrom = make([]corev1.EnvFromSource, len(in.Spec.EnvFrom))
+		for i := range in.Spec.EnvFrom {
+			in.Spec.EnvFrom[i].DeepCopyInto(&out.Spec.EnvFrom[i])
+		}
 	}
 	if in.Spec.Ports != nil {
 		out.Spec.Ports = make([]WorkspacePort, len(in.Spec.Ports))
@@ -203,7 +373,78 @@ func (in *Workspace) DeepCopyInto(out *Workspace) {
 		e := *in.Spec.Exposure
 		out.Spec.Exposure = &e
 	}
+	if in.Spec.HostAliases != nil {
+		out.Spec.HostAliases = make([]corev1.HostAlias, len(in.Spec.HostAliases))
+		for i := range in.Spec.HostAliases {
+			in.Spec.HostAliases[i].DeepCopyInto(&out.Spec.HostAliases[i])
+		}
+	}
+	out.Spec.DNSPolicy = in.Spec.DNSPolicy
+	out.Spec.DNSConfig = in.Spec.DNSConfig.DeepCopy()
+	if in.Spec.Strategy != nil {
+		st := *in.Spec.Strategy
+		if in.Spec.Strategy.MaxSurge != nil {
+			v := *in.Spec.Strategy.MaxSurge
+			st.MaxSurge = &v
+		}
+		if in.Spec.Strategy.MaxUnavailable != nil {
+			v := *in.Spec.Strategy.MaxUnavailable
+			st.MaxUnavailable = &v
+		}
+		out.Spec.Strategy = &st
+	}
+	if in.Spec.Autoscale != nil {
+		as := *in.Spec.Autoscale
+		if in.Spec.Autoscale.MinReplicas != nil {
+			v := *in.Spec.Autoscale.MinReplicas
+			as.MinReplicas = &v
+		}
+		if in.Spec.Autoscale.TargetCPUUtilization != nil {
+			v := *in.Spec.Autoscale.TargetCPUUtilization
+			as.TargetCPUUtilization = &v
+		}
+		out.Spec.Autoscale = &as
+	}
+	out.Spec.ServiceAccountName = in.Spec.ServiceAccountName
+	if in.Spec.AutomountServiceAccountToken != nil {
+		v := *in.Spec.AutomountServiceAccountToken
+		out.Spec.AutomountServiceAccountToken = &v
+	}
+	out.Spec.PriorityClassName = in.Spec.PriorityClassName
+	if in.Spec.MinAvailable != nil {
+		v := *in.Spec.MinAvailable
+		out.Spec.MinAvailable = &v
+	}
+	if in.Spec.MaxUnavailable != nil {
+		v := *in.Spec.MaxUnavailable
+		out.Spec.MaxUnavailable = &v
+	}
+	if in.Spec.Ingress != nil {
+		ing := *in.Spec.Ingress
+		if in.Spec.Ingress.Annotations != nil {
+			ing.Annotations = make(map[string]string, len(in.Spec.Ingress.Annotations))
+			for k, v := range in.Spec.Ingress.Annotations {
+				ing.Annotations[k] = v
+			}
+		}
+		out.Spec.Ingress = &ing
+	}
+	out.Spec.RunMode = in.Spec.RunMode
+	if in.Spec.Job != nil {
+		j := *in.Spec.Job
+		if in.Spec.Job.BackoffLimit != nil {
+			v := *in.Spec.Job.BackoffLimit
+			j.BackoffLimit = &v
+		}
+		if in.Spec.Job.ActiveDeadlineSeconds != nil {
+			v := *in.Spec.Job.ActiveDeadlineSeconds
+			j.ActiveDeadlineSeconds = &v
+		}
+		out.Spec.Job = &j
+	}
+	out.Spec.Resources = in.Spec.Resources.DeepCopy()
 	out.Status = in.Status
+	out.Status.EffectiveResources = in.Status.EffectiveResources.DeepCopy()
 	if in.Status.Conditions != nil {
 		out.Status.Conditions = make([]metav1.Condition, len(in.Status.Conditions))
 		copy(out.Status.Conditions, in.Status.Conditions)
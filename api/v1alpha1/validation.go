@@ -0,0 +1,267 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// FieldError is a validation failure attributed to a single spec field (JSON path).
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string { return e.Field + ": " + e.Message }
+
+func fieldErr(field, format string, args ...any) error {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidateHostAliases checks each alias has a valid IP and DNS-1123 hostnames.
+func ValidateHostAliases(aliases []corev1.HostAlias) error {
+	for i, ha := range aliases {
+		if net.ParseIP(strings.TrimSpace(ha.IP)) == nil {
+			return fieldErr(fmt.Sprintf("hostAliases[%d].ip", i), "invalid IP %q", ha.IP)
+		}
+		if len(ha.Hostnames) == 0 {
+			return fieldErr(fmt.Sprintf("hostAliases[%d].hostnames", i), "at least one hostname required")
+		}
+		for j, h := range ha.Hostnames {
+			if errs := validation.IsDNS1123Subdomain(h); len(errs) > 0 {
+				return fieldErr(fmt.Sprintf("hostAliases[%d].hostnames[%d]", i, j), "%q: %s", h, strings.Join(errs, "; "))
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateEnvFrom checks each source references exactly one ConfigMap or Secret by a valid
+// name and that any prefix is a valid environment variable name.
+func ValidateEnvFrom(sources []corev1.EnvFromSource) error {
+	for i, src := range sources {
+		var name string
+		switch {
+		case src.ConfigMapRef != nil && src.SecretRef != nil:
+			return fieldErr(fmt.Sprintf("envFrom[%d]", i), "only one of configMapRef or secretRef may be set")
+		case src.ConfigMapRef != nil:
+			name = src.ConfigMapRef.Name
+		case src.SecretRef != nil:
+			name = src.SecretRef.Name
+		default:
+			return fieldErr(fmt.Sprintf("envFrom[%d]", i), "one of configMapRef or secretRef required")
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			ref := "configMapRef"
+			if src.SecretRef != nil {
+				ref = "secretRef"
+			}
+			return fieldErr(fmt.Sprintf("envFrom[%d].%s.name", i, ref), "%q: %s", name, strings.Join(errs, "; "))
+		}
+		if src.Prefix != "" {
+			if errs := validation.IsEnvVarName(src.Prefix); len(errs) > 0 {
+				return fieldErr(fmt.Sprintf("envFrom[%d].prefix", i), "%q: %s", src.Prefix, strings.Join(errs, "; "))
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateDNS checks nameserver IPs and search domains, and that policy None carries a config.
+func ValidateDNS(policy corev1.DNSPolicy, cfg *corev1.PodDNSConfig) error {
+	switch policy {
+	case "", corev1.DNSClusterFirst, corev1.DNSClusterFirstWithHostNet, corev1.DNSDefault, corev1.DNSNone:
+	default:
+		return fieldErr("dnsPolicy", "unsupported value %q", policy)
+	}
+	if policy == corev1.DNSNone && (cfg == nil || len(cfg.Nameservers) == 0) {
+		return fieldErr("dnsConfig.nameservers", "required when dnsPolicy is None")
+	}
+	if cfg == nil {
+		return nil
+	}
+	for i, ns := range cfg.Nameservers {
+		if net.ParseIP(strings.TrimSpace(ns)) == nil {
+			return fieldErr(fmt.Sprintf("dnsConfig.nameservers[%d]", i), "invalid IP %q", ns)
+		}
+	}
+	for i, s := range cfg.Searches {
+		if errs := validation.IsDNS1123Subdomain(strings.TrimSuffix(s, ".")); len(errs) > 0 {
+			return fieldErr(fmt.Sprintf("dnsConfig.searches[%d]", i), "%q: %s", s, strings.Join(errs, "; "))
+		}
+	}
+	for i, o := range cfg.Options {
+		if strings.TrimSpace(o.Name) == "" {
+			return fieldErr(fmt.Sprintf("dnsConfig.options[%d].name", i), "required")
+		}
+	}
+	return nil
+}
+
+// ValidateStrategy checks the strategy type and that RollingUpdate cannot stall
+// (maxSurge and maxUnavailable both resolving to zero for a single replica).
+func ValidateStrategy(st *WorkspaceStrategy) error {
+	if st == nil {
+		return nil
+	}
+	switch st.Type {
+	case "", appsv1.RollingUpdateDeploymentStrategyType:
+	case appsv1.RecreateDeploymentStrategyType:
+		if st.MaxSurge != nil || st.MaxUnavailable != nil {
+			return fieldErr("strategy", "maxSurge/maxUnavailable not allowed with Recreate")
+		}
+		return nil
+	default:
+		return fieldErr("strategy.type", "unsupported value %q", st.Type)
+	}
+	surge, unavailable := 1, 0
+	if st.MaxSurge != nil {
+		v, err := intstr.GetScaledValueFromIntOrPercent(st.MaxSurge, 1, true)
+		if err != nil || v < 0 {
+			return fieldErr("strategy.maxSurge", "invalid value %q", st.MaxSurge.String())
+		}
+		surge = v
+	}
+	if st.MaxUnavailable != nil {
+		v, err := intstr.GetScaledValueFromIntOrPercent(st.MaxUnavailable, 1, false)
+		if err != nil || v < 0 {
+			return fieldErr("strategy.maxUnavailable", "invalid value %q", st.MaxUnavailable.String())
+		}
+		unavailable = v
+	}
+	if surge == 0 && unavailable == 0 {
+		return fieldErr("strategy", "maxSurge and maxUnavailable cannot both be 0")
+	}
+	return nil
+}
+
+// ValidateAutoscale checks replica bounds and the CPU target range.
+func ValidateAutoscale(as *WorkspaceAutoscale) error {
+	if as == nil {
+		return nil
+	}
+	minR := int32(1)
+	if as.MinReplicas != nil {
+		minR = *as.MinReplicas
+	}
+	if minR < 1 {
+		return fieldErr("autoscale.minReplicas", "must be >= 1")
+	}
+	if as.MaxReplicas < minR {
+		return fieldErr("autoscale.maxReplicas", "must be >= minReplicas (%d)", minR)
+	}
+	if t := as.TargetCPUUtilization; t != nil && (*t < 1 || *t > 100) {
+		return fieldErr("autoscale.targetCPUUtilization", "must be between 1 and 100")
+	}
+	return nil
+}
+
+// ValidateServiceAccountName checks the optional service account name is a DNS-1123 subdomain.
+func ValidateServiceAccountName(name string) error {
+	if name == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fieldErr("serviceAccountName", "%q: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidatePriorityClassName checks the optional priority class name is a DNS-1123 subdomain.
+// Whether the class exists is only known to the cluster and is not checked here.
+func ValidatePriorityClassName(name string) error {
+	if name == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fieldErr("priorityClassName", "%q: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidateDisruption checks that at most one of minAvailable/maxUnavailable is set and
+// that it is a non-negative integer or a percentage between 0% and 100%.
+func ValidateDisruption(minAvailable, maxUnavailable *intstr.IntOrString) error {
+	if minAvailable != nil && maxUnavailable != nil {
+		return fieldErr("minAvailable", "only one of minAvailable or maxUnavailable may be set")
+	}
+	for field, v := range map[string]*intstr.IntOrString{"minAvailable": minAvailable, "maxUnavailable": maxUnavailable} {
+		if v == nil {
+			continue
+		}
+		if v.Type == intstr.String && !strings.HasSuffix(v.StrVal, "%") {
+			return fieldErr(field, "invalid value %q", v.StrVal)
+		}
+		n, err := intstr.GetScaledValueFromIntOrPercent(v, 100, false)
+		if err != nil || n < 0 || (v.Type == intstr.String && n > 100) {
+			return fieldErr(field, "invalid value %q", v.String())
+		}
+	}
+	return nil
+}
+
+// ValidateIngress checks spec.ingress overrides. When domain is set (the cluster's
+// ingress domain), host must be that domain or a subdomain of it.
+func ValidateIngress(ing *WorkspaceIngress, domain string) error {
+	if ing == nil {
+		return nil
+	}
+	if ing.Host != "" {
+		if errs := validation.IsDNS1123Subdomain(ing.Host); len(errs) > 0 {
+			return fieldErr("ingress.host", "%q: %s", ing.Host, strings.Join(errs, "; "))
+		}
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" && ing.Host != domain && !strings.HasSuffix(ing.Host, "."+domain) {
+			return fieldErr("ingress.host", "%q must be within the cluster ingress domain %q", ing.Host, domain)
+		}
+	}
+	if ing.Path != "" && !strings.HasPrefix(ing.Path, "/") {
+		return fieldErr("ingress.path", "%q must start with /", ing.Path)
+	}
+	switch ing.PathType {
+	case "", networkingv1.PathTypePrefix, networkingv1.PathTypeExact, networkingv1.PathTypeImplementationSpecific:
+	default:
+		return fieldErr("ingress.pathType", "unsupported path type %q", ing.PathType)
+	}
+	for k := range ing.Annotations {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fieldErr("ingress.annotations", "invalid key %q: %s", k, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// ValidateRunMode checks the run mode and that job tuning is only given for runMode Job.
+func ValidateRunMode(mode RunMode, job *WorkspaceJob) error {
+	switch mode {
+	case "", RunModeService:
+		if job != nil {
+			return fieldErr("job", "only allowed with runMode Job")
+		}
+		return nil
+	case RunModeJob:
+	default:
+		return fieldErr("runMode", "unsupported value %q", mode)
+	}
+	if job == nil {
+		return nil
+	}
+	switch job.RestartPolicy {
+	case "", corev1.RestartPolicyNever, corev1.RestartPolicyOnFailure:
+	default:
+		return fieldErr("job.restartPolicy", "unsupported value %q (Never or OnFailure)", job.RestartPolicy)
+	}
+	if job.BackoffLimit != nil && *job.BackoffLimit < 0 {
+		return fieldErr("job.backoffLimit", "must be >= 0")
+	}
+	if job.ActiveDeadlineSeconds != nil && *job.ActiveDeadlineSeconds < 1 {
+		return fieldErr("job.activeDeadlineSeconds", "must be >= 1")
+	}
+	return nil
+}
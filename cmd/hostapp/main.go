@@ -2,9 +2,7 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
 	"crypto/tls"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,8 +14,10 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -65,68 +65,113 @@ type kubeconfigResolver struct {
 	Sec *secrets.Manager
 }
 
+// KubeconfigYAML reads the credential the same way as the API handlers (api.ClusterKubeconfig).
 func (r kubeconfigResolver) KubeconfigYAML(clusterID string) (string, error) {
-	if r.DB == nil {
-		return "", fmt.Errorf("no db")
-	}
-	var cred map[string]any
-	key := fmt.Sprintf("cl:%s:kubeconfig", clusterID)
-	if err := r.DB.Get("credentials", key, &cred); err != nil {
-		return "", err
+	return api.ClusterKubeconfig(r.DB, r.Sec, clusterID)
+}
+
+// operatorConfig controls leader election for an in-process operator.
+type operatorConfig struct {
+	LeaderElection bool
+	LeaseNamespace string
+	LeaseName      string
+}
+
+func operatorConfigFromGlobal(g settings.Global) operatorConfig {
+	oc := operatorConfig{LeaderElection: g.OperatorLeaderElection, LeaseNamespace: g.OperatorLeaseNamespace, LeaseName: g.OperatorLeaseName}
+	if oc.LeaseNamespace == "" {
+		oc.LeaseNamespace = "default"
 	}
-	val := fmt.Sprint(cred["value"])
-	if enc, _ := cred["encrypted"].(bool); enc {
-		if r.Sec == nil {
-			return "", fmt.Errorf("encrypted but no secrets manager")
-		}
-		s, err := r.Sec.Decrypt(val)
-		if err != nil {
-			return "", err
-		}
-		return s, nil
+	if oc.LeaseName == "" {
+		oc.LeaseName = "guildnet-operator"
 	}
-	return val, nil
+	return oc
+}
+
+// operatorState is reported on /livez and /readyz: whether an operator runs in this process and
+// whether it currently holds the lease (always true without leader election).
+var operatorState struct {
+	running atomic.Bool
+	leader  atomic.Bool
 }
 
 // startOperator boots a controller-runtime manager that reconciles Workspace CRDs.
-func startOperator(ctx context.Context, restCfg *rest.Config) error {
+// With leader election enabled only the replica holding the Lease reconciles.
+func startOperator(ctx context.Context, restCfg *rest.Config, oc operatorConfig) error {
 	scheme := runtime.NewScheme()
 	_ = clientgoscheme.AddToScheme(scheme)
 	_ = apiv1alpha1.AddToScheme(scheme)
 	// configure controller-runtime logger (dev mode)
 	crlog.SetLogger(zap.New(zap.UseDevMode(true)))
-	opts := ctrl.Options{Scheme: scheme}
+	opts := ctrl.Options{Scheme: scheme, Cache: operator.CacheOptions()}
 	// Disable metrics and health probe servers to avoid port conflicts in embedded mode.
 	opts.Metrics.BindAddress = "0"
 	opts.HealthProbeBindAddress = "0"
+	if oc.LeaderElection {
+		opts.LeaderElection = true
+		opts.LeaderElectionID = oc.LeaseName
+		opts.LeaderElectionNamespace = oc.LeaseNamespace
+		opts.LeaderElectionReleaseOnCancel = true
+	}
 	mgr, err := ctrl.NewManager(restCfg, opts)
 	if err != nil {
 		return fmt.Errorf("manager create: %w", err)
 	}
-	r := &operator.WorkspaceReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}
+	r := &operator.WorkspaceReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme(), APIReader: mgr.GetAPIReader()}
 	if err := r.SetupWithManager(mgr); err != nil {
 		return fmt.Errorf("setup reconciler: %w", err)
 	}
+	operatorState.running.Store(true)
+	go func() {
+		select {
+		case <-mgr.Elected():
+			operatorState.leader.Store(true)
+			if oc.LeaderElection {
+				log.Printf("operator: acquired lease %s/%s", oc.LeaseNamespace, oc.LeaseName)
+			}
+		case <-ctx.Done():
+		}
+	}()
 	go func() {
 		if err := mgr.Start(ctx); err != nil {
 			log.Printf("operator manager stopped: %v", err)
 		}
+		operatorState.running.Store(false)
+		operatorState.leader.Store(false)
 	}()
-	log.Printf("workspace operator started in-process")
+	log.Printf("workspace operator started in-process (leader election: %v)", oc.LeaderElection)
 	return nil
 }
 
 // WebSocket removed; SSE-only
 
-// ensureSelfSigned creates a minimal self-signed certificate if not present.
+// ensureSelfSigned creates a self-signed certificate if none is present, and regenerates a
+// previously generated one when its SANs, key type or validity no longer match the
+// GUILDNET_TLS_* settings. Certificates placed in dir by hand are never replaced.
 func ensureSelfSigned(dir, certPath, keyPath string) error {
-	// Production mode: do not generate self-signed certificates. Require valid cert and key to exist.
+	opts, err := certOptionsFromEnv()
+	if err != nil {
+		return err
+	}
+	generated, ok := selfSignedUpToDate(certPath, opts)
+	_, keyErr := os.Stat(keyPath)
+	if ok && keyErr == nil {
+		return nil
+	}
 	if _, err := os.Stat(certPath); err == nil {
-		if _, err2 := os.Stat(keyPath); err2 == nil {
-			return nil
+		if !generated {
+			return fmt.Errorf("tls cert %s is unreadable or its key %s is missing; fix or remove it to regenerate", certPath, keyPath)
 		}
+		log.Printf("self-signed cert out of date (SANs/key type/expiry changed); regenerating %s", certPath)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
 	}
-	return fmt.Errorf("tls cert or key not found: %s and %s; place valid certs in ./certs/ or %s", certPath, keyPath, dir)
+	if err := generateSelfSigned(certPath, keyPath, opts); err != nil {
+		return err
+	}
+	log.Printf("generated self-signed %s cert %s (SANs: %s)", opts.KeyType, certPath, sanKey(opts.DNSNames, opts.IPs))
+	return nil
 }
 
 // dns1123Name converts an arbitrary string into a DNS-1123 compliant name:
@@ -214,7 +259,7 @@ func main() {
 		if err != nil || kcli == nil || kcli.Rest == nil {
 			log.Fatalf("k8s config: %v", err)
 		}
-		if err := startOperator(ctx, kcli.Rest); err != nil {
+		if err := startOperator(ctx, kcli.Rest, operatorConfig{}); err != nil {
 			log.Fatalf("operator start: %v", err)
 		}
 		<-ctx.Done()
@@ -250,18 +295,22 @@ func main() {
 	}
 	defer ldb.Close()
 	// Ensure orchestration buckets
-	_ = ldb.EnsureBuckets("orgs", "headscales", "namespaces", "keys", "clusters", "nodes", "credentials", "jobs", "joblogs", "audit")
+	_ = ldb.EnsureBuckets("orgs", "headscales", "namespaces", "keys", "clusters", "nodes", "credentials", "jobs", "joblogs", "audit", "templates", "image_presets")
 	// Ensure settings buckets
 	_ = settings.EnsureBucket(ldb)
 	masterKey := strings.TrimSpace(os.Getenv("GUILDNET_MASTER_KEY"))
 	if masterKey == "" {
-		log.Printf("warning: GUILDNET_MASTER_KEY not set; secrets encryption disabled for dev")
+		log.Printf("WARNING: GUILDNET_MASTER_KEY not set; secrets encryption disabled, credentials will be stored unencrypted (dev only)")
 	}
 	sec, _ := secrets.New(masterKey)
 	_ = sec
 
 	// Read runtime settings
 	setMgr := settings.Manager{DB: ldb}
+	// Upgrade stored settings to the current layout before anything reads or rewrites them
+	if err := setMgr.Migrate(); err != nil {
+		log.Printf("settings migration: %v", err)
+	}
 	var tsSet settings.Tailscale
 	_ = setMgr.GetTailscale(&tsSet)
 	if strings.TrimSpace(tsSet.LoginServer) == "" {
@@ -274,21 +323,23 @@ func main() {
 	_ = setMgr.GetGlobal(&gset)
 	changed := false
 	if strings.TrimSpace(gset.DefaultNamespace) == "" {
-		gset.DefaultNamespace = "default"
+		gset.DefaultNamespace = settings.FallbackNamespace
 		changed = true
 	}
 	if strings.TrimSpace(gset.ListenLocal) == "" {
 		gset.ListenLocal = cfg.ListenLocal
 		changed = true
 	}
-	if strings.TrimSpace(gset.FrontendOrigin) == "" {
+	if len(gset.Origins()) == 0 {
 		// Seed a sensible default; single origin on 8090
-		gset.FrontendOrigin = "https://127.0.0.1:8090"
+		gset.FrontendOrigins = []string{"https://127.0.0.1:8090"}
 		changed = true
 	}
 	// keep existing EmbedOperator value as-is
 	if changed {
-		_ = setMgr.PutGlobal(gset)
+		if err := setMgr.PutGlobal(gset); err != nil {
+			log.Printf("settings: not seeding global defaults: %v", err)
+		}
 	}
 
 	// Single-instance lock to avoid multiple hostapp processes interfering
@@ -330,7 +381,7 @@ func main() {
 	}()
 
 	// Start tsnet from settings
-	s, err := ts.StartServer(ctx, ts.Options{StateDir: config.StateDir(), Hostname: tsSet.Hostname, LoginURL: tsSet.LoginServer, AuthKey: tsSet.PreauthKey})
+	s, err := ts.StartServer(ctx, ts.Options{StateDir: config.StateDir(), Hostname: tsSet.Hostname, LoginURL: tsSet.LoginServer, AuthKey: tsSet.PreauthKey, Ephemeral: tsSet.Ephemeral})
 	if err != nil {
 		log.Fatalf("tsnet start: %v", err)
 	}
@@ -379,6 +430,10 @@ func main() {
 	mux.Handle("/sse/cluster/", apiMux)
 	// Ensure core health endpoint is reachable
 	mux.Handle("/api/health", apiMux)
+	mux.Handle("/api/images/presets", apiMux)
+	// Registry diagnostics (more specific than the local /api/admin/ handler below)
+	mux.Handle("/api/admin/registry", apiMux)
+	mux.Handle("/api/admin/registry/", apiMux)
 
 	// Best-effort: restore persisted published mappings in background
 	go func() {
@@ -387,13 +442,17 @@ func main() {
 		}
 	}()
 
-	// health check
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+	// Liveness: the process is up and serving. /healthz is kept as an alias of /livez.
+	livez := func(w http.ResponseWriter, r *http.Request) {
+		if operatorState.running.Load() {
+			w.Header().Set("X-Operator-Leader", strconv.FormatBool(operatorState.leader.Load()))
+		}
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
-	})
-
+	}
+	mux.HandleFunc("/livez", livez)
+	mux.HandleFunc("/healthz", livez)
 	// internal shutdown endpoint for graceful stop from local tooling
 	mux.HandleFunc("/internal/shutdown", func(w http.ResponseWriter, r *http.Request) {
 		// only accept local POST requests
@@ -478,6 +537,8 @@ func main() {
 		log.Printf("k8s client unavailable: %v (continuing without Kubernetes features)", err)
 		kcli = nil
 	}
+	// Namespace for workspaces on the local cluster: global default, then "default"
+	defaultNS := setMgr.EffectiveNamespace("")
 	var dyn dynamic.Interface
 	// Optional: local port-forward manager for pods (fallback when API server service/pod proxy is unreliable)
 	var pfMgr *k8s.PortForwardManager
@@ -488,7 +549,7 @@ func main() {
 		} else {
 			log.Printf("dynamic client init failed: %v", derr)
 		}
-		pfMgr = k8s.NewPortForwardManager(kcli.Rest, "default")
+		pfMgr = k8s.NewPortForwardManager(kcli.Rest, defaultNS)
 	}
 	// Debug: log resolved API host for visibility
 	if kcli != nil && kcli.Rest != nil {
@@ -502,29 +563,45 @@ func main() {
 
 	// Start operator (controller-runtime) in-process only when explicitly enabled.
 	// Production default: embedded operator is disabled. Enable via GN_EMBED_OPERATOR=1 environment variable.
-	if kcli != nil && kcli.Rest != nil {
-		if strings.TrimSpace(os.Getenv("GN_EMBED_OPERATOR")) == "1" {
-			go func() {
-				if err := startOperator(ctx, kcli.Rest); err != nil {
-					log.Printf("operator start failed: %v", err)
-				}
-			}()
-		}
+	embedOperator := kcli != nil && kcli.Rest != nil && strings.TrimSpace(os.Getenv("GN_EMBED_OPERATOR")) == "1"
+	if embedOperator {
+		go func() {
+			if err := startOperator(ctx, kcli.Rest, operatorConfigFromGlobal(gset)); err != nil {
+				log.Printf("operator start failed: %v", err)
+			}
+		}()
+	}
+
+	// Readiness: 503 until tsnet is connected, the local DB answers and, when embedded,
+	// the operator runs.
+	readyChecks := []httpx.ReadinessCheck{
+		{Name: "tsnet", Check: func(ctx context.Context) error {
+			st, err := ts.Status(ctx, tsServer, false)
+			if err != nil {
+				return err
+			}
+			if st.BackendState != "Running" {
+				return fmt.Errorf("backend state %s", st.BackendState)
+			}
+			return nil
+		}},
+		{Name: "localdb", Check: ldb.Ping},
 	}
+	if embedOperator {
+		readyChecks = append(readyChecks, httpx.ReadinessCheck{Name: "operator", Check: func(context.Context) error {
+			if !operatorState.running.Load() {
+				return errors.New("operator not running")
+			}
+			return nil
+		}})
+	}
+	mux.HandleFunc("/readyz", httpx.ReadyHandler(readyChecks...))
 
 	// Permission cache (prototype) – only used in CRD mode for admin/destructive actions.
 	var permCache *permission.Cache
 	if dyn != nil {
-		permCache = permission.NewCache(dyn, "default", 10*time.Second)
+		permCache = permission.NewCache(dyn, defaultNS, 10*time.Second)
 	}
-	defaultNS := func() string {
-		var g settings.Global
-		_ = setMgr.GetGlobal(&g)
-		if strings.TrimSpace(g.DefaultNamespace) != "" {
-			return strings.TrimSpace(g.DefaultNamespace)
-		}
-		return "default"
-	}()
 
 	// Default workspace ingress knobs: no implicit ingress class via env; use cluster settings per cluster when creating resources.
 
@@ -566,6 +643,23 @@ func main() {
 		}
 	}()
 
+	// tsnet status: self, peers and exit-node/subnet-route info for diagnosing dial failures.
+	// Online peers only unless ?all=1.
+	mux.HandleFunc("/api/tsnet/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		ctxSt, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		st, err := ts.Status(ctxSt, tsServer, r.URL.Query().Get("all") == "1")
+		if err != nil {
+			httpx.JSONError(w, http.StatusServiceUnavailable, "tsnet status unavailable", "tsnet_unavailable", err.Error())
+			return
+		}
+		httpx.JSON(w, http.StatusOK, st)
+	})
+
 	// Smoke: resolve and attempt a tsnet dial to given id:port
 	mux.HandleFunc("/api/v1/smoke-dial", func(w http.ResponseWriter, r *http.Request) {
 		id := strings.TrimSpace(r.URL.Query().Get("id"))
@@ -648,19 +742,13 @@ func main() {
 		}
 	}
 
-	// Preset deployable images (server-sourced; avoid hardcoding in UI)
-	presetImages := []model.DeployImage{
-		{Label: "VS Code (code-server)", Image: "codercom/code-server:4.90.3", Description: "Browser-based VS Code via code-server behind Caddy"},
-		// Add more curated images here in the future.
-	}
-
-	// List deployable images
+	// List deployable images: admin-curated presets (/api/images/presets), else the built-ins
 	mux.HandleFunc("/api/images", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		httpx.JSON(w, http.StatusOK, presetImages)
+		httpx.JSON(w, http.StatusOK, api.ImagePresets(ldb))
 	})
 
 	// Image defaults: return suggested env/ports for a given image reference
@@ -675,6 +763,19 @@ func main() {
 			httpx.JSON(w, http.StatusOK, resp)
 			return
 		}
+		// A preset for exactly this image supplies its own defaults
+		for _, p := range api.ImagePresets(ldb) {
+			if p.Image == img && (len(p.Ports) > 0 || len(p.Env) > 0) {
+				if len(p.Ports) > 0 {
+					resp["ports"] = p.Ports
+				}
+				if len(p.Env) > 0 {
+					resp["env"] = p.Env
+				}
+				httpx.JSON(w, http.StatusOK, resp)
+				return
+			}
+		}
 		// Very simple matcher; can be extended to read from config or OCI metadata.
 		if strings.Contains(img, "guildnet/agent") {
 			resp["ports"] = []model.Port{{Name: "http", Port: 8080}, {Name: "https", Port: 8443}}
@@ -687,6 +788,23 @@ func main() {
 	})
 
 	// servers list (Workspace CRDs only; legacy Deployment path removed)
+	// serverURLBase reads settings.Global.ServerURLBase for server proxy links.
+	// maxLogPods caps the pods aggregated by the workspace log endpoints (0 = k8s.DefaultMaxLogPods);
+	// read at startup like the proxy settings
+	maxLogPods := gset.MaxLogPods
+	// logLine converts a pod log line for the log endpoints, stamped with its kubelet time
+	logLine := func(l k8s.PodLogLine, level string) model.LogLine {
+		t := model.NowISO()
+		if !l.TS.IsZero() {
+			t = l.TS.UTC().Format(time.RFC3339)
+		}
+		return model.LogLine{T: t, LVL: level, MSG: fmt.Sprintf("[%s] %s", l.Pod, l.Text)}
+	}
+	serverURLBase := func() string {
+		var g settings.Global
+		_ = setMgr.GetGlobal(&g)
+		return g.ServerURLBase
+	}
 	mux.HandleFunc("/api/servers", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -703,6 +821,12 @@ func main() {
 			httpx.JSON(w, http.StatusOK, []any{})
 			return
 		}
+		// Pods give failed servers their reason; a lookup error only loses the reason
+		var byWS map[string][]corev1.Pod
+		if kcli != nil && kcli.K != nil {
+			pods, _ := kcli.Objects().Pods(r.Context(), defaultNS, k8s.WorkspaceLabel)
+			byWS = k8s.PodsByWorkspace(pods)
+		}
 		var out []*model.Server
 		for _, item := range lst.Items {
 			obj := item.Object
@@ -744,13 +868,8 @@ func main() {
 					}
 				}
 			}
-			statusStr := "pending"
-			if phase == "Running" && readyReplicas > 0 {
-				statusStr = "running"
-			} else if phase == "Failed" {
-				statusStr = "failed"
-			}
-			out = append(out, &model.Server{ID: name, Name: name, Image: image, Status: statusStr, Ports: ports, URL: ""})
+			statusStr, reason := k8s.ServerStatus(phase, int(readyReplicas), byWS[name])
+			out = append(out, &model.Server{ID: name, Name: name, Image: image, Status: statusStr, StatusReason: reason, Ports: ports, URL: proxy.ServerURL(serverURLBase(), r, "", name)})
 		}
 		httpx.JSON(w, http.StatusOK, out)
 	})
@@ -795,12 +914,11 @@ func main() {
 			if rr, ok := status["readyReplicas"].(int64); ok {
 				readyReplicas = int32(rr)
 			}
-			statusStr := "pending"
-			if phase == "Running" && readyReplicas > 0 {
-				statusStr = "running"
-			} else if phase == "Failed" {
-				statusStr = "failed"
+			var pods []corev1.Pod
+			if kcli != nil && kcli.K != nil {
+				pods, _ = kcli.Objects().Pods(r.Context(), defaultNS, k8s.WorkspaceLabel+"="+id)
 			}
+			statusStr, reason := k8s.ServerStatus(phase, int(readyReplicas), pods)
 			ports := []model.Port{}
 			if rawPorts, ok := spec["ports"].([]any); ok {
 				for _, rp := range rawPorts {
@@ -825,7 +943,7 @@ func main() {
 					}
 				}
 			}
-			httpx.JSON(w, http.StatusOK, &model.Server{ID: id, Name: id, Image: image, Status: statusStr, Ports: ports, URL: ""})
+			httpx.JSON(w, http.StatusOK, &model.Server{ID: id, Name: id, Image: image, Status: statusStr, StatusReason: reason, Ports: ports, URL: proxy.ServerURL(serverURLBase(), r, "", id)})
 			return
 		}
 		if len(parts) == 2 && parts[1] == "logs" && r.Method == http.MethodGet {
@@ -844,55 +962,34 @@ func main() {
 			if v := q.Get("limit"); v != "" {
 				fmt.Sscanf(v, "%d", &limit)
 			}
-			// Sort pods: ready first
-			readyPods := []corev1.Pod{}
-			unreadyPods := []corev1.Pod{}
-			for _, p := range pods.Items {
-				isReady := false
-				for _, c := range p.Status.Conditions {
-					if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
-						isReady = true
-						break
-					}
-				}
-				if isReady {
-					readyPods = append(readyPods, p)
-				} else {
-					unreadyPods = append(unreadyPods, p)
-				}
+			if limit < 1 {
+				limit = 200
 			}
-			ordered := append(readyPods, unreadyPods...)
-			// Aggregate logs from up to N pods (cap at 5 to bound cost)
-			maxPods := 5
-			if len(ordered) < maxPods {
-				maxPods = len(ordered)
+			// ?container= picks a sidecar; pods without it are skipped
+			want := q.Get("container")
+			if want != "" && !slices.Contains(k8s.ContainerNames(pods.Items), want) {
+				httpx.JSONError(w, http.StatusBadRequest, "unknown container", "unknown_container", want)
+				return
 			}
-			tail := int64(limit / maxPods)
-			if tail < 10 {
-				tail = int64(limit)
-			} // if very small limit, just pull full per pod
-			out := []model.LogLine{}
-			for i := 0; i < maxPods; i++ {
-				p := ordered[i]
-				container := ""
-				if len(p.Spec.Containers) > 0 {
-					container = p.Spec.Containers[0].Name
+			// Pods and per-pod tail are chosen deterministically, capped by settings.Global.MaxLogPods
+			ordered, tail := k8s.PlanPodLogs(pods.Items, limit, maxLogPods)
+			var lines []k8s.PodLogLine
+			for _, p := range ordered {
+				container, err := k8s.LogContainer(&p, want)
+				if err != nil {
+					continue
 				}
-				req := kcli.K.CoreV1().Pods(defaultNS).GetLogs(p.Name, &corev1.PodLogOptions{Container: container, TailLines: &tail})
+				req := kcli.K.CoreV1().Pods(defaultNS).GetLogs(p.Name, &corev1.PodLogOptions{Container: container, TailLines: &tail, Timestamps: true})
 				data, err := req.Do(r.Context()).Raw()
 				if err != nil {
 					continue
 				}
-				linesRaw := strings.Split(strings.TrimSpace(string(data)), "\n")
-				for _, ln := range linesRaw {
-					if ln != "" {
-						out = append(out, model.LogLine{T: model.NowISO(), LVL: level, MSG: fmt.Sprintf("[%s] %s", p.Name, ln)})
-					}
-				}
+				lines = append(lines, k8s.ParsePodLogs(p.Name, data)...)
 			}
-			// Truncate to requested limit if aggregated exceeded it
-			if len(out) > limit {
-				out = out[len(out)-limit:]
+			// Keep the newest lines across all pods, not the first pods' lines
+			out := []model.LogLine{}
+			for _, l := range k8s.NewestPodLogs(lines, limit) {
+				out = append(out, logLine(l, level))
 			}
 			httpx.JSON(w, http.StatusOK, out)
 			return
@@ -900,21 +997,35 @@ func main() {
 		w.WriteHeader(http.StatusNotFound)
 	})
 
-	// jobs (Workspace CRD only; legacy Deployment path removed)
-	mux.HandleFunc("/api/workspace-jobs", func(w http.ResponseWriter, r *http.Request) {
+	// jobs (Workspace CRD only; legacy Deployment path removed); retries with the same Idempotency-Key replay the first result
+	mux.Handle("/api/workspace-jobs", httpx.Idempotent(ldb, 0, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
 		b, err := io.ReadAll(r.Body)
+		if httpx.BodyTooLarge(w, err) {
+			return
+		}
 		if err != nil {
 			httpx.JSONError(w, http.StatusBadRequest, "unable to read body", "bad_body")
 			return
 		}
 		defer r.Body.Close()
 		var spec model.JobSpec
-		if err := json.Unmarshal(b, &spec); err != nil || spec.Image == "" {
-			httpx.JSONError(w, http.StatusBadRequest, "invalid job spec", "invalid_spec", err)
+		if err := json.Unmarshal(b, &spec); err != nil {
+			httpx.JSONError(w, http.StatusBadRequest, "invalid job spec", "invalid_spec", err.Error())
+			return
+		}
+		// same rules as POST /api/validate/job
+		var vg settings.Global
+		_ = setMgr.GetGlobal(&vg)
+		if errs := spec.ValidateWith(vg.ValidationOptions()); len(errs) > 0 {
+			fields := make(map[string]string, len(errs))
+			for _, fe := range errs {
+				fields[fe.Field] = fe.Message
+			}
+			httpx.JSONFieldErrors(w, http.StatusBadRequest, "invalid job spec", "invalid_spec", fields)
 			return
 		}
 
@@ -922,42 +1033,13 @@ func main() {
 			httpx.JSONError(w, http.StatusInternalServerError, "dynamic client unavailable", "dyn_unavailable")
 			return
 		}
-		gvr := schema.GroupVersionResource{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}
 		wsName := spec.Name
 		if wsName == "" {
 			wsName = dns1123Name(deriveAgentHost(spec))
 		}
-		baseName := wsName
-		// ensure non-empty base
-		if baseName == "" {
-			baseName = "workspace"
-		}
-		// attempt up to 10 unique name generations if collisions occur
-		for attempt := 0; attempt < 10; attempt++ {
-			candidate := wsName
-			if attempt > 0 { // append short random suffix
-				// 5 hex chars from crypto/rand
-				buf := make([]byte, 3)
-				if _, rerr := rand.Read(buf); rerr == nil {
-					sfx := hex.EncodeToString(buf)[:5]
-					candidate = fmt.Sprintf("%s-%s", baseName, sfx)
-				}
-			}
-			// name must remain <= 63 chars for DNS-1123
-			if len(candidate) > 63 {
-				candidate = candidate[:63]
-			}
-			wsName = candidate
-			// probe existence
-			_, gerr := dyn.Resource(gvr).Namespace(defaultNS).Get(r.Context(), wsName, metav1.GetOptions{})
-			if gerr != nil {
-				if apierrors.IsNotFound(gerr) {
-					break // available
-				}
-				// on unexpected error just break and let create path surface it
-				break
-			}
-			// exists; continue to next attempt
+		// name must remain <= 63 chars for DNS-1123
+		if len(wsName) > 63 {
+			wsName = strings.TrimRight(wsName[:63], "-")
 		}
 		specMap := map[string]any{"image": spec.Image}
 		if len(spec.Env) > 0 {
@@ -990,36 +1072,23 @@ func main() {
 			"metadata":   map[string]any{"name": wsName},
 			"spec":       specMap,
 		}
-		if _, err := dyn.Resource(gvr).Namespace(defaultNS).Create(r.Context(), &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{}); err != nil {
-			if apierrors.IsAlreadyExists(err) {
-				// extremely unlikely due to prior check; add one more randomized suffix and retry once
-				buf := make([]byte, 3)
-				if _, rerr := rand.Read(buf); rerr == nil {
-					alt := fmt.Sprintf("%s-%s", baseName, hex.EncodeToString(buf)[:5])
-					obj["metadata"].(map[string]any)["name"] = alt
-					if _, cerr := dyn.Resource(gvr).Namespace(defaultNS).Create(r.Context(), &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{}); cerr == nil {
-						httpx.JSON(w, http.StatusAccepted, model.JobAccepted{ID: alt, Status: "pending"})
-						return
-					}
-				}
-			}
-			// If schema warning escalates to error referencing env[0].name or value, retry without env.
-			if strings.Contains(err.Error(), "env[0].name") || strings.Contains(err.Error(), "env[0].value") {
-				if specSection, ok := obj["spec"].(map[string]any); ok {
-					if _, had := specSection["env"]; had {
-						delete(specSection, "env")
-						if _, rerr := dyn.Resource(gvr).Namespace(defaultNS).Create(r.Context(), &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{}); rerr == nil {
-							httpx.JSON(w, http.StatusAccepted, model.JobAccepted{ID: wsName, Status: "pending"})
-							return
-						}
-					}
+		// A taken name gets a random suffix; the response carries the name actually created
+		created, err := api.CreateWorkspaceUnique(r.Context(), dyn, defaultNS, &unstructured.Unstructured{Object: obj})
+		// If schema warning escalates to error referencing env[0].name or value, retry without env.
+		if err != nil && (strings.Contains(err.Error(), "env[0].name") || strings.Contains(err.Error(), "env[0].value")) {
+			if specSection, ok := obj["spec"].(map[string]any); ok {
+				if _, had := specSection["env"]; had {
+					delete(specSection, "env")
+					created, err = api.CreateWorkspaceUnique(r.Context(), dyn, defaultNS, &unstructured.Unstructured{Object: obj})
 				}
 			}
+		}
+		if err != nil {
 			httpx.JSONError(w, http.StatusInternalServerError, "workspace create failed", "create_failed", err.Error())
 			return
 		}
-		httpx.JSON(w, http.StatusAccepted, model.JobAccepted{ID: wsName, Status: "pending"})
-	})
+		httpx.JSON(w, http.StatusAccepted, model.JobAccepted{ID: created, Status: "pending"})
+	})))
 
 	// admin: stop all servers (delete managed workloads)
 	adminStopAll := func(w http.ResponseWriter, r *http.Request) {
@@ -1118,7 +1187,13 @@ func main() {
 		w.Header().Set("Connection", "keep-alive")
 		w.Header().Set("X-Accel-Buffering", "no")
 
-		log.Printf("sse/logs open: target=%s level=%s tail=%d from %s", id, level, tail, r.RemoteAddr)
+		reqID := r.Header.Get("X-Request-Id")
+		log.Printf("sse/logs open: req_id=%s target=%s level=%s tail=%d from %s", reqID, id, level, tail, r.RemoteAddr)
+		// The meta frame lets clients quote the request id when a stream stalls.
+		if err := httpx.SSEEvent(w, "meta", model.LogStreamMeta{RequestID: reqID, Target: id, Tail: tail}); err != nil {
+			return
+		}
+		flusher.Flush()
 		enc := json.NewEncoder(w)
 
 		// send tail first (best effort) by reading pods matching the Workspace label
@@ -1128,61 +1203,31 @@ func main() {
 			if err != nil || len(pods.Items) == 0 {
 				return
 			}
-			// Sort pods: ready first
-			readyPods := []corev1.Pod{}
-			unreadyPods := []corev1.Pod{}
-			for _, p := range pods.Items {
-				isReady := false
-				for _, c := range p.Status.Conditions {
-					if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
-						isReady = true
-						break
-					}
-				}
-				if isReady {
-					readyPods = append(readyPods, p)
-				} else {
-					unreadyPods = append(unreadyPods, p)
-				}
-			}
-			ordered := append(readyPods, unreadyPods...)
-			maxPods := 5
-			if len(ordered) < maxPods {
-				maxPods = len(ordered)
-			}
-			tailPer := int64(tail)
-			if maxPods > 1 {
-				tp := int64(tail / maxPods)
-				if tp >= 10 {
-					tailPer = tp
-				}
-			}
-			for i := 0; i < maxPods; i++ {
-				p := ordered[i]
-				container := ""
-				if len(p.Spec.Containers) > 0 {
-					container = p.Spec.Containers[0].Name
+			ordered, tailPer := k8s.PlanPodLogs(pods.Items, tail, maxLogPods)
+			var lines []k8s.PodLogLine
+			for _, p := range ordered {
+				container, err := k8s.LogContainer(&p, q.Get("container"))
+				if err != nil {
+					continue
 				}
-				req := kcli.K.CoreV1().Pods(defaultNS).GetLogs(p.Name, &corev1.PodLogOptions{Container: container, TailLines: &tailPer})
+				req := kcli.K.CoreV1().Pods(defaultNS).GetLogs(p.Name, &corev1.PodLogOptions{Container: container, TailLines: &tailPer, Timestamps: true})
 				data, err := req.Do(r.Context()).Raw()
 				if err != nil {
 					continue
 				}
-				for _, ln := range strings.Split(strings.TrimSpace(string(data)), "\n") {
-					if ln == "" {
-						continue
-					}
-					if _, err := w.Write([]byte("data: ")); err != nil {
-						return
-					}
-					if err := enc.Encode(model.LogLine{T: model.NowISO(), LVL: level, MSG: fmt.Sprintf("[%s] %s", p.Name, ln)}); err != nil {
-						return
-					}
-					if _, err := w.Write([]byte("\n")); err != nil {
-						return
-					}
-					flusher.Flush()
+				lines = append(lines, k8s.ParsePodLogs(p.Name, data)...)
+			}
+			for _, l := range k8s.NewestPodLogs(lines, tail) {
+				if _, err := w.Write([]byte("data: ")); err != nil {
+					return
+				}
+				if err := enc.Encode(logLine(l, level)); err != nil {
+					return
+				}
+				if _, err := w.Write([]byte("\n")); err != nil {
+					return
 				}
+				flusher.Flush()
 			}
 		}()
 
@@ -1194,11 +1239,11 @@ func main() {
 		for {
 			select {
 			case <-ctx.Done():
-				log.Printf("sse/logs close: target=%s level=%s from=%s reason=context-done", id, level, r.RemoteAddr)
+				log.Printf("sse/logs close: req_id=%s target=%s level=%s from=%s reason=context-done", reqID, id, level, r.RemoteAddr)
 				return
 			case <-heartbeat.C:
 				if _, err := w.Write([]byte(": ping\n\n")); err != nil {
-					log.Printf("sse/logs heartbeat write error: %v", err)
+					log.Printf("sse/logs close: req_id=%s target=%s from=%s reason=write-error err=%v", reqID, id, r.RemoteAddr, err)
 					return
 				}
 				flusher.Flush()
@@ -1206,22 +1251,25 @@ func main() {
 		}
 	})
 
-	// proxy handler (CRD-aware resolution)
+	// proxy handler (CRD-aware resolution); workspace annotations and proxy-auth credentials
+	// are cached briefly
+	var resolveAuth func(ctx context.Context, serverID string) (string, error)
+	if kcli != nil && kcli.K != nil {
+		resolveAuth = api.WorkspaceAuthResolver(dyn, kcli.K, defaultNS)
+	}
+	// The local cluster has no cluster settings, so /proxy takes its mode from the global ones
+	proxyMode := gset.EffectiveProxyMode()
 	proxyHandler := proxy.NewReverseProxy(proxy.Options{
-		MaxBody: 10 * 1024 * 1024,
-		Timeout: 30 * time.Second,
+		Timeout:             30 * time.Second,
+		WSPingInterval:      time.Duration(gset.ProxyWSPingSeconds) * time.Second,
+		ServerTiming:        gset.ProxyServerTiming,
+		StripHeaders:        gset.ProxyStripHeaders,
+		MaxStreamsPerServer: gset.ProxyMaxStreamsPerServer,
 		Dial: func(ctx context.Context, network, address string) (any, error) {
 			// For loopback targets in local dev, bypass tsnet and dial OS loopback directly.
-			host, _, err := net.SplitHostPort(address)
-			if err == nil {
-				if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
-					var d net.Dialer
-					return d.DialContext(ctx, network, address)
-				}
-				if strings.EqualFold(host, "localhost") {
-					var d net.Dialer
-					return d.DialContext(ctx, network, address)
-				}
+			if proxy.IsLoopbackHost(address) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
 			}
 			return ts.DialContext(ctx, tsServer, network, address)
 		},
@@ -1251,6 +1299,22 @@ func main() {
 			}
 			return sch, fmt.Sprintf("%s:%d", host, port), subPath, nil
 		},
+		ResolvePathRules: func(ctx context.Context, serverID string) (proxy.PathRules, error) {
+			if dyn == nil {
+				return proxy.PathRules{}, nil
+			}
+			gvr := schema.GroupVersionResource{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}
+			ws, err := dyn.Resource(gvr).Namespace(defaultNS).Get(ctx, serverID, metav1.GetOptions{})
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return proxy.PathRules{}, nil
+				}
+				return proxy.PathRules{}, err
+			}
+			return proxy.PathRulesFromAnnotations(ws.GetAnnotations()), nil
+		},
+		ResolveStripPrefix: api.WorkspaceStripPrefixResolver(dyn, defaultNS),
+		ResolveAuth:        resolveAuth,
 		APIProxy: func() (http.RoundTripper, func(req *http.Request, scheme, hostport, subPath string), bool) {
 			// API proxy availability is determined by k8s client config already built; no HOSTAPP_* env checks here.
 			cfg := kcli.Config()
@@ -1262,11 +1326,7 @@ func main() {
 				return nil, nil, false
 			}
 			set := func(req *http.Request, scheme, hostport, subPath string) {
-				// If targeting local PF (127.0.0.1), do not rewrite to API proxy
-				hn := req.URL.Hostname()
-				if hn == "127.0.0.1" || strings.EqualFold(hn, "localhost") {
-					return
-				}
+				// Loopback targets (local PF) never reach here: the proxy dials them directly
 				baseURL, _ := url.Parse(cfg.Host)
 				if baseURL == nil {
 					baseURL = &url.URL{Scheme: "https"}
@@ -1301,22 +1361,21 @@ func main() {
 						}
 					}
 				}
-				// Prefer pod proxy when header provided
-				preferPod := strings.TrimSpace(req.Header.Get("X-Guild-Prefer-Pod")) != ""
-				usePF := strings.TrimSpace(req.Header.Get("X-Guild-Use-PortForward")) != ""
+				// The global proxy mode picks pod proxy or port-forward; auto follows the headers
+				preferPod, usePF := proxyRoute(proxyMode, req.Header)
 				if preferPod && sid != "" {
 					// Discover pod behind service
 					ns := defaultNS
 					podName := ""
-					if svc, err := kcli.K.CoreV1().Services(ns).Get(context.Background(), sid, metav1.GetOptions{}); err == nil && svc != nil && len(svc.Spec.Selector) > 0 {
+					if svc, err := kcli.Objects().Service(context.Background(), ns, sid); err == nil && svc != nil && len(svc.Spec.Selector) > 0 {
 						var selParts []string
 						for k, v := range svc.Spec.Selector {
 							selParts = append(selParts, fmt.Sprintf("%s=%s", k, v))
 						}
 						selector := strings.Join(selParts, ",")
-						if pods, err := kcli.K.CoreV1().Pods(ns).List(context.Background(), metav1.ListOptions{LabelSelector: selector}); err == nil && len(pods.Items) > 0 {
+						if pods, err := kcli.Objects().Pods(context.Background(), ns, selector); err == nil && len(pods) > 0 {
 							pick := -1
-							for i, pod := range pods.Items {
+							for i, pod := range pods {
 								if pod.Status.Phase == corev1.PodRunning {
 									ready := false
 									for _, c := range pod.Status.Conditions {
@@ -1332,7 +1391,7 @@ func main() {
 								}
 							}
 							if pick == -1 {
-								for i, pod := range pods.Items {
+								for i, pod := range pods {
 									if pod.Status.Phase == corev1.PodRunning {
 										pick = i
 										break
@@ -1342,7 +1401,7 @@ func main() {
 							if pick == -1 {
 								pick = 0
 							}
-							podName = pods.Items[pick].Name
+							podName = pods[pick].Name
 						}
 					}
 					if podName != "" {
@@ -1367,22 +1426,27 @@ func main() {
 							}
 							log.Printf("proxy: port-forward failed, falling back to pod proxy ns=%s pod=%s err=%v", defaultNS, podName, err)
 						}
-						if usePF && fallbackHost != "" {
-							log.Printf("proxy: PF unavailable; trying direct ClusterIP %s for sid=%s", fallbackHost, sid)
-							req.URL.Scheme = fallbackScheme
-							req.URL.Host = fallbackHost
-							req.Host = fallbackHost
-							req.URL.Path = singleJoiningSlash("", subPath)
+						if proxyMode == settings.ProxyModePortForward {
+							// Forced port-forward falls back to the service proxy, as on cluster proxies
+							log.Printf("proxy: PF unavailable; using service proxy for sid=%s", sid)
+						} else {
+							if usePF && fallbackHost != "" {
+								log.Printf("proxy: PF unavailable; trying direct ClusterIP %s for sid=%s", fallbackHost, sid)
+								req.URL.Scheme = fallbackScheme
+								req.URL.Host = fallbackHost
+								req.Host = fallbackHost
+								req.URL.Path = singleJoiningSlash("", subPath)
+								return
+							}
+							proto := "http"
+							if strings.EqualFold(scheme, "https") {
+								proto = "https"
+							}
+							basePath := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s:%s:%s/proxy", defaultNS, proto, podName, portStr)
+							fullBase := singleJoiningSlash(strings.TrimSuffix(baseURL.Path, "/"), basePath)
+							req.URL.Path = singleJoiningSlash("", fullBase) + subPath
 							return
 						}
-						proto := "http"
-						if strings.EqualFold(scheme, "https") {
-							proto = "https"
-						}
-						basePath := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s:%s:%s/proxy", defaultNS, proto, podName, portStr)
-						fullBase := singleJoiningSlash(strings.TrimSuffix(baseURL.Path, "/"), basePath)
-						req.URL.Path = singleJoiningSlash("", fullBase) + subPath
-						return
 					}
 				}
 				// Service proxy
@@ -1428,11 +1492,11 @@ func main() {
 	}
 
 	// Wrap with middleware (logging, request id, CORS)
-	corsOrigin := func() string {
+	corsOrigins := func() []string {
 		var g settings.Global
 		_ = setMgr.GetGlobal(&g)
-		if strings.TrimSpace(g.FrontendOrigin) != "" {
-			return strings.TrimSpace(g.FrontendOrigin)
+		if v := g.Origins(); len(v) > 0 {
+			return v
 		}
 		// Default dev origin follows listen address
 		host, port, err := net.SplitHostPort(listenAddr)
@@ -1441,11 +1505,31 @@ func main() {
 			if host == "" {
 				host = "127.0.0.1"
 			}
-			return "https://" + net.JoinHostPort(host, port)
+			return []string{"https://" + net.JoinHostPort(host, port)}
 		}
-		return "https://127.0.0.1:8090"
+		return []string{"https://127.0.0.1:8090"}
 	}()
-	handler := httpx.RequestID(httpx.Logging(httpx.CORS(corsOrigin)(mux)))
+	corsOpts := httpx.CORSOptions{AllowedOrigins: corsOrigins}
+	{
+		var g settings.Global
+		_ = setMgr.GetGlobal(&g)
+		corsOpts.AllowedMethods = g.CORSAllowedMethods
+		corsOpts.AllowedHeaders = g.CORSAllowedHeaders
+		corsOpts.MaxAge = time.Duration(g.CORSMaxAge) * time.Second
+	}
+	// Request bodies are capped at httpx.DefaultMaxBodyBytes; proxied uploads are left to
+	// the workspace and table imports get more room
+	bodyLimits := httpx.BodyLimitOptions{Limit: func(r *http.Request) int64 {
+		p := r.URL.Path
+		switch {
+		case p == "/proxy" || strings.HasPrefix(p, "/proxy/"), strings.HasPrefix(p, "/api/cluster/") && strings.Contains(p, "/proxy/"):
+			return -1
+		case strings.HasSuffix(p, "/import"):
+			return 64 << 20
+		}
+		return 0
+	}}
+	handler := httpx.RequestID(httpx.Logging(httpx.CORSWithOptions(corsOpts)(httpx.LimitBody(bodyLimits)(mux))))
 
 	// Certs: prefer repo CA-signed ./certs/server.crt|server.key, then ./certs/dev.crt|dev.key; else use ~/.guildnet/state/certs
 	var certFile, keyFile string
@@ -1467,13 +1551,13 @@ func main() {
 	}
 
 	// local server (TLS only)
-	localSrv := &http.Server{
+	localSrv := httpx.HardenServer(&http.Server{
 		Addr:         listenAddr,
 		Handler:      handler,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  60 * time.Second,
-	}
+	})
 	// Force HTTP/1.1 on the local listener to avoid HTTP/2 related TLS/internal errors
 	// which have been observed in development when clients and the server disagree
 	// on ALPN or when intermediates mishandle h2 framing. This keeps local dev
@@ -1491,13 +1575,13 @@ func main() {
 	var lnLocalV6 net.Listener
 	if host, port, err := net.SplitHostPort(bindAddr); err == nil {
 		if host == "127.0.0.1" || strings.EqualFold(host, "localhost") {
-			v6Srv = &http.Server{
+			v6Srv = httpx.HardenServer(&http.Server{
 				Addr:         net.JoinHostPort("::1", port),
 				Handler:      handler,
 				ReadTimeout:  10 * time.Second,
 				WriteTimeout: 10 * time.Second,
 				IdleTimeout:  60 * time.Second,
-			}
+			})
 			if l6, e6 := net.Listen("tcp", v6Srv.Addr); e6 == nil {
 				lnLocalV6 = l6
 			}
@@ -1514,12 +1598,12 @@ func main() {
 			log.Fatalf("tsnet listen: %v", err)
 		}
 		// do not defer ln.Close() here; close explicitly on shutdown to guarantee timely teardown
-		tsSrv = &http.Server{
+		tsSrv = httpx.HardenServer(&http.Server{
 			Handler:      handler,
 			ReadTimeout:  10 * time.Second,
 			WriteTimeout: 10 * time.Second,
 			IdleTimeout:  60 * time.Second,
-		}
+		})
 	}
 
 	errCh := make(chan error, 3)
@@ -1585,18 +1669,26 @@ func restTransport(cfg *rest.Config) (http.RoundTripper, error) {
 	// Force HTTP/1.1 by disabling HTTP/2 via NextProtos and ForceAttemptHTTP2
 	tlsConfig.NextProtos = []string{"http/1.1"}
 	base := &http.Transport{
-		Proxy:              http.ProxyFromEnvironment,
-		TLSClientConfig:    tlsConfig,
-		ForceAttemptHTTP2:  false,
-		DisableKeepAlives:  true,
-		MaxIdleConns:       100,
-		IdleConnTimeout:    90 * time.Second,
-		DisableCompression: false,
+		Proxy:             http.ProxyFromEnvironment,
+		TLSClientConfig:   tlsConfig,
+		ForceAttemptHTTP2: false,
+		DisableKeepAlives: true,
+		MaxIdleConns:      100,
+		IdleConnTimeout:   90 * time.Second,
+		// Proxied responses are passed through to the browser, so never negotiate
+		// compression on its behalf (see internal/proxy stdRT).
+		DisableCompression: true,
 	}
 	// Wrap with client-go auth/impersonation handlers
 	return rest.HTTPWrappersForConfig(cfg, base)
 }
 
+// proxyRoute applies the /proxy mode to a request's X-Guild-Prefer-Pod and
+// X-Guild-Use-PortForward hints, returning whether to use a pod and a port-forward.
+func proxyRoute(mode settings.ProxyMode, h http.Header) (pod, portForward bool) {
+	return mode.Route(strings.TrimSpace(h.Get("X-Guild-Prefer-Pod")) != "", strings.TrimSpace(h.Get("X-Guild-Use-PortForward")) != "")
+}
+
 // join path helper
 func singleJoiningSlash(a, b string) string {
 	aslash := strings.HasSuffix(a, "/")
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/settings"
+)
+
+func TestProxyRouteAppliesGlobalMode(t *testing.T) {
+	hinted := http.Header{}
+	hinted.Set("X-Guild-Prefer-Pod", "1")
+	hinted.Set("X-Guild-Use-PortForward", "1")
+	for _, tc := range []struct {
+		mode       settings.ProxyMode
+		h          http.Header
+		pod, usePF bool
+	}{
+		{settings.ProxyModeAuto, http.Header{}, false, false},
+		{settings.ProxyModeAuto, hinted, true, true},
+		{settings.ProxyModeService, hinted, false, false},
+		{settings.ProxyModePod, http.Header{}, true, false},
+		{settings.ProxyModePod, hinted, true, false},
+		{settings.ProxyModePortForward, http.Header{}, true, true},
+	} {
+		if pod, usePF := proxyRoute(tc.mode, tc.h); pod != tc.pod || usePF != tc.usePF {
+			t.Fatalf("mode=%s headers=%v: pod=%v pf=%v, want %v %v", tc.mode, tc.h, pod, usePF, tc.pod, tc.usePF)
+		}
+	}
+	if (settings.Global{ProxyMode: "bogus"}).EffectiveProxyMode() != settings.ProxyModeAuto {
+		t.Fatal("invalid global mode should act as auto")
+	}
+}
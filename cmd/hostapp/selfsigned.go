@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// selfSignedOrg marks certificates generated by ensureSelfSigned so that operator-provided
+// certificates in the state dir are never overwritten.
+const selfSignedOrg = "GuildNet self-signed"
+
+// certOptions controls self-signed certificate generation.
+//
+//	GUILDNET_TLS_SANS      extra SANs, comma separated; IPs are detected (e.g. "gn.lan,192.168.1.20")
+//	GUILDNET_TLS_VALIDITY  validity as a Go duration or whole days ("720h", "825d"); default 365d
+//	GUILDNET_TLS_KEY_TYPE  "rsa" (RSA-2048, default) or "ecdsa" (P-256)
+type certOptions struct {
+	DNSNames []string
+	IPs      []net.IP
+	Validity time.Duration
+	KeyType  string
+}
+
+func certOptionsFromEnv() (certOptions, error) {
+	o := certOptions{
+		DNSNames: []string{"localhost"},
+		IPs:      []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		Validity: 365 * 24 * time.Hour,
+		KeyType:  "rsa",
+	}
+	for _, s := range strings.Split(os.Getenv("GUILDNET_TLS_SANS"), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if ip := net.ParseIP(s); ip != nil {
+			o.IPs = append(o.IPs, ip)
+		} else {
+			o.DNSNames = append(o.DNSNames, strings.ToLower(s))
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("GUILDNET_TLS_VALIDITY")); v != "" {
+		d, err := parseValidity(v)
+		if err != nil {
+			return o, fmt.Errorf("GUILDNET_TLS_VALIDITY: %w", err)
+		}
+		o.Validity = d
+	}
+	switch kt := strings.ToLower(strings.TrimSpace(os.Getenv("GUILDNET_TLS_KEY_TYPE"))); kt {
+	case "":
+	case "rsa", "ecdsa":
+		o.KeyType = kt
+	default:
+		return o, fmt.Errorf("GUILDNET_TLS_KEY_TYPE: unsupported %q (want rsa or ecdsa)", kt)
+	}
+	return o, nil
+}
+
+func parseValidity(v string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid days %q", v)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive, got %q", v)
+	}
+	return d, nil
+}
+
+// sanKey returns a canonical, order-independent representation of the SAN set.
+func sanKey(dns []string, ips []net.IP) string {
+	keys := make([]string, 0, len(dns)+len(ips))
+	for _, d := range dns {
+		keys = append(keys, "dns:"+strings.ToLower(d))
+	}
+	for _, ip := range ips {
+		keys = append(keys, "ip:"+ip.String())
+	}
+	sort.Strings(keys)
+	out := keys[:0]
+	for i, k := range keys {
+		if i == 0 || k != keys[i-1] {
+			out = append(out, k)
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+// selfSignedUpToDate reports whether certPath holds a certificate we generated that still
+// matches opts (SANs, key type) and is not about to expire.
+func selfSignedUpToDate(certPath string, o certOptions) (generated, ok bool) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return false, false
+	}
+	blk, _ := pem.Decode(data)
+	if blk == nil {
+		return false, false
+	}
+	c, err := x509.ParseCertificate(blk.Bytes)
+	if err != nil {
+		return false, false
+	}
+	if len(c.Subject.Organization) == 0 || c.Subject.Organization[0] != selfSignedOrg {
+		return false, true
+	}
+	if sanKey(c.DNSNames, c.IPAddresses) != sanKey(o.DNSNames, o.IPs) {
+		return true, false
+	}
+	wantAlg := x509.RSA
+	if o.KeyType == "ecdsa" {
+		wantAlg = x509.ECDSA
+	}
+	if c.PublicKeyAlgorithm != wantAlg {
+		return true, false
+	}
+	return true, time.Until(c.NotAfter) > 24*time.Hour
+}
+
+func generateSelfSigned(certPath, keyPath string, o certOptions) error {
+	var (
+		priv crypto.Signer
+		err  error
+	)
+	if o.KeyType == "ecdsa" {
+		priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return fmt.Errorf("generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: o.DNSNames[0], Organization: []string{selfSignedOrg}},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(o.Validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              o.DNSNames,
+		IPAddresses:           o.IPs,
+	}
+	if o.KeyType != "ecdsa" {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	if err != nil {
+		return fmt.Errorf("create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return fmt.Errorf("marshal key: %w", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCertOptionsFromEnv(t *testing.T) {
+	t.Setenv("GUILDNET_TLS_SANS", " GN.lan, 192.168.1.20 ,,")
+	t.Setenv("GUILDNET_TLS_VALIDITY", "30d")
+	t.Setenv("GUILDNET_TLS_KEY_TYPE", "ECDSA")
+	o, err := certOptionsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sanKey(o.DNSNames, o.IPs) != "dns:gn.lan,dns:localhost,ip:127.0.0.1,ip:192.168.1.20,ip:::1" {
+		t.Fatalf("sans = %v %v", o.DNSNames, o.IPs)
+	}
+	if o.Validity != 30*24*time.Hour || o.KeyType != "ecdsa" {
+		t.Fatalf("options = %+v", o)
+	}
+
+	for _, bad := range [][2]string{{"GUILDNET_TLS_VALIDITY", "-1h"}, {"GUILDNET_TLS_VALIDITY", "xd"}, {"GUILDNET_TLS_KEY_TYPE", "ed25519"}} {
+		t.Setenv("GUILDNET_TLS_VALIDITY", "")
+		t.Setenv("GUILDNET_TLS_KEY_TYPE", "")
+		t.Setenv(bad[0], bad[1])
+		if _, err := certOptionsFromEnv(); err == nil {
+			t.Fatalf("%s=%q accepted", bad[0], bad[1])
+		}
+	}
+}
+
+func TestGenerateSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	o := certOptions{DNSNames: []string{"localhost", "gn.lan"}, IPs: []net.IP{net.ParseIP("127.0.0.1")}, Validity: 48 * time.Hour, KeyType: "ecdsa"}
+	if err := generateSelfSigned(certPath, keyPath, o); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("generated pair does not load: %v", err)
+	}
+	c, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.PublicKeyAlgorithm != x509.ECDSA || c.Subject.CommonName != "localhost" || c.VerifyHostname("gn.lan") != nil {
+		t.Fatalf("cert: alg=%v cn=%q dns=%v", c.PublicKeyAlgorithm, c.Subject.CommonName, c.DNSNames)
+	}
+
+	if gen, ok := selfSignedUpToDate(certPath, o); !gen || !ok {
+		t.Fatalf("fresh cert: generated=%v ok=%v", gen, ok)
+	}
+	// SANs compare as a set
+	reordered := o
+	reordered.DNSNames = []string{"GN.lan", "localhost"}
+	if _, ok := selfSignedUpToDate(certPath, reordered); !ok {
+		t.Fatal("reordered SANs treated as a change")
+	}
+	moreSANs := o
+	moreSANs.DNSNames = append([]string{"other.lan"}, o.DNSNames...)
+	if _, ok := selfSignedUpToDate(certPath, moreSANs); ok {
+		t.Fatal("new SAN not detected")
+	}
+	rsaKey := o
+	rsaKey.KeyType = "rsa"
+	if _, ok := selfSignedUpToDate(certPath, rsaKey); ok {
+		t.Fatal("key type change not detected")
+	}
+	// Within a day of expiry the cert is regenerated
+	short := o
+	short.Validity = time.Hour
+	if err := generateSelfSigned(certPath, keyPath, short); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := selfSignedUpToDate(certPath, short); ok {
+		t.Fatal("expiring cert reported up to date")
+	}
+	if gen, ok := selfSignedUpToDate(filepath.Join(dir, "missing.crt"), o); gen || ok {
+		t.Fatalf("missing cert: generated=%v ok=%v", gen, ok)
+	}
+}
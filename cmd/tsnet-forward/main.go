@@ -23,23 +23,33 @@ func (m *multiFlag) String() string     { return strings.Join(*m, ",") }
 func (m *multiFlag) Set(v string) error { *m = append(*m, v); return nil }
 
 type mapping struct {
-	listen string
-	dest   string
+	network string // "tcp" (default) or "udp"
+	listen  string
+	dest    string
 }
 
 func parseMapping(s string) (mapping, error) {
-	// Format: listen=dest e.g. 127.0.0.1:50010=10.0.0.10:50000
+	// Format: [tcp:|udp:]listen=dest e.g. 127.0.0.1:50010=10.0.0.10:50000 or udp:127.0.0.1:5353=10.0.0.10:53
+	network := "tcp"
+	for _, p := range []string{"tcp:", "udp:"} {
+		if strings.HasPrefix(s, p) {
+			network = strings.TrimSuffix(p, ":")
+			s = strings.TrimPrefix(s, p)
+			break
+		}
+	}
 	parts := strings.SplitN(s, "=", 2)
 	if len(parts) != 2 {
-		return mapping{}, fmt.Errorf("invalid mapping %q, expected listen=dest", s)
+		return mapping{}, fmt.Errorf("invalid mapping %q, expected [udp:]listen=dest", s)
 	}
-	return mapping{listen: strings.TrimSpace(parts[0]), dest: strings.TrimSpace(parts[1])}, nil
+	return mapping{network: network, listen: strings.TrimSpace(parts[0]), dest: strings.TrimSpace(parts[1])}, nil
 }
 
 func main() {
 	var maps multiFlag
 	var loginServer, authKey, hostname string
 	var verbose bool
+	var udpIdle time.Duration
 
 	defLogin := os.Getenv("TS_LOGIN_SERVER")
 	if defLogin == "" {
@@ -50,11 +60,19 @@ func main() {
 		defHost = fmt.Sprintf("gn-forward-%s", strings.ToLower(strings.Split(runtime.GOOS+"-"+runtime.GOARCH, "-")[0]))
 	}
 
-	flag.Var(&maps, "map", "Forward mapping in the form listen=dest (repeatable). Example: -map 127.0.0.1:50010=10.0.0.10:50000")
+	defUDPIdle := 60 * time.Second
+	if v := os.Getenv("TS_UDP_IDLE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			defUDPIdle = d
+		}
+	}
+
+	flag.Var(&maps, "map", "Forward mapping in the form [udp:]listen=dest (repeatable, TCP by default). Example: -map 127.0.0.1:50010=10.0.0.10:50000 -map udp:127.0.0.1:5353=10.0.0.10:53")
 	flag.StringVar(&loginServer, "login-server", defLogin, "Tailscale/Headscale login server URL")
 	flag.StringVar(&authKey, "authkey", os.Getenv("TS_AUTHKEY"), "Tailscale auth key (or TS_AUTHKEY env)")
 	flag.StringVar(&hostname, "hostname", defHost, "tsnet hostname for this forwarder")
 	flag.BoolVar(&verbose, "v", false, "verbose logging")
+	flag.DurationVar(&udpIdle, "udp-idle", defUDPIdle, "idle timeout for UDP client associations (or TS_UDP_IDLE_TIMEOUT env)")
 	flag.Parse()
 	log.Printf("tsnet-forward starting; mappings=%d, hostname=%s, login=%s", len(maps), hostname, loginServer)
 	for i, m := range maps {
@@ -93,7 +111,27 @@ func main() {
 		if err != nil {
 			log.Fatalf("%v", err)
 		}
-		log.Printf("parsed map[%d]: %s => %s", i, mp.listen, mp.dest)
+		log.Printf("parsed map[%d]: %s %s => %s", i, mp.network, mp.listen, mp.dest)
+		if mp.network == "udp" {
+			go func(mp mapping) {
+				laddr, err := net.ResolveUDPAddr("udp", mp.listen)
+				if err != nil {
+					errs <- fmt.Errorf("resolve %s: %w", mp.listen, err)
+					return
+				}
+				pc, err := net.ListenUDP("udp", laddr)
+				if err != nil {
+					log.Printf("udp listen failed on %s: %v", mp.listen, err)
+					errs <- fmt.Errorf("listen udp %s: %w", mp.listen, err)
+					return
+				}
+				log.Printf("forwarding udp %s -> %s (via tsnet, idle=%s)", mp.listen, mp.dest, udpIdle)
+				if err := serveUDP(ctx, srv.Dial, pc, mp.dest, udpIdle, verbose); err != nil {
+					errs <- fmt.Errorf("udp on %s: %w", mp.listen, err)
+				}
+			}(mp)
+			continue
+		}
 		go func(mp mapping) {
 			log.Printf("listener init: trying %s -> %s", mp.listen, mp.dest)
 			ln, err := net.Listen("tcp", mp.listen)
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dialFunc matches tsnet.Server.Dial so the UDP forwarder can be driven by any dialer.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// udpAssoc tracks one client source address and its upstream "connection" through tsnet.
+type udpAssoc struct {
+	up       net.Conn
+	lastSeen atomic.Int64 // unix nanos of last datagram in either direction
+}
+
+func (a *udpAssoc) touch() { a.lastSeen.Store(time.Now().UnixNano()) }
+
+// serveUDP forwards datagrams received on pc to dest. Each client source address gets its
+// own upstream association so replies are routed back to the right sender; associations
+// are dropped after idle passes without traffic in either direction.
+func serveUDP(ctx context.Context, dial dialFunc, pc *net.UDPConn, dest string, idle time.Duration, verbose bool) error {
+	var mu sync.Mutex
+	assocs := map[string]*udpAssoc{}
+
+	drop := func(key string, a *udpAssoc) {
+		mu.Lock()
+		if assocs[key] == a {
+			delete(assocs, key)
+		}
+		mu.Unlock()
+		_ = a.up.Close()
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = pc.Close()
+		mu.Lock()
+		for k, a := range assocs {
+			_ = a.up.Close()
+			delete(assocs, k)
+		}
+		mu.Unlock()
+	}()
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, src, err := pc.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		key := src.String()
+		mu.Lock()
+		a := assocs[key]
+		mu.Unlock()
+		if a == nil {
+			up, err := dial(ctx, "udp", dest)
+			if err != nil {
+				log.Printf("udp dial %s via tsnet failed: %v", dest, err)
+				continue
+			}
+			a = &udpAssoc{up: up}
+			a.touch()
+			mu.Lock()
+			assocs[key] = a
+			mu.Unlock()
+			if verbose {
+				log.Printf("udp assoc open %s -> %s", key, dest)
+			}
+			go udpReturnLoop(pc, src, a, idle, func() {
+				drop(key, a)
+				if verbose {
+					log.Printf("udp assoc closed %s -> %s", key, dest)
+				}
+			})
+		}
+		a.touch()
+		if _, err := a.up.Write(buf[:n]); err != nil {
+			log.Printf("udp write to %s failed: %v", dest, err)
+			drop(key, a)
+		}
+	}
+}
+
+// udpReturnLoop copies upstream replies back to the client until the association is idle or fails.
+func udpReturnLoop(pc *net.UDPConn, src *net.UDPAddr, a *udpAssoc, idle time.Duration, done func()) {
+	defer done()
+	buf := make([]byte, 64*1024)
+	for {
+		_ = a.up.SetReadDeadline(time.Now().Add(idle))
+		n, err := a.up.Read(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				// client may still be sending; only expire when both directions are quiet
+				if time.Since(time.Unix(0, a.lastSeen.Load())) < idle {
+					continue
+				}
+			}
+			return
+		}
+		a.touch()
+		if _, err := pc.WriteToUDP(buf[:n], src); err != nil {
+			return
+		}
+	}
+}
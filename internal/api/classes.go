@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// storageClassInfo is the /api/cluster/{id}/storageclasses item shape.
+type storageClassInfo struct {
+	Name        string `json:"name"`
+	Provisioner string `json:"provisioner"`
+	IsDefault   bool   `json:"isDefault"`
+}
+
+// ingressClassInfo is the /api/cluster/{id}/ingressclasses item shape.
+type ingressClassInfo struct {
+	Name       string `json:"name"`
+	Controller string `json:"controller"`
+}
+
+// listStorageClasses returns the cluster's StorageClasses sorted by name, flagging the
+// default class (GA or beta is-default-class annotation).
+func listStorageClasses(ctx context.Context, cli kubernetes.Interface) ([]storageClassInfo, error) {
+	lst, err := cli.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]storageClassInfo, 0, len(lst.Items))
+	for _, sc := range lst.Items {
+		ann := sc.GetAnnotations()
+		out = append(out, storageClassInfo{
+			Name:        sc.Name,
+			Provisioner: sc.Provisioner,
+			IsDefault:   ann["storageclass.kubernetes.io/is-default-class"] == "true" || ann["storageclass.beta.kubernetes.io/is-default-class"] == "true",
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// listIngressClasses returns the cluster's IngressClasses sorted by name.
+func listIngressClasses(ctx context.Context, cli kubernetes.Interface) ([]ingressClassInfo, error) {
+	lst, err := cli.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ingressClassInfo, 0, len(lst.Items))
+	for _, ic := range lst.Items {
+		out = append(out, ingressClassInfo{Name: ic.Name, Controller: ic.Spec.Controller})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
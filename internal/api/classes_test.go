@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListClasses(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard", Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"}}, Provisioner: "rancher.io/local-path"},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fast"}, Provisioner: "ebs.csi.aws.com"},
+		&networkingv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "nginx"}, Spec: networkingv1.IngressClassSpec{Controller: "k8s.io/ingress-nginx"}},
+	)
+	scs, err := listStorageClasses(context.Background(), cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scs) != 2 || scs[0].Name != "fast" || scs[0].IsDefault || scs[1].Name != "standard" || !scs[1].IsDefault || scs[1].Provisioner != "rancher.io/local-path" {
+		t.Fatalf("storage classes = %+v", scs)
+	}
+	ics, err := listIngressClasses(context.Background(), cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ics) != 1 || ics[0].Name != "nginx" || ics[0].Controller != "k8s.io/ingress-nginx" {
+		t.Fatalf("ingress classes = %+v", ics)
+	}
+}
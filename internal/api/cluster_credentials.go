@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/secrets"
+)
+
+func kubeconfigCredKey(clusterID string) string {
+	return fmt.Sprintf("cl:%s:kubeconfig", clusterID)
+}
+
+// StoreClusterKubeconfig saves a cluster's kubeconfig in the credentials bucket, sealed by
+// sec. The record's "encrypted" marker is always written, so readers know whether to
+// decrypt; without a master key the kubeconfig is stored in plaintext with
+// encrypted=false.
+func StoreClusterKubeconfig(db *localdb.DB, sec *secrets.Manager, clusterID, kubeconfig string) error {
+	val, encrypted, err := sec.Seal(kubeconfig)
+	if err != nil {
+		return err
+	}
+	return db.Put("credentials", kubeconfigCredKey(clusterID), map[string]any{
+		"id":        uuid.NewString(),
+		"scopeType": "cluster",
+		"scopeId":   clusterID,
+		"kind":      "cluster.kubeconfig",
+		"value":     val,
+		"encrypted": encrypted,
+		"rotatedAt": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// ClusterKubeconfig returns the kubeconfig stored for a cluster. Records marked
+// encrypted=false are returned as stored and never decrypted; encrypted ones need an
+// enabled sec (secrets.ErrNoMasterKey otherwise) unless they were sealed with the
+// legacy zero key. Older records without a marker are decrypted only when the result
+// parses as a kubeconfig, and are otherwise taken as plaintext.
+func ClusterKubeconfig(db *localdb.DB, sec *secrets.Manager, clusterID string) (string, error) {
+	if db == nil {
+		return "", fmt.Errorf("no db")
+	}
+	var cred map[string]any
+	if err := db.Get("credentials", kubeconfigCredKey(clusterID), &cred); err != nil {
+		return "", err
+	}
+	val, _ := cred["value"].(string)
+	if encrypted, marked := cred["encrypted"].(bool); marked {
+		return sec.Open(val, encrypted)
+	}
+	if v, err := sec.Open(val, true); err == nil {
+		if cfg, err := kubeconfigFrom(v); err == nil && cfg != nil {
+			return v, nil
+		}
+	}
+	return val, nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxClusterEvents caps /api/cluster/{id}/events.
+const maxClusterEvents = 200
+
+// ClusterEvent is a trimmed Warning event returned by /api/cluster/{id}/events.
+type ClusterEvent struct {
+	Namespace string    `json:"namespace"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Object    string    `json:"object"`
+	Count     int32     `json:"count,omitempty"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// eventLastSeen is the most recent time an event was observed; older events only carry
+// EventTime or the creation timestamp.
+func eventLastSeen(ev *corev1.Event) time.Time {
+	if t := ev.LastTimestamp.Time; !t.IsZero() {
+		return t
+	}
+	if t := ev.EventTime.Time; !t.IsZero() {
+		return t
+	}
+	return ev.CreationTimestamp.Time
+}
+
+// parseEventsSince accepts an RFC3339 timestamp or a duration relative to now ("30m").
+func parseEventsSince(v string, now time.Time) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("since must be an RFC3339 time or a duration like 30m")
+}
+
+// listClusterEvents returns Warning events in ns (all namespaces when ns is empty) last
+// seen at or after since, newest first and capped at limit.
+func listClusterEvents(ctx context.Context, cli kubernetes.Interface, ns string, since time.Time, limit int) ([]ClusterEvent, error) {
+	evs, err := cli.CoreV1().Events(ns).List(ctx, metav1.ListOptions{FieldSelector: "type=" + corev1.EventTypeWarning})
+	if err != nil {
+		return nil, err
+	}
+	out := []ClusterEvent{}
+	for i := range evs.Items {
+		ev := &evs.Items[i]
+		// Filter again: not every client honors the field selector
+		if ev.Type != corev1.EventTypeWarning {
+			continue
+		}
+		last := eventLastSeen(ev)
+		if !since.IsZero() && last.Before(since) {
+			continue
+		}
+		out = append(out, ClusterEvent{
+			Namespace: ev.Namespace,
+			Type:      ev.Type,
+			Reason:    ev.Reason,
+			Message:   ev.Message,
+			Object:    ev.InvolvedObject.Kind + "/" + ev.InvolvedObject.Name,
+			Count:     ev.Count,
+			LastSeen:  last,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].LastSeen.After(out[j].LastSeen) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListClusterEvents(t *testing.T) {
+	now := time.Now()
+	ev := func(ns, name, typ string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: ns},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: name},
+			Type:           typ,
+			Reason:         name,
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	cli := fake.NewSimpleClientset(
+		ev("default", "old", corev1.EventTypeWarning, now.Add(-2*time.Hour)),
+		ev("default", "new", corev1.EventTypeWarning, now),
+		ev("default", "normal", corev1.EventTypeNormal, now),
+		ev("kube-system", "pressure", corev1.EventTypeWarning, now.Add(-time.Minute)),
+	)
+
+	got, err := listClusterEvents(context.Background(), cli, "default", time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Reason != "new" || got[1].Reason != "old" {
+		t.Fatalf("default namespace = %+v", got)
+	}
+
+	got, _ = listClusterEvents(context.Background(), cli, metav1.NamespaceAll, now.Add(-time.Hour), 0)
+	if len(got) != 2 || got[0].Reason != "new" || got[1].Namespace != "kube-system" {
+		t.Fatalf("all namespaces since 1h = %+v", got)
+	}
+
+	got, _ = listClusterEvents(context.Background(), cli, metav1.NamespaceAll, time.Time{}, 1)
+	if len(got) != 1 || got[0].Reason != "new" {
+		t.Fatalf("limited = %+v", got)
+	}
+}
+
+func TestParseEventsSince(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got, _ := parseEventsSince("30m", now); !got.Equal(now.Add(-30 * time.Minute)) {
+		t.Fatalf("duration = %v", got)
+	}
+	if got, _ := parseEventsSince("2024-01-01T10:00:00Z", now); !got.Equal(now.Add(-2 * time.Hour)) {
+		t.Fatalf("timestamp = %v", got)
+	}
+	if got, err := parseEventsSince("", now); err != nil || !got.IsZero() {
+		t.Fatalf("empty = %v, %v", got, err)
+	}
+	if _, err := parseEventsSince("yesterday", now); err == nil {
+		t.Fatalf("expected error")
+	}
+}
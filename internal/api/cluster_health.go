@@ -0,0 +1,84 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// clusterHealthTTL is how long a cluster reachability check is reused by
+// GET /api/deploy/clusters?withHealth=1.
+const clusterHealthTTL = 30 * time.Second
+
+// clusterHealthParallel bounds concurrent checks for clusters missing from the cache.
+const clusterHealthParallel = 8
+
+// clusterHealthCache keeps the latest reachability summary per cluster ID. /api/health
+// refreshes it; listings read it and only check clusters with no fresh entry.
+type clusterHealthCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]cachedClusterHealth
+}
+
+type cachedClusterHealth struct {
+	status map[string]any
+	at     time.Time
+}
+
+func newClusterHealthCache(ttl time.Duration) *clusterHealthCache {
+	return &clusterHealthCache{ttl: ttl, now: time.Now, entries: map[string]cachedClusterHealth{}}
+}
+
+// put records status for id, stamping it with checkedAt.
+func (c *clusterHealthCache) put(id string, status map[string]any) map[string]any {
+	at := c.now()
+	st := make(map[string]any, len(status)+1)
+	for k, v := range status {
+		st[k] = v
+	}
+	st["checkedAt"] = at.UTC().Format(time.RFC3339)
+	c.mu.Lock()
+	c.entries[id] = cachedClusterHealth{status: st, at: at}
+	c.mu.Unlock()
+	return st
+}
+
+// get returns the cached status for id when it is younger than the TTL.
+func (c *clusterHealthCache) get(id string) (map[string]any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok || c.now().Sub(e.at) >= c.ttl {
+		return nil, false
+	}
+	return e.status, true
+}
+
+// lookup returns a status for every id, serving fresh entries from the cache and running
+// check concurrently (at most clusterHealthParallel at once) for the rest.
+func (c *clusterHealthCache) lookup(ids []string, check func(id string) map[string]any) map[string]map[string]any {
+	out := make(map[string]map[string]any, len(ids))
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, clusterHealthParallel)
+	)
+	for _, id := range ids {
+		if st, ok := c.get(id); ok {
+			out[id] = st
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() { <-sem; wg.Done() }()
+			st := c.put(id, check(id))
+			mu.Lock()
+			out[id] = st
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return out
+}
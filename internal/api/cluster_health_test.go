@@ -0,0 +1,34 @@
+package api
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClusterHealthCacheLookup(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := newClusterHealthCache(30 * time.Second)
+	c.now = func() time.Time { return now }
+	var checks atomic.Int32
+	check := func(id string) map[string]any {
+		checks.Add(1)
+		return map[string]any{"status": "ok"}
+	}
+
+	// Warm one entry the way /api/health does
+	c.put("a", map[string]any{"status": "error", "code": "cluster_unreachable"})
+	got := c.lookup([]string{"a", "b", "c"}, check)
+	if checks.Load() != 2 || got["a"]["status"] != "error" || got["b"]["status"] != "ok" || got["c"]["checkedAt"] == nil {
+		t.Fatalf("checks=%d got=%v", checks.Load(), got)
+	}
+	c.lookup([]string{"a", "b", "c"}, check)
+	if checks.Load() != 2 {
+		t.Fatalf("fresh entries re-checked: %d", checks.Load())
+	}
+	now = now.Add(31 * time.Second)
+	c.lookup([]string{"a"}, check)
+	if checks.Load() != 3 {
+		t.Fatalf("stale entry not re-checked: %d", checks.Load())
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/localdb"
+)
+
+// clusterRBAC hands out one RBAC store per cluster, shared by the cluster's DB and SSE
+// routes. Stores are backed by localdb when available, so grants (including the
+// maintainer role auto-granted on database create) persist across requests.
+type clusterRBAC struct {
+	mu     sync.Mutex
+	db     *localdb.DB
+	stores map[string]*httpx.RBACStore
+}
+
+func newClusterRBAC(db *localdb.DB) *clusterRBAC {
+	return &clusterRBAC{db: db, stores: map[string]*httpx.RBACStore{}}
+}
+
+// get returns the store for clusterID, loading it on first use.
+func (c *clusterRBAC) get(clusterID string) *httpx.RBACStore {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stores[clusterID]
+	if !ok {
+		s = httpx.NewPersistentRBACStore(c.db, "cluster:"+clusterID)
+		c.stores[clusterID] = s
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// TestClusterRBACPersists grants a permission through one per-request DBAPI (as the
+// cluster DB route builds them) and expects later requests, other clusters and a fresh
+// store over the same localdb to see the right bindings.
+func TestClusterRBACPersists(t *testing.T) {
+	m, err := localdb.OpenManager(nil, t.TempDir(), "hostdb")
+	if err != nil {
+		t.Fatalf("open manager: %v", err)
+	}
+	defer m.Close()
+	do := func(stores *clusterRBAC, clusterID, method, path, body string) *httptest.ResponseRecorder {
+		api := &httpx.DBAPI{OrgID: clusterID, RBAC: stores.get(clusterID)}
+		mux := http.NewServeMux()
+		api.Register(mux)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+	list := func(stores *clusterRBAC, clusterID string) []model.PermissionBinding {
+		rr := do(stores, clusterID, http.MethodGet, "/api/db/db1/permissions", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("list permissions: %d %s", rr.Code, rr.Body.String())
+		}
+		var out []model.PermissionBinding
+		if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	stores := newClusterRBAC(m.DB)
+	rr := do(stores, "c1", http.MethodPost, "/api/db/db1/permissions", `{"principal":"user:ann","scope":"db:db1","role":"editor"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("grant: %d %s", rr.Code, rr.Body.String())
+	}
+	if got := list(stores, "c1"); len(got) != 1 || got[0].Principal != "user:ann" || got[0].Role != model.RoleEditor {
+		t.Fatalf("next request lost the grant: %+v", got)
+	}
+	if got := list(stores, "c2"); len(got) != 0 {
+		t.Fatalf("grant leaked into another cluster: %+v", got)
+	}
+	if got := list(newClusterRBAC(m.DB), "c1"); len(got) != 1 {
+		t.Fatalf("grant not persisted: %+v", got)
+	}
+
+	rr = do(stores, "c1", http.MethodDelete, "/api/db/db1/permissions?scope=db:db1&principal=user:ann", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("revoke: %d %s", rr.Code, rr.Body.String())
+	}
+	if got := list(newClusterRBAC(m.DB), "c1"); len(got) != 0 {
+		t.Fatalf("revoke not persisted: %+v", got)
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+)
+
+// crdCheckTTL bounds how long a discovery result is reused; short so that applying the CRD
+// takes effect without restarting the host app.
+const crdCheckTTL = 15 * time.Second
+
+const crdInstallHint = "the Workspace CRD (workspaces.guildnet.io) is not installed in this cluster; run `make crd-apply` or `kubectl apply -f config/crd/`"
+
+type crdCheckEntry struct {
+	installed bool
+	at        time.Time
+}
+
+var (
+	crdCheckMu    sync.Mutex
+	crdCheckCache = map[string]crdCheckEntry{}
+)
+
+// workspaceCRDInstalled reports whether guildnet.io/v1alpha1 workspaces is served by the
+// cluster, caching the answer per cluster for crdCheckTTL. Discovery errors other than
+// "group not found" are returned uncached so callers can fall through to the real request.
+func workspaceCRDInstalled(ctx context.Context, clusterID string, cli kubernetes.Interface) (bool, error) {
+	crdCheckMu.Lock()
+	e, ok := crdCheckCache[clusterID]
+	crdCheckMu.Unlock()
+	if ok && time.Since(e.at) < crdCheckTTL {
+		return e.installed, nil
+	}
+	installed := false
+	res, err := cli.Discovery().ServerResourcesForGroupVersion("guildnet.io/v1alpha1")
+	switch {
+	case err == nil:
+		for _, r := range res.APIResources {
+			if r.Name == "workspaces" {
+				installed = true
+				break
+			}
+		}
+	case apierrors.IsNotFound(err):
+	default:
+		return false, err
+	}
+	crdCheckMu.Lock()
+	crdCheckCache[clusterID] = crdCheckEntry{installed: installed, at: time.Now()}
+	crdCheckMu.Unlock()
+	return installed, nil
+}
+
+// requireWorkspaceCRD writes a 412 crd_not_installed response and returns false when the
+// Workspace CRD is known to be absent. Unknown (discovery failed) is treated as present.
+func requireWorkspaceCRD(w http.ResponseWriter, r *http.Request, clusterID string, cli kubernetes.Interface) bool {
+	if installed, err := workspaceCRDInstalled(r.Context(), clusterID, cli); err == nil && !installed {
+		httpx.JSONError(w, http.StatusPreconditionFailed, "workspace CRD not installed", "crd_not_installed", crdInstallHint)
+		return false
+	}
+	return true
+}
+
+// forgetWorkspaceCRD drops the cached discovery result for clusterID.
+func forgetWorkspaceCRD(clusterID string) {
+	crdCheckMu.Lock()
+	delete(crdCheckCache, clusterID)
+	crdCheckMu.Unlock()
+}
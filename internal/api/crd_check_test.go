@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWorkspaceCRDInstalled(t *testing.T) {
+	cli := fake.NewSimpleClientset()
+	fd := cli.Discovery().(*fakediscovery.FakeDiscovery)
+	ctx := context.Background()
+
+	if ok, err := workspaceCRDInstalled(ctx, "crd-test", cli); err != nil || ok {
+		t.Fatalf("missing CRD: ok=%v err=%v", ok, err)
+	}
+	rec := httptest.NewRecorder()
+	if requireWorkspaceCRD(rec, httptest.NewRequest(http.MethodPost, "/", nil), "crd-test", cli) || rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412, got %d", rec.Code)
+	}
+
+	fd.Resources = []*metav1.APIResourceList{{GroupVersion: "guildnet.io/v1alpha1", APIResources: []metav1.APIResource{{Name: "workspaces"}}}}
+	// cached negative result is reused until forgotten
+	if ok, _ := workspaceCRDInstalled(ctx, "crd-test", cli); ok {
+		t.Fatalf("expected cached result")
+	}
+	forgetWorkspaceCRD("crd-test")
+	if ok, err := workspaceCRDInstalled(ctx, "crd-test", cli); err != nil || !ok {
+		t.Fatalf("installed CRD: ok=%v err=%v", ok, err)
+	}
+	forgetWorkspaceCRD("crd-test")
+}
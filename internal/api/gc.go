@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// gcSelector matches resources created by the legacy (non-operator) deploy path.
+const gcSelector = "guildnet.io/managed=true"
+
+// OrphanResource is a managed object whose owning Workspace no longer exists.
+type OrphanResource struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Workspace string `json:"workspace"`
+	Deleted   bool   `json:"deleted,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// owningWorkspace names the Workspace a managed object belongs to: the controller owner
+// reference when set, else the workspace or app label. "" means the owner is unknown.
+func owningWorkspace(meta metav1.Object) string {
+	for _, ref := range meta.GetOwnerReferences() {
+		if ref.Kind == "Workspace" && ref.Name != "" {
+			return ref.Name
+		}
+	}
+	if v := meta.GetLabels()["guildnet.io/workspace"]; v != "" {
+		return v
+	}
+	return meta.GetLabels()["app"]
+}
+
+// findOrphans lists managed Deployments, Services and PVCs in ns whose owning Workspace is
+// gone. Objects without an identifiable owner are left alone. Failing to list Workspaces is
+// fatal, so a missing CRD never makes every managed object look orphaned.
+func findOrphans(ctx context.Context, cli kubernetes.Interface, dyn dynamic.Interface, ns string) ([]OrphanResource, error) {
+	gvr := schema.GroupVersionResource{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}
+	wsList, err := dyn.Resource(gvr).Namespace(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	live := map[string]bool{}
+	for _, ws := range wsList.Items {
+		live[ws.GetName()] = true
+	}
+	out := []OrphanResource{}
+	add := func(kind string, meta metav1.Object) {
+		owner := owningWorkspace(meta)
+		if owner == "" || live[owner] {
+			return
+		}
+		out = append(out, OrphanResource{Kind: kind, Namespace: ns, Name: meta.GetName(), Workspace: owner})
+	}
+	sel := metav1.ListOptions{LabelSelector: gcSelector}
+	deps, err := cli.AppsV1().Deployments(ns).List(ctx, sel)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deps.Items {
+		add("Deployment", &deps.Items[i])
+	}
+	svcs, err := cli.CoreV1().Services(ns).List(ctx, sel)
+	if err != nil {
+		return nil, err
+	}
+	for i := range svcs.Items {
+		add("Service", &svcs.Items[i])
+	}
+	pvcs, err := cli.CoreV1().PersistentVolumeClaims(ns).List(ctx, sel)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pvcs.Items {
+		add("PersistentVolumeClaim", &pvcs.Items[i])
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// deleteOrphan removes one orphaned object; an already-deleted object counts as success.
+func deleteOrphan(ctx context.Context, cli kubernetes.Interface, o OrphanResource) error {
+	var err error
+	switch o.Kind {
+	case "Deployment":
+		err = cli.AppsV1().Deployments(o.Namespace).Delete(ctx, o.Name, metav1.DeleteOptions{})
+	case "Service":
+		err = cli.CoreV1().Services(o.Namespace).Delete(ctx, o.Name, metav1.DeleteOptions{})
+	case "PersistentVolumeClaim":
+		err = cli.CoreV1().PersistentVolumeClaims(o.Namespace).Delete(ctx, o.Name, metav1.DeleteOptions{})
+	}
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFindAndDeleteOrphans(t *testing.T) {
+	ws := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "guildnet.io/v1alpha1",
+		"kind":       "Workspace",
+		"metadata":   map[string]any{"name": "live", "namespace": "default"},
+	}}
+	dyn := dynfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}: "WorkspaceList"}, ws)
+
+	managed := func(name, app string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"guildnet.io/managed": "true", "app": app}}
+	}
+	cli := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: managed("live", "live")},
+		&appsv1.Deployment{ObjectMeta: managed("gone", "gone")},
+		&corev1.Service{ObjectMeta: managed("gone", "gone")},
+		&corev1.PersistentVolumeClaim{ObjectMeta: managed("gone-data", "gone")},
+		// Unmanaged and ownerless objects are never candidates.
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", Labels: map[string]string{"app": "other"}}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "anon", Namespace: "default", Labels: map[string]string{"guildnet.io/managed": "true"}}},
+	)
+
+	ctx := context.Background()
+	orphans, err := findOrphans(ctx, cli, dyn, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Deployment/gone", "PersistentVolumeClaim/gone-data", "Service/gone"}
+	if len(orphans) != len(want) {
+		t.Fatalf("orphans=%+v want %v", orphans, want)
+	}
+	for i, o := range orphans {
+		if got := o.Kind + "/" + o.Name; got != want[i] || o.Workspace != "gone" {
+			t.Fatalf("orphan[%d]=%+v want %s", i, o, want[i])
+		}
+		if err := deleteOrphan(ctx, cli, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if again, _ := findOrphans(ctx, cli, dyn, "default"); len(again) != 0 {
+		t.Fatalf("orphans left after delete: %+v", again)
+	}
+	if _, err := cli.AppsV1().Deployments("default").Get(ctx, "live", metav1.GetOptions{}); err != nil {
+		t.Fatalf("live deployment removed: %v", err)
+	}
+	if err := deleteOrphan(ctx, cli, orphans[0]); err != nil {
+		t.Fatalf("second delete should be a no-op: %v", err)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 
@@ -34,11 +33,9 @@ func TestHealthEndpointProxyFallback(t *testing.T) {
 	go func() {
 		_ = http.Serve(ln, handler)
 	}()
-	// Export KUBE_PROXY_ADDR so isLocalKubeProxyAvailable sees this listener (host:port)
+	// Export KUBE_PROXY_ADDR so detectKubeProxy sees this listener (host:port)
 	proxyAddr := ln.Addr().String()
-	if err := os.Setenv("KUBE_PROXY_ADDR", proxyAddr); err != nil {
-		t.Fatalf("setenv KUBE_PROXY_ADDR: %v", err)
-	}
+	t.Setenv("KUBE_PROXY_ADDR", proxyAddr)
 
 	// Create an in-memory localdb manager and DB
 	mgr, err := localdb.OpenManager(context.Background(), t.TempDir(), "hostdb")
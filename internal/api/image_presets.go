@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// imagePresetsBucket holds admin-curated deployable images keyed by image reference.
+const imagePresetsBucket = "image_presets"
+
+// ImagePresets returns the stored image presets sorted by label, or
+// model.BuiltinDeployImages when none are stored (or db is nil).
+func ImagePresets(db *localdb.DB) []model.DeployImage {
+	if db == nil {
+		return model.BuiltinDeployImages()
+	}
+	items := []model.DeployImage{}
+	_ = db.List(imagePresetsBucket, &items)
+	if len(items) == 0 {
+		return model.BuiltinDeployImages()
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Label != items[j].Label {
+			return items[i].Label < items[j].Label
+		}
+		return items[i].Image < items[j].Image
+	})
+	return items
+}
+
+// imagePresetsHandler serves GET/POST/DELETE /api/images/presets. POST upserts by image
+// reference and DELETE takes ?image=; both need the mutating auth check.
+func imagePresetsHandler(deps Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			httpx.JSON(w, http.StatusOK, ImagePresets(deps.DB))
+			return
+		}
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !tokenAuthorized(r, deps.Token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if deps.DB == nil {
+			httpx.JSONError(w, http.StatusServiceUnavailable, "local database unavailable", "no_db")
+			return
+		}
+		if r.Method == http.MethodDelete {
+			image := strings.TrimSpace(r.URL.Query().Get("image"))
+			if image == "" {
+				httpx.JSONError(w, http.StatusBadRequest, "image is required", "missing_image")
+				return
+			}
+			var prev model.DeployImage
+			if err := deps.DB.Get(imagePresetsBucket, image, &prev); err != nil {
+				httpx.JSONError(w, http.StatusNotFound, "preset not found", "preset_not_found")
+				return
+			}
+			_ = deps.DB.Delete(imagePresetsBucket, image)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var p model.DeployImage
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			httpx.JSONError(w, http.StatusBadRequest, "invalid json", "bad_json", err.Error())
+			return
+		}
+		p.Label = strings.TrimSpace(p.Label)
+		p.Image = strings.TrimSpace(p.Image)
+		if errs := p.Validate(); len(errs) > 0 {
+			fields := map[string]string{}
+			for _, fe := range errs {
+				fields[fe.Field] = fe.Message
+			}
+			httpx.JSONFieldErrors(w, http.StatusBadRequest, "invalid preset", "invalid_preset", fields)
+			return
+		}
+		status := http.StatusCreated
+		var prev model.DeployImage
+		if err := deps.DB.Get(imagePresetsBucket, p.Image, &prev); err == nil {
+			status = http.StatusOK
+		}
+		if err := deps.DB.Put(imagePresetsBucket, p.Image, p); err != nil {
+			httpx.JSONError(w, http.StatusInternalServerError, "save preset failed", "save_failed", err.Error())
+			return
+		}
+		httpx.JSON(w, status, p)
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+func TestImagePresets(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mux := Router(Deps{DB: db, Token: "tok"})
+	do := func(method, path, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth {
+			req.Header.Set("Authorization", "Bearer tok")
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	list := func() []model.DeployImage {
+		rec := do(http.MethodGet, "/api/images/presets", "", false)
+		var out []model.DeployImage
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("list status=%d body=%s", rec.Code, rec.Body.String())
+		}
+		return out
+	}
+
+	if got := list(); len(got) != 1 || got[0].Image != model.BuiltinDeployImages()[0].Image {
+		t.Fatalf("empty store should list the built-ins, got %+v", got)
+	}
+	preset := `{"label":"Jupyter","image":"jupyter/base-notebook:latest","ports":[{"name":"http","port":8888}],"env":{"JUPYTER_TOKEN":""}}`
+	if rec := do(http.MethodPost, "/api/images/presets", preset, false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated POST status=%d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/images/presets", `{"label":"","image":"a b","ports":[{"port":0}]}`, true); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "ports[0].port") {
+		t.Fatalf("invalid POST status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/images/presets", preset, true); rec.Code != http.StatusCreated {
+		t.Fatalf("POST status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/images/presets", preset, true); rec.Code != http.StatusOK {
+		t.Fatalf("upsert status=%d", rec.Code)
+	}
+	got := list()
+	if len(got) != 1 || got[0].Label != "Jupyter" || len(got[0].Ports) != 1 || got[0].Ports[0].Port != 8888 {
+		t.Fatalf("stored presets replace the built-ins, got %+v", got)
+	}
+	if ImagePresets(db)[0].Image != "jupyter/base-notebook:latest" {
+		t.Fatalf("ImagePresets disagrees with the handler")
+	}
+	if rec := do(http.MethodDelete, "/api/images/presets?image=jupyter/base-notebook:latest", "", false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated DELETE status=%d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/images/presets?image=jupyter/base-notebook:latest", "", true); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status=%d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/images/presets?image=jupyter/base-notebook:latest", "", true); rec.Code != http.StatusNotFound {
+		t.Fatalf("second DELETE status=%d", rec.Code)
+	}
+	if got := list(); got[0].Image != model.BuiltinDeployImages()[0].Image {
+		t.Fatalf("deleting the last preset should restore the built-ins, got %+v", got)
+	}
+}
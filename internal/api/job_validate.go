@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// jobSpecFromWorkspaceBody adapts a workspace create body ({image, env:[{name,value}],
+// ports:[{containerPort,name}], args:[...]}) to a JobSpec so both create paths share
+// model.JobSpec.Validate. Env entries using valueFrom are left to the apiserver since they
+// carry no literal value.
+func jobSpecFromWorkspaceBody(body map[string]any, name string) model.JobSpec {
+	js := model.JobSpec{Name: name}
+	js.Image, _ = body["image"].(string)
+	if args, ok := body["args"].([]any); ok {
+		for _, a := range args {
+			js.Args = append(js.Args, fmt.Sprint(a))
+		}
+	}
+	if envs, ok := body["env"].([]any); ok {
+		js.Env = map[string]string{}
+		for _, e := range envs {
+			m, ok := e.(map[string]any)
+			if !ok || m["valueFrom"] != nil {
+				continue
+			}
+			k, _ := m["name"].(string)
+			v, _ := m["value"].(string)
+			js.Env[k] = v
+		}
+	}
+	if ports, ok := body["ports"].([]any); ok {
+		for _, p := range ports {
+			m, _ := p.(map[string]any)
+			port := 0
+			if f, ok := m["containerPort"].(float64); ok {
+				port = int(f)
+			}
+			pn, _ := m["name"].(string)
+			js.Expose = append(js.Expose, model.Port{Port: port, Name: pn})
+		}
+	}
+	return js
+}
+
+// workspaceFieldPath maps a JobSpec field path onto the workspace create body's spec.* paths.
+func workspaceFieldPath(field string) string {
+	switch {
+	case field == "name":
+		return "metadata.name"
+	case strings.HasPrefix(field, "expose["):
+		idx := strings.TrimSuffix(strings.TrimPrefix(field, "expose"), ".port")
+		return fmt.Sprintf("spec.ports%s.containerPort", idx)
+	}
+	return "spec." + field
+}
+
+// workspaceResources accepts spec.resources either in Kubernetes form ({requests, limits})
+// or as the flat {cpu, memory} shorthand of model.Resources, which sets both the request
+// and the limit. The CRD only knows the Kubernetes form.
+func workspaceResources(v any) any {
+	m, ok := v.(map[string]any)
+	if !ok || m["requests"] != nil || m["limits"] != nil {
+		return v
+	}
+	list := map[string]any{}
+	for k, q := range m {
+		list[k] = q
+	}
+	if len(list) == 0 {
+		return v
+	}
+	return map[string]any{"requests": list, "limits": list}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+func TestValidateJobEndpoint(t *testing.T) {
+	m, err := localdb.OpenManager(nil, t.TempDir(), "hostdb-validate")
+	if err != nil {
+		t.Fatalf("open manager: %v", err)
+	}
+	defer m.Close()
+	mux := Router(Deps{DB: m.DB})
+
+	do := func(body string) model.ValidationResult {
+		req := httptest.NewRequest(http.MethodPost, "/api/validate/job", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+		}
+		var res model.ValidationResult
+		if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	if res := do(`{"image":"nginx","name":"web"}`); !res.Valid || len(res.Errors) != 0 {
+		t.Fatalf("expected valid, got %+v", res)
+	}
+	res := do(`{"name":"Web!","expose":[{"port":99999}]}`)
+	if res.Valid || len(res.Errors) != 3 {
+		t.Fatalf("expected 3 errors, got %+v", res)
+	}
+}
+
+func TestWorkspaceFieldPath(t *testing.T) {
+	js := jobSpecFromWorkspaceBody(map[string]any{
+		"image": "nginx",
+		"env":   []any{map[string]any{"name": "A", "value": ""}, map[string]any{"name": "S", "valueFrom": map[string]any{}}},
+		"ports": []any{map[string]any{"containerPort": float64(0)}},
+	}, "")
+	got := map[string]bool{}
+	for _, fe := range js.Validate() {
+		got[workspaceFieldPath(fe.Field)] = true
+	}
+	if len(got) != 2 || !got["spec.env.A"] || !got["spec.ports[0].containerPort"] {
+		t.Fatalf("unexpected field paths: %v", got)
+	}
+}
+
+func TestWorkspaceResourcesShorthand(t *testing.T) {
+	got := workspaceResources(map[string]any{"cpu": "500m", "memory": "1Gi"}).(map[string]any)
+	if got["requests"].(map[string]any)["cpu"] != "500m" || got["limits"].(map[string]any)["memory"] != "1Gi" {
+		t.Fatalf("shorthand not expanded: %v", got)
+	}
+	k8s := map[string]any{"limits": map[string]any{"cpu": "1"}}
+	if out := workspaceResources(k8s).(map[string]any); out["requests"] != nil {
+		t.Fatalf("kubernetes form should pass through: %v", out)
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/docxology/GuildNet/internal/settings"
+)
+
+// KubeconfigTestResult is the response of POST /api/deploy/clusters/test-kubeconfig.
+type KubeconfigTestResult struct {
+	Valid         bool   `json:"valid"`
+	Reachable     bool   `json:"reachable"`
+	ServerVersion string `json:"serverVersion,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// testKubeconfig parses kc, applies the API proxy overrides of clusterID (or only the
+// KUBE_PROXY_ADDR fallback when clusterID is empty) and checks the API server the same way
+// imports do. Nothing is persisted.
+func testKubeconfig(setMgr settings.Manager, clusterID, kc string) KubeconfigTestResult {
+	cfg, err := kubeconfigFrom(kc)
+	if err != nil {
+		return KubeconfigTestResult{Error: err.Error()}
+	}
+	out := KubeconfigTestResult{Valid: true}
+	applyClusterAPIProxy(cfg, setMgr, clusterID)
+	if err := healthyCluster(cfg); err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	out.Reachable = true
+	// healthyCluster left a short timeout on cfg; a failed lookup only loses the version
+	if cli, err := kubernetes.NewForConfig(cfg); err == nil {
+		if v, err := cli.Discovery().ServerVersion(); err == nil {
+			out.ServerVersion = v.GitVersion
+		}
+	}
+	return out
+}
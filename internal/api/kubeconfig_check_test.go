@@ -0,0 +1,88 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/settings"
+)
+
+func kubeconfigFor(server string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: c
+  cluster:
+    server: %s
+contexts:
+- name: c
+  context:
+    cluster: c
+    user: u
+current-context: c
+users:
+- name: u
+  user:
+    token: t
+`, server)
+}
+
+func TestTestKubeconfig(t *testing.T) {
+	t.Setenv("KUBE_PROXY_ADDR", "")
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"major":"1","minor":"30","gitVersion":"v1.30.2"}`))
+	}))
+	defer api.Close()
+	m, err := localdb.OpenManager(nil, t.TempDir(), "hostdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	// A stored override for "c1" routes around the kubeconfig's unreachable server
+	if err := (settings.Manager{DB: m.DB}).PutCluster("c1", settings.Cluster{APIProxyURL: api.URL}); err != nil {
+		t.Fatal(err)
+	}
+	mux := Router(Deps{DB: m.DB})
+
+	post := func(body map[string]any) (int, KubeconfigTestResult) {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/deploy/clusters/test-kubeconfig", bytes.NewReader(b))
+		req.RemoteAddr = "127.0.0.1:1234"
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		var res KubeconfigTestResult
+		_ = json.Unmarshal(rr.Body.Bytes(), &res)
+		return rr.Code, res
+	}
+
+	if code, _ := post(map[string]any{}); code != http.StatusBadRequest {
+		t.Fatalf("missing kubeconfig: status=%d, want 400", code)
+	}
+	if code, res := post(map[string]any{"kubeconfig": "not: [yaml"}); code != http.StatusOK || res.Valid || res.Reachable || res.Error == "" {
+		t.Fatalf("malformed: status=%d res=%+v", code, res)
+	}
+	if _, res := post(map[string]any{"kubeconfig": kubeconfigFor(api.URL)}); !res.Valid || !res.Reachable || res.ServerVersion != "v1.30.2" || res.Error != "" {
+		t.Fatalf("reachable: res=%+v", res)
+	}
+	if _, res := post(map[string]any{"kubeconfig": kubeconfigFor("http://127.0.0.1:1")}); !res.Valid || res.Reachable || res.Error == "" {
+		t.Fatalf("unreachable: res=%+v", res)
+	}
+	if _, res := post(map[string]any{"kubeconfig": kubeconfigFor("http://127.0.0.1:1"), "clusterId": "c1"}); !res.Reachable || res.ServerVersion != "v1.30.2" {
+		t.Fatalf("with cluster overrides: res=%+v", res)
+	}
+
+	var clusters []map[string]any
+	_ = m.DB.List("clusters", &clusters)
+	if len(clusters) != 0 {
+		t.Fatalf("test-kubeconfig stored clusters: %v", clusters)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/settings"
+)
+
+// kubeProxyDetectTTL bounds how often candidate kubectl proxy addresses are re-probed.
+const kubeProxyDetectTTL = 30 * time.Second
+
+var kubeProxyCache struct {
+	mu  sync.Mutex
+	key string
+	url string
+	at  time.Time
+}
+
+// kubeProxyCandidates lists kubectl proxy addresses to probe: KUBE_PROXY_ADDR first, then
+// settings.Global.KubeProxyCandidates. Empty means the fallback is not enabled.
+func kubeProxyCandidates(setMgr settings.Manager) []string {
+	var out []string
+	if v := strings.TrimSpace(os.Getenv("KUBE_PROXY_ADDR")); v != "" {
+		out = append(out, v)
+	}
+	if setMgr.DB != nil {
+		var g settings.Global
+		_ = setMgr.GetGlobal(&g)
+		out = append(out, g.KubeProxyCandidates...)
+	}
+	return out
+}
+
+// kubeProxyURL normalizes a host:port or URL candidate to an http(s) base URL and dial address.
+func kubeProxyURL(candidate string) (base, addr string, ok bool) {
+	candidate = strings.TrimSpace(candidate)
+	if candidate == "" {
+		return "", "", false
+	}
+	if !strings.HasPrefix(candidate, "http://") && !strings.HasPrefix(candidate, "https://") {
+		candidate = "http://" + candidate
+	}
+	u, err := url.Parse(candidate)
+	if err != nil || u.Host == "" {
+		return "", "", false
+	}
+	addr = u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "8001")
+	}
+	return u.Scheme + "://" + addr, addr, true
+}
+
+// detectKubeProxy returns the base URL of the first reachable kubectl proxy candidate.
+// Results (including misses) are cached for kubeProxyDetectTTL per candidate list.
+func detectKubeProxy(setMgr settings.Manager) (string, bool) {
+	cands := kubeProxyCandidates(setMgr)
+	if len(cands) == 0 {
+		return "", false
+	}
+	key := strings.Join(cands, ",")
+	kubeProxyCache.mu.Lock()
+	defer kubeProxyCache.mu.Unlock()
+	if kubeProxyCache.key == key && time.Since(kubeProxyCache.at) < kubeProxyDetectTTL {
+		return kubeProxyCache.url, kubeProxyCache.url != ""
+	}
+	found := ""
+	for _, c := range cands {
+		base, addr, ok := kubeProxyURL(c)
+		if !ok {
+			continue
+		}
+		if conn, err := net.DialTimeout("tcp", addr, 500*time.Millisecond); err == nil {
+			_ = conn.Close()
+			found = base
+			break
+		}
+	}
+	kubeProxyCache.key, kubeProxyCache.url, kubeProxyCache.at = key, found, time.Now()
+	return found, found != ""
+}
+
+// ensureProxyFallbackOnTimeout will enable per-cluster local proxy fallback when a timeout is detected.
+// Returns true if it modified settings.
+func ensureProxyFallbackOnTimeout(setMgr settings.Manager, clusterID string) bool {
+	return ensureProxyFallback(setMgr, setMgr, clusterID)
+}
+
+// ensureProxyFallback probes the candidates configured in global and, when one answers,
+// points the cluster's APIProxyURL (stored in clusterMgr) at it. Clusters with an explicit
+// APIProxyURL or DisableAPIProxy are left alone.
+func ensureProxyFallback(global, clusterMgr settings.Manager, clusterID string) bool {
+	var cs settings.Cluster
+	_ = clusterMgr.GetCluster(clusterID, &cs)
+	if cs.DisableAPIProxy || strings.TrimSpace(cs.APIProxyURL) != "" {
+		return false
+	}
+	base, ok := detectKubeProxy(global)
+	if !ok {
+		return false
+	}
+	cs.APIProxyURL = base
+	cs.APIProxyForceHTTP = strings.HasPrefix(base, "http://")
+	_ = clusterMgr.PutCluster(clusterID, cs)
+	return true
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/settings"
+)
+
+func TestEnsureProxyFallbackProbesCandidates(t *testing.T) {
+	t.Setenv("KUBE_PROXY_ADDR", "")
+	// A port nothing listens on, followed by a live "kubectl proxy --port=N".
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadAddr := dead.Addr().String()
+	_ = dead.Close()
+	live, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	liveAddr := live.Addr().String()
+
+	m, err := localdb.OpenManager(nil, t.TempDir(), "kubeproxy")
+	if err != nil {
+		t.Fatalf("open manager: %v", err)
+	}
+	defer m.Close()
+	sm := settings.Manager{DB: m.DB}
+	if err := sm.PutGlobal(settings.Global{KubeProxyCandidates: []string{deadAddr, "http://" + liveAddr}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.PutCluster("c1", settings.Cluster{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ensureProxyFallbackOnTimeout(sm, "c1") {
+		t.Fatalf("expected fallback to be enabled")
+	}
+	var cs settings.Cluster
+	_ = sm.GetCluster("c1", &cs)
+	if cs.APIProxyURL != "http://"+liveAddr || !cs.APIProxyForceHTTP {
+		t.Fatalf("APIProxyURL=%q force=%v, want http://%s", cs.APIProxyURL, cs.APIProxyForceHTTP, liveAddr)
+	}
+	// An explicit APIProxyURL is never overwritten.
+	if ensureProxyFallbackOnTimeout(sm, "c1") {
+		t.Fatalf("fallback should not re-apply once APIProxyURL is set")
+	}
+
+	// Detection is cached: the proxy going away is not noticed until the TTL lapses.
+	_ = live.Close()
+	if got, ok := detectKubeProxy(sm); !ok || got != "http://"+liveAddr {
+		t.Fatalf("expected cached detection, got %q ok=%v", got, ok)
+	}
+}
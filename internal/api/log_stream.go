@@ -0,0 +1,95 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// logHeartbeatInterval is how often an idle workspace log stream sends "event: heartbeat".
+var logHeartbeatInterval = 20 * time.Second
+
+// streamLogLines relays a followed container log as typed SSE events: "log" per line,
+// "heartbeat" while idle, and "eof" (from eof) once the log ends. Returns early without
+// eof when ctx ends or a write fails.
+func streamLogLines(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, logs io.Reader, pod, container string, eof func() model.LogStreamEOF) {
+	lines := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sc := bufio.NewScanner(logs)
+		sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+		for sc.Scan() {
+			select {
+			case lines <- sc.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	heartbeat := time.NewTicker(logHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case line := <-lines:
+			msg := fmt.Sprintf("[%s] %s", pod, strings.TrimSpace(line))
+			err = httpx.SSEEvent(w, "log", model.LogStreamLine{T: model.NowISO(), Msg: msg, Pod: pod, Container: container})
+		case <-heartbeat.C:
+			err = httpx.SSEEvent(w, "heartbeat", map[string]string{"t": model.NowISO()})
+		case <-done:
+			if ctx.Err() == nil {
+				_ = httpx.SSEEvent(w, "eof", eof())
+				flusher.Flush()
+			}
+			return
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// containerLogEOF describes why container's log in pod ended, from a fresh read of the
+// pod. The termination fields stay empty when the pod is gone or the container has not
+// terminated (e.g. the API server closed the stream).
+func containerLogEOF(cli kubernetes.Interface, ns, pod, container string) model.LogStreamEOF {
+	out := model.LogStreamEOF{T: model.NowISO(), Pod: pod, Container: container}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	p, err := cli.CoreV1().Pods(ns).Get(ctx, pod, metav1.GetOptions{})
+	if err != nil {
+		return out
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		if cs.Name != container {
+			continue
+		}
+		t := cs.State.Terminated
+		if t == nil {
+			// Already restarted: the log that ended belongs to the last run
+			t = cs.LastTerminationState.Terminated
+		}
+		if t != nil {
+			out.Reason, out.ExitCode = t.Reason, &t.ExitCode
+		}
+	}
+	if out.Reason == "" && p.Status.Phase == corev1.PodFailed {
+		out.Reason = p.Status.Reason
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// sseFrames splits an SSE body into (event, data) pairs.
+func sseFrames(body string) [][2]string {
+	var out [][2]string
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		var ev, data string
+		for _, ln := range strings.Split(block, "\n") {
+			if v, ok := strings.CutPrefix(ln, "event: "); ok {
+				ev = v
+			} else if v, ok := strings.CutPrefix(ln, "data: "); ok {
+				data = v
+			}
+		}
+		out = append(out, [2]string{ev, data})
+	}
+	return out
+}
+
+func TestStreamLogLinesTypedEvents(t *testing.T) {
+	prev := logHeartbeatInterval
+	logHeartbeatInterval = 20 * time.Millisecond
+	defer func() { logHeartbeatInterval = prev }()
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = io.WriteString(pw, "hello\n")
+		time.Sleep(70 * time.Millisecond) // idle long enough for heartbeats
+		_, _ = io.WriteString(pw, "bye\n")
+		pw.Close()
+	}()
+	rec := httptest.NewRecorder()
+	code := int32(137)
+	streamLogLines(context.Background(), rec, rec, pr, "ws-abc", "workspace", func() model.LogStreamEOF {
+		return model.LogStreamEOF{Pod: "ws-abc", Container: "workspace", Reason: "OOMKilled", ExitCode: &code}
+	})
+
+	frames := sseFrames(rec.Body.String())
+	var logs []model.LogStreamLine
+	heartbeats := 0
+	for _, f := range frames {
+		switch f[0] {
+		case "log":
+			var l model.LogStreamLine
+			if err := json.Unmarshal([]byte(f[1]), &l); err != nil {
+				t.Fatal(err)
+			}
+			logs = append(logs, l)
+		case "heartbeat":
+			heartbeats++
+		}
+	}
+	if len(logs) != 2 || logs[0].Msg != "[ws-abc] hello" || logs[0].Pod != "ws-abc" || logs[0].Container != "workspace" || logs[1].Msg != "[ws-abc] bye" {
+		t.Fatalf("log frames = %+v", logs)
+	}
+	if heartbeats == 0 {
+		t.Fatalf("no heartbeat in %q", rec.Body.String())
+	}
+	last := frames[len(frames)-1]
+	var eof model.LogStreamEOF
+	if last[0] != "eof" || json.Unmarshal([]byte(last[1]), &eof) != nil || eof.Reason != "OOMKilled" || eof.ExitCode == nil || *eof.ExitCode != 137 {
+		t.Fatalf("last frame = %v", last)
+	}
+
+	// A cancelled client gets no eof
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	streamLogLines(ctx, rec, rec, strings.NewReader(""), "p", "c", func() model.LogStreamEOF { return model.LogStreamEOF{} })
+	if strings.Contains(rec.Body.String(), "event: eof") {
+		t.Fatalf("eof after cancel: %q", rec.Body.String())
+	}
+}
+
+func TestContainerLogEOF(t *testing.T) {
+	cli := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws-abc", Namespace: "default"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "workspace",
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+		}}},
+	})
+	eof := containerLogEOF(cli, "default", "ws-abc", "workspace")
+	if eof.Reason != "Error" || eof.ExitCode == nil || *eof.ExitCode != 1 || eof.Pod != "ws-abc" {
+		t.Fatalf("eof = %+v", eof)
+	}
+	if eof := containerLogEOF(cli, "default", "gone", "workspace"); eof.Reason != "" || eof.ExitCode != nil || eof.Pod != "gone" {
+		t.Fatalf("missing pod eof = %+v", eof)
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestWorkspaceProxyAuth(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "basic", Namespace: "default"}, Data: map[string][]byte{"username": []byte("ada"), "password": []byte("s3cret")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tok", Namespace: "default"}, Data: map[string][]byte{"token": []byte("abc")}},
+	)
+
+	if h, err := workspaceProxyAuth(ctx, cli, "default", "c1/none", nil); err != nil || h != "" {
+		t.Fatalf("no annotation: h=%q err=%v", h, err)
+	}
+	h, err := workspaceProxyAuth(ctx, cli, "default", "c1/ws1", map[string]string{proxy.AnnotationAuthSecret: "basic"})
+	if want := "Basic " + base64.StdEncoding.EncodeToString([]byte("ada:s3cret")); err != nil || h != want {
+		t.Fatalf("basic: h=%q err=%v", h, err)
+	}
+	if h, err := workspaceProxyAuth(ctx, cli, "default", "c1/ws2", map[string]string{proxy.AnnotationAuthSecret: "tok"}); err != nil || h != "Bearer abc" {
+		t.Fatalf("bearer: h=%q err=%v", h, err)
+	}
+	if _, err := workspaceProxyAuth(ctx, cli, "default", "c1/ws3", map[string]string{proxy.AnnotationAuthSecret: "missing"}); err == nil {
+		t.Fatalf("expected error for missing secret")
+	}
+
+	// Cached: deleting the secret does not affect lookups within the TTL.
+	_ = cli.CoreV1().Secrets("default").Delete(ctx, "tok", metav1.DeleteOptions{})
+	if h, err := workspaceProxyAuth(ctx, cli, "default", "c1/ws2", map[string]string{proxy.AnnotationAuthSecret: "tok"}); err != nil || h != "Bearer abc" {
+		t.Fatalf("cached bearer: h=%q err=%v", h, err)
+	}
+}
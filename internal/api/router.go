@@ -1,9 +1,10 @@
 package api
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,6 +12,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,10 +22,13 @@ import (
 	"github.com/google/uuid"
 	"nhooyr.io/websocket"
 
+	apiv1alpha1 "github.com/docxology/GuildNet/api/v1alpha1"
 	"github.com/docxology/GuildNet/internal/cluster"
 	"github.com/docxology/GuildNet/internal/httpx"
 	"github.com/docxology/GuildNet/internal/jobs"
+	"github.com/docxology/GuildNet/internal/k8s"
 	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/model"
 	"github.com/docxology/GuildNet/internal/orch"
 	"github.com/docxology/GuildNet/internal/proxy"
 	"github.com/docxology/GuildNet/internal/secrets"
@@ -31,9 +38,12 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -72,7 +82,15 @@ func (d Deps) ensure() Deps {
 	dd := d
 	if dd.Runner == nil {
 		persist := jobs.LocalPersist{DB: db}
-		r := jobs.New(jobs.WithPersist(persist))
+		var retention time.Duration
+		if db != nil {
+			var g settings.Global
+			if err := (settings.Manager{DB: db}).GetGlobal(&g); err == nil {
+				persist.MaxLogBytes = g.MaxJobLogBytes
+				retention = time.Duration(g.SucceededJobRetentionHours) * time.Hour
+			}
+		}
+		r := jobs.New(jobs.WithPersist(persist), jobs.WithSucceededRetention(retention))
 		dd.Runner = r
 	}
 	return dd
@@ -83,6 +101,14 @@ func Router(deps Deps) *http.ServeMux {
 	deps = deps.ensure()
 	mux := http.NewServeMux()
 
+	// tokenOK checks the API token (or loopback when none is configured) regardless of method.
+	tokenOK := func(w http.ResponseWriter, r *http.Request) bool {
+		if tokenAuthorized(r, deps.Token) {
+			return true
+		}
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
 	// Authorization helper for mutating endpoints
 	authOK := func(w http.ResponseWriter, r *http.Request) bool {
 		// Allow all GETs; guard mutating methods
@@ -92,26 +118,7 @@ func Router(deps Deps) *http.ServeMux {
 		if r.Method == http.MethodOptions {
 			return true
 		}
-		tok := strings.TrimSpace(deps.Token)
-		if tok == "" {
-			// No token set: allow only loopback clients
-			host, _, _ := net.SplitHostPort(r.RemoteAddr)
-			ip := net.ParseIP(host)
-			if ip != nil && (ip.IsLoopback() || host == "127.0.0.1" || host == "::1") {
-				return true
-			}
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return false
-		}
-		authz := r.Header.Get("Authorization")
-		if strings.HasPrefix(strings.ToLower(authz), "bearer ") && strings.TrimSpace(authz[7:]) == tok {
-			return true
-		}
-		if r.Header.Get("X-API-Token") == tok {
-			return true
-		}
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return false
+		return tokenOK(w, r)
 	}
 
 	// Settings manager
@@ -134,6 +141,7 @@ func Router(deps Deps) *http.ServeMux {
 				DisableAPIProxy    bool   `json:"disable_api_proxy,omitempty"`
 				PreferPodProxy     bool   `json:"prefer_pod_proxy,omitempty"`
 				UsePortForward     bool   `json:"use_port_forward,omitempty"`
+				ProxyMode          string `json:"proxy_mode,omitempty"`
 				IngressDomain      string `json:"ingress_domain,omitempty"`
 				IngressClassName   string `json:"ingress_class_name,omitempty"`
 				WorkspaceTLSSecret string `json:"workspace_tls_secret,omitempty"`
@@ -157,7 +165,11 @@ func Router(deps Deps) *http.ServeMux {
 			}
 			rec := map[string]any{"id": id, "name": name, "state": "imported"}
 			_ = deps.DB.Put("clusters", id, rec)
-			_ = deps.DB.Put("credentials", fmt.Sprintf("cl:%s:kubeconfig", id), map[string]any{"value": body.Cluster.Kubeconfig})
+			if err := StoreClusterKubeconfig(deps.DB, deps.Secrets, id, body.Cluster.Kubeconfig); err != nil {
+				_ = deps.DB.Delete("clusters", id)
+				httpx.JSONError(w, http.StatusInternalServerError, "store kubeconfig failed", "store_failed", err.Error())
+				return
+			}
 			// Attempt to pre-warm per-cluster clients via registry (if available).
 			// If pre-warm fails, remove persisted records and return an error to the caller.
 			if deps.Registry != nil {
@@ -207,6 +219,7 @@ func Router(deps Deps) *http.ServeMux {
 				DisableAPIProxy:    body.Cluster.DisableAPIProxy,
 				PreferPodProxy:     body.Cluster.PreferPodProxy,
 				UsePortForward:     body.Cluster.UsePortForward,
+				ProxyMode:          settings.ProxyMode(body.Cluster.ProxyMode),
 				IngressDomain:      body.Cluster.IngressDomain,
 				IngressClassName:   body.Cluster.IngressClassName,
 				WorkspaceTLSSecret: body.Cluster.WorkspaceTLSSecret,
@@ -289,7 +302,21 @@ func Router(deps Deps) *http.ServeMux {
 		if r.Method == http.MethodPut {
 			var g settings.Global
 			_ = json.NewDecoder(r.Body).Decode(&g)
-			_ = setMgr.PutGlobal(g)
+			fieldErrs := map[string]string{}
+			if err := settings.ValidateNamespace(g.DefaultNamespace); err != nil {
+				fieldErrs["default_namespace"] = err.Error()
+			}
+			if _, err := settings.ParseProxyMode(string(g.ProxyMode)); err != nil {
+				fieldErrs["proxy_mode"] = err.Error()
+			}
+			if len(fieldErrs) > 0 {
+				httpx.JSONFieldErrors(w, http.StatusBadRequest, "invalid global settings", "invalid_settings", fieldErrs)
+				return
+			}
+			if err := setMgr.PutGlobal(g); errors.Is(err, settings.ErrNewerSchema) {
+				httpx.JSONError(w, http.StatusConflict, "settings were written by a newer version", "settings_schema_newer", err.Error())
+				return
+			}
 			if deps.OnSettingsChanged != nil {
 				deps.OnSettingsChanged("global")
 			}
@@ -330,9 +357,32 @@ func Router(deps Deps) *http.ServeMux {
 		if r.Method == http.MethodPut {
 			var cs settings.Cluster
 			_ = json.NewDecoder(r.Body).Decode(&cs)
-			_ = sm.PutCluster(id, cs)
+			fieldErrs := map[string]string{}
+			if err := settings.ValidateNamespace(cs.Namespace); err != nil {
+				fieldErrs["namespace"] = err.Error()
+			}
+			if _, err := proxy.CertPoolFromPEM(cs.UpstreamCA); err != nil {
+				fieldErrs["upstream_ca"] = err.Error()
+			}
+			if _, err := settings.ParseProxyMode(string(cs.ProxyMode)); err != nil {
+				fieldErrs["proxy_mode"] = err.Error()
+			}
+			for field, list := range map[string]map[string]string{"workspace_default_requests": cs.WorkspaceDefaultRequests, "workspace_default_limits": cs.WorkspaceDefaultLimits} {
+				for name, q := range list {
+					if _, err := resource.ParseQuantity(strings.TrimSpace(q)); err != nil {
+						fieldErrs[field+"."+name] = err.Error()
+					}
+				}
+			}
+			if len(fieldErrs) > 0 {
+				httpx.JSONFieldErrors(w, http.StatusBadRequest, "invalid cluster settings", "invalid_settings", fieldErrs)
+				return
+			}
 			// Persist cluster settings and notify runtime hooks
-			_ = sm.PutCluster(id, cs)
+			if err := sm.PutCluster(id, cs); errors.Is(err, settings.ErrNewerSchema) {
+				httpx.JSONError(w, http.StatusConflict, "settings were written by a newer version", "settings_schema_newer", err.Error())
+				return
+			}
 			if deps.OnSettingsChanged != nil {
 				deps.OnSettingsChanged("cluster:" + id)
 			}
@@ -340,6 +390,13 @@ func Router(deps Deps) *http.ServeMux {
 			// can pick up runtime preferences without access to host localdb.
 			if inst.K8s != nil {
 				cm := map[string]string{"workspace_lb_enabled": fmt.Sprintf("%v", cs.WorkspaceLBEnabled)}
+				// Default workspace resources travel as JSON objects; empty means none
+				for k, v := range map[string]map[string]string{"workspace_default_requests": cs.WorkspaceDefaultRequests, "workspace_default_limits": cs.WorkspaceDefaultLimits} {
+					if len(v) > 0 {
+						b, _ := json.Marshal(v)
+						cm[k] = string(b)
+					}
+				}
 				ns := "guildnet-system"
 				// Ensure namespace exists
 				_, _ = inst.K8s.K.CoreV1().Namespaces().Get(r.Context(), ns, metav1.GetOptions{})
@@ -359,9 +416,130 @@ func Router(deps Deps) *http.ServeMux {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	})
 
+	// Registry diagnostics: GET /api/admin/registry, POST /api/admin/registry/{id}/warm
+	mux.HandleFunc("/api/admin/registry", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if deps.Registry == nil {
+			httpx.JSONError(w, http.StatusServiceUnavailable, "registry unavailable", "no_registry")
+			return
+		}
+		httpx.JSON(w, http.StatusOK, map[string]any{"clusters": deps.Registry.List()})
+	})
+	mux.HandleFunc("/api/admin/registry/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/registry/"), "/"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] != "warm" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !tokenOK(w, r) {
+			return
+		}
+		if deps.Registry == nil {
+			httpx.JSONError(w, http.StatusServiceUnavailable, "registry unavailable", "no_registry")
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+		st, err := deps.Registry.Warm(ctx, parts[0])
+		if err != nil {
+			httpx.JSONError(w, http.StatusBadGateway, "warm failed", "warm_failed", st)
+			return
+		}
+		httpx.JSON(w, http.StatusOK, st)
+	})
+
+	// Orphan GC: POST /api/admin/gc?cluster={id}[&namespace=ns][&dry_run=1] removes managed
+	// Deployments/Services/PVCs whose Workspace is gone (leftovers of failed creates).
+	mux.HandleFunc("/api/admin/gc", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !tokenOK(w, r) {
+			return
+		}
+		if deps.Registry == nil {
+			httpx.JSONError(w, http.StatusServiceUnavailable, "registry unavailable", "no_registry")
+			return
+		}
+		q := r.URL.Query()
+		clusterID := strings.TrimSpace(q.Get("cluster"))
+		if clusterID == "" {
+			httpx.JSONError(w, http.StatusBadRequest, "cluster is required", "missing_cluster")
+			return
+		}
+		dryRun := q.Get("dry_run") == "1" || q.Get("dry_run") == "true"
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		inst, err := deps.Registry.Get(ctx, clusterID)
+		if err != nil || inst == nil || inst.K8s == nil || inst.K8s.K == nil || inst.Dyn == nil {
+			httpx.JSONError(w, http.StatusServiceUnavailable, "kubernetes clients not available for cluster", "no_k8s_clients")
+			return
+		}
+		ns := strings.TrimSpace(q.Get("namespace"))
+		if ns == "" {
+			var cs settings.Cluster
+			_ = settings.Manager{DB: inst.DB}.GetCluster(clusterID, &cs)
+			var g settings.Global
+			_ = setMgr.GetGlobal(&g)
+			ns = settings.ResolveNamespace(cs, g)
+		} else if err := settings.ValidateNamespace(ns); err != nil {
+			httpx.JSONError(w, http.StatusBadRequest, err.Error(), "invalid_namespace")
+			return
+		}
+		orphans, err := findOrphans(ctx, inst.K8s.K, inst.Dyn, ns)
+		if err != nil {
+			httpx.JSONError(w, http.StatusBadGateway, "list managed resources failed", "gc_list_failed", err.Error())
+			return
+		}
+		if !dryRun {
+			for i := range orphans {
+				o := &orphans[i]
+				if err := deleteOrphan(ctx, inst.K8s.K, *o); err != nil {
+					o.Error = err.Error()
+					continue
+				}
+				o.Deleted = true
+				diff, _ := json.Marshal(map[string]any{"cluster": clusterID, "namespace": o.Namespace, "workspace": o.Workspace})
+				orch.AppendAudit(deps.DB, "admin", "gc.delete", o.Kind, clusterID+"/"+o.Namespace+"/"+o.Name, string(diff))
+			}
+		}
+		httpx.JSON(w, http.StatusOK, map[string]any{"cluster": clusterID, "namespace": ns, "dryRun": dryRun, "orphans": orphans})
+	})
+
+	// Validate a job/workspace spec without creating anything; same rules as the create paths
+	mux.HandleFunc("/api/validate/job", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var spec model.JobSpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			httpx.JSONError(w, http.StatusBadRequest, "invalid json", "bad_json", err.Error())
+			return
+		}
+		var g settings.Global
+		_ = setMgr.GetGlobal(&g)
+		errs := spec.ValidateWith(g.ValidationOptions())
+		if errs == nil {
+			errs = []model.FieldError{}
+		}
+		httpx.JSON(w, http.StatusOK, model.ValidationResult{Valid: len(errs) == 0, Errors: errs})
+	})
+
 	// Jobs: list and detail
 	mux.HandleFunc("/api/jobs", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
+			st := deps.Runner.Stats()
+			w.Header().Set("X-Jobs-Running", strconv.Itoa(st.Running))
+			w.Header().Set("X-Jobs-Queued", strconv.Itoa(st.Queued))
 			_ = json.NewEncoder(w).Encode(deps.Runner.List())
 			return
 		}
@@ -380,7 +558,11 @@ func Router(deps Deps) *http.ServeMux {
 				return
 			}
 			h := orch.HandlerFor(req.Kind, orch.Deps{DB: deps.DB, Secrets: deps.Secrets})
-			jobID, _ := deps.Runner.Submit(req.Kind, req.Spec, h)
+			jobID, err := deps.Runner.Submit(req.Kind, req.Spec, h)
+			if err != nil {
+				jobSubmitError(w, err)
+				return
+			}
 			w.WriteHeader(http.StatusAccepted)
 			_ = json.NewEncoder(w).Encode(map[string]any{"jobId": jobID})
 			return
@@ -393,6 +575,10 @@ func Router(deps Deps) *http.ServeMux {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
+		if id == "stats" && r.Method == http.MethodGet {
+			httpx.JSON(w, http.StatusOK, deps.Runner.Stats())
+			return
+		}
 		if r.Method == http.MethodGet {
 			rec := deps.Runner.Get(id)
 			if rec == nil {
@@ -413,6 +599,26 @@ func Router(deps Deps) *http.ServeMux {
 				_, _ = w.Write([]byte(`{"ok":true}`))
 				return
 			}
+			if action == "retry" {
+				rec := deps.Runner.Get(id)
+				if rec == nil {
+					httpx.JSONError(w, http.StatusNotFound, "job not found", "not_found")
+					return
+				}
+				h := orch.HandlerFor(rec.Kind, orch.Deps{DB: deps.DB, Secrets: deps.Secrets})
+				jobID, err := deps.Runner.Retry(id, h)
+				switch {
+				case errors.Is(err, jobs.ErrJobNotFound):
+					httpx.JSONError(w, http.StatusNotFound, "job not found", "not_found")
+				case errors.Is(err, jobs.ErrNotRetriable):
+					httpx.JSONError(w, http.StatusConflict, "only failed, retriable jobs can be retried, once", "not_retriable")
+				case err != nil:
+					jobSubmitError(w, err)
+				default:
+					httpx.JSON(w, http.StatusAccepted, map[string]any{"jobId": jobID, "retryOf": id})
+				}
+				return
+			}
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
@@ -448,12 +654,56 @@ func Router(deps Deps) *http.ServeMux {
 			<-ctx.Done()
 			_ = c.Close(websocket.StatusNormalClosure, "bye")
 		}()
-		for e := range ch {
+		// Replay the stored (possibly trimmed) log first so the stream starts from the same
+		// content as /api/jobs-logs, then follow live events after the last replayed one
+		var last int64
+		if deps.DB != nil {
+			b, _ := deps.DB.ReadLog("joblogs", id)
+			for _, line := range bytes.Split(b, []byte("\n")) {
+				var e jobs.LogEvent
+				if len(line) == 0 || json.Unmarshal(line, &e) != nil {
+					continue
+				}
+				if werr := c.Write(ctx, websocket.MessageText, line); werr != nil {
+					return
+				}
+				if e.Step != jobs.TruncatedStep && e.Seq > last {
+					last = e.Seq
+				}
+			}
+		}
+		send := func(e jobs.LogEvent) bool {
+			if e.Seq <= last {
+				return true
+			}
 			b, _ := json.Marshal(e)
-			if werr := c.Write(ctx, websocket.MessageText, b); werr != nil {
+			return c.Write(ctx, websocket.MessageText, b) == nil
+		}
+		// The subscription is closed when the job finishes. A job that finished (or is
+		// unknown) by now only has the events buffered since subscribing left to send.
+		done := false
+		if rec := deps.Runner.Get(id); rec == nil || rec.Done() {
+			done = true
+		}
+		for {
+			var e jobs.LogEvent
+			var ok bool
+			if done {
+				select {
+				case e, ok = <-ch:
+				default:
+				}
+			} else {
+				e, ok = <-ch
+			}
+			if !ok {
 				break
 			}
+			if !send(e) {
+				return
+			}
 		}
+		_ = c.Close(websocket.StatusNormalClosure, "job finished")
 	})
 
 	// Audit list
@@ -471,6 +721,135 @@ func Router(deps Deps) *http.ServeMux {
 		_ = json.NewEncoder(w).Encode(items)
 	})
 
+	// Workspace templates: GET/POST /api/templates, GET/DELETE /api/templates/{name}.
+	// POST upserts by name; templates are applied with ?template= on workspace create.
+	mux.HandleFunc("/api/templates", func(w http.ResponseWriter, r *http.Request) {
+		if deps.DB == nil {
+			httpx.JSONError(w, http.StatusServiceUnavailable, "local database unavailable", "no_db")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			items := []model.WorkspaceTemplate{}
+			_ = deps.DB.List("templates", &items)
+			sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+			httpx.JSON(w, http.StatusOK, items)
+		case http.MethodPost:
+			if !tokenOK(w, r) {
+				return
+			}
+			var t model.WorkspaceTemplate
+			if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+				httpx.JSONError(w, http.StatusBadRequest, "invalid json", "bad_json", err.Error())
+				return
+			}
+			t.Name = strings.TrimSpace(t.Name)
+			if errs := t.Validate(); len(errs) > 0 {
+				fields := map[string]string{}
+				for _, fe := range errs {
+					fields[fe.Field] = fe.Message
+				}
+				httpx.JSONFieldErrors(w, http.StatusBadRequest, "invalid template", "invalid_template", fields)
+				return
+			}
+			status := http.StatusCreated
+			t.UpdatedAt = model.NowISO()
+			t.CreatedAt = t.UpdatedAt
+			var prev model.WorkspaceTemplate
+			if err := deps.DB.Get("templates", t.Name, &prev); err == nil {
+				status = http.StatusOK
+				t.CreatedAt = prev.CreatedAt
+			}
+			if err := deps.DB.Put("templates", t.Name, t); err != nil {
+				httpx.JSONError(w, http.StatusInternalServerError, "save template failed", "save_failed", err.Error())
+				return
+			}
+			httpx.JSON(w, status, t)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/templates/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/templates/"), "/")
+		if name == "" || strings.Contains(name, "/") || deps.DB == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			var t model.WorkspaceTemplate
+			if err := deps.DB.Get("templates", name, &t); err != nil {
+				httpx.JSONError(w, http.StatusNotFound, "template not found", "template_not_found")
+				return
+			}
+			httpx.JSON(w, http.StatusOK, t)
+		case http.MethodDelete:
+			if !tokenOK(w, r) {
+				return
+			}
+			_ = deps.DB.Delete("templates", name)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Deployable image presets for the launch form (built-in list until some are stored)
+	mux.HandleFunc("/api/images/presets", imagePresetsHandler(deps))
+
+	// clusterStatus checks reachability of a registered cluster for /api/health and
+	// ?withHealth=1 listings: status ok|error|unknown plus code/error/note.
+	clusterStatus := func(ctx context.Context, id string) map[string]any {
+		kc, ok := readClusterKubeconfig(deps.DB, deps.Secrets, id)
+		st := map[string]any{"status": "unknown"}
+		if !ok {
+			st["code"] = "no_kubeconfig"
+			return st
+		}
+		// Prefer registry-provided client (tsnet Dial) if available
+		if deps.Registry != nil {
+			if inst, err := deps.Registry.Get(ctx, id); err == nil && inst != nil && inst.K8s != nil {
+				cfg2 := inst.K8s.Config()
+				if err2 := healthyCluster(cfg2); err2 == nil {
+					st["status"] = "ok"
+					return st
+				}
+			}
+		}
+		cfg, err := kubeconfigFrom(kc)
+		if err != nil {
+			st["status"] = "error"
+			st["code"] = "bad_kubeconfig"
+			st["error"] = err.Error()
+			return st
+		}
+		// Apply per-cluster overrides and fallback to local proxy
+		applyClusterAPIProxy(cfg, setMgr, id)
+		if err := healthyCluster(cfg); err == nil {
+			st["status"] = "ok"
+		} else {
+			// Auto-heal: on timeout, try enabling local proxy fallback then retry once
+			if isTimeoutErr(err) && ensureProxyFallbackOnTimeout(setMgr, id) {
+				applyClusterAPIProxy(cfg, setMgr, id)
+				if err2 := healthyCluster(cfg); err2 == nil {
+					st["status"] = "ok"
+					st["note"] = "proxy_fallback_enabled"
+				} else {
+					st["status"] = "error"
+					st["code"] = "cluster_unreachable"
+					st["error"] = err2.Error()
+				}
+			} else {
+				st["status"] = "error"
+				st["code"] = "cluster_unreachable"
+				st["error"] = err.Error()
+			}
+		}
+		return st
+	}
+	healthCache := newClusterHealthCache(clusterHealthTTL)
+	rbacStores := newClusterRBAC(deps.DB)
+
 	// Health summary
 	mux.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -497,52 +876,11 @@ func Router(deps Deps) *http.ServeMux {
 			arrCL := make([]any, 0, len(cls))
 			for _, c := range cls {
 				id := fmt.Sprint(c["id"])
-				name := fmt.Sprint(c["name"]) // include name for UI
-				kc, ok := readClusterKubeconfig(deps.DB, deps.Secrets, id)
-				st := map[string]any{"id": id, "name": name, "status": "unknown"}
-				if !ok {
-					st["code"] = "no_kubeconfig"
-				} else {
-					// Prefer registry-provided client (tsnet Dial) if available
-					usedRegistry := false
-					if deps.Registry != nil {
-						if inst, err := deps.Registry.Get(r.Context(), id); err == nil && inst != nil && inst.K8s != nil {
-							cfg2 := inst.K8s.Config()
-							if err2 := healthyCluster(cfg2); err2 == nil {
-								st["status"] = "ok"
-								usedRegistry = true
-							}
-						}
-					}
-					if !usedRegistry {
-						if cfg, err := kubeconfigFrom(kc); err == nil {
-							// Apply per-cluster overrides and fallback to local proxy
-							applyClusterAPIProxy(cfg, setMgr, id)
-							if err := healthyCluster(cfg); err == nil {
-								st["status"] = "ok"
-							} else {
-								// Auto-heal: on timeout, try enabling local proxy fallback then retry once
-								if isTimeoutErr(err) && ensureProxyFallbackOnTimeout(setMgr, id) {
-									applyClusterAPIProxy(cfg, setMgr, id)
-									if err2 := healthyCluster(cfg); err2 == nil {
-										st["status"] = "ok"
-										st["note"] = "proxy_fallback_enabled"
-									} else {
-										st["status"] = "error"
-										st["code"] = "cluster_unreachable"
-										st["error"] = err2.Error()
-									}
-								} else {
-									st["status"] = "error"
-									st["code"] = "cluster_unreachable"
-									st["error"] = err.Error()
-								}
-							}
-						} else {
-							st["status"] = "error"
-							st["code"] = "bad_kubeconfig"
-							st["error"] = err.Error()
-						}
+				// Full checks refresh the cache used by ?withHealth=1 listings
+				st := map[string]any{"id": id, "name": fmt.Sprint(c["name"])} // include name for UI
+				for k, v := range healthCache.put(id, clusterStatus(r.Context(), id)) {
+					if k != "checkedAt" {
+						st[k] = v
 					}
 				}
 				arrCL = append(arrCL, st)
@@ -584,7 +922,11 @@ func Router(deps Deps) *http.ServeMux {
 				_ = deps.DB.Put("headscales", id, rec)
 			}
 			h := orch.HandlerFor("headscale.create", orch.Deps{DB: deps.DB, Secrets: deps.Secrets})
-			jobID, _ := deps.Runner.Submit("headscale.create", rec, h)
+			jobID, err := deps.Runner.Submit("headscale.create", rec, h)
+			if err != nil {
+				jobSubmitError(w, err)
+				return
+			}
 			w.WriteHeader(http.StatusAccepted)
 			_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "jobId": jobID})
 			return
@@ -656,11 +998,10 @@ func Router(deps Deps) *http.ServeMux {
 					http.Error(w, "missing value", http.StatusBadRequest)
 					return
 				}
-				enc := body.Value
-				if deps.Secrets != nil {
-					if v, err := deps.Secrets.Encrypt(body.Value); err == nil {
-						enc = v
-					}
+				enc, encrypted, err := deps.Secrets.Seal(body.Value)
+				if err != nil {
+					httpx.JSONError(w, http.StatusInternalServerError, "encrypt preauth key failed", "encrypt_failed", err.Error())
+					return
 				}
 				cred := map[string]any{
 					"id":        uuid.NewString(),
@@ -668,6 +1009,7 @@ func Router(deps Deps) *http.ServeMux {
 					"scopeId":   id,
 					"kind":      "headscale.preauth",
 					"value":     enc,
+					"encrypted": encrypted,
 					"rotatedAt": time.Now().UTC().Format(time.RFC3339),
 				}
 				if deps.DB != nil {
@@ -706,7 +1048,11 @@ func Router(deps Deps) *http.ServeMux {
 			}
 			kind := "headscale." + action
 			h := orch.HandlerFor(kind, orch.Deps{DB: deps.DB, Secrets: deps.Secrets})
-			jobID, _ := deps.Runner.Submit(kind, map[string]string{"id": id}, h)
+			jobID, err := deps.Runner.Submit(kind, map[string]string{"id": id}, h)
+			if err != nil {
+				jobSubmitError(w, err)
+				return
+			}
 			w.WriteHeader(http.StatusAccepted)
 			_ = json.NewEncoder(w).Encode(map[string]any{"jobId": jobID})
 			return
@@ -722,6 +1068,17 @@ func Router(deps Deps) *http.ServeMux {
 			if deps.DB != nil {
 				_ = deps.DB.List("clusters", &items)
 			}
+			// ?withHealth=1 attaches cached reachability (checked now when stale or missing)
+			if v := r.URL.Query().Get("withHealth"); v == "1" || v == "true" {
+				ids := make([]string, 0, len(items))
+				for _, it := range items {
+					ids = append(ids, fmt.Sprint(it["id"]))
+				}
+				health := healthCache.lookup(ids, func(id string) map[string]any { return clusterStatus(r.Context(), id) })
+				for i, id := range ids {
+					items[i]["health"] = health[id]
+				}
+			}
 			_ = json.NewEncoder(w).Encode(items)
 			return
 		case http.MethodPost:
@@ -745,7 +1102,11 @@ func Router(deps Deps) *http.ServeMux {
 				_ = deps.DB.Put("clusters", id, rec)
 			}
 			h := orch.HandlerFor("cluster.create", orch.Deps{DB: deps.DB, Secrets: deps.Secrets})
-			jobID, _ := deps.Runner.Submit("cluster.create", rec, h)
+			jobID, err := deps.Runner.Submit("cluster.create", rec, h)
+			if err != nil {
+				jobSubmitError(w, err)
+				return
+			}
 			w.WriteHeader(http.StatusAccepted)
 			_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "jobId": jobID})
 			return
@@ -759,6 +1120,45 @@ func Router(deps Deps) *http.ServeMux {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
+		if id == "test-kubeconfig" {
+			// Dry run before an import: parse and probe a kubeconfig without storing it
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			if !authOK(w, r) {
+				return
+			}
+			var body struct {
+				Kubeconfig string `json:"kubeconfig"`
+				ClusterID  string `json:"clusterId"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				httpx.JSONError(w, http.StatusBadRequest, "invalid json", "bad_json", err.Error())
+				return
+			}
+			if strings.TrimSpace(body.Kubeconfig) == "" {
+				httpx.JSONError(w, http.StatusBadRequest, "missing kubeconfig", "missing_kubeconfig")
+				return
+			}
+			httpx.JSON(w, http.StatusOK, testKubeconfig(setMgr, strings.TrimSpace(body.ClusterID), body.Kubeconfig))
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Query().Get("action") == "kubeconfig" {
+			// Decrypted credentials: require the token even though this is a GET
+			if !tokenOK(w, r) {
+				return
+			}
+			kc, ok := readClusterKubeconfig(deps.DB, deps.Secrets, id)
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/x-yaml")
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = io.WriteString(w, kc)
+			return
+		}
 		if r.Method == http.MethodGet {
 			var rec map[string]any
 			if deps.DB == nil || deps.DB.Get("clusters", id, &rec) != nil {
@@ -801,25 +1201,15 @@ func Router(deps Deps) *http.ServeMux {
 					httpx.JSONError(w, http.StatusBadRequest, "invalid kubeconfig", "bad_kubeconfig", err.Error())
 					return
 				}
-				enc := body.Kubeconfig
-				encrypted := false
-				if deps.Secrets != nil {
-					if v, err := deps.Secrets.Encrypt(body.Kubeconfig); err == nil {
-						enc = v
-						encrypted = true
+				if deps.DB != nil {
+					if err := StoreClusterKubeconfig(deps.DB, deps.Secrets, id, body.Kubeconfig); err != nil {
+						httpx.JSONError(w, http.StatusInternalServerError, "store kubeconfig failed", "store_failed", err.Error())
+						return
 					}
 				}
-				cred := map[string]any{
-					"id":        uuid.NewString(),
-					"scopeType": "cluster",
-					"scopeId":   id,
-					"kind":      "cluster.kubeconfig",
-					"value":     enc,
-					"encrypted": encrypted,
-					"rotatedAt": time.Now().UTC().Format(time.RFC3339),
-				}
-				if deps.DB != nil {
-					_ = deps.DB.Put("credentials", fmt.Sprintf("cl:%s:kubeconfig", id), cred)
+				// Rebuild per-cluster clients (and the proxy's config) from the new kubeconfig.
+				if deps.Registry != nil {
+					deps.Registry.Invalidate(id)
 				}
 				// Mark cluster ready if reachable
 				if cfg, err := kubeconfigFrom(body.Kubeconfig); err == nil {
@@ -843,9 +1233,9 @@ func Router(deps Deps) *http.ServeMux {
 				out["created_at"] = time.Now().UTC().Format(time.RFC3339)
 				out["creator"] = map[string]any{"host": r.Host, "user": ""}
 
-				// Hostapp/ui base URL: prefer X-Forwarded-Proto/Host if present, otherwise infer from request
+				// Hostapp/ui base URL: prefer a trusted peer's X-Forwarded-Proto, otherwise infer from request
 				scheme := "http"
-				if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+				if r.TLS != nil || (proxy.TrustedPeer(r) && strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")) {
 					scheme = "https"
 				}
 				host := r.Host
@@ -891,6 +1281,9 @@ func Router(deps Deps) *http.ServeMux {
 				if cs.UsePortForward {
 					clusterRec["use_port_forward"] = true
 				}
+				if m := cs.EffectiveProxyMode(); m != settings.ProxyModeAuto {
+					clusterRec["proxy_mode"] = string(m)
+				}
 				if cs.IngressDomain != "" {
 					clusterRec["ingress_domain"] = cs.IngressDomain
 				}
@@ -968,7 +1361,11 @@ func Router(deps Deps) *http.ServeMux {
 			}
 			kind := "cluster." + action
 			h := orch.HandlerFor(kind, orch.Deps{DB: deps.DB, Secrets: deps.Secrets})
-			jobID, _ := deps.Runner.Submit(kind, map[string]string{"id": id}, h)
+			jobID, err := deps.Runner.Submit(kind, map[string]string{"id": id}, h)
+			if err != nil {
+				jobSubmitError(w, err)
+				return
+			}
 			w.WriteHeader(http.StatusAccepted)
 			_ = json.NewEncoder(w).Encode(map[string]any{"jobId": jobID})
 			return
@@ -985,8 +1382,22 @@ func Router(deps Deps) *http.ServeMux {
 		_ = json.NewEncoder(w).Encode(map[string]any{})
 	})
 
+	// Cluster-scoped proxies are built per request, so their stream counts and learned
+	// upstream schemes are kept here, one limiter and one scheme cache per cluster
+	var proxyStreams sync.Map // clusterID -> *proxy.StreamLimiter
+	streamsFor := func(clusterID string) *proxy.StreamLimiter {
+		v, _ := proxyStreams.LoadOrStore(clusterID, proxy.NewStreamLimiter())
+		return v.(*proxy.StreamLimiter)
+	}
+	var proxySchemes sync.Map // clusterID -> *proxy.SchemeCache
+	schemesFor := func(clusterID string) *proxy.SchemeCache {
+		v, _ := proxySchemes.LoadOrStore(clusterID, proxy.NewSchemeCache())
+		return v.(*proxy.SchemeCache)
+	}
+
 	// Per-cluster scoped APIs: /api/cluster/:id/servers, /workspaces, etc.
-	mux.HandleFunc("/api/cluster/", func(w http.ResponseWriter, r *http.Request) {
+	// Workspace creates honour Idempotency-Key so clients can retry them safely.
+	mux.Handle("/api/cluster/", httpx.Idempotent(deps.DB, 0, isWorkspaceCreate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := strings.TrimPrefix(r.URL.Path, "/api/cluster/")
 		parts := strings.Split(strings.Trim(path, "/"), "/")
 		if len(parts) == 0 || parts[0] == "" {
@@ -1121,18 +1532,16 @@ func Router(deps Deps) *http.ServeMux {
 						if cli == nil || dyn == nil {
 							// attempt to detect a timeout on the initial client creation/health
 							// and enable proxy fallback once to recover.
-							if isLocalKubeProxyAvailable() {
-								if ensureProxyFallbackOnTimeout(setMgrLocal, clusterID) {
-									// Re-apply proxy settings and try to rebuild clients using local proxy
-									applyClusterAPIProxy(cfg2, setMgrLocal, clusterID)
-									if c2, e2 := kubernetes.NewForConfig(cfg2); e2 == nil {
-										cli = c2
-										log.Printf("cluster: rebuilt kubernetes client after enabling proxy fallback for id=%s", clusterID)
-									}
-									if d2, e2 := dynamic.NewForConfig(cfg2); e2 == nil {
-										dyn = d2
-										log.Printf("cluster: rebuilt dynamic client after enabling proxy fallback for id=%s", clusterID)
-									}
+							if ensureProxyFallback(setMgr, setMgrLocal, clusterID) {
+								// Re-apply proxy settings and try to rebuild clients using local proxy
+								applyClusterAPIProxy(cfg2, setMgrLocal, clusterID)
+								if c2, e2 := kubernetes.NewForConfig(cfg2); e2 == nil {
+									cli = c2
+									log.Printf("cluster: rebuilt kubernetes client after enabling proxy fallback for id=%s", clusterID)
+								}
+								if d2, e2 := dynamic.NewForConfig(cfg2); e2 == nil {
+									dyn = d2
+									log.Printf("cluster: rebuilt dynamic client after enabling proxy fallback for id=%s", clusterID)
 								}
 							}
 						}
@@ -1156,9 +1565,61 @@ func Router(deps Deps) *http.ServeMux {
 		}
 		// Fetch per-cluster settings to derive default namespace using (possibly) per-cluster DB
 		_ = setMgrLocal.GetCluster(clusterID, &cs)
-		defaultNS := strings.TrimSpace(cs.Namespace)
-		if defaultNS == "" {
-			defaultNS = "default"
+		var gset settings.Global
+		_ = setMgr.GetGlobal(&gset)
+		defaultNS := settings.ResolveNamespace(cs, gset)
+		// Class discovery for the create-workspace form: /api/cluster/{id}/storageclasses, /ingressclasses
+		if len(parts) == 2 && (parts[1] == "storageclasses" || parts[1] == "ingressclasses") {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			var (
+				out any
+				err error
+			)
+			if parts[1] == "storageclasses" {
+				out, err = listStorageClasses(r.Context(), cli)
+			} else {
+				out, err = listIngressClasses(r.Context(), cli)
+			}
+			if err != nil {
+				httpx.JSONError(w, http.StatusBadGateway, "list "+parts[1]+" failed", "list_failed", err.Error())
+				return
+			}
+			httpx.JSON(w, http.StatusOK, out)
+			return
+		}
+		// Cluster-wide diagnostics: /api/cluster/{id}/events lists recent Warning events in the
+		// default namespace, or everywhere with ?allNamespaces=1; ?since= and ?limit= narrow it
+		if len(parts) == 2 && parts[1] == "events" {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			q := r.URL.Query()
+			since, err := parseEventsSince(q.Get("since"), time.Now())
+			if err != nil {
+				httpx.JSONError(w, http.StatusBadRequest, err.Error(), "invalid_since")
+				return
+			}
+			limit := maxClusterEvents
+			if v := q.Get("limit"); v != "" {
+				if n, err := strconv.Atoi(v); err == nil && n > 0 && n < limit {
+					limit = n
+				}
+			}
+			ns := defaultNS
+			if v := q.Get("allNamespaces"); v == "1" || v == "true" {
+				ns = metav1.NamespaceAll
+			}
+			evs, err := listClusterEvents(r.Context(), cli, ns, since, limit)
+			if err != nil {
+				httpx.JSONError(w, http.StatusBadGateway, "list events failed", "list_failed", err.Error())
+				return
+			}
+			httpx.JSON(w, http.StatusOK, evs)
+			return
 		}
 		// Proxy: /api/cluster/{id}/proxy/server/{name}/...
 		if len(parts) >= 3 && parts[1] == "proxy" && parts[2] == "server" {
@@ -1166,29 +1627,78 @@ func Router(deps Deps) *http.ServeMux {
 				w.WriteHeader(http.StatusBadRequest)
 				return
 			}
-			name := parts[3]
+			// The server segment is "{name}" for the default port or "{name}:{port}" to pick a
+			// declared port by name or number
+			seg := parts[3]
+			name, portSel := splitServerPort(seg)
 			restPath := "/"
 			if len(parts) > 4 {
 				restPath = "/" + strings.Join(parts[4:], "/")
 			}
-			// Determine service port (first port as default)
+			// Enforce per-workspace path rules (annotations) before any forwarding path is chosen,
+			// resolve credentials to inject when the workspace references a proxy-auth secret, and
+			// note whether the app needs its responses rewritten (strip-prefix)
+			var authz string
+			var stripPrefix bool
+			{
+				gvr := schema.GroupVersionResource{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}
+				if ws, err := dyn.Resource(gvr).Namespace(defaultNS).Get(r.Context(), name, metav1.GetOptions{}); err == nil {
+					if !proxy.PathRulesFromAnnotations(ws.GetAnnotations()).Allowed(restPath) {
+						httpx.JSONError(w, http.StatusForbidden, "path not allowed", "path_denied")
+						return
+					}
+					h, err := workspaceProxyAuth(r.Context(), cli, defaultNS, clusterID+"/"+name, ws.GetAnnotations())
+					if err != nil {
+						httpx.JSONError(w, http.StatusBadGateway, "proxy credentials unavailable", "proxy_auth", err.Error())
+						return
+					}
+					authz = h
+					stripPrefix = proxy.StripPrefixFromAnnotations(ws.GetAnnotations())
+				} else if !apierrors.IsNotFound(err) {
+					httpx.JSONError(w, http.StatusBadGateway, "workspace lookup failed", "workspace_lookup", err.Error())
+					return
+				}
+			}
+			// Service/Pod lookups go through the instance's informer cache when available
+			objs := k8s.Uncached(cli)
+			if regInst != nil && regInst.K8s != nil && regInst.K8s.K != nil {
+				objs = regInst.K8s.Objects()
+			}
+			// Determine service port (first port as default, or the one selected in the segment)
 			port := 0
-			if svc, err := cli.CoreV1().Services(defaultNS).Get(r.Context(), name, metav1.GetOptions{}); err == nil {
-				if len(svc.Spec.Ports) > 0 {
-					port = int(svc.Spec.Ports[0].Port)
+			portName := ""
+			if svc, err := objs.Service(r.Context(), defaultNS, name); err == nil {
+				for i, sp := range svc.Spec.Ports {
+					if (portSel == "" && i == 0) || (portSel != "" && (sp.Name == portSel || strconv.Itoa(int(sp.Port)) == portSel)) {
+						port = int(sp.Port)
+						portName = sp.Name
+						break
+					}
 				}
 			}
+			if port == 0 && portSel != "" {
+				httpx.JSONError(w, http.StatusNotFound, "workspace port not found", "port_not_found", map[string]any{"port": portSel})
+				return
+			}
 			if port == 0 {
 				port = 80
 			}
-			// Check endpoints to decide whether to prefer port-forward fallback
-			preferPF := cs.PreferPodProxy || cs.UsePortForward
+			svcScheme := portScheme(portName, port)
+			// The cluster's proxy mode picks the strategy; auto keeps the heuristic of
+			// port-forwarding when the settings prefer it or the Service has no endpoints
+			mode := cs.EffectiveProxyMode()
+			preferPF := mode == settings.ProxyModePortForward
 			endpointsMissing := false
-			if !preferPF {
-				if eps, err := cli.CoreV1().Endpoints(defaultNS).Get(r.Context(), name, metav1.GetOptions{}); err != nil || eps == nil || len(eps.Subsets) == 0 {
-					endpointsMissing = true
+			if mode == settings.ProxyModeAuto {
+				preferPF = cs.PreferPodProxy || cs.UsePortForward
+				if !preferPF {
+					if eps, err := cli.CoreV1().Endpoints(defaultNS).Get(r.Context(), name, metav1.GetOptions{}); err != nil || eps == nil || len(eps.Subsets) == 0 {
+						endpointsMissing = true
+					}
 				}
 			}
+			// The header names the strategy actually used, for debugging
+			w.Header().Set(proxyModeHeader, string(settings.ProxyModeService))
 			// Build API transport to kube-apiserver
 			rt, err := rest.TransportFor(cfg)
 			if err != nil {
@@ -1234,42 +1744,16 @@ func Router(deps Deps) *http.ServeMux {
 						}
 					}
 				}
-				// Build a label selector from the Service's spec.selector if present
-				selector := ""
-				if svc, err := cli.CoreV1().Services(defaultNS).Get(r.Context(), name, metav1.GetOptions{}); err == nil {
-					if len(svc.Spec.Selector) > 0 {
-						// Build a comma-separated label selector
-						parts := []string{}
-						for k, v := range svc.Spec.Selector {
-							parts = append(parts, fmt.Sprintf("%s=%s", k, v))
-						}
-						selector = strings.Join(parts, ",")
-					}
-				}
-				if selector == "" {
-					// Fallback heuristic: try app=<serviceName>
-					selector = fmt.Sprintf("app=%s", name)
-				}
+				podName, selector, err := workspacePod(r.Context(), objs, defaultNS, name)
 				log.Printf("cluster: trying port-forward fallback cluster=%s service=%s selector=%s", clusterID, name, selector)
-				pods, err := cli.CoreV1().Pods(defaultNS).List(r.Context(), metav1.ListOptions{LabelSelector: selector})
-				if err == nil && len(pods.Items) > 0 {
-					// Prefer ready pods if possible
-					podName := pods.Items[0].Name
-					for _, p := range pods.Items {
-						for _, cs := range p.Status.ContainerStatuses {
-							if cs.Ready {
-								podName = p.Name
-								break
-							}
-						}
-					}
+				if err == nil {
 					log.Printf("cluster: selected pod %s for service %s (cluster=%s)", podName, name, clusterID)
 					lp, err := regInst.PF.Ensure(r.Context(), defaultNS, podName, port)
 					if err == nil && lp > 0 {
 						log.Printf("cluster: started port-forward cluster=%s pod=%s localPort=%d", clusterID, podName, lp)
 						// If tsnet connector available, publish the local port so tailnet nodes can reach it
 						if regInst.TS != nil {
-							key := clusterID + ":" + name
+							key := clusterID + ":" + seg
 							publishedMapMu.Lock()
 							pl, exists := publishedMap[key]
 							if exists && pl != nil {
@@ -1281,11 +1765,11 @@ func Router(deps Deps) *http.ServeMux {
 									publishedMapMu.Unlock()
 									log.Printf("cluster: ts publish listen failed cluster=%s port=%d err=%v", clusterID, lp, lerr)
 								} else {
-									pl = &publishedListener{clusterID: clusterID, service: name, addr: ln.Addr().String(), ln: ln, addedAt: time.Now()}
+									pl = &publishedListener{clusterID: clusterID, service: seg, addr: ln.Addr().String(), ln: ln, addedAt: time.Now()}
 									publishedMap[key] = pl
 									// persist mapping
 									if deps.DB != nil {
-										ps := localdb.PublishedService{ClusterID: clusterID, Service: name, Addr: pl.addr, AddedAt: pl.addedAt}
+										ps := localdb.PublishedService{ClusterID: clusterID, Service: seg, Addr: pl.addr, AddedAt: pl.addedAt}
 										if err := deps.DB.SavePublished(key, ps); err != nil {
 											log.Printf("cluster: failed to persist published mapping key=%s err=%v", key, err)
 										}
@@ -1335,6 +1819,7 @@ func Router(deps Deps) *http.ServeMux {
 							}
 						}
 						// Rewrite target to local loopback address and skip API proxy
+						w.Header().Set(proxyModeHeader, string(settings.ProxyModePortForward))
 						r2 := r.Clone(r.Context())
 						r2.URL = new(url.URL)
 						*r2.URL = *r.URL
@@ -1346,12 +1831,16 @@ func Router(deps Deps) *http.ServeMux {
 								// Connect to local loopback
 								return net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", lp))
 							},
+							ResolveAuth:         func(context.Context, string) (string, error) { return authz, nil },
+							ResolveStripPrefix:  func(context.Context, string) (bool, error) { return stripPrefix, nil },
+							ServerTiming:        gset.ProxyServerTiming,
+							StripHeaders:        gset.ProxyStripHeaders,
+							MaxStreamsPerServer: gset.ProxyMaxStreamsPerServer,
+							Streams:             streamsFor(clusterID),
+							Schemes:             schemesFor(clusterID),
 						})
-						// Ensure forwarded prefix header remains so iframe rewriting works
-						if r2.Header == nil {
-							r2.Header = make(http.Header)
-						}
-						r2.Header.Set("X-Forwarded-Prefix", "/api/cluster/"+clusterID+"/proxy/server/"+name)
+						// Ensure the forwarded prefix reaches the proxy so iframe rewriting works
+						r2 = r2.WithContext(proxy.WithForwardedPrefix(r2.Context(), "/api/cluster/"+clusterID+"/proxy/server/"+seg))
 						p2.ServeHTTP(w, r2)
 						return
 					}
@@ -1359,16 +1848,38 @@ func Router(deps Deps) *http.ServeMux {
 					log.Printf("cluster: no pods found for selector=%s service=%s cluster=%s err=%v", selector, name, clusterID, err)
 				}
 			}
+			// Explicitly include the scheme segment for the kube API service (or pod) proxy
+			upstreamBase := "/api/v1/namespaces/" + defaultNS + "/services/" + svcScheme + ":" + name + ":" + strconv.Itoa(port) + "/proxy"
+			if mode == settings.ProxyModePod {
+				podName, selector, err := workspacePod(r.Context(), objs, defaultNS, name)
+				if err != nil {
+					httpx.JSONError(w, http.StatusBadGateway, "no pod found for workspace", "no_pod", map[string]any{"selector": selector, "error": err.Error()})
+					return
+				}
+				upstreamBase = "/api/v1/namespaces/" + defaultNS + "/pods/" + svcScheme + ":" + podName + ":" + strconv.Itoa(port) + "/proxy"
+				w.Header().Set(proxyModeHeader, string(settings.ProxyModePod))
+			}
 
+			upstreamCAs, err := proxy.CertPoolFromPEM(cs.UpstreamCA)
+			if err != nil {
+				httpx.JSONError(w, http.StatusInternalServerError, "invalid upstream CA in cluster settings", "bad_upstream_ca", err.Error())
+				return
+			}
 			rp := proxy.NewReverseProxy(proxy.Options{
-				Timeout: 60 * time.Second,
+				Timeout:             60 * time.Second,
+				RootCAs:             upstreamCAs,
+				ServerTiming:        gset.ProxyServerTiming,
+				StripHeaders:        gset.ProxyStripHeaders,
+				MaxStreamsPerServer: gset.ProxyMaxStreamsPerServer,
+				Streams:             streamsFor(clusterID),
+				Schemes:             schemesFor(clusterID),
 				// Enable logging for cluster-scoped proxy so we can capture upstream headers and transport errors
 				Logger: httpx.Logger(),
 				ResolveServer: func(ctx context.Context, serverID string, subPath string) (string, string, string, error) {
-					// Explicitly include http: scheme segment for kube API service proxy
-					p := "/api/v1/namespaces/" + defaultNS + "/services/http:" + name + ":" + fmt.Sprintf("%d", port) + "/proxy" + subPath
-					return "http", "", p, nil
+					return "http", "", upstreamBase + subPath, nil
 				},
+				ResolveAuth:        func(context.Context, string) (string, error) { return authz, nil },
+				ResolveStripPrefix: func(context.Context, string) (bool, error) { return stripPrefix, nil },
 				APIProxy: func() (http.RoundTripper, func(req *http.Request, scheme, hostport, p string), bool) {
 					return rt, func(req *http.Request, scheme, hostport, pth string) {
 						// Honor any base path present on the API host (env override or kubeconfig)
@@ -1386,72 +1897,47 @@ func Router(deps Deps) *http.ServeMux {
 			*r2.URL = *r.URL
 			r2.URL.Path = "/proxy/server/" + url.PathEscape(name) + restPath
 			// Preserve outer prefix for iframe-safe rewriting (Location, cookies)
-			if r2.Header == nil {
-				r2.Header = make(http.Header)
-			}
-			r2.Header.Set("X-Forwarded-Prefix", "/api/cluster/"+clusterID+"/proxy/server/"+name)
+			r2 = r2.WithContext(proxy.WithForwardedPrefix(r2.Context(), "/api/cluster/"+clusterID+"/proxy/server/"+seg))
 			rp.ServeHTTP(w, r2)
 			return
 		}
-		if len(parts) == 2 && parts[1] == "servers" {
+		if len(parts) >= 2 && parts[1] == "servers" && len(parts) <= 3 {
 			if r.Method != http.MethodGet {
 				w.WriteHeader(http.StatusMethodNotAllowed)
 				return
 			}
+			var g settings.Global
+			_ = setMgr.GetGlobal(&g)
 			gvr := schema.GroupVersionResource{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}
+			// Pods give failed servers their reason; a lookup error only loses the reason
+			objs := k8s.Uncached(cli)
+			if regInst != nil && regInst.K8s != nil && regInst.K8s.K != nil {
+				objs = regInst.K8s.Objects()
+			}
+			if len(parts) == 3 {
+				ws, err := dyn.Resource(gvr).Namespace(defaultNS).Get(r.Context(), parts[2], metav1.GetOptions{})
+				if err != nil {
+					httpx.JSONError(w, http.StatusNotFound, "server not found", "not_found")
+					return
+				}
+				pods, _ := objs.Pods(r.Context(), defaultNS, k8s.WorkspaceLabel+"="+parts[2])
+				srv := serverFromWorkspace(ws.Object, pods)
+				srv.URL = proxy.ServerURL(g.ServerURLBase, r, clusterID, srv.Name)
+				httpx.JSON(w, http.StatusOK, srv)
+				return
+			}
 			lst, err := dyn.Resource(gvr).Namespace(defaultNS).List(r.Context(), metav1.ListOptions{})
 			if err != nil {
 				httpx.JSON(w, http.StatusOK, []any{})
 				return
 			}
-			// map to Server model (local, keep fields minimal)
-			type Port struct {
-				Name string `json:"name,omitempty"`
-				Port int    `json:"port"`
-			}
-			type Server struct {
-				ID     string `json:"id"`
-				Name   string `json:"name"`
-				Image  string `json:"image"`
-				Status string `json:"status"`
-				Ports  []Port `json:"ports"`
-			}
-			out := []Server{}
+			pods, _ := objs.Pods(r.Context(), defaultNS, k8s.WorkspaceLabel)
+			byWS := k8s.PodsByWorkspace(pods)
+			out := []clusterServer{}
 			for _, item := range lst.Items {
-				obj := item.Object
-				meta := obj["metadata"].(map[string]any)
-				spec := obj["spec"].(map[string]any)
-				status, _ := obj["status"].(map[string]any)
-				name := fmt.Sprint(meta["name"])
-				image := fmt.Sprint(spec["image"])
-				phase, _ := status["phase"].(string)
-				readyReplicas := 0
-				if rr, ok := status["readyReplicas"].(int64); ok {
-					readyReplicas = int(rr)
-				}
-				st := "pending"
-				if phase == "Running" && readyReplicas > 0 {
-					st = "running"
-				} else if phase == "Failed" {
-					st = "failed"
-				}
-				ports := []Port{}
-				if raw, ok := spec["ports"].([]any); ok {
-					for _, rp := range raw {
-						if pm, ok := rp.(map[string]any); ok {
-							pnum := 0
-							if pv, ok := pm["containerPort"].(int64); ok {
-								pnum = int(pv)
-							} else if pvf, ok := pm["containerPort"].(float64); ok {
-								pnum = int(pvf)
-							}
-							if pnum > 0 {
-								ports = append(ports, Port{Name: strings.TrimSpace(fmt.Sprint(pm["name"])), Port: pnum})
-							}
-						}
-					}
-				}
-				out = append(out, Server{ID: name, Name: name, Image: image, Status: st, Ports: ports})
+				srv := serverFromWorkspace(item.Object, byWS[item.GetName()])
+				srv.URL = proxy.ServerURL(g.ServerURLBase, r, clusterID, srv.Name)
+				out = append(out, srv)
 			}
 			httpx.JSON(w, http.StatusOK, out)
 			return
@@ -1459,27 +1945,23 @@ func Router(deps Deps) *http.ServeMux {
 		if len(parts) >= 2 && parts[1] == "workspaces" {
 			gvr := schema.GroupVersionResource{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}
 			if len(parts) == 2 && r.Method == http.MethodPost {
-				// auth for mutating
-				if r.Method != http.MethodGet {
-					if deps.Token != "" || true { // enforce localhost-or-token via authOK equivalent
-						// simple check mimicking authOK: allow only localhost if no token
-						host, _, _ := net.SplitHostPort(r.RemoteAddr)
-						ip := net.ParseIP(host)
-						if strings.TrimSpace(deps.Token) != "" {
-							// require header token match
-							authz := r.Header.Get("Authorization")
-							if !strings.HasPrefix(strings.ToLower(authz), "bearer ") || strings.TrimSpace(authz[7:]) != strings.TrimSpace(deps.Token) {
-								http.Error(w, "unauthorized", http.StatusUnauthorized)
-								return
-							}
-						} else if !(ip != nil && (ip.IsLoopback() || host == "127.0.0.1" || host == "::1")) {
-							http.Error(w, "unauthorized", http.StatusUnauthorized)
-							return
-						}
-					}
+				if !tokenOK(w, r) {
+					return
+				}
+				if !requireWorkspaceCRD(w, r, clusterID, cli) {
+					return
 				}
 				var spec map[string]any
 				_ = json.NewDecoder(r.Body).Decode(&spec)
+				// ?template=name: the request body overrides the stored template spec
+				if tn := strings.TrimSpace(r.URL.Query().Get("template")); tn != "" {
+					var tmpl model.WorkspaceTemplate
+					if deps.DB == nil || deps.DB.Get("templates", tn, &tmpl) != nil {
+						httpx.JSONError(w, http.StatusNotFound, "template not found", "template_not_found", tn)
+						return
+					}
+					spec = tmpl.Apply(spec)
+				}
 				// expect { image, name?, env?, ports?, args?, resources?, labels? }
 				// Avoid fmt.Sprint on nil which prints "<nil>"; only use string when present.
 				var name string
@@ -1493,20 +1975,95 @@ func Router(deps Deps) *http.ServeMux {
 				if name == "" {
 					name = fmt.Sprintf("ws-%s", uuid.NewString()[:8])
 				}
+				// Validate optional pod networking/rollout overrides before handing them to the CRD
+				var netSpec struct {
+					EnvFrom     []corev1.EnvFromSource          `json:"envFrom"`
+					HostAliases []corev1.HostAlias              `json:"hostAliases"`
+					DNSPolicy   corev1.DNSPolicy                `json:"dnsPolicy"`
+					DNSConfig   *corev1.PodDNSConfig            `json:"dnsConfig"`
+					Strategy    *apiv1alpha1.WorkspaceStrategy  `json:"strategy"`
+					Autoscale   *apiv1alpha1.WorkspaceAutoscale `json:"autoscale"`
+					SA          string                          `json:"serviceAccountName"`
+					Priority    string                          `json:"priorityClassName"`
+					MinAvail    *intstr.IntOrString             `json:"minAvailable"`
+					MaxUnavail  *intstr.IntOrString             `json:"maxUnavailable"`
+					Ingress     *apiv1alpha1.WorkspaceIngress   `json:"ingress"`
+					RunMode     apiv1alpha1.RunMode             `json:"runMode"`
+					Job         *apiv1alpha1.WorkspaceJob       `json:"job"`
+					Resources   *corev1.ResourceRequirements    `json:"resources"`
+				}
+				if v, ok := spec["resources"]; ok && v != nil {
+					spec["resources"] = workspaceResources(v)
+				}
+				if b, err := json.Marshal(map[string]any{"envFrom": spec["envFrom"], "hostAliases": spec["hostAliases"], "dnsPolicy": spec["dnsPolicy"], "dnsConfig": spec["dnsConfig"], "strategy": spec["strategy"], "autoscale": spec["autoscale"], "serviceAccountName": spec["serviceAccountName"], "priorityClassName": spec["priorityClassName"], "minAvailable": spec["minAvailable"], "maxUnavailable": spec["maxUnavailable"], "ingress": spec["ingress"], "runMode": spec["runMode"], "job": spec["job"], "resources": spec["resources"]}); err == nil {
+					if err := json.Unmarshal(b, &netSpec); err != nil {
+						httpx.JSONError(w, http.StatusBadRequest, "invalid workspace spec", "invalid_spec", err.Error())
+						return
+					}
+				}
+				fieldErrs := map[string]string{}
+				userName, _ := spec["name"].(string)
+				var g settings.Global
+				_ = setMgr.GetGlobal(&g)
+				for _, fe := range jobSpecFromWorkspaceBody(spec, strings.TrimSpace(userName)).ValidateWith(g.ValidationOptions()) {
+					fieldErrs[workspaceFieldPath(fe.Field)] = fe.Message
+				}
+				for _, err := range []error{
+					apiv1alpha1.ValidateEnvFrom(netSpec.EnvFrom),
+					apiv1alpha1.ValidateHostAliases(netSpec.HostAliases),
+					apiv1alpha1.ValidateDNS(netSpec.DNSPolicy, netSpec.DNSConfig),
+					apiv1alpha1.ValidateStrategy(netSpec.Strategy),
+					apiv1alpha1.ValidateAutoscale(netSpec.Autoscale),
+					apiv1alpha1.ValidateServiceAccountName(netSpec.SA),
+					apiv1alpha1.ValidatePriorityClassName(netSpec.Priority),
+					apiv1alpha1.ValidateDisruption(netSpec.MinAvail, netSpec.MaxUnavail),
+					apiv1alpha1.ValidateIngress(netSpec.Ingress, cs.IngressDomain),
+					apiv1alpha1.ValidateRunMode(netSpec.RunMode, netSpec.Job),
+				} {
+					var fe *apiv1alpha1.FieldError
+					if errors.As(err, &fe) {
+						fieldErrs["spec."+fe.Field] = fe.Message
+					} else if err != nil {
+						fieldErrs["spec"] = err.Error()
+					}
+				}
+				if len(fieldErrs) > 0 {
+					httpx.JSONFieldErrors(w, http.StatusBadRequest, "invalid workspace spec", "invalid_spec", fieldErrs)
+					return
+				}
+				wsSpec := map[string]any{
+					"image":     spec["image"],
+					"env":       spec["env"],
+					"ports":     spec["ports"],
+					"args":      spec["args"],
+					"resources": spec["resources"],
+					"labels":    spec["labels"],
+				}
+				for _, k := range []string{"envFrom", "hostAliases", "dnsPolicy", "dnsConfig", "strategy", "autoscale", "serviceAccountName", "automountServiceAccountToken", "priorityClassName", "minAvailable", "maxUnavailable", "exposure", "ingress", "runMode", "job"} {
+					if v, ok := spec[k]; ok && v != nil {
+						wsSpec[k] = v
+					}
+				}
+				meta := map[string]any{"name": name}
+				// Mirror labels onto the CR metadata so label selectors (bulk delete, listing) can match them
+				if lm, ok := spec["labels"].(map[string]any); ok && len(lm) > 0 {
+					meta["labels"] = lm
+				}
 				obj := map[string]any{
 					"apiVersion": "guildnet.io/v1alpha1",
 					"kind":       "Workspace",
-					"metadata":   map[string]any{"name": name},
-					"spec": map[string]any{
-						"image":     spec["image"],
-						"env":       spec["env"],
-						"ports":     spec["ports"],
-						"args":      spec["args"],
-						"resources": spec["resources"],
-						"labels":    spec["labels"],
-					},
-				}
-				if _, err := dyn.Resource(gvr).Namespace(defaultNS).Create(r.Context(), &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{}); err != nil {
+					"metadata":   meta,
+					"spec":       wsSpec,
+				}
+				// A taken name gets a random suffix, as on /api/workspace-jobs; the response carries the final name
+				created, err := CreateWorkspaceUnique(r.Context(), dyn, defaultNS, &unstructured.Unstructured{Object: obj})
+				if err != nil {
+					// A 404 on create means the resource type itself is missing (CRD removed since the cached check)
+					if apierrors.IsNotFound(err) {
+						forgetWorkspaceCRD(clusterID)
+						httpx.JSONError(w, http.StatusPreconditionFailed, "workspace CRD not installed", "crd_not_installed", crdInstallHint)
+						return
+					}
 					// If this is a Kubernetes StatusError (validation, etc), surface its structured
 					// details to the client so the UI can display helpful messages.
 					var details any = err.Error()
@@ -1519,7 +2076,7 @@ func Router(deps Deps) *http.ServeMux {
 					httpx.JSONError(w, http.StatusInternalServerError, "workspace create failed", "create_failed", details)
 					return
 				}
-				httpx.JSON(w, http.StatusAccepted, map[string]any{"id": name, "status": "pending"})
+				httpx.JSON(w, http.StatusAccepted, map[string]any{"id": created, "name": created, "status": "pending"})
 				return
 			}
 			if len(parts) == 3 && r.Method == http.MethodGet {
@@ -1529,9 +2086,58 @@ func Router(deps Deps) *http.ServeMux {
 					httpx.JSONError(w, http.StatusNotFound, "workspace not found", "not_found")
 					return
 				}
+				// Proxy entry points per declared port; proxyAuth reports that the proxy
+				// injects credentials, so callers need none of their own
+				var g settings.Global
+				_ = setMgr.GetGlobal(&g)
+				ws.Object["endpoints"] = workspaceEndpoints(ws.Object, g.ServerURLBase, r, clusterID)
+				ws.Object["proxyAuth"] = strings.TrimSpace(ws.GetAnnotations()[proxy.AnnotationAuthSecret]) != ""
+				// Containers of the workspace pods, selectable with logs?container=
+				containers := []string{}
+				if pods, err := cli.CoreV1().Pods(defaultNS).List(r.Context(), metav1.ListOptions{LabelSelector: fmt.Sprintf("guildnet.io/workspace=%s", name)}); err == nil {
+					containers = k8s.ContainerNames(pods.Items)
+				}
+				ws.Object["containers"] = containers
 				httpx.JSON(w, http.StatusOK, ws.Object)
 				return
 			}
+			if len(parts) == 4 && parts[3] == "describe" && r.Method == http.MethodGet {
+				tail := int64(50)
+				if v := r.URL.Query().Get("tail"); v != "" {
+					if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+						tail = n
+					}
+				}
+				desc, err := describeWorkspace(r.Context(), cli, dyn, defaultNS, parts[2], tail)
+				if err != nil {
+					if apierrors.IsNotFound(err) {
+						httpx.JSONError(w, http.StatusNotFound, "workspace not found", "not_found")
+						return
+					}
+					httpx.JSONError(w, http.StatusInternalServerError, "describe failed", "describe_failed", err.Error())
+					return
+				}
+				httpx.JSON(w, http.StatusOK, desc)
+				return
+			}
+			if len(parts) == 4 && parts[3] == "credentials" && r.Method == http.MethodGet {
+				// The generated code-server password is a secret: same guard as mutations
+				if !tokenOK(w, r) {
+					return
+				}
+				sec, err := cli.CoreV1().Secrets(defaultNS).Get(r.Context(), apiv1alpha1.CredentialsSecretName(parts[2]), metav1.GetOptions{})
+				if err != nil {
+					if apierrors.IsNotFound(err) {
+						httpx.JSONError(w, http.StatusNotFound, "workspace has no generated credentials", "no_credentials")
+						return
+					}
+					httpx.JSONError(w, http.StatusInternalServerError, "credentials lookup failed", "credentials_failed", err.Error())
+					return
+				}
+				w.Header().Set("Cache-Control", "no-store")
+				httpx.JSON(w, http.StatusOK, map[string]any{"name": parts[2], "password": string(sec.Data[apiv1alpha1.CredentialsSecretKey])})
+				return
+			}
 			if len(parts) == 4 && parts[3] == "logs" && r.Method == http.MethodGet {
 				name := parts[2]
 				pods, err := cli.CoreV1().Pods(defaultNS).List(r.Context(), metav1.ListOptions{LabelSelector: fmt.Sprintf("guildnet.io/workspace=%s", name)})
@@ -1543,11 +2149,17 @@ func Router(deps Deps) *http.ServeMux {
 				if v := r.URL.Query().Get("limit"); v != "" {
 					fmt.Sscanf(v, "%d", &limit)
 				}
+				// ?container= picks a sidecar; pods without it are skipped
+				want := r.URL.Query().Get("container")
+				if want != "" && !slices.Contains(k8s.ContainerNames(pods.Items), want) {
+					httpx.JSONError(w, http.StatusBadRequest, "unknown container", "unknown_container", want)
+					return
+				}
 				out := []map[string]string{}
 				for _, p := range pods.Items {
-					container := ""
-					if len(p.Spec.Containers) > 0 {
-						container = p.Spec.Containers[0].Name
+					container, err := k8s.LogContainer(&p, want)
+					if err != nil {
+						continue
 					}
 					data, err := cli.CoreV1().Pods(defaultNS).GetLogs(p.Name, &corev1.PodLogOptions{Container: container}).Do(r.Context()).Raw()
 					if err != nil {
@@ -1607,9 +2219,16 @@ func Router(deps Deps) *http.ServeMux {
 					return
 				}
 				pod := pods.Items[0]
-				container := ""
-				if len(pod.Spec.Containers) > 0 {
-					container = pod.Spec.Containers[0].Name
+				container, err := k8s.LogContainer(&pod, r.URL.Query().Get("container"))
+				if err != nil {
+					httpx.JSONError(w, http.StatusBadRequest, "unknown container", "unknown_container", err.Error())
+					return
+				}
+				logOpts := &corev1.PodLogOptions{Container: container, Follow: true}
+				tail, _ := strconv.Atoi(r.URL.Query().Get("tail"))
+				if tail > 0 {
+					tl := int64(tail)
+					logOpts.TailLines = &tl
 				}
 				w.Header().Set("Content-Type", "text/event-stream")
 				w.Header().Set("Cache-Control", "no-cache")
@@ -1620,46 +2239,67 @@ func Router(deps Deps) *http.ServeMux {
 					return
 				}
 				ctx := r.Context()
-				stream, err := cli.CoreV1().Pods(defaultNS).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container, Follow: true}).Stream(ctx)
+				stream, err := cli.CoreV1().Pods(defaultNS).GetLogs(pod.Name, logOpts).Stream(ctx)
 				if err != nil {
 					http.Error(w, "log stream error", http.StatusInternalServerError)
 					return
 				}
 				defer stream.Close()
-				scanner := bufio.NewScanner(stream)
-				for scanner.Scan() {
-					select {
-					case <-ctx.Done():
-						return
-					default:
+				reqID := r.Header.Get("X-Request-Id")
+				log.Printf("logs/stream open: req_id=%s cluster=%s target=%s tail=%d from %s", reqID, clusterID, name, tail, r.RemoteAddr)
+				defer log.Printf("logs/stream close: req_id=%s cluster=%s target=%s", reqID, clusterID, name)
+				_ = httpx.SSEEvent(w, "meta", model.LogStreamMeta{RequestID: reqID, Target: name, Tail: tail})
+				flusher.Flush()
+				streamLogLines(ctx, w, flusher, stream, pod.Name, container, func() model.LogStreamEOF {
+					return containerLogEOF(cli, defaultNS, pod.Name, container)
+				})
+				return
+			}
+			// Bulk delete: DELETE /api/cluster/{id}/workspaces?labelSelector=k=v
+			if len(parts) == 2 && r.Method == http.MethodDelete {
+				if !tokenOK(w, r) {
+					return
+				}
+				if !requireWorkspaceCRD(w, r, clusterID, cli) {
+					return
+				}
+				selector := strings.TrimSpace(r.URL.Query().Get("labelSelector"))
+				if selector == "" {
+					// refuse to wipe every workspace in the namespace by accident
+					httpx.JSONError(w, http.StatusBadRequest, "labelSelector required", "missing_selector")
+					return
+				}
+				if _, err := labels.Parse(selector); err != nil {
+					httpx.JSONError(w, http.StatusBadRequest, "invalid labelSelector", "invalid_selector", err.Error())
+					return
+				}
+				lst, err := dyn.Resource(gvr).Namespace(defaultNS).List(r.Context(), metav1.ListOptions{LabelSelector: selector})
+				if err != nil {
+					httpx.JSONError(w, http.StatusInternalServerError, "list workspaces failed", "list_failed", err.Error())
+					return
+				}
+				deleted := []string{}
+				failed := map[string]string{}
+				for _, item := range lst.Items {
+					if err := dyn.Resource(gvr).Namespace(defaultNS).Delete(r.Context(), item.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+						failed[item.GetName()] = err.Error()
+						continue
 					}
-					line := scanner.Text()
-					msg := fmt.Sprintf("[%s] %s", pod.Name, strings.TrimSpace(line))
-					io.WriteString(w, "data: ")
-					b, _ := json.Marshal(map[string]string{"t": time.Now().UTC().Format(time.RFC3339), "msg": msg})
-					w.Write(b)
-					io.WriteString(w, "\n\n")
-					flusher.Flush()
+					deleted = append(deleted, item.GetName())
+				}
+				resp := map[string]any{"deleted": deleted}
+				if len(failed) > 0 {
+					resp["failed"] = failed
 				}
+				httpx.JSON(w, http.StatusOK, resp)
 				return
 			}
 			if len(parts) == 3 && r.Method == http.MethodDelete {
-				// auth for mutating
-				if r.Method != http.MethodGet {
-					if deps.Token != "" || true {
-						host, _, _ := net.SplitHostPort(r.RemoteAddr)
-						ip := net.ParseIP(host)
-						if strings.TrimSpace(deps.Token) != "" {
-							authz := r.Header.Get("Authorization")
-							if !strings.HasPrefix(strings.ToLower(authz), "bearer ") || strings.TrimSpace(authz[7:]) != strings.TrimSpace(deps.Token) {
-								http.Error(w, "unauthorized", http.StatusUnauthorized)
-								return
-							}
-						} else if !(ip != nil && (ip.IsLoopback() || host == "127.0.0.1" || host == "::1")) {
-							http.Error(w, "unauthorized", http.StatusUnauthorized)
-							return
-						}
-					}
+				if !tokenOK(w, r) {
+					return
+				}
+				if !requireWorkspaceCRD(w, r, clusterID, cli) {
+					return
 				}
 				name := parts[2]
 				if err := dyn.Resource(gvr).Namespace(defaultNS).Delete(r.Context(), name, metav1.DeleteOptions{}); err != nil {
@@ -1685,7 +2325,7 @@ func Router(deps Deps) *http.ServeMux {
 					}
 				}
 				return nil
-			}(), OrgID: clusterID, RBAC: httpx.NewRBACStore()}
+			}(), OrgID: clusterID, RBAC: rbacStores.get(clusterID)}
 			mux2 := http.NewServeMux()
 			api.Register(mux2)
 			// Rewrite path to /api/db...
@@ -1701,7 +2341,7 @@ func Router(deps Deps) *http.ServeMux {
 			return
 		}
 		w.WriteHeader(http.StatusNotFound)
-	})
+	})))
 
 	// SSE: per-cluster DB changefeed: /sse/cluster/:id/db/...
 	mux.HandleFunc("/sse/cluster/", func(w http.ResponseWriter, r *http.Request) {
@@ -1724,7 +2364,7 @@ func Router(deps Deps) *http.ServeMux {
 					}
 				}
 				return nil
-			}(), OrgID: clusterID, RBAC: httpx.NewRBACStore()}
+			}(), OrgID: clusterID, RBAC: rbacStores.get(clusterID)}
 			mux2 := http.NewServeMux()
 			api.Register(mux2)
 			// Rewrite to /sse/db/...
@@ -1867,39 +2507,14 @@ func healthyCluster(cfg *rest.Config) error {
 }
 
 func readClusterKubeconfig(db *localdb.DB, sec *secrets.Manager, id string) (string, bool) {
-	if db == nil {
+	kc, err := ClusterKubeconfig(db, sec, id)
+	if err != nil {
 		return "", false
 	}
-	var cred map[string]any
-	if db.Get("credentials", fmt.Sprintf("cl:%s:kubeconfig", id), &cred) != nil {
+	if cfg, err := kubeconfigFrom(kc); err != nil || cfg == nil {
 		return "", false
 	}
-	val := fmt.Sprint(cred["value"])
-	// If explicitly marked encrypted, require successful decryption and basic validation
-	if encFlag, ok := cred["encrypted"].(bool); ok && encFlag {
-		if sec == nil {
-			return "", false
-		}
-		if v, err := sec.Decrypt(val); err == nil {
-			if cfg, e2 := kubeconfigFrom(v); e2 == nil && cfg != nil {
-				return v, true
-			}
-		}
-		return "", false
-	}
-	// Legacy/unknown: try decrypt first, then fall back to plaintext; validate either way
-	if sec != nil {
-		if v, err := sec.Decrypt(val); err == nil {
-			if cfg, e2 := kubeconfigFrom(v); e2 == nil && cfg != nil {
-				return v, true
-			}
-		}
-	}
-	// Treat as plaintext and validate
-	if cfg, err := kubeconfigFrom(val); err == nil && cfg != nil {
-		return val, true
-	}
-	return "", false
+	return kc, true
 }
 
 func headscaleHealth(endpoint string) (string, error) {
@@ -1926,29 +2541,6 @@ func headscaleHealth(endpoint string) (string, error) {
 	return "error", err
 }
 
-// isLocalKubeProxyAvailable returns true if a kubectl proxy is listening on 127.0.0.1:8001.
-func isLocalKubeProxyAvailable() bool {
-	addr := "127.0.0.1:8001"
-	if v := strings.TrimSpace(os.Getenv("KUBE_PROXY_ADDR")); v != "" {
-		// Accept either host:port or URL. If URL, extract host:port
-		if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
-			if u, err := url.Parse(v); err == nil {
-				addr = u.Host
-			} else {
-				addr = v
-			}
-		} else {
-			addr = v
-		}
-	}
-	c, err := net.DialTimeout("tcp", addr, 500*time.Millisecond)
-	if err == nil {
-		_ = c.Close()
-		return true
-	}
-	return false
-}
-
 // isTimeoutErr returns true if err looks like a client timeout/connection timeout to the API server.
 func isTimeoutErr(err error) bool {
 	if err == nil {
@@ -1965,41 +2557,9 @@ func isTimeoutErr(err error) bool {
 	return false
 }
 
-// ensureProxyFallbackOnTimeout will enable per-cluster local proxy fallback when a timeout is detected.
-// Returns true if it modified settings.
-func ensureProxyFallbackOnTimeout(setMgr settings.Manager, clusterID string) bool {
-	// Do not auto-enable local kubectl proxy fallback by default.
-	// Only enable when an explicit env override `KUBE_PROXY_ADDR` is set to a host:port or URL.
-	if strings.TrimSpace(os.Getenv("KUBE_PROXY_ADDR")) == "" {
-		return false
-	}
-	var cs settings.Cluster
-	_ = setMgr.GetCluster(clusterID, &cs)
-	if cs.DisableAPIProxy {
-		return false
-	}
-	host := strings.TrimSpace(cs.APIProxyURL)
-	if host == "" {
-		if v := strings.TrimSpace(os.Getenv("KUBE_PROXY_ADDR")); v != "" {
-			// If env var is host:port, prefix with http:// for APIProxyURL
-			if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
-				cs.APIProxyURL = v
-			} else {
-				cs.APIProxyURL = "http://" + v
-			}
-		}
-		if !cs.APIProxyForceHTTP {
-			cs.APIProxyForceHTTP = true
-		}
-		_ = setMgr.PutCluster(clusterID, cs)
-		return true
-	}
-	return false
-}
-
 // applyClusterAPIProxy applies per-cluster proxy overrides and a local proxy fallback.
-// If DisableAPIProxy is false and no explicit APIProxyURL is configured, a local
-// kubectl proxy at http://127.0.0.1:8001 will be used when available.
+// If DisableAPIProxy is false and no explicit APIProxyURL is configured, KUBE_PROXY_ADDR
+// is used when set; detected proxies are persisted by ensureProxyFallback.
 func applyClusterAPIProxy(cfg *rest.Config, setMgr settings.Manager, clusterID string) {
 	var cs settings.Cluster
 	_ = setMgr.GetCluster(clusterID, &cs)
@@ -2027,3 +2587,66 @@ func applyClusterAPIProxy(cfg *rest.Config, setMgr settings.Manager, clusterID s
 		}
 	}
 }
+
+// tokenAuthorized reports whether r carries the API token, as a Bearer Authorization
+// or X-API-Token header. Without a configured token only loopback clients are allowed.
+func tokenAuthorized(r *http.Request, token string) bool {
+	tok := strings.TrimSpace(token)
+	if tok == "" {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	}
+	authz := r.Header.Get("Authorization")
+	if strings.HasPrefix(strings.ToLower(authz), "bearer ") && strings.TrimSpace(authz[7:]) == tok {
+		return true
+	}
+	return r.Header.Get("X-API-Token") == tok
+}
+
+// proxyModeHeader names the strategy (service, pod or portforward) the cluster proxy
+// used for a response.
+const proxyModeHeader = "X-Guild-Proxy-Mode"
+
+// workspacePod picks the pod behind a workspace Service for the pod proxy and
+// port-forwarding: pods matching the Service's selector (app=<name> when it has none),
+// preferring one with a ready container. The selector is returned for diagnostics.
+func workspacePod(ctx context.Context, objs *k8s.ObjectCache, ns, name string) (string, string, error) {
+	selector := ""
+	if svc, err := objs.Service(ctx, ns, name); err == nil && len(svc.Spec.Selector) > 0 {
+		parts := []string{}
+		for k, v := range svc.Spec.Selector {
+			parts = append(parts, fmt.Sprintf("%s=%s", k, v))
+		}
+		sort.Strings(parts)
+		selector = strings.Join(parts, ",")
+	}
+	if selector == "" {
+		selector = fmt.Sprintf("app=%s", name)
+	}
+	pods, err := objs.Pods(ctx, ns, selector)
+	if err != nil {
+		return "", selector, err
+	}
+	if len(pods) == 0 {
+		return "", selector, fmt.Errorf("no pods match %s", selector)
+	}
+	for _, p := range pods {
+		for _, c := range p.Status.ContainerStatuses {
+			if c.Ready {
+				return p.Name, selector, nil
+			}
+		}
+	}
+	return pods[0].Name, selector, nil
+}
+
+// jobSubmitError reports a failed Runner.Submit; a full queue is 503 so callers can retry.
+func jobSubmitError(w http.ResponseWriter, err error) {
+	if errors.Is(err, jobs.ErrQueueFull) {
+		w.Header().Set("Retry-After", "5")
+		httpx.JSONError(w, http.StatusServiceUnavailable, "job queue full; retry later", "queue_full")
+		return
+	}
+	httpx.JSONError(w, http.StatusInternalServerError, "job submit failed", "submit_failed", err.Error())
+}
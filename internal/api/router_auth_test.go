@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokenAuthorized(t *testing.T) {
+	req := httptest.NewRequest("DELETE", "/api/cluster/c1/workspaces?labelSelector=a=b", nil)
+	req.RemoteAddr = "127.0.0.1:5555"
+	if !tokenAuthorized(req, "") {
+		t.Fatalf("loopback without token should be allowed")
+	}
+	req.RemoteAddr = "10.1.2.3:5555"
+	if tokenAuthorized(req, "") {
+		t.Fatalf("remote without token should be rejected")
+	}
+	if tokenAuthorized(req, "s3cret") {
+		t.Fatalf("missing bearer should be rejected")
+	}
+	req.Header.Set("Authorization", "Bearer s3cret")
+	if !tokenAuthorized(req, "s3cret") {
+		t.Fatalf("matching bearer should be allowed")
+	}
+	req.Header.Del("Authorization")
+	req.Header.Set("X-API-Token", "s3cret")
+	if !tokenAuthorized(req, "s3cret") {
+		t.Fatalf("matching X-API-Token should be allowed")
+	}
+	req.Header.Set("X-API-Token", "wrong")
+	if tokenAuthorized(req, "s3cret") {
+		t.Fatalf("wrong X-API-Token should be rejected")
+	}
+}
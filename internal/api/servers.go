@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/docxology/GuildNet/internal/k8s"
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+type clusterServerPort struct {
+	Name string `json:"name,omitempty"`
+	Port int    `json:"port"`
+}
+
+// clusterServer is the per-cluster server list/detail shape (kept minimal for the UI).
+type clusterServer struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+	Status string `json:"status"`
+	// StatusReason is set for failed servers; see k8s.PodFailureReason.
+	StatusReason string              `json:"statusReason,omitempty"`
+	Ports        []clusterServerPort `json:"ports"`
+	URL          string              `json:"url,omitempty"`
+}
+
+// serverFromWorkspace maps an unstructured Workspace object to a clusterServer. pods are
+// the workspace's pods, used for the failure reason; nil skips that.
+func serverFromWorkspace(obj map[string]any, pods []corev1.Pod) clusterServer {
+	meta, _ := obj["metadata"].(map[string]any)
+	spec, _ := obj["spec"].(map[string]any)
+	status, _ := obj["status"].(map[string]any)
+	name := fmt.Sprint(meta["name"])
+	image, _ := spec["image"].(string)
+	phase, _ := status["phase"].(string)
+	readyReplicas := 0
+	if rr, ok := status["readyReplicas"].(int64); ok {
+		readyReplicas = int(rr)
+	}
+	st, reason := k8s.ServerStatus(phase, readyReplicas, pods)
+	ports := []clusterServerPort{}
+	if raw, ok := spec["ports"].([]any); ok {
+		for _, rp := range raw {
+			if pm, ok := rp.(map[string]any); ok {
+				pnum := 0
+				if pv, ok := pm["containerPort"].(int64); ok {
+					pnum = int(pv)
+				} else if pvf, ok := pm["containerPort"].(float64); ok {
+					pnum = int(pvf)
+				}
+				if pnum > 0 {
+					pname, _ := pm["name"].(string)
+					ports = append(ports, clusterServerPort{Name: strings.TrimSpace(pname), Port: pnum})
+				}
+			}
+		}
+	}
+	return clusterServer{ID: name, Name: name, Image: image, Status: st, StatusReason: reason, Ports: ports}
+}
+
+// workspaceEndpoint is a proxy entry point for one declared workspace port.
+type workspaceEndpoint struct {
+	PortName string `json:"portName,omitempty"`
+	Port     int    `json:"port"`
+	Scheme   string `json:"scheme"`
+	ProxyURL string `json:"proxyURL"`
+}
+
+// workspaceEndpoints lists the proxy entry points of a Workspace, one per declared port
+// (the operator's default 8080 "http" port when none are declared). The first port is
+// the Service default and uses the plain server URL; the others select their port with
+// a "{name}:{port}" server segment.
+func workspaceEndpoints(obj map[string]any, base string, r *http.Request, clusterID string) []workspaceEndpoint {
+	srv := serverFromWorkspace(obj, nil)
+	ports := srv.Ports
+	if len(ports) == 0 {
+		ports = []clusterServerPort{{Name: "http", Port: 8080}}
+	}
+	out := make([]workspaceEndpoint, 0, len(ports))
+	for i, p := range ports {
+		seg := srv.Name
+		if i > 0 {
+			sel := p.Name
+			if sel == "" {
+				sel = fmt.Sprint(p.Port)
+			}
+			seg += ":" + sel
+		}
+		out = append(out, workspaceEndpoint{PortName: p.Name, Port: p.Port, Scheme: portScheme(p.Name, p.Port), ProxyURL: proxy.ServerURL(base, r, clusterID, seg)})
+	}
+	return out
+}
+
+// portScheme guesses the upstream scheme of a Service port from its name or number.
+func portScheme(name string, port int) string {
+	n := strings.ToLower(name)
+	if n == "https" || strings.HasPrefix(n, "https-") || port == 443 || port == 8443 {
+		return "https"
+	}
+	return "http"
+}
+
+// splitServerPort splits a proxy server segment "{name}:{port}" into the workspace name
+// and port selector (a port name or number, "" for the default port).
+func splitServerPort(seg string) (name, port string) {
+	if i := strings.IndexByte(seg, ':'); i > 0 {
+		return seg[:i], seg[i+1:]
+	}
+	return seg, ""
+}
+
+// isWorkspaceCreate matches POST /api/cluster/{id}/workspaces.
+func isWorkspaceCreate(r *http.Request) bool {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/cluster/"), "/"), "/")
+	return r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "workspaces"
+}
+
+// proxyAuthCache holds Authorization headers built from workspace proxy-auth secrets. The
+// hostapp proxy and the cluster proxies share it.
+var proxyAuthCache = proxy.NewAuthCache(30 * time.Second)
+
+// workspaceProxyAuth returns the Authorization header to inject for a workspace, read from
+// the Secret named by its proxy-auth annotation ("" when none). cacheKey scopes the cache entry.
+func workspaceProxyAuth(ctx context.Context, cli kubernetes.Interface, ns, cacheKey string, ann map[string]string) (string, error) {
+	secretName := strings.TrimSpace(ann[proxy.AnnotationAuthSecret])
+	if secretName == "" {
+		return "", nil
+	}
+	return proxyAuthCache.Get(ctx, cacheKey+"/"+secretName, func(ctx context.Context) (string, error) {
+		return proxy.AuthorizationFromAnnotations(ctx, ann, secretGetter(cli, ns))
+	})
+}
+
+// WorkspaceAuthResolver returns a proxy.Options.ResolveAuth for workspaces in ns. The
+// Workspace and its credentials Secret are read once per cache period, not per request.
+func WorkspaceAuthResolver(dyn dynamic.Interface, cli kubernetes.Interface, ns string) func(ctx context.Context, serverID string) (string, error) {
+	return func(ctx context.Context, serverID string) (string, error) {
+		if dyn == nil || cli == nil || serverID == "" {
+			return "", nil
+		}
+		ann, err := workspaceAnnotations(ctx, dyn, ns, serverID)
+		if err != nil {
+			return "", err
+		}
+		return workspaceProxyAuth(ctx, cli, ns, "workspace/"+ns+"/"+serverID, ann)
+	}
+}
+
+// WorkspaceStripPrefixResolver returns a proxy.Options.ResolveStripPrefix for workspaces
+// in ns, reading the annotation through the same cache as WorkspaceAuthResolver.
+func WorkspaceStripPrefixResolver(dyn dynamic.Interface, ns string) func(ctx context.Context, serverID string) (bool, error) {
+	return func(ctx context.Context, serverID string) (bool, error) {
+		if dyn == nil || serverID == "" {
+			return false, nil
+		}
+		ann, err := workspaceAnnotations(ctx, dyn, ns, serverID)
+		if err != nil {
+			return false, err
+		}
+		return proxy.StripPrefixFromAnnotations(ann), nil
+	}
+}
+
+// secretGetter reads Secrets from ns for proxy credentials.
+func secretGetter(cli kubernetes.Interface, ns string) proxy.SecretGetter {
+	return func(ctx context.Context, name string) (map[string][]byte, error) {
+		sec, err := cli.CoreV1().Secrets(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return sec.Data, nil
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// workspaceAnnotationTTL is how long the proxy reuses a Workspace's annotations.
+const workspaceAnnotationTTL = 30 * time.Second
+
+// annotationCache keeps Workspace annotations for the per-request proxy lookups
+// (credentials, strip-prefix) so they do not each GET the Workspace.
+type annotationCache struct {
+	mu      sync.Mutex
+	entries map[string]annotationEntry
+}
+
+type annotationEntry struct {
+	ann map[string]string
+	at  time.Time
+}
+
+var workspaceAnnotationCache = &annotationCache{entries: map[string]annotationEntry{}}
+
+// get returns the cached annotations for key, calling fetch when they are missing or
+// older than workspaceAnnotationTTL. When fetch fails, an expired entry is served rather
+// than failing the request.
+func (c *annotationCache) get(ctx context.Context, key string, fetch func(ctx context.Context) (map[string]string, error)) (map[string]string, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Since(e.at) < workspaceAnnotationTTL {
+		return e.ann, nil
+	}
+	ann, err := fetch(ctx)
+	if err != nil {
+		if ok {
+			return e.ann, nil
+		}
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[key] = annotationEntry{ann: ann, at: time.Now()}
+	c.mu.Unlock()
+	return ann, nil
+}
+
+// workspaceAnnotations returns the annotations of Workspace name in ns (nil when it does
+// not exist), cached for workspaceAnnotationTTL.
+func workspaceAnnotations(ctx context.Context, dyn dynamic.Interface, ns, name string) (map[string]string, error) {
+	return workspaceAnnotationCache.get(ctx, ns+"/"+name, func(ctx context.Context) (map[string]string, error) {
+		gvr := schema.GroupVersionResource{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}
+		ws, err := dyn.Resource(gvr).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return ws.GetAnnotations(), nil
+	})
+}
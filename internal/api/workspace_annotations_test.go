@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestWorkspaceResolversShareCachedAnnotations(t *testing.T) {
+	const ns = "annotations-test"
+	ws := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "guildnet.io/v1alpha1",
+		"kind":       "Workspace",
+		"metadata": map[string]any{"name": "demo", "namespace": ns, "annotations": map[string]any{
+			proxy.AnnotationStripPrefix: "true",
+			proxy.AnnotationAuthSecret:  "tok",
+		}},
+	}}
+	dyn := dynfake.NewSimpleDynamicClient(runtime.NewScheme(), ws)
+	gets := 0
+	var failGet error
+	dyn.PrependReactor("get", "workspaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		gets++
+		return failGet != nil, nil, failGet
+	})
+	cli := fake.NewSimpleClientset(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tok", Namespace: ns}, Data: map[string][]byte{"token": []byte("abc")}})
+	strip, auth := WorkspaceStripPrefixResolver(dyn, ns), WorkspaceAuthResolver(dyn, cli, ns)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if on, err := strip(ctx, "demo"); err != nil || !on {
+			t.Fatalf("strip=%v err=%v", on, err)
+		}
+		if h, err := auth(ctx, "demo"); err != nil || h != "Bearer abc" {
+			t.Fatalf("auth=%q err=%v", h, err)
+		}
+	}
+	if gets != 1 {
+		t.Fatalf("workspace GETs = %d, want one shared by both resolvers", gets)
+	}
+
+	// Once expired, a failing lookup keeps serving the last annotations
+	workspaceAnnotationCache.mu.Lock()
+	e := workspaceAnnotationCache.entries[ns+"/demo"]
+	e.at = time.Now().Add(-2 * workspaceAnnotationTTL)
+	workspaceAnnotationCache.entries[ns+"/demo"] = e
+	workspaceAnnotationCache.mu.Unlock()
+	failGet = errors.New("apiserver unavailable")
+	if on, err := strip(ctx, "demo"); err != nil || !on {
+		t.Fatalf("after failed refresh: strip=%v err=%v", on, err)
+	}
+	if _, err := strip(ctx, "other"); err == nil {
+		t.Fatal("uncached workspace lookup error was swallowed")
+	}
+}
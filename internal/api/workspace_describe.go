@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/docxology/GuildNet/internal/k8s"
+)
+
+// WorkspaceDescription is the combined view returned by /workspaces/{name}/describe,
+// roughly the programmatic equivalent of `kubectl describe` scoped to one workspace.
+type WorkspaceDescription struct {
+	Name   string            `json:"name"`
+	Spec   map[string]any    `json:"spec,omitempty"`
+	Status map[string]any    `json:"status,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Events []DescribeEvent   `json:"events"`
+	Pods   []DescribePod     `json:"pods"`
+	Logs   []string          `json:"logs"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// DescribeEvent is a trimmed Kubernetes event related to the workspace.
+type DescribeEvent struct {
+	Type     string    `json:"type"`
+	Reason   string    `json:"reason"`
+	Message  string    `json:"message"`
+	Object   string    `json:"object"`
+	Count    int32     `json:"count,omitempty"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// DescribePod summarizes one pod backing the workspace.
+type DescribePod struct {
+	Name       string              `json:"name"`
+	Phase      string              `json:"phase"`
+	Node       string              `json:"node,omitempty"`
+	PodIP      string              `json:"podIP,omitempty"`
+	StartTime  *time.Time          `json:"startTime,omitempty"`
+	Containers []DescribeContainer `json:"containers"`
+	Conditions map[string]string   `json:"conditions,omitempty"`
+}
+
+// DescribeContainer is the per-container status of a pod.
+type DescribeContainer struct {
+	Name     string `json:"name"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	State    string `json:"state"`
+	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// describeWorkspace gathers the Workspace CR, related events, pod statuses and the last
+// tail log lines. Only a missing Workspace is fatal; partial failures are reported in Errors.
+func describeWorkspace(ctx context.Context, cli kubernetes.Interface, dyn dynamic.Interface, ns, name string, tail int64) (*WorkspaceDescription, error) {
+	gvr := schema.GroupVersionResource{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}
+	ws, err := dyn.Resource(gvr).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	out := &WorkspaceDescription{Name: name, Labels: ws.GetLabels(), Events: []DescribeEvent{}, Pods: []DescribePod{}, Logs: []string{}, Errors: map[string]string{}}
+	out.Spec, _ = ws.Object["spec"].(map[string]any)
+	out.Status, _ = ws.Object["status"].(map[string]any)
+
+	var podList []corev1.Pod
+	pods, err := cli.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: k8s.WorkspaceLabel + "=" + name})
+	if err != nil {
+		out.Errors["pods"] = err.Error()
+	} else {
+		podList = pods.Items
+		for _, p := range pods.Items {
+			dp := DescribePod{Name: p.Name, Phase: string(p.Status.Phase), Node: p.Spec.NodeName, PodIP: p.Status.PodIP, Containers: []DescribeContainer{}, Conditions: map[string]string{}}
+			if p.Status.StartTime != nil {
+				t := p.Status.StartTime.Time
+				dp.StartTime = &t
+			}
+			for _, c := range p.Status.Conditions {
+				dp.Conditions[string(c.Type)] = string(c.Status)
+			}
+			for _, cs := range append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...) {
+				dc := DescribeContainer{Name: cs.Name, Ready: cs.Ready, Restarts: cs.RestartCount}
+				switch {
+				case cs.State.Running != nil:
+					dc.State = "running"
+				case cs.State.Waiting != nil:
+					dc.State, dc.Reason, dc.Message = "waiting", cs.State.Waiting.Reason, cs.State.Waiting.Message
+				case cs.State.Terminated != nil:
+					dc.State, dc.Reason, dc.Message = "terminated", cs.State.Terminated.Reason, cs.State.Terminated.Message
+				}
+				dp.Containers = append(dp.Containers, dc)
+			}
+			out.Pods = append(out.Pods, dp)
+			if tail > 0 && len(p.Spec.Containers) > 0 {
+				container, _ := k8s.LogContainer(&p, "")
+				data, err := cli.CoreV1().Pods(ns).GetLogs(p.Name, &corev1.PodLogOptions{Container: container, TailLines: &tail}).Do(ctx).Raw()
+				if err != nil {
+					out.Errors["logs/"+p.Name] = err.Error()
+					continue
+				}
+				for _, ln := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+					if ln != "" {
+						out.Logs = append(out.Logs, "["+p.Name+"] "+ln)
+					}
+				}
+			}
+		}
+	}
+	// Events: the Workspace and the objects named after it (Deployment, Service, HPA,
+	// Ingress, Job), plus its pods and ReplicaSets, found by the workspace label. Matching
+	// exact objects keeps events of a workspace "demo-2" out of "demo".
+	related := map[string]bool{}
+	for _, p := range podList {
+		related["Pod/"+p.Name] = true
+		for _, ref := range p.OwnerReferences {
+			related[ref.Kind+"/"+ref.Name] = true
+		}
+	}
+	if rss, err := cli.AppsV1().ReplicaSets(ns).List(ctx, metav1.ListOptions{LabelSelector: k8s.WorkspaceLabel + "=" + name}); err == nil {
+		for _, rs := range rss.Items {
+			related["ReplicaSet/"+rs.Name] = true
+		}
+	}
+	if evs, err := cli.CoreV1().Events(ns).List(ctx, metav1.ListOptions{}); err == nil {
+		for _, ev := range evs.Items {
+			on := ev.InvolvedObject.Name
+			if on != name && !related[ev.InvolvedObject.Kind+"/"+on] {
+				continue
+			}
+			out.Events = append(out.Events, DescribeEvent{Type: ev.Type, Reason: ev.Reason, Message: ev.Message, Object: ev.InvolvedObject.Kind + "/" + on, Count: ev.Count, LastSeen: eventLastSeen(&ev)})
+		}
+		sort.Slice(out.Events, func(i, j int) bool { return out.Events[i].LastSeen.After(out.Events[j].LastSeen) })
+		if len(out.Events) > 50 {
+			out.Events = out.Events[:50]
+		}
+	} else {
+		out.Errors["events"] = err.Error()
+	}
+	if int64(len(out.Logs)) > tail && tail > 0 {
+		out.Logs = out.Logs[int64(len(out.Logs))-tail:]
+	}
+	if len(out.Errors) == 0 {
+		out.Errors = nil
+	}
+	return out, nil
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDescribeWorkspace(t *testing.T) {
+	ws := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "guildnet.io/v1alpha1",
+		"kind":       "Workspace",
+		"metadata":   map[string]any{"name": "demo", "namespace": "default"},
+		"spec":       map[string]any{"image": "nginx"},
+		"status":     map[string]any{"phase": "Running"},
+	}}
+	dyn := dynfake.NewSimpleDynamicClient(runtime.NewScheme(), ws)
+
+	now := time.Now()
+	ev := func(name, kind, obj string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: obj},
+			Reason:         name,
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo-7f9-abc", Namespace: "default", Labels: map[string]string{"guildnet.io/workspace": "demo"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "demo-7f9"}}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{
+			Name: "main", RestartCount: 2, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		}}},
+	}
+	cli := fake.NewSimpleClientset(pod,
+		ev("old", "Pod", "demo-7f9-abc", now.Add(-time.Hour)),
+		ev("scaled", "ReplicaSet", "demo-7f9", now.Add(-time.Minute)),
+		ev("new", "Deployment", "demo", now),
+		ev("other", "Pod", "demonstration", now),
+		// workspace "demo-2" shares the prefix but is a different workspace
+		ev("sibling", "Deployment", "demo-2", now),
+		ev("sibling-pod", "Pod", "demo-2-5c8-xyz", now),
+	)
+
+	desc, err := describeWorkspace(context.Background(), cli, dyn, "default", "demo", 10)
+	if err != nil {
+		t.Fatalf("describe: %v", err)
+	}
+	if desc.Spec["image"] != "nginx" || desc.Status["phase"] != "Running" {
+		t.Fatalf("unexpected spec/status: %v %v", desc.Spec, desc.Status)
+	}
+	if len(desc.Events) != 3 || desc.Events[0].Reason != "new" || desc.Events[1].Reason != "scaled" {
+		t.Fatalf("events = %+v", desc.Events)
+	}
+	if len(desc.Pods) != 1 || len(desc.Pods[0].Containers) != 1 {
+		t.Fatalf("pods = %+v", desc.Pods)
+	}
+	if c := desc.Pods[0].Containers[0]; c.State != "waiting" || c.Reason != "CrashLoopBackOff" || c.Restarts != 2 {
+		t.Fatalf("container = %+v", c)
+	}
+
+	if _, err := describeWorkspace(context.Background(), cli, dyn, "default", "missing", 10); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWorkspaceEndpoints(t *testing.T) {
+	obj := map[string]any{
+		"metadata": map[string]any{"name": "ide"},
+		"spec": map[string]any{"ports": []any{
+			map[string]any{"name": "http", "containerPort": int64(8080)},
+			map[string]any{"name": "https", "containerPort": int64(8443)},
+			map[string]any{"containerPort": int64(9000)},
+		}},
+	}
+	r := httptest.NewRequest("GET", "http://guild.local/api/cluster/c1/workspaces/ide", nil)
+	got := workspaceEndpoints(obj, "", r, "c1")
+	want := []workspaceEndpoint{
+		{PortName: "http", Port: 8080, Scheme: "http", ProxyURL: "/api/cluster/c1/proxy/server/ide/"},
+		{PortName: "https", Port: 8443, Scheme: "https", ProxyURL: "/api/cluster/c1/proxy/server/ide:https/"},
+		{Port: 9000, Scheme: "http", ProxyURL: "/api/cluster/c1/proxy/server/ide:9000/"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d endpoints: %+v", len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("endpoint %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// No declared ports: the operator's default port
+	def := workspaceEndpoints(map[string]any{"metadata": map[string]any{"name": "ide"}}, "request", r, "c1")
+	if len(def) != 1 || def[0].Port != 8080 || def[0].ProxyURL != "http://guild.local/api/cluster/c1/proxy/server/ide/" {
+		t.Fatalf("default endpoints = %+v", def)
+	}
+
+	for seg, want := range map[string][2]string{"ide": {"ide", ""}, "ide:https": {"ide", "https"}, "ide:9000": {"ide", "9000"}} {
+		if n, p := splitServerPort(seg); n != want[0] || p != want[1] {
+			t.Fatalf("splitServerPort(%q) = %q, %q", seg, n, p)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// workspaceNameAttempts bounds the suffixed names tried after a collision.
+const workspaceNameAttempts = 10
+
+// CreateWorkspaceUnique creates the Workspace obj in ns. When its name is taken, it retries
+// with "<name>-<5 hex chars>" (trimmed to stay a 63-char DNS label) and returns the name
+// actually created. Errors other than AlreadyExists are returned immediately.
+func CreateWorkspaceUnique(ctx context.Context, dyn dynamic.Interface, ns string, obj *unstructured.Unstructured) (string, error) {
+	gvr := schema.GroupVersionResource{Group: "guildnet.io", Version: "v1alpha1", Resource: "workspaces"}
+	base := obj.GetName()
+	if base == "" {
+		base = "workspace"
+		obj.SetName(base)
+	}
+	for attempt := 0; ; attempt++ {
+		_, err := dyn.Resource(gvr).Namespace(ns).Create(ctx, obj, metav1.CreateOptions{})
+		if err == nil {
+			return obj.GetName(), nil
+		}
+		if !apierrors.IsAlreadyExists(err) || attempt >= workspaceNameAttempts {
+			return "", err
+		}
+		obj.SetName(suffixedName(base))
+	}
+}
+
+// suffixedName appends a random 5-hex-char suffix, trimming base so the result fits
+// in a DNS-1123 label.
+func suffixedName(base string) string {
+	buf := make([]byte, 3)
+	_, _ = rand.Read(buf)
+	sfx := hex.EncodeToString(buf)[:5]
+	if len(base) > 63-len(sfx)-1 {
+		base = strings.TrimRight(base[:63-len(sfx)-1], "-")
+	}
+	return base + "-" + sfx
+}
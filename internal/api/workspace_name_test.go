@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestCreateWorkspaceUniqueSuffixesOnCollision(t *testing.T) {
+	ws := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "guildnet.io/v1alpha1",
+			"kind":       "Workspace",
+			"metadata":   map[string]any{"name": name, "namespace": "default"},
+			"spec":       map[string]any{"image": "nginx"},
+		}}
+	}
+	dyn := dynfake.NewSimpleDynamicClient(runtime.NewScheme(), ws("demo"))
+	ctx := context.Background()
+
+	got, err := CreateWorkspaceUnique(ctx, dyn, "default", ws("fresh"))
+	if err != nil || got != "fresh" {
+		t.Fatalf("free name: got %q err=%v", got, err)
+	}
+	got, err = CreateWorkspaceUnique(ctx, dyn, "default", ws("demo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "demo-") || len(got) != len("demo-")+5 {
+		t.Fatalf("collision: got %q want demo-<5 hex>", got)
+	}
+
+	long := strings.Repeat("a", 62) + "b"
+	if _, err := CreateWorkspaceUnique(ctx, dyn, "default", ws(long)); err != nil {
+		t.Fatal(err)
+	}
+	got, err = CreateWorkspaceUnique(ctx, dyn, "default", ws(long))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) > 63 || !strings.HasPrefix(got, strings.Repeat("a", 57)+"-") {
+		t.Fatalf("long collision: got %q (%d chars)", got, len(got))
+	}
+}
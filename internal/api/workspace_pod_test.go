@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/docxology/GuildNet/internal/k8s"
+)
+
+func TestWorkspacePod(t *testing.T) {
+	ctx := context.Background()
+	pod := func(name string, labels map[string]string, ready bool) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "workspace", Ready: ready}}},
+		}
+	}
+	cli := fake.NewSimpleClientset(
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "ws1", Namespace: "default"}, Spec: corev1.ServiceSpec{Selector: map[string]string{"guildnet.io/workspace": "ws1", "app": "ws1"}}},
+		pod("ws1-a", map[string]string{"guildnet.io/workspace": "ws1", "app": "ws1"}, false),
+		pod("ws1-b", map[string]string{"guildnet.io/workspace": "ws1", "app": "ws1"}, true),
+		pod("other", map[string]string{"app": "other"}, true),
+		pod("legacy-x", map[string]string{"app": "legacy"}, false),
+	)
+	objs := k8s.Uncached(cli)
+
+	name, sel, err := workspacePod(ctx, objs, "default", "ws1")
+	if err != nil || name != "ws1-b" || sel != "app=ws1,guildnet.io/workspace=ws1" {
+		t.Fatalf("ws1: pod=%q selector=%q err=%v", name, sel, err)
+	}
+	// No Service: fall back to app=<name>, and a pod that is not ready still qualifies
+	if name, sel, err := workspacePod(ctx, objs, "default", "legacy"); err != nil || name != "legacy-x" || sel != "app=legacy" {
+		t.Fatalf("legacy: pod=%q selector=%q err=%v", name, sel, err)
+	}
+	if _, _, err := workspacePod(ctx, objs, "default", "missing"); err == nil {
+		t.Fatal("expected error when no pod matches")
+	}
+}
@@ -27,6 +27,9 @@ func (f *fakeCF) CreateDatabase(ctx context.Context, orgID, dbID, name, descript
 func (f *fakeCF) GetDatabase(ctx context.Context, orgID, dbID string) (model.DatabaseInstance, error) {
 	return model.DatabaseInstance{}, nil
 }
+func (f *fakeCF) DatabaseExists(ctx context.Context, orgID, dbID string) (bool, error) {
+	return true, nil
+}
 func (f *fakeCF) DeleteDatabase(ctx context.Context, orgID, dbID string) error { return nil }
 func (f *fakeCF) GetTables(ctx context.Context, orgID, dbID string) ([]model.Table, error) {
 	return nil, nil
@@ -37,21 +40,55 @@ func (f *fakeCF) CreateTable(ctx context.Context, orgID, dbID string, t model.Ta
 func (f *fakeCF) UpdateTableSchema(ctx context.Context, orgID, dbID, table string, schema []model.ColumnDef, pk string) error {
 	return nil
 }
-func (f *fakeCF) QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool) ([]map[string]any, string, error) {
+func (f *fakeCF) SetTableAuditReads(ctx context.Context, orgID, dbID, table string, on bool) error {
+	return nil
+}
+func (f *fakeCF) QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, error) {
 	return nil, "", nil
 }
+func (f *fakeCF) QueryRowsProfiled(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, any, error) {
+	return nil, "", nil, nil
+}
 func (f *fakeCF) InsertRows(ctx context.Context, orgID, dbID, table string, rows []map[string]any) ([]string, error) {
 	return nil, nil
 }
 func (f *fakeCF) UpdateRow(ctx context.Context, orgID, dbID, table, id string, patch map[string]any) error {
 	return nil
 }
-func (f *fakeCF) DeleteRow(ctx context.Context, orgID, dbID, table, id string) error { return nil }
+func (f *fakeCF) GetRow(ctx context.Context, orgID, dbID, table, id string) (map[string]any, error) {
+	return nil, db.ErrNotFound
+}
+func (f *fakeCF) DeleteRow(ctx context.Context, orgID, dbID, table, id string) error  { return nil }
+func (f *fakeCF) RestoreRow(ctx context.Context, orgID, dbID, table, id string) error { return nil }
+func (f *fakeCF) TruncateTable(ctx context.Context, orgID, dbID, table string) (int, error) {
+	return 0, nil
+}
+func (f *fakeCF) DeleteRows(ctx context.Context, orgID, dbID, table string, filter map[string]any) (int64, error) {
+	return 0, nil
+}
+func (f *fakeCF) BatchGet(ctx context.Context, orgID, dbID, table string, ids []string) ([]map[string]any, error) {
+	return make([]map[string]any, len(ids)), nil
+}
+func (f *fakeCF) Aggregate(ctx context.Context, orgID, dbID, table, groupBy string) (map[string]int64, error) {
+	return map[string]int64{}, nil
+}
 func (f *fakeCF) ListAudit(ctx context.Context, orgID, dbID string, limit int) ([]model.AuditEvent, error) {
 	return nil, nil
 }
+func (f *fakeCF) IndexStatus(ctx context.Context, orgID, dbID string) ([]db.TableIndexStatus, error) {
+	return nil, nil
+}
 func (f *fakeCF) Ping(ctx context.Context) error { return nil }
 
+func (f *fakeCF) SubscribeTableFiltered(ctx context.Context, orgID, dbID, table string, _ db.ChangefeedFilter) (*db.ChangefeedStream, error) {
+	return f.SubscribeTable(ctx, orgID, dbID, table)
+}
+func (f *fakeCF) SubscribeDatabase(ctx context.Context, orgID, dbID string) (*db.ChangefeedStream, error) {
+	return f.SubscribeDatabaseFiltered(ctx, orgID, dbID, db.ChangefeedFilter{})
+}
+func (f *fakeCF) SubscribeDatabaseFiltered(ctx context.Context, orgID, dbID string, _ db.ChangefeedFilter) (*db.ChangefeedStream, error) {
+	return nil, nil
+}
 func (f *fakeCF) SubscribeTable(ctx context.Context, orgID, dbID, table string) (*db.ChangefeedStream, error) {
 	ch := make(chan model.ChangefeedEvent, 4)
 	// populate with a single init event then block until canceled
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -52,19 +53,28 @@ type Instance struct {
 	ctx context.Context
 	wg  sync.WaitGroup
 
+	// diagnostics (guarded by mu)
+	lastUsed time.Time
+	lastErr  string
+
 	// teardown coordination
 	cancel func()
+	tsStop sync.Once
 }
 
 // Status represents lightweight lifecycle status.
 type Status struct {
-	ID        string
-	Started   bool
-	StateDir  string
-	HasDB     bool
-	HasK8s    bool
-	Forwards  int
-	CreatedAt time.Time
+	ID           string    `json:"id"`
+	Started      bool      `json:"started"`
+	StateDir     string    `json:"stateDir,omitempty"`
+	HasDB        bool      `json:"hasDB"`
+	HasK8s       bool      `json:"hasK8s"`
+	HasDyn       bool      `json:"hasDyn"`
+	RDBConnected bool      `json:"rdbConnected"`
+	Forwards     int       `json:"forwards"`
+	CreatedAt    time.Time `json:"createdAt,omitempty"`
+	LastUsed     time.Time `json:"lastUsed,omitempty"`
+	LastError    string    `json:"lastError,omitempty"`
 }
 
 // Resolver provides cluster-specific materials needed to start an Instance.
@@ -85,10 +95,14 @@ type Registry struct {
 	opts    Options
 	items   map[string]*Instance
 	created map[string]time.Time
+	// failed records the last Get error for clusters without an instance
+	failed map[string]string
+	// draining holds invalidated instances still within invalidateGrace
+	draining map[string]*Instance
 }
 
 func NewRegistry(opts Options) *Registry {
-	return &Registry{opts: opts, items: map[string]*Instance{}, created: map[string]time.Time{}}
+	return &Registry{opts: opts, items: map[string]*Instance{}, created: map[string]time.Time{}, failed: map[string]string{}, draining: map[string]*Instance{}}
 }
 
 // hooks for testing/override
@@ -99,6 +113,16 @@ var (
 		return m, err
 	}
 	rdbPingInterval = 5 * time.Second
+	// invalidateGrace is how long an invalidated instance stays open for in-flight requests.
+	invalidateGrace = 30 * time.Second
+	// startConnector and stopConnector run a cluster's tsnet connector; tests override them.
+	startConnector = func(c *connector.Connector) error {
+		return c.Start(context.Background())
+	}
+	stopConnector = func(c *connector.Connector) {
+		_ = c.Stop(context.Background())
+		_ = c.CloseServer()
+	}
 )
 
 // Get returns an existing instance or creates a new one.
@@ -107,6 +131,7 @@ func (r *Registry) Get(ctx context.Context, clusterID string) (*Instance, error)
 	r.mu.RLock()
 	if inst, ok := r.items[id]; ok {
 		r.mu.RUnlock()
+		inst.touch()
 		return inst, nil
 	}
 	r.mu.RUnlock()
@@ -115,8 +140,21 @@ func (r *Registry) Get(ctx context.Context, clusterID string) (*Instance, error)
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if inst, ok := r.items[id]; ok {
+		inst.touch()
 		return inst, nil
 	}
+	inst, err := r.create(ctx, id)
+	if err != nil {
+		r.failed[id] = err.Error()
+		return nil, err
+	}
+	delete(r.failed, id)
+	inst.touch()
+	return inst, nil
+}
+
+// create builds a new Instance; caller holds r.mu.
+func (r *Registry) create(ctx context.Context, id string) (*Instance, error) {
 	if r.opts.Resolver == nil {
 		return nil, fmt.Errorf("cluster resolver not configured")
 	}
@@ -158,9 +196,14 @@ func (r *Registry) Get(ctx context.Context, clusterID string) (*Instance, error)
 			if h, err := os.UserHomeDir(); err == nil {
 				state = filepath.Join(h, ".guildnet", "tsnet", "cluster-"+id)
 			}
+			// An invalidated instance still draining runs a connector on the same state
+			// dir; it must release it before the replacement starts
+			if old := r.draining[id]; old != nil {
+				old.stopTS()
+			}
 			if c, err := connector.New(connector.Config{ClusterID: id, LoginServer: cs.TSLoginServer, ClientAuthKey: clientKey, StateDir: state}); err == nil {
-				// Best-effort start (non-blocking)
-				_ = c.Start(context.Background())
+				// Best-effort start
+				_ = startConnector(c)
 				conn = c
 			}
 		}
@@ -258,7 +301,11 @@ func (inst *Instance) EnsureRDB(ctx context.Context, addrOverride, user, pass st
 			delay = delay * 2
 		}
 	}
-	return fmt.Errorf("connect rethinkdb failed after retries: %w", lastErr)
+	err := fmt.Errorf("connect rethinkdb failed after retries: %w", lastErr)
+	inst.mu.Lock()
+	inst.lastErr = err.Error()
+	inst.mu.Unlock()
+	return err
 }
 
 // reconnect & health monitor: pings periodically and attempts reconnection on transient failures.
@@ -327,6 +374,44 @@ func (r *Registry) Close(clusterID string) error {
 	if !ok {
 		return nil
 	}
+	inst.shutdown()
+	delete(r.items, id)
+	delete(r.created, id)
+	log.Printf("cluster: stop id=%s", id)
+	return nil
+}
+
+// Invalidate drops the cached instance for clusterID so the next Get rebuilds its clients
+// from the current kubeconfig. The old instance keeps serving requests that already hold
+// it and is shut down after invalidateGrace.
+func (r *Registry) Invalidate(clusterID string) {
+	id := NormalID(clusterID)
+	r.mu.Lock()
+	inst, ok := r.items[id]
+	delete(r.items, id)
+	delete(r.created, id)
+	delete(r.failed, id)
+	if ok {
+		r.draining[id] = inst
+	}
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	log.Printf("cluster: invalidate id=%s", id)
+	time.AfterFunc(invalidateGrace, func() {
+		r.mu.Lock()
+		if r.draining[id] == inst {
+			delete(r.draining, id)
+		}
+		r.mu.Unlock()
+		inst.shutdown()
+	})
+}
+
+// shutdown stops background work, closes the instance's databases and stops its tsnet
+// connector.
+func (inst *Instance) shutdown() {
 	if inst.cancel != nil {
 		inst.cancel()
 	}
@@ -342,25 +427,100 @@ func (r *Registry) Close(clusterID string) error {
 	if inst.wg != (sync.WaitGroup{}) {
 		inst.wg.Wait()
 	}
-	// No explicit Close for K8s client; GC handles it. Port forwards will die with cancel.
-	delete(r.items, id)
-	delete(r.created, id)
-	log.Printf("cluster: stop id=%s", id)
-	return nil
+	// Stop the client's Service/Pod informers and any open port-forwards
+	if inst.K8s != nil {
+		inst.K8s.Close()
+	}
+	if inst.PF != nil {
+		inst.PF.Close()
+	}
+	inst.stopTS()
+}
+
+// stopTS stops the instance's tsnet connector once, releasing its state dir.
+func (inst *Instance) stopTS() {
+	if inst.TS == nil {
+		return
+	}
+	inst.tsStop.Do(func() { stopConnector(inst.TS) })
 }
 
-// List returns current instance IDs.
+// List returns the status of every started instance plus clusters whose last Get failed.
 func (r *Registry) List() []Status {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	out := make([]Status, 0, len(r.items))
+	out := make([]Status, 0, len(r.items)+len(r.failed))
 	for id, inst := range r.items {
-		s := Status{ID: id, Started: true, StateDir: inst.stateDir, HasDB: inst.DB != nil, HasK8s: inst.K8s != nil, CreatedAt: r.created[id]}
-		out = append(out, s)
+		out = append(out, inst.status(r.created[id]))
+	}
+	for id, msg := range r.failed {
+		out = append(out, Status{ID: id, LastError: msg})
 	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
 	return out
 }
 
+// Warm builds the instance for clusterID if needed and verifies Kubernetes (and, when
+// present, RethinkDB) connectivity. The result is recorded as the instance's last error.
+func (r *Registry) Warm(ctx context.Context, clusterID string) (Status, error) {
+	inst, err := r.Get(ctx, clusterID)
+	if err != nil {
+		return Status{ID: NormalID(clusterID), LastError: err.Error()}, err
+	}
+	werr := inst.checkConnectivity(ctx)
+	inst.mu.Lock()
+	inst.lastErr = ""
+	if werr != nil {
+		inst.lastErr = werr.Error()
+	}
+	inst.mu.Unlock()
+	r.mu.RLock()
+	created := r.created[inst.id]
+	r.mu.RUnlock()
+	return inst.status(created), werr
+}
+
+func (inst *Instance) checkConnectivity(ctx context.Context) error {
+	if inst.K8s == nil || inst.K8s.K == nil {
+		return fmt.Errorf("k8s client not built")
+	}
+	if _, err := inst.K8s.K.Discovery().ServerVersion(); err != nil {
+		return fmt.Errorf("k8s: %w", err)
+	}
+	inst.mu.Lock()
+	mgr := inst.RDB
+	inst.mu.Unlock()
+	if mgr != nil {
+		if err := mgr.Ping(ctx); err != nil {
+			return fmt.Errorf("rdb: %w", err)
+		}
+	}
+	return nil
+}
+
+func (inst *Instance) touch() {
+	inst.mu.Lock()
+	inst.lastUsed = time.Now()
+	inst.mu.Unlock()
+}
+
+func (inst *Instance) status(created time.Time) Status {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	return Status{
+		ID:           inst.id,
+		Started:      true,
+		StateDir:     inst.stateDir,
+		HasDB:        inst.DB != nil,
+		HasK8s:       inst.K8s != nil,
+		HasDyn:       inst.Dyn != nil,
+		RDBConnected: inst.RDB != nil,
+		CreatedAt:    created,
+		LastUsed:     inst.lastUsed,
+		LastError:    inst.lastErr,
+	}
+}
+
 func sanitizeID(s string) string {
 	// keep simple: lowercase alnum and dash
 	b := make([]rune, 0, len(s))
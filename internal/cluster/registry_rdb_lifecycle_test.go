@@ -27,6 +27,9 @@ func (f *fakeHTTPDB) CreateDatabase(ctx context.Context, orgID, dbID, name, desc
 func (f *fakeHTTPDB) GetDatabase(ctx context.Context, orgID, dbID string) (model.DatabaseInstance, error) {
 	return model.DatabaseInstance{}, nil
 }
+func (f *fakeHTTPDB) DatabaseExists(ctx context.Context, orgID, dbID string) (bool, error) {
+	return true, nil
+}
 func (f *fakeHTTPDB) DeleteDatabase(ctx context.Context, orgID, dbID string) error { return nil }
 func (f *fakeHTTPDB) GetTables(ctx context.Context, orgID, dbID string) ([]model.Table, error) {
 	return nil, nil
@@ -37,22 +40,57 @@ func (f *fakeHTTPDB) CreateTable(ctx context.Context, orgID, dbID string, t mode
 func (f *fakeHTTPDB) UpdateTableSchema(ctx context.Context, orgID, dbID, table string, schema []model.ColumnDef, pk string) error {
 	return nil
 }
-func (f *fakeHTTPDB) QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool) ([]map[string]any, string, error) {
+func (f *fakeHTTPDB) SetTableAuditReads(ctx context.Context, orgID, dbID, table string, on bool) error {
+	return nil
+}
+func (f *fakeHTTPDB) QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, error) {
 	return nil, "", nil
 }
+func (f *fakeHTTPDB) QueryRowsProfiled(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, any, error) {
+	return nil, "", nil, nil
+}
 func (f *fakeHTTPDB) InsertRows(ctx context.Context, orgID, dbID, table string, rows []map[string]any) ([]string, error) {
 	return nil, nil
 }
 func (f *fakeHTTPDB) UpdateRow(ctx context.Context, orgID, dbID, table, id string, patch map[string]any) error {
 	return nil
 }
-func (f *fakeHTTPDB) DeleteRow(ctx context.Context, orgID, dbID, table, id string) error { return nil }
+func (f *fakeHTTPDB) GetRow(ctx context.Context, orgID, dbID, table, id string) (map[string]any, error) {
+	return nil, db.ErrNotFound
+}
+func (f *fakeHTTPDB) DeleteRow(ctx context.Context, orgID, dbID, table, id string) error  { return nil }
+func (f *fakeHTTPDB) RestoreRow(ctx context.Context, orgID, dbID, table, id string) error { return nil }
+func (f *fakeHTTPDB) TruncateTable(ctx context.Context, orgID, dbID, table string) (int, error) {
+	return 0, nil
+}
+func (f *fakeHTTPDB) DeleteRows(ctx context.Context, orgID, dbID, table string, filter map[string]any) (int64, error) {
+	return 0, nil
+}
+func (f *fakeHTTPDB) BatchGet(ctx context.Context, orgID, dbID, table string, ids []string) ([]map[string]any, error) {
+	return make([]map[string]any, len(ids)), nil
+}
+func (f *fakeHTTPDB) Aggregate(ctx context.Context, orgID, dbID, table, groupBy string) (map[string]int64, error) {
+	return map[string]int64{}, nil
+}
 func (f *fakeHTTPDB) ListAudit(ctx context.Context, orgID, dbID string, limit int) ([]model.AuditEvent, error) {
 	return nil, nil
 }
+func (f *fakeHTTPDB) SubscribeTableFiltered(ctx context.Context, orgID, dbID, table string, _ db.ChangefeedFilter) (*db.ChangefeedStream, error) {
+	return f.SubscribeTable(ctx, orgID, dbID, table)
+}
+func (f *fakeHTTPDB) SubscribeDatabase(ctx context.Context, orgID, dbID string) (*db.ChangefeedStream, error) {
+	return f.SubscribeDatabaseFiltered(ctx, orgID, dbID, db.ChangefeedFilter{})
+}
+func (f *fakeHTTPDB) SubscribeDatabaseFiltered(ctx context.Context, orgID, dbID string, _ db.ChangefeedFilter) (*db.ChangefeedStream, error) {
+	return nil, nil
+}
 func (f *fakeHTTPDB) SubscribeTable(ctx context.Context, orgID, dbID, table string) (*db.ChangefeedStream, error) {
 	return nil, nil
 }
+func (f *fakeHTTPDB) IndexStatus(ctx context.Context, orgID, dbID string) ([]db.TableIndexStatus, error) {
+	return nil, nil
+}
+
 func (f *fakeHTTPDB) Ping(ctx context.Context) error {
 	if atomic.LoadInt32(&f.pingOK) == 1 {
 		return nil
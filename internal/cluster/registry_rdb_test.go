@@ -22,6 +22,9 @@ func (f *fakeDBMgr) CreateDatabase(ctx context.Context, orgID, dbID, name, descr
 func (f *fakeDBMgr) GetDatabase(ctx context.Context, orgID, dbID string) (model.DatabaseInstance, error) {
 	return model.DatabaseInstance{}, nil
 }
+func (f *fakeDBMgr) DatabaseExists(ctx context.Context, orgID, dbID string) (bool, error) {
+	return true, nil
+}
 func (f *fakeDBMgr) DeleteDatabase(ctx context.Context, orgID, dbID string) error { return nil }
 func (f *fakeDBMgr) GetTables(ctx context.Context, orgID, dbID string) ([]model.Table, error) {
 	return nil, nil
@@ -32,22 +35,56 @@ func (f *fakeDBMgr) CreateTable(ctx context.Context, orgID, dbID string, t model
 func (f *fakeDBMgr) UpdateTableSchema(ctx context.Context, orgID, dbID, table string, schema []model.ColumnDef, pk string) error {
 	return nil
 }
-func (f *fakeDBMgr) QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool) ([]map[string]any, string, error) {
+func (f *fakeDBMgr) SetTableAuditReads(ctx context.Context, orgID, dbID, table string, on bool) error {
+	return nil
+}
+func (f *fakeDBMgr) QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, error) {
 	return nil, "", nil
 }
+func (f *fakeDBMgr) QueryRowsProfiled(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, any, error) {
+	return nil, "", nil, nil
+}
 func (f *fakeDBMgr) InsertRows(ctx context.Context, orgID, dbID, table string, rows []map[string]any) ([]string, error) {
 	return nil, nil
 }
 func (f *fakeDBMgr) UpdateRow(ctx context.Context, orgID, dbID, table, id string, patch map[string]any) error {
 	return nil
 }
-func (f *fakeDBMgr) DeleteRow(ctx context.Context, orgID, dbID, table, id string) error { return nil }
+func (f *fakeDBMgr) GetRow(ctx context.Context, orgID, dbID, table, id string) (map[string]any, error) {
+	return nil, db.ErrNotFound
+}
+func (f *fakeDBMgr) DeleteRow(ctx context.Context, orgID, dbID, table, id string) error  { return nil }
+func (f *fakeDBMgr) RestoreRow(ctx context.Context, orgID, dbID, table, id string) error { return nil }
+func (f *fakeDBMgr) TruncateTable(ctx context.Context, orgID, dbID, table string) (int, error) {
+	return 0, nil
+}
+func (f *fakeDBMgr) DeleteRows(ctx context.Context, orgID, dbID, table string, filter map[string]any) (int64, error) {
+	return 0, nil
+}
+func (f *fakeDBMgr) BatchGet(ctx context.Context, orgID, dbID, table string, ids []string) ([]map[string]any, error) {
+	return make([]map[string]any, len(ids)), nil
+}
+func (f *fakeDBMgr) Aggregate(ctx context.Context, orgID, dbID, table, groupBy string) (map[string]int64, error) {
+	return map[string]int64{}, nil
+}
 func (f *fakeDBMgr) ListAudit(ctx context.Context, orgID, dbID string, limit int) ([]model.AuditEvent, error) {
 	return nil, nil
 }
+func (f *fakeDBMgr) SubscribeTableFiltered(ctx context.Context, orgID, dbID, table string, _ db.ChangefeedFilter) (*db.ChangefeedStream, error) {
+	return f.SubscribeTable(ctx, orgID, dbID, table)
+}
+func (f *fakeDBMgr) SubscribeDatabase(ctx context.Context, orgID, dbID string) (*db.ChangefeedStream, error) {
+	return f.SubscribeDatabaseFiltered(ctx, orgID, dbID, db.ChangefeedFilter{})
+}
+func (f *fakeDBMgr) SubscribeDatabaseFiltered(ctx context.Context, orgID, dbID string, _ db.ChangefeedFilter) (*db.ChangefeedStream, error) {
+	return nil, nil
+}
 func (f *fakeDBMgr) SubscribeTable(ctx context.Context, orgID, dbID, table string) (*db.ChangefeedStream, error) {
 	return nil, nil
 }
+func (f *fakeDBMgr) IndexStatus(ctx context.Context, orgID, dbID string) ([]db.TableIndexStatus, error) {
+	return nil, nil
+}
 func (f *fakeDBMgr) Ping(ctx context.Context) error { return nil }
 
 // Close is not part of httpx.DBManager but our code expects concrete *db.Manager to be closable.
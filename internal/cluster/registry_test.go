@@ -2,9 +2,18 @@ package cluster
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/settings"
+	"github.com/docxology/GuildNet/internal/ts/connector"
 )
 
 type fakeResolver struct{ kc string }
@@ -54,3 +63,145 @@ func TestRegistryGetAndClose(t *testing.T) {
 		t.Fatalf("close: %v", err)
 	}
 }
+
+type errResolver struct{}
+
+func (errResolver) KubeconfigYAML(clusterID string) (string, error) { return "", nil }
+
+func TestRegistryListAndWarm(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRegistry(Options{StateDir: dir, Resolver: fakeResolver{kc: sampleKubeconfig}})
+	defer r.Close("c-warm")
+
+	// nothing listens on the sample kubeconfig server, so warm builds clients but fails the probe
+	st, err := r.Warm(context.Background(), "c-warm")
+	if err == nil {
+		t.Skip("unexpected k8s endpoint reachable at 127.0.0.1:8001")
+	}
+	if !st.Started || !st.HasK8s || st.LastError == "" || st.LastUsed.IsZero() {
+		t.Fatalf("unexpected warm status: %+v", st)
+	}
+	list := r.List()
+	if len(list) != 1 || list[0].ID != "c-warm" || list[0].LastError == "" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	bad := NewRegistry(Options{StateDir: dir, Resolver: errResolver{}})
+	if _, err := bad.Get(context.Background(), "c-missing"); err == nil {
+		t.Fatalf("expected get error")
+	}
+	if l := bad.List(); len(l) != 1 || l[0].Started || l[0].LastError == "" {
+		t.Fatalf("expected failed entry, got %+v", l)
+	}
+}
+
+type swapResolver struct {
+	mu sync.Mutex
+	kc string
+}
+
+func (s *swapResolver) KubeconfigYAML(clusterID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kc, nil
+}
+
+func (s *swapResolver) set(kc string) {
+	s.mu.Lock()
+	s.kc = kc
+	s.mu.Unlock()
+}
+
+func TestRegistryInvalidateRebuildsFromNewKubeconfig(t *testing.T) {
+	oldGrace := invalidateGrace
+	invalidateGrace = 0
+	defer func() { invalidateGrace = oldGrace }()
+
+	res := &swapResolver{kc: sampleKubeconfig}
+	r := NewRegistry(Options{StateDir: t.TempDir(), Resolver: res})
+	defer r.Close("c-swap")
+	ctx := context.Background()
+	inst1, err := r.Get(ctx, "c-swap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host := inst1.K8s.Config().Host; host != "http://127.0.0.1:8001" {
+		t.Fatalf("initial host=%s", host)
+	}
+
+	res.set(strings.Replace(sampleKubeconfig, "http://127.0.0.1:8001", "https://10.9.8.7:6443", 1))
+	if inst, _ := r.Get(ctx, "c-swap"); inst != inst1 {
+		t.Fatal("instance rebuilt before Invalidate")
+	}
+	r.Invalidate("c-swap")
+	inst2, err := r.Get(ctx, "c-swap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst2 == inst1 {
+		t.Fatal("Invalidate kept the old instance")
+	}
+	if host := inst2.K8s.Config().Host; host != "https://10.9.8.7:6443" {
+		t.Fatalf("rebuilt host=%s, want the new kubeconfig's server", host)
+	}
+	// Invalidating an unknown cluster is a no-op.
+	r.Invalidate("c-missing")
+}
+
+func TestRegistryInvalidateStopsTSConnector(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var mu sync.Mutex
+	var events []string
+	record := func(op string, c *connector.Connector) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, fmt.Sprintf("%s %p", op, c))
+	}
+	oldStart, oldStop, oldGrace := startConnector, stopConnector, invalidateGrace
+	startConnector = func(c *connector.Connector) error { record("start", c); return nil }
+	stopConnector = func(c *connector.Connector) { record("stop", c) }
+	invalidateGrace = time.Hour
+	defer func() { startConnector, stopConnector, invalidateGrace = oldStart, oldStop, oldGrace }()
+
+	dir := t.TempDir()
+	db, err := localdb.Open(filepath.Join(dir, "c-ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = settings.EnsureBucket(db)
+	if err := (settings.Manager{DB: db}).PutCluster("c-ts", settings.Cluster{TSLoginServer: "https://hs.example"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	r := NewRegistry(Options{StateDir: dir, Resolver: fakeResolver{kc: sampleKubeconfig}})
+	ctx := context.Background()
+	inst1, err := r.Get(ctx, "c-ts")
+	if err != nil || inst1.TS == nil {
+		t.Fatalf("get: %v, connector=%v", err, inst1)
+	}
+	// The old instance is still draining, but its connector is stopped before the
+	// replacement starts on the same state dir
+	r.Invalidate("c-ts")
+	inst2, err := r.Get(ctx, "c-ts")
+	if err != nil || inst2.TS == nil || inst2.TS == inst1.TS {
+		t.Fatalf("rebuild: %v", err)
+	}
+	want := []string{fmt.Sprintf("start %p", inst1.TS), fmt.Sprintf("stop %p", inst1.TS), fmt.Sprintf("start %p", inst2.TS)}
+	mu.Lock()
+	got := append([]string(nil), events...)
+	mu.Unlock()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events=%v want %v", got, want)
+	}
+
+	// Shutting an instance down stops its connector once
+	_ = r.Close("c-ts")
+	inst1.shutdown()
+	mu.Lock()
+	got = append([]string(nil), events...)
+	mu.Unlock()
+	if want := append(want, fmt.Sprintf("stop %p", inst2.TS)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events=%v want %v", got, want)
+	}
+}
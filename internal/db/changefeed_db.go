@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// tableChange reports a table appearing in or leaving a database's _schemas list.
+type tableChange struct {
+	Name    string
+	Dropped bool
+	Err     error
+}
+
+// SubscribeDatabase streams changes from every user table in the database as one feed.
+// Events carry their table in TableID. Tables created during the subscription are
+// picked up (announced with a "table_added" event, then their initial rows), and a
+// "table_dropped" event follows the last change of a table whose schema entry is
+// removed or whose feed ends.
+func (m *Manager) SubscribeDatabase(ctx context.Context, orgID, dbID string) (*ChangefeedStream, error) {
+	return m.SubscribeDatabaseFiltered(ctx, orgID, dbID, ChangefeedFilter{})
+}
+
+// SubscribeDatabaseFiltered is SubscribeDatabase with f applied to every table feed.
+func (m *Manager) SubscribeDatabaseFiltered(ctx context.Context, orgID, dbID string, f ChangefeedFilter) (*ChangefeedStream, error) {
+	if err := m.ensureMetaTables(ctx, orgID, dbID); err != nil {
+		return nil, err
+	}
+	dbn := dbName(orgID, dbID)
+	cur, err := r.DB(dbn).Table("_schemas").Changes(r.ChangesOpts{IncludeInitial: true}).Run(m.sess)
+	if err != nil {
+		return nil, err
+	}
+	tables := make(chan tableChange)
+	go func() {
+		defer close(tables)
+		type schemaRow struct {
+			Name string `json:"name"`
+		}
+		type raw struct {
+			NewVal *schemaRow `json:"new_val"`
+			OldVal *schemaRow `json:"old_val"`
+		}
+		for {
+			var rchg raw
+			if !cur.Next(&rchg) {
+				break
+			}
+			var tc tableChange
+			switch {
+			case rchg.NewVal != nil:
+				tc.Name = rchg.NewVal.Name
+			case rchg.OldVal != nil:
+				tc = tableChange{Name: rchg.OldVal.Name, Dropped: true}
+			default:
+				continue
+			}
+			select {
+			case tables <- tc:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := cur.Err(); err != nil {
+			select {
+			case tables <- tableChange{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	subscribe := func(ctx context.Context, table string) (*ChangefeedStream, error) {
+		return m.SubscribeTableFiltered(ctx, orgID, dbID, table, f)
+	}
+	return fanInTables(ctx, tables, subscribe, func() { cur.Close() }), nil
+}
+
+// fanInTables merges the feeds of the tables announced on tables into one stream,
+// subscribing as tables appear and cancelling as they go. The stream ends when ctx is
+// done, the table list ends, or Cancel is called; stop releases the table list feed.
+func fanInTables(ctx context.Context, tables <-chan tableChange, subscribe func(context.Context, string) (*ChangefeedStream, error), stop func()) *ChangefeedStream {
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan model.ChangefeedEvent, 256)
+	type ended struct {
+		table  string
+		stream *ChangefeedStream
+	}
+	endedC := make(chan ended)
+	go func() {
+		defer close(out)
+		active := map[string]*ChangefeedStream{}
+		var wg sync.WaitGroup
+		defer func() {
+			cancel()
+			stop()
+			for _, s := range active {
+				s.Cancel()
+			}
+			wg.Wait()
+		}()
+		emit := func(ev model.ChangefeedEvent) bool {
+			ev.TS = model.NowISO()
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case tc, ok := <-tables:
+				if !ok {
+					return
+				}
+				if tc.Err != nil {
+					emit(model.ChangefeedEvent{Type: "error", Error: tc.Err.Error()})
+					return
+				}
+				if tc.Name == "" || strings.HasPrefix(tc.Name, "_") {
+					continue
+				}
+				if tc.Dropped {
+					if s, ok := active[tc.Name]; ok {
+						delete(active, tc.Name)
+						s.Cancel()
+						if !emit(model.ChangefeedEvent{Type: "table_dropped", TableID: tc.Name}) {
+							return
+						}
+					}
+					continue
+				}
+				if _, ok := active[tc.Name]; ok {
+					continue // schema update for a table already streaming
+				}
+				s, err := subscribe(ctx, tc.Name)
+				if err != nil {
+					if !emit(model.ChangefeedEvent{Type: "error", TableID: tc.Name, Error: err.Error()}) {
+						return
+					}
+					continue
+				}
+				active[tc.Name] = s
+				if !emit(model.ChangefeedEvent{Type: "table_added", TableID: tc.Name}) {
+					return
+				}
+				wg.Add(1)
+				go func(table string, s *ChangefeedStream) {
+					defer wg.Done()
+					for {
+						select {
+						case ev, ok := <-s.C:
+							if !ok {
+								select {
+								case endedC <- ended{table, s}:
+								case <-ctx.Done():
+								}
+								return
+							}
+							select {
+							case out <- ev:
+							case <-ctx.Done():
+								return
+							}
+						case <-ctx.Done():
+							return
+						}
+					}
+				}(tc.Name, s)
+			case e := <-endedC:
+				// A feed that ends on its own means the table went away underneath us
+				if active[e.table] == e.stream {
+					delete(active, e.table)
+					if !emit(model.ChangefeedEvent{Type: "table_dropped", TableID: e.table}) {
+						return
+					}
+				}
+			}
+		}
+	}()
+	return &ChangefeedStream{C: out, Cancel: cancel}
+}
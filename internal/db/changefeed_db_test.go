@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+func TestFanInTablesFollowsTableList(t *testing.T) {
+	feeds := map[string]chan model.ChangefeedEvent{}
+	subscribe := func(ctx context.Context, table string) (*ChangefeedStream, error) {
+		ch := make(chan model.ChangefeedEvent, 4)
+		feeds[table] = ch
+		ch <- model.ChangefeedEvent{Type: "insert", TableID: table}
+		return &ChangefeedStream{C: ch, Cancel: func() {}}, nil
+	}
+	tables := make(chan tableChange)
+	stopped := make(chan struct{})
+	s := fanInTables(context.Background(), tables, subscribe, func() { close(stopped) })
+
+	next := func() model.ChangefeedEvent {
+		t.Helper()
+		select {
+		case ev := <-s.C:
+			return ev
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+		return model.ChangefeedEvent{}
+	}
+	expect := func(typ, table string) {
+		t.Helper()
+		if ev := next(); ev.Type != typ || ev.TableID != table {
+			t.Fatalf("got %s/%s want %s/%s", ev.Type, ev.TableID, typ, table)
+		}
+	}
+
+	tables <- tableChange{Name: "_audit"} // meta tables are never streamed
+	tables <- tableChange{Name: "users"}
+	expect("table_added", "users")
+	expect("insert", "users")
+	tables <- tableChange{Name: "users"} // schema update: no second subscription
+	tables <- tableChange{Name: "orders"}
+	expect("table_added", "orders")
+	expect("insert", "orders")
+
+	// Dropped from the schema list
+	tables <- tableChange{Name: "users", Dropped: true}
+	expect("table_dropped", "users")
+	// Feed ended underneath us
+	close(feeds["orders"])
+	expect("table_dropped", "orders")
+
+	s.Cancel()
+	for range s.C {
+	}
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("table list feed not released")
+	}
+	if _, ok := feeds["_audit"]; ok {
+		t.Fatal("meta table subscribed")
+	}
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// ChangefeedFilter narrows a table changefeed. Empty Ops means all operations; empty
+// Columns means every change and full documents. With Columns set, updates are only
+// emitted when one of the columns changed, and before/after are trimmed to those
+// columns plus the primary key.
+type ChangefeedFilter struct {
+	Ops     []string
+	Columns []string
+}
+
+// ParseChangefeedFilter reads comma separated ops (insert,update,delete) and columns.
+func ParseChangefeedFilter(ops, columns string) (ChangefeedFilter, error) {
+	var f ChangefeedFilter
+	for _, op := range splitList(ops) {
+		switch op {
+		case "insert", "update", "delete":
+			f.Ops = append(f.Ops, op)
+		default:
+			return f, fmt.Errorf("unsupported op %q (want insert, update or delete)", op)
+		}
+	}
+	f.Columns = splitList(columns)
+	return f, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Empty reports whether the filter lets everything through unchanged.
+func (f ChangefeedFilter) Empty() bool { return len(f.Ops) == 0 && len(f.Columns) == 0 }
+
+// term builds the server-side predicate for a changes() document so unwanted events are
+// dropped inside RethinkDB rather than shipped to the host.
+func (f ChangefeedFilter) term(c r.Term) r.Term {
+	oldV := c.Field("old_val").Default(nil)
+	newV := c.Field("new_val").Default(nil)
+	pred := r.Expr(true)
+	if len(f.Ops) > 0 {
+		var ops []interface{}
+		for _, op := range f.Ops {
+			switch op {
+			case "insert":
+				ops = append(ops, oldV.Eq(nil).And(newV.Ne(nil)))
+			case "update":
+				ops = append(ops, oldV.Ne(nil).And(newV.Ne(nil)))
+			case "delete":
+				ops = append(ops, oldV.Ne(nil).And(newV.Eq(nil)))
+			}
+		}
+		pred = r.Or(ops...)
+	}
+	if len(f.Columns) > 0 {
+		changed := make([]interface{}, 0, len(f.Columns))
+		for _, col := range f.Columns {
+			changed = append(changed, oldV.Field(col).Default(nil).Ne(newV.Field(col).Default(nil)))
+		}
+		// inserts/deletes always touch every column; updates must change a watched one
+		pred = pred.And(oldV.Eq(nil).Or(newV.Eq(nil)).Or(r.Or(changed...)))
+	}
+	return pred
+}
+
+// Match is the client-side equivalent of term, applied to decoded events.
+func (f ChangefeedFilter) Match(ev model.ChangefeedEvent) bool {
+	if len(f.Ops) > 0 {
+		ok := false
+		for _, op := range f.Ops {
+			if op == ev.Type {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(f.Columns) > 0 && ev.Type == "update" {
+		before, _ := ev.Before.(map[string]any)
+		after, _ := ev.After.(map[string]any)
+		for _, col := range f.Columns {
+			if fmt.Sprint(before[col]) != fmt.Sprint(after[col]) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Project trims before/after to the filtered columns (keeping "id").
+func (f ChangefeedFilter) Project(ev model.ChangefeedEvent) model.ChangefeedEvent {
+	if len(f.Columns) == 0 {
+		return ev
+	}
+	trim := func(v any) any {
+		m, ok := v.(map[string]any)
+		if !ok || m == nil {
+			return v
+		}
+		out := make(map[string]any, len(f.Columns)+1)
+		if id, ok := m["id"]; ok {
+			out["id"] = id
+		}
+		for _, col := range f.Columns {
+			if val, ok := m[col]; ok {
+				out[col] = val
+			}
+		}
+		return out
+	}
+	ev.Before = trim(ev.Before)
+	ev.After = trim(ev.After)
+	return ev
+}
@@ -94,7 +94,7 @@ func ConnectForK8s(ctx context.Context, kc *k8sclient.Client, addrOverride, user
 	if addr == "" {
 		return nil, fmt.Errorf("rethinkdb: no in-cluster service address discovered for service '%s' in namespace '%s'", os.Getenv("RETHINKDB_SERVICE_NAME"), os.Getenv("RETHINKDB_NAMESPACE"))
 	}
-	opts := r.ConnectOpts{Address: addr, InitialCap: 2, MaxOpen: 10, Timeout: 3 * time.Second, ReadTimeout: 3 * time.Second, WriteTimeout: 3 * time.Second}
+	opts := r.ConnectOpts{Address: addr, InitialCap: 2, MaxOpen: poolMaxOpen, Timeout: 3 * time.Second, ReadTimeout: 3 * time.Second, WriteTimeout: 3 * time.Second}
 	if strings.TrimSpace(user) != "" {
 		opts.Username = strings.TrimSpace(user)
 	}
@@ -105,5 +105,5 @@ func ConnectForK8s(ctx context.Context, kc *k8sclient.Client, addrOverride, user
 	if err != nil {
 		return nil, fmt.Errorf("rethinkdb connect failed addr=%s: %w", addr, err)
 	}
-	return &Manager{sess: sess}, nil
+	return &Manager{sess: newPooledSession(sess)}, nil
 }
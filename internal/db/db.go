@@ -20,10 +20,13 @@ import (
 
 // Manager wraps a single RethinkDB cluster connection and provides per-org helpers.
 type Manager struct {
-	sess *r.Session
+	sess *pooledSession
 	mu   sync.RWMutex
 	// simple sequence generator for changefeed cursor tokens (monotonic per manager)
 	seq uint64
+	// metas caches _schemas entries by "db/table" (see tableMeta)
+	metaMu sync.Mutex
+	metas  map[string]cachedMeta
 }
 
 // Connect creates a Manager using Settings (preferred) then env/discovery.
@@ -48,7 +51,7 @@ func Connect(ctx context.Context) (*Manager, error) {
 			return nil, fmt.Errorf("rethinkdb: no in-cluster address discovered; RethinkDB must run inside the Kubernetes cluster")
 		}
 	}
-	opts := r.ConnectOpts{Address: addr, InitialCap: 2, MaxOpen: 10, Timeout: 3 * time.Second, ReadTimeout: 3 * time.Second, WriteTimeout: 3 * time.Second}
+	opts := r.ConnectOpts{Address: addr, InitialCap: 2, MaxOpen: poolMaxOpen, Timeout: 3 * time.Second, ReadTimeout: 3 * time.Second, WriteTimeout: 3 * time.Second}
 	if u := os.Getenv("RETHINKDB_USER"); u != "" {
 		user = u
 	}
@@ -65,7 +68,7 @@ func Connect(ctx context.Context) (*Manager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("rethinkdb connect failed addr=%s: %w", addr, err)
 	}
-	return &Manager{sess: sess}, nil
+	return &Manager{sess: newPooledSession(sess)}, nil
 }
 
 // ConnectWithOptions connects to RethinkDB at the given address with optional user/pass.
@@ -75,7 +78,7 @@ func ConnectWithOptions(ctx context.Context, address, user, pass string) (*Manag
 		// Require explicit address when calling ConnectWithOptions; do not fall back to localhost.
 		return nil, fmt.Errorf("rethinkdb: explicit address required; RethinkDB must run inside the Kubernetes cluster")
 	}
-	opts := r.ConnectOpts{Address: addr, InitialCap: 2, MaxOpen: 10, Timeout: 3 * time.Second, ReadTimeout: 3 * time.Second, WriteTimeout: 3 * time.Second}
+	opts := r.ConnectOpts{Address: addr, InitialCap: 2, MaxOpen: poolMaxOpen, Timeout: 3 * time.Second, ReadTimeout: 3 * time.Second, WriteTimeout: 3 * time.Second}
 	if strings.TrimSpace(user) != "" {
 		opts.Username = strings.TrimSpace(user)
 	}
@@ -86,7 +89,7 @@ func ConnectWithOptions(ctx context.Context, address, user, pass string) (*Manag
 	if err != nil {
 		return nil, fmt.Errorf("rethinkdb connect failed addr=%s: %w", addr, err)
 	}
-	return &Manager{sess: sess}, nil
+	return &Manager{sess: newPooledSession(sess)}, nil
 }
 
 // ConnectWithSettings prefers explicit addr/user/pass and does not read envs.
@@ -95,7 +98,7 @@ func ConnectWithSettings(ctx context.Context, addr, user, pass string) (*Manager
 	if address == "" {
 		return nil, fmt.Errorf("rethinkdb: explicit address required; RethinkDB must run inside the Kubernetes cluster")
 	}
-	opts := r.ConnectOpts{Address: address, InitialCap: 2, MaxOpen: 10, Timeout: 3 * time.Second, ReadTimeout: 3 * time.Second, WriteTimeout: 3 * time.Second}
+	opts := r.ConnectOpts{Address: address, InitialCap: 2, MaxOpen: poolMaxOpen, Timeout: 3 * time.Second, ReadTimeout: 3 * time.Second, WriteTimeout: 3 * time.Second}
 	if strings.TrimSpace(user) != "" {
 		opts.Username = strings.TrimSpace(user)
 	}
@@ -106,7 +109,27 @@ func ConnectWithSettings(ctx context.Context, addr, user, pass string) (*Manager
 	if err != nil {
 		return nil, fmt.Errorf("rethinkdb connect failed addr=%s: %w", address, err)
 	}
-	return &Manager{sess: sess}, nil
+	return &Manager{sess: newPooledSession(sess)}, nil
+}
+
+// CheckConnection connects with explicit settings, pings and closes the session. It gives
+// up when ctx is done even if the driver is still dialing; nothing is cached or persisted.
+func CheckConnection(ctx context.Context, addr, user, pass string) error {
+	done := make(chan error, 1)
+	go func() {
+		m, err := ConnectWithSettings(ctx, addr, user, pass)
+		if err == nil {
+			err = m.Ping(ctx)
+			_ = m.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("rethinkdb connect timed out addr=%s: %w", strings.TrimSpace(addr), ctx.Err())
+	}
 }
 
 // AutoDiscoverAddr returns the best-effort RethinkDB address (host:port).
@@ -263,34 +286,10 @@ func dbName(orgID, dbID string) string {
 // EnsureDatabase creates the database if absent for the given org and dbID
 func (m *Manager) EnsureDatabase(ctx context.Context, orgID, dbID string) error {
 	name := dbName(orgID, dbID)
-	var cur *r.Cursor
-	var err error
-	// retry DBList on transient errors
-	for i := 0; i < 3; i++ {
-		cur, err = r.DBList().Run(m.sess)
-		if err == nil {
-			break
-		}
-		if !isTransientErr(err) {
-			break
-		}
-		time.Sleep(time.Duration(200*(i+1)) * time.Millisecond)
-	}
+	found, err := m.DatabaseExists(ctx, orgID, dbID)
 	if err != nil {
 		return err
 	}
-	defer cur.Close()
-	var dbs []string
-	if err := cur.All(&dbs); err != nil {
-		return err
-	}
-	found := false
-	for _, d := range dbs {
-		if d == name {
-			found = true
-			break
-		}
-	}
 	if !found {
 		var wres r.WriteResponse
 		for i := 0; i < 3; i++ {
@@ -312,6 +311,29 @@ func (m *Manager) EnsureDatabase(ctx context.Context, orgID, dbID string) error
 	return m.ensureMetaTables(ctx, orgID, dbID)
 }
 
+// DatabaseExists reports whether the database for orgID/dbID exists. The membership test
+// runs server-side, and unlike EnsureDatabase it never creates the database or its meta
+// tables, so callers can check before deciding to create.
+func (m *Manager) DatabaseExists(ctx context.Context, orgID, dbID string) (bool, error) {
+	name := dbName(orgID, dbID)
+	var found bool
+	var err error
+	// retry on transient errors
+	for i := 0; i < 3; i++ {
+		var cur *r.Cursor
+		cur, err = r.DBList().Contains(name).Run(m.sess)
+		if err == nil {
+			err = cur.One(&found)
+			cur.Close()
+		}
+		if err == nil || !isTransientErr(err) {
+			break
+		}
+		time.Sleep(time.Duration(200*(i+1)) * time.Millisecond)
+	}
+	return found, err
+}
+
 func isTransientErr(err error) bool {
 	if err == nil {
 		return false
@@ -413,6 +435,7 @@ func (m *Manager) CreateTable(ctx context.Context, orgID, dbID string, tbl model
 	tbl.DatabaseID = dbn
 	tbl.CreatedAt = model.NowISO()
 	_, err = r.DB(dbn).Table("_schemas").Insert(tbl, r.InsertOpts{Conflict: "replace"}).RunWrite(m.sess)
+	m.forgetTableMeta(dbn, tbl.ID)
 	if err == nil {
 		_ = m.ensureMetaTables(ctx, orgID, dbID)
 		_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: tbl.ID + "/schema", Scope: model.ScopeTable, ScopeID: tbl.ID, Actor: "system", Action: "create_table", TS: model.NowISO(), Diff: tbl})
@@ -433,11 +456,12 @@ func (m *Manager) UpdateTableSchema(ctx context.Context, orgID, dbID string, tab
 		_ = cur.One(&existing)
 		cur.Close()
 	}
-	updated := model.Table{ID: table, Name: table, PrimaryKey: primaryKey, Schema: schema, DatabaseID: dbn, CreatedAt: existing.CreatedAt}
+	updated := model.Table{ID: table, Name: table, PrimaryKey: primaryKey, Schema: schema, DatabaseID: dbn, CreatedAt: existing.CreatedAt, SoftDelete: existing.SoftDelete, AuditReads: existing.AuditReads}
 	if updated.CreatedAt == "" {
 		updated.CreatedAt = model.NowISO()
 	}
 	_, err = r.DB(dbn).Table("_schemas").Insert(updated, r.InsertOpts{Conflict: "replace"}).RunWrite(m.sess)
+	m.forgetTableMeta(dbn, table)
 	if err == nil {
 		_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%d/schema", table, time.Now().UnixNano()), Scope: model.ScopeTable, ScopeID: table, Actor: "system", Action: "update_schema", TS: model.NowISO(), Diff: map[string]any{"schema": schema}})
 	}
@@ -480,8 +504,30 @@ func (m *Manager) InsertRows(ctx context.Context, orgID, dbID, table string, row
 	return res.GeneratedKeys, nil
 }
 
-// QueryRows simple paginated scan with optional sort by primary key.
-func (m *Manager) QueryRows(ctx context.Context, orgID, dbID, table, pk string, limit int, cursor string, ascending bool) ([]map[string]any, string, error) {
+// QueryRows simple paginated scan with optional sort by primary key. Rows are narrowed
+// to those satisfying every match in where. Non-empty fields returns only those top-level
+// fields of each row (a RethinkDB pluck) plus the primary key.
+func (m *Manager) QueryRows(ctx context.Context, orgID, dbID, table, pk string, limit int, cursor string, ascending bool, where []FieldMatch, fields []string) ([]map[string]any, string, error) {
+	meta := m.tableMeta(dbName(orgID, dbID), table)
+	list, next, _, err := m.queryRows(orgID, dbID, table, pk, limit, cursor, ascending, meta.SoftDelete && !IncludeDeleted(ctx), where, fields, r.RunOpts{})
+	if err == nil {
+		m.auditRead(ctx, orgID, dbID, meta, table, "query", len(list))
+	}
+	return list, next, err
+}
+
+// QueryRowsProfiled is QueryRows with the RethinkDB query profiler enabled. The profile is
+// returned as decoded by the driver (a list of timed sub-operations) for diagnosing slow scans.
+func (m *Manager) QueryRowsProfiled(ctx context.Context, orgID, dbID, table, pk string, limit int, cursor string, ascending bool, where []FieldMatch, fields []string) ([]map[string]any, string, any, error) {
+	meta := m.tableMeta(dbName(orgID, dbID), table)
+	list, next, prof, err := m.queryRows(orgID, dbID, table, pk, limit, cursor, ascending, meta.SoftDelete && !IncludeDeleted(ctx), where, fields, r.RunOpts{Profile: true})
+	if err == nil {
+		m.auditRead(ctx, orgID, dbID, meta, table, "query", len(list))
+	}
+	return list, next, prof, err
+}
+
+func (m *Manager) queryRows(orgID, dbID, table, pk string, limit int, cursor string, ascending, hideDeleted bool, matches []FieldMatch, fields []string, opts r.RunOpts) ([]map[string]any, string, any, error) {
 	if limit <= 0 {
 		limit = 50
 	}
@@ -503,15 +549,24 @@ func (m *Manager) QueryRows(ctx context.Context, orgID, dbID, table, pk string,
 			}
 		}
 	}
+	if hideDeleted {
+		term = term.Filter(r.Row.HasFields(model.SoftDeleteField).Not())
+	}
+	for _, f := range matches {
+		term = term.Filter(f.term())
+	}
+	if len(fields) > 0 {
+		term = term.Pluck(projection(fields, pk)...)
+	}
 	term = term.Limit(limit + 1)
-	cur, err := term.Run(m.sess)
+	cur, err := term.Run(m.sess, opts)
 	if err != nil {
-		return nil, "", err
+		return nil, "", nil, err
 	}
 	defer cur.Close()
 	var list []map[string]any
 	if err := cur.All(&list); err != nil {
-		return nil, "", err
+		return nil, "", nil, err
 	}
 	next := ""
 	if len(list) > limit { // more
@@ -523,27 +578,210 @@ func (m *Manager) QueryRows(ctx context.Context, orgID, dbID, table, pk string,
 		}
 		list = list[:limit]
 	}
-	return list, next, nil
+	return list, next, cur.Profile(), nil
+}
+
+// Aggregate returns row counts grouped by the value of groupBy. Group values are stringified
+// (missing/null values map to "null") so the result is JSON-friendly. Soft-deleted rows
+// are not counted (unless ctx is from WithDeleted).
+func (m *Manager) Aggregate(ctx context.Context, orgID, dbID, table, groupBy string) (map[string]int64, error) {
+	if strings.TrimSpace(groupBy) == "" {
+		return nil, errors.New("groupBy required")
+	}
+	dbn := dbName(orgID, dbID)
+	term := r.DB(dbn).Table(table)
+	if m.tableMeta(dbn, table).SoftDelete && !IncludeDeleted(ctx) {
+		term = term.Filter(r.Row.HasFields(model.SoftDeleteField).Not())
+	}
+	cur, err := term.Group(groupBy).Count().Ungroup().Run(m.sess)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+	var groups []map[string]any
+	if err := cur.All(&groups); err != nil {
+		return nil, err
+	}
+	out := make(map[string]int64, len(groups))
+	for _, g := range groups {
+		key := "null"
+		if v := g["group"]; v != nil {
+			key = fmt.Sprint(v)
+		}
+		switch n := g["reduction"].(type) {
+		case float64:
+			out[key] += int64(n)
+		case int64:
+			out[key] += n
+		case int:
+			out[key] += int64(n)
+		}
+	}
+	return out, nil
 }
 
-// UpdateRow merges partial doc.
+// GetRow fetches a single row by primary key. Returns ErrNotFound when the row does not exist
+// or is soft-deleted (unless ctx is from WithDeleted).
+func (m *Manager) GetRow(ctx context.Context, orgID, dbID, table, id string) (map[string]any, error) {
+	dbn := dbName(orgID, dbID)
+	cur, err := r.DB(dbn).Table(table).Get(id).Run(m.sess)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+	if cur.IsNil() {
+		return nil, ErrNotFound
+	}
+	var row map[string]any
+	if err := cur.One(&row); err != nil {
+		if errors.Is(err, r.ErrEmptyResult) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	meta := m.tableMeta(dbn, table)
+	if _, deleted := row[model.SoftDeleteField]; deleted && meta.SoftDelete && !IncludeDeleted(ctx) {
+		return nil, ErrNotFound
+	}
+	m.auditRead(ctx, orgID, dbID, meta, table, "get", 1)
+	return row, nil
+}
+
+// BatchGet fetches several rows by primary key in a single GetAll query. The result is
+// aligned with ids: entry i is the row for ids[i], or nil when that id does not exist or
+// is soft-deleted.
+func (m *Manager) BatchGet(ctx context.Context, orgID, dbID, table string, ids []string) ([]map[string]any, error) {
+	out := make([]map[string]any, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	dbn := dbName(orgID, dbID)
+	meta := m.tableMeta(dbn, table)
+	pk := "id"
+	if meta.PrimaryKey != "" {
+		pk = meta.PrimaryKey
+	}
+	hide := meta.SoftDelete && !IncludeDeleted(ctx)
+	keys := make([]any, len(ids))
+	for i, id := range ids {
+		keys[i] = id
+	}
+	cur, err := r.DB(dbn).Table(table).GetAll(keys...).Run(m.sess)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	defer cur.Close()
+	var rows []map[string]any
+	if err := cur.All(&rows); err != nil {
+		return nil, err
+	}
+	byID := make(map[string]map[string]any, len(rows))
+	for _, row := range rows {
+		if _, deleted := row[model.SoftDeleteField]; deleted && hide {
+			continue
+		}
+		byID[fmt.Sprint(row[pk])] = row
+	}
+	found := 0
+	for i, id := range ids {
+		if out[i] = byID[id]; out[i] != nil {
+			found++
+		}
+	}
+	m.auditRead(ctx, orgID, dbID, meta, table, "batch_get", found)
+	return out, nil
+}
+
+// UpdateRow merges partial doc. Returns ErrNotFound when no row matched (RethinkDB
+// reports an update of a missing key as skipped rather than failing) or the row is
+// soft-deleted.
 func (m *Manager) UpdateRow(ctx context.Context, orgID, dbID, table, id string, patch map[string]any) error {
 	dbn := dbName(orgID, dbID)
-	_, err := r.DB(dbn).Table(table).Get(id).Update(patch).RunWrite(m.sess)
-	if err == nil {
-		_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%s/upd", table, id), Scope: model.ScopeRow, ScopeID: id, Actor: "system", Action: "update", TS: model.NowISO(), Diff: patch})
+	var term r.Term
+	if m.tableMeta(dbn, table).SoftDelete {
+		term = r.DB(dbn).Table(table).GetAll(id).Filter(r.Row.HasFields(model.SoftDeleteField).Not())
+	} else {
+		term = r.DB(dbn).Table(table).Get(id)
 	}
-	return err
+	res, err := term.Update(patch).RunWrite(m.sess)
+	if err != nil {
+		return err
+	}
+	if res.Replaced+res.Unchanged == 0 {
+		return ErrNotFound
+	}
+	_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%s/upd", table, id), Scope: model.ScopeRow, ScopeID: id, Actor: "system", Action: "update", TS: model.NowISO(), Diff: patch})
+	return nil
 }
 
-// DeleteRow removes by id.
+// DeleteRow removes by id, or marks the row deleted when the table has SoftDelete set.
+// Returns ErrNotFound when no row was deleted.
 func (m *Manager) DeleteRow(ctx context.Context, orgID, dbID, table, id string) error {
 	dbn := dbName(orgID, dbID)
-	_, err := r.DB(dbn).Table(table).Get(id).Delete().RunWrite(m.sess)
-	if err == nil {
-		_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%s/del", table, id), Scope: model.ScopeRow, ScopeID: id, Actor: "system", Action: "delete", TS: model.NowISO()})
+	if m.tableMeta(dbn, table).SoftDelete {
+		return m.softDeleteRow(ctx, orgID, dbID, table, id)
 	}
-	return err
+	res, err := r.DB(dbn).Table(table).Get(id).Delete().RunWrite(m.sess)
+	if err != nil {
+		return err
+	}
+	if res.Deleted == 0 {
+		return ErrNotFound
+	}
+	_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%s/del", table, id), Scope: model.ScopeRow, ScopeID: id, Actor: "system", Action: "delete", TS: model.NowISO()})
+	return nil
+}
+
+// DeleteRows removes every row whose fields equal filter in one query, soft-deleting
+// them when the table has SoftDelete set. An empty filter matches the whole table; callers
+// guard against that. Returns the number of rows removed, or ErrNotFound for an unknown table.
+func (m *Manager) DeleteRows(ctx context.Context, orgID, dbID, table string, filter map[string]any) (int64, error) {
+	dbn := dbName(orgID, dbID)
+	term := r.DB(dbn).Table(table)
+	if len(filter) > 0 {
+		term = term.Filter(filter)
+	}
+	soft := m.tableMeta(dbn, table).SoftDelete
+	var (
+		res r.WriteResponse
+		err error
+	)
+	if soft {
+		now := model.NowISO()
+		res, err = term.Filter(r.Row.HasFields(model.SoftDeleteField).Not()).Update(map[string]any{model.SoftDeleteField: now}).RunWrite(m.sess)
+	} else {
+		res, err = term.Delete().RunWrite(m.sess)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	n := int64(res.Deleted)
+	if soft {
+		n = int64(res.Replaced)
+	}
+	_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%d/bulkdel", table, time.Now().UnixNano()), Scope: model.ScopeTable, ScopeID: table, Actor: "system", Action: "delete", TS: model.NowISO(), Diff: map[string]any{"filter": filter, "deleted": n, "soft": soft}})
+	return n, nil
+}
+
+// TruncateTable deletes every row while keeping the table, its indexes and schema
+// metadata. Returns the number of rows removed, or ErrNotFound for an unknown table.
+func (m *Manager) TruncateTable(ctx context.Context, orgID, dbID, table string) (int, error) {
+	dbn := dbName(orgID, dbID)
+	res, err := r.DB(dbn).Table(table).Delete().RunWrite(m.sess)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%d/truncate", table, time.Now().UnixNano()), Scope: model.ScopeTable, ScopeID: table, Actor: "system", Action: "truncate", TS: model.NowISO(), Diff: map[string]any{"deleted": res.Deleted}})
+	return res.Deleted, nil
 }
 
 // InsertAudit writes an audit event (best-effort; errors ignored by callers when logging).
@@ -585,6 +823,12 @@ type ChangefeedStream struct {
 
 // SubscribeTable produces events for inserts/updates/deletes. Resume token currently unused (placeholder).
 func (m *Manager) SubscribeTable(ctx context.Context, orgID, dbID, table string) (*ChangefeedStream, error) {
+	return m.SubscribeTableFiltered(ctx, orgID, dbID, table, ChangefeedFilter{})
+}
+
+// SubscribeTableFiltered is SubscribeTable restricted to the operations/columns in f. The
+// predicate runs server-side; column projection is applied as events are decoded.
+func (m *Manager) SubscribeTableFiltered(ctx context.Context, orgID, dbID, table string, f ChangefeedFilter) (*ChangefeedStream, error) {
 	// Increment a simple sequence to form a monotonic token (future: expose for resume)
 	m.mu.Lock()
 	m.seq++
@@ -592,6 +836,9 @@ func (m *Manager) SubscribeTable(ctx context.Context, orgID, dbID, table string)
 	m.mu.Unlock()
 	dbn := dbName(orgID, dbID)
 	term := r.DB(dbn).Table(table).Changes(r.ChangesOpts{IncludeInitial: true, IncludeStates: false})
+	if !f.Empty() {
+		term = term.Filter(f.term)
+	}
 	cur, err := term.Run(m.sess)
 	if err != nil {
 		return nil, err
@@ -620,7 +867,7 @@ func (m *Manager) SubscribeTable(ctx context.Context, orgID, dbID, table string)
 					ev.Before = rchg.OldVal
 				}
 				select {
-				case ch <- ev:
+				case ch <- f.Project(ev):
 				case <-ctx.Done():
 					return
 				}
@@ -714,25 +961,12 @@ func (m *Manager) CreateDatabase(ctx context.Context, orgID, dbID, name, descrip
 func (m *Manager) GetDatabase(ctx context.Context, orgID, dbID string) (model.DatabaseInstance, error) {
 	dbn := dbName(orgID, dbID)
 	info := model.DatabaseInstance{ID: dbID, OrgID: orgID, Name: dbID}
-	cur, err := r.DBList().Run(m.sess)
+	found, err := m.DatabaseExists(ctx, orgID, dbID)
 	if err != nil {
 		return info, err
 	}
-	var names []string
-	if err := cur.All(&names); err != nil {
-		cur.Close()
-		return info, err
-	}
-	cur.Close()
-	found := false
-	for _, n := range names {
-		if n == dbn {
-			found = true
-			break
-		}
-	}
 	if !found {
-		return info, fmt.Errorf("not found")
+		return info, ErrNotFound
 	}
 	// fetch _info
 	cur2, err2 := r.DB(dbn).Table("_info").Get("db").Run(m.sess)
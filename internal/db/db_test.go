@@ -3,6 +3,8 @@ package db
 import (
 	"os"
 	"testing"
+
+	"github.com/docxology/GuildNet/internal/metrics"
 )
 
 // helper to run a subtest with controlled env variables
@@ -113,3 +115,28 @@ func TestAutoDiscoverAddr_OutsideCluster(t *testing.T) {
 }
 
 // (no-op to ensure file compiles even if build tags change)
+
+func TestPoolStatsCountSaturation(t *testing.T) {
+	before := metrics.Export().DBPool
+	s := &pooledSession{maxOpen: 2}
+	m := &Manager{sess: s}
+	for i := 0; i < 3; i++ {
+		s.begin()
+	}
+	st := m.PoolStats()
+	if st.InUse != 3 || st.Idle != 0 || st.Queries != 3 || st.Waits != 1 {
+		t.Fatalf("saturated stats=%+v", st)
+	}
+	if got := metrics.Export().DBPool; got.InUse-before.InUse != 3 || got.Waits-before.Waits != 1 {
+		t.Fatalf("exported pool=%+v before=%+v", got, before)
+	}
+	for i := 0; i < 3; i++ {
+		s.end()
+	}
+	if st := m.PoolStats(); st.InUse != 0 || st.Idle != 2 || st.Waits != 1 {
+		t.Fatalf("drained stats=%+v", st)
+	}
+	if (*Manager)(nil).PoolStats() != (PoolStats{}) {
+		t.Fatal("nil manager should report zero stats")
+	}
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+)
+
+// IndexState is the readiness of one secondary index. Progress (0-1) is only reported by
+// RethinkDB while the index is building.
+type IndexState struct {
+	Name     string  `json:"name" rethinkdb:"index"`
+	Ready    bool    `json:"ready" rethinkdb:"ready"`
+	Progress float64 `json:"progress,omitempty" rethinkdb:"progress,omitempty"`
+}
+
+// TableIndexStatus lists the secondary indexes of a table. Ready is true when all of
+// them are; a table without secondary indexes is ready.
+type TableIndexStatus struct {
+	Table   string       `json:"table"`
+	Ready   bool         `json:"ready"`
+	Indexes []IndexState `json:"indexes"`
+}
+
+// IndexStatus reports secondary index readiness for every user table of the database.
+// Queries using an index that is still building fail, so this explains transient errors
+// right after schema changes. Meta tables (leading underscore) are skipped; a missing
+// database is ErrNotFound.
+func (m *Manager) IndexStatus(ctx context.Context, orgID, dbID string) ([]TableIndexStatus, error) {
+	found, err := m.DatabaseExists(ctx, orgID, dbID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	dbn := dbName(orgID, dbID)
+	var tables []string
+	if err := retryTransient(3, func() error {
+		cur, err := r.DB(dbn).TableList().Run(m.sess)
+		if err != nil {
+			return err
+		}
+		defer cur.Close()
+		return cur.All(&tables)
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(tables)
+	out := []TableIndexStatus{}
+	for _, t := range tables {
+		if strings.HasPrefix(t, "_") {
+			continue
+		}
+		st := TableIndexStatus{Table: t, Ready: true, Indexes: []IndexState{}}
+		if err := retryTransient(3, func() error {
+			cur, err := r.DB(dbn).Table(t).IndexStatus().Run(m.sess)
+			if err != nil {
+				return err
+			}
+			defer cur.Close()
+			return cur.All(&st.Indexes)
+		}); err != nil {
+			return nil, err
+		}
+		for _, ix := range st.Indexes {
+			if !ix.Ready {
+				st.Ready = false
+			}
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"sync/atomic"
+
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+
+	"github.com/docxology/GuildNet/internal/metrics"
+)
+
+// poolMaxOpen is the connection pool size used for every Manager session.
+const poolMaxOpen = 10
+
+// PoolStats reports query pressure on a Manager's session pool. rethinkdb-go multiplexes
+// queries over at most MaxOpen connections without exposing pool state, so InUse counts
+// queries in flight and Waits counts queries started while every connection was busy.
+type PoolStats struct {
+	MaxOpen int    `json:"max_open"`
+	InUse   int    `json:"in_use"`
+	Idle    int    `json:"idle"`
+	Queries uint64 `json:"queries"`
+	Waits   uint64 `json:"waits"`
+}
+
+// pooledSession wraps a session to count in-flight queries. It still satisfies
+// r.QueryExecutor, so terms run against it unchanged.
+type pooledSession struct {
+	*r.Session
+	maxOpen int64
+	inUse   atomic.Int64
+	queries atomic.Uint64
+	waits   atomic.Uint64
+	closed  atomic.Bool
+}
+
+func newPooledSession(sess *r.Session) *pooledSession {
+	metrics.DBPoolOpened(poolMaxOpen)
+	return &pooledSession{Session: sess, maxOpen: poolMaxOpen}
+}
+
+func (s *pooledSession) begin() {
+	n := s.inUse.Add(1)
+	s.queries.Add(1)
+	waited := n > s.maxOpen
+	if waited {
+		s.waits.Add(1)
+	}
+	metrics.DBQueryStarted(waited)
+}
+
+func (s *pooledSession) end() {
+	s.inUse.Add(-1)
+	metrics.DBQueryDone()
+}
+
+func (s *pooledSession) Query(ctx context.Context, q r.Query) (*r.Cursor, error) {
+	s.begin()
+	defer s.end()
+	return s.Session.Query(ctx, q)
+}
+
+func (s *pooledSession) Exec(ctx context.Context, q r.Query) error {
+	s.begin()
+	defer s.end()
+	return s.Session.Exec(ctx, q)
+}
+
+// Close closes the session and drops its capacity from the exported pool gauges.
+func (s *pooledSession) Close(optArgs ...r.CloseOpts) error {
+	if s.closed.CompareAndSwap(false, true) {
+		metrics.DBPoolClosed(int(s.maxOpen))
+	}
+	return s.Session.Close(optArgs...)
+}
+
+// PoolStats returns the current session pool counters (zero when not connected).
+func (m *Manager) PoolStats() PoolStats {
+	if m == nil || m.sess == nil {
+		return PoolStats{}
+	}
+	inUse := int(m.sess.inUse.Load())
+	idle := int(m.sess.maxOpen) - inUse
+	if idle < 0 {
+		idle = 0
+	}
+	return PoolStats{MaxOpen: int(m.sess.maxOpen), InUse: inUse, Idle: idle, Queries: m.sess.queries.Load(), Waits: m.sess.waits.Load()}
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+type actorKey struct{}
+
+// WithActor returns a context whose reads are attributed to actor in read audit events.
+func WithActor(ctx context.Context, actor string) context.Context {
+	if actor == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor attached by WithActor, or "system".
+func Actor(ctx context.Context) string {
+	if v, _ := ctx.Value(actorKey{}).(string); v != "" {
+		return v
+	}
+	return "system"
+}
+
+// auditRead records that the actor of ctx read count rows of a table with AuditReads set.
+// Only the operation and count are kept, never row values or ids. Best-effort.
+func (m *Manager) auditRead(ctx context.Context, orgID, dbID string, meta model.Table, table, op string, count int) {
+	if !meta.AuditReads {
+		return
+	}
+	_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%d/read", table, time.Now().UnixNano()), Scope: model.ScopeTable, ScopeID: table, Actor: Actor(ctx), Action: "read", TS: model.NowISO(), Diff: map[string]any{"op": op, "count": count}})
+}
+
+// SetTableAuditReads turns read auditing on or off for a table. Returns ErrNotFound when
+// the table has no schema entry.
+func (m *Manager) SetTableAuditReads(ctx context.Context, orgID, dbID, table string, on bool) error {
+	dbn := dbName(orgID, dbID)
+	res, err := r.DB(dbn).Table("_schemas").Get(table).Update(map[string]any{"audit_reads": on}).RunWrite(m.sess)
+	m.forgetTableMeta(dbn, table)
+	if err != nil {
+		return err
+	}
+	if res.Replaced+res.Unchanged == 0 {
+		return ErrNotFound
+	}
+	_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%d/audit_reads", table, time.Now().UnixNano()), Scope: model.ScopeTable, ScopeID: table, Actor: Actor(ctx), Action: "update_table", TS: model.NowISO(), Diff: map[string]any{"audit_reads": on}})
+	return nil
+}
@@ -0,0 +1,16 @@
+package db
+
+// projection returns fields with pk added when missing, as pluck arguments. QueryRows
+// needs the primary key for pagination.
+func projection(fields []string, pk string) []any {
+	out := make([]any, 0, len(fields)+1)
+	hasPK := false
+	for _, f := range fields {
+		out = append(out, f)
+		hasPK = hasPK || f == pk
+	}
+	if !hasPK && pk != "" {
+		out = append(out, pk)
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+)
+
+// FieldMatch is an equality condition on a row field. Path has one element for a
+// top-level column and more for a field nested inside a json column (meta.region is
+// ["meta", "region"]).
+type FieldMatch struct {
+	Path  []string
+	Value any
+}
+
+// term returns the ReQL predicate for f. Nested fields are plain field access, so they
+// are evaluated per row rather than through a secondary index.
+func (f FieldMatch) term() r.Term {
+	field := r.Row
+	for _, seg := range f.Path {
+		field = field.Field(seg)
+	}
+	return field.Eq(f.Value)
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+type includeDeletedKey struct{}
+
+// WithDeleted returns a context under which QueryRows, QueryRowsProfiled and GetRow also
+// return soft-deleted rows.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludeDeleted reports whether ctx was built by WithDeleted.
+func IncludeDeleted(ctx context.Context) bool {
+	v, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return v
+}
+
+// tableMetaTTL bounds how long a cached _schemas entry is used. Writes through this
+// manager invalidate it at once; the TTL covers changes made by other instances.
+const tableMetaTTL = 10 * time.Second
+
+type cachedMeta struct {
+	meta model.Table
+	at   time.Time
+}
+
+// tableMeta returns the _schemas entry for table, or a zero Table when none is stored.
+// Entries are cached for tableMetaTTL so row operations do not each add a round trip.
+func (m *Manager) tableMeta(dbn, table string) model.Table {
+	key := dbn + "/" + table
+	m.metaMu.Lock()
+	if c, ok := m.metas[key]; ok && time.Since(c.at) < tableMetaTTL {
+		m.metaMu.Unlock()
+		return c.meta
+	}
+	m.metaMu.Unlock()
+	var meta model.Table
+	cur, err := r.DB(dbn).Table("_schemas").Get(table).Run(m.sess)
+	if err != nil {
+		return meta
+	}
+	defer cur.Close()
+	if !cur.IsNil() {
+		if err := cur.One(&meta); err != nil {
+			return model.Table{}
+		}
+	}
+	m.metaMu.Lock()
+	if m.metas == nil {
+		m.metas = map[string]cachedMeta{}
+	}
+	m.metas[key] = cachedMeta{meta: meta, at: time.Now()}
+	m.metaMu.Unlock()
+	return meta
+}
+
+// forgetTableMeta drops the cached _schemas entry for table after it changed.
+func (m *Manager) forgetTableMeta(dbn, table string) {
+	m.metaMu.Lock()
+	delete(m.metas, dbn+"/"+table)
+	m.metaMu.Unlock()
+}
+
+// softDeleteRow stamps SoftDeleteField on a live row. Rows that are missing or already
+// deleted report ErrNotFound.
+func (m *Manager) softDeleteRow(ctx context.Context, orgID, dbID, table, id string) error {
+	dbn := dbName(orgID, dbID)
+	now := model.NowISO()
+	res, err := r.DB(dbn).Table(table).Get(id).Update(func(row r.Term) any {
+		return r.Branch(row.HasFields(model.SoftDeleteField), map[string]any{}, map[string]any{model.SoftDeleteField: now})
+	}).RunWrite(m.sess)
+	if err != nil {
+		return err
+	}
+	if res.Replaced == 0 {
+		return ErrNotFound
+	}
+	_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%s/del/%d", table, id, time.Now().UnixNano()), Scope: model.ScopeRow, ScopeID: id, Actor: "system", Action: "delete", TS: now, Diff: map[string]any{"soft": true}})
+	return nil
+}
+
+// RestoreRow clears SoftDeleteField on a soft-deleted row. Returns ErrNotFound when the
+// row does not exist or is not deleted.
+func (m *Manager) RestoreRow(ctx context.Context, orgID, dbID, table, id string) error {
+	dbn := dbName(orgID, dbID)
+	res, err := r.DB(dbn).Table(table).Get(id).Replace(func(row r.Term) any {
+		return r.Branch(row.Eq(nil).Not().And(row.HasFields(model.SoftDeleteField)), row.Without(model.SoftDeleteField), row)
+	}).RunWrite(m.sess)
+	if err != nil {
+		return err
+	}
+	if res.Replaced == 0 {
+		return ErrNotFound
+	}
+	_ = m.InsertAudit(ctx, orgID, dbID, model.AuditEvent{ID: fmt.Sprintf("%s/%s/restore/%d", table, id, time.Now().UnixNano()), Scope: model.ScopeRow, ScopeID: id, Actor: "system", Action: "restore", TS: model.NowISO()})
+	return nil
+}
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+func TestTableMetaCache(t *testing.T) {
+	// No session: a cache miss would panic, so hits must not query _schemas
+	m := &Manager{metas: map[string]cachedMeta{"org_o__d/users": {meta: model.Table{ID: "users", SoftDelete: true}, at: time.Now()}}}
+	for i := 0; i < 3; i++ {
+		if !m.tableMeta("org_o__d", "users").SoftDelete {
+			t.Fatal("cached entry not used")
+		}
+	}
+	m.forgetTableMeta("org_o__d", "users")
+	if _, ok := m.metas["org_o__d/users"]; ok {
+		t.Fatal("entry kept after forgetTableMeta")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/docxology/GuildNet/internal/audit"
+	"github.com/docxology/GuildNet/internal/jobs"
 	"github.com/docxology/GuildNet/internal/localdb"
 	"github.com/docxology/GuildNet/internal/secrets"
 )
@@ -24,16 +25,21 @@ func (m *Manager) Create(ctx context.Context, id string, logf func(step, msg str
 	if m.DB == nil {
 		return fmt.Errorf("no db")
 	}
+	report := jobs.ReporterFrom(ctx)
+	report(10, "loading headscale record")
 	var rec map[string]any
 	if err := m.DB.Get("headscales", id, &rec); err != nil {
 		return err
 	}
+	report(40, "ensuring headscale resources")
 	logf("create", "ensure headscale resources in cluster", map[string]any{"id": id})
 	// TODO: apply K8s resources/operator CRDs here. For now, mark ready.
+	report(80, "marking headscale ready")
 	rec["state"] = "ready"
 	rec["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
 	_ = m.DB.Put("headscales", id, rec)
 	audit.Append(m.DB, "system", "create", "headscale", id, "")
+	report(100, "headscale ready")
 	return nil
 }
 
@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -24,8 +26,18 @@ type DBAPI struct {
 	// For MVP we assume single org until auth/tenancy implemented. Stub OrgID.
 	OrgID string
 	RBAC  *RBACStore
+	// ChangefeedBacklog bounds the events buffered for a paused changefeed
+	// (0 = DefaultChangefeedBacklog). Clients may override it with ?backlog=.
+	ChangefeedBacklog int
 }
 
+const (
+	// DefaultChangefeedBacklog is the paused changefeed buffer when none is configured.
+	DefaultChangefeedBacklog = 512
+	// MaxChangefeedBacklog caps the ?backlog= override.
+	MaxChangefeedBacklog = 10000
+)
+
 // ensureManager lazily initializes the DB manager if it's nil.
 func (a *DBAPI) ensureManager(ctx context.Context) {
 	if a.Manager != nil {
@@ -46,6 +58,8 @@ func (a *DBAPI) Register(mux *http.ServeMux) {
 	mux.HandleFunc("/api/db/", a.handleDatabaseSubroutes)
 	// SSE changefeed
 	mux.HandleFunc("/sse/db/", a.handleChangefeed)
+	// Try candidate connection settings before saving them (nothing is persisted)
+	mux.HandleFunc("/api/db/test-connection", a.handleTestConnection)
 	// DB connectivity health
 	mux.HandleFunc("/api/db/health", func(w http.ResponseWriter, r *http.Request) {
 		status := "ok"
@@ -71,10 +85,92 @@ func (a *DBAPI) Register(mux *http.ServeMux) {
 				resp["error"] = err.Error()
 			}
 		}
+		if status == "ok" && r.URL.Query().Get("indexes") != "" {
+			a.addIndexHealth(r.Context(), resp, r.URL.Query().Get("db"))
+		}
 		JSON(w, http.StatusOK, resp)
 	})
 }
 
+// addIndexHealth extends a health response (?indexes=1) with secondary index readiness
+// per database and table: "indexes" maps database id to db.TableIndexStatus entries and
+// "indexes_ready" is false while any index is still building. dbID limits the report to
+// one database; otherwise every database of the org is checked.
+func (a *DBAPI) addIndexHealth(ctx context.Context, resp map[string]any, dbID string) {
+	ids := []string{dbID}
+	if dbID == "" {
+		dbs, err := a.Manager.ListDatabases(ctx, a.OrgID)
+		if err != nil {
+			resp["indexes_error"] = err.Error()
+			return
+		}
+		ids = ids[:0]
+		for _, d := range dbs {
+			ids = append(ids, d.ID)
+		}
+	}
+	ready := true
+	byDB := map[string][]db.TableIndexStatus{}
+	for _, id := range ids {
+		st, err := a.Manager.IndexStatus(ctx, a.OrgID, id)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) && dbID != "" {
+				resp["indexes_error"] = "database not found"
+				return
+			}
+			resp["indexes_error"] = err.Error()
+			ready = false
+			continue
+		}
+		for _, t := range st {
+			ready = ready && t.Ready
+		}
+		byDB[id] = st
+	}
+	resp["indexes"] = byDB
+	resp["indexes_ready"] = ready
+}
+
+// testConnectionTimeout bounds connect+ping for POST /api/db/test-connection.
+const testConnectionTimeout = 5 * time.Second
+
+// handleTestConnection dials the given RethinkDB settings and reports
+// {ok, addr, error, classify} without storing anything.
+func (a *DBAPI) handleTestConnection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if principal := PrincipalFromRequest(r.Header.Get("X-Debug-Principal")); principal != "" && a.RBAC != nil {
+		if !Allow(a.RBAC.RoleFor(principal, "", a.OrgID), "db.create") {
+			JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
+			return
+		}
+	}
+	var req struct {
+		Addr string `json:"addr"`
+		User string `json:"user"`
+		Pass string `json:"pass"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		JSONError(w, http.StatusBadRequest, "invalid json", "bad_json")
+		return
+	}
+	addr := strings.TrimSpace(req.Addr)
+	if addr == "" {
+		JSONFieldErrors(w, http.StatusBadRequest, "invalid connection settings", "bad_request", map[string]string{"addr": "required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), testConnectionTimeout)
+	defer cancel()
+	err := db.CheckConnection(ctx, addr, req.User, req.Pass)
+	resp := map[string]any{"ok": err == nil, "addr": addr, "classify": db.ClassifyError(err)}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	JSON(w, http.StatusOK, resp)
+}
+
 func (a *DBAPI) handleDatabases(w http.ResponseWriter, r *http.Request) {
 	// Ensure manager is initialized on demand
 	a.ensureManager(r.Context())
@@ -101,7 +197,10 @@ func (a *DBAPI) handleDatabases(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		// Parse create payload (name optional)
-		b, _ := io.ReadAll(r.Body)
+		b, err := io.ReadAll(r.Body)
+		if BodyTooLarge(w, err) {
+			return
+		}
 		_ = r.Body.Close()
 		var req struct {
 			ID          string `json:"id"`
@@ -127,7 +226,9 @@ func (a *DBAPI) handleDatabases(w http.ResponseWriter, r *http.Request) {
 		}
 		// Auto-grant maintainer on the new DB to the creator principal (MVP convenience)
 		if a.RBAC != nil && strings.TrimSpace(principal) != "" {
-			a.RBAC.Grant(model.PermissionBinding{Principal: principal, Scope: "db:" + req.ID, Role: model.RoleMaintainer, CreatedAt: model.NowISO()})
+			if err := a.RBAC.Grant(model.PermissionBinding{Principal: principal, Scope: "db:" + req.ID, Role: model.RoleMaintainer, CreatedAt: model.NowISO()}); err != nil {
+				log.Printf("db: auto-grant maintainer on %s to %s failed: %v", req.ID, principal, err)
+			}
 		}
 		JSON(w, http.StatusCreated, inst)
 	default:
@@ -139,6 +240,12 @@ func (a *DBAPI) handleDatabases(w http.ResponseWriter, r *http.Request) {
 func (a *DBAPI) handleDatabaseSubroutes(w http.ResponseWriter, r *http.Request) {
 	// Ensure manager is initialized on demand
 	a.ensureManager(r.Context())
+	// Attribute reads of tables with audit_reads to the caller
+	actor := PrincipalFromRequest(r.Header.Get("X-Debug-Principal"))
+	if actor == "" {
+		actor = "anonymous"
+	}
+	r = r.WithContext(db.WithActor(r.Context(), actor))
 	// path after /api/db/
 	tail := strings.TrimPrefix(r.URL.Path, "/api/db/")
 	if tail == "" {
@@ -209,13 +316,7 @@ func (a *DBAPI) handleDatabaseSubroutes(w http.ResponseWriter, r *http.Request)
 	// permissions list/create (MVP in-memory)
 	if len(parts) >= 2 && parts[1] == "permissions" {
 		if r.Method == http.MethodGet {
-			a.RBAC.mu.RLock()
-			defer a.RBAC.mu.RUnlock()
-			var out []model.PermissionBinding
-			for _, arr := range a.RBAC.bindings {
-				out = append(out, arr...)
-			}
-			JSON(w, http.StatusOK, out)
+			JSON(w, http.StatusOK, a.RBAC.Bindings())
 			return
 		}
 		if r.Method == http.MethodPost {
@@ -224,7 +325,10 @@ func (a *DBAPI) handleDatabaseSubroutes(w http.ResponseWriter, r *http.Request)
 				JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
 				return
 			}
-			b, _ := io.ReadAll(r.Body)
+			b, err := io.ReadAll(r.Body)
+			if BodyTooLarge(w, err) {
+				return
+			}
 			_ = r.Body.Close()
 			var req model.PermissionBinding
 			if err := json.Unmarshal(b, &req); err != nil || strings.TrimSpace(req.Principal) == "" || strings.TrimSpace(req.Scope) == "" {
@@ -232,7 +336,10 @@ func (a *DBAPI) handleDatabaseSubroutes(w http.ResponseWriter, r *http.Request)
 				return
 			}
 			req.CreatedAt = model.NowISO()
-			a.RBAC.Grant(req)
+			if err := a.RBAC.Grant(req); err != nil {
+				JSONError(w, http.StatusInternalServerError, "permission save failed", "perm_save_failed", err.Error())
+				return
+			}
 			JSON(w, http.StatusCreated, req)
 			return
 		}
@@ -248,7 +355,10 @@ func (a *DBAPI) handleDatabaseSubroutes(w http.ResponseWriter, r *http.Request)
 				JSONError(w, http.StatusBadRequest, "missing scope/principal", "invalid_perm")
 				return
 			}
-			a.RBAC.Revoke(scope, who)
+			if err := a.RBAC.Revoke(scope, who); err != nil {
+				JSONError(w, http.StatusInternalServerError, "permission save failed", "perm_save_failed", err.Error())
+				return
+			}
 			JSON(w, http.StatusOK, map[string]any{"revoked": true})
 			return
 		}
@@ -294,18 +404,23 @@ func (a *DBAPI) handleTables(w http.ResponseWriter, r *http.Request, dbID string
 				JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
 				return
 			}
-			b, _ := io.ReadAll(r.Body)
+			b, err := io.ReadAll(r.Body)
+			if BodyTooLarge(w, err) {
+				return
+			}
 			_ = r.Body.Close()
 			var req struct {
 				Name       string            `json:"name"`
 				Schema     []model.ColumnDef `json:"schema"`
 				PrimaryKey string            `json:"primary_key"`
+				SoftDelete bool              `json:"soft_delete"`
+				AuditReads bool              `json:"audit_reads"`
 			}
 			if err := json.Unmarshal(b, &req); err != nil || strings.TrimSpace(req.Name) == "" {
 				JSONError(w, http.StatusBadRequest, "invalid table spec", "invalid_spec")
 				return
 			}
-			tbl := model.Table{ID: req.Name, Name: req.Name, PrimaryKey: req.PrimaryKey, Schema: req.Schema}
+			tbl := model.Table{ID: req.Name, Name: req.Name, PrimaryKey: req.PrimaryKey, Schema: req.Schema, SoftDelete: req.SoftDelete, AuditReads: req.AuditReads}
 			if err := a.Manager.CreateTable(r.Context(), a.OrgID, dbID, tbl); err != nil {
 				JSONError(w, http.StatusInternalServerError, "table create failed", "create_failed", err.Error())
 				return
@@ -319,6 +434,14 @@ func (a *DBAPI) handleTables(w http.ResponseWriter, r *http.Request, dbID string
 	tableName := rest[0]
 	if len(rest) == 1 { // metadata GET/PATCH/DELETE
 		if r.Method == http.MethodGet {
+			// check first: GetTables would create a missing database
+			if ok, err := a.Manager.DatabaseExists(r.Context(), a.OrgID, dbID); err != nil {
+				JSONError(w, http.StatusInternalServerError, "database lookup failed", "db_lookup_failed", err.Error())
+				return
+			} else if !ok {
+				JSONError(w, http.StatusNotFound, "database not found", "not_found")
+				return
+			}
 			// naive lookup
 			tbls, _ := a.Manager.GetTables(r.Context(), a.OrgID, dbID)
 			for _, t := range tbls {
@@ -336,19 +459,36 @@ func (a *DBAPI) handleTables(w http.ResponseWriter, r *http.Request, dbID string
 				JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
 				return
 			}
-			b, _ := io.ReadAll(r.Body)
+			b, err := io.ReadAll(r.Body)
+			if BodyTooLarge(w, err) {
+				return
+			}
 			_ = r.Body.Close()
 			var req struct {
 				Schema     []model.ColumnDef `json:"schema"`
 				PrimaryKey string            `json:"primary_key"`
+				AuditReads *bool             `json:"audit_reads"`
 			}
-			if err := json.Unmarshal(b, &req); err != nil || len(req.Schema) == 0 {
+			// The schema may be left out when only audit_reads changes
+			if err := json.Unmarshal(b, &req); err != nil || (len(req.Schema) == 0 && req.AuditReads == nil) {
 				JSONError(w, http.StatusBadRequest, "invalid schema", "invalid_schema")
 				return
 			}
-			if err := a.Manager.UpdateTableSchema(r.Context(), a.OrgID, dbID, tableName, req.Schema, req.PrimaryKey); err != nil {
-				JSONError(w, http.StatusInternalServerError, "schema update failed", "schema_failed", err.Error())
-				return
+			if len(req.Schema) > 0 {
+				if err := a.Manager.UpdateTableSchema(r.Context(), a.OrgID, dbID, tableName, req.Schema, req.PrimaryKey); err != nil {
+					JSONError(w, http.StatusInternalServerError, "schema update failed", "schema_failed", err.Error())
+					return
+				}
+			}
+			if req.AuditReads != nil {
+				if err := a.Manager.SetTableAuditReads(r.Context(), a.OrgID, dbID, tableName, *req.AuditReads); err != nil {
+					if errors.Is(err, db.ErrNotFound) {
+						JSONError(w, http.StatusNotFound, "table not found", "not_found")
+						return
+					}
+					JSONError(w, http.StatusInternalServerError, "table update failed", "update_failed", err.Error())
+					return
+				}
 			}
 			JSON(w, http.StatusOK, map[string]any{"updated": tableName})
 			return
@@ -364,11 +504,43 @@ func (a *DBAPI) handleTables(w http.ResponseWriter, r *http.Request, dbID string
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	// /schema:diff previews a schema PATCH against the current schema and sampled rows
+	if len(rest) == 2 && rest[1] == "schema:diff" {
+		a.handleSchemaDiff(w, r, dbID, tableName)
+		return
+	}
+	// /rows:batchGet fetches several rows by id in one round trip
+	if len(rest) == 2 && rest[1] == "rows:batchGet" {
+		a.handleBatchGet(w, r, dbID, tableName)
+		return
+	}
 	// /rows operations
 	if len(rest) >= 2 && rest[1] == "rows" {
 		a.handleRows(w, r, dbID, tableName, rest[2:])
 		return
 	}
+	// /truncate removes all rows but keeps the table and schema
+	if len(rest) >= 2 && rest[1] == "truncate" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !Allow(a.roleFor(principal, tableName, dbID), "table.truncate") {
+			JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
+			return
+		}
+		n, err := a.Manager.TruncateTable(r.Context(), a.OrgID, dbID, tableName)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				JSONError(w, http.StatusNotFound, "table not found", "not_found")
+				return
+			}
+			JSONError(w, http.StatusInternalServerError, "truncate failed", "truncate_failed", err.Error())
+			return
+		}
+		JSON(w, http.StatusOK, map[string]any{"table": tableName, "deleted": n})
+		return
+	}
 	// /views placeholder
 	if len(rest) >= 2 && rest[1] == "views" {
 		JSON(w, http.StatusOK, []model.View{})
@@ -397,7 +569,10 @@ func (a *DBAPI) handleTables(w http.ResponseWriter, r *http.Request, dbID string
 		}
 		ct := r.Header.Get("Content-Type")
 		dryRun := r.URL.Query().Get("dry_run") == "1"
-		data, _ := io.ReadAll(r.Body)
+		data, err := io.ReadAll(r.Body)
+		if BodyTooLarge(w, err) {
+			return
+		}
 		_ = r.Body.Close()
 		var rows []map[string]any
 		var preview []model.ImportPreviewRow
@@ -534,6 +709,47 @@ func (a *DBAPI) handleTables(w http.ResponseWriter, r *http.Request, dbID string
 		JSON(w, http.StatusOK, map[string]any{"inserted": len(ids), "ids": ids})
 		return
 	}
+	// /aggregate?groupBy=col -> {value: count}
+	if len(rest) >= 2 && rest[1] == "aggregate" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		principal := PrincipalFromRequest(r.Header.Get("X-Debug-Principal"))
+		role := a.roleFor(principal, tableName, dbID)
+		if !Allow(role, "row.read") {
+			JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
+			return
+		}
+		groupBy := strings.TrimSpace(r.URL.Query().Get("groupBy"))
+		if groupBy == "" {
+			JSONError(w, http.StatusBadRequest, "groupBy required", "missing_group_by")
+			return
+		}
+		// grouping on a masked column would leak its values
+		if role != model.RoleAdmin && role != model.RoleMaintainer {
+			tbls, _ := a.Manager.GetTables(r.Context(), a.OrgID, dbID)
+			for _, t := range tbls {
+				if t.Name != tableName {
+					continue
+				}
+				for _, c := range t.Schema {
+					if c.Name == groupBy && c.Mask {
+						JSONError(w, http.StatusForbidden, "cannot group by masked column", "masked_column")
+						return
+					}
+				}
+			}
+		}
+		counts, err := a.Manager.Aggregate(r.Context(), a.OrgID, dbID, tableName, groupBy)
+		if err != nil {
+			JSONError(w, http.StatusInternalServerError, "aggregate failed", "aggregate_failed", err.Error())
+			return
+		}
+		metrics.IncOp(a.OrgID, tableName, "aggregate", 0)
+		JSON(w, http.StatusOK, counts)
+		return
+	}
 	// /export
 	if len(rest) >= 2 && rest[1] == "export" {
 		principal := PrincipalFromRequest(r.Header.Get("X-Debug-Principal"))
@@ -556,7 +772,7 @@ func (a *DBAPI) handleTables(w http.ResponseWriter, r *http.Request, dbID string
 		cursor := ""
 		rowsAccum := make([]map[string]any, 0, limit)
 		for len(rowsAccum) < limit {
-			rows, next, err := a.Manager.QueryRows(r.Context(), a.OrgID, dbID, tableName, "id", 200, cursor, true)
+			rows, next, err := a.Manager.QueryRows(r.Context(), a.OrgID, dbID, tableName, "id", 200, cursor, true, nil, nil)
 			if err != nil {
 				JSONError(w, http.StatusInternalServerError, "export query failed", "export_query", err.Error())
 				return
@@ -612,6 +828,71 @@ func (a *DBAPI) handleTables(w http.ResponseWriter, r *http.Request, dbID string
 	w.WriteHeader(http.StatusNotFound)
 }
 
+// maxBatchGetIDs caps the number of ids accepted by a single rows:batchGet request.
+const maxBatchGetIDs = 1000
+
+// handleBatchGet serves POST /api/db/{db}/tables/{t}/rows:batchGet with {"ids":[...]}.
+// Rows come back masked and in request order; missing ids are dropped unless
+// "missing":"null" asks for a null placeholder. Missing ids are also listed separately.
+func (a *DBAPI) handleBatchGet(w http.ResponseWriter, r *http.Request, dbID, table string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	role := a.roleFor(PrincipalFromRequest(r.Header.Get("X-Debug-Principal")), table, dbID)
+	if !Allow(role, "row.read") {
+		JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
+		return
+	}
+	var req struct {
+		IDs     []string `json:"ids"`
+		Missing string   `json:"missing"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		JSONError(w, http.StatusBadRequest, "invalid json", "bad_json")
+		return
+	}
+	if req.Missing != "" && req.Missing != "omit" && req.Missing != "null" {
+		JSONFieldErrors(w, http.StatusBadRequest, "invalid batch get", "bad_request", map[string]string{"missing": "must be omit or null"})
+		return
+	}
+	if len(req.IDs) > maxBatchGetIDs {
+		JSONFieldErrors(w, http.StatusBadRequest, "invalid batch get", "bad_request", map[string]string{"ids": fmt.Sprintf("at most %d ids per request", maxBatchGetIDs)})
+		return
+	}
+	rows, err := a.Manager.BatchGet(r.Context(), a.OrgID, dbID, table, req.IDs)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			JSONError(w, http.StatusNotFound, "table not found", "not_found")
+			return
+		}
+		JSONError(w, http.StatusInternalServerError, "batch get failed", "batch_get_failed", err.Error())
+		return
+	}
+	schema := []model.ColumnDef{}
+	if tbls, _ := a.Manager.GetTables(r.Context(), a.OrgID, dbID); true {
+		for _, t := range tbls {
+			if t.Name == table {
+				schema = t.Schema
+				break
+			}
+		}
+	}
+	items := make([]map[string]any, 0, len(rows))
+	missing := []string{}
+	for i, row := range rows {
+		if row == nil {
+			missing = append(missing, req.IDs[i])
+			if req.Missing == "null" {
+				items = append(items, nil)
+			}
+			continue
+		}
+		items = append(items, MaskRow(role, schema, row))
+	}
+	JSON(w, http.StatusOK, map[string]any{"items": items, "missing": missing})
+}
+
 func (a *DBAPI) handleRows(w http.ResponseWriter, r *http.Request, dbID, table string, rest []string) {
 	principal := PrincipalFromRequest(r.Header.Get("X-Debug-Principal"))
 	// collection path
@@ -623,12 +904,13 @@ func (a *DBAPI) handleRows(w http.ResponseWriter, r *http.Request, dbID, table s
 				JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
 				return
 			}
-			rows, next, err := a.Manager.QueryRows(r.Context(), a.OrgID, dbID, table, "id", 50, r.URL.Query().Get("cursor"), true)
-			if err != nil {
-				JSONError(w, http.StatusInternalServerError, "query failed", "query_failed", err.Error())
+			// ?profile=1 runs the query with the RethinkDB profiler (admin only)
+			profile := r.URL.Query().Get("profile") == "1"
+			if profile && role != model.RoleAdmin {
+				JSONError(w, http.StatusForbidden, "profiling requires admin", "forbidden")
 				return
 			}
-			// schema for masking
+			// schema for masking and where[...] validation
 			schema := []model.ColumnDef{}
 			if tbls, _ := a.Manager.GetTables(r.Context(), a.OrgID, dbID); true {
 				for _, t := range tbls {
@@ -638,17 +920,53 @@ func (a *DBAPI) handleRows(w http.ResponseWriter, r *http.Request, dbID, table s
 					}
 				}
 			}
+			matches, werr := parseWhereParams(r.URL.Query(), schema)
+			if werr != nil {
+				JSONError(w, http.StatusBadRequest, werr.Error(), "bad_where")
+				return
+			}
+			// filtering on a masked column would reveal its values one guess at a time
+			if role == model.RoleViewer || role == model.RoleEditor {
+				for _, f := range matches {
+					for _, c := range schema {
+						if c.Name == f.Path[0] && c.Mask {
+							JSONError(w, http.StatusForbidden, "cannot filter by masked column", "masked_column", c.Name)
+							return
+						}
+					}
+				}
+			}
+			var (
+				rows    []map[string]any
+				next    string
+				profOut any
+				err     error
+			)
+			ctx, fields := rowsContext(r), parseFieldsParam(r.URL.Query().Get("fields"))
+			if profile {
+				rows, next, profOut, err = a.Manager.QueryRowsProfiled(ctx, a.OrgID, dbID, table, "id", 50, r.URL.Query().Get("cursor"), true, matches, fields)
+			} else {
+				rows, next, err = a.Manager.QueryRows(ctx, a.OrgID, dbID, table, "id", 50, r.URL.Query().Get("cursor"), true, matches, fields)
+			}
+			if err != nil {
+				JSONError(w, http.StatusInternalServerError, "query failed", "query_failed", err.Error())
+				return
+			}
+			// Masking runs on the projected rows, so ?fields= cannot reveal a masked column
 			masked := make([]map[string]any, 0, len(rows))
 			for _, row := range rows {
 				masked = append(masked, MaskRow(role, schema, row))
 			}
-			JSON(w, http.StatusOK, model.QueryPage[map[string]any]{Items: masked, NextCursor: next})
+			JSON(w, http.StatusOK, model.QueryPage[map[string]any]{Items: masked, NextCursor: next, Profile: profOut})
 		case http.MethodPost:
 			if !Allow(a.roleFor(principal, table, dbID), "row.write") {
 				JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
 				return
 			}
-			b, _ := io.ReadAll(r.Body)
+			b, err := io.ReadAll(r.Body)
+			if BodyTooLarge(w, err) {
+				return
+			}
 			_ = r.Body.Close()
 			var payload any
 			if err := json.Unmarshal(b, &payload); err != nil {
@@ -676,6 +994,35 @@ func (a *DBAPI) handleRows(w http.ResponseWriter, r *http.Request, dbID, table s
 			}
 			metrics.IncOp(a.OrgID, table, "insert", 0)
 			JSON(w, http.StatusCreated, map[string]any{"inserted": len(ids), "ids": ids})
+		case http.MethodDelete:
+			// Bulk delete by equality filter: ?where={"status":"done"}. An empty filter
+			// would wipe the table, so it additionally needs ?all=1.
+			if !Allow(a.roleFor(principal, table, dbID), "row.write") {
+				JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
+				return
+			}
+			filter := map[string]any{}
+			if where := strings.TrimSpace(r.URL.Query().Get("where")); where != "" {
+				if err := json.Unmarshal([]byte(where), &filter); err != nil {
+					JSONError(w, http.StatusBadRequest, "where must be a JSON object", "bad_where", err.Error())
+					return
+				}
+			}
+			if len(filter) == 0 && r.URL.Query().Get("all") != "1" {
+				JSONError(w, http.StatusBadRequest, "empty filter deletes every row; pass all=1 to confirm", "filter_required")
+				return
+			}
+			n, err := a.Manager.DeleteRows(r.Context(), a.OrgID, dbID, table, filter)
+			if err != nil {
+				if errors.Is(err, db.ErrNotFound) {
+					JSONError(w, http.StatusNotFound, "table not found", "not_found")
+					return
+				}
+				JSONError(w, http.StatusInternalServerError, "delete failed", "delete_failed", err.Error())
+				return
+			}
+			metrics.IncOp(a.OrgID, table, "delete", 0)
+			JSON(w, http.StatusOK, map[string]any{"deleted": n})
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
@@ -687,12 +1034,63 @@ func (a *DBAPI) handleRows(w http.ResponseWriter, r *http.Request, dbID, table s
 		JSONError(w, http.StatusBadRequest, "missing id", "missing_id")
 		return
 	}
+	// POST /:rowId/restore undoes a soft delete
+	if len(rest) == 2 && rest[1] == "restore" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !Allow(a.roleFor(principal, table, dbID), "row.write") {
+			JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
+			return
+		}
+		if err := a.Manager.RestoreRow(r.Context(), a.OrgID, dbID, table, rowID); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				JSONError(w, http.StatusNotFound, "no deleted row", "not_found")
+				return
+			}
+			JSONError(w, http.StatusInternalServerError, "restore failed", "restore_failed", err.Error())
+			return
+		}
+		JSON(w, http.StatusOK, map[string]any{"restored": rowID})
+		return
+	}
+	if r.Method == http.MethodGet {
+		role := a.roleFor(principal, table, dbID)
+		if !Allow(role, "row.read") {
+			JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
+			return
+		}
+		row, err := a.Manager.GetRow(rowsContext(r), a.OrgID, dbID, table, rowID)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				JSONError(w, http.StatusNotFound, "row not found", "not_found")
+				return
+			}
+			JSONError(w, http.StatusInternalServerError, "get failed", "get_failed", err.Error())
+			return
+		}
+		schema := []model.ColumnDef{}
+		if tbls, _ := a.Manager.GetTables(r.Context(), a.OrgID, dbID); true {
+			for _, t := range tbls {
+				if t.Name == table {
+					schema = t.Schema
+					break
+				}
+			}
+		}
+		JSON(w, http.StatusOK, MaskRow(role, schema, row))
+		return
+	}
 	if r.Method == http.MethodPatch {
 		if !Allow(a.roleFor(principal, table, dbID), "row.write") {
 			JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
 			return
 		}
-		b, _ := io.ReadAll(r.Body)
+		b, err := io.ReadAll(r.Body)
+		if BodyTooLarge(w, err) {
+			return
+		}
 		_ = r.Body.Close()
 		var patch map[string]any
 		if err := json.Unmarshal(b, &patch); err != nil {
@@ -700,6 +1098,10 @@ func (a *DBAPI) handleRows(w http.ResponseWriter, r *http.Request, dbID, table s
 			return
 		}
 		if err := a.Manager.UpdateRow(r.Context(), a.OrgID, dbID, table, rowID, patch); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				JSONError(w, http.StatusNotFound, "row not found", "not_found")
+				return
+			}
 			JSONError(w, http.StatusInternalServerError, "update failed", "update_failed", err.Error())
 			return
 		}
@@ -712,6 +1114,10 @@ func (a *DBAPI) handleRows(w http.ResponseWriter, r *http.Request, dbID, table s
 			return
 		}
 		if err := a.Manager.DeleteRow(r.Context(), a.OrgID, dbID, table, rowID); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				JSONError(w, http.StatusNotFound, "row not found", "not_found")
+				return
+			}
 			JSONError(w, http.StatusInternalServerError, "delete failed", "delete_failed", err.Error())
 			return
 		}
@@ -721,24 +1127,124 @@ func (a *DBAPI) handleRows(w http.ResponseWriter, r *http.Request, dbID, table s
 	w.WriteHeader(http.StatusMethodNotAllowed)
 }
 
-// handleChangefeed implements SSE streaming for table changes: /sse/db/:dbId/tables/:table/changes
+// rowsContext returns the request context, widened by db.WithDeleted when the request
+// asks for soft-deleted rows with ?includeDeleted=1.
+func rowsContext(r *http.Request) context.Context {
+	if v := r.URL.Query().Get("includeDeleted"); v == "1" || v == "true" {
+		return db.WithDeleted(r.Context())
+	}
+	return r.Context()
+}
+
+// parseFieldsParam splits ?fields=name,email into distinct top-level field names.
+func parseFieldsParam(v string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, f := range strings.Split(v, ",") {
+		if f = strings.TrimSpace(f); f != "" && !seen[f] {
+			seen[f] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// parseWhereParams turns where[<path>]=<value> query params into equality matches. A
+// dotted path (where[meta.region]=us) reads a field nested in a json column; its root
+// must not be a declared column of another type. Values for declared number and boolean
+// columns are parsed as such; nested values are decoded as JSON when they parse and kept
+// as strings otherwise.
+func parseWhereParams(q url.Values, schema []model.ColumnDef) ([]db.FieldMatch, error) {
+	cols := make(map[string]model.ColumnType, len(schema))
+	for _, c := range schema {
+		cols[c.Name] = c.Type
+	}
+	var out []db.FieldMatch
+	for key, vals := range q {
+		if !strings.HasPrefix(key, "where[") || !strings.HasSuffix(key, "]") || len(vals) == 0 {
+			continue
+		}
+		path := strings.Split(key[len("where["):len(key)-1], ".")
+		for _, seg := range path {
+			if seg == "" {
+				return nil, fmt.Errorf("invalid field path %q", key)
+			}
+		}
+		raw := vals[len(vals)-1]
+		typ, declared := cols[path[0]]
+		if len(path) > 1 {
+			if declared && typ != model.ColJSON {
+				return nil, fmt.Errorf("column %q is not a json column", path[0])
+			}
+			var v any
+			if json.Unmarshal([]byte(raw), &v) != nil {
+				v = raw
+			}
+			out = append(out, db.FieldMatch{Path: path, Value: v})
+			continue
+		}
+		var v any = raw
+		switch typ {
+		case model.ColNumber:
+			n, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("column %q expects a number", path[0])
+			}
+			v = n
+		case model.ColBoolean:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("column %q expects a boolean", path[0])
+			}
+			v = b
+		}
+		out = append(out, db.FieldMatch{Path: path, Value: v})
+	}
+	return out, nil
+}
+
+// handleChangefeed implements SSE streaming for table changes: /sse/db/:dbId/tables/:table/changes,
+// and for every table of a database at once: /sse/db/:dbId/changes (events carry their
+// table in tableId; "table_added"/"table_dropped" mark tables appearing or going away).
 // Query params:
 //
 //	cursor=<token> (resume not yet implemented; placeholder)
 //	pause=1 to start paused (buffering up to a bounded backlog)
+//	backlog=N overrides the paused buffer size (1..MaxChangefeedBacklog)
+//	ops=insert,update limits the operations streamed
+//	columns=status,name only streams changes touching those columns, trimmed to them
+//
+// When a paused backlog fills, it is discarded and an "overflow" event reports the
+// number of events dropped; clients must re-read the table rather than rely on the
+// buffered changes.
 func (a *DBAPI) handleChangefeed(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/sse/db/")
 	parts := strings.Split(path, "/")
-	if len(parts) < 4 || parts[1] != "tables" || parts[3] != "changes" {
+	var table string
+	switch {
+	case len(parts) == 2 && parts[1] == "changes":
+		// database-wide feed; table stays empty
+	case len(parts) >= 4 && parts[1] == "tables" && parts[3] == "changes":
+		table = parts[2]
+	default:
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 	dbID := parts[0]
-	table := parts[2]
 	// Basic validation (dbID ignored for now since single-org stub)
 	_ = dbID
 	// Establish changefeed
-	stream, err := a.Manager.SubscribeTable(r.Context(), a.OrgID, dbID, table)
+	filter, err := db.ParseChangefeedFilter(r.URL.Query().Get("ops"), r.URL.Query().Get("columns"))
+	if err != nil {
+		JSONError(w, http.StatusBadRequest, "invalid changefeed filter", "invalid_filter", err.Error())
+		return
+	}
+	var stream *db.ChangefeedStream
+	if table == "" {
+		stream, err = a.Manager.SubscribeDatabaseFiltered(r.Context(), a.OrgID, dbID, filter)
+	} else {
+		stream, err = a.Manager.SubscribeTableFiltered(r.Context(), a.OrgID, dbID, table, filter)
+	}
 	if err != nil {
 		JSONError(w, http.StatusInternalServerError, "subscribe failed", "subscribe_failed", err.Error())
 		return
@@ -756,7 +1262,19 @@ func (a *DBAPI) handleChangefeed(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Accel-Buffering", "no")
 	enc := json.NewEncoder(w)
 	paused := r.URL.Query().Get("pause") == "1"
-	backlog := make([]model.ChangefeedEvent, 0, 512)
+	backlogSize := a.ChangefeedBacklog
+	if backlogSize <= 0 {
+		backlogSize = DefaultChangefeedBacklog
+	}
+	if v := r.URL.Query().Get("backlog"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxChangefeedBacklog {
+			JSONError(w, http.StatusBadRequest, fmt.Sprintf("backlog must be between 1 and %d", MaxChangefeedBacklog), "invalid_backlog")
+			return
+		}
+		backlogSize = n
+	}
+	backlog := make([]model.ChangefeedEvent, 0, backlogSize)
 	heartbeat := time.NewTicker(20 * time.Second)
 	defer heartbeat.Stop()
 	writeEvent := func(ev model.ChangefeedEvent) bool {
@@ -785,8 +1303,15 @@ func (a *DBAPI) handleChangefeed(w http.ResponseWriter, r *http.Request) {
 			if paused {
 				if len(backlog) < cap(backlog) {
 					backlog = append(backlog, ev)
+					continue
+				}
+				// Buffer full: the client has to reload anyway, so drop the backlog and say so
+				dropped := len(backlog) + 1
+				backlog = backlog[:0]
+				log.Printf("changefeed overflow table=%s dropped=%d", table, dropped)
+				if !writeEvent(model.ChangefeedEvent{Type: "overflow", TableID: table, Dropped: dropped, TS: model.NowISO()}) {
+					return
 				}
-				// Update pending counter (lightweight) by sending a heartbeat style comment
 			} else {
 				if !writeEvent(ev) {
 					return
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -20,9 +24,13 @@ import (
 // and delegate to DBAPI with OrgID bound to the provided cluster id.
 
 type mockManager struct {
-	dbs    map[string]model.DatabaseInstance
-	tables map[string][]model.Table    // key=dbID
-	rows   map[string][]map[string]any // key=dbID:table
+	dbs       map[string]model.DatabaseInstance
+	tables    map[string][]model.Table         // key=dbID
+	rows      map[string][]map[string]any      // key=dbID:table
+	feed      []model.ChangefeedEvent          // replayed by SubscribeTableFiltered and SubscribeDatabaseFiltered
+	idx       map[string][]db.TableIndexStatus // key=dbID
+	actor     string                           // db.Actor of the last QueryRows
+	existsErr error                            // returned by DatabaseExists
 }
 
 func newMock() *mockManager {
@@ -47,6 +55,11 @@ func (m *mockManager) GetDatabase(ctx context.Context, orgID, dbID string) (mode
 	}
 	return model.DatabaseInstance{}, io.EOF
 }
+func (m *mockManager) DatabaseExists(ctx context.Context, orgID, dbID string) (bool, error) {
+	_, ok := m.dbs[dbID]
+	_, hasTables := m.tables[dbID]
+	return ok || hasTables, m.existsErr
+}
 func (m *mockManager) DeleteDatabase(ctx context.Context, orgID, dbID string) error {
 	delete(m.dbs, dbID)
 	return nil
@@ -63,9 +76,76 @@ func (m *mockManager) CreateTable(ctx context.Context, orgID, dbID string, t mod
 func (m *mockManager) UpdateTableSchema(ctx context.Context, orgID, dbID, table string, schema []model.ColumnDef, pk string) error {
 	return nil
 }
-func (m *mockManager) QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool) ([]map[string]any, string, error) {
+func (m *mockManager) SetTableAuditReads(ctx context.Context, orgID, dbID, table string, on bool) error {
+	for i := range m.tables[dbID] {
+		if m.tables[dbID][i].Name == table {
+			m.tables[dbID][i].AuditReads = on
+			return nil
+		}
+	}
+	return db.ErrNotFound
+}
+func (m *mockManager) QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, error) {
+	m.actor = db.Actor(ctx)
 	key := dbID + ":" + table
-	return m.rows[key], "", nil
+	if (!m.softDelete(dbID, table) || db.IncludeDeleted(ctx)) && len(where) == 0 && len(fields) == 0 {
+		return m.rows[key], "", nil
+	}
+	out := []map[string]any{}
+rows:
+	for _, row := range m.rows[key] {
+		if _, deleted := row[model.SoftDeleteField]; deleted && m.softDelete(dbID, table) && !db.IncludeDeleted(ctx) {
+			continue
+		}
+		for _, f := range where {
+			if !fieldMatches(f, row) {
+				continue rows
+			}
+		}
+		out = append(out, projectRow(row, fields))
+	}
+	return out, "", nil
+}
+
+// projectRow mirrors the pluck of QueryRows' fields: row reduced to fields plus "id".
+func projectRow(row map[string]any, fields []string) map[string]any {
+	if len(fields) == 0 {
+		return row
+	}
+	out := map[string]any{}
+	for _, f := range append([]string{"id"}, fields...) {
+		if v, ok := row[f]; ok {
+			out[f] = v
+		}
+	}
+	return out
+}
+
+// fieldMatches mirrors the ReQL filter of a db.FieldMatch: row holds f.Value at f.Path.
+func fieldMatches(f db.FieldMatch, row map[string]any) bool {
+	var cur any = row
+	for _, seg := range f.Path {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return false
+		}
+		if cur, ok = obj[seg]; !ok {
+			return false
+		}
+	}
+	return reflect.DeepEqual(cur, f.Value)
+}
+func (m *mockManager) softDelete(dbID, table string) bool {
+	for _, t := range m.tables[dbID] {
+		if t.Name == table {
+			return t.SoftDelete
+		}
+	}
+	return false
+}
+func (m *mockManager) QueryRowsProfiled(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, any, error) {
+	key := dbID + ":" + table
+	return m.rows[key], "", []any{map[string]any{"description": "Perform read on table."}}, nil
 }
 func (m *mockManager) InsertRows(ctx context.Context, orgID, dbID, table string, rows []map[string]any) ([]string, error) {
 	key := dbID + ":" + table
@@ -78,16 +158,151 @@ func (m *mockManager) InsertRows(ctx context.Context, orgID, dbID, table string,
 	}
 	return ids, nil
 }
+func (m *mockManager) findRow(dbID, table, id string) int {
+	for i, row := range m.rows[dbID+":"+table] {
+		if v, ok := row["id"].(string); ok && v == id {
+			return i
+		}
+	}
+	return -1
+}
+func (m *mockManager) GetRow(ctx context.Context, orgID, dbID, table, id string) (map[string]any, error) {
+	i := m.findRow(dbID, table, id)
+	if i < 0 {
+		return nil, db.ErrNotFound
+	}
+	row := m.rows[dbID+":"+table][i]
+	if _, deleted := row[model.SoftDeleteField]; deleted && !db.IncludeDeleted(ctx) {
+		return nil, db.ErrNotFound
+	}
+	return row, nil
+}
+func (m *mockManager) BatchGet(ctx context.Context, orgID, dbID, table string, ids []string) ([]map[string]any, error) {
+	out := make([]map[string]any, len(ids))
+	for i, id := range ids {
+		if j := m.findRow(dbID, table, id); j >= 0 {
+			out[i] = m.rows[dbID+":"+table][j]
+		}
+	}
+	return out, nil
+}
 func (m *mockManager) UpdateRow(ctx context.Context, orgID, dbID, table, id string, patch map[string]any) error {
+	i := m.findRow(dbID, table, id)
+	if i < 0 {
+		return db.ErrNotFound
+	}
+	for k, v := range patch {
+		m.rows[dbID+":"+table][i][k] = v
+	}
+	return nil
+}
+func (m *mockManager) DeleteRow(ctx context.Context, orgID, dbID, table, id string) error {
+	i := m.findRow(dbID, table, id)
+	if i < 0 {
+		return db.ErrNotFound
+	}
+	key := dbID + ":" + table
+	if m.softDelete(dbID, table) {
+		if _, deleted := m.rows[key][i][model.SoftDeleteField]; deleted {
+			return db.ErrNotFound
+		}
+		m.rows[key][i][model.SoftDeleteField] = model.NowISO()
+		return nil
+	}
+	m.rows[key] = append(m.rows[key][:i], m.rows[key][i+1:]...)
+	return nil
+}
+func (m *mockManager) RestoreRow(ctx context.Context, orgID, dbID, table, id string) error {
+	i := m.findRow(dbID, table, id)
+	if i < 0 {
+		return db.ErrNotFound
+	}
+	row := m.rows[dbID+":"+table][i]
+	if _, deleted := row[model.SoftDeleteField]; !deleted {
+		return db.ErrNotFound
+	}
+	delete(row, model.SoftDeleteField)
 	return nil
 }
-func (m *mockManager) DeleteRow(ctx context.Context, orgID, dbID, table, id string) error { return nil }
+func (m *mockManager) TruncateTable(ctx context.Context, orgID, dbID, table string) (int, error) {
+	found := false
+	for _, t := range m.tables[dbID] {
+		if t.Name == table {
+			found = true
+		}
+	}
+	if !found {
+		return 0, db.ErrNotFound
+	}
+	key := dbID + ":" + table
+	n := len(m.rows[key])
+	m.rows[key] = nil
+	return n, nil
+}
+func (m *mockManager) DeleteRows(ctx context.Context, orgID, dbID, table string, filter map[string]any) (int64, error) {
+	found := false
+	for _, t := range m.tables[dbID] {
+		if t.Name == table {
+			found = true
+		}
+	}
+	if !found {
+		return 0, db.ErrNotFound
+	}
+	key := dbID + ":" + table
+	kept := m.rows[key][:0]
+	var n int64
+	for _, row := range m.rows[key] {
+		match := true
+		for k, v := range filter {
+			if stringify(row[k]) != stringify(v) {
+				match = false
+			}
+		}
+		if match {
+			n++
+			continue
+		}
+		kept = append(kept, row)
+	}
+	m.rows[key] = kept
+	return n, nil
+}
+func (m *mockManager) Aggregate(ctx context.Context, orgID, dbID, table, groupBy string) (map[string]int64, error) {
+	out := map[string]int64{}
+	for _, row := range m.rows[dbID+":"+table] {
+		out[stringify(row[groupBy])]++
+	}
+	return out, nil
+}
 func (m *mockManager) ListAudit(ctx context.Context, orgID, dbID string, limit int) ([]model.AuditEvent, error) {
 	return nil, nil
 }
+func (m *mockManager) SubscribeTableFiltered(ctx context.Context, orgID, dbID, table string, f db.ChangefeedFilter) (*db.ChangefeedStream, error) {
+	ch := make(chan model.ChangefeedEvent, len(m.feed))
+	for _, ev := range m.feed {
+		if f.Match(ev) {
+			ch <- f.Project(ev)
+		}
+	}
+	close(ch)
+	return &db.ChangefeedStream{C: ch, Cancel: func() {}}, nil
+}
+func (m *mockManager) SubscribeDatabaseFiltered(ctx context.Context, orgID, dbID string, f db.ChangefeedFilter) (*db.ChangefeedStream, error) {
+	return m.SubscribeTableFiltered(ctx, orgID, dbID, "", f)
+}
+func (m *mockManager) SubscribeDatabase(ctx context.Context, orgID, dbID string) (*db.ChangefeedStream, error) {
+	return m.SubscribeDatabaseFiltered(ctx, orgID, dbID, db.ChangefeedFilter{})
+}
 func (m *mockManager) SubscribeTable(ctx context.Context, orgID, dbID, table string) (*db.ChangefeedStream, error) {
 	return nil, nil
 }
+func (m *mockManager) IndexStatus(ctx context.Context, orgID, dbID string) ([]db.TableIndexStatus, error) {
+	if _, ok := m.dbs[dbID]; !ok {
+		return nil, db.ErrNotFound
+	}
+	return m.idx[dbID], nil
+}
 func (m *mockManager) Ping(ctx context.Context) error { return nil }
 
 func setupClusterMux(t *testing.T, clusterID string) *httptest.Server {
@@ -206,3 +421,742 @@ func TestE2E_ClusterDB_TablesAndRows(t *testing.T) {
 	}
 	_ = resp3.Body.Close()
 }
+
+func TestAggregateGroupByAndMasking(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	api.RBAC.Grant(model.PermissionBinding{Principal: "user:viewer", Scope: "db:db1", Role: model.RoleViewer, CreatedAt: model.NowISO()})
+	mux := http.NewServeMux()
+	api.Register(mux)
+	_ = m.CreateTable(context.Background(), "org", "db1", model.Table{ID: "users", Name: "users", Schema: []model.ColumnDef{{Name: "plan", Type: model.ColString}, {Name: "email", Type: model.ColString, Mask: true}}})
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "users", []map[string]any{
+		{"id": "u1", "plan": "free", "email": "a@b"},
+		{"id": "u2", "plan": "pro", "email": "c@d"},
+		{"id": "u3", "plan": "free", "email": "e@f"},
+	})
+
+	do := func(principal, groupBy string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/db/db1/tables/users/aggregate?groupBy="+groupBy, nil)
+		if principal != "" {
+			req.Header.Set("X-Debug-Principal", principal)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("user:viewer", "plan")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("aggregate status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var counts map[string]int64
+	if err := json.Unmarshal(rec.Body.Bytes(), &counts); err != nil {
+		t.Fatal(err)
+	}
+	if counts["free"] != 2 || counts["pro"] != 1 {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+	if rec := do("user:viewer", "email"); rec.Code != http.StatusForbidden {
+		t.Fatalf("masked groupBy for viewer status=%d want 403", rec.Code)
+	}
+	if rec := do("", "email"); rec.Code != http.StatusOK {
+		t.Fatalf("masked groupBy for admin status=%d want 200", rec.Code)
+	}
+	if rec := do("", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing groupBy status=%d want 400", rec.Code)
+	}
+
+	// where[] on a masked column is refused the same way
+	where := func(principal, query string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/db/db1/tables/users/rows?"+query, nil)
+		if principal != "" {
+			req.Header.Set("X-Debug-Principal", principal)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := where("user:viewer", "where%5Bemail%5D=a@b"); code != http.StatusForbidden {
+		t.Fatalf("masked where for viewer status=%d want 403", code)
+	}
+	if code := where("user:viewer", "where%5Bplan%5D=free"); code != http.StatusOK {
+		t.Fatalf("unmasked where for viewer status=%d want 200", code)
+	}
+	if code := where("", "where%5Bemail%5D=a@b"); code != http.StatusOK {
+		t.Fatalf("masked where for admin status=%d want 200", code)
+	}
+}
+
+func TestRowItemNotFound(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	mux := http.NewServeMux()
+	api.Register(mux)
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "users", []map[string]any{{"id": "u1", "email": "a@b"}})
+
+	do := func(method, id, body string) int {
+		req := httptest.NewRequest(method, "/api/db/db1/tables/users/rows/"+id, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := do(http.MethodGet, "u1", ""); code != http.StatusOK {
+		t.Fatalf("get existing status=%d", code)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+		if code := do(method, "missing", `{"email":"x"}`); code != http.StatusNotFound {
+			t.Fatalf("%s missing row status=%d want 404", method, code)
+		}
+	}
+	if code := do(http.MethodPatch, "u1", `{"email":"x@y"}`); code != http.StatusOK {
+		t.Fatalf("patch existing status=%d", code)
+	}
+	if code := do(http.MethodDelete, "u1", ""); code != http.StatusOK {
+		t.Fatalf("delete existing status=%d", code)
+	}
+	if code := do(http.MethodGet, "u1", ""); code != http.StatusNotFound {
+		t.Fatalf("get deleted status=%d want 404", code)
+	}
+}
+
+func TestTableLookupMissingDatabase(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	mux := http.NewServeMux()
+	api.Register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/db/nope/tables/users", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status=%d want 404", rec.Code)
+	}
+
+	_ = m.CreateTable(context.Background(), "org", "db1", model.Table{ID: "users", Name: "users"})
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/db/db1/tables/users", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("existing table status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	// A failed lookup is not a missing database
+	m.existsErr = errors.New("connection refused")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/db/db1/tables/users", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("lookup error status=%d want 500", rec.Code)
+	}
+}
+
+func TestHealthIndexStatus(t *testing.T) {
+	m := newMock()
+	m.dbs["db1"] = model.DatabaseInstance{ID: "db1"}
+	m.idx = map[string][]db.TableIndexStatus{"db1": {
+		{Table: "users", Ready: true, Indexes: []db.IndexState{{Name: "email", Ready: true}}},
+		{Table: "events", Ready: false, Indexes: []db.IndexState{{Name: "ts", Progress: 0.4}}},
+	}}
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	mux := http.NewServeMux()
+	api.Register(mux)
+	get := func(path string) map[string]any {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var out map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		return out
+	}
+
+	if out := get("/api/db/health"); out["indexes"] != nil {
+		t.Fatalf("plain health should not report indexes: %v", out)
+	}
+	out := get("/api/db/health?indexes=1")
+	if out["status"] != "ok" || out["indexes_ready"] != false {
+		t.Fatalf("extended health: %v", out)
+	}
+	tables := out["indexes"].(map[string]any)["db1"].([]any)
+	if len(tables) != 2 || tables[1].(map[string]any)["table"] != "events" || tables[1].(map[string]any)["ready"] != false {
+		t.Fatalf("tables: %v", tables)
+	}
+	m.idx["db1"] = m.idx["db1"][:1]
+	if out := get("/api/db/health?indexes=1&db=db1"); out["indexes_ready"] != true {
+		t.Fatalf("ready db: %v", out)
+	}
+	if out := get("/api/db/health?indexes=1&db=nope"); out["indexes_error"] != "database not found" {
+		t.Fatalf("missing db: %v", out)
+	}
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	mux := http.NewServeMux()
+	api.Register(mux)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/db/db1/tables"+path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := do(http.MethodPost, "", `{"name":"users","soft_delete":true}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create table status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "users", []map[string]any{{"id": "u1"}, {"id": "u2"}})
+
+	if rec := do(http.MethodPost, "/users/rows/u1/restore", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("restore live row status=%d want 404", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/users/rows/u1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete status=%d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/users/rows/u1", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("second delete status=%d want 404", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/users/rows/u1", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("get soft-deleted status=%d want 404", rec.Code)
+	}
+	rec := do(http.MethodGet, "/users/rows/u1?includeDeleted=1", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), model.SoftDeleteField) {
+		t.Fatalf("get includeDeleted status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	count := func(query string) int {
+		var page model.QueryPage[map[string]any]
+		if err := json.Unmarshal(do(http.MethodGet, "/users/rows"+query, "").Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		return len(page.Items)
+	}
+	if n := count(""); n != 1 {
+		t.Fatalf("list=%d rows want 1", n)
+	}
+	if n := count("?includeDeleted=1"); n != 2 {
+		t.Fatalf("list includeDeleted=%d rows want 2", n)
+	}
+	if rec := do(http.MethodPost, "/users/rows/u1/restore", ""); rec.Code != http.StatusOK {
+		t.Fatalf("restore status=%d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/users/rows/u1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("get restored status=%d", rec.Code)
+	}
+}
+
+func TestRowsProfileAdminOnly(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	api.RBAC.Grant(model.PermissionBinding{Principal: "user:viewer", Scope: "db:db1", Role: model.RoleViewer, CreatedAt: model.NowISO()})
+	mux := http.NewServeMux()
+	api.Register(mux)
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "users", []map[string]any{{"id": "u1"}})
+
+	do := func(principal string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/db/db1/tables/users/rows?profile=1", nil)
+		if principal != "" {
+			req.Header.Set("X-Debug-Principal", principal)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := do("user:viewer"); rec.Code != http.StatusForbidden {
+		t.Fatalf("viewer profile status=%d want 403", rec.Code)
+	}
+	rec := do("")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("admin profile status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var page model.QueryPage[map[string]any]
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Profile == nil {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+}
+
+func TestTruncateTable(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	api.RBAC.Grant(model.PermissionBinding{Principal: "user:editor", Scope: "db:db1", Role: model.RoleEditor, CreatedAt: model.NowISO()})
+	mux := http.NewServeMux()
+	api.Register(mux)
+	_ = m.CreateTable(context.Background(), "org", "db1", model.Table{ID: "users", Name: "users"})
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "users", []map[string]any{{"id": "u1"}, {"id": "u2"}})
+
+	do := func(method, path, principal string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if principal != "" {
+			req.Header.Set("X-Debug-Principal", principal)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := do(http.MethodGet, "/api/db/db1/tables/users/truncate", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET truncate status=%d want 405", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/db/db1/tables/users/truncate", "user:editor"); rec.Code != http.StatusForbidden {
+		t.Fatalf("editor truncate status=%d want 403", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/db/db1/tables/users/truncate", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("truncate status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || out.Deleted != 2 {
+		t.Fatalf("deleted=%d err=%v body=%s", out.Deleted, err, rec.Body.String())
+	}
+	if rows := m.rows["db1:users"]; len(rows) != 0 {
+		t.Fatalf("rows left after truncate: %v", rows)
+	}
+	if tbls, _ := m.GetTables(context.Background(), "org", "db1"); len(tbls) != 1 {
+		t.Fatalf("table should survive truncate, got %v", tbls)
+	}
+	if rec := do(http.MethodPost, "/api/db/db1/tables/missing/truncate", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing table status=%d want 404", rec.Code)
+	}
+}
+
+func TestDeleteRowsByFilter(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	api.RBAC.Grant(model.PermissionBinding{Principal: "user:viewer", Scope: "db:db1", Role: model.RoleViewer, CreatedAt: model.NowISO()})
+	mux := http.NewServeMux()
+	api.Register(mux)
+	_ = m.CreateTable(context.Background(), "org", "db1", model.Table{ID: "jobs", Name: "jobs"})
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "jobs", []map[string]any{
+		{"id": "j1", "status": "done"}, {"id": "j2", "status": "running"}, {"id": "j3", "status": "done"},
+	})
+
+	do := func(path, principal string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, path, nil)
+		if principal != "" {
+			req.Header.Set("X-Debug-Principal", principal)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	where := url.QueryEscape(`{"status":"done"}`)
+	if rec := do("/api/db/db1/tables/jobs/rows?where="+where, "user:viewer"); rec.Code != http.StatusForbidden {
+		t.Fatalf("viewer delete status=%d want 403", rec.Code)
+	}
+	if rec := do("/api/db/db1/tables/jobs/rows?where=notjson", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad where status=%d want 400", rec.Code)
+	}
+	if rec := do("/api/db/db1/tables/jobs/rows", ""); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "filter_required") {
+		t.Fatalf("empty filter status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	rec := do("/api/db/db1/tables/jobs/rows?where="+where, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"deleted":2`) {
+		t.Fatalf("delete status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if rows := m.rows["db1:jobs"]; len(rows) != 1 || rows[0]["id"] != "j2" {
+		t.Fatalf("rows left = %v", rows)
+	}
+	if rec := do("/api/db/db1/tables/jobs/rows?all=1", ""); rec.Code != http.StatusOK || len(m.rows["db1:jobs"]) != 0 {
+		t.Fatalf("delete all status=%d rows=%v", rec.Code, m.rows["db1:jobs"])
+	}
+	if rec := do("/api/db/db1/tables/missing/rows?all=1", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing table status=%d want 404", rec.Code)
+	}
+}
+
+func TestQueryRowsNestedWhere(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	mux := http.NewServeMux()
+	api.Register(mux)
+	_ = m.CreateTable(context.Background(), "org", "db1", model.Table{ID: "hosts", Name: "hosts", Schema: []model.ColumnDef{
+		{Name: "name", Type: model.ColString}, {Name: "cores", Type: model.ColNumber}, {Name: "meta", Type: model.ColJSON},
+	}})
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "hosts", []map[string]any{
+		{"id": "h1", "name": "a", "cores": float64(4), "meta": map[string]any{"region": "us", "rack": float64(1)}},
+		{"id": "h2", "name": "b", "cores": float64(8), "meta": map[string]any{"region": "eu", "rack": float64(1)}},
+		{"id": "h3", "name": "c", "cores": float64(4), "meta": map[string]any{"region": "us", "rack": float64(2)}},
+		{"id": "h4", "name": "d", "cores": float64(4)},
+	})
+
+	get := func(query string) (int, []string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/db/db1/tables/hosts/rows?"+query, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var out struct {
+			Items []map[string]any `json:"items"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		ids := []string{}
+		for _, it := range out.Items {
+			ids = append(ids, it["id"].(string))
+		}
+		return rec.Code, ids
+	}
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"where%5Bmeta.region%5D=us", []string{"h1", "h3"}},
+		{"where%5Bmeta.region%5D=us&where%5Bmeta.rack%5D=2", []string{"h3"}},
+		{"where%5Bcores%5D=8", []string{"h2"}},
+		{"where%5Bmeta.zone%5D=x", []string{}},
+	}
+	for _, tc := range cases {
+		code, ids := get(tc.query)
+		if code != http.StatusOK || strings.Join(ids, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: status=%d ids=%v want %v", tc.query, code, ids, tc.want)
+		}
+	}
+	for _, bad := range []string{"where%5Bname.first%5D=x", "where%5Bcores%5D=many", "where%5Bmeta..region%5D=us"} {
+		if code, _ := get(bad); code != http.StatusBadRequest {
+			t.Fatalf("%s: status=%d want 400", bad, code)
+		}
+	}
+}
+
+func TestQueryRowsFieldProjection(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	api.RBAC.Grant(model.PermissionBinding{Principal: "user:viewer", Scope: "db:db1", Role: model.RoleViewer, CreatedAt: model.NowISO()})
+	mux := http.NewServeMux()
+	api.Register(mux)
+	_ = m.CreateTable(context.Background(), "org", "db1", model.Table{ID: "users", Name: "users", Schema: []model.ColumnDef{
+		{Name: "name", Type: model.ColString}, {Name: "email", Type: model.ColString, Mask: true}, {Name: "bio", Type: model.ColString},
+	}})
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "users", []map[string]any{{"id": "u1", "name": "a", "email": "a@x", "bio": "long"}})
+
+	get := func(query string) map[string]any {
+		req := httptest.NewRequest(http.MethodGet, "/api/db/db1/tables/users/rows?"+query, nil)
+		req.Header.Set("X-Debug-Principal", "user:viewer")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var out struct {
+			Items []map[string]any `json:"items"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || rec.Code != http.StatusOK || len(out.Items) != 1 {
+			t.Fatalf("%s: status=%d body=%s", query, rec.Code, rec.Body.String())
+		}
+		return out.Items[0]
+	}
+	row := get("fields=name")
+	if len(row) != 2 || row["id"] != "u1" || row["name"] != "a" {
+		t.Fatalf("projection should keep only name plus the primary key, got %v", row)
+	}
+	row = get("fields=email,%20name,email")
+	if row["email"] != "***" || row["bio"] != nil {
+		t.Fatalf("projected masked column must stay masked, got %v", row)
+	}
+	if row := get(""); len(row) != 4 {
+		t.Fatalf("no fields should return whole rows, got %v", row)
+	}
+}
+
+func TestBatchGetRows(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	api.RBAC.Grant(model.PermissionBinding{Principal: "user:viewer", Scope: "db:db1", Role: model.RoleViewer, CreatedAt: model.NowISO()})
+	mux := http.NewServeMux()
+	api.Register(mux)
+	_ = m.CreateTable(context.Background(), "org", "db1", model.Table{ID: "users", Name: "users", Schema: []model.ColumnDef{{Name: "email", Type: model.ColString, Mask: true}}})
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "users", []map[string]any{{"id": "u1", "email": "a@x"}, {"id": "u2", "email": "b@x"}})
+
+	do := func(body string) (int, []map[string]any, []string) {
+		req := httptest.NewRequest(http.MethodPost, "/api/db/db1/tables/users/rows:batchGet", strings.NewReader(body))
+		req.Header.Set("X-Debug-Principal", "user:viewer")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var out struct {
+			Items   []map[string]any `json:"items"`
+			Missing []string         `json:"missing"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		return rec.Code, out.Items, out.Missing
+	}
+
+	code, items, missing := do(`{"ids":["u2","nope","u1"]}`)
+	if code != http.StatusOK || len(items) != 2 || items[0]["id"] != "u2" || items[1]["id"] != "u1" {
+		t.Fatalf("status=%d items=%v", code, items)
+	}
+	if items[0]["email"] != "***" || len(missing) != 1 || missing[0] != "nope" {
+		t.Fatalf("expected masked email and missing=[nope], got items=%v missing=%v", items, missing)
+	}
+	if code, items, _ := do(`{"ids":["nope","u1"],"missing":"null"}`); code != http.StatusOK || len(items) != 2 || items[0] != nil || items[1]["id"] != "u1" {
+		t.Fatalf("null placeholders: status=%d items=%v", code, items)
+	}
+	if code, _, _ := do(`{"ids":["u1"],"missing":"skip"}`); code != http.StatusBadRequest {
+		t.Fatalf("bad missing mode status=%d want 400", code)
+	}
+}
+
+func TestTestConnection(t *testing.T) {
+	api := &DBAPI{OrgID: "org", RBAC: NewRBACStore()}
+	mux := http.NewServeMux()
+	api.Register(mux)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := ln.Addr().String()
+	_ = ln.Close()
+
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/db/test-connection", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := do(`{"user":"admin"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing addr status=%d want 400", rec.Code)
+	}
+	rec := do(`{"addr":"` + closedAddr + `"}`)
+	var out struct {
+		OK       bool   `json:"ok"`
+		Addr     string `json:"addr"`
+		Error    string `json:"error"`
+		Classify string `json:"classify"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status=%d err=%v body=%s", rec.Code, err, rec.Body.String())
+	}
+	if out.OK || out.Addr != closedAddr || out.Error == "" || out.Classify == "" || out.Classify == "none" {
+		t.Fatalf("unexpected result: %+v", out)
+	}
+	if api.Manager != nil {
+		t.Fatalf("test connection must not install a manager")
+	}
+}
+
+func TestChangefeedFilterOpsAndColumns(t *testing.T) {
+	m := newMock()
+	m.feed = []model.ChangefeedEvent{
+		{Type: "insert", TableID: "jobs", After: map[string]any{"id": "j1", "status": "new", "blob": "x"}},
+		{Type: "update", TableID: "jobs", Before: map[string]any{"id": "j1", "status": "new", "blob": "x"}, After: map[string]any{"id": "j1", "status": "new", "blob": "y"}},
+		{Type: "update", TableID: "jobs", Before: map[string]any{"id": "j1", "status": "new", "blob": "y"}, After: map[string]any{"id": "j1", "status": "done", "blob": "y"}},
+		{Type: "delete", TableID: "jobs", Before: map[string]any{"id": "j1", "status": "done"}},
+	}
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	mux := http.NewServeMux()
+	api.Register(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/sse/db/db1/tables/jobs/changes?ops=insert,update&columns=status", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var got []model.ChangefeedEvent
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var ev model.ChangefeedEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Type != "init" {
+			got = append(got, ev)
+		}
+	}
+	if len(got) != 2 || got[0].Type != "insert" || got[1].Type != "update" {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+	after, _ := got[1].After.(map[string]any)
+	if after["status"] != "done" || after["id"] != "j1" || after["blob"] != nil {
+		t.Fatalf("expected after trimmed to id+status, got %v", after)
+	}
+
+	bad := httptest.NewRecorder()
+	mux.ServeHTTP(bad, httptest.NewRequest(http.MethodGet, "/sse/db/db1/tables/jobs/changes?ops=upsert", nil))
+	if bad.Code != http.StatusBadRequest {
+		t.Fatalf("invalid op status=%d want 400", bad.Code)
+	}
+}
+
+func TestChangefeedPausedOverflow(t *testing.T) {
+	m := newMock()
+	for i := 0; i < 7; i++ {
+		m.feed = append(m.feed, model.ChangefeedEvent{Type: "insert", TableID: "jobs", After: map[string]any{"id": strconv.Itoa(i)}})
+	}
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore(), ChangefeedBacklog: 100}
+	mux := http.NewServeMux()
+	api.Register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sse/db/db1/tables/jobs/changes?pause=1&backlog=3", nil))
+	var overflows []model.ChangefeedEvent
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var ev model.ChangefeedEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+			t.Fatal(err)
+		}
+		switch ev.Type {
+		case "overflow":
+			overflows = append(overflows, ev)
+		case "insert", "update", "delete":
+			t.Fatalf("paused feed streamed %+v", ev)
+		}
+	}
+	// 3 buffered + the 4th overflows (4 dropped); then 5-7 refill the buffer.
+	if len(overflows) != 1 || overflows[0].Dropped != 4 || overflows[0].Cursor != "" {
+		t.Fatalf("overflow events=%+v want one with dropped=4 and no cursor", overflows)
+	}
+
+	bad := httptest.NewRecorder()
+	mux.ServeHTTP(bad, httptest.NewRequest(http.MethodGet, "/sse/db/db1/tables/jobs/changes?backlog=0", nil))
+	if bad.Code != http.StatusBadRequest {
+		t.Fatalf("invalid backlog status=%d want 400", bad.Code)
+	}
+}
+
+func TestDatabaseChangefeedRoute(t *testing.T) {
+	m := newMock()
+	m.feed = []model.ChangefeedEvent{
+		{Type: "table_added", TableID: "jobs"},
+		{Type: "insert", TableID: "jobs", After: map[string]any{"id": "j1"}},
+		{Type: "insert", TableID: "users", After: map[string]any{"id": "u1"}},
+	}
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	mux := http.NewServeMux()
+	api.Register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sse/db/db1/changes", nil))
+	var tables []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var ev model.ChangefeedEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Type == "insert" {
+			tables = append(tables, ev.TableID)
+		}
+	}
+	if strings.Join(tables, ",") != "jobs,users" {
+		t.Fatalf("got inserts for %v want jobs,users", tables)
+	}
+
+	nf := httptest.NewRecorder()
+	mux.ServeHTTP(nf, httptest.NewRequest(http.MethodGet, "/sse/db/db1/bogus", nil))
+	if nf.Code != http.StatusNotFound {
+		t.Fatalf("unknown path status=%d want 404", nf.Code)
+	}
+}
+
+func TestSchemaDiff(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	api.RBAC.Grant(model.PermissionBinding{Principal: "user:maint", Scope: "db:db1", Role: model.RoleMaintainer, CreatedAt: model.NowISO()})
+	api.RBAC.Grant(model.PermissionBinding{Principal: "user:viewer", Scope: "db:db1", Role: model.RoleViewer, CreatedAt: model.NowISO()})
+	mux := http.NewServeMux()
+	api.Register(mux)
+	_ = m.CreateTable(context.Background(), "org", "db1", model.Table{ID: "users", Name: "users", Schema: []model.ColumnDef{
+		{Name: "name", Type: model.ColString}, {Name: "age", Type: model.ColString}, {Name: "legacy", Type: model.ColString},
+	}})
+	_, _ = m.InsertRows(context.Background(), "org", "db1", "users", []map[string]any{
+		{"id": "u1", "name": "a", "age": "41", "tier": "gold"},
+		{"id": "u2", "name": "b", "age": float64(30), "tier": "gold"},
+		{"id": "u3", "age": float64(22), "tier": "tin"},
+	})
+
+	post := func(principal, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/db/db1/tables/users/schema:diff", strings.NewReader(body))
+		req.Header.Set("X-Debug-Principal", principal)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	proposed := `{"schema":[{"name":"name","type":"string","required":true},{"name":"age","type":"number"},{"name":"tier","type":"string","enum":["gold","silver"]}]}`
+	rec := post("user:maint", proposed)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var diff model.SchemaDiff
+	if err := json.Unmarshal(rec.Body.Bytes(), &diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Name != "tier" || len(diff.Removed) != 1 || diff.Removed[0].Name != "legacy" {
+		t.Fatalf("added=%v removed=%v", diff.Added, diff.Removed)
+	}
+	changed := map[string][]string{}
+	for _, c := range diff.Changed {
+		changed[c.Name] = c.Fields
+	}
+	if len(changed) != 2 || !reflect.DeepEqual(changed["name"], []string{"required"}) || !reflect.DeepEqual(changed["age"], []string{"type"}) {
+		t.Fatalf("changed=%v", changed)
+	}
+	bad := map[string][]string{}
+	for _, row := range diff.IncompatibleRows {
+		bad[row.ID] = row.Errors
+	}
+	if diff.SampledRows != 3 || len(bad) != 2 || !reflect.DeepEqual(bad["u1"], []string{"type:age"}) || !reflect.DeepEqual(bad["u3"], []string{"missing:name", "enum:tier"}) {
+		t.Fatalf("sampled=%d incompatible=%v", diff.SampledRows, bad)
+	}
+	// A preview changes nothing
+	tbls, _ := m.GetTables(context.Background(), "org", "db1")
+	if len(tbls[0].Schema) != 3 || tbls[0].Schema[2].Name != "legacy" {
+		t.Fatalf("schema modified by diff: %v", tbls[0].Schema)
+	}
+
+	if rec := post("user:viewer", proposed); rec.Code != http.StatusForbidden {
+		t.Fatalf("viewer status=%d", rec.Code)
+	}
+	if rec := post("user:maint", `{"schema":[{"name":"a","type":"string"},{"name":"a","type":"number"}]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("duplicate column status=%d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/db/db1/tables/missing/schema:diff", strings.NewReader(proposed))
+	req.Header.Set("X-Debug-Principal", "user:maint")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("missing table status=%d", rec.Code)
+	}
+	// A failed lookup is not a missing database
+	m.existsErr = errors.New("connection refused")
+	if rec := post("user:maint", proposed); rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "db_lookup_failed") {
+		t.Fatalf("lookup error status=%d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTableAuditReadsFlag(t *testing.T) {
+	m := newMock()
+	api := &DBAPI{Manager: m, OrgID: "org", RBAC: NewRBACStore()}
+	api.RBAC.Grant(model.PermissionBinding{Principal: "user:alice", Scope: "db:db1", Role: model.RoleMaintainer, CreatedAt: model.NowISO()})
+	mux := http.NewServeMux()
+	api.Register(mux)
+	do := func(method, path, body, principal string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/db/db1/tables"+path, strings.NewReader(body))
+		if principal != "" {
+			req.Header.Set("X-Debug-Principal", principal)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := do(http.MethodPost, "", `{"name":"patients","audit_reads":true}`, ""); rec.Code != http.StatusCreated || !m.tables["db1"][0].AuditReads {
+		t.Fatalf("create status=%d tables=%+v", rec.Code, m.tables["db1"])
+	}
+
+	// Reads carry the caller for the manager's audit event
+	if rec := do(http.MethodGet, "/patients/rows", "", "user:alice"); rec.Code != http.StatusOK || m.actor != "user:alice" {
+		t.Fatalf("rows status=%d actor=%q", rec.Code, m.actor)
+	}
+	if do(http.MethodGet, "/patients/rows", "", ""); m.actor != "anonymous" {
+		t.Fatalf("anonymous actor=%q", m.actor)
+	}
+
+	// PATCH may change only the flag
+	if rec := do(http.MethodPatch, "/patients", `{"audit_reads":false}`, ""); rec.Code != http.StatusOK || m.tables["db1"][0].AuditReads {
+		t.Fatalf("patch status=%d tables=%+v", rec.Code, m.tables["db1"])
+	}
+	if rec := do(http.MethodPatch, "/patients", `{}`, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty patch status=%d want 400", rec.Code)
+	}
+	if rec := do(http.MethodPatch, "/missing", `{"audit_reads":true}`, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("patch missing table status=%d want 404", rec.Code)
+	}
+}
@@ -12,18 +12,31 @@ type DBManager interface {
 	ListDatabases(ctx context.Context, orgID string) ([]model.DatabaseInstance, error)
 	CreateDatabase(ctx context.Context, orgID, dbID, name, description string) (model.DatabaseInstance, error)
 	GetDatabase(ctx context.Context, orgID, dbID string) (model.DatabaseInstance, error)
+	DatabaseExists(ctx context.Context, orgID, dbID string) (bool, error)
 	DeleteDatabase(ctx context.Context, orgID, dbID string) error
 
 	GetTables(ctx context.Context, orgID, dbID string) ([]model.Table, error)
 	CreateTable(ctx context.Context, orgID, dbID string, t model.Table) error
 	UpdateTableSchema(ctx context.Context, orgID, dbID, table string, schema []model.ColumnDef, pk string) error
+	SetTableAuditReads(ctx context.Context, orgID, dbID, table string, on bool) error
 
-	QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool) ([]map[string]any, string, error)
+	QueryRows(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, error)
+	QueryRowsProfiled(ctx context.Context, orgID, dbID, table, orderBy string, limit int, cursor string, forward bool, where []db.FieldMatch, fields []string) ([]map[string]any, string, any, error)
+	GetRow(ctx context.Context, orgID, dbID, table, id string) (map[string]any, error)
+	BatchGet(ctx context.Context, orgID, dbID, table string, ids []string) ([]map[string]any, error)
 	InsertRows(ctx context.Context, orgID, dbID, table string, rows []map[string]any) ([]string, error)
 	UpdateRow(ctx context.Context, orgID, dbID, table, id string, patch map[string]any) error
 	DeleteRow(ctx context.Context, orgID, dbID, table, id string) error
+	RestoreRow(ctx context.Context, orgID, dbID, table, id string) error
+	TruncateTable(ctx context.Context, orgID, dbID, table string) (int, error)
+	DeleteRows(ctx context.Context, orgID, dbID, table string, filter map[string]any) (int64, error)
+	Aggregate(ctx context.Context, orgID, dbID, table, groupBy string) (map[string]int64, error)
 
 	ListAudit(ctx context.Context, orgID, dbID string, limit int) ([]model.AuditEvent, error)
 	SubscribeTable(ctx context.Context, orgID, dbID, table string) (*db.ChangefeedStream, error)
+	SubscribeTableFiltered(ctx context.Context, orgID, dbID, table string, f db.ChangefeedFilter) (*db.ChangefeedStream, error)
+	SubscribeDatabase(ctx context.Context, orgID, dbID string) (*db.ChangefeedStream, error)
+	SubscribeDatabaseFiltered(ctx context.Context, orgID, dbID string, f db.ChangefeedFilter) (*db.ChangefeedStream, error)
+	IndexStatus(ctx context.Context, orgID, dbID string) ([]db.TableIndexStatus, error)
 	Ping(ctx context.Context) error
 }
@@ -0,0 +1,168 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// schemaDiffSample caps how many existing rows a schema diff checks.
+const schemaDiffSample = 500
+
+// handleSchemaDiff serves POST /api/db/{dbId}/tables/{table}/schema:diff. The body is the
+// same {schema, primary_key} as the schema PATCH; nothing is written.
+func (a *DBAPI) handleSchemaDiff(w http.ResponseWriter, r *http.Request, dbID, tableName string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	principal := PrincipalFromRequest(r.Header.Get("X-Debug-Principal"))
+	if !Allow(a.roleFor(principal, tableName, dbID), "table.schema") {
+		JSONError(w, http.StatusForbidden, "permission denied", "forbidden")
+		return
+	}
+	b, err := io.ReadAll(r.Body)
+	if BodyTooLarge(w, err) {
+		return
+	}
+	_ = r.Body.Close()
+	var req struct {
+		Schema     []model.ColumnDef `json:"schema"`
+		PrimaryKey string            `json:"primary_key"`
+	}
+	if err := json.Unmarshal(b, &req); err != nil || len(req.Schema) == 0 {
+		JSONError(w, http.StatusBadRequest, "invalid schema", "invalid_schema")
+		return
+	}
+	seen := map[string]bool{}
+	for _, c := range req.Schema {
+		if strings.TrimSpace(c.Name) == "" || seen[c.Name] {
+			JSONError(w, http.StatusBadRequest, "column names must be non-empty and unique", "invalid_schema", c.Name)
+			return
+		}
+		seen[c.Name] = true
+	}
+	// check first: GetTables would create a missing database
+	if ok, err := a.Manager.DatabaseExists(r.Context(), a.OrgID, dbID); err != nil {
+		JSONError(w, http.StatusInternalServerError, "database lookup failed", "db_lookup_failed", err.Error())
+		return
+	} else if !ok {
+		JSONError(w, http.StatusNotFound, "database not found", "not_found")
+		return
+	}
+	var cur *model.Table
+	tbls, _ := a.Manager.GetTables(r.Context(), a.OrgID, dbID)
+	for i := range tbls {
+		if tbls[i].Name == tableName {
+			cur = &tbls[i]
+			break
+		}
+	}
+	if cur == nil {
+		JSONError(w, http.StatusNotFound, "table not found", "not_found")
+		return
+	}
+	diff := diffSchema(cur.Schema, req.Schema)
+	diff.PrimaryKeyChanged = req.PrimaryKey != "" && req.PrimaryKey != pkOrDefault(cur.PrimaryKey)
+	rows, _, err := a.Manager.QueryRows(r.Context(), a.OrgID, dbID, tableName, "", schemaDiffSample, "", true, nil, nil)
+	if err != nil {
+		JSONError(w, http.StatusInternalServerError, "sample rows failed", "query_failed", err.Error())
+		return
+	}
+	diff.SampledRows = len(rows)
+	pk := pkOrDefault(cur.PrimaryKey)
+	for _, row := range rows {
+		if errs := rowSchemaErrors(req.Schema, row); len(errs) > 0 {
+			diff.IncompatibleRows = append(diff.IncompatibleRows, model.IncompatibleRow{ID: stringify(row[pk]), Errors: errs})
+		}
+	}
+	JSON(w, http.StatusOK, diff)
+}
+
+func pkOrDefault(pk string) string {
+	if pk == "" {
+		return "id"
+	}
+	return pk
+}
+
+// diffSchema compares columns by name; a renamed column shows as removed plus added.
+// The row fields of the result are left for the caller.
+func diffSchema(cur, next []model.ColumnDef) model.SchemaDiff {
+	d := model.SchemaDiff{Added: []model.ColumnDef{}, Removed: []model.ColumnDef{}, Changed: []model.ColumnChange{}, IncompatibleRows: []model.IncompatibleRow{}}
+	old := make(map[string]model.ColumnDef, len(cur))
+	for _, c := range cur {
+		old[c.Name] = c
+	}
+	kept := map[string]bool{}
+	for _, c := range next {
+		prev, ok := old[c.Name]
+		if !ok {
+			d.Added = append(d.Added, c)
+			continue
+		}
+		kept[c.Name] = true
+		if fields := changedColumnFields(prev, c); len(fields) > 0 {
+			d.Changed = append(d.Changed, model.ColumnChange{Name: c.Name, From: prev, To: c, Fields: fields})
+		}
+	}
+	for _, c := range cur {
+		if !kept[c.Name] {
+			d.Removed = append(d.Removed, c)
+		}
+	}
+	return d
+}
+
+func changedColumnFields(a, b model.ColumnDef) []string {
+	var out []string
+	for _, f := range []struct {
+		name string
+		same bool
+	}{
+		{"type", a.Type == b.Type},
+		{"required", a.Required == b.Required},
+		{"unique", a.Unique == b.Unique},
+		{"indexed", a.Indexed == b.Indexed},
+		{"default", reflect.DeepEqual(a.Default, b.Default)},
+		{"enum", slices.Equal(a.Enum, b.Enum)},
+		{"regex", a.Regex == b.Regex},
+		{"mask", a.Mask == b.Mask},
+	} {
+		if !f.same {
+			out = append(out, f.name)
+		}
+	}
+	return out
+}
+
+// rowSchemaErrors checks a stored row against schema: required columns without a default
+// must be present, values must have the column type, and enum columns must hold a listed
+// value. Stored timestamps may come back from the driver as time.Time.
+func rowSchemaErrors(schema []model.ColumnDef, row map[string]any) []string {
+	var errs []string
+	for _, col := range schema {
+		v, ok := row[col.Name]
+		if !ok || v == nil {
+			if col.Required && col.Default == nil {
+				errs = append(errs, "missing:"+col.Name)
+			}
+			continue
+		}
+		if _, isTime := v.(time.Time); !(isTime && col.Type == model.ColTimestamp) && !validateType(col.Type, v) {
+			errs = append(errs, "type:"+col.Name)
+			continue
+		}
+		if len(col.Enum) > 0 && !slices.Contains(col.Enum, fmt.Sprint(v)) {
+			errs = append(errs, "enum:"+col.Name)
+		}
+	}
+	return errs
+}
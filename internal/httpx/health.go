@@ -0,0 +1,54 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ReadinessCheck probes one subsystem for ReadyHandler. Check returns nil once the
+// subsystem is ready to serve.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// SubsystemState is the readiness of one subsystem as reported by ReadyHandler.
+type SubsystemState struct {
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+}
+
+// readinessTimeout bounds each check so a hung dependency reports not ready instead of
+// stalling the probe.
+const readinessTimeout = 2 * time.Second
+
+// ReadyHandler serves a readiness probe: 200 when every check passes, 503 otherwise,
+// with {"status":"ready"|"not_ready","checks":{name:{ready,error}}} either way.
+func ReadyHandler(checks ...ReadinessCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		states := make(map[string]SubsystemState, len(checks))
+		ready := true
+		for _, c := range checks {
+			ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+			err := c.Check(ctx)
+			cancel()
+			st := SubsystemState{Ready: err == nil}
+			if err != nil {
+				st.Error = err.Error()
+				ready = false
+			}
+			states[c.Name] = st
+		}
+		status, code := "ready", http.StatusOK
+		if !ready {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		JSON(w, code, map[string]any{"status": status, "checks": states})
+	}
+}
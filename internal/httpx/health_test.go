@@ -0,0 +1,40 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadyHandler(t *testing.T) {
+	dbErr := errors.New("closed")
+	h := ReadyHandler(
+		ReadinessCheck{Name: "tsnet", Check: func(context.Context) error { return nil }},
+		ReadinessCheck{Name: "localdb", Check: func(context.Context) error { return dbErr }},
+	)
+	probe := func() (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var out map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		return rec.Code, out
+	}
+	code, out := probe()
+	if code != http.StatusServiceUnavailable || out["status"] != "not_ready" {
+		t.Fatalf("status=%d body=%v", code, out)
+	}
+	checks := out["checks"].(map[string]any)
+	if db := checks["localdb"].(map[string]any); db["ready"] != false || db["error"] != "closed" {
+		t.Fatalf("localdb = %v", db)
+	}
+	if ts := checks["tsnet"].(map[string]any); ts["ready"] != true {
+		t.Fatalf("tsnet = %v", ts)
+	}
+	dbErr = nil
+	if code, out := probe(); code != http.StatusOK || out["status"] != "ready" {
+		t.Fatalf("status=%d body=%v", code, out)
+	}
+}
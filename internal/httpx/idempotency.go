@@ -0,0 +1,205 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/localdb"
+)
+
+const (
+	// IdempotencyKeyHeader carries a client-chosen key that makes a POST safe to retry.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader is set on responses replayed from a stored result.
+	IdempotentReplayHeader = "Idempotent-Replayed"
+	// DefaultIdempotencyTTL bounds how long a key's result is remembered.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	idempotencyBucket = "idempotency"
+	maxIdempotencyKey = 255
+	// idempotencySweepEvery is how often expired records are swept from localdb.
+	idempotencySweepEvery = time.Hour
+)
+
+type idempotentResult struct {
+	Fingerprint string    `json:"fingerprint"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        []byte    `json:"body"`
+	Expires     time.Time `json:"expires"`
+}
+
+// idempotentFlight is a keyed request that is still running.
+type idempotentFlight struct {
+	fingerprint string
+	done        chan struct{}
+}
+
+// Idempotent records the first successful (2xx) response for each Idempotency-Key in
+// localdb and replays it for later requests from the same caller carrying the same key,
+// method and path.
+// Reusing a key with a different body yields 422. A retry that arrives while the original
+// is still running waits for it, then replays its result, or runs itself when the original
+// did not succeed. Expired records are swept hourly. Requests without the header, non-POST
+// requests, requests not selected by match (nil matches all) and a nil db pass straight
+// through.
+func Idempotent(db *localdb.DB, ttl time.Duration, match func(*http.Request) bool) func(http.Handler) http.Handler {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	var mu sync.Mutex
+	inflight := map[string]*idempotentFlight{}
+	var lastSweep time.Time
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+			if db == nil || key == "" || r.Method != http.MethodPost || (match != nil && !match(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKey {
+				JSONError(w, http.StatusBadRequest, "idempotency key too long", "invalid_idempotency_key")
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if BodyTooLarge(w, err) {
+				return
+			}
+			if err != nil {
+				JSONError(w, http.StatusBadRequest, "unable to read body", "bad_body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+			id := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + idempotencyScope(r) + "\n" + key))
+			storeKey := hex.EncodeToString(id[:])
+
+			flight := &idempotentFlight{fingerprint: fingerprint, done: make(chan struct{})}
+			for {
+				mu.Lock()
+				if time.Since(lastSweep) > idempotencySweepEvery {
+					lastSweep = time.Now()
+					go func() { _, _ = SweepIdempotency(db) }()
+				}
+				if f := inflight[storeKey]; f != nil {
+					mu.Unlock()
+					if f.fingerprint != fingerprint {
+						JSONError(w, http.StatusUnprocessableEntity, "idempotency key reused with a different request", "idempotency_key_reused")
+						return
+					}
+					select {
+					case <-f.done:
+						continue
+					case <-r.Context().Done():
+						return
+					}
+				}
+				var prev idempotentResult
+				if err := db.Get(idempotencyBucket, storeKey, &prev); err == nil {
+					if time.Now().Before(prev.Expires) {
+						mu.Unlock()
+						if prev.Fingerprint != fingerprint {
+							JSONError(w, http.StatusUnprocessableEntity, "idempotency key reused with a different request", "idempotency_key_reused")
+							return
+						}
+						if prev.ContentType != "" {
+							w.Header().Set("Content-Type", prev.ContentType)
+						}
+						w.Header().Set(IdempotentReplayHeader, "true")
+						w.WriteHeader(prev.Status)
+						_, _ = w.Write(prev.Body)
+						return
+					}
+					_ = db.Delete(idempotencyBucket, storeKey)
+				}
+				inflight[storeKey] = flight
+				mu.Unlock()
+				break
+			}
+			// The result is stored before waiting retries are released, so they replay it
+			defer func() {
+				mu.Lock()
+				delete(inflight, storeKey)
+				mu.Unlock()
+				close(flight.done)
+			}()
+
+			rec := &recordingWriter{ResponseWriter: w, code: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			if rec.code >= 200 && rec.code < 300 {
+				_ = db.Put(idempotencyBucket, storeKey, idempotentResult{
+					Fingerprint: fingerprint,
+					Status:      rec.code,
+					ContentType: w.Header().Get("Content-Type"),
+					Body:        rec.buf.Bytes(),
+					Expires:     time.Now().Add(ttl),
+				})
+			}
+		})
+	}
+}
+
+// idempotencyScope identifies the caller, so two callers using the same key never see each
+// other's results: the principal plus the presented credentials. It only feeds the hashed
+// record key, so credentials are never stored.
+func idempotencyScope(r *http.Request) string {
+	return strings.Join([]string{
+		PrincipalFromRequest(r.Header.Get("X-Debug-Principal")),
+		r.Header.Get("Authorization"),
+		r.Header.Get("X-API-Token"),
+	}, "\n")
+}
+
+// SweepIdempotency deletes expired idempotency records and returns how many it removed.
+func SweepIdempotency(db *localdb.DB) (int, error) {
+	keys, err := db.Keys(idempotencyBucket)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	n := 0
+	for _, k := range keys {
+		var rec idempotentResult
+		if err := db.Get(idempotencyBucket, k, &rec); err != nil || now.Before(rec.Expires) {
+			continue
+		}
+		if db.Delete(idempotencyBucket, k) == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// recordingWriter tees the response body so it can be stored for replay.
+type recordingWriter struct {
+	http.ResponseWriter
+	code int
+	buf  bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush passes through so streamed responses are not held back by the recording.
+func (w *recordingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *recordingWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
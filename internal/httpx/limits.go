@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultMaxBodyBytes bounds request bodies on API endpoints (1 MiB).
+	DefaultMaxBodyBytes int64 = 1 << 20
+	// DefaultReadHeaderTimeout bounds how long a client may take to send request headers.
+	DefaultReadHeaderTimeout = 5 * time.Second
+	// DefaultMaxHeaderBytes bounds the size of request headers (64 KiB).
+	DefaultMaxHeaderBytes = 64 << 10
+)
+
+// BodyLimitOptions configures LimitBody.
+type BodyLimitOptions struct {
+	// Max is the limit for requests Limit leaves at the default (DefaultMaxBodyBytes when 0).
+	Max int64
+	// Limit optionally picks a per-request limit: 0 uses Max, a negative value disables the
+	// check (e.g. proxy routes that enforce their own cap).
+	Limit func(r *http.Request) int64
+}
+
+// LimitBody caps request bodies. Requests that declare a larger Content-Length get a
+// 413 before the handler runs; streamed bodies are cut off at the limit, and handlers
+// should report the read error with BodyTooLarge.
+func LimitBody(opts BodyLimitOptions) func(http.Handler) http.Handler {
+	def := opts.Max
+	if def <= 0 {
+		def = DefaultMaxBodyBytes
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := def
+			if opts.Limit != nil {
+				if v := opts.Limit(r); v < 0 {
+					next.ServeHTTP(w, r)
+					return
+				} else if v > 0 {
+					limit = v
+				}
+			}
+			if r.ContentLength > limit {
+				JSONError(w, http.StatusRequestEntityTooLarge, "request body too large", "body_too_large", map[string]any{"limit": limit})
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BodyTooLarge writes a 413 and returns true when err came from a body cut off by
+// LimitBody. Other errors are left to the caller.
+func BodyTooLarge(w http.ResponseWriter, err error) bool {
+	var mbe *http.MaxBytesError
+	if !errors.As(err, &mbe) {
+		return false
+	}
+	JSONError(w, http.StatusRequestEntityTooLarge, "request body too large", "body_too_large", map[string]any{"limit": mbe.Limit})
+	return true
+}
+
+// HardenServer fills in the slow-client protections a handler cannot enforce itself:
+// ReadHeaderTimeout (against slowloris) and MaxHeaderBytes. Values already set are kept.
+func HardenServer(srv *http.Server) *http.Server {
+	if srv.ReadHeaderTimeout == 0 {
+		srv.ReadHeaderTimeout = DefaultReadHeaderTimeout
+	}
+	if srv.MaxHeaderBytes == 0 {
+		srv.MaxHeaderBytes = DefaultMaxHeaderBytes
+	}
+	return srv
+}
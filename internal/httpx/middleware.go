@@ -11,6 +11,8 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -34,6 +36,8 @@ type ErrorPayload struct {
 	Message string      `json:"message"`
 	Request string      `json:"request_id,omitempty"`
 	Details interface{} `json:"details,omitempty"`
+	// Fields holds per-field validation messages keyed by field path (e.g. "spec.image").
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
 // JSONError writes a structured error. Code param is HTTP status; errCode is stable machine code.
@@ -48,24 +52,47 @@ func JSONError(w http.ResponseWriter, httpStatus int, msg string, errCodeAndDeta
 	if len(errCodeAndDetails) > 1 {
 		details = errCodeAndDetails[1]
 	}
-	// Attempt to pull request id from context if available by inspecting a header writer wrapper later.
-	// Caller should set X-Request-Id header already through middleware.
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(httpStatus)
-	// Try best-effort to read request id from interface (middleware sets it on header already)
-	// We don't have direct access to context here; callers can wrap if needed.
-	payload := ErrorPayload{Code: errCode, Message: msg, Request: w.Header().Get("X-Request-Id")}
+	payload := ErrorPayload{Code: errCode, Message: msg}
 	if details != nil {
 		payload.Details = details
 	}
+	writeError(w, httpStatus, payload)
+}
+
+// JSONFieldErrors writes a structured validation error with per-field messages:
+// {code, message, fields: {field: message}}. Use for form/spec validation so clients
+// can attach messages to inputs instead of parsing Details.
+func JSONFieldErrors(w http.ResponseWriter, httpStatus int, msg, errCode string, fields map[string]string) {
+	if errCode == "" {
+		errCode = http.StatusText(httpStatus)
+	}
+	writeError(w, httpStatus, ErrorPayload{Code: errCode, Message: msg, Fields: fields})
+}
+
+// writeError fills in the request id (set on the response by the RequestID middleware),
+// logs the error and writes it.
+func writeError(w http.ResponseWriter, httpStatus int, payload ErrorPayload) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	payload.Request = w.Header().Get("X-Request-Id")
 	// Log the structured error server-side for easier debugging in CI and scripts.
 	// This does not expose secrets but gives operators visibility into the error shape.
 	if logger := Logger(); logger != nil {
-		logger.Printf("httpx.JSONError code=%d errcode=%s msg=%s details=%v request_id=%s", httpStatus, payload.Code, payload.Message, payload.Details, payload.Request)
+		logger.Printf("httpx.JSONError code=%d errcode=%s msg=%s details=%v fields=%v request_id=%s", httpStatus, payload.Code, payload.Message, payload.Details, payload.Fields, payload.Request)
 	}
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
+// SSEEvent writes a named server-sent event whose data is v encoded as JSON.
+func SSEEvent(w io.Writer, event string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
 // RequestID middleware adds/propagates a request ID.
 func RequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -128,6 +155,9 @@ func (w *respWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return nil, nil, fmt.Errorf("hijacker not supported")
 }
 
+// Unwrap lets http.ResponseController reach the connection, e.g. to clear deadlines.
+func (w *respWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
 // Optional pass-throughs for completeness when servers/handlers check these.
 func (w *respWriter) Push(target string, opts *http.PushOptions) error {
 	if p, ok := w.ResponseWriter.(http.Pusher); ok {
@@ -167,20 +197,76 @@ func genID() string {
 	return hex.EncodeToString(b[:])
 }
 
+// CORSOptions configures CORSWithOptions.
+type CORSOptions struct {
+	// AllowedOrigin is the single frontend origin allowed, or "*" to reflect any origin.
+	AllowedOrigin string
+	// AllowedOrigins are allowed in addition to AllowedOrigin.
+	AllowedOrigins []string
+	// AllowedMethods replaces DefaultCORSMethods when non-empty.
+	AllowedMethods []string
+	// AllowedHeaders are allowed in addition to DefaultCORSHeaders.
+	AllowedHeaders []string
+	// MaxAge lets browsers cache preflight results (default 10 minutes).
+	MaxAge time.Duration
+}
+
+var (
+	DefaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	// DefaultCORSHeaders covers the headers the UI and DB API send (X-Debug-Principal,
+	// Last-Event-ID for changefeed resume).
+	DefaultCORSHeaders = []string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "X-Request-Id", "X-Debug-Principal", "Last-Event-ID"}
+)
+
 // CORS middleware allowing a specific frontend origin (e.g., https://127.0.0.1:8090 in dev).
 // Preflights (OPTIONS) are short-circuited with 204.
 func CORS(allowedOrigin string) func(http.Handler) http.Handler {
+	return CORSWithOptions(CORSOptions{AllowedOrigin: allowedOrigin})
+}
+
+// CORSWithOptions is CORS with configurable methods, headers and preflight caching.
+func CORSWithOptions(opts CORSOptions) func(http.Handler) http.Handler {
+	methods := DefaultCORSMethods
+	if len(opts.AllowedMethods) > 0 {
+		methods = nil
+		for _, m := range opts.AllowedMethods {
+			methods = appendUniqueFold(methods, strings.ToUpper(strings.TrimSpace(m)))
+		}
+	}
+	var headers []string
+	for _, h := range append(append([]string{}, DefaultCORSHeaders...), opts.AllowedHeaders...) {
+		headers = appendUniqueFold(headers, http.CanonicalHeaderKey(strings.TrimSpace(h)))
+	}
+	maxAge := opts.MaxAge
+	if maxAge <= 0 {
+		maxAge = 10 * time.Minute
+	}
+	origins := map[string]bool{}
+	for _, o := range append([]string{opts.AllowedOrigin}, opts.AllowedOrigins...) {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			origins[o] = true
+		}
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	maxAgeSecs := strconv.Itoa(int(maxAge / time.Second))
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
-			if origin != "" && (allowedOrigin == "*" || origin == allowedOrigin) {
+			allowed := origin != "" && (origins["*"] || origins[origin])
+			if allowed {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
-				w.Header().Set("Vary", "Origin")
+				w.Header().Add("Vary", "Origin")
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, X-Request-Id")
+				w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+				w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
 			}
 			if r.Method == http.MethodOptions {
+				if allowed && r.Header.Get("Access-Control-Request-Method") != "" {
+					w.Header().Add("Vary", "Access-Control-Request-Method")
+					w.Header().Add("Vary", "Access-Control-Request-Headers")
+					w.Header().Set("Access-Control-Max-Age", maxAgeSecs)
+				}
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
@@ -188,3 +274,15 @@ func CORS(allowedOrigin string) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+func appendUniqueFold(list []string, v string) []string {
+	if v == "" {
+		return list
+	}
+	for _, x := range list {
+		if strings.EqualFold(x, v) {
+			return list
+		}
+	}
+	return append(list, v)
+}
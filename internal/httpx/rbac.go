@@ -1,25 +1,96 @@
 package httpx
 
 import (
+	"sort"
 	"strings"
 	"sync"
 
+	"github.com/docxology/GuildNet/internal/localdb"
 	"github.com/docxology/GuildNet/internal/model"
 )
 
-// RBACStore is an in-memory permission binding store (MVP) keyed by scope.
+// rbacBucket holds one record per persistent RBAC store: all of its bindings.
+const rbacBucket = "rbac_bindings"
+
+// RBACStore is a permission binding store keyed by scope. It is in-memory unless created
+// with NewPersistentRBACStore.
 type RBACStore struct {
 	mu       sync.RWMutex
 	bindings map[string][]model.PermissionBinding // scope -> bindings
+	// db and key persist the bindings when set
+	db  *localdb.DB
+	key string
 }
 
 func NewRBACStore() *RBACStore { return &RBACStore{bindings: map[string][]model.PermissionBinding{}} }
 
+// NewPersistentRBACStore returns a store loaded from and saved to localdb under key
+// (e.g. a cluster ID), so grants outlive requests and restarts. A nil db yields an
+// in-memory store.
+func NewPersistentRBACStore(db *localdb.DB, key string) *RBACStore {
+	s := NewRBACStore()
+	if db == nil {
+		return s
+	}
+	s.db, s.key = db, key
+	var saved []model.PermissionBinding
+	if err := db.Get(rbacBucket, key, &saved); err == nil {
+		for _, b := range saved {
+			s.bindings[b.Scope] = append(s.bindings[b.Scope], b)
+		}
+	}
+	return s
+}
+
+// Bindings returns every binding, ordered by scope then principal.
+func (s *RBACStore) Bindings() []model.PermissionBinding {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshotLocked()
+}
+
+func (s *RBACStore) snapshotLocked() []model.PermissionBinding {
+	out := []model.PermissionBinding{}
+	for _, arr := range s.bindings {
+		out = append(out, arr...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Scope != out[j].Scope {
+			return out[i].Scope < out[j].Scope
+		}
+		return out[i].Principal < out[j].Principal
+	})
+	return out
+}
+
+// setLocked replaces the bindings of scope and persists the store, restoring the
+// previous bindings when the write fails.
+func (s *RBACStore) setLocked(scope string, arr []model.PermissionBinding) error {
+	prev, had := s.bindings[scope]
+	if len(arr) == 0 {
+		delete(s.bindings, scope)
+	} else {
+		s.bindings[scope] = arr
+	}
+	if s.db == nil {
+		return nil
+	}
+	if err := s.db.Put(rbacBucket, s.key, s.snapshotLocked()); err != nil {
+		if had {
+			s.bindings[scope] = prev
+		} else {
+			delete(s.bindings, scope)
+		}
+		return err
+	}
+	return nil
+}
+
 // Grant adds or replaces a permission binding.
-func (s *RBACStore) Grant(b model.PermissionBinding) {
+func (s *RBACStore) Grant(b model.PermissionBinding) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	arr := s.bindings[b.Scope]
+	arr := append([]model.PermissionBinding(nil), s.bindings[b.Scope]...)
 	replaced := false
 	for i, existing := range arr {
 		if existing.Principal == b.Principal {
@@ -31,26 +102,21 @@ func (s *RBACStore) Grant(b model.PermissionBinding) {
 	if !replaced {
 		arr = append(arr, b)
 	}
-	s.bindings[b.Scope] = arr
+	return s.setLocked(b.Scope, arr)
 }
 
 // Revoke removes a permission binding for a principal at a given scope.
-func (s *RBACStore) Revoke(scope, principal string) {
+func (s *RBACStore) Revoke(scope, principal string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	arr := s.bindings[scope]
-	out := arr[:0]
-	for _, b := range arr {
+	var out []model.PermissionBinding
+	for _, b := range s.bindings[scope] {
 		if b.Principal == principal {
 			continue
 		}
 		out = append(out, b)
 	}
-	if len(out) == 0 {
-		delete(s.bindings, scope)
-	} else {
-		s.bindings[scope] = out
-	}
+	return s.setLocked(scope, out)
 }
 
 // RoleFor returns the most specific role for principal (table scope overrides db scope). principal may be user:<id> or role:<name>.
@@ -85,7 +151,7 @@ func Allow(role model.Role, action string) bool {
 	switch action {
 	case "db.create", "db.delete":
 		return role == model.RoleMaintainer || role == model.RoleAdmin
-	case "table.create", "table.schema", "table.delete":
+	case "table.create", "table.schema", "table.delete", "table.truncate":
 		return role == model.RoleMaintainer || role == model.RoleAdmin
 	case "row.read":
 		return role == model.RoleViewer || role == model.RoleEditor || role == model.RoleMaintainer || role == model.RoleAdmin
@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -11,7 +12,23 @@ import (
 // LocalPersist implements Persist on top of localdb.DB
 // Buckets used: jobs, joblogs
 
-type LocalPersist struct{ DB *localdb.DB }
+type LocalPersist struct {
+	DB *localdb.DB
+	// MaxLogBytes caps each job's stored NDJSON log (0 = DefaultMaxLogBytes). Older lines
+	// are dropped first and replaced by a TruncatedStep sentinel line.
+	MaxLogBytes int
+}
+
+// DefaultMaxLogBytes is the per-job log cap used when LocalPersist.MaxLogBytes is 0.
+const DefaultMaxLogBytes = 1 << 20
+
+// TruncatedStep is the step of the sentinel event heading a trimmed job log. Its KV
+// "dropped" holds the total number of lines removed so far, so readers can keep
+// absolute positions across trims.
+const TruncatedStep = "truncated"
+
+// sentinelReserve is the room kept for the sentinel line when trimming.
+const sentinelReserve = 256
 
 func (p LocalPersist) SaveJob(rec Record) error {
 	if p.DB == nil {
@@ -23,17 +40,63 @@ func (p LocalPersist) SaveJob(rec Record) error {
 		"created": rec.Created.Format(time.RFC3339Nano),
 		"updated": rec.Updated.Format(time.RFC3339Nano),
 		"result":  string(rec.Result), "error": rec.Error,
+		"retriable": rec.Retriable, "retryOf": rec.RetryOf, "retriedBy": rec.RetriedBy,
 	}
 	return p.DB.Put("jobs", rec.ID, m)
 }
 
+// DeleteJob removes the job record and its log.
+func (p LocalPersist) DeleteJob(id string) error {
+	if p.DB == nil {
+		return nil
+	}
+	if err := p.DB.Delete("jobs", id); err != nil {
+		return err
+	}
+	return p.DB.DeleteLog("joblogs", id)
+}
+
 func (p LocalPersist) AppendLog(jobID string, e LogEvent) error {
 	if p.DB == nil {
 		return nil
 	}
 	b, _ := json.Marshal(e)
 	b = append(b, '\n')
-	return p.DB.AppendLog("joblogs", jobID, b)
+	max := p.MaxLogBytes
+	if max <= 0 {
+		max = DefaultMaxLogBytes
+	}
+	return p.DB.UpdateLog("joblogs", jobID, func(cur []byte) []byte {
+		return trimLog(jobID, append(cur, b...), max)
+	})
+}
+
+// trimLog keeps the most recent whole lines of an NDJSON log within max bytes, heading
+// the result with a TruncatedStep sentinel that accumulates the dropped line count.
+func trimLog(jobID string, buf []byte, max int) []byte {
+	if len(buf) <= max {
+		return buf
+	}
+	dropped, body := 0, buf
+	if first, rest, ok := bytes.Cut(buf, []byte("\n")); ok {
+		var e LogEvent
+		if json.Unmarshal(first, &e) == nil && e.Step == TruncatedStep {
+			n, _ := e.KV["dropped"].(float64)
+			dropped, body = int(n), rest
+		}
+	}
+	budget := max - sentinelReserve
+	for len(body) > 0 && len(body) > budget {
+		i := bytes.IndexByte(body, '\n')
+		if i < 0 {
+			body = nil
+		} else {
+			body = body[i+1:]
+		}
+		dropped++
+	}
+	sentinel, _ := json.Marshal(LogEvent{TS: time.Now(), Job: jobID, Step: TruncatedStep, Msg: fmt.Sprintf("%d earlier log lines dropped", dropped), KV: map[string]any{"dropped": dropped}})
+	return append(append(sentinel, '\n'), body...)
 }
 
 func (p LocalPersist) ListJobs() ([]Record, error) {
@@ -78,6 +141,9 @@ func (p LocalPersist) ListJobs() ([]Record, error) {
 		if v, _ := m["error"].(string); v != "" {
 			rec.Error = v
 		}
+		rec.Retriable, _ = m["retriable"].(bool)
+		rec.RetryOf, _ = m["retryOf"].(string)
+		rec.RetriedBy, _ = m["retriedBy"].(string)
 		if rec.ID == "" {
 			continue
 		}
@@ -3,11 +3,14 @@ package jobs
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/docxology/GuildNet/internal/metrics"
 )
 
 // Status enumerates job states.
@@ -29,6 +32,29 @@ type LogEvent struct {
 	Msg  string         `json:"msg"`
 	KV   map[string]any `json:"kv,omitempty"`
 	Err  string         `json:"err,omitempty"`
+	// Percent is set on progress events (Step "progress") emitted through a Reporter.
+	Percent int `json:"percent,omitempty"`
+	// Seq numbers a job's events from 1 in emission order, so streams that replay the
+	// stored log and then follow live events can drop the overlap.
+	Seq int64 `json:"seq,omitempty"`
+}
+
+// ProgressStep is the LogEvent step used for Reporter updates.
+const ProgressStep = "progress"
+
+// Reporter records handler progress: percent (0-100) and a short message describing the
+// current step. Each call updates the job record and is streamed to log subscribers.
+type Reporter func(percent int, message string)
+
+type reporterKey struct{}
+
+// ReporterFrom returns the Reporter the runner attached to a handler's context, or a
+// no-op when ctx was not created by the runner.
+func ReporterFrom(ctx context.Context) Reporter {
+	if rep, ok := ctx.Value(reporterKey{}).(Reporter); ok && rep != nil {
+		return rep
+	}
+	return func(int, string) {}
 }
 
 // Persist abstracts durable storage needed by Runner.
@@ -37,16 +63,57 @@ type Persist interface {
 	AppendLog(jobID string, e LogEvent) error
 	ListJobs() ([]Record, error)
 	GetJob(id string) (*Record, error)
+	// DeleteJob removes a job record and its log.
+	DeleteJob(id string) error
 }
 
-// Runner is an in-memory job orchestrator with resumable checkpoint support.
+// Defaults for New when WithMaxConcurrency / WithQueueSize are not given.
+const (
+	DefaultMaxConcurrency = 4
+	DefaultQueueSize      = 256
+)
+
+// ErrQueueFull is returned by Submit when all workers are busy and the queue is at capacity.
+var ErrQueueFull = errors.New("job queue full")
+
+// Errors returned by Retry.
+var (
+	ErrJobNotFound  = errors.New("job not found")
+	ErrNotRetriable = errors.New("job is not retriable")
+)
+
+// Handler runs a single job.
+type Handler func(ctx context.Context, rec *Record, logf func(step, msg string, kv map[string]any))
+
+// Runner is an in-memory job orchestrator with resumable checkpoint support. At most
+// maxConc jobs run at once; further submissions wait in a bounded FIFO queue.
 type Runner struct {
 	mu       sync.RWMutex
 	jobs     map[string]*Record
-	queues   map[string]chan string // per-kind queue
+	handlers map[string]Handler // queued jobs awaiting a worker
+	pending  []string           // queued job IDs, FIFO
+	running  int
+	maxConc  int
+	maxQueue int
 	logSubs  map[string][]chan LogEvent
+	logSeq   map[string]int64 // last LogEvent.Seq per job
 	store    Persist
 	canceled map[string]struct{}
+	// keepSucceeded is how long succeeded jobs are kept; <= 0 keeps them forever.
+	keepSucceeded time.Duration
+	// pruneEvery overrides the background prune interval; 0 derives it from keepSucceeded.
+	pruneEvery time.Duration
+	retryMu    sync.Mutex // serializes Retry so a failed job is resubmitted once
+	stopPrune  chan struct{}
+	closeOnce  sync.Once
+}
+
+// QueueStats reports runner load.
+type QueueStats struct {
+	Running        int `json:"running"`
+	Queued         int `json:"queued"`
+	MaxConcurrency int `json:"maxConcurrency"`
+	QueueSize      int `json:"queueSize"`
 }
 
 type Record struct {
@@ -59,93 +126,269 @@ type Record struct {
 	Updated  time.Time       `json:"updated"`
 	Result   json.RawMessage `json:"result,omitempty"`
 	Error    string          `json:"error,omitempty"`
+	// Retriable marks a failed job that may succeed when resubmitted unchanged (a
+	// transient error rather than a bad spec or a handler panic).
+	Retriable bool `json:"retriable,omitempty"`
+	// RetryOf is the failed job this one resubmits; RetriedBy is set on that job once
+	// it has been retried.
+	RetryOf   string `json:"retryOf,omitempty"`
+	RetriedBy string `json:"retriedBy,omitempty"`
+}
+
+// Done reports whether rec reached a terminal status.
+func (rec *Record) Done() bool {
+	return rec.Status == Succeeded || rec.Status == Failed || rec.Status == Canceled
+}
+
+// SetFailed marks rec failed with err. Handlers call it instead of returning an error;
+// the runner then records the failure rather than marking the job succeeded. A record
+// that already reached a terminal status keeps it.
+func (rec *Record) SetFailed(err error, retriable bool) {
+	if rec.Done() {
+		return
+	}
+	rec.Status = Failed
+	rec.Error = err.Error()
+	rec.Retriable = retriable
 }
 
 func New(opts ...Option) *Runner {
 	r := &Runner{
 		jobs:     map[string]*Record{},
-		queues:   map[string]chan string{},
+		handlers: map[string]Handler{},
+		maxConc:  DefaultMaxConcurrency,
+		maxQueue: DefaultQueueSize,
 		logSubs:  map[string][]chan LogEvent{},
+		logSeq:   map[string]int64{},
 		canceled: map[string]struct{}{},
+
+		stopPrune: make(chan struct{}),
 	}
 	for _, o := range opts {
 		o(r)
 	}
+	if r.keepSucceeded > 0 {
+		go r.pruneLoop()
+	}
 	return r
 }
 
+// Close stops the background pruning started by WithSucceededRetention.
+func (r *Runner) Close() {
+	r.closeOnce.Do(func() { close(r.stopPrune) })
+}
+
+// pruneLoop prunes expired succeeded jobs periodically until Close.
+func (r *Runner) pruneLoop() {
+	interval := r.pruneEvery
+	if interval <= 0 {
+		interval = min(max(r.keepSucceeded/4, time.Minute), time.Hour)
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			r.Prune(now)
+		case <-r.stopPrune:
+			return
+		}
+	}
+}
+
 type Option func(*Runner)
 
 func WithPersist(p Persist) Option { return func(r *Runner) { r.store = p } }
 
-// Submit enqueues a job of a given kind with spec.
+// WithMaxConcurrency caps how many jobs run at once (n < 1 keeps the default).
+func WithMaxConcurrency(n int) Option {
+	return func(r *Runner) {
+		if n > 0 {
+			r.maxConc = n
+		}
+	}
+}
+
+// WithQueueSize bounds how many jobs may wait for a worker (n < 0 keeps the default;
+// 0 rejects submissions whenever all workers are busy).
+func WithQueueSize(n int) Option {
+	return func(r *Runner) {
+		if n >= 0 {
+			r.maxQueue = n
+		}
+	}
+}
+
+// WithSucceededRetention prunes succeeded jobs, and their logs, once they are older than
+// d, checking in the background until Close. Without it (or with d <= 0) they are kept
+// forever.
+func WithSucceededRetention(d time.Duration) Option {
+	return func(r *Runner) { r.keepSucceeded = d }
+}
+
+// WithPruneInterval sets how often the background prune runs. By default it is a quarter
+// of the retention, kept between a minute and an hour.
+func WithPruneInterval(d time.Duration) Option {
+	return func(r *Runner) { r.pruneEvery = d }
+}
+
+// Submit starts a job of a given kind with spec, or queues it (status queued) when
+// MaxConcurrency jobs are already running. Returns ErrQueueFull when the queue is full.
 func (r *Runner) Submit(kind string, spec any, handler func(ctx context.Context, rec *Record, logf func(step, msg string, kv map[string]any))) (string, error) {
 	b, _ := json.Marshal(spec)
+	return r.submit(&Record{Kind: kind, SpecJSON: string(b)}, handler)
+}
+
+// Retry resubmits a failed, retriable job with the same kind and spec and returns the
+// new job ID. The failed job is kept, with RetriedBy pointing at the new one, and can
+// only be retried once.
+func (r *Runner) Retry(id string, handler Handler) (string, error) {
+	r.retryMu.Lock()
+	defer r.retryMu.Unlock()
+	old := r.Get(id)
+	if old == nil {
+		return "", ErrJobNotFound
+	}
+	if old.Status != Failed || !old.Retriable || old.RetriedBy != "" {
+		return "", ErrNotRetriable
+	}
+	newID, err := r.submit(&Record{Kind: old.Kind, SpecJSON: old.SpecJSON, RetryOf: id}, handler)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	if cur, ok := r.jobs[id]; ok {
+		old = cur
+	}
+	old.RetriedBy = newID
+	cpy := *old
+	r.mu.Unlock()
+	r.persist(cpy)
+	return newID, nil
+}
+
+// submit assigns rec an ID and queues or starts it.
+func (r *Runner) submit(rec *Record, handler Handler) (string, error) {
 	id := uuid.NewString()
-	rec := &Record{ID: id, Kind: kind, SpecJSON: string(b), Status: Queued, Created: time.Now(), Updated: time.Now()}
+	rec.ID, rec.Status, rec.Created, rec.Updated = id, Queued, time.Now(), time.Now()
 	r.mu.Lock()
+	start := r.running < r.maxConc
+	if !start && len(r.pending) >= r.maxQueue {
+		r.mu.Unlock()
+		return "", ErrQueueFull
+	}
 	r.jobs[id] = rec
-	q := r.ensureQueue(kind)
+	if start {
+		r.running++
+	} else {
+		r.pending = append(r.pending, id)
+		r.handlers[id] = handler
+	}
+	r.reportLocked()
+	cpy := *rec
 	r.mu.Unlock()
-	r.persist(*rec)
-	// Start a worker per kind if not started
-	go r.worker(kind, handler)
-	// enqueue
-	q <- id
+	r.persist(cpy)
+	if start {
+		go r.work(&cpy, handler)
+	}
 	return id, nil
 }
 
-func (r *Runner) ensureQueue(kind string) chan string {
-	if q, ok := r.queues[kind]; ok {
-		return q
+// work runs rec and then keeps draining the queue on the same worker slot.
+func (r *Runner) work(rec *Record, handler Handler) {
+	for rec != nil {
+		r.runOne(handler, rec)
+		rec, handler = r.next()
 	}
-	q := make(chan string, 64)
-	r.queues[kind] = q
-	return q
 }
 
-func (r *Runner) worker(kind string, handler func(ctx context.Context, rec *Record, logf func(step, msg string, kv map[string]any))) {
-	q := r.ensureQueue(kind)
-	for id := range q {
-		rec := r.Get(id)
-		if rec == nil {
+// next pops the oldest queued job, or releases the worker slot when none is left.
+func (r *Runner) next() (*Record, Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.pending) > 0 {
+		id := r.pending[0]
+		r.pending = r.pending[1:]
+		h := r.handlers[id]
+		delete(r.handlers, id)
+		rec, ok := r.jobs[id]
+		if !ok || h == nil {
 			continue
 		}
-		r.runOne(handler, rec)
+		r.reportLocked()
+		cpy := *rec
+		return &cpy, h
 	}
+	r.running--
+	r.reportLocked()
+	return nil, nil
+}
+
+// Stats returns current running/queued counts and limits.
+func (r *Runner) Stats() QueueStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return QueueStats{Running: r.running, Queued: len(r.pending), MaxConcurrency: r.maxConc, QueueSize: r.maxQueue}
 }
 
+func (r *Runner) reportLocked() { metrics.SetJobs(r.running, len(r.pending)) }
+
 func (r *Runner) runOne(handler func(ctx context.Context, rec *Record, logf func(step, msg string, kv map[string]any)), rec *Record) {
 	rec.Status = Running
 	rec.Updated = time.Now()
 	r.put(rec)
 	r.persist(*rec)
-	ctx := context.Background()
 	logf := func(step, msg string, kv map[string]any) {
-		e := LogEvent{TS: time.Now(), Job: rec.ID, Step: step, Msg: msg, KV: kv}
-		r.publish(rec.ID, e)
-		r.append(e)
+		r.emit(LogEvent{TS: time.Now(), Job: rec.ID, Step: step, Msg: msg, KV: kv})
 	}
+	ctx := context.WithValue(context.Background(), reporterKey{}, Reporter(func(percent int, message string) {
+		r.report(rec, percent, message)
+	}))
+	// Runs last, once the final status and log event are recorded
+	defer r.endLogs(rec.ID)
 	defer func() {
 		if v := recover(); v != nil {
-			rec.Status = Failed
-			rec.Error = fmt.Sprint(v)
-			rec.Updated = time.Now()
-			r.put(rec)
-			r.persist(*rec)
+			// A panic is a handler bug; resubmitting the same spec would hit it again
+			rec.SetFailed(fmt.Errorf("panic: %v", v), false)
+			r.finish(rec)
 		}
 	}()
 	// run handler
 	handler(ctx, rec, logf)
-	if rec.Status == Running {
+	r.finish(rec)
+}
+
+// finish records rec's outcome once its handler returned. A job canceled meanwhile stays
+// canceled: the first terminal status wins.
+func (r *Runner) finish(rec *Record) {
+	if r.IsCanceled(rec.ID) {
+		rec.Status = Canceled
+		rec.Updated = time.Now()
+		r.put(rec)
+		r.persist(*rec)
+		return
+	}
+	switch rec.Status {
+	case Running:
 		rec.Status = Succeeded
 		rec.Progress = 1
 		rec.Updated = time.Now()
 		r.put(rec)
 		r.persist(*rec)
+	case Failed:
+		r.finishFailed(rec)
 	}
 }
 
+// finishFailed stores a failed rec and records its error as the last log event.
+func (r *Runner) finishFailed(rec *Record) {
+	rec.Updated = time.Now()
+	r.put(rec)
+	r.persist(*rec)
+	e := LogEvent{TS: time.Now(), Job: rec.ID, Msg: "failed", Err: rec.Error, KV: map[string]any{"retriable": rec.Retriable}}
+	r.emit(e)
+}
+
 // Get returns a copy of job record by id.
 func (r *Runner) Get(id string) *Record {
 	r.mu.RLock()
@@ -188,6 +431,38 @@ func (r *Runner) List() []Record {
 	return out
 }
 
+// Prune removes succeeded jobs last updated before now minus the succeeded retention,
+// from memory and the store, and returns how many were removed.
+func (r *Runner) Prune(now time.Time) int {
+	if r.keepSucceeded <= 0 {
+		return 0
+	}
+	cutoff := now.Add(-r.keepSucceeded)
+	expired := func(rec Record) bool { return rec.Status == Succeeded && rec.Updated.Before(cutoff) }
+	ids := map[string]struct{}{}
+	r.mu.Lock()
+	for id, rec := range r.jobs {
+		if expired(*rec) {
+			delete(r.jobs, id)
+			ids[id] = struct{}{}
+		}
+	}
+	r.mu.Unlock()
+	if r.store != nil {
+		if persisted, err := r.store.ListJobs(); err == nil {
+			for _, rec := range persisted {
+				if expired(rec) {
+					ids[rec.ID] = struct{}{}
+				}
+			}
+		}
+		for id := range ids {
+			_ = r.store.DeleteJob(id)
+		}
+	}
+	return len(ids)
+}
+
 func (r *Runner) put(rec *Record) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -195,7 +470,8 @@ func (r *Runner) put(rec *Record) {
 	r.jobs[rec.ID] = &cpy
 }
 
-// SubscribeLogs returns a channel of log events for a job.
+// SubscribeLogs returns a channel of log events for a job. The channel is closed once the
+// job finishes (after its last event) or when cancel is called.
 func (r *Runner) SubscribeLogs(jobID string) (<-chan LogEvent, func()) {
 	ch := make(chan LogEvent, 128)
 	r.mu.Lock()
@@ -203,20 +479,45 @@ func (r *Runner) SubscribeLogs(jobID string) (<-chan LogEvent, func()) {
 	r.mu.Unlock()
 	cancel := func() {
 		r.mu.Lock()
+		defer r.mu.Unlock()
 		arr := r.logSubs[jobID]
 		for i := range arr {
 			if arr[i] == ch {
-				arr = append(arr[:i], arr[i+1:]...)
-				break
+				r.logSubs[jobID] = append(arr[:i], arr[i+1:]...)
+				close(ch)
+				return
 			}
 		}
-		r.logSubs[jobID] = arr
-		r.mu.Unlock()
-		close(ch)
 	}
 	return ch, cancel
 }
 
+// endLogs closes the log subscriptions of a finished job.
+func (r *Runner) endLogs(jobID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endLogsLocked(jobID)
+}
+
+func (r *Runner) endLogsLocked(jobID string) {
+	for _, ch := range r.logSubs[jobID] {
+		close(ch)
+	}
+	delete(r.logSubs, jobID)
+	delete(r.logSeq, jobID)
+}
+
+// emit numbers e, stores it and sends it to subscribers. Storing first means a
+// subscriber that replays the stored log after subscribing cannot miss it.
+func (r *Runner) emit(e LogEvent) {
+	r.mu.Lock()
+	r.logSeq[e.Job]++
+	e.Seq = r.logSeq[e.Job]
+	r.mu.Unlock()
+	r.append(e)
+	r.publish(e.Job, e)
+}
+
 func (r *Runner) publish(jobID string, e LogEvent) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -235,18 +536,29 @@ func (r *Runner) WithStep(rec *Record, progress float64, step, msg string, kv ma
 	r.put(rec)
 	r.persist(*rec)
 	e := LogEvent{TS: time.Now(), Job: rec.ID, Step: step, Msg: msg, KV: kv}
-	r.publish(rec.ID, e)
-	r.append(e)
+	r.emit(e)
 }
 
-// Fail marks a job as failed with error.
-func (r *Runner) Fail(rec *Record, err error) {
-	rec.Status = Failed
-	rec.Error = err.Error()
+// report applies a Reporter update: percent is clamped to 0-100 and stored as
+// rec.Progress (0-1) before the progress event is published and appended.
+func (r *Runner) report(rec *Record, percent int, message string) {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	rec.Progress = float64(percent) / 100
 	rec.Updated = time.Now()
 	r.put(rec)
 	r.persist(*rec)
-	r.publish(rec.ID, LogEvent{TS: time.Now(), Job: rec.ID, Msg: "failed", Err: err.Error()})
+	e := LogEvent{TS: time.Now(), Job: rec.ID, Step: ProgressStep, Msg: message, Percent: percent}
+	r.emit(e)
+}
+
+// Fail marks a job as failed with a retriable error.
+func (r *Runner) Fail(rec *Record, err error) {
+	rec.SetFailed(err, true)
+	r.finishFailed(rec)
 }
 
 func (r *Runner) persist(rec Record) {
@@ -269,7 +581,17 @@ func (r *Runner) Cancel(id string) {
 		r.canceled = make(map[string]struct{})
 	}
 	r.canceled[id] = struct{}{}
-	if rec, ok := r.jobs[id]; ok {
+	// Queued jobs never start: drop them from the queue.
+	for i, qid := range r.pending {
+		if qid == id {
+			r.pending = append(r.pending[:i], r.pending[i+1:]...)
+			delete(r.handlers, id)
+			r.reportLocked()
+			r.endLogsLocked(id)
+			break
+		}
+	}
+	if rec, ok := r.jobs[id]; ok && !rec.Done() {
 		rec.Status = Canceled
 		rec.Updated = time.Now()
 		r.persist(*rec)
@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DefaultCacheResync is the informer resync period used by Client.Objects.
+const DefaultCacheResync = 10 * time.Minute
+
+// ObjectCache serves the Service and Pod reads made by proxy resolution from shared
+// informers. Informers start lazily for each namespace that is read, so only active
+// namespaces are watched. Until a namespace has synced, and on any cache miss, reads go
+// to the API server so freshly created objects are still found. Returned objects are
+// copies sorted by name, matching the API server's list order.
+type ObjectCache struct {
+	cli    kubernetes.Interface
+	resync time.Duration
+	cached bool
+
+	mu     sync.Mutex
+	ns     map[string]*nsListers
+	stop   chan struct{}
+	closed bool
+}
+
+type nsListers struct {
+	services corelisters.ServiceLister
+	pods     corelisters.PodLister
+	synced   []cache.InformerSynced
+}
+
+// NewObjectCache returns a cache over cli; nothing is watched until the first read.
+func NewObjectCache(cli kubernetes.Interface, resync time.Duration) *ObjectCache {
+	return &ObjectCache{cli: cli, resync: resync, cached: true, ns: map[string]*nsListers{}, stop: make(chan struct{})}
+}
+
+// Uncached returns an ObjectCache that always reads from the API server, for callers
+// without a long-lived client to hang informers on.
+func Uncached(cli kubernetes.Interface) *ObjectCache {
+	return &ObjectCache{cli: cli}
+}
+
+// listers returns the synced listers for ns, starting its informers on first use.
+// nil means the caller should read from the API server.
+func (c *ObjectCache) listers(ns string) *nsListers {
+	if !c.cached {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	n, ok := c.ns[ns]
+	if !ok {
+		f := informers.NewSharedInformerFactoryWithOptions(c.cli, c.resync, informers.WithNamespace(ns))
+		svc, pods := f.Core().V1().Services(), f.Core().V1().Pods()
+		n = &nsListers{
+			services: svc.Lister(),
+			pods:     pods.Lister(),
+			synced:   []cache.InformerSynced{svc.Informer().HasSynced, pods.Informer().HasSynced},
+		}
+		c.ns[ns] = n
+		f.Start(c.stop)
+		return nil
+	}
+	for _, synced := range n.synced {
+		if !synced() {
+			return nil
+		}
+	}
+	return n
+}
+
+// Service returns the named Service.
+func (c *ObjectCache) Service(ctx context.Context, ns, name string) (*corev1.Service, error) {
+	if n := c.listers(ns); n != nil {
+		if svc, err := n.services.Services(ns).Get(name); err == nil {
+			return svc.DeepCopy(), nil
+		}
+	}
+	return c.cli.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
+}
+
+// Services returns the Services matching a label selector.
+func (c *ObjectCache) Services(ctx context.Context, ns, selector string) ([]corev1.Service, error) {
+	if n := c.listers(ns); n != nil {
+		if sel, err := labels.Parse(selector); err == nil {
+			if items, err := n.services.Services(ns).List(sel); err == nil && len(items) > 0 {
+				out := make([]corev1.Service, len(items))
+				for i, s := range items {
+					out[i] = *s.DeepCopy()
+				}
+				sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+				return out, nil
+			}
+		}
+	}
+	list, err := c.cli.CoreV1().Services(ns).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// Pods returns the Pods matching a label selector.
+func (c *ObjectCache) Pods(ctx context.Context, ns, selector string) ([]corev1.Pod, error) {
+	if n := c.listers(ns); n != nil {
+		if sel, err := labels.Parse(selector); err == nil {
+			if items, err := n.pods.Pods(ns).List(sel); err == nil && len(items) > 0 {
+				out := make([]corev1.Pod, len(items))
+				for i, p := range items {
+					out[i] = *p.DeepCopy()
+				}
+				sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+				return out, nil
+			}
+		}
+	}
+	list, err := c.cli.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// Close stops all informers; later reads go to the API server.
+func (c *ObjectCache) Close() {
+	if !c.cached {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.stop)
+	}
+}
@@ -7,14 +7,13 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
+	"sync"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -25,6 +24,23 @@ import (
 type Client struct {
 	K    *kubernetes.Clientset
 	Rest *rest.Config
+
+	objOnce sync.Once
+	objs    *ObjectCache
+}
+
+// Objects returns the client's shared Service/Pod cache, created on first use.
+func (c *Client) Objects() *ObjectCache {
+	c.objOnce.Do(func() { c.objs = NewObjectCache(c.K, DefaultCacheResync) })
+	return c.objs
+}
+
+// Close stops the informers started by Objects, if any.
+func (c *Client) Close() {
+	c.objOnce.Do(func() {})
+	if c.objs != nil {
+		c.objs.Close()
+	}
 }
 
 func kubeconfigDefault() string {
@@ -100,293 +116,6 @@ func NewFromKubeconfig(ctx context.Context, kubeconfigYAML string, opts struct {
 // Config returns the REST config used to reach the API server.
 func (c *Client) Config() *rest.Config { return c.Rest }
 
-func dns1123Name(s string) string {
-	s = strings.ToLower(strings.TrimSpace(s))
-	var b strings.Builder
-	prevDash := false
-	for _, r := range s {
-		switch {
-		case r >= 'a' && r <= 'z':
-			b.WriteRune(r)
-			prevDash = false
-		case r >= '0' && r <= '9':
-			b.WriteRune(r)
-			prevDash = false
-		case r == '-' || r == '_' || r == ' ':
-			if !prevDash && b.Len() > 0 {
-				b.WriteByte('-')
-				prevDash = true
-			}
-		}
-	}
-	res := strings.Trim(b.String(), "-")
-	for strings.Contains(res, "--") {
-		res = strings.ReplaceAll(res, "--", "-")
-	}
-	if res == "" {
-		res = "workload"
-	}
-	return res
-}
-
-type EnsureOpts struct {
-	Namespace string
-	ID        string // stable id label
-	// New: runtime options from per-cluster settings
-	ImagePullSecret   string
-	LBEnabled         bool
-	LBPool            string
-	IngressDomain     string
-	IngressTLSSecret  string
-	IngressClassName  string
-	CertManagerIssuer string
-	IngressAuthURL    string
-	IngressAuthSignin string
-}
-
-// EnsureDeploymentAndService creates or updates a Deployment and Service for the job spec.
-func (c *Client) EnsureDeploymentAndService(ctx context.Context, spec model.JobSpec, opt EnsureOpts) (name string, id string, err error) {
-	ns := opt.Namespace
-	if ns == "" {
-		ns = "default"
-	}
-	name = dns1123Name(spec.Name)
-	if name == "" {
-		name = dns1123Name(spec.Image)
-	}
-	if opt.ID == "" {
-		id = name
-	} else {
-		id = opt.ID
-	}
-
-	labels := map[string]string{
-		"app":                    name,
-		"app.kubernetes.io/name": name,
-		"guildnet.io/managed":    "true",
-		"guildnet.io/id":         id,
-	}
-
-	// container ports
-	cports := []corev1.ContainerPort{}
-	for _, p := range spec.Expose {
-		if p.Port <= 0 {
-			continue
-		}
-		cp := corev1.ContainerPort{ContainerPort: int32(p.Port)}
-		if p.Name != "" {
-			cp.Name = p.Name
-		}
-		cports = append(cports, cp)
-	}
-	if len(cports) == 0 {
-		// If image looks like code-server, expose only HTTP 8080 by default.
-		img := strings.ToLower(strings.TrimSpace(spec.Image))
-		if strings.Contains(img, "codercom/code-server") || strings.Contains(img, "ghcr.io/coder/code-server") || strings.Contains(img, "code-server") {
-			cports = append(cports, corev1.ContainerPort{Name: "http", ContainerPort: 8080})
-		} else {
-			// Default to both 8080 (http) and 8443 (https). Readiness will target 8080.
-			cports = append(cports,
-				corev1.ContainerPort{Name: "http", ContainerPort: 8080},
-				corev1.ContainerPort{Name: "https", ContainerPort: 8443},
-			)
-		}
-	}
-
-	// env
-	env := []corev1.EnvVar{}
-	if spec.Env == nil {
-		spec.Env = map[string]string{}
-	}
-	// Ensure PORT=8080 by default
-	if strings.TrimSpace(spec.Env["PORT"]) == "" {
-		spec.Env["PORT"] = "8080"
-	}
-	// Ensure PASSWORD for code-server; default fallback if not provided in spec
-	if strings.TrimSpace(spec.Env["PASSWORD"]) == "" {
-		spec.Env["PASSWORD"] = "changeme"
-	}
-	for k, v := range spec.Env {
-		env = append(env, corev1.EnvVar{Name: k, Value: v})
-	}
-	sort.Slice(env, func(i, j int) bool { return env[i].Name < env[j].Name })
-
-	// Deployment
-	replicas := int32(1)
-	// Optional imagePullSecret name
-	imgPullSecret := strings.TrimSpace(opt.ImagePullSecret)
-	// Do not inject a default image; the API layer validates image is provided.
-
-	// Add explicit args for code-server so it binds correctly under our reverse proxy base path.
-	var containerArgs []string
-	imgLower := strings.ToLower(strings.TrimSpace(spec.Image))
-	if strings.Contains(imgLower, "codercom/code-server") || strings.Contains(imgLower, "code-server") {
-		// Older images may not support --base-path; omit it and rely on proxy rewrites.
-		containerArgs = []string{"--bind-addr", "0.0.0.0:8080", "--auth", "password"}
-	}
-
-	// Security context: default strict; relax for code-server (fixuid needs no_new_privs disabled)
-	secCtx := &corev1.SecurityContext{
-		AllowPrivilegeEscalation: func() *bool { b := false; return &b }(),
-		RunAsNonRoot:             func() *bool { b := true; return &b }(),
-		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
-	}
-	if strings.Contains(imgLower, "codercom/code-server") || strings.Contains(imgLower, "code-server") {
-		secCtx = &corev1.SecurityContext{
-			AllowPrivilegeEscalation: func() *bool { b := true; return &b }(),
-			RunAsNonRoot:             func() *bool { b := true; return &b }(),
-			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
-		}
-	}
-
-	// pick probe port: first declared container port or 8080
-	probePort := int32(8080)
-	if len(cports) > 0 {
-		probePort = cports[0].ContainerPort
-	}
-	dep := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
-			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{Labels: labels},
-				Spec: corev1.PodSpec{
-					Tolerations: []corev1.Toleration{
-						{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
-						{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
-					},
-					ImagePullSecrets: func() []corev1.LocalObjectReference {
-						if imgPullSecret == "" {
-							return nil
-						}
-						return []corev1.LocalObjectReference{{Name: imgPullSecret}}
-					}(),
-					SecurityContext: &corev1.PodSecurityContext{
-						RunAsUser:  func() *int64 { v := int64(1000); return &v }(),
-						RunAsGroup: func() *int64 { v := int64(1000); return &v }(),
-						FSGroup:    func() *int64 { v := int64(1000); return &v }(),
-					},
-					Containers: []corev1.Container{{
-						Name:            "app",
-						Image:           spec.Image,
-						Args:            append(containerArgs, spec.Args...),
-						Env:             env,
-						Ports:           cports,
-						ReadinessProbe:  &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: intstr.FromInt(int(probePort))}}},
-						LivenessProbe:   &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: intstr.FromInt(int(probePort))}}},
-						SecurityContext: secCtx,
-					}},
-				},
-			},
-		},
-	}
-
-	// Create or update
-	if _, err := c.K.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{}); err == nil {
-		if _, err := c.K.AppsV1().Deployments(ns).Update(ctx, dep, metav1.UpdateOptions{}); err != nil {
-			return "", "", err
-		}
-	} else {
-		if _, err := c.K.AppsV1().Deployments(ns).Create(ctx, dep, metav1.CreateOptions{}); err != nil {
-			return "", "", err
-		}
-	}
-
-	// Service ports
-	sports := []corev1.ServicePort{}
-	if len(spec.Expose) == 0 && len(cports) == 0 {
-		// Should not happen due to defaults above, but keep a sane default.
-		sports = append(sports, corev1.ServicePort{Name: "http", Port: 8080, TargetPort: intstr.FromInt(8080)})
-	} else {
-		for _, p := range spec.Expose {
-			if p.Port <= 0 {
-				continue
-			}
-			nm := p.Name
-			if nm == "" {
-				nm = fmt.Sprintf("p-%d", p.Port)
-			}
-			sports = append(sports, corev1.ServicePort{Name: nm, Port: int32(p.Port), TargetPort: intstr.FromInt(p.Port)})
-		}
-		if len(sports) == 0 {
-			// Mirror container ports (both 8080 and 8443 by default)
-			for _, cp := range cports {
-				sports = append(sports, corev1.ServicePort{Name: cp.Name, Port: cp.ContainerPort, TargetPort: intstr.FromInt(int(cp.ContainerPort))})
-			}
-		}
-	}
-
-	// Service type: default ClusterIP; when WORKSPACE_LB is set, expose as LoadBalancer (MetalLB expected)
-	svcType := corev1.ServiceTypeClusterIP
-	if opt.LBEnabled {
-		svcType = corev1.ServiceTypeLoadBalancer
-	}
-
-	svc := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: ns,
-			Labels:    labels,
-			Annotations: func() map[string]string {
-				m := map[string]string{}
-				if opt.LBPool != "" {
-					// MetalLB pool selection
-					m["metallb.universe.tf/address-pool"] = opt.LBPool
-				}
-				return m
-			}(),
-		},
-		Spec: corev1.ServiceSpec{
-			Selector:                 map[string]string{"app": name},
-			Ports:                    sports,
-			PublishNotReadyAddresses: true,
-			Type:                     svcType,
-		},
-	}
-	if _, err := c.K.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{}); err == nil {
-		if _, err := c.K.CoreV1().Services(ns).Update(ctx, svc, metav1.UpdateOptions{}); err != nil {
-			return "", "", err
-		}
-	} else {
-		if _, err := c.K.CoreV1().Services(ns).Create(ctx, svc, metav1.CreateOptions{}); err != nil {
-			return "", "", err
-		}
-	}
-	// Optionally ensure an Ingress per workspace if domain is configured and LoadBalancer exposure is not requested
-	if strings.TrimSpace(opt.IngressDomain) != "" && !opt.LBEnabled {
-		dom := strings.TrimSpace(opt.IngressDomain)
-		host := fmt.Sprintf("%s.%s", id, dom)
-		tlsSec := strings.TrimSpace(opt.IngressTLSSecret)
-		iclass := strings.TrimSpace(opt.IngressClassName)
-		anns := map[string]string{
-			"nginx.ingress.kubernetes.io/enable-websocket":   "true",
-			"nginx.ingress.kubernetes.io/proxy-read-timeout": "3600",
-			"nginx.ingress.kubernetes.io/proxy-send-timeout": "3600",
-			"nginx.ingress.kubernetes.io/backend-protocol":   "HTTP",
-		}
-		// If a cert-manager issuer is provided, request a per-host cert
-		if iss := strings.TrimSpace(opt.CertManagerIssuer); iss != "" && tlsSec == "" {
-			anns["cert-manager.io/cluster-issuer"] = iss
-			tlsSec = fmt.Sprintf("workspace-%s-tls", id)
-		}
-		if v := strings.TrimSpace(opt.IngressAuthURL); v != "" {
-			anns["nginx.ingress.kubernetes.io/auth-url"] = v
-		}
-		if v := strings.TrimSpace(opt.IngressAuthSignin); v != "" {
-			anns["nginx.ingress.kubernetes.io/auth-signin"] = v
-		}
-		// OwnerRef to the Deployment
-		owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: name, UID: ""}
-		_ = owner
-		// We can’t easily get UID without a get; do a best-effort without owner for MVP
-		if err := c.EnsureIngress(ctx, ns, name, host, name, 8080, tlsSec, anns, iclass, metav1.OwnerReference{}); err != nil {
-			return name, id, err
-		}
-	}
-	return name, id, nil
-}
-
 // DeleteManaged deletes Deployments and Services labeled with guildnet.io/managed=true in the given namespace.
 func (c *Client) DeleteManaged(ctx context.Context, ns string) error {
 	if ns == "" {
@@ -565,15 +294,15 @@ func (c *Client) ResolveServiceAddress(ctx context.Context, ns, idOrName string)
 	if ns == "" {
 		ns = "default"
 	}
-	// prefer by name; fallback by label selection
-	svc, err1 := c.K.CoreV1().Services(ns).Get(ctx, idOrName, metav1.GetOptions{})
+	// prefer by name; fallback by label selection (both served from the informer cache)
+	svc, err1 := c.Objects().Service(ctx, ns, idOrName)
 	if err1 != nil {
 		// try by label selector guildnet.io/id
-		list, err2 := c.K.CoreV1().Services(ns).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("guildnet.io/id=%s", idOrName)})
-		if err2 != nil || len(list.Items) == 0 {
+		list, err2 := c.Objects().Services(ctx, ns, fmt.Sprintf("guildnet.io/id=%s", idOrName))
+		if err2 != nil || len(list) == 0 {
 			return "", 0, false, fmt.Errorf("service not found for %s", idOrName)
 		}
-		svc = &list.Items[0]
+		svc = &list[0]
 	}
 	if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == "None" {
 		return "", 0, false, fmt.Errorf("service has no clusterIP")
@@ -646,10 +375,7 @@ func (c *Client) GetLogs(ctx context.Context, ns, idOrName, level string, limit
 		return nil, fmt.Errorf("no pods")
 	}
 	pod := pods.Items[0]
-	container := ""
-	if len(pod.Spec.Containers) > 0 {
-		container = pod.Spec.Containers[0].Name
-	}
+	container, _ := LogContainer(&pod, "")
 	// fetch logs
 	tail := int64(limit)
 	req := c.K.CoreV1().Pods(ns).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container, TailLines: &tail})
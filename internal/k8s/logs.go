@@ -0,0 +1,136 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultMaxLogPods bounds the pods aggregated by a workspace log request when
+// settings.Global.MaxLogPods is unset.
+const DefaultMaxLogPods = 5
+
+// PlanPodLogs picks the pods to read and the per-pod TailLines for an aggregated log
+// request of at most limit lines. Ready pods come first, then by name, so repeated
+// requests read the same pods. At most maxPods pods are used (<= 0 means
+// DefaultMaxLogPods), and never more pods than lines. Each gets ceil(limit/pods) lines,
+// so the total overshoots limit by less than one line per pod; callers trim the rest
+// with NewestPodLogs.
+func PlanPodLogs(pods []corev1.Pod, limit, maxPods int) ([]corev1.Pod, int64) {
+	if limit < 1 || len(pods) == 0 {
+		return nil, 0
+	}
+	if maxPods <= 0 {
+		maxPods = DefaultMaxLogPods
+	}
+	ordered := append([]corev1.Pod(nil), pods...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, rj := podReady(&ordered[i]), podReady(&ordered[j])
+		if ri != rj {
+			return ri
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+	n := len(ordered)
+	if n > maxPods {
+		n = maxPods
+	}
+	if n > limit {
+		n = limit
+	}
+	return ordered[:n], int64((limit + n - 1) / n)
+}
+
+// PodLogLine is one line of a pod's log. TS is the kubelet timestamp (zero when the line
+// carried none).
+type PodLogLine struct {
+	Pod  string
+	TS   time.Time
+	Text string
+}
+
+// ParsePodLogs splits logs read with PodLogOptions.Timestamps into lines, taking the
+// RFC3339 timestamp the kubelet prefixes to each.
+func ParsePodLogs(pod string, data []byte) []PodLogLine {
+	var out []PodLogLine
+	for _, ln := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if ln == "" {
+			continue
+		}
+		l := PodLogLine{Pod: pod, Text: ln}
+		if ts, rest, ok := strings.Cut(ln, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+				l.TS, l.Text = t, rest
+			}
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// NewestPodLogs merges the lines of several pods by timestamp and keeps the newest limit,
+// oldest first. Lines with equal timestamps keep their per-pod order.
+func NewestPodLogs(lines []PodLogLine, limit int) []PodLogLine {
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].TS.Before(lines[j].TS) })
+	if limit >= 0 && len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	return lines
+}
+
+func podReady(p *corev1.Pod) bool {
+	for _, c := range p.Status.Conditions {
+		if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// mainContainerNames are the container names used for logs when none is requested: the
+// operator's "workspace" container, then "app" for legacy Deployments.
+var mainContainerNames = []string{"workspace", "app"}
+
+// LogContainer returns the container of pod to read logs from. A non-empty want must name
+// one of the pod's containers. Otherwise the main container is used, falling back to the
+// first one so single-container pods keep working whatever they are named.
+func LogContainer(pod *corev1.Pod, want string) (string, error) {
+	if want != "" {
+		for _, c := range pod.Spec.Containers {
+			if c.Name == want {
+				return want, nil
+			}
+		}
+		return "", fmt.Errorf("pod %s has no container %q", pod.Name, want)
+	}
+	for _, name := range mainContainerNames {
+		for _, c := range pod.Spec.Containers {
+			if c.Name == name {
+				return name, nil
+			}
+		}
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name, nil
+	}
+	return "", nil
+}
+
+// ContainerNames lists the container names of pods in first-seen order, so clients can
+// choose one for logs.
+func ContainerNames(pods []corev1.Pod) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, p := range pods {
+		for _, c := range p.Spec.Containers {
+			if !seen[c.Name] {
+				seen[c.Name] = true
+				out = append(out, c.Name)
+			}
+		}
+	}
+	return out
+}
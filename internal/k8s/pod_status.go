@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// WorkspaceLabel is the pod label the operator sets to the owning Workspace name.
+const WorkspaceLabel = "guildnet.io/workspace"
+
+// benignWaiting are container waiting reasons that are part of a normal start.
+var benignWaiting = map[string]bool{"": true, "ContainerCreating": true, "PodInitializing": true}
+
+// PodFailureReason returns why pods are failing, from their container statuses: a waiting
+// reason such as ImagePullBackOff or CrashLoopBackOff, a terminated reason such as
+// OOMKilled, or the reason of a failed pod (e.g. Evicted). A crash loop whose last exit was
+// an OOM kill reports OOMKilled, the more actionable of the two. "" when no pod is failing.
+func PodFailureReason(pods []corev1.Pod) string {
+	for _, p := range pods {
+		if p.DeletionTimestamp != nil {
+			continue
+		}
+		for _, cs := range append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...) {
+			switch {
+			case cs.State.Waiting != nil && !benignWaiting[cs.State.Waiting.Reason]:
+				if last := cs.LastTerminationState.Terminated; last != nil && last.Reason == "OOMKilled" {
+					return last.Reason
+				}
+				return cs.State.Waiting.Reason
+			case cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0:
+				if cs.State.Terminated.Reason != "" {
+					return cs.State.Terminated.Reason
+				}
+				return "Error"
+			}
+		}
+		if p.Status.Phase == corev1.PodFailed && p.Status.Reason != "" {
+			return p.Status.Reason
+		}
+	}
+	return ""
+}
+
+// ServerStatus maps a Workspace phase and ready replica count to the model.Server status:
+// running, pending or failed. Pods stuck failing (see PodFailureReason) make a workspace
+// failed even while its phase is still Pending; reason is set only for failed.
+func ServerStatus(phase string, readyReplicas int, pods []corev1.Pod) (status, reason string) {
+	if phase == "Running" && readyReplicas > 0 {
+		return "running", ""
+	}
+	reason = PodFailureReason(pods)
+	if phase == "Failed" || reason != "" {
+		return "failed", reason
+	}
+	return "pending", ""
+}
+
+// PodsByWorkspace groups pods by their WorkspaceLabel value.
+func PodsByWorkspace(pods []corev1.Pod) map[string][]corev1.Pod {
+	out := map[string][]corev1.Pod{}
+	for _, p := range pods {
+		if ws := p.Labels[WorkspaceLabel]; ws != "" {
+			out[ws] = append(out[ws], p)
+		}
+	}
+	return out
+}
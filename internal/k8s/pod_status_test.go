@@ -0,0 +1,52 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func podWith(ws string, cs corev1.ContainerStatus) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: ws + "-abc", Labels: map[string]string{WorkspaceLabel: ws}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending, ContainerStatuses: []corev1.ContainerStatus{cs}},
+	}
+}
+
+func TestServerStatusReasons(t *testing.T) {
+	waiting := func(reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: "main", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}}
+	}
+	oomLoop := waiting("CrashLoopBackOff")
+	oomLoop.LastTerminationState.Terminated = &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}
+	evicted := corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"}}
+
+	cases := []struct {
+		name         string
+		phase        string
+		ready        int
+		pods         []corev1.Pod
+		status, want string
+	}{
+		{"running", "Running", 1, []corev1.Pod{podWith("a", waiting("CrashLoopBackOff"))}, "running", ""},
+		{"starting", "Pending", 0, []corev1.Pod{podWith("a", waiting("ContainerCreating"))}, "pending", ""},
+		{"no pods", "Pending", 0, nil, "pending", ""},
+		{"image pull", "Pending", 0, []corev1.Pod{podWith("a", waiting("ImagePullBackOff"))}, "failed", "ImagePullBackOff"},
+		{"crash loop", "Running", 0, []corev1.Pod{podWith("a", waiting("CrashLoopBackOff"))}, "failed", "CrashLoopBackOff"},
+		{"oom loop", "Running", 0, []corev1.Pod{podWith("a", oomLoop)}, "failed", "OOMKilled"},
+		{"evicted", "Pending", 0, []corev1.Pod{evicted}, "failed", "Evicted"},
+		{"invalid spec", "Failed", 0, nil, "failed", ""},
+	}
+	for _, c := range cases {
+		st, reason := ServerStatus(c.phase, c.ready, c.pods)
+		if st != c.status || reason != c.want {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", c.name, st, reason, c.status, c.want)
+		}
+	}
+
+	by := PodsByWorkspace([]corev1.Pod{podWith("a", waiting("")), podWith("b", waiting("")), {}})
+	if len(by) != 2 || len(by["a"]) != 1 || len(by["b"]) != 1 {
+		t.Fatalf("PodsByWorkspace = %v", by)
+	}
+}
@@ -3,33 +3,69 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
 	spdy "k8s.io/client-go/transport/spdy"
+
+	"github.com/docxology/GuildNet/internal/metrics"
+)
+
+// Defaults for PortForwardManager.IdleTimeout and MaxForwards.
+const (
+	DefaultPortForwardIdleTimeout = 10 * time.Minute
+	DefaultMaxPortForwards        = 32
 )
 
 // PortForwardManager maintains on-demand port-forwards to pods.
 // It is best-effort and optimized for single-user dev scenarios.
 // Not production-hardened.
-
+//
+// Forwards unused for IdleTimeout are closed by a background sweep that runs while any
+// forward is open, and opening one beyond MaxForwards closes the least recently used.
+// Client connections are relayed through a listener the manager owns, so a forward
+// carrying an open connection (a WebSocket, a streamed response) is never evicted.
+// Active, evicted and failed counts are exported through the metrics package.
 type PortForwardManager struct {
 	cfg       *rest.Config
 	namespace string
 	clusterID string
 	mu        sync.Mutex
 	forwards  map[string]*pfEntry // key: ns/pod:port
+	sweeping  bool
+
+	// IdleTimeout closes forwards without connections and not returned by Ensure for this
+	// long (0 = default).
+	IdleTimeout time.Duration
+	// MaxForwards caps concurrently open forwards (0 = default).
+	MaxForwards int
 }
 
 type pfEntry struct {
 	localPort int
 	stopCh    chan struct{}
 	readyCh   chan struct{}
+	ln        net.Listener // accepts client connections on localPort; nil in tests
+	lastUsed  time.Time    // guarded by PortForwardManager.mu, like conns
+	conns     int          // open client connections
+	stopOnce  sync.Once
+}
+
+// stop ends the forward; safe to call more than once.
+func (e *pfEntry) stop() {
+	e.stopOnce.Do(func() {
+		close(e.stopCh)
+		if e.ln != nil {
+			_ = e.ln.Close()
+		}
+	})
 }
 
 func NewPortForwardManager(cfg *rest.Config, namespace string) *PortForwardManager {
@@ -56,29 +92,40 @@ func (m *PortForwardManager) Ensure(ctx context.Context, namespace, pod string,
 		conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", fmt.Sprintf("%d", lp)), 300*time.Millisecond)
 		if err == nil {
 			_ = conn.Close()
+			m.mu.Lock()
+			e.lastUsed = time.Now()
+			m.mu.Unlock()
 			return lp, nil
 		}
 		// fallthrough to recreate
+		metrics.PortForwardFailed()
 		m.mu.Lock()
-		delete(m.forwards, key)
+		m.removeLocked(key, e, false)
 	}
 	m.mu.Unlock()
 
-	// Pick a free local port
+	// Clients connect to ln; the forwarder itself listens on a second free port
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		return 0, err
 	}
-	lp := ln.Addr().(*net.TCPAddr).Port
-	_ = ln.Close()
+	innerLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		_ = ln.Close()
+		return 0, err
+	}
+	innerPort := innerLn.Addr().(*net.TCPAddr).Port
+	_ = innerLn.Close()
 
 	// Build spdy roundtripper/dialer against the kube-apiserver from rest.Config
 	rt, upgrader, err := spdy.RoundTripperFor(m.cfg)
 	if err != nil {
+		_ = ln.Close()
 		return 0, err
 	}
 	hostURL, err := url.Parse(m.cfg.Host)
 	if err != nil {
+		_ = ln.Close()
 		return 0, err
 	}
 	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", namespace, pod)
@@ -87,22 +134,190 @@ func (m *PortForwardManager) Ensure(ctx context.Context, namespace, pod string,
 
 	stopCh := make(chan struct{}, 1)
 	readyCh := make(chan struct{}, 1)
-	fw, err := portforward.New(dialer, []string{fmt.Sprintf("%d:%d", lp, podPort)}, stopCh, readyCh, nil, nil)
+	fw, err := portforward.New(dialer, []string{fmt.Sprintf("%d:%d", innerPort, podPort)}, stopCh, readyCh, nil, nil)
 	if err != nil {
+		_ = ln.Close()
+		metrics.PortForwardFailed()
 		return 0, err
 	}
+	e := &pfEntry{localPort: ln.Addr().(*net.TCPAddr).Port, stopCh: stopCh, readyCh: readyCh, ln: ln}
 	go func() {
 		_ = fw.ForwardPorts()
+		// The forward was stopped, or ended on its own (pod gone, connection lost) while
+		// still registered, which counts as a failure
+		m.mu.Lock()
+		if m.forwards[key] == e {
+			metrics.PortForwardFailed()
+		}
+		m.removeLocked(key, e, false)
+		m.mu.Unlock()
 	}()
 	select {
 	case <-readyCh:
 		// started
 	case <-time.After(8 * time.Second):
-		close(stopCh)
+		e.stop()
+		metrics.PortForwardFailed()
 		return 0, fmt.Errorf("port-forward start timeout")
 	}
+	go m.relay(e, innerPort)
+	return m.add(key, e), nil
+}
+
+// relay accepts client connections on e's listener and pipes each to the forwarder on
+// innerPort, counting them so eviction skips the forward while any is open. It returns
+// once the forward is stopped.
+func (m *PortForwardManager) relay(e *pfEntry, innerPort int) {
+	inner := net.JoinHostPort("127.0.0.1", strconv.Itoa(innerPort))
+	for {
+		c, err := e.ln.Accept()
+		if err != nil {
+			return
+		}
+		m.mu.Lock()
+		e.conns++
+		m.mu.Unlock()
+		go func() {
+			defer func() {
+				m.mu.Lock()
+				e.conns--
+				e.lastUsed = time.Now()
+				m.mu.Unlock()
+			}()
+			defer c.Close()
+			up, err := net.DialTimeout("tcp", inner, 5*time.Second)
+			if err != nil {
+				return
+			}
+			defer up.Close()
+			done := make(chan struct{}, 2)
+			go func() { _, _ = io.Copy(up, c); done <- struct{}{} }()
+			go func() { _, _ = io.Copy(c, up); done <- struct{}{} }()
+			<-done
+		}()
+	}
+}
+
+// add records a started forward and returns the local port to use. A forward for the
+// same key opened concurrently wins; e is stopped in that case.
+func (m *PortForwardManager) add(key string, e *pfEntry) int {
 	m.mu.Lock()
-	m.forwards[key] = &pfEntry{localPort: lp, stopCh: stopCh, readyCh: readyCh}
-	m.mu.Unlock()
-	return lp, nil
+	defer m.mu.Unlock()
+	if cur, ok := m.forwards[key]; ok {
+		e.stop()
+		cur.lastUsed = time.Now()
+		return cur.localPort
+	}
+	// Forwards with open connections are kept even if that leaves more than the cap
+	for len(m.forwards) >= m.maxForwards() {
+		if !m.evictOldestLocked() {
+			break
+		}
+	}
+	e.lastUsed = time.Now()
+	m.forwards[key] = e
+	metrics.PortForwardOpened()
+	if !m.sweeping {
+		m.sweeping = true
+		go m.sweep()
+	}
+	return e.localPort
+}
+
+// removeLocked stops e and drops it when it is still the forward stored under key.
+func (m *PortForwardManager) removeLocked(key string, e *pfEntry, evicted bool) {
+	e.stop()
+	if m.forwards[key] != e {
+		return
+	}
+	delete(m.forwards, key)
+	metrics.PortForwardClosed(evicted)
+}
+
+// evictOldestLocked closes the least recently used forward without open connections and
+// reports whether there was one.
+func (m *PortForwardManager) evictOldestLocked() bool {
+	var (
+		oldestKey string
+		oldest    *pfEntry
+	)
+	for k, e := range m.forwards {
+		if e.conns > 0 {
+			continue
+		}
+		if oldest == nil || e.lastUsed.Before(oldest.lastUsed) {
+			oldestKey, oldest = k, e
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	m.removeLocked(oldestKey, oldest, true)
+	return true
+}
+
+// EvictIdle closes forwards without open connections that were unused since before now
+// minus IdleTimeout and returns how many were closed.
+func (m *PortForwardManager) EvictIdle(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cutoff := now.Add(-m.idleTimeout())
+	n := 0
+	for k, e := range m.forwards {
+		if e.conns == 0 && e.lastUsed.Before(cutoff) {
+			m.removeLocked(k, e, true)
+			n++
+		}
+	}
+	return n
+}
+
+// Active returns the number of open forwards.
+func (m *PortForwardManager) Active() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.forwards)
+}
+
+// Close stops every forward.
+func (m *PortForwardManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, e := range m.forwards {
+		m.removeLocked(k, e, false)
+	}
+}
+
+// sweep evicts idle forwards periodically and exits once none are left; add restarts it.
+func (m *PortForwardManager) sweep() {
+	interval := m.idleTimeout() / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for now := range t.C {
+		m.EvictIdle(now)
+		m.mu.Lock()
+		if len(m.forwards) == 0 {
+			m.sweeping = false
+			m.mu.Unlock()
+			return
+		}
+		m.mu.Unlock()
+	}
+}
+
+func (m *PortForwardManager) idleTimeout() time.Duration {
+	if m.IdleTimeout > 0 {
+		return m.IdleTimeout
+	}
+	return DefaultPortForwardIdleTimeout
+}
+
+func (m *PortForwardManager) maxForwards() int {
+	if m.MaxForwards > 0 {
+		return m.MaxForwards
+	}
+	return DefaultMaxPortForwards
 }
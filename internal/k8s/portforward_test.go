@@ -0,0 +1,147 @@
+package k8s
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/metrics"
+)
+
+func newTestEntry(lp int) *pfEntry {
+	return &pfEntry{localPort: lp, stopCh: make(chan struct{}), readyCh: make(chan struct{})}
+}
+
+func stopped(e *pfEntry) bool {
+	select {
+	case <-e.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestPortForwardIdleEviction(t *testing.T) {
+	m := NewPortForwardManager(nil, "default")
+	m.IdleTimeout = time.Hour
+	defer m.Close()
+	before := metrics.Export().PortForwards
+
+	old, fresh := newTestEntry(1001), newTestEntry(1002)
+	m.add("old", old)
+	m.add("fresh", fresh)
+	m.mu.Lock()
+	old.lastUsed = time.Now().Add(-2 * time.Hour)
+	m.mu.Unlock()
+
+	if n := m.EvictIdle(time.Now()); n != 1 {
+		t.Fatalf("evicted %d, want 1", n)
+	}
+	if !stopped(old) || stopped(fresh) || m.Active() != 1 {
+		t.Fatalf("old stopped=%v fresh stopped=%v active=%d", stopped(old), stopped(fresh), m.Active())
+	}
+	after := metrics.Export().PortForwards
+	if after.Evicted-before.Evicted != 1 || after.Active-before.Active != 1 {
+		t.Fatalf("metrics before=%+v after=%+v", before, after)
+	}
+}
+
+func TestPortForwardCapEvictsLeastRecentlyUsed(t *testing.T) {
+	m := NewPortForwardManager(nil, "default")
+	m.MaxForwards = 2
+	defer m.Close()
+
+	a, b, c := newTestEntry(1), newTestEntry(2), newTestEntry(3)
+	m.add("a", a)
+	m.add("b", b)
+	m.mu.Lock()
+	a.lastUsed = time.Now().Add(time.Minute) // a was used most recently
+	m.mu.Unlock()
+	m.add("c", c)
+
+	if m.Active() != 2 || !stopped(b) || stopped(a) || stopped(c) {
+		t.Fatalf("active=%d stopped a=%v b=%v c=%v", m.Active(), stopped(a), stopped(b), stopped(c))
+	}
+
+	// A concurrent duplicate is stopped and the registered forward reused
+	dup := newTestEntry(4)
+	if lp := m.add("a", dup); lp != 1 || !stopped(dup) {
+		t.Fatalf("duplicate add returned %d, stopped=%v", lp, stopped(dup))
+	}
+}
+
+func TestPortForwardKeepsForwardsWithOpenConnections(t *testing.T) {
+	m := NewPortForwardManager(nil, "default")
+	m.IdleTimeout = time.Hour
+	m.MaxForwards = 1
+	defer m.Close()
+
+	// Stand-in for the forwarder: echo on the inner port
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	go func() {
+		for {
+			c, err := upstream.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(c, c); c.Close() }()
+		}
+	}()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy := newTestEntry(ln.Addr().(*net.TCPAddr).Port)
+	busy.ln = ln
+	go m.relay(busy, upstream.Addr().(*net.TCPAddr).Port)
+	m.add("busy", busy)
+
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := c.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(c, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("relay echoed %q err=%v", buf, err)
+	}
+	m.mu.Lock()
+	busy.lastUsed = time.Now().Add(-2 * time.Hour)
+	m.mu.Unlock()
+
+	// Neither the idle sweep nor the cap closes a forward carrying a connection
+	if n := m.EvictIdle(time.Now()); n != 0 || stopped(busy) {
+		t.Fatalf("idle sweep evicted %d, busy stopped=%v", n, stopped(busy))
+	}
+	other := newTestEntry(9)
+	m.add("other", other)
+	if stopped(busy) || m.Active() != 2 {
+		t.Fatalf("busy stopped=%v active=%d", stopped(busy), m.Active())
+	}
+
+	// Once the connection closes the forward is idle again
+	c.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		m.mu.Lock()
+		open := busy.conns
+		m.mu.Unlock()
+		if open == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d connections still counted", open)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := m.EvictIdle(time.Now().Add(2 * time.Hour)); n != 2 || !stopped(busy) {
+		t.Fatalf("evicted %d, busy stopped=%v", n, stopped(busy))
+	}
+}
@@ -1,6 +1,7 @@
 package localdb
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -50,6 +51,9 @@ func Open(stateDir string) (*DB, error) {
 
 func (d *DB) Close() error { return d.db.Close() }
 
+// Ping reports whether the database is open and reachable.
+func (d *DB) Ping(ctx context.Context) error { return d.db.PingContext(ctx) }
+
 func (d *DB) EnsureBuckets(names ...string) error {
 	// No-op for sqlite; tables are global. Return nil for compatibility.
 	return nil
@@ -105,6 +109,24 @@ func (d *DB) List(collection string, out any) error {
 	return json.Unmarshal(bb, out)
 }
 
+// Keys returns the keys stored in collection.
+func (d *DB) Keys(collection string) ([]string, error) {
+	rows, err := d.db.Query(`SELECT key FROM kv WHERE collection=? ORDER BY key`, collection)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
 func (d *DB) AppendLog(collection, k string, line []byte) error {
 	// Fetch existing
 	var cur []byte
@@ -137,3 +159,29 @@ func (d *DB) ReadLog(collection, k string) ([]byte, error) {
 	}
 	return append([]byte(nil), b...), nil
 }
+
+// DeleteLog removes a log; a missing log is not an error.
+func (d *DB) DeleteLog(collection, k string) error {
+	_, err := d.db.Exec(`DELETE FROM logs WHERE collection=? AND key=?`, collection, k)
+	return err
+}
+
+// UpdateLog replaces a log with fn(current) inside a transaction, so concurrent appends
+// through UpdateLog cannot interleave. fn receives nil for a missing log.
+func (d *DB) UpdateLog(collection, k string, fn func(cur []byte) []byte) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var cur []byte
+	switch err := tx.QueryRow(`SELECT value FROM logs WHERE collection=? AND key=?`, collection, k).Scan(&cur); err {
+	case nil, sql.ErrNoRows:
+	default:
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO logs(collection,key,value) VALUES(?,?,?) ON CONFLICT(collection,key) DO UPDATE SET value=excluded.value`, collection, k, fn(cur)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -14,7 +14,18 @@ type keyC struct{ cluster, org, table, op string }
 var (
 	opCounts          syncMap[key, uint64]
 	activeChangefeeds atomic.Int64
+	jobsRunning       atomic.Int64
+	jobsQueued        atomic.Int64
 	opCountsC         syncMap[keyC, uint64]
+
+	dbPoolMaxOpen atomic.Int64
+	dbPoolInUse   atomic.Int64
+	dbQueries     atomic.Uint64
+	dbPoolWaits   atomic.Uint64
+
+	pfActive  atomic.Int64
+	pfEvicted atomic.Uint64
+	pfFailed  atomic.Uint64
 )
 
 // syncMap is a tiny generic wrapper using atomic.Value for copy-on-write maps.
@@ -64,11 +75,71 @@ func ChangefeedInc() { activeChangefeeds.Add(1) }
 // ChangefeedDec decrements active changefeed gauge.
 func ChangefeedDec() { activeChangefeeds.Add(-1) }
 
+// SetJobs records the job runner's running and queued gauges.
+func SetJobs(running, queued int) {
+	jobsRunning.Store(int64(running))
+	jobsQueued.Store(int64(queued))
+}
+
+// DBPoolOpened adds a RethinkDB session's pool capacity to the pool gauges.
+func DBPoolOpened(maxOpen int) { dbPoolMaxOpen.Add(int64(maxOpen)) }
+
+// DBPoolClosed removes a closed session's pool capacity.
+func DBPoolClosed(maxOpen int) { dbPoolMaxOpen.Add(-int64(maxOpen)) }
+
+// DBQueryStarted records a query entering a session pool; waited marks queries started
+// while every pooled connection was already busy.
+func DBQueryStarted(waited bool) {
+	dbPoolInUse.Add(1)
+	dbQueries.Add(1)
+	if waited {
+		dbPoolWaits.Add(1)
+	}
+}
+
+// DBQueryDone records a query leaving a session pool.
+func DBQueryDone() { dbPoolInUse.Add(-1) }
+
+// PortForwardOpened records a new local port-forward.
+func PortForwardOpened() { pfActive.Add(1) }
+
+// PortForwardClosed records a port-forward being stopped; evicted marks idle or
+// over-capacity evictions.
+func PortForwardClosed(evicted bool) {
+	pfActive.Add(-1)
+	if evicted {
+		pfEvicted.Add(1)
+	}
+}
+
+// PortForwardFailed records a port-forward that failed to start or was found dead.
+func PortForwardFailed() { pfFailed.Add(1) }
+
+// PortForwards counts local port-forwards across all managers.
+type PortForwards struct {
+	Active  int64  `json:"active"`
+	Evicted uint64 `json:"evicted"`
+	Failed  uint64 `json:"failed"`
+}
+
+// DBPool summarizes RethinkDB session pools across all open managers.
+type DBPool struct {
+	MaxOpen int64  `json:"max_open"`
+	InUse   int64  `json:"in_use"`
+	Idle    int64  `json:"idle"`
+	Queries uint64 `json:"queries"`
+	Waits   uint64 `json:"waits"`
+}
+
 // Snapshot returns all metrics as a simple structure.
 type Snapshot struct {
-	Timestamp   time.Time         `json:"ts"`
-	Ops         map[string]uint64 `json:"ops"`
-	Changefeeds int64             `json:"changefeeds"`
+	Timestamp    time.Time         `json:"ts"`
+	Ops          map[string]uint64 `json:"ops"`
+	Changefeeds  int64             `json:"changefeeds"`
+	JobsRunning  int64             `json:"jobs_running"`
+	JobsQueued   int64             `json:"jobs_queued"`
+	DBPool       DBPool            `json:"db_pool"`
+	PortForwards PortForwards      `json:"port_forwards"`
 }
 
 func Export() Snapshot {
@@ -82,5 +153,10 @@ func Export() Snapshot {
 	for k, v := range curC {
 		flat["cluster/"+k.cluster+"/"+k.org+"/"+k.table+"/"+k.op] = v
 	}
-	return Snapshot{Timestamp: time.Now(), Ops: flat, Changefeeds: activeChangefeeds.Load()}
+	pool := DBPool{MaxOpen: dbPoolMaxOpen.Load(), InUse: dbPoolInUse.Load(), Queries: dbQueries.Load(), Waits: dbPoolWaits.Load()}
+	if pool.Idle = pool.MaxOpen - pool.InUse; pool.Idle < 0 {
+		pool.Idle = 0
+	}
+	pf := PortForwards{Active: pfActive.Load(), Evicted: pfEvicted.Load(), Failed: pfFailed.Load()}
+	return Snapshot{Timestamp: time.Now(), Ops: flat, Changefeeds: activeChangefeeds.Load(), JobsRunning: jobsRunning.Load(), JobsQueued: jobsQueued.Load(), DBPool: pool, PortForwards: pf}
 }
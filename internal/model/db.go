@@ -45,15 +45,23 @@ type ColumnDef struct {
 
 // Table represents a logical collection (RethinkDB table) with a schema.
 type Table struct {
-	ID         string      `json:"id"`
-	DatabaseID string      `json:"db_id"`
-	Name       string      `json:"name"`
-	PrimaryKey string      `json:"primary_key"`
-	TTL        int         `json:"ttl,omitempty"` // seconds (0 = none)
+	ID         string `json:"id"`
+	DatabaseID string `json:"db_id"`
+	Name       string `json:"name"`
+	PrimaryKey string `json:"primary_key"`
+	TTL        int    `json:"ttl,omitempty"` // seconds (0 = none)
+	// SoftDelete marks deleted rows with SoftDeleteField instead of removing them.
+	SoftDelete bool `json:"soft_delete,omitempty"`
+	// AuditReads writes a read event (actor, operation, row count; no values) to _audit
+	// for each QueryRows, GetRow and BatchGet. Off by default: every read becomes a write.
+	AuditReads bool        `json:"audit_reads,omitempty"`
 	Schema     []ColumnDef `json:"schema"`
 	CreatedAt  string      `json:"created_at,omitempty"`
 }
 
+// SoftDeleteField holds the deletion time of a soft-deleted row.
+const SoftDeleteField = "_deleted_at"
+
 // View represents a saved query (filters, sort, column selection) for a table.
 type View struct {
 	ID        string   `json:"id"`
@@ -82,7 +90,7 @@ type AuditEvent struct {
 	Scope   AuditScope `json:"scope"`
 	ScopeID string     `json:"scope_id"`
 	Actor   string     `json:"actor"`
-	Action  string     `json:"action"`         // e.g. create_db, update_schema, insert_row, update_row, delete_row
+	Action  string     `json:"action"`         // e.g. create_db, update_schema, insert_row, update_row, delete_row, restore
 	Diff    any        `json:"diff,omitempty"` // bounded diff representation
 	TS      string     `json:"ts"`
 }
@@ -107,7 +115,7 @@ type PermissionBinding struct {
 
 // ChangefeedEvent is emitted to realtime subscribers.
 type ChangefeedEvent struct {
-	Type     string `json:"type"` // init|insert|update|delete|snapshot|error
+	Type     string `json:"type"` // init|insert|update|delete|snapshot|paused|overflow|error
 	TableID  string `json:"table_id"`
 	RowID    string `json:"row_id,omitempty"`
 	Before   any    `json:"before,omitempty"`
@@ -115,6 +123,7 @@ type ChangefeedEvent struct {
 	Cursor   string `json:"cursor,omitempty"` // resume token (monotonic increasing logical sequence)
 	TS       string `json:"ts"`
 	Pending  int    `json:"pending,omitempty"` // backlog size when paused
+	Dropped  int    `json:"dropped,omitempty"` // events discarded by an overflow
 	Snapshot bool   `json:"snapshot,omitempty"`
 	Error    string `json:"error,omitempty"`
 }
@@ -125,6 +134,8 @@ type QueryPage[T any] struct {
 	NextCursor string `json:"next_cursor,omitempty"`
 	PrevCursor string `json:"prev_cursor,omitempty"`
 	Total      int64  `json:"total,omitempty"`
+	// Profile carries the RethinkDB query profile when requested with ?profile=1.
+	Profile any `json:"profile,omitempty"`
 }
 
 // TableQueryRequest holds server-side filter/sort/pagination; kept generic for MVP.
@@ -152,6 +163,35 @@ type ImportPreviewResponse struct {
 	Errors    []string           `json:"errors,omitempty"`
 }
 
+// SchemaDiff previews a table schema update: the column changes between the current and
+// a proposed schema, and the sampled rows the proposed schema would reject.
+type SchemaDiff struct {
+	Added             []ColumnDef       `json:"added"`
+	Removed           []ColumnDef       `json:"removed"`
+	Changed           []ColumnChange    `json:"changed"`
+	PrimaryKeyChanged bool              `json:"primaryKeyChanged,omitempty"`
+	IncompatibleRows  []IncompatibleRow `json:"incompatibleRows"`
+	// SampledRows is how many existing rows were checked against the proposed schema.
+	SampledRows int `json:"sampledRows"`
+}
+
+// ColumnChange is a column present in both schemas whose definition differs. Fields
+// names the attributes that changed (type, required, unique, indexed, default, enum,
+// regex, mask).
+type ColumnChange struct {
+	Name   string    `json:"name"`
+	From   ColumnDef `json:"from"`
+	To     ColumnDef `json:"to"`
+	Fields []string  `json:"fields"`
+}
+
+// IncompatibleRow is an existing row the proposed schema rejects. Errors use the import
+// preview form: "missing:<col>", "type:<col>" or "enum:<col>".
+type IncompatibleRow struct {
+	ID     string   `json:"id"`
+	Errors []string `json:"errors"`
+}
+
 // ExportRequest for exporting rows.
 type ExportRequest struct {
 	Format  string   `json:"format"`
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BuiltinDeployImages are the presets listed while no admin-curated preset is stored.
+func BuiltinDeployImages() []DeployImage {
+	return []DeployImage{
+		{
+			Label:       "VS Code (code-server)",
+			Image:       "codercom/code-server:4.90.3",
+			Description: "Browser-based VS Code via code-server behind Caddy",
+			Ports:       []Port{{Name: "http", Port: 8080}},
+			Env:         map[string]string{"AGENT_HOST": ""},
+		},
+	}
+}
+
+// Validate checks a preset: label and image required, image without whitespace, env
+// names non-empty and ports within 1-65535. Errors come in a stable order; nil means valid.
+func (d DeployImage) Validate() []FieldError {
+	var errs []FieldError
+	add := func(field, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	if strings.TrimSpace(d.Label) == "" {
+		add("label", "required")
+	}
+	if strings.TrimSpace(d.Image) == "" {
+		add("image", "required")
+	} else if strings.ContainsAny(d.Image, " \t\n") {
+		add("image", "must not contain whitespace")
+	}
+	keys := make([]string, 0, len(d.Env))
+	for k := range d.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.TrimSpace(k) == "" {
+			add("env", "variable name must not be empty")
+		}
+	}
+	for i, p := range d.Ports {
+		if p.Port < 1 || p.Port > 65535 {
+			add(fmt.Sprintf("ports[%d].port", i), "must be between 1 and 65535, got %d", p.Port)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,53 @@
+package model
+
+// WorkspaceTemplate is a named, reusable workspace create body (the same JSON accepted by
+// POST /api/cluster/{id}/workspaces), stored in the host's "templates" bucket.
+type WorkspaceTemplate struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Spec        map[string]any `json:"spec"`
+	CreatedAt   string         `json:"createdAt,omitempty"`
+	UpdatedAt   string         `json:"updatedAt,omitempty"`
+}
+
+// Validate checks the template name is a DNS-1123 label and that the spec is present.
+// Templates never fix the workspace name, so every create from one gets its own.
+func (t WorkspaceTemplate) Validate() []FieldError {
+	var errs []FieldError
+	switch {
+	case t.Name == "":
+		errs = append(errs, FieldError{Field: "name", Message: "required"})
+	case len(t.Name) > 63 || !dns1123Label.MatchString(t.Name):
+		errs = append(errs, FieldError{Field: "name", Message: "must be a DNS-1123 label of at most 63 characters"})
+	}
+	if t.Spec == nil {
+		errs = append(errs, FieldError{Field: "spec", Message: "required"})
+	} else if _, ok := t.Spec["name"]; ok {
+		errs = append(errs, FieldError{Field: "spec.name", Message: "templates cannot set the workspace name"})
+	}
+	return errs
+}
+
+// Apply returns the template spec with overrides merged on top. Objects (labels, env maps,
+// resources, ingress) merge key by key; any other value, including lists such as ports
+// and args, replaces the template's. Neither input is modified.
+func (t WorkspaceTemplate) Apply(overrides map[string]any) map[string]any {
+	return mergeSpec(t.Spec, overrides)
+}
+
+func mergeSpec(base, over map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		bm, bok := out[k].(map[string]any)
+		om, ook := v.(map[string]any)
+		if bok && ook {
+			out[k] = mergeSpec(bm, om)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
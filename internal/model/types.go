@@ -13,19 +13,22 @@ type Resources struct {
 }
 
 type Server struct {
-	ID        string            `json:"id"`
-	Name      string            `json:"name"`
-	Image     string            `json:"image"`
-	Status    string            `json:"status"`
-	Node      string            `json:"node,omitempty"`
-	CreatedAt string            `json:"created_at,omitempty"`
-	UpdatedAt string            `json:"updated_at,omitempty"`
-	Ports     []Port            `json:"ports,omitempty"`
-	Resources *Resources        `json:"resources,omitempty"`
-	Args      []string          `json:"args,omitempty"`
-	Env       map[string]string `json:"env,omitempty"`
-	Events    []Event           `json:"events,omitempty"`
-	URL       string            `json:"url,omitempty"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+	Status string `json:"status"`
+	// StatusReason says why a failed server failed, from its pods' container states
+	// (e.g. ImagePullBackOff, CrashLoopBackOff, OOMKilled).
+	StatusReason string            `json:"statusReason,omitempty"`
+	Node         string            `json:"node,omitempty"`
+	CreatedAt    string            `json:"created_at,omitempty"`
+	UpdatedAt    string            `json:"updated_at,omitempty"`
+	Ports        []Port            `json:"ports,omitempty"`
+	Resources    *Resources        `json:"resources,omitempty"`
+	Args         []string          `json:"args,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+	Events       []Event           `json:"events,omitempty"`
+	URL          string            `json:"url,omitempty"`
 }
 
 type Event struct {
@@ -41,6 +44,34 @@ type LogLine struct {
 	MSG string `json:"msg,omitempty"`
 }
 
+// LogStreamMeta is the first frame ("event: meta") of a log stream. RequestID matches the
+// X-Request-Id logged by the server so a stalled stream can be traced.
+type LogStreamMeta struct {
+	RequestID string `json:"requestId"`
+	Target    string `json:"target"`
+	Tail      int    `json:"tail"`
+}
+
+// LogStreamLine is an "event: log" frame. Msg keeps the "[pod] " prefix older clients
+// display; Pod and Container name the source.
+type LogStreamLine struct {
+	T         string `json:"t"`
+	Msg       string `json:"msg"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+}
+
+// LogStreamEOF is the last frame ("event: eof") of a log stream whose container log ended,
+// e.g. because the container exited. Reason and ExitCode are set when the container is
+// known to have terminated. A stream cut without it ended for another reason.
+type LogStreamEOF struct {
+	T         string `json:"t"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Reason    string `json:"reason,omitempty"`
+	ExitCode  *int32 `json:"exitCode,omitempty"`
+}
+
 func NowISO() string { return time.Now().UTC().Format(time.RFC3339) }
 
 // JobSpec mirrors UI expectations for launches.
@@ -59,11 +90,15 @@ type JobAccepted struct {
 	Status string `json:"status"`
 }
 
-// DeployImage describes an image option the backend exposes for the UI to list.
+// DeployImage describes an image option the backend exposes for the UI to list. Ports
+// and Env prefill the launch form when the image is picked; env values may be empty
+// placeholders for the user to fill in.
 type DeployImage struct {
-	Label       string `json:"label"`
-	Image       string `json:"image"`
-	Description string `json:"description,omitempty"`
+	Label       string            `json:"label"`
+	Image       string            `json:"image"`
+	Description string            `json:"description,omitempty"`
+	Ports       []Port            `json:"ports,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
 }
 
 // AgentRecord represents a gateway/agent presence in the overlay network.
@@ -78,6 +113,43 @@ type AgentRecord struct {
 	LastSeen     string         `json:"last_seen"`
 }
 
+// TSNetStatus summarizes the hostapp's tsnet node and netmap. Node keys and auth URLs are
+// never included.
+type TSNetStatus struct {
+	BackendState   string         `json:"backend_state"`
+	Self           TSNetPeer      `json:"self"`
+	Tailnet        string         `json:"tailnet,omitempty"`
+	MagicDNSSuffix string         `json:"magic_dns_suffix,omitempty"`
+	Health         []string       `json:"health,omitempty"`
+	ExitNode       *TSNetExitNode `json:"exit_node,omitempty"`
+	Peers          []TSNetPeer    `json:"peers"`
+	PeersTotal     int            `json:"peers_total"`
+	PeersOnline    int            `json:"peers_online"`
+}
+
+// TSNetPeer is a node in the tsnet netmap.
+type TSNetPeer struct {
+	ID             string   `json:"id"`
+	Hostname       string   `json:"hostname"`
+	FQDN           string   `json:"fqdn,omitempty"`
+	OS             string   `json:"os,omitempty"`
+	Addresses      []string `json:"addresses"`
+	Online         bool     `json:"online"`
+	LastSeen       string   `json:"last_seen,omitempty"`
+	Relay          string   `json:"relay,omitempty"`
+	CurAddr        string   `json:"cur_addr,omitempty"` // direct endpoint; empty when relayed
+	ExitNode       bool     `json:"exit_node,omitempty"`
+	ExitNodeOption bool     `json:"exit_node_option,omitempty"`
+	SubnetRoutes   []string `json:"subnet_routes,omitempty"`
+}
+
+// TSNetExitNode is the exit node currently in use.
+type TSNetExitNode struct {
+	ID        string   `json:"id"`
+	Online    bool     `json:"online"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
 type ResolveResponse struct {
 	IP        string         `json:"ip"`
 	Ports     map[string]int `json:"ports,omitempty"`
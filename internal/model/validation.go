@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FieldError is a single validation failure keyed by JSON field path.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationResult is the response shape of POST /api/validate/job.
+type ValidationResult struct {
+	Valid  bool         `json:"valid"`
+	Errors []FieldError `json:"errors"`
+}
+
+var dns1123Label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Limits applied to container args when ValidationOptions leaves them zero.
+const (
+	DefaultMaxArgs      = 64
+	DefaultMaxArgLength = 4096
+)
+
+// shellMetachars are rejected in args under ValidationOptions.RejectShellMetachars.
+const shellMetachars = ";&|`$<>\\\n\r"
+
+// ValidationOptions tunes JobSpec.ValidateWith. Args are handed to the container as-is
+// (no shell), but images whose entrypoint wraps them in "sh -c" would interpret them, so
+// deployments running such images can opt into RejectShellMetachars.
+type ValidationOptions struct {
+	// MaxArgs caps the number of args (0 = DefaultMaxArgs).
+	MaxArgs int
+	// MaxArgLength caps each arg in bytes (0 = DefaultMaxArgLength).
+	MaxArgLength int
+	// RejectShellMetachars refuses args containing ; & | ` $ < > \ or newlines.
+	RejectShellMetachars bool
+}
+
+// Validate checks a JobSpec with the rules every create path applies: image required,
+// optional name must be a DNS-1123 label, env keys and values non-empty, exposed ports
+// within 1-65535, and args bounded in count and length with no NUL bytes. Errors are
+// returned in a stable order; nil means valid.
+func (s JobSpec) Validate() []FieldError {
+	return s.ValidateWith(ValidationOptions{})
+}
+
+// ValidateWith is Validate with the args rules tuned by opts.
+func (s JobSpec) ValidateWith(opts ValidationOptions) []FieldError {
+	var errs []FieldError
+	add := func(field, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	if n := s.Name; n != "" {
+		if len(n) > 63 {
+			add("name", "must be at most 63 characters")
+		} else if !dns1123Label.MatchString(n) {
+			add("name", "must be a DNS-1123 label (lowercase alphanumerics and '-', starting and ending alphanumeric)")
+		}
+	}
+	if strings.TrimSpace(s.Image) == "" {
+		add("image", "required")
+	} else if strings.ContainsAny(s.Image, " \t\n") {
+		add("image", "must not contain whitespace")
+	}
+	keys := make([]string, 0, len(s.Env))
+	for k := range s.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.TrimSpace(k) == "" {
+			add("env", "variable name must not be empty")
+			continue
+		}
+		if strings.TrimSpace(s.Env[k]) == "" {
+			add("env."+k, "value must not be empty")
+		}
+	}
+	for i, p := range s.Expose {
+		if p.Port < 1 || p.Port > 65535 {
+			add(fmt.Sprintf("expose[%d].port", i), "must be between 1 and 65535, got %d", p.Port)
+		}
+	}
+	maxArgs, maxLen := opts.MaxArgs, opts.MaxArgLength
+	if maxArgs <= 0 {
+		maxArgs = DefaultMaxArgs
+	}
+	if maxLen <= 0 {
+		maxLen = DefaultMaxArgLength
+	}
+	if len(s.Args) > maxArgs {
+		add("args", "at most %d args allowed, got %d", maxArgs, len(s.Args))
+	}
+	for i, a := range s.Args {
+		field := fmt.Sprintf("args[%d]", i)
+		switch {
+		case strings.ContainsRune(a, 0):
+			add(field, "must not contain NUL bytes")
+		case len(a) > maxLen:
+			add(field, "must be at most %d bytes", maxLen)
+		case opts.RejectShellMetachars && strings.ContainsAny(a, shellMetachars):
+			add(field, "must not contain shell metacharacters")
+		}
+	}
+	return errs
+}
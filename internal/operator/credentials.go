@@ -0,0 +1,106 @@
+package operator
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	apiv1alpha1 "github.com/docxology/GuildNet/api/v1alpha1"
+)
+
+// CacheOptions limits the manager's Secret informer to Secrets labeled with a workspace,
+// i.e. the credentials Secrets the reconciler owns, so the operator does not cache every
+// Secret in the cluster. Other Secrets read through the manager's client appear missing.
+func CacheOptions() cache.Options {
+	req, _ := labels.NewRequirement("guildnet.io/workspace", selection.Exists, nil)
+	return cache.Options{ByObject: map[client.Object]cache.ByObject{
+		&corev1.Secret{}: {Label: labels.NewSelector().Add(*req)},
+	}}
+}
+
+// isCodeServerImage reports whether image looks like code-server.
+func isCodeServerImage(image string) bool {
+	return strings.Contains(strings.ToLower(image), "code-server")
+}
+
+// codeServerPasswordEnv returns the PASSWORD env var for a code-server workspace that set
+// neither PASSWORD nor HASHED_PASSWORD, creating the credentials Secret on first use. It
+// returns nil when the workspace brings its own password or opted out of auth.
+func (r *WorkspaceReconciler) codeServerPasswordEnv(ctx context.Context, ws *apiv1alpha1.Workspace, envIndex map[string]int) (*corev1.EnvVar, error) {
+	if ws.Annotations[apiv1alpha1.AnnotationCodeServerAuth] == "none" {
+		return nil, nil
+	}
+	if _, ok := envIndex["PASSWORD"]; ok {
+		return nil, nil
+	}
+	if _, ok := envIndex["HASHED_PASSWORD"]; ok {
+		return nil, nil
+	}
+	if err := r.ensureCredentialsSecret(ctx, ws); err != nil {
+		return nil, err
+	}
+	return &corev1.EnvVar{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: apiv1alpha1.CredentialsSecretName(ws.Name)},
+		Key:                  apiv1alpha1.CredentialsSecretKey,
+	}}}, nil
+}
+
+// ensureCredentialsSecret creates the workspace's credentials Secret with a random
+// password. An existing password is kept so restarts do not log users out.
+func (r *WorkspaceReconciler) ensureCredentialsSecret(ctx context.Context, ws *apiv1alpha1.Workspace) error {
+	sec := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: ws.Namespace, Name: apiv1alpha1.CredentialsSecretName(ws.Name)}
+	err := r.Get(ctx, key, sec)
+	if err == nil && len(sec.Data[apiv1alpha1.CredentialsSecretKey]) > 0 {
+		return nil
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	pw, perr := generatePassword()
+	if perr != nil {
+		return perr
+	}
+	if err == nil {
+		if sec.Data == nil {
+			sec.Data = map[string][]byte{}
+		}
+		sec.Data[apiv1alpha1.CredentialsSecretKey] = []byte(pw)
+		return r.Update(ctx, sec)
+	}
+	sec = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels:    map[string]string{"guildnet.io/workspace": ws.Name},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{apiv1alpha1.CredentialsSecretKey: []byte(pw)},
+	}
+	if err := controllerutil.SetControllerReference(ws, sec, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, sec); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// generatePassword returns 24 random bytes, URL-safe base64 encoded.
+func generatePassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
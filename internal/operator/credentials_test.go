@@ -0,0 +1,66 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/docxology/GuildNet/api/v1alpha1"
+)
+
+func TestCodeServerPasswordEnv(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = apiv1alpha1.AddToScheme(scheme)
+	r := &WorkspaceReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Scheme: scheme}
+	ctx := context.Background()
+	ws := &apiv1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "ide", Namespace: "default", UID: "u1"}, Spec: apiv1alpha1.WorkspaceSpec{Image: "codercom/code-server:4.9.0"}}
+
+	env, err := r.codeServerPasswordEnv(ctx, ws, map[string]int{})
+	if err != nil || env == nil || env.Value != "" || env.ValueFrom.SecretKeyRef.Name != "ide-credentials" || env.ValueFrom.SecretKeyRef.Key != "password" {
+		t.Fatalf("env=%+v err=%v", env, err)
+	}
+	var sec corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Namespace: "default", Name: "ide-credentials"}, &sec); err != nil {
+		t.Fatal(err)
+	}
+	// The scoped Secret informer must still see the credentials Secret
+	var secretCache cache.ByObject
+	for obj, opt := range CacheOptions().ByObject {
+		if _, ok := obj.(*corev1.Secret); ok {
+			secretCache = opt
+		}
+	}
+	if secretCache.Label == nil || !secretCache.Label.Matches(labels.Set(sec.Labels)) || secretCache.Label.Matches(labels.Set{"app": "other"}) {
+		t.Fatalf("secret cache selector %v vs labels %v", secretCache.Label, sec.Labels)
+	}
+	pw := string(sec.Data["password"])
+	if len(pw) < 24 || pw == "changeme" || len(sec.OwnerReferences) != 1 {
+		t.Fatalf("secret: %q owners=%v", pw, sec.OwnerReferences)
+	}
+	// A second reconcile keeps the password
+	if _, err := r.codeServerPasswordEnv(ctx, ws, map[string]int{}); err != nil {
+		t.Fatal(err)
+	}
+	_ = r.Get(ctx, client.ObjectKey{Namespace: "default", Name: "ide-credentials"}, &sec)
+	if string(sec.Data["password"]) != pw {
+		t.Fatal("password regenerated on reconcile")
+	}
+
+	for name, idx := range map[string]map[string]int{"PASSWORD": {"PASSWORD": 0}, "HASHED_PASSWORD": {"HASHED_PASSWORD": 0}} {
+		if env, err := r.codeServerPasswordEnv(ctx, ws, idx); env != nil || err != nil {
+			t.Fatalf("%s set: env=%+v err=%v", name, env, err)
+		}
+	}
+	ws.Annotations = map[string]string{apiv1alpha1.AnnotationCodeServerAuth: "none"}
+	if env, err := r.codeServerPasswordEnv(ctx, ws, map[string]int{}); env != nil || err != nil {
+		t.Fatalf("opt-out: env=%+v err=%v", env, err)
+	}
+}
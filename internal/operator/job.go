@@ -0,0 +1,168 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/docxology/GuildNet/api/v1alpha1"
+)
+
+// specHashAnnotation records the spec a workspace Job was built from. Job pod templates
+// are immutable, so a changed spec replaces the Job, which reruns it.
+const specHashAnnotation = "guildnet.io/spec-hash"
+
+// reconcileJob runs a runMode Job workspace: it removes the long-running resources of
+// runMode Service, keeps one Job built from tmpl and mirrors its outcome into status.
+func (r *WorkspaceReconciler) reconcileJob(ctx context.Context, req ctrl.Request, ws *apiv1alpha1.Workspace, tmpl corev1.PodTemplateSpec) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	om := metav1.ObjectMeta{Name: ws.Name, Namespace: ws.Namespace}
+	for _, obj := range []client.Object{
+		&appsv1.Deployment{ObjectMeta: om},
+		&corev1.Service{ObjectMeta: om},
+		&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: om},
+		&policyv1.PodDisruptionBudget{ObjectMeta: om},
+		&networkingv1.Ingress{ObjectMeta: om},
+	} {
+		if err := r.deleteIfExists(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+			logger.Error(err, "failed to remove service-mode resource", "kind", fmt.Sprintf("%T", obj))
+		}
+	}
+
+	desired := buildWorkspaceJob(ws, tmpl)
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: ws.Namespace, Name: ws.Name}, job); err != nil {
+		if !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to get job")
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		if err := controllerutil.SetControllerReference(ws, desired, r.Scheme); err != nil {
+			logger.Error(err, "failed to set controller reference on job")
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		if err := r.Create(ctx, desired); err != nil {
+			logger.Error(err, "failed to create job", "job", ws.Name)
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		job = desired
+	} else if job.Annotations[specHashAnnotation] != desired.Annotations[specHashAnnotation] {
+		logger.Info("workspace spec changed; replacing job", "job", ws.Name)
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete outdated job", "job", ws.Name)
+		}
+		return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+	}
+
+	phase, reason := jobPhase(job)
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		fresh := &apiv1alpha1.Workspace{}
+		if gerr := r.Get(ctx, req.NamespacedName, fresh); gerr != nil {
+			return gerr
+		}
+		fresh.Status.Phase = phase
+		if fresh.DeletionTimestamp != nil {
+			fresh.Status.Phase = apiv1alpha1.PhaseTerminating
+		}
+		fresh.Status.ReadyReplicas = 0
+		if job.Status.Ready != nil {
+			fresh.Status.ReadyReplicas = *job.Status.Ready
+		}
+		fresh.Status.CurrentReplicas = 0
+		fresh.Status.DesiredReplicas = 0
+		fresh.Status.EffectiveResources = nil
+		if len(tmpl.Spec.Containers) > 0 {
+			fresh.Status.EffectiveResources = statusResources(tmpl.Spec.Containers[0].Resources)
+		}
+		fresh.Status.ServiceDNS = ""
+		fresh.Status.ServiceIP = ""
+		fresh.Status.ExternalURL = ""
+		fresh.Status.ProxyTarget = ""
+		if reason != "" {
+			fresh.Status.LastError = "job: " + reason
+		} else if strings.HasPrefix(fresh.Status.LastError, "job: ") {
+			fresh.Status.LastError = ""
+		}
+		return r.Status().Update(ctx, fresh)
+	}); err != nil {
+		logger.Error(err, "status update failed")
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	logger.Info("reconcile complete", "phase", phase, "job", ws.Name)
+	return ctrl.Result{}, nil
+}
+
+// buildWorkspaceJob wraps tmpl in the Job for ws. Restart policy defaults to Never and
+// backoffLimit to 0, so a failing run is reported once rather than retried.
+func buildWorkspaceJob(ws *apiv1alpha1.Workspace, tmpl corev1.PodTemplateSpec) *batchv1.Job {
+	restart := corev1.RestartPolicyNever
+	backoff := int32(0)
+	var deadline *int64
+	if j := ws.Spec.Job; j != nil {
+		if j.RestartPolicy != "" {
+			restart = j.RestartPolicy
+		}
+		if j.BackoffLimit != nil {
+			backoff = *j.BackoffLimit
+		}
+		deadline = j.ActiveDeadlineSeconds
+	}
+	tmpl.Spec.RestartPolicy = restart
+	spec := batchv1.JobSpec{Template: tmpl, BackoffLimit: &backoff, ActiveDeadlineSeconds: deadline}
+	h := fnv.New64a()
+	b, _ := json.Marshal(spec)
+	_, _ = h.Write(b)
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ws.Name,
+			Namespace:   ws.Namespace,
+			Labels:      map[string]string{"guildnet.io/workspace": ws.Name},
+			Annotations: map[string]string{specHashAnnotation: fmt.Sprintf("%x", h.Sum64())},
+		},
+		Spec: spec,
+	}
+}
+
+// jobPhase maps Job status onto a workspace phase, with the failure reason for Failed.
+func jobPhase(job *batchv1.Job) (apiv1alpha1.WorkspacePhase, string) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return apiv1alpha1.PhaseSucceeded, ""
+		case batchv1.JobFailed:
+			reason := c.Reason
+			if c.Message != "" {
+				reason = strings.TrimPrefix(reason+": "+c.Message, ": ")
+			}
+			if reason == "" {
+				reason = "failed"
+			}
+			return apiv1alpha1.PhaseFailed, reason
+		}
+	}
+	// Job pods carry no readiness probe, so ready means the container is running
+	if job.Status.Ready != nil && *job.Status.Ready > 0 {
+		return apiv1alpha1.PhaseRunning, ""
+	}
+	return apiv1alpha1.PhasePending, ""
+}
@@ -0,0 +1,82 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/docxology/GuildNet/api/v1alpha1"
+)
+
+func TestJobPhase(t *testing.T) {
+	one := int32(1)
+	cases := []struct {
+		name   string
+		status batchv1.JobStatus
+		phase  apiv1alpha1.WorkspacePhase
+		reason string
+	}{
+		{"new", batchv1.JobStatus{}, apiv1alpha1.PhasePending, ""},
+		{"running", batchv1.JobStatus{Active: 1, Ready: &one}, apiv1alpha1.PhaseRunning, ""},
+		{"complete", batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}}, apiv1alpha1.PhaseSucceeded, ""},
+		{"failed", batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"}}}, apiv1alpha1.PhaseFailed, "BackoffLimitExceeded: Job has reached the specified backoff limit"},
+		{"failed condition false", batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionFalse}}}, apiv1alpha1.PhasePending, ""},
+	}
+	for _, c := range cases {
+		phase, reason := jobPhase(&batchv1.Job{Status: c.status})
+		if phase != c.phase || reason != c.reason {
+			t.Fatalf("%s: got %s %q want %s %q", c.name, phase, reason, c.phase, c.reason)
+		}
+	}
+}
+
+func TestBuildWorkspaceJob(t *testing.T) {
+	ws := &apiv1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: "default"}, Spec: apiv1alpha1.WorkspaceSpec{Image: "busybox", RunMode: apiv1alpha1.RunModeJob}}
+	tmpl := corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "workspace", Image: "busybox"}}}}
+	job := buildWorkspaceJob(ws, tmpl)
+	if job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever || *job.Spec.BackoffLimit != 0 || job.Labels["guildnet.io/workspace"] != "batch" {
+		t.Fatalf("defaults: %+v", job.Spec)
+	}
+	if h := buildWorkspaceJob(ws, tmpl).Annotations[specHashAnnotation]; h == "" || h != job.Annotations[specHashAnnotation] {
+		t.Fatalf("spec hash not stable: %q vs %q", h, job.Annotations[specHashAnnotation])
+	}
+	three := int32(3)
+	ws.Spec.Job = &apiv1alpha1.WorkspaceJob{RestartPolicy: corev1.RestartPolicyOnFailure, BackoffLimit: &three}
+	tuned := buildWorkspaceJob(ws, tmpl)
+	if tuned.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyOnFailure || *tuned.Spec.BackoffLimit != 3 {
+		t.Fatalf("tuning ignored: %+v", tuned.Spec)
+	}
+	if tuned.Annotations[specHashAnnotation] == job.Annotations[specHashAnnotation] {
+		t.Fatal("spec hash unchanged after job tuning changed")
+	}
+}
+
+func TestReconcileJobSkipsDeletesOfAbsentServiceResources(t *testing.T) {
+	ctx := context.Background()
+	ws := &apiv1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: "default"}, Spec: apiv1alpha1.WorkspaceSpec{Image: "busybox", RunMode: apiv1alpha1.RunModeJob}}
+	tmpl := corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "workspace", Image: "busybox"}}}}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(ws)}
+	r, deletes := countingReconciler(t, ws.DeepCopy())
+	for i := 0; i < 2; i++ {
+		if _, err := r.reconcileJob(ctx, req, ws, tmpl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *deletes != 0 {
+		t.Fatalf("deletes = %d for a workspace that never ran as a service", *deletes)
+	}
+
+	r, deletes = countingReconciler(t, ws.DeepCopy(), &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: "default"}})
+	if _, err := r.reconcileJob(ctx, req, ws, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if *deletes != 1 {
+		t.Fatalf("deletes = %d, want the stale Deployment removed", *deletes)
+	}
+}
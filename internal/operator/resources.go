@@ -0,0 +1,90 @@
+package operator
+
+import (
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Keys of the guildnet-cluster-settings ConfigMap holding default workspace container
+// resources, each a JSON object of resource name to quantity ({"cpu":"500m","memory":"1Gi"}).
+const (
+	defaultRequestsKey = "workspace_default_requests"
+	defaultLimitsKey   = "workspace_default_limits"
+)
+
+// parseDefaultResources reads the cluster default requests and limits from ConfigMap
+// data. Malformed values are skipped so one bad entry does not drop the others.
+func parseDefaultResources(data map[string]string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: parseResourceList(data[defaultRequestsKey]),
+		Limits:   parseResourceList(data[defaultLimitsKey]),
+	}
+}
+
+func parseResourceList(s string) corev1.ResourceList {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil
+	}
+	out := corev1.ResourceList{}
+	for name, v := range raw {
+		q, err := resource.ParseQuantity(strings.TrimSpace(v))
+		if err != nil || strings.TrimSpace(name) == "" {
+			continue
+		}
+		out[corev1.ResourceName(strings.TrimSpace(name))] = q
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// effectiveResources fills every request or limit the workspace leaves unset from the
+// cluster defaults. A default is skipped when it would conflict with an explicit value:
+// a default limit below an explicit request, or a default request above an explicit limit.
+func effectiveResources(spec *corev1.ResourceRequirements, defaults corev1.ResourceRequirements) corev1.ResourceRequirements {
+	var out corev1.ResourceRequirements
+	if spec != nil {
+		out = *spec.DeepCopy()
+	}
+	for name, q := range defaults.Requests {
+		if _, ok := out.Requests[name]; ok {
+			continue
+		}
+		if lim, ok := out.Limits[name]; ok && q.Cmp(lim) > 0 {
+			continue
+		}
+		if out.Requests == nil {
+			out.Requests = corev1.ResourceList{}
+		}
+		out.Requests[name] = q.DeepCopy()
+	}
+	for name, q := range defaults.Limits {
+		if _, ok := out.Limits[name]; ok {
+			continue
+		}
+		if req, ok := out.Requests[name]; ok && q.Cmp(req) < 0 {
+			continue
+		}
+		if out.Limits == nil {
+			out.Limits = corev1.ResourceList{}
+		}
+		out.Limits[name] = q.DeepCopy()
+	}
+	return out
+}
+
+// statusResources returns res for status.effectiveResources, nil when nothing is set.
+func statusResources(res corev1.ResourceRequirements) *corev1.ResourceRequirements {
+	if len(res.Requests) == 0 && len(res.Limits) == 0 {
+		return nil
+	}
+	return res.DeepCopy()
+}
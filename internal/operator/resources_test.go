@@ -0,0 +1,52 @@
+package operator
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestEffectiveResources(t *testing.T) {
+	defaults := parseDefaultResources(map[string]string{
+		defaultRequestsKey: `{"cpu":"250m","memory":"256Mi","bogus":"x"}`,
+		defaultLimitsKey:   `{"cpu":"1","memory":"1Gi"}`,
+	})
+	if len(defaults.Requests) != 2 || len(defaults.Limits) != 2 {
+		t.Fatalf("parsed defaults: %+v", defaults)
+	}
+	q := resource.MustParse
+	eq := func(l corev1.ResourceList, name corev1.ResourceName, want string) bool {
+		v, ok := l[name]
+		return ok && v.Cmp(q(want)) == 0
+	}
+
+	// No spec.resources: defaults apply as-is
+	got := effectiveResources(nil, defaults)
+	if !eq(got.Requests, corev1.ResourceCPU, "250m") || !eq(got.Limits, corev1.ResourceMemory, "1Gi") {
+		t.Fatalf("defaults not applied: %+v", got)
+	}
+
+	// Explicit values win; a default limit below an explicit request is skipped
+	spec := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: q("2Gi")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: q("200m")},
+	}
+	got = effectiveResources(spec, defaults)
+	if !eq(got.Requests, corev1.ResourceMemory, "2Gi") || !eq(got.Limits, corev1.ResourceCPU, "200m") {
+		t.Fatalf("explicit values overridden: %+v", got)
+	}
+	if _, ok := got.Limits[corev1.ResourceMemory]; ok {
+		t.Fatalf("default memory limit below the explicit request was applied: %+v", got)
+	}
+	if _, ok := got.Requests[corev1.ResourceCPU]; ok {
+		t.Fatalf("default cpu request above the explicit limit was applied: %+v", got)
+	}
+	if len(spec.Requests) != 1 || len(spec.Limits) != 1 {
+		t.Fatalf("spec mutated: %+v", spec)
+	}
+
+	if statusResources(effectiveResources(nil, corev1.ResourceRequirements{})) != nil {
+		t.Fatal("empty resources should leave status unset")
+	}
+}
@@ -9,8 +9,14 @@ import (
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -23,7 +29,8 @@ import (
 	apiv1alpha1 "github.com/docxology/GuildNet/api/v1alpha1"
 )
 
-// WorkspaceReconciler reconciles a Workspace object into a Deployment + Service.
+// WorkspaceReconciler reconciles a Workspace object into a Deployment + Service, or a Job
+// for runMode Job.
 type WorkspaceReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
@@ -32,6 +39,12 @@ type WorkspaceReconciler struct {
 	// the in-cluster ConfigMap `guildnet-cluster-settings` in namespace
 	// `guildnet-system` so we avoid a GET on every reconcile.
 	DefaultLB bool
+	// DefaultResources are the cluster-wide container requests/limits from the same
+	// ConfigMap, filled into workspaces that leave them unset.
+	DefaultResources corev1.ResourceRequirements
+	// APIReader reads straight from the API server, for lookups that should not start a
+	// cluster-wide informer (priority classes). Nil falls back to the client.
+	APIReader client.Reader
 	mu        sync.RWMutex
 }
 
@@ -47,6 +60,40 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// Reject invalid networking overrides up front rather than letting the Deployment fail admission.
+	if err := apiv1alpha1.ValidateEnvFrom(ws.Spec.EnvFrom); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	if err := apiv1alpha1.ValidateServiceAccountName(ws.Spec.ServiceAccountName); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	if err := apiv1alpha1.ValidateHostAliases(ws.Spec.HostAliases); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	if err := apiv1alpha1.ValidateDNS(ws.Spec.DNSPolicy, ws.Spec.DNSConfig); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	if err := apiv1alpha1.ValidateStrategy(ws.Spec.Strategy); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	if err := apiv1alpha1.ValidateAutoscale(ws.Spec.Autoscale); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	if err := apiv1alpha1.ValidatePriorityClassName(ws.Spec.PriorityClassName); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	if err := apiv1alpha1.ValidateDisruption(ws.Spec.MinAvailable, ws.Spec.MaxUnavailable); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	if err := apiv1alpha1.ValidateIngress(ws.Spec.Ingress, ""); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	if err := apiv1alpha1.ValidateRunMode(ws.Spec.RunMode, ws.Spec.Job); err != nil {
+		return r.markInvalid(ctx, req, err)
+	}
+	// runMode Job runs the same pod once to completion instead of as a Deployment
+	jobMode := ws.Spec.RunMode == apiv1alpha1.RunModeJob
+
 	// Desired Deployment + Service names.
 	depName := ws.Name
 	svcName := ws.Name
@@ -76,9 +123,16 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		env = append(env, corev1.EnvVar{Name: "PORT", Value: "8080"})
 	}
 	imgLower := strings.ToLower(ws.Spec.Image)
-	if strings.Contains(imgLower, "codercom/code-server") || strings.Contains(imgLower, "code-server") {
-		if _, exists := envIndex["PASSWORD"]; !exists {
-			env = append(env, corev1.EnvVar{Name: "PASSWORD", Value: "changeme"})
+	codeServer := isCodeServerImage(ws.Spec.Image)
+	if codeServer {
+		// Never fall back to a known password: generate one into the credentials Secret
+		pw, err := r.codeServerPasswordEnv(ctx, ws, envIndex)
+		if err != nil {
+			logger.Error(err, "failed to ensure workspace credentials")
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		if pw != nil {
+			env = append(env, *pw)
 		}
 	}
 
@@ -88,7 +142,9 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	var liveness *corev1.Probe
 	var args []string
 	probePort := intstr.FromInt(int(ports[0].ContainerPort))
-	if strings.Contains(imgLower, "alpine") {
+	if jobMode {
+		// Batch runs keep the image entrypoint and are not probed
+	} else if strings.Contains(imgLower, "alpine") {
 		command = []string{"/bin/sh", "-c", "while true; do echo -e 'HTTP/1.1 200 OK\\r\\nContent-Length:2\\r\\n\\r\\nok' | nc -l -p 8080 -w 1; done"}
 		readiness = &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: probePort}}, InitialDelaySeconds: 10, PeriodSeconds: 5, TimeoutSeconds: 3, FailureThreshold: 12, SuccessThreshold: 1}
 		liveness = &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: probePort}}, InitialDelaySeconds: 60, PeriodSeconds: 15, TimeoutSeconds: 5, FailureThreshold: 3, SuccessThreshold: 1}
@@ -97,9 +153,13 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		readiness = &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: probePort}}, InitialDelaySeconds: 10, PeriodSeconds: 5, TimeoutSeconds: 3, FailureThreshold: 12, SuccessThreshold: 1}
 		liveness = &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: probePort}}, InitialDelaySeconds: 60, PeriodSeconds: 15, TimeoutSeconds: 5, FailureThreshold: 4, SuccessThreshold: 1}
 	}
-	if strings.Contains(imgLower, "codercom/code-server") || strings.Contains(imgLower, "code-server") {
+	if codeServer {
 		// code-server must bind on 0.0.0.0:8080 w/ password auth; base path handled by proxy
-		args = []string{"--bind-addr", "0.0.0.0:8080", "--auth", "password"}
+		auth := "password"
+		if ws.Annotations[apiv1alpha1.AnnotationCodeServerAuth] == "none" {
+			auth = "none"
+		}
+		args = []string{"--bind-addr", "0.0.0.0:8080", "--auth", auth}
 	}
 
 	// Security context
@@ -155,10 +215,15 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		VolumeMounts:    []corev1.VolumeMount{{Name: "nginx-cache", MountPath: "/var/cache/nginx"}},
 	}
 
+	r.mu.RLock()
+	resources := effectiveResources(ws.Spec.Resources, r.DefaultResources)
+	r.mu.RUnlock()
 	workspaceContainer := corev1.Container{
 		Name:            "workspace",
+		Resources:       resources,
 		Image:           ws.Spec.Image,
 		Env:             env,
+		EnvFrom:         ws.Spec.EnvFrom,
 		Command:         command,
 		Args:            args,
 		Ports:           ports,
@@ -169,10 +234,23 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	}
 
 	podSpec := corev1.PodSpec{
-		Containers:     []corev1.Container{workspaceContainer},
-		InitContainers: []corev1.Container{init},
-		Volumes:        []corev1.Volume{{Name: "nginx-cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
-		Tolerations:    []corev1.Toleration{{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+		Containers:                   []corev1.Container{workspaceContainer},
+		InitContainers:               []corev1.Container{init},
+		Volumes:                      []corev1.Volume{{Name: "nginx-cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+		Tolerations:                  []corev1.Toleration{{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+		HostAliases:                  ws.Spec.HostAliases,
+		DNSPolicy:                    ws.Spec.DNSPolicy,
+		DNSConfig:                    ws.Spec.DNSConfig,
+		ServiceAccountName:           ws.Spec.ServiceAccountName,
+		AutomountServiceAccountToken: automountToken(ws.Spec.AutomountServiceAccountToken),
+	}
+	// A missing priority class would make pod admission fail, so it is left off (and
+	// reported in status) until the class exists.
+	priorityErr := r.checkPriorityClass(ctx, ws.Spec.PriorityClassName)
+	if priorityErr != nil {
+		logger.Info("priority class not applied", "workspace", ws.Name, "priorityClassName", ws.Spec.PriorityClassName, "reason", priorityErr.Error())
+	} else {
+		podSpec.PriorityClassName = ws.Spec.PriorityClassName
 	}
 	if strings.Contains(imgLower, "nginx") {
 		// Use non-root pod-level securityContext for the unprivileged nginx
@@ -211,14 +289,20 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	}
 
 	podTemplate := corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"guildnet.io/workspace": ws.Name}}, Spec: podSpec}
+	if jobMode {
+		return r.reconcileJob(ctx, req, ws, podTemplate)
+	}
 
 	replicas := int32(1)
+	if as := ws.Spec.Autoscale; as != nil && as.MinReplicas != nil {
+		replicas = *as.MinReplicas
+	}
 	desired.Labels = map[string]string{"guildnet.io/workspace": ws.Name}
 	desired.Spec = appsv1.DeploymentSpec{
 		Replicas: &replicas,
 		Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"guildnet.io/workspace": ws.Name}},
 		Template: podTemplate,
-		Strategy: appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType, RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: func() *intstr.IntOrString { v := intstr.FromString("25%"); return &v }(), MaxUnavailable: func() *intstr.IntOrString { v := intstr.FromString("25%"); return &v }()}},
+		Strategy: deploymentStrategy(ws.Spec.Strategy),
 	}
 
 	// NOTE: Do NOT set the owner reference on the desired object before
@@ -253,7 +337,12 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	} else {
 		// Update only the spec portion we manage. This avoids clobbering other
 		// fields that other controllers may legitimately manage.
+		liveReplicas := existing.Spec.Replicas
 		existing.Spec = desired.Spec
+		if ws.Spec.Autoscale != nil && liveReplicas != nil {
+			// replicas belong to the HPA when autoscaling is enabled
+			existing.Spec.Replicas = liveReplicas
+		}
 		if uerr := r.Update(ctx, existing); uerr != nil {
 			logger.Error(uerr, "failed to update deployment", "deployment", depName)
 			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
@@ -391,7 +480,11 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			if err := r.Get(ctx, client.ObjectKey{Namespace: ws.Namespace, Name: depName}, cur); err != nil {
 				return err
 			}
+			liveReplicas := cur.Spec.Replicas
 			cur.Spec = desired.Spec
+			if ws.Spec.Autoscale != nil && liveReplicas != nil {
+				cur.Spec.Replicas = liveReplicas
+			}
 			return r.Update(ctx, cur)
 		}); perr != nil {
 			logger.Error(perr, "re-apply update failed", "attempt", attempt)
@@ -486,6 +579,27 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
 
+	// Reconcile the optional HPA. Failures (e.g. autoscaling/v2 unavailable) are
+	// recorded but do not block the rest of the workspace from running.
+	hpa, hpaErr := r.reconcileHPA(ctx, ws, depName)
+	if hpaErr != nil {
+		logger.Error(hpaErr, "reconcile hpa failed")
+	}
+	// Same for the optional PodDisruptionBudget.
+	pdbErr := r.reconcilePDB(ctx, ws, replicas)
+	if pdbErr != nil {
+		logger.Error(pdbErr, "reconcile pdb failed")
+	}
+	// A Job left over from runMode Job is no longer wanted.
+	if err := r.deleteIfExists(ctx, &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: ws.Name, Namespace: ws.Namespace}}, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+		logger.Error(err, "remove job failed")
+	}
+	// And for the Ingress used by exposure.type Ingress.
+	externalURL, ingErr := r.reconcileIngress(ctx, ws, svcName, ports[0].ContainerPort)
+	if ingErr != nil {
+		logger.Error(ingErr, "reconcile ingress failed")
+	}
+
 	// Update Status with retry on conflict
 	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		fresh := &apiv1alpha1.Workspace{}
@@ -493,6 +607,26 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			return gerr
 		}
 		fresh.Status.ReadyReplicas = dep.Status.ReadyReplicas
+		fresh.Status.EffectiveResources = statusResources(resources)
+		fresh.Status.CurrentReplicas = 0
+		fresh.Status.DesiredReplicas = 0
+		if hpa != nil {
+			fresh.Status.CurrentReplicas = hpa.Status.CurrentReplicas
+			fresh.Status.DesiredReplicas = hpa.Status.DesiredReplicas
+		}
+		for _, w := range []struct {
+			prefix string
+			err    error
+		}{{"autoscale: ", hpaErr}, {"disruption: ", pdbErr}, {"priorityClass: ", priorityErr}, {"ingress: ", ingErr}} {
+			if w.err != nil {
+				fresh.Status.LastError = w.prefix + w.err.Error()
+			} else if strings.HasPrefix(fresh.Status.LastError, w.prefix) {
+				fresh.Status.LastError = ""
+			}
+		}
+		if ingErr == nil {
+			fresh.Status.ExternalURL = externalURL
+		}
 		fresh.Status.ServiceDNS = fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace)
 		if svc.Spec.ClusterIP != "" {
 			fresh.Status.ServiceIP = svc.Spec.ClusterIP
@@ -516,6 +650,220 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	return ctrl.Result{}, nil
 }
 
+// reconcileHPA creates/updates the Workspace HPA when spec.autoscale is set and removes
+// it otherwise. Returns the live HPA (nil when autoscaling is disabled).
+func (r *WorkspaceReconciler) reconcileHPA(ctx context.Context, ws *apiv1alpha1.Workspace, depName string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: ws.Name, Namespace: ws.Namespace}}
+	as := ws.Spec.Autoscale
+	if as == nil {
+		return nil, r.deleteIfExists(ctx, hpa)
+	}
+	minR := int32(1)
+	if as.MinReplicas != nil {
+		minR = *as.MinReplicas
+	}
+	target := int32(80)
+	if as.TargetCPUUtilization != nil {
+		target = *as.TargetCPUUtilization
+	}
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		hpa.ObjectMeta = metav1.ObjectMeta{Name: ws.Name, Namespace: ws.Namespace}
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, hpa, func() error {
+			hpa.Labels = map[string]string{"guildnet.io/workspace": ws.Name}
+			hpa.Spec.ScaleTargetRef = autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: depName}
+			hpa.Spec.MinReplicas = &minR
+			hpa.Spec.MaxReplicas = as.MaxReplicas
+			hpa.Spec.Metrics = []autoscalingv2.MetricSpec{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name:   corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &target},
+				},
+			}}
+			return controllerutil.SetControllerReference(ws, hpa, r.Scheme)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hpa, nil
+}
+
+// deleteIfExists deletes obj only after a Get finds it, so reconciles of workspaces that
+// never had the object issue no deletes. Kinds the cluster does not serve count as absent.
+func (r *WorkspaceReconciler) deleteIfExists(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	if err := r.Delete(ctx, obj, opts...); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// checkPriorityClass reports why a requested priority class cannot be applied. Only a
+// missing class is an error; other lookup failures (e.g. a forbidden get) leave the class
+// applied.
+func (r *WorkspaceReconciler) checkPriorityClass(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+	var reader client.Reader = r.Client
+	if r.APIReader != nil {
+		reader = r.APIReader
+	}
+	pc := &schedulingv1.PriorityClass{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: name}, pc); err != nil && apierrors.IsNotFound(err) {
+		return fmt.Errorf("priority class %q not found", name)
+	}
+	return nil
+}
+
+// reconcilePDB keeps a PodDisruptionBudget owned by the Workspace while spec.minAvailable
+// or spec.maxUnavailable is set and more than one replica runs, and removes it otherwise.
+func (r *WorkspaceReconciler) reconcilePDB(ctx context.Context, ws *apiv1alpha1.Workspace, replicas int32) error {
+	pdb := &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: ws.Name, Namespace: ws.Namespace}}
+	if (ws.Spec.MinAvailable == nil && ws.Spec.MaxUnavailable == nil) || replicas <= 1 {
+		return r.deleteIfExists(ctx, pdb)
+	}
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		pdb.ObjectMeta = metav1.ObjectMeta{Name: ws.Name, Namespace: ws.Namespace}
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, pdb, func() error {
+			pdb.Labels = map[string]string{"guildnet.io/workspace": ws.Name}
+			pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"guildnet.io/workspace": ws.Name}}
+			pdb.Spec.MinAvailable = ws.Spec.MinAvailable
+			pdb.Spec.MaxUnavailable = ws.Spec.MaxUnavailable
+			return controllerutil.SetControllerReference(ws, pdb, r.Scheme)
+		})
+		return err
+	})
+}
+
+// reconcileIngress keeps an Ingress owned by the Workspace while exposure.type is Ingress
+// and removes it otherwise. spec.ingress overrides host, path and annotations; annotations
+// are merged over DefaultIngressAnnotations. Returns the external URL ("" when removed).
+func (r *WorkspaceReconciler) reconcileIngress(ctx context.Context, ws *apiv1alpha1.Workspace, svcName string, port int32) (string, error) {
+	ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: ws.Name, Namespace: ws.Namespace}}
+	exp := ws.Spec.Exposure
+	if exp == nil || exp.Type != apiv1alpha1.ExposureIngress {
+		return "", r.deleteIfExists(ctx, ing)
+	}
+	over := ws.Spec.Ingress
+	if over == nil {
+		over = &apiv1alpha1.WorkspaceIngress{}
+	}
+	host := over.Host
+	if host == "" && strings.TrimSpace(exp.Domain) != "" {
+		host = ws.Name + "." + strings.Trim(strings.TrimSpace(exp.Domain), ".")
+	}
+	if host == "" {
+		return "", fmt.Errorf("ingress.host or exposure.domain is required")
+	}
+	path := over.Path
+	if path == "" {
+		path = "/"
+	}
+	pathType := over.PathType
+	if pathType == "" {
+		pathType = networkingv1.PathTypePrefix
+	}
+	anns := apiv1alpha1.DefaultIngressAnnotations()
+	tlsSecret := ""
+	if iss := strings.TrimSpace(exp.TLSIssuer); iss != "" {
+		anns["cert-manager.io/cluster-issuer"] = iss
+		tlsSecret = fmt.Sprintf("workspace-%s-tls", ws.Name)
+	}
+	for k, v := range over.Annotations {
+		anns[k] = v
+	}
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		ing.ObjectMeta = metav1.ObjectMeta{Name: ws.Name, Namespace: ws.Namespace}
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, ing, func() error {
+			ing.Labels = map[string]string{"guildnet.io/workspace": ws.Name}
+			ing.Annotations = anns
+			ing.Spec.IngressClassName = nil
+			if c := strings.TrimSpace(exp.IngressClass); c != "" {
+				ing.Spec.IngressClassName = &c
+			}
+			ing.Spec.Rules = []networkingv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []networkingv1.HTTPIngressPath{{
+					Path:     path,
+					PathType: &pathType,
+					Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+						Name: svcName,
+						Port: networkingv1.ServiceBackendPort{Number: port},
+					}},
+				}}}},
+			}}
+			ing.Spec.TLS = nil
+			if tlsSecret != "" {
+				ing.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: tlsSecret}}
+			}
+			return controllerutil.SetControllerReference(ws, ing, r.Scheme)
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	scheme := "http"
+	if tlsSecret != "" {
+		scheme = "https"
+	}
+	return scheme + "://" + host + path, nil
+}
+
+// deploymentStrategy maps spec.strategy onto a Deployment strategy. Unset fields keep the
+// historical RollingUpdate 25%/25% default; Recreate carries no rolling parameters.
+func deploymentStrategy(st *apiv1alpha1.WorkspaceStrategy) appsv1.DeploymentStrategy {
+	if st != nil && st.Type == appsv1.RecreateDeploymentStrategyType {
+		return appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
+	surge := intstr.FromString("25%")
+	unavailable := intstr.FromString("25%")
+	if st != nil && st.MaxSurge != nil {
+		surge = *st.MaxSurge
+	}
+	if st != nil && st.MaxUnavailable != nil {
+		unavailable = *st.MaxUnavailable
+	}
+	return appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType, RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: &surge, MaxUnavailable: &unavailable}}
+}
+
+// automountToken defaults spec.automountServiceAccountToken to false so workspaces only
+// get an API token when explicitly requested.
+func automountToken(v *bool) *bool {
+	out := false
+	if v != nil {
+		out = *v
+	}
+	return &out
+}
+
+// markInvalid records a spec validation error in status and stops requeueing until the spec changes.
+func (r *WorkspaceReconciler) markInvalid(ctx context.Context, req ctrl.Request, cause error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("workspace spec invalid", "name", req.Name, "error", cause.Error())
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		fresh := &apiv1alpha1.Workspace{}
+		if gerr := r.Get(ctx, req.NamespacedName, fresh); gerr != nil {
+			return gerr
+		}
+		fresh.Status.Phase = apiv1alpha1.PhaseFailed
+		fresh.Status.LastError = cause.Error()
+		return r.Status().Update(ctx, fresh)
+	}); err != nil {
+		logger.Error(err, "status update failed")
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
 func intstrFromPort(p corev1.ContainerPort) intstr.IntOrString {
 	return intstr.FromInt(int(p.ContainerPort))
 }
@@ -527,6 +875,9 @@ func (r *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	ctx := context.Background()
 	var cm corev1.ConfigMap
 	if err := mgr.GetClient().Get(ctx, client.ObjectKey{Namespace: "guildnet-system", Name: "guildnet-cluster-settings"}, &cm); err == nil {
+		r.mu.Lock()
+		r.DefaultResources = parseDefaultResources(cm.Data)
+		r.mu.Unlock()
 		if v, ok := cm.Data["workspace_lb_enabled"]; ok {
 			vv := strings.ToLower(strings.TrimSpace(v))
 			if vv == "1" || vv == "true" || vv == "yes" {
@@ -541,12 +892,16 @@ func (r *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&apiv1alpha1.Workspace{}).
 		Owns(&appsv1.Deployment{}).
-		Owns(&corev1.Service{})
+		Owns(&corev1.Service{}).
+		Owns(&corev1.Secret{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Owns(&networkingv1.Ingress{}).
+		Owns(&batchv1.Job{})
 
 	// Start a background goroutine that polls the guildnet-cluster-settings
-	// ConfigMap in `guildnet-system` for changes. When the boolean value
-	// changes we update the cached flag and patch all Workspace objects with a
-	// short annotation (`guildnet.io/config-hash`) to force reconcile.
+	// ConfigMap in `guildnet-system` for changes. When the LB flag or the default
+	// resources change we update the cached values and patch all Workspace objects
+	// with a short annotation (`guildnet.io/config-hash`) to force reconcile.
 	go func() {
 		// use the manager's client for reads/patches
 		cli := mgr.GetClient()
@@ -572,11 +927,12 @@ func (r *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				}
 			}
 
-			if v != last {
-				last = v
+			if key := v + "|" + cm.Data[defaultRequestsKey] + "|" + cm.Data[defaultLimitsKey]; key != last {
+				last = key
 				val := v == "true"
 				r.mu.Lock()
 				r.DefaultLB = val
+				r.DefaultResources = parseDefaultResources(cm.Data)
 				r.mu.Unlock()
 
 				// Patch workspaces to trigger reconciles by setting/updating an annotation
@@ -0,0 +1,117 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	apiv1alpha1 "github.com/docxology/GuildNet/api/v1alpha1"
+)
+
+// countingReconciler returns a reconciler over a fake client holding objs, and a counter
+// of the Delete calls it issues.
+func countingReconciler(t *testing.T, objs ...client.Object) (*WorkspaceReconciler, *int) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, apiv1alpha1.AddToScheme} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deletes := 0
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deletes++
+			return c.Delete(ctx, obj, opts...)
+		},
+	}).Build()
+	return &WorkspaceReconciler{Client: cli, Scheme: scheme}, &deletes
+}
+
+func TestReconcileHPAWithoutAutoscaleSkipsDelete(t *testing.T) {
+	ctx := context.Background()
+	ws := &apiv1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "ide", Namespace: "default"}}
+	r, deletes := countingReconciler(t)
+	if hpa, err := r.reconcileHPA(ctx, ws, "ide"); hpa != nil || err != nil {
+		t.Fatalf("hpa=%v err=%v", hpa, err)
+	}
+	if *deletes != 0 {
+		t.Fatalf("deletes = %d for a workspace that never had an HPA", *deletes)
+	}
+
+	stale := &autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "ide", Namespace: "default"}}
+	r, deletes = countingReconciler(t, stale)
+	if _, err := r.reconcileHPA(ctx, ws, "ide"); err != nil {
+		t.Fatal(err)
+	}
+	if *deletes != 1 {
+		t.Fatalf("deletes = %d, want the stale HPA removed", *deletes)
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(stale), &autoscalingv2.HorizontalPodAutoscaler{}); err == nil {
+		t.Fatal("stale HPA still present")
+	}
+}
+
+func TestReconcilePDBWithoutBudgetSkipsDelete(t *testing.T) {
+	ctx := context.Background()
+	ws := &apiv1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "ide", Namespace: "default"}}
+	r, deletes := countingReconciler(t)
+	if err := r.reconcilePDB(ctx, ws, 3); err != nil {
+		t.Fatal(err)
+	}
+	if *deletes != 0 {
+		t.Fatalf("deletes = %d for a workspace that never had a PDB", *deletes)
+	}
+
+	stale := &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "ide", Namespace: "default"}}
+	r, deletes = countingReconciler(t, stale)
+	if err := r.reconcilePDB(ctx, ws, 3); err != nil {
+		t.Fatal(err)
+	}
+	if *deletes != 1 {
+		t.Fatalf("deletes = %d, want the stale PDB removed", *deletes)
+	}
+}
+
+func TestCheckPriorityClassUsesAPIReader(t *testing.T) {
+	ctx := context.Background()
+	r, _ := countingReconciler(t)
+	reader, _ := countingReconciler(t, &schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "high"}})
+	r.APIReader = reader.Client
+	if err := r.checkPriorityClass(ctx, "high"); err != nil {
+		t.Fatalf("class served by the API reader reported missing: %v", err)
+	}
+	if err := r.checkPriorityClass(ctx, "low"); err == nil {
+		t.Fatal("missing class not reported")
+	}
+}
+
+func TestReconcileIngressWithoutExposureSkipsDelete(t *testing.T) {
+	ctx := context.Background()
+	ws := &apiv1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "ide", Namespace: "default"}}
+	r, deletes := countingReconciler(t)
+	if url, err := r.reconcileIngress(ctx, ws, "ide", 8080); url != "" || err != nil {
+		t.Fatalf("url=%q err=%v", url, err)
+	}
+	if *deletes != 0 {
+		t.Fatalf("deletes = %d for a workspace that never had an Ingress", *deletes)
+	}
+
+	r, deletes = countingReconciler(t, &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "ide", Namespace: "default"}})
+	if _, err := r.reconcileIngress(ctx, ws, "ide", 8080); err != nil {
+		t.Fatal(err)
+	}
+	if *deletes != 1 {
+		t.Fatalf("deletes = %d, want the stale Ingress removed", *deletes)
+	}
+}
@@ -3,6 +3,7 @@ package orch
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -18,6 +19,9 @@ type Deps struct {
 	Secrets *secrets.Manager
 }
 
+// errMissingID fails jobs whose spec has no id; resubmitting the same spec cannot help.
+var errMissingID = errors.New("job spec has no id")
+
 // HandlerFor returns a jobs handler function for a given kind.
 func HandlerFor(kind string, deps Deps) func(ctx context.Context, j *jobs.Record, logf func(step, msg string, kv map[string]any)) {
 	switch kind {
@@ -25,76 +29,98 @@ func HandlerFor(kind string, deps Deps) func(ctx context.Context, j *jobs.Record
 		return func(ctx context.Context, j *jobs.Record, logf func(step, msg string, kv map[string]any)) {
 			var spec map[string]any
 			_ = json.Unmarshal([]byte(j.SpecJSON), &spec)
-			id := fmt.Sprint(spec["id"])
+			id, _ := spec["id"].(string)
 			if id == "" {
+				j.SetFailed(errMissingID, false)
 				return
 			}
 			mgr := headscale.New(deps.DB, deps.Secrets)
-			_ = mgr.Create(ctx, id, logf)
+			if err := mgr.Create(ctx, id, logf); err != nil {
+				j.SetFailed(err, true)
+				return
+			}
 			j.Progress = 1
 		}
 	case "headscale.start":
 		return func(ctx context.Context, j *jobs.Record, logf func(step, msg string, kv map[string]any)) {
 			var spec map[string]any
 			_ = json.Unmarshal([]byte(j.SpecJSON), &spec)
-			id := fmt.Sprint(spec["id"])
+			id, _ := spec["id"].(string)
 			if id == "" {
+				j.SetFailed(errMissingID, false)
 				return
 			}
 			mgr := headscale.New(deps.DB, deps.Secrets)
-			_ = mgr.Start(ctx, id, logf)
+			if err := mgr.Start(ctx, id, logf); err != nil {
+				j.SetFailed(err, true)
+				return
+			}
 			j.Progress = 1
 		}
 	case "headscale.stop":
 		return func(ctx context.Context, j *jobs.Record, logf func(step, msg string, kv map[string]any)) {
 			var spec map[string]any
 			_ = json.Unmarshal([]byte(j.SpecJSON), &spec)
-			id := fmt.Sprint(spec["id"])
+			id, _ := spec["id"].(string)
 			if id == "" {
+				j.SetFailed(errMissingID, false)
 				return
 			}
 			mgr := headscale.New(deps.DB, deps.Secrets)
-			_ = mgr.Stop(ctx, id, logf)
+			if err := mgr.Stop(ctx, id, logf); err != nil {
+				j.SetFailed(err, true)
+				return
+			}
 			j.Progress = 1
 		}
 	case "headscale.destroy":
 		return func(ctx context.Context, j *jobs.Record, logf func(step, msg string, kv map[string]any)) {
 			var spec map[string]any
 			_ = json.Unmarshal([]byte(j.SpecJSON), &spec)
-			id := fmt.Sprint(spec["id"])
+			id, _ := spec["id"].(string)
 			if id == "" {
+				j.SetFailed(errMissingID, false)
 				return
 			}
 			mgr := headscale.New(deps.DB, deps.Secrets)
-			_ = mgr.Destroy(ctx, id, logf)
+			if err := mgr.Destroy(ctx, id, logf); err != nil {
+				j.SetFailed(err, true)
+				return
+			}
 			j.Progress = 1
 		}
 	case "cluster.create":
 		return func(ctx context.Context, j *jobs.Record, logf func(step, msg string, kv map[string]any)) {
 			var spec map[string]any
 			_ = json.Unmarshal([]byte(j.SpecJSON), &spec)
-			id := fmt.Sprint(spec["id"])
+			id, _ := spec["id"].(string)
 			name := fmt.Sprint(spec["name"])
 			if id == "" {
+				j.SetFailed(errMissingID, false)
 				return
 			}
+			report := jobs.ReporterFrom(ctx)
+			report(10, "registering cluster")
 			logf("create", "registering cluster", map[string]any{"id": id, "name": name})
 			if deps.DB != nil {
 				var rec map[string]any
 				if err := deps.DB.Get("clusters", id, &rec); err == nil {
+					report(60, "marking cluster ready")
 					rec["state"] = "ready"
 					rec["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
 					_ = deps.DB.Put("clusters", id, rec)
 				}
 			}
+			report(100, "cluster registered")
 			j.Progress = 1
 		}
 	case "cluster.scale", "cluster.upgrade":
 		return func(ctx context.Context, j *jobs.Record, logf func(step, msg string, kv map[string]any)) {
 			var spec map[string]any
 			_ = json.Unmarshal([]byte(j.SpecJSON), &spec)
-			id := fmt.Sprint(spec["id"])
+			id, _ := spec["id"].(string)
 			if id == "" {
+				j.SetFailed(errMissingID, false)
 				return
 			}
 			action := "scale"
@@ -115,8 +141,9 @@ func HandlerFor(kind string, deps Deps) func(ctx context.Context, j *jobs.Record
 		return func(ctx context.Context, j *jobs.Record, logf func(step, msg string, kv map[string]any)) {
 			var spec map[string]any
 			_ = json.Unmarshal([]byte(j.SpecJSON), &spec)
-			id := fmt.Sprint(spec["id"])
+			id, _ := spec["id"].(string)
 			if id == "" {
+				j.SetFailed(errMissingID, false)
 				return
 			}
 			logf("op", "destroy cluster", map[string]any{"id": id})
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AnnotationAuthSecret names a Secret (in the workspace namespace) whose credentials the proxy
+// injects as an Authorization header. A "token" key yields Bearer auth; "username" and
+// "password" keys (the kubernetes.io/basic-auth layout) yield Basic auth.
+const AnnotationAuthSecret = "guildnet.io/proxy-auth-secret"
+
+// SecretGetter returns the data of the named Secret.
+type SecretGetter func(ctx context.Context, name string) (map[string][]byte, error)
+
+// AuthorizationFromSecret builds an Authorization header value from Secret data.
+// Errors never include credential material.
+func AuthorizationFromSecret(data map[string][]byte) (string, error) {
+	if tok := strings.TrimSpace(string(data["token"])); tok != "" {
+		return "Bearer " + tok, nil
+	}
+	user, pass := string(data["username"]), string(data["password"])
+	if user != "" || pass != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass)), nil
+	}
+	return "", fmt.Errorf("secret has neither token nor username/password")
+}
+
+// AuthorizationFromAnnotations resolves the Authorization header for a workspace from its
+// annotations. It returns "" when no credentials secret is configured.
+func AuthorizationFromAnnotations(ctx context.Context, ann map[string]string, get SecretGetter) (string, error) {
+	name := strings.TrimSpace(ann[AnnotationAuthSecret])
+	if name == "" {
+		return "", nil
+	}
+	data, err := get(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("read proxy auth secret %s: %w", name, err)
+	}
+	h, err := AuthorizationFromSecret(data)
+	if err != nil {
+		return "", fmt.Errorf("proxy auth secret %s: %w", name, err)
+	}
+	return h, nil
+}
+
+// AuthCache keeps resolved Authorization headers for a short time so every proxied
+// request does not read the Secret. Failed lookups are not cached.
+type AuthCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]authEntry
+}
+
+type authEntry struct {
+	header string
+	at     time.Time
+}
+
+// NewAuthCache returns a cache holding entries for ttl.
+func NewAuthCache(ttl time.Duration) *AuthCache {
+	return &AuthCache{ttl: ttl, entries: map[string]authEntry{}}
+}
+
+// Get returns the cached header for key or calls fetch and caches its result.
+func (c *AuthCache) Get(ctx context.Context, key string, fetch func(ctx context.Context) (string, error)) (string, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && time.Since(e.at) < c.ttl {
+		c.mu.Unlock()
+		return e.header, nil
+	}
+	c.mu.Unlock()
+	h, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.entries[key] = authEntry{header: h, at: time.Now()}
+	c.mu.Unlock()
+	return h, nil
+}
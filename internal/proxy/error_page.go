@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"encoding/json"
+	"html/template"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ProxyError describes a failure the proxy answers itself instead of relaying an
+// upstream response.
+type ProxyError struct {
+	Status    int    // HTTP status to send (502 for upstream failures)
+	Code      string // machine-readable code, e.g. upstream_error
+	Message   string // short human-readable summary
+	Detail    string // underlying error text
+	RequestID string
+	// RetryURL reloads the original request; every request resolves the target again, so
+	// following it picks up a restarted or rescheduled workspace.
+	RetryURL string
+}
+
+// ErrorPageFunc writes the response for a ProxyError.
+type ErrorPageFunc func(w http.ResponseWriter, r *http.Request, e ProxyError)
+
+// DefaultErrorPage answers clients that prefer HTML (browsers, the embedded IDE iframe)
+// with a small page carrying a retry link, and everyone else with the JSON error shape
+// used by the API ({code, message, request_id, details}).
+func DefaultErrorPage(w http.ResponseWriter, r *http.Request, e ProxyError) {
+	w.Header().Set("Cache-Control", "no-store")
+	if prefersHTML(r.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(e.Status)
+		_ = errorPageTmpl.Execute(w, e)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	payload := map[string]any{"code": e.Code, "message": e.Message}
+	if e.RequestID != "" {
+		payload["request_id"] = e.RequestID
+	}
+	if e.Detail != "" {
+		payload["details"] = e.Detail
+	}
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+// prefersHTML reports whether an Accept header ranks text/html above application/json.
+// Missing or wildcard-only headers get JSON.
+func prefersHTML(accept string) bool {
+	qHTML, qJSON := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		switch mt {
+		case "text/html", "application/xhtml+xml":
+			if q > qHTML {
+				qHTML = q
+			}
+		case "application/json":
+			if q > qJSON {
+				qJSON = q
+			}
+		}
+	}
+	return qHTML > 0 && qHTML >= qJSON
+}
+
+// proxyError reports a failure through Options.ErrorPage, or DefaultErrorPage.
+func (p *ReverseProxy) proxyError(w http.ResponseWriter, r *http.Request, status int, code, msg string, err error) {
+	e := ProxyError{Status: status, Code: code, Message: msg, RequestID: r.Header.Get("X-Request-Id"), RetryURL: retryURL(r)}
+	if err != nil {
+		e.Detail = err.Error()
+	}
+	page := p.opts.ErrorPage
+	if page == nil {
+		page = DefaultErrorPage
+	}
+	page(w, r, e)
+}
+
+// retryURL is the path and query the client requested, as seen before any rewriting.
+// Leading slashes are collapsed so the link cannot become a protocol-relative URL.
+func retryURL(r *http.Request) string {
+	u := r.RequestURI
+	if !strings.HasPrefix(u, "/") {
+		u = r.URL.RequestURI()
+	}
+	return "/" + strings.TrimLeft(u, "/")
+}
+
+var errorPageTmpl = template.Must(template.New("proxy-error").Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>GuildNet · {{.Message}}</title>
+<style>
+body{margin:0;min-height:100vh;display:flex;align-items:center;justify-content:center;font-family:system-ui,-apple-system,sans-serif;background:#0f172a;color:#e2e8f0}
+main{max-width:32rem;padding:2rem;text-align:center}
+h1{font-size:1.25rem;margin:0 0 .5rem}
+p{color:#94a3b8;margin:.25rem 0}
+code{font-size:.8rem;word-break:break-word}
+a{display:inline-block;margin-top:1.25rem;padding:.5rem 1.25rem;border-radius:.375rem;background:#6366f1;color:#fff;text-decoration:none}
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Message}}</h1>
+<p>The workspace did not respond. It may still be starting or restarting.</p>
+{{if .Detail}}<p><code>{{.Detail}}</code></p>{{end}}
+{{if .RequestID}}<p><small>Request {{.RequestID}}</small></p>{{end}}
+<a href="{{.RetryURL}}">Retry</a>
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,188 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultTrustedProxies is used when neither Options.TrustedProxies nor
+// GUILDNET_TRUSTED_PROXIES is set: the tailnet CGNAT range and its IPv6 prefix, so
+// Tailscale-fronted peers (e.g. a tailnet ingress) can forward headers. Loopback is
+// always trusted on top of this.
+var DefaultTrustedProxies = []string{"100.64.0.0/10", "fd7a:115c:a1e0::/48"}
+
+// parseTrusted turns IPs/CIDRs into networks. Loopback is always trusted.
+func parseTrusted(list []string) []*net.IPNet {
+	out := []*net.IPNet{
+		{IP: net.IPv4(127, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+		{IP: net.IPv6loopback, Mask: net.CIDRMask(128, 128)},
+	}
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil {
+				bits := 128
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 32
+				}
+				out = append(out, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			}
+			continue
+		}
+		if _, n, err := net.ParseCIDR(s); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// trustedFromEnv reads GUILDNET_TRUSTED_PROXIES (comma separated IPs/CIDRs).
+func trustedFromEnv() []string {
+	v := strings.TrimSpace(os.Getenv("GUILDNET_TRUSTED_PROXIES"))
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// trustedList resolves the configured list: explicit, then env, then the defaults.
+func trustedList(list []string) []string {
+	if list == nil {
+		list = trustedFromEnv()
+	}
+	if list == nil {
+		list = DefaultTrustedProxies
+	}
+	return list
+}
+
+var (
+	defaultTrustedOnce sync.Once
+	defaultTrusted     []*net.IPNet
+)
+
+// TrustedPeer reports whether r came from a peer whose X-Forwarded-* headers may be
+// believed, using GUILDNET_TRUSTED_PROXIES or DefaultTrustedProxies. Handlers outside a
+// ReverseProxy use it before deriving URLs from forwarded headers.
+func TrustedPeer(r *http.Request) bool {
+	defaultTrustedOnce.Do(func() { defaultTrusted = parseTrusted(trustedList(nil)) })
+	return containsIP(defaultTrusted, remoteIP(r.RemoteAddr))
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ReverseProxy) isTrusted(ip net.IP) bool { return containsIP(p.trusted, ip) }
+
+type forwardedPrefixKey struct{}
+
+// WithForwardedPrefix sets the X-Forwarded-Prefix the proxy sends upstream and uses for
+// rewriting. Routers that mount the proxy under another path (e.g. the cluster-scoped
+// /api/cluster/{id}/proxy/server/{name}) use it instead of the header, which is only
+// honoured from trusted peers.
+func WithForwardedPrefix(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, forwardedPrefixKey{}, prefix)
+}
+
+// forwardedPrefix returns the prefix set by WithForwardedPrefix, else the incoming
+// X-Forwarded-Prefix when the peer is trusted, else "".
+func (p *ReverseProxy) forwardedPrefix(r *http.Request) string {
+	if v, ok := r.Context().Value(forwardedPrefixKey{}).(string); ok {
+		return v
+	}
+	if p.isTrusted(remoteIP(r.RemoteAddr)) {
+		return r.Header.Get("X-Forwarded-Prefix")
+	}
+	return ""
+}
+
+// setForwardedHost sets X-Forwarded-Host/Proto on the outbound request. A trusted
+// peer's values are kept (it saw the original request); anyone else's are replaced by
+// what this hop observed.
+func (p *ReverseProxy) setForwardedHost(out *http.Request, in *http.Request) {
+	if p.isTrusted(remoteIP(in.RemoteAddr)) {
+		if out.Header.Get("X-Forwarded-Host") == "" && in.Host != "" {
+			out.Header.Set("X-Forwarded-Host", in.Host)
+		}
+		if out.Header.Get("X-Forwarded-Proto") == "" {
+			out.Header.Set("X-Forwarded-Proto", requestScheme(in))
+		}
+		return
+	}
+	out.Header.Del("X-Forwarded-Host")
+	if in.Host != "" {
+		out.Header.Set("X-Forwarded-Host", in.Host)
+	}
+	out.Header.Set("X-Forwarded-Proto", requestScheme(in))
+}
+
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func remoteIP(remoteAddr string) net.IP {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return net.ParseIP(strings.Trim(host, "[]"))
+}
+
+// setForwardedFor prepares X-Forwarded-For and X-Real-IP on the outbound request.
+// Headers from untrusted peers are dropped so clients cannot spoof their address; the
+// peer itself (for tsnet listeners, the tailnet IP) is appended to X-Forwarded-For by
+// httputil.ReverseProxy after the Director runs. X-Real-IP is the nearest untrusted hop.
+func (p *ReverseProxy) setForwardedFor(out *http.Request, in *http.Request) {
+	peer := remoteIP(in.RemoteAddr)
+	if peer == nil {
+		return
+	}
+	if !p.isTrusted(peer) {
+		out.Header.Del("X-Forwarded-For")
+		out.Header.Del("X-Real-IP")
+		out.Header.Set("X-Real-IP", peer.String())
+		return
+	}
+	if out.Header.Get("X-Real-IP") != "" {
+		return
+	}
+	var hops []string
+	for _, v := range out.Header.Values("X-Forwarded-For") {
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				hops = append(hops, h)
+			}
+		}
+	}
+	real := peer.String()
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			break
+		}
+		real = ip.String()
+		if !p.isTrusted(ip) {
+			break
+		}
+	}
+	out.Header.Set("X-Real-IP", real)
+}
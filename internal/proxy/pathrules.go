@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"path"
+	"strings"
+)
+
+// Workspace annotations holding comma-separated path prefixes enforced by the proxy.
+const (
+	AnnotationAllowPaths = "guildnet.io/proxy-allow-paths"
+	AnnotationDenyPaths  = "guildnet.io/proxy-deny-paths"
+)
+
+// PathRules restricts which upstream paths of a server may be reached through the proxy.
+// Deny prefixes always win; when Allow is non-empty only matching prefixes are forwarded.
+type PathRules struct {
+	Allow []string
+	Deny  []string
+}
+
+// PathRulesFromAnnotations builds rules from Workspace annotations. Missing annotations yield empty rules.
+func PathRulesFromAnnotations(ann map[string]string) PathRules {
+	return PathRules{
+		Allow: splitPrefixes(ann[AnnotationAllowPaths]),
+		Deny:  splitPrefixes(ann[AnnotationDenyPaths]),
+	}
+}
+
+// IsEmpty reports whether no rules are configured.
+func (pr PathRules) IsEmpty() bool { return len(pr.Allow) == 0 && len(pr.Deny) == 0 }
+
+// Allowed reports whether the given upstream path may be forwarded.
+// The path is cleaned first so "//admin" or "/x/../admin" cannot bypass a deny rule.
+func (pr PathRules) Allowed(p string) bool {
+	if pr.IsEmpty() {
+		return true
+	}
+	if p == "" {
+		p = "/"
+	}
+	p = path.Clean("/" + p)
+	for _, d := range pr.Deny {
+		if matchPrefix(p, d) {
+			return false
+		}
+	}
+	if len(pr.Allow) == 0 {
+		return true
+	}
+	for _, a := range pr.Allow {
+		if matchPrefix(p, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPrefix matches on path segment boundaries: "/admin" matches "/admin" and "/admin/x" but not "/administrator".
+func matchPrefix(p, prefix string) bool {
+	if prefix == "/" {
+		return true
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	return p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+func splitPrefixes(s string) []string {
+	var out []string
+	for _, it := range strings.Split(s, ",") {
+		it = strings.TrimSpace(it)
+		if it == "" {
+			continue
+		}
+		if !strings.HasPrefix(it, "/") {
+			it = "/" + it
+		}
+		out = append(out, path.Clean(it))
+	}
+	return out
+}
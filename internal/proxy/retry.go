@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// retryDelay is the pause before each retry, scaled by the attempt number.
+const retryDelay = 100 * time.Millisecond
+
+func (p *ReverseProxy) retries() int {
+	switch {
+	case p.opts.Retries < 0:
+		return 0
+	case p.opts.Retries == 0:
+		return DefaultRetries
+	}
+	return p.opts.Retries
+}
+
+// retryTransport retries idempotent, bodiless requests whose round trip fails before a
+// response arrives. Once headers are returned the response is handed back as-is, so a
+// body that has started streaming is never replayed.
+type retryTransport struct {
+	next      http.RoundTripper
+	max       int
+	reresolve func(req *http.Request) error
+	logger    *log.Logger
+	reqID     string
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryableRequest(req) {
+		return t.next.RoundTrip(req)
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err == nil || attempt > t.max || !retryableError(err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, err
+		case <-time.After(retryDelay * time.Duration(attempt)):
+		}
+		next := req.Clone(req.Context())
+		if t.reresolve != nil {
+			if rerr := t.reresolve(next); rerr != nil {
+				return nil, err
+			}
+		}
+		if t.logger != nil {
+			t.logger.Printf("proxy retry req_id=%s attempt=%d url=%s err=%v", t.reqID, attempt, next.URL.String(), err)
+		}
+		req = next
+	}
+}
+
+// retryableRequest reports whether req is GET/HEAD without a body to replay.
+func retryableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+// retryableError reports connection-level failures: dial errors, refused or reset
+// connections, and connections closed before the first response byte.
+func retryableError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -2,21 +2,30 @@ package proxy
 
 import (
 	"context"
-	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"log"
+	"mime"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/http/httputil"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Options struct {
+	// MaxBody caps request bodies (0 = unlimited). Bodies are streamed to the upstream,
+	// never buffered: a declared Content-Length over the cap gets 413 up front, a chunked
+	// body is cut off once it passes the cap.
 	MaxBody int64
+	// Timeout bounds bodiless requests end to end. Requests with a body (streamed uploads)
+	// are only bounded by it while dialing and, once the body is sent, while waiting for
+	// response headers; the server's read and write deadlines are cleared for them.
 	Timeout time.Duration
 	Dial    func(ctx context.Context, network, address string) (any, error)
 	Logger  *log.Logger
@@ -25,17 +34,114 @@ type Options struct {
 	// Optional: APIProxy builds a RoundTripper to reach in-cluster services via the Kubernetes API server proxy.
 	// When non-nil and the hostport appears to be a ClusterIP or *.svc address, this transport will be used.
 	APIProxy func() (http.RoundTripper, func(req *http.Request, scheme, hostport, subPath string), bool)
+	// Optional: ResolvePathRules returns per-server path allow/deny rules (typically from Workspace annotations).
+	// Checked for the /proxy/server/{id}/... form before forwarding; denied paths get 403.
+	ResolvePathRules func(ctx context.Context, serverID string) (PathRules, error)
+	// Optional: ResolveAuth returns an Authorization header value injected into upstream
+	// requests reached directly ("" for none); requests through the API server proxy keep
+	// the cluster credentials. serverID is empty for non server-aware targets. Never logged.
+	ResolveAuth func(ctx context.Context, serverID string) (string, error)
+	// Optional: ResolveStripPrefix reports whether the server ignores X-Forwarded-Prefix
+	// (typically AnnotationStripPrefix). When true the header is not forwarded and
+	// root-relative URLs in allowlisted response bodies are rewritten under the base.
+	ResolveStripPrefix func(ctx context.Context, serverID string) (bool, error)
+	// TrustedProxies lists IPs/CIDRs whose X-Forwarded-For/X-Real-IP/Host/Proto/Prefix
+	// headers are kept; other peers' are dropped and recomputed. Loopback is always
+	// trusted; nil falls back to GUILDNET_TRUSTED_PROXIES, then DefaultTrustedProxies.
+	TrustedProxies []string
+	// Retries bounds extra attempts for GET/HEAD requests whose upstream dial or first
+	// byte fails with a connection error; server targets are re-resolved before each
+	// retry. Zero uses DefaultRetries, negative disables retrying.
+	Retries int
+	// RootCAs, when set, is the only trust anchor for HTTPS upstreams (e.g. a per-cluster
+	// CA). When nil, upstreams are verified against the system roots except where
+	// InsecureUpstreamAllowed permits skipping verification.
+	RootCAs *x509.CertPool
+	// WSPingInterval is how often both legs of an upgraded WebSocket connection are
+	// pinged so idle sessions survive intermediaries' idle timeouts. Zero uses
+	// DefaultWSPingInterval; negative disables keepalive and leaves upgrades to
+	// httputil.ReverseProxy.
+	WSPingInterval time.Duration
+	// WSPongTimeout is how long a ping may go unanswered before the connection is
+	// dropped. Zero uses DefaultWSPongTimeout.
+	WSPongTimeout time.Duration
+	// ServerTiming adds a Server-Timing response header with resolve, dial and upstream
+	// first-byte durations. It exposes internal latency, so it is meant for debugging.
+	ServerTiming bool
+	// ErrorPage writes the response when the proxy fails to reach or resolve the upstream
+	// (502) or a streamed body exceeds MaxBody (413). Nil uses DefaultErrorPage: HTML with
+	// a retry link for browsers, JSON for API clients.
+	ErrorPage ErrorPageFunc
+	// StripHeaders are removed from requests before they reach the upstream, on top of
+	// the X-Guild-* control headers which are always removed. An entry ending in "*"
+	// matches a prefix ("X-Internal-*"); matching ignores case.
+	StripHeaders []string
+	// DisableSchemeFallback turns off retrying a direct upstream with the other scheme.
+	// By default an https attempt answered in plain HTTP is retried over http, and an
+	// http attempt redirected to https on the same address (or refused with a
+	// plain-HTTP-to-HTTPS 400) over https. The scheme that worked is remembered per server.
+	DisableSchemeFallback bool
+	// MaxStreamsPerServer bounds concurrent in-flight requests (including SSE and
+	// WebSocket sessions) per resolved server ID, or per target for direct forms; excess
+	// requests get 429 with Retry-After. Zero uses DefaultMaxStreamsPerServer, negative
+	// disables the limit.
+	MaxStreamsPerServer int
+	// Streams shares stream counts across proxies, for callers that build a ReverseProxy
+	// per request. Nil gives the proxy its own limiter.
+	Streams *StreamLimiter
+	// Schemes shares the schemes learned by the scheme fallback the same way. Nil gives
+	// the proxy its own cache.
+	Schemes *SchemeCache
 }
 
+// DefaultRetries is the retry bound used when Options.Retries is zero.
+const DefaultRetries = 2
+
+// expectContinueTimeout is how long a request carrying "Expect: 100-continue" waits for
+// the upstream's interim 100 before its body is sent anyway. Holding the body back lets an
+// upstream refuse a large upload (401, 413) before the client sends it; httputil relays
+// the 100 to the client, which only then starts uploading.
+const expectContinueTimeout = time.Second
+
 type ReverseProxy struct {
-	opts Options
+	opts    Options
+	trusted []*net.IPNet
+
+	// loopback is shared by all requests to loopback targets so local dev upstreams keep
+	// their connections alive across requests
+	loopbackOnce sync.Once
+	loopback     *http.Transport
+
+	// schemes caches the upstream scheme that worked after a fallback, keyed by server ID
+	// (or host:port for direct targets)
+	schemes *SchemeCache
+
+	streams *StreamLimiter
 }
 
-func NewReverseProxy(opts Options) *ReverseProxy { return &ReverseProxy{opts: opts} }
+func NewReverseProxy(opts Options) *ReverseProxy {
+	streams := opts.Streams
+	if streams == nil {
+		streams = NewStreamLimiter()
+	}
+	schemes := opts.Schemes
+	if schemes == nil {
+		schemes = NewSchemeCache()
+	}
+	return &ReverseProxy{opts: opts, trusted: parseTrusted(trustedList(opts.TrustedProxies)), streams: streams, schemes: schemes}
+}
 
 func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
 	// Attach a request id if available for correlation
 	reqID := r.Header.Get("X-Request-Id")
+	if p.opts.MaxBody > 0 && hasBody(r) {
+		if r.ContentLength > p.opts.MaxBody {
+			p.proxyError(w, r, http.StatusRequestEntityTooLarge, "body_too_large", "Request body too large", nil)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, p.opts.MaxBody)
+	}
 	q := r.URL.Query()
 	to := q.Get("to")
 	subPath := q.Get("path")
@@ -43,7 +149,7 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Also support path-based form: /proxy/{to}/<rest>
 	// When path-based form is used, ignore query "to"/"path".
-	var serverIDForAPI string
+	var serverIDForAPI, serverRest string
 	if to == "" || subPath == "" {
 		if strings.HasPrefix(r.URL.Path, "/proxy/") {
 			suffix := strings.TrimPrefix(r.URL.Path, "/proxy/")
@@ -62,16 +168,31 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				if rest == "" {
 					rest = "/"
 				}
+				if p.opts.ResolvePathRules != nil {
+					rules, err := p.opts.ResolvePathRules(r.Context(), id)
+					if err != nil {
+						p.proxyError(w, r, http.StatusBadGateway, "resolve_failed", "Server resolution failed", err)
+						return
+					}
+					if !rules.Allowed(rest) {
+						if p.opts.Logger != nil {
+							p.opts.Logger.Printf("proxy path-denied req_id=%s server=%s path=%q", reqID, id, rest)
+						}
+						p.proxyError(w, r, http.StatusForbidden, "path_denied", "Path not allowed", nil)
+						return
+					}
+				}
 				// Delegate to resolver
 				sch, hostport, path, err := p.opts.ResolveServer(r.Context(), id, rest)
 				if err != nil {
-					http.Error(w, "server resolution failed: "+err.Error(), http.StatusBadGateway)
+					p.proxyError(w, r, http.StatusBadGateway, "resolve_failed", "Server resolution failed", err)
 					return
 				}
 				scheme = sch
 				to = hostport
 				subPath = path
 				serverIDForAPI = id
+				serverRest = rest
 			} else {
 				// legacy path-based: /proxy/{to}/{rest}
 				var rest string
@@ -139,8 +260,56 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), p.opts.Timeout)
+	streamKey := to
+	if serverIDForAPI != "" {
+		streamKey = serverIDForAPI
+	}
+	release, ok := p.acquireStream(w, r, streamKey, reqID)
+	if !ok {
+		return
+	}
+	defer release()
+
+	var authz string
+	if p.opts.ResolveAuth != nil {
+		h, err := p.opts.ResolveAuth(r.Context(), serverIDForAPI)
+		if err != nil {
+			if p.opts.Logger != nil {
+				p.opts.Logger.Printf("proxy auth-unavailable req_id=%s server=%s err=%v", reqID, serverIDForAPI, err)
+			}
+			p.proxyError(w, r, http.StatusBadGateway, "auth_unavailable", "Proxy credentials unavailable", nil)
+			return
+		}
+		authz = h
+	}
+
+	var stripPrefix bool
+	if p.opts.ResolveStripPrefix != nil {
+		v, err := p.opts.ResolveStripPrefix(r.Context(), serverIDForAPI)
+		if err != nil {
+			p.proxyError(w, r, http.StatusBadGateway, "resolve_failed", "Server resolution failed", err)
+			return
+		}
+		stripPrefix = v
+	}
+
+	// A total deadline would cut off long uploads mid-stream; those rely on the dial and
+	// response header timeouts instead
+	ctx, cancel := context.WithCancel(r.Context())
+	if !hasBody(r) {
+		ctx, cancel = context.WithTimeout(r.Context(), p.opts.Timeout)
+	} else {
+		// The server's ReadTimeout/WriteTimeout would still cut the upload off
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(time.Time{})
+		_ = rc.SetWriteDeadline(time.Time{})
+	}
 	defer cancel()
+	var timing *serverTiming
+	if p.opts.ServerTiming {
+		timing = &serverTiming{resolve: time.Since(start)}
+		ctx = httptrace.WithClientTrace(ctx, timing.trace())
+	}
 
 	targetURL := &url.URL{Scheme: scheme, Host: to, Path: subPath}
 
@@ -156,6 +325,8 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	stdRT := &http.Transport{
 		Proxy: nil,
 		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			ctx, cancel := p.dialContext(ctx)
+			defer cancel()
 			c, err := p.opts.Dial(ctx, network, address)
 			if err != nil {
 				return nil, err
@@ -168,53 +339,104 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		},
 		TLSHandshakeTimeout:   10 * time.Second,
 		ResponseHeaderTimeout: p.opts.Timeout,
+		ExpectContinueTimeout: expectContinueTimeout,
 		ForceAttemptHTTP2:     false,
-		TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
+		TLSClientConfig:       UpstreamTLSConfig(upstreamHost(to), serverIDForAPI != "", p.opts.RootCAs),
+		// Pass encodings through untouched: only the client's Accept-Encoding reaches the
+		// upstream and its Content-Encoding is returned as-is. Letting the transport add
+		// gzip and transparently decode would disagree with what the client negotiated.
+		DisableCompression: true,
 	}
-	transport := http.RoundTripper(stdRT)
-	if apiRT != nil {
-		transport = &dualTransport{std: stdRT, api: apiRT}
+	transport := http.RoundTripper(&dualTransport{std: stdRT, api: apiRT, loop: p.loopbackTransport()})
+	// Upstreams may speak the other scheme than declared; an explicit ?scheme= is taken
+	// as given
+	if !p.opts.DisableSchemeFallback && (serverIDForAPI != "" || q.Get("scheme") == "") {
+		transport = &schemeFallbackTransport{next: transport, cache: p.schemes, key: streamKey, logf: p.logf, reqID: reqID}
 	}
-
-	rp := &httputil.ReverseProxy{
-		Director: func(req *http.Request) {
-			// Preserve original method, body, and most headers. Adjust URL.
-			// Mirror ProxyPreserveHost (Apache) behavior via X-Forwarded-Host/Proto for upstream awareness.
-			if r.Host != "" {
-				req.Header.Set("X-Forwarded-Host", r.Host)
-			}
-			if r.TLS != nil {
-				req.Header.Set("X-Forwarded-Proto", "https")
-			} else {
-				req.Header.Set("X-Forwarded-Proto", "http")
-			}
-			// add forwarded prefix for upstreams (code-server) to generate correct links
-			// Honor existing X-Forwarded-Prefix if provided by an upstream router (e.g., cluster-scoped prefix)
-			if req.Header.Get("X-Forwarded-Prefix") == "" {
-				if base := basePrefixFromPath(r.URL.Path); base != "" {
-					req.Header.Set("X-Forwarded-Prefix", base)
-				}
+	// applyTarget points an outbound request at target (directly or via the API proxy).
+	applyTarget := func(req *http.Request, target *url.URL) {
+		// Fast path: loopback targets (local dev, port-forwards) are dialed directly and
+		// never go through the API proxy director's pod/service discovery
+		if setAPIDirector != nil && !IsLoopbackHost(target.Host) {
+			// Control headers steer the API director; a retry gets them back from the
+			// inbound request since the first attempt stripped them
+			copyControlHeaders(req.Header, r.Header)
+			// Include the logical server ID for service/pod discovery by API proxy layer
+			if serverIDForAPI != "" {
+				req.Header.Set("X-Guild-Server-ID", serverIDForAPI)
 			}
-			if setAPIDirector != nil {
-				// Include the logical server ID for service/pod discovery by API proxy layer
-				if serverIDForAPI != "" {
-					req.Header.Set("X-Guild-Server-ID", serverIDForAPI)
+			// The API server authenticates this hop with the cluster credentials; an
+			// Authorization header here would replace them
+			req.Header.Del("Authorization")
+			setAPIDirector(req, target.Scheme, target.Host, target.Path)
+			// The director may point the request at a loopback API server (kind, k3d);
+			// it still needs the API transport and its credentials
+			setDirectTarget(req, false)
+			p.stripUpstreamHeaders(req.Header)
+			return
+		}
+		setDirectTarget(req, true)
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+		req.Host = target.Host
+		req.URL.Path = singleJoiningSlash("", target.Path)
+		// Workspace credentials only go to workspaces reached directly
+		if authz != "" {
+			req.Header.Set("Authorization", authz)
+		}
+		p.stripUpstreamHeaders(req.Header)
+	}
+	if retries := p.retries(); retries > 0 {
+		rt := &retryTransport{next: transport, max: retries, logger: p.opts.Logger, reqID: reqID}
+		if serverIDForAPI != "" && p.opts.ResolveServer != nil {
+			// Re-resolve so a retry can land on a freshly ready pod
+			rt.reresolve = func(req *http.Request) error {
+				sch, hostport, path, err := p.opts.ResolveServer(req.Context(), serverIDForAPI, serverRest)
+				if err != nil {
+					return err
 				}
-				setAPIDirector(req, targetURL.Scheme, targetURL.Host, targetURL.Path)
-			} else {
-				req.URL.Scheme = targetURL.Scheme
-				req.URL.Host = targetURL.Host
-				req.Host = targetURL.Host
-				req.URL.Path = singleJoiningSlash("", targetURL.Path)
+				applyTarget(req, &url.URL{Scheme: sch, Host: hostport, Path: path})
+				return nil
 			}
-			// Remove our control params from query string
-			q2 := req.URL.Query()
-			q2.Del("to")
-			q2.Del("path")
-			q2.Del("scheme")
-			req.URL.RawQuery = q2.Encode()
-			// Best-effort header sanitization; hop-by-hop headers will be stripped by ReverseProxy internally too.
-		},
+		}
+		transport = rt
+	}
+
+	director := func(req *http.Request) {
+		// Preserve original method, body, and most headers. Adjust URL.
+		// Mirror ProxyPreserveHost (Apache) behavior via X-Forwarded-Host/Proto for upstream awareness.
+		p.setForwardedHost(req, r)
+		p.setForwardedFor(req, r)
+		// add forwarded prefix for upstreams (code-server) to generate correct links
+		// Honor a prefix set by the router (e.g., cluster-scoped prefix) or a trusted peer
+		req.Header.Del("X-Forwarded-Prefix")
+		if stripPrefix {
+			// The app ignores the prefix; responses are rewritten instead, which needs
+			// plain-text bodies
+			req.Header.Del("Accept-Encoding")
+		} else if base := p.forwardedPrefix(r); base != "" {
+			req.Header.Set("X-Forwarded-Prefix", base)
+		} else if base := basePrefixFromPath(r.URL.Path); base != "" {
+			req.Header.Set("X-Forwarded-Prefix", base)
+		}
+		applyTarget(req, targetURL)
+		// Remove our control params from query string
+		q2 := req.URL.Query()
+		q2.Del("to")
+		q2.Del("path")
+		q2.Del("scheme")
+		req.URL.RawQuery = q2.Encode()
+		// Best-effort header sanitization; hop-by-hop headers will be stripped by ReverseProxy internally too.
+	}
+	// WebSocket upgrades get a frame-aware relay with keepalive pings; httputil would
+	// splice raw bytes and cut the session at Options.Timeout
+	if p.wsPingInterval() > 0 && isWebSocketUpgrade(r) {
+		p.serveWebSocket(w, r, director, transport, reqID)
+		return
+	}
+
+	rp := &httputil.ReverseProxy{
+		Director:  director,
 		Transport: transport,
 		ErrorHandler: func(rw http.ResponseWriter, req *http.Request, err error) {
 			if p.opts.Logger != nil {
@@ -223,7 +445,12 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				// Fallback to standard logger so errors are visible in typical stdout/stderr logs
 				log.Printf("proxy error req_id=%s method=%s url=%s to=%s path=%s err=%v", reqID, req.Method, req.URL.String(), to, subPath, err)
 			}
-			http.Error(rw, fmt.Sprintf("upstream error: %v", err), http.StatusBadGateway)
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				p.proxyError(rw, r, http.StatusRequestEntityTooLarge, "body_too_large", "Request body too large", nil)
+				return
+			}
+			p.proxyError(rw, r, http.StatusBadGateway, "upstream_error", "Workspace unavailable", err)
 		},
 		FlushInterval: 100 * time.Millisecond,
 		BufferPool:    nil,
@@ -231,6 +458,10 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Rewrite response headers for iframe/subpath compatibility (Location, Set-Cookie, CSP)
 	rp.ModifyResponse = func(resp *http.Response) error {
+		if timing != nil {
+			// Add, not Set: the upstream's own Server-Timing metrics are kept
+			resp.Header.Add("Server-Timing", timing.header())
+		}
 		// Debug: log upstream headers that affect embedding so callers can trace why an
 		// iframe may be blocked (e.g., ingress reintroducing X-Frame-Options/CSP).
 		// Emit upstream header summary to configured logger or to standard logger as a fallback
@@ -251,23 +482,31 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		// Determine baseHref from incoming path or forwarded prefix
 		base := resp.Request.Header.Get("X-Forwarded-Prefix")
+		if base == "" {
+			base = p.forwardedPrefix(r)
+		}
 		if base == "" {
 			base = basePrefixFromPath(r.URL.Path)
 			if base == "" {
 				base = "/proxy"
 			}
 		}
-		// COOP/COEP safe for embedding
+		// Bodies are only rewritten for strip-prefix servers (never 206 responses), so Range
+		// requests and 206/Content-Range/Accept-Ranges responses pass through byte-for-byte.
 		resp.Header.Del("X-Frame-Options")
-		resp.Header.Set("Cross-Origin-Opener-Policy", "same-origin-allow-popups")
-		// Avoid requiring cross-origin isolation which can break iframe subresources
-		resp.Header.Del("Cross-Origin-Embedder-Policy")
 		resp.Header.Set("Cross-Origin-Resource-Policy", "cross-origin")
-		// Relax CSP for frame-ancestors; if none, add permissive
-		if csp := resp.Header.Get("Content-Security-Policy"); csp != "" {
-			resp.Header.Set("Content-Security-Policy", relaxFrameAncestors(csp))
-		} else {
-			resp.Header.Set("Content-Security-Policy", "frame-ancestors *")
+		// Document-level embedding policies only make sense on HTML pages
+		if isHTMLResponse(resp) {
+			// COOP/COEP safe for embedding
+			resp.Header.Set("Cross-Origin-Opener-Policy", "same-origin-allow-popups")
+			// Avoid requiring cross-origin isolation which can break iframe subresources
+			resp.Header.Del("Cross-Origin-Embedder-Policy")
+			// Relax CSP for frame-ancestors; if none, add permissive
+			if csp := resp.Header.Get("Content-Security-Policy"); csp != "" {
+				resp.Header.Set("Content-Security-Policy", relaxFrameAncestors(csp))
+			} else {
+				resp.Header.Set("Content-Security-Policy", "frame-ancestors *")
+			}
 		}
 		// Ensure service worker can scope itself under the proxy base
 		// Use only the path component of base
@@ -283,6 +522,11 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if resp.Header.Get("Referrer-Policy") == "" {
 			resp.Header.Set("Referrer-Policy", "no-referrer")
 		}
+		if stripPrefix {
+			if err := rewriteResponseBody(resp, basePath); err != nil {
+				return err
+			}
+		}
 		if loc := resp.Header.Get("Location"); loc != "" {
 			resp.Header.Set("Location", rewriteLocation(loc, base))
 		}
@@ -300,6 +544,25 @@ func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	rp.ServeHTTP(w, r.WithContext(ctx))
 }
 
+// hasBody reports whether r carries a request body, declared or chunked.
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
+
+// dialContext bounds a dial by Options.Timeout; upload requests carry no overall deadline.
+func (p *ReverseProxy) dialContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.opts.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.opts.Timeout)
+}
+
+// isHTMLResponse reports whether resp carries an HTML document.
+func isHTMLResponse(resp *http.Response) bool {
+	mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && (mt == "text/html" || mt == "application/xhtml+xml")
+}
+
 // singleJoiningSlash returns a/b ensuring only one slash joins.
 func singleJoiningSlash(a, b string) string {
 	aslash := strings.HasSuffix(a, "/")
@@ -344,25 +607,46 @@ func basePrefixFromPath(pth string) string {
 //
 
 // rewriteLocation rewrites absolute or root-relative Location headers to stay under the proxy baseHref.
+// Redirects that land on the base itself are normalized to "base/" so that SPAs redirecting
+// "/app" -> "/app/" (or to the forwarded prefix without a slash) cannot escape the prefix.
 func rewriteLocation(loc string, baseHref string) string {
 	if loc == "" {
 		return loc
 	}
-	// Absolute URL: replace scheme+host and keep path
-	if u, err := url.Parse(loc); err == nil && u.IsAbs() {
-		// If upstream redirects to "/" or absolute path, map into baseHref
-		if u.Path == "" || strings.HasPrefix(u.Path, "/") {
-			return strings.TrimRight(baseHref, "/") + u.Path
-		}
-		// fallback: join
-		return strings.TrimRight(baseHref, "/") + "/" + u.String()
+	base := strings.TrimRight(baseHref, "/")
+	u, err := url.Parse(loc)
+	if err != nil {
+		return base + "/" + strings.TrimLeft(loc, "/")
 	}
-	// Root-relative path
-	if strings.HasPrefix(loc, "/") {
-		return strings.TrimRight(baseHref, "/") + loc
+	// Absolute URL or root-relative path: replace scheme+host and keep path/query
+	if u.IsAbs() || strings.HasPrefix(loc, "/") {
+		if u.IsAbs() && u.Path != "" && !strings.HasPrefix(u.Path, "/") {
+			// opaque or odd absolute form; fallback: join
+			return base + "/" + u.String()
+		}
+		out := underBase(base, u.EscapedPath())
+		if u.RawQuery != "" {
+			out += "?" + u.RawQuery
+		}
+		if u.Fragment != "" {
+			out += "#" + u.EscapedFragment()
+		}
+		return out
 	}
 	// Relative path: join with base
-	return strings.TrimRight(baseHref, "/") + "/" + loc
+	return base + "/" + loc
+}
+
+// underBase maps an upstream path into base. Paths that already carry the base (upstreams
+// honoring X-Forwarded-Prefix) are kept, and the bare base gets its trailing slash.
+func underBase(base, p string) string {
+	switch {
+	case p == "" || p == "/" || p == base:
+		return base + "/"
+	case strings.HasPrefix(p, base+"/"):
+		return p
+	}
+	return base + p
 }
 
 // Note: We no longer rewrite Set-Cookie to avoid interfering with upstream auth flows.
@@ -474,10 +758,79 @@ func rewriteSetCookieForIframe(h string, baseHref string) string {
 	return strings.Join(out, "; ")
 }
 
-// dualTransport chooses API transport for Kubernetes API server endpoints and std for direct/PF/ClusterIP.
-type dualTransport struct{ std, api http.RoundTripper }
+// upstreamHost strips the port from a host:port target.
+func upstreamHost(hostport string) string {
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		return h
+	}
+	return hostport
+}
+
+// IsLoopbackHost reports whether hostport ("host:port" or a bare host) names localhost
+// or a loopback IP.
+func IsLoopbackHost(hostport string) bool {
+	host := strings.TrimSuffix(strings.ToLower(upstreamHost(hostport)), ".")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// loopbackTransport returns the transport shared by requests to loopback targets.
+func (p *ReverseProxy) loopbackTransport() *http.Transport {
+	p.loopbackOnce.Do(func() {
+		p.loopback = &http.Transport{
+			Proxy: nil,
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				ctx, cancel := p.dialContext(ctx)
+				defer cancel()
+				if p.opts.Dial == nil {
+					var d net.Dialer
+					return d.DialContext(ctx, network, address)
+				}
+				c, err := p.opts.Dial(ctx, network, address)
+				if err != nil {
+					return nil, err
+				}
+				conn, ok := c.(net.Conn)
+				if !ok {
+					return nil, errors.New("dialer returned non-Conn")
+				}
+				return conn, nil
+			},
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: p.opts.Timeout,
+			ExpectContinueTimeout: expectContinueTimeout,
+			ForceAttemptHTTP2:     false,
+			TLSClientConfig:       UpstreamTLSConfig("localhost", false, p.opts.RootCAs),
+			DisableCompression:    true,
+			MaxIdleConnsPerHost:   16,
+			IdleConnTimeout:       90 * time.Second,
+		}
+	})
+	return p.loopback
+}
+
+// directTargetKey marks an outbound request that applyTarget sent to its target directly
+// rather than through the API proxy director.
+type directTargetKey struct{}
+
+// setDirectTarget records on req whether applyTarget dialed its target directly. A retry
+// clones the previous attempt, so the flag is always set explicitly.
+func setDirectTarget(req *http.Request, direct bool) {
+	*req = *req.WithContext(context.WithValue(req.Context(), directTargetKey{}, direct))
+}
+
+// dualTransport routes loopback targets reached directly to loop, Kubernetes API server
+// endpoints to api, and everything else (direct/PF/ClusterIP) to std.
+type dualTransport struct{ std, api, loop http.RoundTripper }
 
 func (d *dualTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	direct, _ := req.Context().Value(directTargetKey{}).(bool)
+	if d.loop != nil && direct && IsLoopbackHost(req.URL.Host) {
+		return d.loop.RoundTrip(req)
+	}
 	p := req.URL.Path
 	// Use API transport only when talking to the kube-apiserver endpoints
 	if d.api != nil && (strings.HasPrefix(p, "/api/") || strings.HasPrefix(p, "/apis/")) {
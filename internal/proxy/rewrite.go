@@ -0,0 +1,151 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AnnotationStripPrefix marks a workspace whose app ignores X-Forwarded-Prefix. For such
+// servers the proxy stops sending the header and instead rewrites root-relative URLs in
+// HTML, JavaScript and CSS responses to live under the proxy base.
+const AnnotationStripPrefix = "guildnet.io/proxy-strip-prefix"
+
+// MaxRewriteBody bounds the response bodies buffered for rewriting; larger bodies pass
+// through unchanged.
+const MaxRewriteBody = 8 << 20
+
+// StripPrefixFromAnnotations reports whether AnnotationStripPrefix is enabled.
+func StripPrefixFromAnnotations(ann map[string]string) bool {
+	v, err := strconv.ParseBool(strings.TrimSpace(ann[AnnotationStripPrefix]))
+	return err == nil && v
+}
+
+// rewriteKind classifies a response Content-Type against the rewrite allowlist.
+func rewriteKind(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mt {
+	case "text/html", "application/xhtml+xml":
+		return "html"
+	case "text/javascript", "application/javascript", "application/x-javascript", "application/ecmascript":
+		return "js"
+	case "text/css":
+		return "css"
+	}
+	return ""
+}
+
+// Each pattern ends on the root-relative slash (submatch 1 is the slash) followed by one
+// character that rules out protocol-relative "//host" URLs.
+var (
+	// quoted strings: "/x", '/x', `/x` (attributes in HTML, string literals in JS)
+	quotedRootRel = regexp.MustCompile("[\"'`](/)(?:[^/]|$)")
+	// unquoted HTML attributes: href=/x
+	attrRootRel = regexp.MustCompile(`=(/)[^/\s>"']`)
+	// CSS url(/x) and url("/x")
+	cssURLRootRel = regexp.MustCompile(`url\(\s*["']?(/)[^/]`)
+	// JavaScript string literals that are a whole URL path: "/x/y?z", no spaces, escapes
+	// or template substitutions. Literals like "/" or "/ " (separators, messages) stay as is.
+	jsURLRootRel = []*regexp.Regexp{
+		regexp.MustCompile(`"(/)[\w.~%-][^"\s\\]*"`),
+		regexp.MustCompile(`'(/)[\w.~%-][^'\s\\]*'`),
+		regexp.MustCompile("`(/)[\\w.~%-][^`\\s\\\\$]*`"),
+	}
+)
+
+// rewriteRootRelative prefixes root-relative URLs in body with base. kind selects the
+// patterns: JavaScript only has string literals holding a whole URL path rewritten, since
+// a bare "/" may start a regular expression and other strings may merely contain a slash.
+// URLs already under base are left alone.
+func rewriteRootRelative(body []byte, base, kind string) []byte {
+	base = strings.TrimRight(base, "/")
+	if base == "" {
+		return body
+	}
+	var pats []*regexp.Regexp
+	switch kind {
+	case "html":
+		pats = []*regexp.Regexp{quotedRootRel, attrRootRel, cssURLRootRel}
+	case "js":
+		pats = jsURLRootRel
+	case "css":
+		pats = []*regexp.Regexp{quotedRootRel, cssURLRootRel}
+	default:
+		return body
+	}
+	// Collect slash offsets from all patterns, then splice in one pass
+	at := map[int]bool{}
+	for _, re := range pats {
+		for _, m := range re.FindAllSubmatchIndex(body, -1) {
+			at[m[2]] = true
+		}
+	}
+	if len(at) == 0 {
+		return body
+	}
+	var out bytes.Buffer
+	out.Grow(len(body) + len(at)*len(base))
+	for i := 0; i < len(body); i++ {
+		if at[i] && !underBasePrefix(body[i:], base) {
+			out.WriteString(base)
+		}
+		out.WriteByte(body[i])
+	}
+	return out.Bytes()
+}
+
+// underBasePrefix reports whether rest (starting at a slash) already begins with base as
+// a whole path segment, as emitted by apps that do honor the prefix.
+func underBasePrefix(rest []byte, base string) bool {
+	if !bytes.HasPrefix(rest, []byte(base)) {
+		return false
+	}
+	if len(rest) == len(base) {
+		return true
+	}
+	switch c := rest[len(base)]; {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		return false
+	}
+	return true
+}
+
+// rewriteResponseBody applies rewriteRootRelative to an allowlisted, uncompressed, full
+// (non-206) response no larger than MaxRewriteBody, fixing up Content-Length.
+func rewriteResponseBody(resp *http.Response, base string) error {
+	kind := rewriteKind(resp.Header.Get("Content-Type"))
+	if kind == "" || resp.StatusCode == http.StatusPartialContent || resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+	if ce := resp.Header.Get("Content-Encoding"); ce != "" && !strings.EqualFold(ce, "identity") {
+		return nil
+	}
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, MaxRewriteBody+1))
+	if err != nil {
+		return err
+	}
+	if len(buf) > MaxRewriteBody {
+		// Too large to buffer: stream the original bytes through untouched
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+		return nil
+	}
+	_ = resp.Body.Close()
+	out := rewriteRootRelative(buf, base, kind)
+	resp.Body = io.NopCloser(bytes.NewReader(out))
+	resp.ContentLength = int64(len(out))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(out)))
+	// Validators describe the upstream bytes, not ours
+	resp.Header.Del("ETag")
+	resp.Header.Del("Accept-Ranges")
+	return nil
+}
@@ -0,0 +1,197 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// SchemeCache remembers the upstream scheme that worked after a fallback, per server. A
+// ReverseProxy owns one unless Options.Schemes shares one across proxies that are built
+// per request.
+type SchemeCache struct{ m sync.Map }
+
+// NewSchemeCache returns an empty cache.
+func NewSchemeCache() *SchemeCache { return &SchemeCache{} }
+
+func (c *SchemeCache) load(key string) (string, bool) {
+	v, ok := c.m.Load(key)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+func (c *SchemeCache) store(key, scheme string) { c.m.Store(key, scheme) }
+
+// schemeFallbackTransport retries a request once with the other scheme when the upstream
+// evidently speaks it: an https attempt whose TLS handshake gets a plain-HTTP reply, or
+// an http attempt answered with a redirect to https on the same host:port or with a
+// "plain HTTP sent to an HTTPS port" 400. The scheme that worked is cached per key, so
+// later requests (including ones with bodies, which are never replayed) go straight to it.
+// Requests through the API server's service or pod proxy carry the scheme in their path;
+// there the failed handshake comes back as the API server's 5xx error.
+type schemeFallbackTransport struct {
+	next  http.RoundTripper
+	cache *SchemeCache
+	key   string
+	logf  func(format string, args ...any)
+	reqID string
+}
+
+func (t *schemeFallbackTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if v, ok := t.cache.load(t.key); ok && v != targetScheme(req) {
+		req = withScheme(req, v)
+	}
+	resp, err := t.next.RoundTrip(req)
+	scheme, viaAPI := apiProxyScheme(req.URL.Path)
+	if !viaAPI {
+		scheme = req.URL.Scheme
+	}
+	alt := ""
+	switch {
+	case err != nil && scheme == "https" && !viaAPI && isPlainHTTPReply(err):
+		alt = "http"
+	case err == nil && scheme == "https" && viaAPI:
+		var plain bool
+		if resp, plain = apiReportsPlainHTTP(resp); plain {
+			alt = "http"
+		}
+	case err == nil && scheme == "http":
+		var wantsTLS bool
+		resp, wantsTLS = expectsHTTPS(req, resp)
+		if wantsTLS {
+			alt = "https"
+		}
+	}
+	if alt == "" || (hasBody(req) && req.GetBody == nil) || req.Context().Err() != nil {
+		return resp, err
+	}
+	next := withScheme(req, alt)
+	if hasBody(req) {
+		body, berr := req.GetBody()
+		if berr != nil {
+			return resp, err
+		}
+		next.Body = body
+	}
+	altResp, altErr := t.next.RoundTrip(next)
+	if altErr != nil {
+		return resp, err
+	}
+	if alt == "http" {
+		var loop bool
+		if altResp, loop = expectsHTTPS(next, altResp); loop {
+			// Each scheme points at the other; keep the original error
+			altResp.Body.Close()
+			return resp, err
+		}
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	t.cache.store(t.key, alt)
+	if t.logf != nil {
+		t.logf("proxy scheme-fallback req_id=%s key=%s from=%s to=%s", t.reqID, t.key, scheme, alt)
+	}
+	return altResp, nil
+}
+
+// apiProxyPath matches the scheme segment of an API server service or pod proxy path,
+// e.g. /api/v1/namespaces/ns/services/http:name:8080/proxy.
+var apiProxyPath = regexp.MustCompile(`/(?:services|pods)/(https?):[^/]+/proxy(?:/|$)`)
+
+// apiProxyScheme returns the upstream scheme named in an API server proxy path.
+func apiProxyScheme(path string) (string, bool) {
+	m := apiProxyPath.FindStringSubmatchIndex(path)
+	if m == nil {
+		return "", false
+	}
+	return path[m[2]:m[3]], true
+}
+
+// targetScheme returns the scheme req reaches its upstream with.
+func targetScheme(req *http.Request) string {
+	if s, ok := apiProxyScheme(req.URL.Path); ok {
+		return s
+	}
+	return req.URL.Scheme
+}
+
+// withScheme returns a shallow clone of req aimed at scheme: the scheme segment of an API
+// server proxy path, or else the URL scheme.
+func withScheme(req *http.Request, scheme string) *http.Request {
+	out := req.Clone(req.Context())
+	if m := apiProxyPath.FindStringSubmatchIndex(out.URL.Path); m != nil {
+		out.URL.Path = out.URL.Path[:m[2]] + scheme + out.URL.Path[m[3]:]
+		out.URL.RawPath = ""
+		return out
+	}
+	out.URL.Scheme = scheme
+	return out
+}
+
+// isPlainHTTPReply reports a TLS handshake answered by a plain-HTTP server.
+func isPlainHTTPReply(err error) bool {
+	var rh tls.RecordHeaderError
+	return errors.As(err, &rh)
+}
+
+// apiTLSMarker is the Go TLS error the API server relays (as a 5xx) when its https proxy
+// request reaches a plain-HTTP upstream.
+const apiTLSMarker = "first record does not look like a TLS handshake"
+
+// apiReportsPlainHTTP reports whether resp is the API server's error for an https proxy
+// request answered in plain HTTP. Like expectsHTTPS it returns the response to use.
+func apiReportsPlainHTTP(resp *http.Response) (*http.Response, bool) {
+	if resp.StatusCode < 500 || resp.Body == nil {
+		return resp, false
+	}
+	resp, head := peekBody(resp)
+	return resp, bytes.Contains(head, []byte(apiTLSMarker))
+}
+
+// peekBody returns up to 512 bytes of resp's body and a response that still reads all of it.
+func peekBody(resp *http.Response) (*http.Response, []byte) {
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(resp.Body, head)
+	head = head[:n]
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	return resp, head
+}
+
+// plainToTLSMarkers are the 400 bodies TLS servers send back to plain-HTTP requests
+// (Go's net/http and nginx).
+var plainToTLSMarkers = []string{"HTTP request to an HTTPS server", "plain HTTP request was sent to HTTPS port"}
+
+// expectsHTTPS reports whether resp to the http request req says the upstream wants TLS:
+// a redirect to https on the same host:port, or a 400 carrying a known marker. The
+// returned response replaces resp, since the 400 check peeks at the body.
+func expectsHTTPS(req *http.Request, resp *http.Response) (*http.Response, bool) {
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		loc, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil || !strings.EqualFold(loc.Scheme, "https") {
+			return resp, false
+		}
+		return resp, strings.EqualFold(loc.Host, req.URL.Host) || strings.EqualFold(loc.Host, req.Host)
+	case resp.StatusCode == http.StatusBadRequest && resp.Body != nil:
+		var head []byte
+		resp, head = peekBody(resp)
+		for _, m := range plainToTLSMarkers {
+			if bytes.Contains(head, []byte(m)) {
+				return resp, true
+			}
+		}
+	}
+	return resp, false
+}
@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// serverTiming collects the latency breakdown reported in the Server-Timing header when
+// Options.ServerTiming is set: resolve covers routing, path rules and target resolution;
+// dial is connection setup (near zero for a reused connection); upstream is the wait from
+// having a connection to the first response byte. Retries overwrite earlier attempts.
+type serverTiming struct {
+	mu        sync.Mutex
+	resolve   time.Duration
+	dial      time.Duration
+	upstream  time.Duration
+	getConn   time.Time
+	gotConn   time.Time
+	connected bool
+}
+
+// trace returns hooks recording dial and first-byte times. Transports may call them from
+// other goroutines.
+func (st *serverTiming) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			st.mu.Lock()
+			st.getConn = time.Now()
+			st.connected = false
+			st.mu.Unlock()
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			st.mu.Lock()
+			st.gotConn = time.Now()
+			st.dial = st.gotConn.Sub(st.getConn)
+			st.connected = true
+			st.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			st.mu.Lock()
+			if st.connected {
+				st.upstream = time.Since(st.gotConn)
+			}
+			st.mu.Unlock()
+		},
+	}
+}
+
+// header formats the timings as a Server-Timing value, durations in milliseconds.
+func (st *serverTiming) header() string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return fmt.Sprintf("resolve;dur=%.1f, dial;dur=%.1f, upstream;dur=%.1f", ms(st.resolve), ms(st.dial), ms(st.upstream))
+}
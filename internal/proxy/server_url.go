@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ServerURLBaseRequest makes ServerURL derive an absolute origin from the incoming request.
+const ServerURLBaseRequest = "request"
+
+// ServerURL returns the proxy link for a workspace server:
+// /api/cluster/{id}/proxy/server/{name}/ (or /proxy/server/{name}/ without a cluster).
+// An empty base yields a relative link; ServerURLBaseRequest uses the request origin
+// (honouring X-Forwarded-Proto/Host from trusted peers); any other value is used as the
+// origin.
+func ServerURL(base string, r *http.Request, clusterID, name string) string {
+	p := "/proxy/server/" + url.PathEscape(name) + "/"
+	if clusterID != "" {
+		p = "/api/cluster/" + url.PathEscape(clusterID) + p
+	}
+	base = strings.TrimSpace(base)
+	if base == ServerURLBaseRequest {
+		base = ""
+		if r != nil {
+			base = requestOrigin(r)
+		}
+	}
+	return strings.TrimRight(base, "/") + p
+}
+
+func requestOrigin(r *http.Request) string {
+	scheme := requestScheme(r)
+	host := r.Host
+	if TrustedPeer(r) {
+		if strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+			scheme = "https"
+		}
+		if h := r.Header.Get("X-Forwarded-Host"); h != "" {
+			host = h
+		}
+	}
+	if host == "" {
+		return ""
+	}
+	return scheme + "://" + host
+}
@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"net/http"
+	"sync"
+)
+
+// DefaultMaxStreamsPerServer is the per-server concurrent stream bound used when
+// Options.MaxStreamsPerServer is zero. It is generous on purpose: an IDE tab keeps a
+// handful of WebSocket/SSE streams open, so this only stops runaway clients.
+const DefaultMaxStreamsPerServer = 256
+
+// streamRetryAfter is the Retry-After value (seconds) sent with a 429.
+const streamRetryAfter = "5"
+
+// StreamLimiter counts in-flight proxied requests (plain requests, SSE and WebSocket
+// sessions alike) per server key. A ReverseProxy owns one unless Options.Streams shares
+// one across proxies that are built per request.
+type StreamLimiter struct {
+	mu     sync.Mutex
+	active map[string]int
+}
+
+// NewStreamLimiter returns an empty limiter.
+func NewStreamLimiter() *StreamLimiter {
+	return &StreamLimiter{active: map[string]int{}}
+}
+
+// Active reports how many streams are open for key.
+func (l *StreamLimiter) Active(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active[key]
+}
+
+// acquire takes a slot for key unless max are already open. The release func must be
+// called exactly once when the stream ends.
+func (l *StreamLimiter) acquire(key string, max int) (func(), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[key] >= max {
+		return nil, false
+	}
+	l.active[key]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.active[key]--; l.active[key] <= 0 {
+				delete(l.active, key)
+			}
+		})
+	}, true
+}
+
+func (p *ReverseProxy) maxStreams() int {
+	if p.opts.MaxStreamsPerServer == 0 {
+		return DefaultMaxStreamsPerServer
+	}
+	return p.opts.MaxStreamsPerServer
+}
+
+// acquireStream takes a stream slot for key, answering 429 when the server is at its
+// limit. A negative limit disables the check.
+func (p *ReverseProxy) acquireStream(w http.ResponseWriter, r *http.Request, key, reqID string) (release func(), ok bool) {
+	max := p.maxStreams()
+	if max < 0 {
+		return func() {}, true
+	}
+	release, ok = p.streams.acquire(key, max)
+	if !ok {
+		p.logf("proxy stream-limit req_id=%s key=%s max=%d", reqID, key, max)
+		w.Header().Set("Retry-After", streamRetryAfter)
+		p.proxyError(w, r, http.StatusTooManyRequests, "too_many_streams", "Too many concurrent streams to this server", nil)
+	}
+	return release, ok
+}
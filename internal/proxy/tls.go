@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+)
+
+// InsecureUpstreamAllowed reports whether certificate verification may be skipped for an
+// upstream host. Only loopback targets (port-forwards, local dev) and in-cluster services
+// qualify: *.svc names, or private IPs returned for a resolved server target (ClusterIPs).
+// Anything else, including tailnet and public addresses, must present a valid certificate.
+func InsecureUpstreamAllowed(host string, serverTarget bool) bool {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	if strings.HasSuffix(host, ".svc") || strings.HasSuffix(host, ".svc.cluster.local") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+	return serverTarget && ip.IsPrivate()
+}
+
+// UpstreamTLSConfig returns the client TLS config for an upstream host. A non-nil roots
+// pool (e.g. a cluster CA from settings) always verifies against that pool; otherwise
+// verification uses the system roots unless InsecureUpstreamAllowed permits skipping it.
+func UpstreamTLSConfig(host string, serverTarget bool, roots *x509.CertPool) *tls.Config {
+	if roots != nil {
+		return &tls.Config{RootCAs: roots}
+	}
+	if InsecureUpstreamAllowed(host, serverTarget) {
+		return &tls.Config{InsecureSkipVerify: true}
+	}
+	return &tls.Config{}
+}
+
+// CertPoolFromPEM parses PEM certificates into a pool; empty input returns nil.
+func CertPoolFromPEM(pem string) (*x509.CertPool, error) {
+	if strings.TrimSpace(pem) == "" {
+		return nil, nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(pem)) {
+		return nil, errors.New("no valid certificates in CA bundle")
+	}
+	return pool, nil
+}
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ControlHeaderPrefix marks the headers that steer routing inside the hostapp
+// (X-Guild-Server-ID, X-Guild-Prefer-Pod, X-Guild-Use-PortForward, ...). The upstream app
+// never sees them.
+const ControlHeaderPrefix = "X-Guild-"
+
+// isControlHeader reports whether the canonical header name k is a control header.
+func isControlHeader(k string) bool {
+	return len(k) >= len(ControlHeaderPrefix) && strings.EqualFold(k[:len(ControlHeaderPrefix)], ControlHeaderPrefix)
+}
+
+// copyControlHeaders sets the control headers of src on dst.
+func copyControlHeaders(dst, src http.Header) {
+	for k, vv := range src {
+		if isControlHeader(k) {
+			dst[k] = append([]string(nil), vv...)
+		}
+	}
+}
+
+// stripUpstreamHeaders removes control headers and Options.StripHeaders from h. It runs
+// last in the director, after the API proxy director has read the control headers.
+func (p *ReverseProxy) stripUpstreamHeaders(h http.Header) {
+	for k := range h {
+		if isControlHeader(k) || matchHeaderList(p.opts.StripHeaders, k) {
+			delete(h, k)
+		}
+	}
+}
+
+// matchHeaderList reports whether name matches an entry of list: exactly, or by prefix
+// for entries ending in "*". Case is ignored.
+func matchHeaderList(list []string, name string) bool {
+	for _, e := range list {
+		e = strings.TrimSpace(e)
+		if prefix, ok := strings.CutSuffix(e, "*"); ok {
+			if prefix != "" && len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				return true
+			}
+			continue
+		}
+		if e != "" && strings.EqualFold(e, name) {
+			return true
+		}
+	}
+	return false
+}
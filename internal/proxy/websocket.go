@@ -0,0 +1,323 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultWSPingInterval is how often idle upgraded WebSocket connections are pinged
+	// when Options.WSPingInterval is zero. It stays under the 30-60s idle timeouts common
+	// in load balancers and ingress controllers.
+	DefaultWSPingInterval = 25 * time.Second
+	// DefaultWSPongTimeout bounds the wait for a pong when Options.WSPongTimeout is zero.
+	DefaultWSPongTimeout = 15 * time.Second
+)
+
+// wsPingPayload tags the proxy's own pings so their pongs are consumed here instead of
+// being forwarded to the other side.
+var wsPingPayload = []byte("guildnet-proxy-keepalive")
+
+// WebSocket opcodes used by the frame pump (RFC 6455 section 5.2).
+const (
+	wsOpPing = 0x9
+	wsOpPong = 0xA
+)
+
+func (p *ReverseProxy) wsPingInterval() time.Duration {
+	switch {
+	case p.opts.WSPingInterval < 0:
+		return 0
+	case p.opts.WSPingInterval == 0:
+		return DefaultWSPingInterval
+	}
+	return p.opts.WSPingInterval
+}
+
+func (p *ReverseProxy) wsPongTimeout() time.Duration {
+	if p.opts.WSPongTimeout > 0 {
+		return p.opts.WSPongTimeout
+	}
+	return DefaultWSPongTimeout
+}
+
+// isWebSocketUpgrade reports whether r asks to switch to the WebSocket protocol.
+func isWebSocketUpgrade(r *http.Request) bool {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	for _, v := range r.Header.Values("Connection") {
+		for _, tok := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(tok), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hopHeaders are stripped from the upstream handshake; Connection and Upgrade are set
+// again explicitly.
+var hopHeaders = []string{"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// serveWebSocket proxies a WebSocket upgrade itself instead of through
+// httputil.ReverseProxy, which only splices raw bytes. Frames are relayed one at a time
+// so keepalive pings can be slipped in between them on both legs; a leg that stops
+// answering pings gets the whole connection closed. Only the handshake is bounded by
+// Options.Timeout.
+func (p *ReverseProxy) serveWebSocket(w http.ResponseWriter, r *http.Request, director func(*http.Request), transport http.RoundTripper, reqID string) {
+	hctx, cancel := context.WithTimeout(r.Context(), p.opts.Timeout)
+	defer cancel()
+	out := r.Clone(hctx)
+	out.RequestURI = ""
+	out.Close = false
+	for _, h := range hopHeaders {
+		out.Header.Del(h)
+	}
+	out.Header.Set("Connection", "Upgrade")
+	out.Header.Set("Upgrade", "websocket")
+	director(out)
+
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		p.logf("proxy ws-error req_id=%s url=%s err=%v", reqID, out.URL.String(), err)
+		p.proxyError(w, r, http.StatusBadGateway, "upstream_error", "Workspace unavailable", err)
+		return
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		// Handshake refused (auth, 404, ...): relay the response as-is
+		defer resp.Body.Close()
+		for k, vv := range resp.Header {
+			for _, v := range vv {
+				w.Header().Add(k, v)
+			}
+		}
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+		return
+	}
+	backConn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		http.Error(w, "upstream connection is not writable", http.StatusBadGateway)
+		return
+	}
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		backConn.Close()
+		http.Error(w, "websocket upgrade not supported", http.StatusInternalServerError)
+		return
+	}
+	resp.Body = nil
+	if err := resp.Write(brw); err == nil {
+		err = brw.Flush()
+	}
+	if err != nil {
+		p.logf("proxy ws-error req_id=%s handshake write err=%v", reqID, err)
+		conn.Close()
+		backConn.Close()
+		return
+	}
+
+	client := &wsLeg{name: "client", rwc: conn, r: brw.Reader}
+	upstream := &wsLeg{name: "upstream", rwc: backConn, r: bufio.NewReader(backConn), mask: true}
+	s := &wsSession{done: make(chan struct{})}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); s.stop(pumpFrames(client, upstream)) }()
+	go func() { defer wg.Done(); s.stop(pumpFrames(upstream, client)) }()
+	if interval := p.wsPingInterval(); interval > 0 {
+		timeout := p.wsPongTimeout()
+		for _, leg := range []*wsLeg{client, upstream} {
+			wg.Add(1)
+			go func(leg *wsLeg) {
+				defer wg.Done()
+				if err := leg.keepalive(s.done, interval, timeout); err != nil {
+					p.logf("proxy ws-keepalive req_id=%s closing: %v", reqID, err)
+					s.stop(err)
+				}
+			}(leg)
+		}
+	}
+	<-s.done
+	conn.Close()
+	backConn.Close()
+	wg.Wait()
+}
+
+func (p *ReverseProxy) logf(format string, args ...any) {
+	if p.opts.Logger != nil {
+		p.opts.Logger.Printf(format, args...)
+	}
+}
+
+// wsSession ends once, when either pump or keepalive gives up.
+type wsSession struct {
+	once sync.Once
+	done chan struct{}
+}
+
+func (s *wsSession) stop(error) { s.once.Do(func() { close(s.done) }) }
+
+// wsLeg is one side of a proxied WebSocket connection. Writes take wmu for a whole
+// frame so injected pings never split a relayed frame.
+type wsLeg struct {
+	name string
+	rwc  io.ReadWriteCloser
+	r    *bufio.Reader
+	// mask is set when the proxy acts as the client on this leg; RFC 6455 requires
+	// client-to-server frames to be masked.
+	mask bool
+
+	wmu     sync.Mutex
+	waiting atomic.Bool // a keepalive ping is unanswered
+}
+
+// keepalive pings the leg every interval and fails once a ping goes unanswered for
+// timeout. A ping is skipped while a relayed frame is being written to the leg: that
+// traffic keeps intermediaries alive on its own.
+func (l *wsLeg) keepalive(done <-chan struct{}, interval, timeout time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	var deadline <-chan time.Time
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-deadline:
+			if l.waiting.Load() {
+				return errors.New(l.name + " stopped answering pings")
+			}
+			deadline = nil
+		case <-t.C:
+			if l.waiting.Load() || !l.wmu.TryLock() {
+				continue
+			}
+			l.waiting.Store(true)
+			_, err := l.rwc.Write(wsControlFrame(wsOpPing, wsPingPayload, l.mask))
+			l.wmu.Unlock()
+			if err != nil {
+				return err
+			}
+			deadline = time.After(timeout)
+		}
+	}
+}
+
+// pumpFrames relays frames from src to dst until src fails. Pongs answering the proxy's
+// own pings are consumed and clear src's keepalive state.
+func pumpFrames(src, dst *wsLeg) error {
+	for {
+		hdr, op, n, maskKey, err := readFrameHeader(src.r)
+		if err != nil {
+			return err
+		}
+		if op == wsOpPong && n <= 125 {
+			payload := make([]byte, n)
+			if _, err := io.ReadFull(src.r, payload); err != nil {
+				return err
+			}
+			if maskKey != nil {
+				unmask(payload, maskKey)
+			}
+			if bytes.Equal(payload, wsPingPayload) {
+				src.waiting.Store(false)
+				continue
+			}
+			if maskKey != nil {
+				unmask(payload, maskKey)
+			}
+			if err := dst.writeFrame(hdr, bytes.NewReader(payload), n); err != nil {
+				return err
+			}
+			continue
+		}
+		// Close frames are relayed like any other; the pump keeps going until the peer
+		// drops the connection, so the close handshake can complete
+		if err := dst.writeFrame(hdr, src.r, n); err != nil {
+			return err
+		}
+	}
+}
+
+// writeFrame writes a raw frame header followed by n payload bytes from body.
+func (l *wsLeg) writeFrame(hdr []byte, body io.Reader, n uint64) error {
+	l.wmu.Lock()
+	defer l.wmu.Unlock()
+	if _, err := l.rwc.Write(hdr); err != nil {
+		return err
+	}
+	_, err := io.CopyN(l.rwc, body, int64(n))
+	return err
+}
+
+// readFrameHeader reads one frame header, returning its raw bytes, opcode, payload
+// length and mask key (nil when unmasked).
+func readFrameHeader(r *bufio.Reader) (hdr []byte, op byte, n uint64, maskKey []byte, err error) {
+	hdr = make([]byte, 2, 14)
+	if _, err = io.ReadFull(r, hdr); err != nil {
+		return nil, 0, 0, nil, err
+	}
+	op = hdr[0] & 0x0f
+	masked := hdr[1]&0x80 != 0
+	switch n = uint64(hdr[1] & 0x7f); n {
+	case 126:
+		hdr = append(hdr, 0, 0)
+		if _, err = io.ReadFull(r, hdr[2:4]); err != nil {
+			return nil, 0, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(hdr[2:4]))
+	case 127:
+		hdr = append(hdr, make([]byte, 8)...)
+		if _, err = io.ReadFull(r, hdr[2:10]); err != nil {
+			return nil, 0, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(hdr[2:10])
+		if n > 1<<63-1 {
+			return nil, 0, 0, nil, errors.New("websocket frame too large")
+		}
+	}
+	if masked {
+		off := len(hdr)
+		hdr = append(hdr, 0, 0, 0, 0)
+		if _, err = io.ReadFull(r, hdr[off:]); err != nil {
+			return nil, 0, 0, nil, err
+		}
+		maskKey = hdr[off:]
+	}
+	return hdr, op, n, maskKey, nil
+}
+
+// wsControlFrame builds a final control frame carrying payload (at most 125 bytes),
+// masked with a random key when mask is set.
+func wsControlFrame(op byte, payload []byte, mask bool) []byte {
+	b := []byte{0x80 | op, byte(len(payload))}
+	if !mask {
+		return append(b, payload...)
+	}
+	b[1] |= 0x80
+	key := make([]byte, 4)
+	_, _ = rand.Read(key)
+	b = append(b, key...)
+	off := len(b)
+	b = append(b, payload...)
+	unmask(b[off:], key)
+	return b
+}
+
+// unmask XORs p with key in place; applying it twice restores the input.
+func unmask(p, key []byte) {
+	for i := range p {
+		p[i] ^= key[i%4]
+	}
+}
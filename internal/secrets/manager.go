@@ -7,44 +7,135 @@ import (
 	"encoding/base64"
 	"errors"
 	"io"
+	"log"
+	"sync"
 )
 
-// Manager provides envelope encryption for sensitive values using a master key.
+// Ciphertext format versions. Version 1 values are base64(version || nonce || ct) and
+// authenticate the version byte as AAD. Legacy (version 0) values are base64(nonce || ct)
+// with no prefix; they are still readable but never written. Values over StreamThreshold
+// use the chunked streaming format (version 2, see stream.go).
+const (
+	versionLegacy byte = 0
+	versionV1     byte = 1
+
+	currentVersion = versionV1
+)
+
+// Manager provides envelope encryption for sensitive values using a master key. A
+// Manager without a key (New(""), the zero value, or nil) is disabled: Seal stores
+// plaintext marked unencrypted and Open never decrypts.
 type Manager struct {
-	key []byte // 32 bytes AES-256
+	key []byte // 32 bytes AES-256; empty when disabled
 }
 
+// New returns a Manager for masterKey. An empty key is allowed for development and
+// yields a disabled Manager.
 func New(masterKey string) (*Manager, error) {
 	if masterKey == "" {
-		// Allow empty key in dev; encryption becomes a no-op with a static zero key
-		masterKey = ""
+		return &Manager{}, nil
 	}
 	b := make([]byte, 32)
 	copy(b, []byte(masterKey))
 	return &Manager{key: b}, nil
 }
 
+// ErrNoMasterKey is returned by Open for a value stored encrypted when the Manager is
+// disabled.
+var ErrNoMasterKey = errors.New("value is encrypted but no master key is configured (GUILDNET_MASTER_KEY)")
+
+// Enabled reports whether m encrypts; false for a nil or keyless Manager.
+func (m *Manager) Enabled() bool { return m != nil && len(m.key) > 0 }
+
+var plaintextWarning sync.Once
+
+// Seal prepares plaintext for storage and reports whether the stored value is encrypted,
+// which callers must record next to it (the "encrypted" marker) for Open. A disabled
+// Manager returns plaintext and false, and logs a warning the first time.
+func (m *Manager) Seal(plaintext string) (string, bool, error) {
+	if !m.Enabled() {
+		plaintextWarning.Do(func() {
+			log.Printf("WARNING: GUILDNET_MASTER_KEY is not set; secrets such as kubeconfigs are stored UNENCRYPTED in the local database")
+		})
+		return plaintext, false, nil
+	}
+	v, err := m.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return v, true, nil
+}
+
+// Open returns the plaintext of a value stored by Seal. Unencrypted values are returned
+// as they are without any decryption attempt; encrypted ones need an enabled Manager,
+// except values sealed with the legacy zero key, which any Manager can still read.
+func (m *Manager) Open(value string, encrypted bool) (string, error) {
+	if !encrypted {
+		return value, nil
+	}
+	if !m.Enabled() {
+		if v, err := openLegacyKey(value); err == nil {
+			return v, nil
+		}
+		return "", ErrNoMasterKey
+	}
+	v, err := m.DecryptAny(value)
+	if err == nil {
+		return v, nil
+	}
+	if lv, lerr := openLegacyKey(value); lerr == nil {
+		return lv, nil
+	}
+	return "", err
+}
+
+// openLegacyKey decrypts with the all-zero key that New("") used before keyless
+// Managers were disabled, so values stored encrypted that way stay readable. The key is
+// never used to encrypt.
+func openLegacyKey(value string) (string, error) {
+	return (&Manager{key: make([]byte, 32)}).DecryptAny(value)
+}
+
+func (m *Manager) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(m.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt seals plaintext using the current ciphertext version, or the streaming format
+// when it is larger than StreamThreshold.
 func (m *Manager) Encrypt(plaintext string) (string, error) {
 	if len(m.key) == 0 {
 		return plaintext, nil
 	}
-	block, err := aes.NewCipher(m.key)
-	if err != nil {
-		return "", err
+	if len(plaintext) > StreamThreshold {
+		return m.encryptLarge(plaintext)
 	}
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := m.gcm()
 	if err != nil {
 		return "", err
 	}
-	nonce := make([]byte, gcm.NonceSize())
+	out := make([]byte, 1+gcm.NonceSize(), 1+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	out[0] = currentVersion
+	nonce := out[1:]
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", err
 	}
-	ct := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ct), nil
+	out = gcm.Seal(out, nonce, []byte(plaintext), []byte{currentVersion})
+	return base64.StdEncoding.EncodeToString(out), nil
 }
 
+// Decrypt opens a ciphertext of any supported version. Kept for existing callers; see DecryptAny.
 func (m *Manager) Decrypt(ciphertext string) (string, error) {
+	return m.DecryptAny(ciphertext)
+}
+
+// DecryptAny dispatches on the version prefix. Values without a recognizable prefix are
+// treated as legacy. Because legacy values start with a random nonce byte, a v1 prefix
+// that fails authentication is retried as legacy before giving up.
+func (m *Manager) DecryptAny(ciphertext string) (string, error) {
 	if len(m.key) == 0 {
 		return ciphertext, nil
 	}
@@ -52,20 +143,76 @@ func (m *Manager) Decrypt(ciphertext string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	block, err := aes.NewCipher(m.key)
+	if len(b) == 0 {
+		return "", errors.New("ciphertext too short")
+	}
+	gcm, err := m.gcm()
 	if err != nil {
 		return "", err
 	}
-	gcm, err := cipher.NewGCM(block)
+	switch b[0] {
+	case versionV1:
+		pt, err := openV1(gcm, b)
+		if err == nil {
+			return pt, nil
+		}
+		if lpt, lerr := openLegacy(gcm, b); lerr == nil {
+			return lpt, nil
+		}
+		return "", err
+	case versionStream:
+		pt, err := m.openStream(b)
+		if err == nil {
+			return pt, nil
+		}
+		if lpt, lerr := openLegacy(gcm, b); lerr == nil {
+			return lpt, nil
+		}
+		return "", err
+	default:
+		return openLegacy(gcm, b)
+	}
+}
+
+// Version reports the ciphertext version of an encrypted value (0 for legacy).
+func (m *Manager) Version(ciphertext string) (byte, error) {
+	b, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) > 0 && b[0] == versionStream {
+		if _, err := m.openStream(b); err == nil {
+			return versionStream, nil
+		}
+	}
+	if len(b) > 0 && b[0] == versionV1 {
+		if gcm, err := m.gcm(); err == nil {
+			if _, err := openV1(gcm, b); err == nil {
+				return versionV1, nil
+			}
+		}
+	}
+	return versionLegacy, nil
+}
+
+func openV1(gcm cipher.AEAD, b []byte) (string, error) {
+	if len(b) < 1+gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce := b[1 : 1+gcm.NonceSize()]
+	pt, err := gcm.Open(nil, nonce, b[1+gcm.NonceSize():], b[:1])
 	if err != nil {
 		return "", err
 	}
+	return string(pt), nil
+}
+
+func openLegacy(gcm cipher.AEAD, b []byte) (string, error) {
 	if len(b) < gcm.NonceSize() {
 		return "", errors.New("ciphertext too short")
 	}
 	nonce := b[:gcm.NonceSize()]
-	ct := b[gcm.NonceSize():]
-	pt, err := gcm.Open(nil, nonce, ct, nil)
+	pt, err := gcm.Open(nil, nonce, b[gcm.NonceSize():], nil)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,181 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Streaming ciphertexts (version 2) split the plaintext into StreamChunkSize chunks, each
+// sealed separately so values never need to be held in memory at once. Layout:
+//
+//	version(1) || noncePrefix(7) || { len(4, big-endian) || sealed chunk }*
+//
+// Chunk nonces are noncePrefix || counter(4) || last(1); the final chunk sets last=1, so
+// reordered, dropped or truncated chunks fail authentication. The header is the AAD.
+const (
+	versionStream byte = 2
+
+	// StreamChunkSize is the plaintext size of each sealed chunk.
+	StreamChunkSize = 64 << 10
+	// StreamThreshold is the plaintext size above which Encrypt switches to the
+	// streaming format.
+	StreamThreshold = StreamChunkSize
+
+	streamPrefixSize = 7
+	streamHeaderSize = 1 + streamPrefixSize
+)
+
+var (
+	errStreamTruncated = errors.New("encrypted stream truncated")
+	errStreamTrailing  = errors.New("encrypted stream has trailing data")
+	errStreamTooLong   = errors.New("encrypted stream too long")
+)
+
+// EncryptStream reads plaintext from r until EOF and writes the streaming ciphertext to w.
+func (m *Manager) EncryptStream(r io.Reader, w io.Writer) error {
+	if len(m.key) == 0 {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	gcm, err := m.gcm()
+	if err != nil {
+		return err
+	}
+	header := make([]byte, streamHeaderSize)
+	header[0] = versionStream
+	if _, err := io.ReadFull(rand.Reader, header[1:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	var counter uint32
+	seal := func(chunk []byte, last bool) error {
+		sealed := gcm.Seal(nil, streamNonce(header, counter, last), chunk, header)
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(sealed)))
+		if _, err := w.Write(n[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if counter == ^uint32(0) {
+			return errStreamTooLong
+		}
+		counter++
+		return nil
+	}
+	// Read one chunk ahead so the final chunk can be marked before it is sealed.
+	cur, next := make([]byte, StreamChunkSize), make([]byte, StreamChunkSize)
+	n, rerr := io.ReadFull(r, cur)
+	for {
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			return seal(cur[:n], true)
+		}
+		if rerr != nil {
+			return rerr
+		}
+		n2, rerr2 := io.ReadFull(r, next)
+		if rerr2 == io.EOF {
+			return seal(cur[:n], true)
+		}
+		if err := seal(cur[:n], false); err != nil {
+			return err
+		}
+		cur, next = next, cur
+		n, rerr = n2, rerr2
+	}
+}
+
+// DecryptStream reads a streaming ciphertext from r and writes the plaintext to w. Each
+// chunk is authenticated before it is written; a stream that ends before its final
+// chunk returns an error, so callers must discard partial output on failure.
+func (m *Manager) DecryptStream(r io.Reader, w io.Writer) error {
+	if len(m.key) == 0 {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	gcm, err := m.gcm()
+	if err != nil {
+		return err
+	}
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return errStreamTruncated
+	}
+	if header[0] != versionStream {
+		return errors.New("not an encrypted stream")
+	}
+	maxSealed := StreamChunkSize + gcm.Overhead()
+	buf := make([]byte, maxSealed)
+	var counter uint32
+	for {
+		var n [4]byte
+		if _, err := io.ReadFull(r, n[:]); err != nil {
+			return errStreamTruncated
+		}
+		size := int(binary.BigEndian.Uint32(n[:]))
+		if size < gcm.Overhead() || size > maxSealed {
+			return errors.New("encrypted stream chunk has invalid length")
+		}
+		sealed := buf[:size]
+		if _, err := io.ReadFull(r, sealed); err != nil {
+			return errStreamTruncated
+		}
+		last := false
+		pt, err := gcm.Open(nil, streamNonce(header, counter, false), sealed, header)
+		if err != nil {
+			if pt, err = gcm.Open(nil, streamNonce(header, counter, true), sealed, header); err != nil {
+				return err
+			}
+			last = true
+		}
+		if _, err := w.Write(pt); err != nil {
+			return err
+		}
+		if last {
+			var extra [1]byte
+			if k, _ := r.Read(extra[:]); k > 0 {
+				return errStreamTrailing
+			}
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return errStreamTooLong
+		}
+		counter++
+	}
+}
+
+func streamNonce(header []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, header[1:streamHeaderSize])
+	binary.BigEndian.PutUint32(nonce[streamPrefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptLarge is Encrypt for values above StreamThreshold.
+func (m *Manager) encryptLarge(plaintext string) (string, error) {
+	var buf bytes.Buffer
+	if err := m.EncryptStream(bytes.NewReader([]byte(plaintext)), &buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// openStream is DecryptAny for streaming ciphertexts held in memory.
+func (m *Manager) openStream(b []byte) (string, error) {
+	var buf bytes.Buffer
+	if err := m.DecryptStream(bytes.NewReader(b), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,117 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SchemaVersion is the settings layout version stamped on every record this build writes
+// (as "schema_version"). Bump it and append a migration whenever a field is renamed,
+// removed or changes shape; plain additions need no bump.
+const SchemaVersion = 1
+
+const keyVersion = "schema_version"
+
+// ErrNewerSchema is returned when a stored record was written by a newer build. Such
+// records are read best-effort but never overwritten.
+var ErrNewerSchema = errors.New("settings written by a newer schema version")
+
+// Record kinds, used to pick migrations.
+const (
+	kindGlobal    = "global"
+	kindTailscale = "tailscale"
+	kindDatabase  = "database"
+	kindCluster   = "cluster"
+)
+
+// migrations upgrade a stored record in place, indexed by the version they upgrade from.
+// A nil step only stamps the new version.
+var migrations = map[string][]func(rec map[string]any){
+	kindGlobal: {
+		// 0 -> 1: frontend_origin (single string) became frontend_origins (list)
+		func(rec map[string]any) {
+			if len(asStrings(rec["frontend_origins"])) == 0 {
+				if o := asString(rec["frontend_origin"]); o != "" {
+					rec["frontend_origins"] = []any{o}
+				}
+			}
+			delete(rec, "frontend_origin")
+		},
+	},
+}
+
+// migrate upgrades rec to SchemaVersion, reporting whether it changed. Records from a
+// newer version are left untouched and yield ErrNewerSchema.
+func migrate(kind string, rec map[string]any) (bool, error) {
+	v := asInt(rec[keyVersion])
+	if v > SchemaVersion {
+		return false, fmt.Errorf("%s settings at version %d (this build: %d): %w", kind, v, SchemaVersion, ErrNewerSchema)
+	}
+	if v == SchemaVersion {
+		return false, nil
+	}
+	steps := migrations[kind]
+	for ; v < SchemaVersion; v++ {
+		if v < len(steps) && steps[v] != nil {
+			steps[v](rec)
+		}
+	}
+	rec[keyVersion] = SchemaVersion
+	return true, nil
+}
+
+// load reads a raw record and migrates it in memory. ok is false when no record exists.
+func (m Manager) load(collection, key, kind string) (rec map[string]any, ok bool, err error) {
+	if err := m.DB.Get(collection, key, &rec); err != nil || rec == nil {
+		return map[string]any{}, false, nil
+	}
+	_, err = migrate(kind, rec)
+	return rec, true, err
+}
+
+// store writes rec stamped with SchemaVersion, refusing to replace a newer record.
+func (m Manager) store(collection, key, kind string, rec map[string]any) error {
+	var cur map[string]any
+	if err := m.DB.Get(collection, key, &cur); err == nil && asInt(cur[keyVersion]) > SchemaVersion {
+		return fmt.Errorf("%s settings at version %d (this build: %d): %w", kind, asInt(cur[keyVersion]), SchemaVersion, ErrNewerSchema)
+	}
+	rec[keyVersion] = SchemaVersion
+	return m.DB.Put(collection, key, rec)
+}
+
+// Migrate upgrades every stored settings record to SchemaVersion and writes back the ones
+// that changed. It is idempotent and meant to run at startup before settings are read.
+// Records from a newer build are skipped and reported in the returned error.
+func (m Manager) Migrate() error {
+	type ref struct{ collection, key, kind string }
+	refs := []ref{
+		{bucket, keyGlobal, kindGlobal},
+		{bucket, keyTS, kindTailscale},
+		{bucket, keyDB, kindDatabase},
+	}
+	ids, err := m.DB.Keys(bucketClusters)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		refs = append(refs, ref{bucketClusters, id, kindCluster})
+	}
+	var errs []error
+	for _, r := range refs {
+		var rec map[string]any
+		if err := m.DB.Get(r.collection, r.key, &rec); err != nil || rec == nil {
+			continue
+		}
+		changed, err := migrate(r.kind, rec)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if changed {
+			if err := m.DB.Put(r.collection, r.key, rec); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// FallbackNamespace is used when neither the cluster nor the global settings name a
+// valid namespace.
+const FallbackNamespace = "default"
+
+// ValidateNamespace reports whether ns is usable as a Kubernetes namespace (a DNS-1123
+// label). Empty means "not set" and is valid.
+func ValidateNamespace(ns string) error {
+	ns = strings.TrimSpace(ns)
+	if ns == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace %q: %s", ns, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ResolveNamespace applies the namespace precedence: the cluster override, then the
+// global default, then FallbackNamespace. Invalid values are skipped so a bad stored
+// setting cannot produce broken API paths.
+func ResolveNamespace(c Cluster, g Global) string {
+	for _, ns := range []string{c.Namespace, g.DefaultNamespace} {
+		ns = strings.TrimSpace(ns)
+		if ns != "" && ValidateNamespace(ns) == nil {
+			return ns
+		}
+	}
+	return FallbackNamespace
+}
+
+// EffectiveNamespace resolves the namespace for clusterID from settings stored in m.
+// An empty clusterID skips the per-cluster override.
+func (m Manager) EffectiveNamespace(clusterID string) string {
+	var c Cluster
+	if strings.TrimSpace(clusterID) != "" {
+		_ = m.GetCluster(clusterID, &c)
+	}
+	var g Global
+	_ = m.GetGlobal(&g)
+	return ResolveNamespace(c, g)
+}
@@ -0,0 +1,67 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProxyMode selects how the cluster-scoped workspace proxy reaches a workspace.
+type ProxyMode string
+
+const (
+	// ProxyModeAuto keeps the heuristic: port-forward when prefer_pod_proxy or
+	// use_port_forward is set or the Service has no endpoints, else the service proxy.
+	ProxyModeAuto ProxyMode = "auto"
+	// ProxyModeService always goes through the API server's service proxy.
+	ProxyModeService ProxyMode = "service"
+	// ProxyModePod goes through the API server's pod proxy to a pod behind the Service.
+	ProxyModePod ProxyMode = "pod"
+	// ProxyModePortForward port-forwards to a pod behind the Service, falling back to
+	// the service proxy when the forward cannot be opened.
+	ProxyModePortForward ProxyMode = "portforward"
+)
+
+// ParseProxyMode normalizes a configured proxy mode. Empty means ProxyModeAuto.
+func ParseProxyMode(s string) (ProxyMode, error) {
+	switch m := ProxyMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return ProxyModeAuto, nil
+	case ProxyModeAuto, ProxyModeService, ProxyModePod, ProxyModePortForward:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid proxy mode %q: want auto, service, pod or portforward", s)
+}
+
+// EffectiveProxyMode returns the cluster's proxy mode, treating unset or invalid
+// stored values as ProxyModeAuto.
+func (c Cluster) EffectiveProxyMode() ProxyMode {
+	return effectiveProxyMode(c.ProxyMode)
+}
+
+// EffectiveProxyMode returns the proxy mode of the hostapp's /proxy, treating unset or
+// invalid stored values as ProxyModeAuto.
+func (g Global) EffectiveProxyMode() ProxyMode {
+	return effectiveProxyMode(g.ProxyMode)
+}
+
+func effectiveProxyMode(m ProxyMode) ProxyMode {
+	if m, err := ParseProxyMode(string(m)); err == nil {
+		return m
+	}
+	return ProxyModeAuto
+}
+
+// Route reports whether /proxy should go through a pod behind the Service and whether
+// to port-forward to it. Auto follows the request's prefer-pod and port-forward hints;
+// the other modes ignore them.
+func (m ProxyMode) Route(preferPod, usePortForward bool) (pod, portForward bool) {
+	switch m {
+	case ProxyModeService:
+		return false, false
+	case ProxyModePod:
+		return true, false
+	case ProxyModePortForward:
+		return true, true
+	}
+	return preferPod, usePortForward
+}
@@ -0,0 +1,24 @@
+package settings
+
+import "testing"
+
+func TestClusterProxyMode(t *testing.T) {
+	m := testManager(t)
+	for in, want := range map[string]ProxyMode{"": ProxyModeAuto, " Pod ": ProxyModePod, "portforward": ProxyModePortForward, "service": ProxyModeService} {
+		if got, err := ParseProxyMode(in); err != nil || got != want {
+			t.Fatalf("ParseProxyMode(%q)=%q,%v want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseProxyMode("port-forward"); err == nil {
+		t.Fatal("unknown mode accepted")
+	}
+
+	var cs Cluster
+	_ = m.GetCluster("c1", &cs)
+	if cs.EffectiveProxyMode() != ProxyModeAuto {
+		t.Fatalf("unset mode=%q, want auto", cs.EffectiveProxyMode())
+	}
+	if (Cluster{ProxyMode: "bogus"}).EffectiveProxyMode() != ProxyModeAuto {
+		t.Fatal("invalid stored mode should act as auto")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/model"
 )
 
 // Tailscale holds tsnet control-plane settings managed at runtime.
@@ -12,6 +13,10 @@ type Tailscale struct {
 	LoginServer string `json:"login_server"`
 	PreauthKey  string `json:"preauth_key"`
 	Hostname    string `json:"hostname"`
+	// Ephemeral joins the tailnet as an ephemeral node with in-memory state: it is removed
+	// from the tailnet shortly after the hostapp stops, but gets a new identity and
+	// tailnet address on every start. Off keeps a stable node in the state dir.
+	Ephemeral bool `json:"ephemeral,omitempty"`
 }
 
 // Database holds DB connection settings.
@@ -24,11 +29,75 @@ type Database struct {
 // Global holds global runtime settings not tied to a specific cluster.
 // Example: default Org ID for new nodes, UI CORS origin overrides, etc.
 type Global struct {
-	OrgID            string `json:"org_id"`
+	OrgID string `json:"org_id"`
+	// FrontendOrigins are the UI origins allowed by CORS; the first is the primary one.
+	FrontendOrigins []string `json:"frontend_origins,omitempty"`
+	// Deprecated: FrontendOrigin is the single-origin layout kept for API clients. Reads
+	// set it to the first of FrontendOrigins; writes use it only when FrontendOrigins is
+	// empty.
 	FrontendOrigin   string `json:"frontend_origin,omitempty"`
 	EmbedOperator    bool   `json:"embed_operator,omitempty"`
 	DefaultNamespace string `json:"default_namespace,omitempty"`
 	ListenLocal      string `json:"listen_local,omitempty"`
+	// CORS preflight tuning. Extra headers are added to the built-in list; methods replace
+	// the default set when non-empty. CORSMaxAge is in seconds (0 = default 600).
+	CORSAllowedMethods []string `json:"cors_allowed_methods,omitempty"`
+	CORSAllowedHeaders []string `json:"cors_allowed_headers,omitempty"`
+	CORSMaxAge         int      `json:"cors_max_age,omitempty"`
+	// KubeProxyCandidates are kubectl proxy addresses (host:port or URL) probed in order
+	// when a cluster API server times out, e.g. ["127.0.0.1:8001", "127.0.0.1:8002"].
+	KubeProxyCandidates []string `json:"kube_proxy_candidates,omitempty"`
+	// ServerURLBase prefixes proxy links returned as server URLs: empty for relative links,
+	// "request" to use the request origin, or an absolute origin like https://guild.example.
+	ServerURLBase string `json:"server_url_base,omitempty"`
+	// OperatorLeaderElection makes embedded operators across hostapp replicas elect a single
+	// reconciler via a Lease (default namespace "default", name "guildnet-operator").
+	OperatorLeaderElection bool   `json:"operator_leader_election,omitempty"`
+	OperatorLeaseNamespace string `json:"operator_lease_namespace,omitempty"`
+	OperatorLeaseName      string `json:"operator_lease_name,omitempty"`
+	// MaxLogPods caps how many pods workspace log endpoints aggregate (0 = 5); read at startup.
+	MaxLogPods int `json:"max_log_pods,omitempty"`
+	// MaxJobLogBytes caps each job's stored log (0 = 1 MiB); read when the runner starts.
+	MaxJobLogBytes int `json:"max_job_log_bytes,omitempty"`
+	// SucceededJobRetentionHours is how long succeeded jobs are kept before being
+	// pruned (0 or negative keeps them forever); read when the runner starts. Failed and
+	// canceled jobs are always kept.
+	SucceededJobRetentionHours int `json:"succeeded_job_retention_hours,omitempty"`
+	// StrictArgs rejects shell metacharacters in job/workspace args, for images whose
+	// entrypoint runs args through a shell.
+	StrictArgs bool `json:"strict_args,omitempty"`
+	// ProxyWSPingSeconds is the keepalive ping interval for WebSocket sessions through the
+	// workspace proxy (0 = 25s, negative disables); read at startup.
+	ProxyWSPingSeconds int `json:"proxy_ws_ping_seconds,omitempty"`
+	// ProxyServerTiming adds a Server-Timing header (resolve, dial, upstream first byte)
+	// to workspace proxy responses. Debug only: it reveals internal latency. Cluster-scoped
+	// proxies read it per request, /proxy at startup.
+	ProxyServerTiming bool `json:"proxy_server_timing,omitempty"`
+	// ProxyStripHeaders are request headers removed before the workspace proxy forwards to
+	// the app, e.g. ["X-Internal-*", "X-Debug-Principal"]; X-Guild-* is always removed.
+	ProxyStripHeaders []string `json:"proxy_strip_headers,omitempty"`
+	// ProxyMaxStreamsPerServer caps concurrent proxied requests (SSE and WebSocket
+	// sessions included) to one workspace; excess requests get 429 (0 = 256, negative
+	// disables). Cluster-scoped proxies read it per request, /proxy at startup.
+	ProxyMaxStreamsPerServer int `json:"proxy_max_streams_per_server,omitempty"`
+	// ProxyMode picks how the hostapp's /proxy reaches workspaces on the local cluster:
+	// auto (default, follows the X-Guild-Prefer-Pod and X-Guild-Use-PortForward request
+	// headers), service, pod or portforward; read at startup.
+	ProxyMode ProxyMode `json:"proxy_mode,omitempty"`
+}
+
+// Origins returns the configured UI origins, falling back to the deprecated single
+// FrontendOrigin.
+func (g Global) Origins() []string {
+	if v := trimStrings(g.FrontendOrigins); len(v) > 0 {
+		return v
+	}
+	return trimStrings([]string{g.FrontendOrigin})
+}
+
+// ValidationOptions returns the spec validation options implied by the global settings.
+func (g Global) ValidationOptions() model.ValidationOptions {
+	return model.ValidationOptions{RejectShellMetachars: g.StrictArgs}
 }
 
 // Cluster holds per-cluster runtime settings that affect connectivity and proxying.
@@ -47,9 +116,12 @@ type Cluster struct {
 	// Disable API proxy rewriting entirely for this cluster
 	DisableAPIProxy bool `json:"disable_api_proxy,omitempty"`
 
-	// Proxy style preferences for user workloads
+	// Proxy style preferences for user workloads, consulted when ProxyMode is auto
 	PreferPodProxy bool `json:"prefer_pod_proxy,omitempty"`
 	UsePortForward bool `json:"use_port_forward,omitempty"`
+	// ProxyMode picks the workspace proxy strategy: auto (default), service, pod or
+	// portforward
+	ProxyMode ProxyMode `json:"proxy_mode,omitempty"`
 
 	// Ingress and domain knobs (optional; used when creating ingress resources)
 	IngressDomain      string `json:"ingress_domain,omitempty"`
@@ -63,6 +135,11 @@ type Cluster struct {
 	// Default to expose workspaces as LoadBalancer when not specified per-workspace
 	WorkspaceLBEnabled bool `json:"workspace_lb_enabled,omitempty"`
 
+	// Default container requests/limits (resource name to quantity, e.g. "cpu": "500m")
+	// the operator fills into workspaces that leave them unset in spec.resources
+	WorkspaceDefaultRequests map[string]string `json:"workspace_default_requests,omitempty"`
+	WorkspaceDefaultLimits   map[string]string `json:"workspace_default_limits,omitempty"`
+
 	// Optional org scope if multi-tenant DB is used per cluster scope
 	OrgID string `json:"org_id,omitempty"`
 
@@ -72,6 +149,10 @@ type Cluster struct {
 	TSRoutes        string `json:"ts_routes,omitempty"`
 	TSStatePath     string `json:"ts_state_path,omitempty"`
 	HeadscaleNS     string `json:"headscale_namespace,omitempty"`
+
+	// PEM CA bundle trusted for HTTPS workspace upstreams reached through the proxy.
+	// When set, upstream certificates must chain to it even for in-cluster targets.
+	UpstreamCA string `json:"upstream_ca,omitempty"`
 }
 
 // Manager wraps localdb for typed settings.
@@ -88,14 +169,15 @@ const (
 func EnsureBucket(db *localdb.DB) error { return db.EnsureBuckets(bucket, bucketClusters) }
 
 func (m Manager) GetTailscale(out *Tailscale) error {
-	var tmp map[string]any
-	if err := m.DB.Get(bucket, keyTS, &tmp); err != nil {
+	tmp, ok, _ := m.load(bucket, keyTS, kindTailscale)
+	if !ok {
 		*out = Tailscale{}
 		return nil
 	}
 	out.LoginServer = strings.TrimSpace(asString(tmp["login_server"]))
 	out.PreauthKey = strings.TrimSpace(asString(tmp["preauth_key"]))
 	out.Hostname = strings.TrimSpace(asString(tmp["hostname"]))
+	out.Ephemeral = asBool(tmp["ephemeral"])
 	return nil
 }
 
@@ -105,12 +187,15 @@ func (m Manager) PutTailscale(ts Tailscale) error {
 		"preauth_key":  strings.TrimSpace(ts.PreauthKey),
 		"hostname":     strings.TrimSpace(ts.Hostname),
 	}
-	return m.DB.Put(bucket, keyTS, rec)
+	if ts.Ephemeral {
+		rec["ephemeral"] = true
+	}
+	return m.store(bucket, keyTS, kindTailscale, rec)
 }
 
 func (m Manager) GetDatabase(out *Database) error {
-	var tmp map[string]any
-	if err := m.DB.Get(bucket, keyDB, &tmp); err != nil {
+	tmp, ok, _ := m.load(bucket, keyDB, kindDatabase)
+	if !ok {
 		*out = Database{}
 		return nil
 	}
@@ -126,36 +211,108 @@ func (m Manager) PutDatabase(db Database) error {
 		"user": strings.TrimSpace(db.User),
 		"pass": strings.TrimSpace(db.Pass),
 	}
-	return m.DB.Put(bucket, keyDB, rec)
+	return m.store(bucket, keyDB, kindDatabase, rec)
 }
 
 // Global settings CRUD
 func (m Manager) GetGlobal(out *Global) error {
-	var tmp map[string]any
-	if err := m.DB.Get(bucket, keyGlobal, &tmp); err != nil {
+	tmp, ok, _ := m.load(bucket, keyGlobal, kindGlobal)
+	if !ok {
 		*out = Global{}
 		return nil
 	}
 	out.OrgID = strings.TrimSpace(asString(tmp["org_id"]))
-	out.FrontendOrigin = strings.TrimSpace(asString(tmp["frontend_origin"]))
+	out.FrontendOrigins = asStrings(tmp["frontend_origins"])
+	out.FrontendOrigin = ""
+	if len(out.FrontendOrigins) > 0 {
+		out.FrontendOrigin = out.FrontendOrigins[0]
+	}
 	out.EmbedOperator = asBool(tmp["embed_operator"])
 	out.DefaultNamespace = strings.TrimSpace(asString(tmp["default_namespace"]))
 	out.ListenLocal = strings.TrimSpace(asString(tmp["listen_local"]))
 	if out.ListenLocal == "" {
 		out.ListenLocal = "127.0.0.1:8090"
 	}
+	out.CORSAllowedMethods = asStrings(tmp["cors_allowed_methods"])
+	out.CORSAllowedHeaders = asStrings(tmp["cors_allowed_headers"])
+	out.CORSMaxAge = asInt(tmp["cors_max_age"])
+	out.KubeProxyCandidates = asStrings(tmp["kube_proxy_candidates"])
+	out.ServerURLBase = strings.TrimSpace(asString(tmp["server_url_base"]))
+	out.OperatorLeaderElection = asBool(tmp["operator_leader_election"])
+	out.OperatorLeaseNamespace = strings.TrimSpace(asString(tmp["operator_lease_namespace"]))
+	out.OperatorLeaseName = strings.TrimSpace(asString(tmp["operator_lease_name"]))
+	out.MaxLogPods = asInt(tmp["max_log_pods"])
+	out.MaxJobLogBytes = asInt(tmp["max_job_log_bytes"])
+	out.SucceededJobRetentionHours = asInt(tmp["succeeded_job_retention_hours"])
+	out.StrictArgs = asBool(tmp["strict_args"])
+	out.ProxyWSPingSeconds = asInt(tmp["proxy_ws_ping_seconds"])
+	out.ProxyServerTiming = asBool(tmp["proxy_server_timing"])
+	out.ProxyStripHeaders = asStrings(tmp["proxy_strip_headers"])
+	out.ProxyMaxStreamsPerServer = asInt(tmp["proxy_max_streams_per_server"])
+	out.ProxyMode = ProxyMode(strings.TrimSpace(asString(tmp["proxy_mode"])))
 	return nil
 }
 
 func (m Manager) PutGlobal(g Global) error {
 	rec := map[string]any{
 		"org_id":            strings.TrimSpace(g.OrgID),
-		"frontend_origin":   strings.TrimSpace(g.FrontendOrigin),
 		"embed_operator":    g.EmbedOperator,
 		"default_namespace": strings.TrimSpace(g.DefaultNamespace),
 		"listen_local":      strings.TrimSpace(g.ListenLocal),
+		"server_url_base":   strings.TrimSpace(g.ServerURLBase),
+	}
+	if v := g.Origins(); len(v) > 0 {
+		rec["frontend_origins"] = v
+	}
+	if g.OperatorLeaderElection {
+		rec["operator_leader_election"] = true
+	}
+	if v := strings.TrimSpace(g.OperatorLeaseNamespace); v != "" {
+		rec["operator_lease_namespace"] = v
+	}
+	if v := strings.TrimSpace(g.OperatorLeaseName); v != "" {
+		rec["operator_lease_name"] = v
+	}
+	if v := trimStrings(g.CORSAllowedMethods); len(v) > 0 {
+		rec["cors_allowed_methods"] = v
 	}
-	return m.DB.Put(bucket, keyGlobal, rec)
+	if v := trimStrings(g.CORSAllowedHeaders); len(v) > 0 {
+		rec["cors_allowed_headers"] = v
+	}
+	if g.CORSMaxAge > 0 {
+		rec["cors_max_age"] = g.CORSMaxAge
+	}
+	if v := trimStrings(g.KubeProxyCandidates); len(v) > 0 {
+		rec["kube_proxy_candidates"] = v
+	}
+	if g.MaxLogPods > 0 {
+		rec["max_log_pods"] = g.MaxLogPods
+	}
+	if g.MaxJobLogBytes > 0 {
+		rec["max_job_log_bytes"] = g.MaxJobLogBytes
+	}
+	if g.SucceededJobRetentionHours != 0 {
+		rec["succeeded_job_retention_hours"] = g.SucceededJobRetentionHours
+	}
+	if g.StrictArgs {
+		rec["strict_args"] = true
+	}
+	if g.ProxyWSPingSeconds != 0 {
+		rec["proxy_ws_ping_seconds"] = g.ProxyWSPingSeconds
+	}
+	if g.ProxyServerTiming {
+		rec["proxy_server_timing"] = true
+	}
+	if v := trimStrings(g.ProxyStripHeaders); len(v) > 0 {
+		rec["proxy_strip_headers"] = v
+	}
+	if g.ProxyMaxStreamsPerServer != 0 {
+		rec["proxy_max_streams_per_server"] = g.ProxyMaxStreamsPerServer
+	}
+	if m, err := ParseProxyMode(string(g.ProxyMode)); err == nil && m != ProxyModeAuto {
+		rec["proxy_mode"] = string(m)
+	}
+	return m.store(bucket, keyGlobal, kindGlobal, rec)
 }
 
 // Per-cluster settings CRUD. Keys are cluster IDs.
@@ -163,8 +320,8 @@ func (m Manager) GetCluster(clusterID string, out *Cluster) error {
 	if strings.TrimSpace(clusterID) == "" {
 		return fmt.Errorf("cluster id required")
 	}
-	var tmp map[string]any
-	if err := m.DB.Get(bucketClusters, clusterID, &tmp); err != nil {
+	tmp, ok, _ := m.load(bucketClusters, clusterID, kindCluster)
+	if !ok {
 		*out = Cluster{}
 		return nil
 	}
@@ -175,6 +332,7 @@ func (m Manager) GetCluster(clusterID string, out *Cluster) error {
 	out.DisableAPIProxy = asBool(tmp["disable_api_proxy"])
 	out.PreferPodProxy = asBool(tmp["prefer_pod_proxy"])
 	out.UsePortForward = asBool(tmp["use_port_forward"])
+	out.ProxyMode = ProxyMode(strings.TrimSpace(asString(tmp["proxy_mode"])))
 	out.IngressDomain = strings.TrimSpace(asString(tmp["ingress_domain"]))
 	out.IngressClassName = strings.TrimSpace(asString(tmp["ingress_class_name"]))
 	out.WorkspaceTLSSecret = strings.TrimSpace(asString(tmp["workspace_tls_secret"]))
@@ -183,12 +341,15 @@ func (m Manager) GetCluster(clusterID string, out *Cluster) error {
 	out.IngressAuthSignin = strings.TrimSpace(asString(tmp["ingress_auth_signin"]))
 	out.ImagePullSecret = strings.TrimSpace(asString(tmp["image_pull_secret"]))
 	out.WorkspaceLBEnabled = asBool(tmp["workspace_lb_enabled"])
+	out.WorkspaceDefaultRequests = asStringMap(tmp["workspace_default_requests"])
+	out.WorkspaceDefaultLimits = asStringMap(tmp["workspace_default_limits"])
 	out.OrgID = strings.TrimSpace(asString(tmp["org_id"]))
 	// TS fields; client auth key intentionally omitted from GET
 	out.TSLoginServer = strings.TrimSpace(asString(tmp["ts_login_server"]))
 	out.TSRoutes = strings.TrimSpace(asString(tmp["ts_routes"]))
 	out.TSStatePath = strings.TrimSpace(asString(tmp["ts_state_path"]))
 	out.HeadscaleNS = strings.TrimSpace(asString(tmp["headscale_namespace"]))
+	out.UpstreamCA = strings.TrimSpace(asString(tmp["upstream_ca"]))
 	return nil
 }
 
@@ -197,32 +358,40 @@ func (m Manager) PutCluster(clusterID string, cs Cluster) error {
 		return fmt.Errorf("cluster id required")
 	}
 	rec := map[string]any{
-		"name":                 strings.TrimSpace(cs.Name),
-		"namespace":            strings.TrimSpace(cs.Namespace),
-		"api_proxy_url":        strings.TrimSpace(cs.APIProxyURL),
-		"api_proxy_force_http": cs.APIProxyForceHTTP,
-		"disable_api_proxy":    cs.DisableAPIProxy,
-		"prefer_pod_proxy":     cs.PreferPodProxy,
-		"use_port_forward":     cs.UsePortForward,
-		"ingress_domain":       strings.TrimSpace(cs.IngressDomain),
-		"ingress_class_name":   strings.TrimSpace(cs.IngressClassName),
-		"workspace_tls_secret": strings.TrimSpace(cs.WorkspaceTLSSecret),
-		"cert_manager_issuer":  strings.TrimSpace(cs.CertManagerIssuer),
-		"ingress_auth_url":     strings.TrimSpace(cs.IngressAuthURL),
-		"ingress_auth_signin":  strings.TrimSpace(cs.IngressAuthSignin),
-		"image_pull_secret":    strings.TrimSpace(cs.ImagePullSecret),
-		"workspace_lb_enabled": cs.WorkspaceLBEnabled,
-		"org_id":               strings.TrimSpace(cs.OrgID),
-		"ts_login_server":      strings.TrimSpace(cs.TSLoginServer),
-		"ts_routes":            strings.TrimSpace(cs.TSRoutes),
-		"ts_state_path":        strings.TrimSpace(cs.TSStatePath),
-		"headscale_namespace":  strings.TrimSpace(cs.HeadscaleNS),
+		"name":                       strings.TrimSpace(cs.Name),
+		"namespace":                  strings.TrimSpace(cs.Namespace),
+		"api_proxy_url":              strings.TrimSpace(cs.APIProxyURL),
+		"api_proxy_force_http":       cs.APIProxyForceHTTP,
+		"disable_api_proxy":          cs.DisableAPIProxy,
+		"prefer_pod_proxy":           cs.PreferPodProxy,
+		"use_port_forward":           cs.UsePortForward,
+		"ingress_domain":             strings.TrimSpace(cs.IngressDomain),
+		"ingress_class_name":         strings.TrimSpace(cs.IngressClassName),
+		"workspace_tls_secret":       strings.TrimSpace(cs.WorkspaceTLSSecret),
+		"cert_manager_issuer":        strings.TrimSpace(cs.CertManagerIssuer),
+		"ingress_auth_url":           strings.TrimSpace(cs.IngressAuthURL),
+		"ingress_auth_signin":        strings.TrimSpace(cs.IngressAuthSignin),
+		"image_pull_secret":          strings.TrimSpace(cs.ImagePullSecret),
+		"workspace_lb_enabled":       cs.WorkspaceLBEnabled,
+		"workspace_default_requests": trimStringMap(cs.WorkspaceDefaultRequests),
+		"workspace_default_limits":   trimStringMap(cs.WorkspaceDefaultLimits),
+		"org_id":                     strings.TrimSpace(cs.OrgID),
+		"ts_login_server":            strings.TrimSpace(cs.TSLoginServer),
+		"ts_routes":                  strings.TrimSpace(cs.TSRoutes),
+		"ts_state_path":              strings.TrimSpace(cs.TSStatePath),
+		"headscale_namespace":        strings.TrimSpace(cs.HeadscaleNS),
+	}
+	if v := strings.TrimSpace(cs.UpstreamCA); v != "" {
+		rec["upstream_ca"] = v
+	}
+	if m, err := ParseProxyMode(string(cs.ProxyMode)); err == nil && m != ProxyModeAuto {
+		rec["proxy_mode"] = string(m)
 	}
 	// Store client auth key in credentials bucket to avoid accidental echo
 	if strings.TrimSpace(cs.TSClientAuthKey) != "" && m.DB != nil {
 		_ = m.DB.Put("credentials", fmt.Sprintf("cl:%s:ts_client_auth", clusterID), map[string]any{"value": cs.TSClientAuthKey, "encrypted": false})
 	}
-	return m.DB.Put(bucketClusters, clusterID, rec)
+	return m.store(bucketClusters, clusterID, kindCluster, rec)
 }
 
 func asString(v any) string {
@@ -252,3 +421,67 @@ func asBool(v any) bool {
 		return false
 	}
 }
+
+func asInt(v any) int {
+	switch t := v.(type) {
+	case float64:
+		return int(t)
+	case int:
+		return t
+	default:
+		return 0
+	}
+}
+
+// asStrings reads a JSON array of strings, skipping non-string and blank entries.
+func asStrings(v any) []string {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, e := range arr {
+		if s := strings.TrimSpace(asString(e)); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// asStringMap reads a JSON object of strings, skipping non-string and blank entries.
+func asStringMap(v any) map[string]string {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	out := map[string]string{}
+	for k, e := range obj {
+		if s := strings.TrimSpace(asString(e)); s != "" && strings.TrimSpace(k) != "" {
+			out[strings.TrimSpace(k)] = s
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func trimStringMap(in map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range in {
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); k != "" && v != "" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func trimStrings(in []string) []string {
+	var out []string
+	for _, s := range in {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,179 @@
+package settings
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/localdb"
+)
+
+func testManager(t *testing.T) Manager {
+	t.Helper()
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := EnsureBucket(db); err != nil {
+		t.Fatal(err)
+	}
+	return Manager{DB: db}
+}
+
+// TestSettingsRoundTrip writes each case's settings (nothing when put is nil) and
+// compares what a fresh read returns, so Put and Get stay in step field by field.
+func TestSettingsRoundTrip(t *testing.T) {
+	pem := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"
+	global := func(f func(Global) any) func(Manager) any {
+		return func(m Manager) any {
+			var g Global
+			_ = m.GetGlobal(&g)
+			return f(g)
+		}
+	}
+	cluster := func(id string, f func(Cluster) any) func(Manager) any {
+		return func(m Manager) any {
+			var cs Cluster
+			_ = m.GetCluster(id, &cs)
+			return f(cs)
+		}
+	}
+	tailscale := func(f func(Tailscale) any) func(Manager) any {
+		return func(m Manager) any {
+			var ts Tailscale
+			_ = m.GetTailscale(&ts)
+			return f(ts)
+		}
+	}
+	raw := func(collection, key, field string) func(Manager) any {
+		return func(m Manager) any {
+			var rec map[string]any
+			_ = m.DB.Get(collection, key, &rec)
+			return rec[field]
+		}
+	}
+	for _, tc := range []struct {
+		name   string
+		put    func(Manager) error
+		putErr error
+		got    func(Manager) any
+		want   any
+	}{
+		{
+			name: "global defaults",
+			got:  global(func(g Global) any { return []any{g.OperatorLeaderElection, g.EffectiveProxyMode()} }),
+			want: []any{false, ProxyModeAuto},
+		},
+		{
+			name: "global max log pods",
+			put:  func(m Manager) error { return m.PutGlobal(Global{MaxLogPods: 7}) },
+			got:  global(func(g Global) any { return g.MaxLogPods }),
+			want: 7,
+		},
+		{
+			name: "global max job log bytes",
+			put:  func(m Manager) error { return m.PutGlobal(Global{MaxJobLogBytes: 4096}) },
+			got:  global(func(g Global) any { return g.MaxJobLogBytes }),
+			want: 4096,
+		},
+		{
+			name: "global strict args",
+			put:  func(m Manager) error { return m.PutGlobal(Global{StrictArgs: true}) },
+			got:  global(func(g Global) any { return g.StrictArgs }),
+			want: true,
+		},
+		{
+			name: "global operator leader election",
+			put: func(m Manager) error {
+				return m.PutGlobal(Global{OperatorLeaderElection: true, OperatorLeaseNamespace: " guildnet-system ", OperatorLeaseName: "gn-op"})
+			},
+			got: global(func(g Global) any {
+				return []any{g.OperatorLeaderElection, g.OperatorLeaseNamespace, g.OperatorLeaseName}
+			}),
+			want: []any{true, "guildnet-system", "gn-op"},
+		},
+		{
+			name: "global proxy mode",
+			put:  func(m Manager) error { return m.PutGlobal(Global{ProxyMode: " Service "}) },
+			got:  global(func(g Global) any { return []any{g.ProxyMode, g.EffectiveProxyMode()} }),
+			want: []any{ProxyModeService, ProxyModeService},
+		},
+		{
+			name: "global legacy frontend_origin migrates on read",
+			put: func(m Manager) error {
+				return m.DB.Put(bucket, keyGlobal, map[string]any{"org_id": "o1", "frontend_origin": "https://ui.example"})
+			},
+			got:  global(func(g Global) any { return []any{g.FrontendOrigins, g.FrontendOrigin, g.OrgID} }),
+			want: []any{[]string{"https://ui.example"}, "https://ui.example", "o1"},
+		},
+		{
+			name: "global newer schema_version is not overwritten",
+			put: func(m Manager) error {
+				_ = m.DB.Put(bucket, keyGlobal, map[string]any{keyVersion: SchemaVersion + 1, "org_id": "o1"})
+				return m.PutGlobal(Global{OrgID: "o2"})
+			},
+			putErr: ErrNewerSchema,
+			got:    raw(bucket, keyGlobal, "org_id"),
+			want:   "o1",
+		},
+		{
+			name: "cluster upstream CA",
+			put:  func(m Manager) error { return m.PutCluster("c1", Cluster{Name: "one", UpstreamCA: pem + "\n"}) },
+			got:  cluster("c1", func(cs Cluster) any { return []any{cs.UpstreamCA, cs.Name} }),
+			want: []any{pem, "one"},
+		},
+		{
+			name: "cluster default resources",
+			put: func(m Manager) error {
+				return m.PutCluster("c2", Cluster{WorkspaceDefaultRequests: map[string]string{"cpu": " 250m ", "memory": ""}, WorkspaceDefaultLimits: map[string]string{"memory": "1Gi"}})
+			},
+			got:  cluster("c2", func(cs Cluster) any { return []any{cs.WorkspaceDefaultRequests, cs.WorkspaceDefaultLimits} }),
+			want: []any{map[string]string{"cpu": "250m"}, map[string]string{"memory": "1Gi"}},
+		},
+		{
+			name: "cluster proxy mode",
+			put:  func(m Manager) error { return m.PutCluster("c1", Cluster{ProxyMode: "PortForward"}) },
+			got:  cluster("c1", func(cs Cluster) any { return []any{cs.ProxyMode, cs.EffectiveProxyMode()} }),
+			want: []any{ProxyModePortForward, ProxyModePortForward},
+		},
+		{
+			name: "cluster newer schema_version is not overwritten",
+			put: func(m Manager) error {
+				_ = m.DB.Put(bucketClusters, "c1", map[string]any{keyVersion: SchemaVersion + 1, "name": "future"})
+				return m.PutCluster("c1", Cluster{Name: "one"})
+			},
+			putErr: ErrNewerSchema,
+			got:    raw(bucketClusters, "c1", "name"),
+			want:   "future",
+		},
+		{
+			name: "tailscale ephemeral defaults off",
+			put: func(m Manager) error {
+				return m.PutTailscale(Tailscale{LoginServer: "https://hs.example", Hostname: "gn"})
+			},
+			got:  tailscale(func(ts Tailscale) any { return []any{ts.Ephemeral, ts.Hostname} }),
+			want: []any{false, "gn"},
+		},
+		{
+			name: "tailscale ephemeral",
+			put: func(m Manager) error {
+				return m.PutTailscale(Tailscale{LoginServer: "https://hs.example", Hostname: "gn", Ephemeral: true})
+			},
+			got:  tailscale(func(ts Tailscale) any { return []any{ts.Ephemeral, ts.Hostname} }),
+			want: []any{true, "gn"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := testManager(t)
+			if tc.put != nil {
+				if err := tc.put(m); !errors.Is(err, tc.putErr) {
+					t.Fatalf("put err=%v want %v", err, tc.putErr)
+				}
+			}
+			if got := tc.got(m); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %#v want %#v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package ts
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/tsnet"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// Status returns a summary of the tsnet node and its peers. Only online peers are
+// listed unless all is set.
+func Status(ctx context.Context, s *tsnet.Server, all bool) (*model.TSNetStatus, error) {
+	lc, err := s.LocalClient()
+	if err != nil {
+		return nil, err
+	}
+	st, err := lc.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Summarize(st, all), nil
+}
+
+// Summarize converts a tailscale status into model.TSNetStatus, dropping keys, auth URLs
+// and user profiles. Peers are sorted by hostname.
+func Summarize(st *ipnstate.Status, all bool) *model.TSNetStatus {
+	out := &model.TSNetStatus{BackendState: st.BackendState, MagicDNSSuffix: st.MagicDNSSuffix, Health: st.Health, Peers: []model.TSNetPeer{}}
+	if st.Self != nil {
+		out.Self = summarizePeer(st.Self)
+	}
+	if len(out.Self.Addresses) == 0 {
+		out.Self.Addresses = addrStrings(st.TailscaleIPs)
+	}
+	if st.CurrentTailnet != nil {
+		out.Tailnet = st.CurrentTailnet.Name
+	}
+	if en := st.ExitNodeStatus; en != nil {
+		ex := &model.TSNetExitNode{ID: string(en.ID), Online: en.Online}
+		for _, p := range en.TailscaleIPs {
+			ex.Addresses = append(ex.Addresses, p.Addr().String())
+		}
+		out.ExitNode = ex
+	}
+	for _, ps := range st.Peer {
+		if ps == nil {
+			continue
+		}
+		out.PeersTotal++
+		if ps.Online {
+			out.PeersOnline++
+		} else if !all {
+			continue
+		}
+		out.Peers = append(out.Peers, summarizePeer(ps))
+	}
+	sort.Slice(out.Peers, func(i, j int) bool { return out.Peers[i].Hostname < out.Peers[j].Hostname })
+	return out
+}
+
+func summarizePeer(ps *ipnstate.PeerStatus) model.TSNetPeer {
+	p := model.TSNetPeer{
+		ID:             string(ps.ID),
+		Hostname:       ps.HostName,
+		FQDN:           strings.TrimSuffix(ps.DNSName, "."),
+		OS:             ps.OS,
+		Addresses:      addrStrings(ps.TailscaleIPs),
+		Online:         ps.Online,
+		Relay:          ps.Relay,
+		CurAddr:        ps.CurAddr,
+		ExitNode:       ps.ExitNode,
+		ExitNodeOption: ps.ExitNodeOption,
+	}
+	if !ps.LastSeen.IsZero() {
+		p.LastSeen = ps.LastSeen.UTC().Format(time.RFC3339)
+	}
+	if ps.PrimaryRoutes != nil {
+		for i := 0; i < ps.PrimaryRoutes.Len(); i++ {
+			p.SubnetRoutes = append(p.SubnetRoutes, ps.PrimaryRoutes.At(i).String())
+		}
+	}
+	return p
+}
+
+func addrStrings(addrs []netip.Addr) []string {
+	out := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		out = append(out, a.String())
+	}
+	return out
+}
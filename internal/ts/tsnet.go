@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"tailscale.com/ipn/store/mem"
 	"tailscale.com/tsnet"
 )
 
@@ -16,6 +17,10 @@ type Options struct {
 	Hostname string
 	LoginURL string
 	AuthKey  string
+	// Ephemeral registers the node as ephemeral and keeps its state in memory: the
+	// control server removes it soon after it goes offline, and every start joins as a
+	// new node with a new identity and address. StateDir is then only used for logs.
+	Ephemeral bool
 }
 
 // StartServer initializes and starts a tsnet.Server.
@@ -26,6 +31,10 @@ func StartServer(ctx context.Context, opts Options) (*tsnet.Server, error) {
 		AuthKey:  opts.AuthKey,
 		// ControlURL was renamed from LoginServer in older tailscale versions; current API uses ControlURL
 		ControlURL: opts.LoginURL,
+		Ephemeral:  opts.Ephemeral,
+	}
+	if opts.Ephemeral {
+		s.Store = new(mem.Store)
 	}
 	if err := s.Start(); err != nil {
 		return nil, fmt.Errorf("tsnet start: %w", err)
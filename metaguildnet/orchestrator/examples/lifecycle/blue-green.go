@@ -55,18 +55,16 @@ func main() {
 		},
 	}
 
-	_, err = c.Workspaces(*clusterID).Create(ctx, greenSpec)
-	if err != nil {
-		log.Fatalf("Failed to create green deployment: %v", err)
-	}
-
-	// Wait for green to be ready
+	// Create green and wait for it to be ready
 	fmt.Println("Waiting for green deployment to be ready...")
-	err = c.Workspaces(*clusterID).Wait(ctx, greenName, 5*time.Minute)
+	green, err := c.Workspaces(*clusterID).Create(ctx, greenSpec, client.WithWaitReady(5*time.Minute))
 	if err != nil {
 		log.Printf("Green deployment failed: %v", err)
+		if green == nil {
+			log.Fatal("Deployment failed")
+		}
 		log.Println("Rolling back...")
-		c.Workspaces(*clusterID).Delete(ctx, greenName)
+		c.Workspaces(*clusterID).Delete(ctx, green.Name)
 		log.Fatal("Deployment failed")
 	}
 
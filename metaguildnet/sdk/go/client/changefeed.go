@@ -0,0 +1,244 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// ChangefeedEvent is a single changefeed message. Type is one of init, insert, update,
+// delete, table_added/table_dropped (database feeds only), paused (Pending holds the server backlog), overflow (the paused backlog was
+// discarded: Dropped events were lost, so reload the table) or error.
+type ChangefeedEvent = model.ChangefeedEvent
+
+// SubscribeOptions configures DatabaseClient.Subscribe
+type SubscribeOptions struct {
+	// Ops limits the stream to insert, update and/or delete. Empty streams everything.
+	Ops []string
+	// Columns only streams changes touching these columns, trimmed to them.
+	Columns []string
+	// Cursor is a resume token to start from.
+	Cursor string
+	// StartPaused asks the server to buffer events and report pending counts.
+	StartPaused bool
+	// Backlog sets the server's paused buffer size (0 uses the server default).
+	Backlog int
+	// ReconnectDelay is the wait between reconnect attempts (default 1s).
+	ReconnectDelay time.Duration
+	// MaxReconnects caps consecutive failed reconnects. Zero retries until the context
+	// is cancelled; negative disables reconnecting.
+	MaxReconnects int
+}
+
+// Subscribe streams changes for a table over SSE. The returned channel is closed when
+// ctx is cancelled (the cancel for the subscription) or reconnecting gives up. Dropped
+// connections are re-established with Last-Event-ID set to the last resume token seen,
+// and failed attempts are reported as "error" events. Errors establishing the first
+// connection are returned directly.
+func (dc *DatabaseClient) Subscribe(ctx context.Context, dbID, table string, opts SubscribeOptions) (<-chan ChangefeedEvent, error) {
+	s := &changefeedStream{
+		client: dc.client,
+		path:   fmt.Sprintf("/sse/cluster/%s/db/%s/tables/%s/changes", dc.clusterID, dbID, table),
+		table:  table,
+		opts:   opts,
+		lastID: opts.Cursor,
+	}
+	body, err := s.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+	ch := make(chan ChangefeedEvent, 64)
+	go s.run(ctx, body, ch)
+	return ch, nil
+}
+
+// SubscribeDatabase streams changes from every table of a database over one SSE
+// connection, reconnecting like Subscribe. Events carry their table in TableID;
+// "table_added" and "table_dropped" events mark tables created or removed while
+// subscribed. Ops and Columns apply to every table.
+func (dc *DatabaseClient) SubscribeDatabase(ctx context.Context, dbID string, opts SubscribeOptions) (<-chan ChangefeedEvent, error) {
+	s := &changefeedStream{
+		client: dc.client,
+		path:   fmt.Sprintf("/sse/cluster/%s/db/%s/changes", dc.clusterID, dbID),
+		opts:   opts,
+		lastID: opts.Cursor,
+	}
+	body, err := s.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+	ch := make(chan ChangefeedEvent, 64)
+	go s.run(ctx, body, ch)
+	return ch, nil
+}
+
+type changefeedStream struct {
+	client *Client
+	path   string
+	table  string
+	opts   SubscribeOptions
+	lastID string
+}
+
+func (s *changefeedStream) connect(ctx context.Context) (io.ReadCloser, error) {
+	q := url.Values{}
+	if len(s.opts.Ops) > 0 {
+		q.Set("ops", strings.Join(s.opts.Ops, ","))
+	}
+	if len(s.opts.Columns) > 0 {
+		q.Set("columns", strings.Join(s.opts.Columns, ","))
+	}
+	if s.opts.StartPaused {
+		q.Set("pause", "1")
+	}
+	if s.opts.Backlog > 0 {
+		q.Set("backlog", strconv.Itoa(s.opts.Backlog))
+	}
+	if s.opts.Cursor != "" {
+		q.Set("cursor", s.opts.Cursor)
+	}
+	u := s.client.baseURL + s.path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if s.client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.client.token)
+	}
+	if s.lastID != "" {
+		req.Header.Set("Last-Event-ID", s.lastID)
+	}
+	// The client timeout would cut long-lived streams; rely on ctx instead.
+	hc := *s.client.httpClient
+	hc.Timeout = 0
+	resp, err := hc.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, apiErrorFromResponse(resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *changefeedStream) run(ctx context.Context, body io.ReadCloser, ch chan<- ChangefeedEvent) {
+	defer close(ch)
+	delay := s.opts.ReconnectDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for {
+		s.read(ctx, body, ch)
+		body.Close()
+		if ctx.Err() != nil || s.opts.MaxReconnects < 0 {
+			return
+		}
+		failures := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			var err error
+			if body, err = s.connect(ctx); err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			if !s.emit(ctx, ch, ChangefeedEvent{Type: "error", TableID: s.table, Error: err.Error(), TS: time.Now().UTC().Format(time.RFC3339)}) {
+				return
+			}
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusTooManyRequests {
+				return
+			}
+			if s.opts.MaxReconnects > 0 && failures >= s.opts.MaxReconnects {
+				return
+			}
+		}
+	}
+}
+
+// read dispatches SSE events from body until it ends or ctx is cancelled.
+func (s *changefeedStream) read(ctx context.Context, body io.Reader, ch chan<- ChangefeedEvent) {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	var data strings.Builder
+	var id, kind string
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if data.Len() > 0 && !s.dispatch(ctx, ch, id, kind, data.String()) {
+				return
+			}
+			data.Reset()
+			id, kind = "", ""
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment / heartbeat
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "id":
+			id = value
+		case "event":
+			kind = value
+		}
+	}
+	if data.Len() > 0 {
+		s.dispatch(ctx, ch, id, kind, data.String())
+	}
+}
+
+func (s *changefeedStream) dispatch(ctx context.Context, ch chan<- ChangefeedEvent, id, kind, data string) bool {
+	var ev ChangefeedEvent
+	if err := json.Unmarshal([]byte(data), &ev); err != nil {
+		ev = ChangefeedEvent{Type: "error", TableID: s.table, Error: fmt.Sprintf("invalid event: %v", err), TS: time.Now().UTC().Format(time.RFC3339)}
+	}
+	if ev.Type == "" {
+		ev.Type = kind
+	}
+	if id != "" {
+		s.lastID = id
+	} else if ev.Cursor != "" {
+		s.lastID = ev.Cursor
+	}
+	return s.emit(ctx, ch, ev)
+}
+
+func (s *changefeedStream) emit(ctx context.Context, ch chan<- ChangefeedEvent, ev ChangefeedEvent) bool {
+	select {
+	case ch <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
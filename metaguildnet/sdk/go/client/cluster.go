@@ -1,9 +1,13 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"time"
 )
 
 // ClusterClient handles cluster operations
@@ -21,6 +25,7 @@ type Cluster struct {
 	DisableAPIProxy    bool                   `json:"disable_api_proxy,omitempty"`
 	PreferPodProxy     bool                   `json:"prefer_pod_proxy,omitempty"`
 	UsePortForward     bool                   `json:"use_port_forward,omitempty"`
+	ProxyMode          string                 `json:"proxy_mode,omitempty"`
 	IngressDomain      string                 `json:"ingress_domain,omitempty"`
 	IngressClassName   string                 `json:"ingress_class_name,omitempty"`
 	WorkspaceTLSSecret string                 `json:"workspace_tls_secret,omitempty"`
@@ -29,8 +34,23 @@ type Cluster struct {
 	WorkspaceLBEnabled bool                   `json:"workspace_lb_enabled,omitempty"`
 	OrgID              string                 `json:"org_id,omitempty"`
 	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+	// Health is the server's cached reachability check, set by ListWithHealth.
+	Health *ClusterHealthStatus `json:"health,omitempty"`
 }
 
+// ClusterHealthStatus is a cluster's cached reachability summary. Status is ok, error
+// or unknown; Code says why (no_kubeconfig, bad_kubeconfig, cluster_unreachable).
+type ClusterHealthStatus struct {
+	Status    string    `json:"status"`
+	Code      string    `json:"code,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Healthy reports whether the cluster was reachable at CheckedAt.
+func (h *ClusterHealthStatus) Healthy() bool { return h != nil && h.Status == "ok" }
+
 // ClusterSettings represents configurable cluster settings
 type ClusterSettings struct {
 	Name               string `json:"name,omitempty"`
@@ -40,6 +60,7 @@ type ClusterSettings struct {
 	DisableAPIProxy    bool   `json:"disable_api_proxy,omitempty"`
 	PreferPodProxy     bool   `json:"prefer_pod_proxy,omitempty"`
 	UsePortForward     bool   `json:"use_port_forward,omitempty"`
+	ProxyMode          string `json:"proxy_mode,omitempty"` // auto, service, pod or portforward
 	IngressDomain      string `json:"ingress_domain,omitempty"`
 	IngressClassName   string `json:"ingress_class_name,omitempty"`
 	WorkspaceTLSSecret string `json:"workspace_tls_secret,omitempty"`
@@ -49,18 +70,92 @@ type ClusterSettings struct {
 	OrgID              string `json:"org_id,omitempty"`
 }
 
-// List returns all registered clusters
+// List returns all registered clusters. With WithClusterCacheTTL, results younger than
+// the TTL are served from a client-side cache.
 func (cc *ClusterClient) List(ctx context.Context) ([]Cluster, error) {
-	var response struct {
-		Clusters []Cluster `json:"clusters"`
+	c := cc.client
+	if c.clusterCacheTTL <= 0 {
+		return cc.fetch(ctx)
+	}
+
+	c.clusterCache.mu.Lock()
+	defer c.clusterCache.mu.Unlock()
+	if c.clusterCache.clusters != nil && time.Since(c.clusterCache.fetched) < c.clusterCacheTTL {
+		return copyClusters(c.clusterCache.clusters), nil
+	}
+	clusters, err := cc.fetch(ctx)
+	if err != nil {
+		return nil, err
 	}
+	c.clusterCache.clusters = clusters
+	c.clusterCache.fetched = time.Now()
+	return copyClusters(clusters), nil
+}
+
+// Refresh reloads the cluster list from the server, replacing any cached copy
+func (cc *ClusterClient) Refresh(ctx context.Context) ([]Cluster, error) {
+	cc.invalidate()
+	return cc.List(ctx)
+}
+
+func (cc *ClusterClient) fetch(ctx context.Context) ([]Cluster, error) {
+	return cc.fetchPath(ctx, "/api/deploy/clusters")
+}
+
+// ListWithHealth returns all clusters with Health filled from the server's cached
+// reachability checks, in one request instead of one health call per cluster. It
+// bypasses the client-side cluster cache.
+func (cc *ClusterClient) ListWithHealth(ctx context.Context) ([]Cluster, error) {
+	return cc.fetchPath(ctx, "/api/deploy/clusters?withHealth=1")
+}
 
-	err := cc.client.get(ctx, "/api/deploy/clusters", &response)
+// fetchPath reads a cluster list, accepting both a bare array and {"clusters": [...]}.
+func (cc *ClusterClient) fetchPath(ctx context.Context, path string) ([]Cluster, error) {
+	b, err := cc.client.getRaw(ctx, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list clusters: %w", err)
 	}
+	var clusters []Cluster
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &clusters)
+	} else if len(trimmed) > 0 {
+		var response struct {
+			Clusters []Cluster `json:"clusters"`
+		}
+		err = json.Unmarshal(trimmed, &response)
+		clusters = response.Clusters
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode clusters: %w", err)
+	}
+	if clusters == nil {
+		clusters = []Cluster{}
+	}
 
-	return response.Clusters, nil
+	return clusters, nil
+}
+
+// invalidate drops the cached cluster list; called after mutations
+func (cc *ClusterClient) invalidate() {
+	cc.client.clusterCache.mu.Lock()
+	cc.client.clusterCache.clusters = nil
+	cc.client.clusterCache.mu.Unlock()
+}
+
+// copyClusters returns a copy so callers cannot mutate the cached slice or metadata maps
+func copyClusters(in []Cluster) []Cluster {
+	out := make([]Cluster, len(in))
+	copy(out, in)
+	for i := range out {
+		if in[i].Metadata != nil {
+			md := make(map[string]interface{}, len(in[i].Metadata))
+			for k, v := range in[i].Metadata {
+				md[k] = v
+			}
+			out[i].Metadata = md
+		}
+	}
+	return out
 }
 
 // Get returns details for a specific cluster
@@ -90,16 +185,38 @@ func (cc *ClusterClient) Bootstrap(ctx context.Context, kubeconfig []byte) (stri
 	if err != nil {
 		return "", fmt.Errorf("failed to bootstrap cluster: %w", err)
 	}
+	cc.invalidate()
 
 	return response.ClusterID, nil
 }
 
+// KubeconfigTest is the result of TestKubeconfig. Valid means the kubeconfig parsed;
+// Reachable means its API server answered. Error explains the first check that failed.
+type KubeconfigTest struct {
+	Valid         bool   `json:"valid"`
+	Reachable     bool   `json:"reachable"`
+	ServerVersion string `json:"serverVersion,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// TestKubeconfig checks a kubeconfig before Bootstrap without storing it. The host's
+// API proxy fallback (KUBE_PROXY_ADDR) applies, as it would on import.
+func (cc *ClusterClient) TestKubeconfig(ctx context.Context, kubeconfig []byte) (*KubeconfigTest, error) {
+	var res KubeconfigTest
+	err := cc.client.post(ctx, "/api/deploy/clusters/test-kubeconfig", map[string]string{"kubeconfig": string(kubeconfig)}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to test kubeconfig: %w", err)
+	}
+	return &res, nil
+}
+
 // UpdateSettings updates cluster-specific settings
 func (cc *ClusterClient) UpdateSettings(ctx context.Context, id string, settings ClusterSettings) error {
 	err := cc.client.put(ctx, fmt.Sprintf("/api/settings/cluster/%s", id), settings, nil)
 	if err != nil {
 		return fmt.Errorf("failed to update cluster settings: %w", err)
 	}
+	cc.invalidate()
 
 	return nil
 }
@@ -115,24 +232,26 @@ func (cc *ClusterClient) GetSettings(ctx context.Context, id string) (*ClusterSe
 	return &settings, nil
 }
 
-// GetKubeconfig retrieves the stored kubeconfig for a cluster
-func (cc *ClusterClient) GetKubeconfig(ctx context.Context, id string) ([]byte, error) {
-	var response struct {
-		Kubeconfig string `json:"kubeconfig"`
-	}
-
-	err := cc.client.post(ctx, fmt.Sprintf("/api/deploy/clusters/%s?action=kubeconfig", id), nil, &response)
+// Kubeconfig returns the decrypted kubeconfig YAML stored for a cluster, e.g. to
+// bootstrap kubectl access. The result carries cluster credentials: treat it as a
+// secret, never log it, and write it only to files readable by the current user.
+// Requires an authorized token.
+func (cc *ClusterClient) Kubeconfig(ctx context.Context, id string) (string, error) {
+	b, err := cc.client.getRaw(ctx, fmt.Sprintf("/api/deploy/clusters/%s?action=kubeconfig", url.PathEscape(id)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
+		return "", fmt.Errorf("failed to get kubeconfig: %w", err)
 	}
+	return string(b), nil
+}
 
-	kubeconfig, err := base64.StdEncoding.DecodeString(response.Kubeconfig)
+// GetKubeconfig retrieves the stored kubeconfig for a cluster. Like Kubeconfig, the
+// result is sensitive.
+func (cc *ClusterClient) GetKubeconfig(ctx context.Context, id string) ([]byte, error) {
+	kc, err := cc.Kubeconfig(ctx, id)
 	if err != nil {
-		// Try without base64 decoding in case it's plain text
-		return []byte(response.Kubeconfig), nil
+		return nil, err
 	}
-
-	return kubeconfig, nil
+	return []byte(kc), nil
 }
 
 // Delete removes a cluster registration
@@ -141,6 +260,7 @@ func (cc *ClusterClient) Delete(ctx context.Context, id string) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete cluster: %w", err)
 	}
+	cc.invalidate()
 
 	return nil
 }
@@ -167,3 +287,79 @@ type ClusterHealth struct {
 	TSAvailable       bool   `json:"tsnetAvailable"`
 	RecommendedAction string `json:"recommendedAction,omitempty"`
 }
+
+// StorageClass describes a StorageClass available in a cluster
+type StorageClass struct {
+	Name        string `json:"name"`
+	Provisioner string `json:"provisioner"`
+	IsDefault   bool   `json:"isDefault"`
+}
+
+// IngressClass describes an IngressClass available in a cluster
+type IngressClass struct {
+	Name       string `json:"name"`
+	Controller string `json:"controller"`
+}
+
+// StorageClasses lists the cluster's storage classes, sorted by name
+func (cc *ClusterClient) StorageClasses(ctx context.Context, id string) ([]StorageClass, error) {
+	var out []StorageClass
+	if err := cc.client.get(ctx, fmt.Sprintf("/api/cluster/%s/storageclasses", id), &out); err != nil {
+		return nil, fmt.Errorf("failed to list storage classes: %w", err)
+	}
+	return out, nil
+}
+
+// IngressClasses lists the cluster's ingress classes, sorted by name
+func (cc *ClusterClient) IngressClasses(ctx context.Context, id string) ([]IngressClass, error) {
+	var out []IngressClass
+	if err := cc.client.get(ctx, fmt.Sprintf("/api/cluster/%s/ingressclasses", id), &out); err != nil {
+		return nil, fmt.Errorf("failed to list ingress classes: %w", err)
+	}
+	return out, nil
+}
+
+// ClusterEvent is a Kubernetes Warning event somewhere in a cluster
+type ClusterEvent struct {
+	Namespace string    `json:"namespace"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Object    string    `json:"object"`
+	Count     int32     `json:"count,omitempty"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// EventOptions narrows a cluster event listing
+type EventOptions struct {
+	AllNamespaces bool      // all namespaces instead of the cluster's default one
+	Since         time.Time // drop events last seen before this time
+	Limit         int       // lower the server cap of 200
+}
+
+// Events lists recent Warning events in a cluster (node pressure, failed scheduling),
+// newest first
+func (cc *ClusterClient) Events(ctx context.Context, id string, opts EventOptions) ([]ClusterEvent, error) {
+	q := url.Values{}
+	if opts.AllNamespaces {
+		q.Set("allNamespaces", "1")
+	}
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", fmt.Sprint(opts.Limit))
+	}
+	path := fmt.Sprintf("/api/cluster/%s/events", id)
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var out []ClusterEvent
+	if err := cc.client.get(ctx, path, &out); err != nil {
+		return nil, fmt.Errorf("failed to list cluster events: %w", err)
+	}
+	if out == nil {
+		out = []ClusterEvent{}
+	}
+	return out, nil
+}
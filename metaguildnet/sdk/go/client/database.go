@@ -2,7 +2,11 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 
 	"github.com/docxology/GuildNet/internal/model"
 )
@@ -106,23 +110,101 @@ func (dc *DatabaseClient) DeleteTable(ctx context.Context, dbID, table string) e
 	return nil
 }
 
+// Truncate deletes every row in a table, keeping the table and its schema. Returns the
+// number of rows deleted.
+func (dc *DatabaseClient) Truncate(ctx context.Context, dbID, table string) (int, error) {
+	var response struct {
+		Deleted int `json:"deleted"`
+	}
+
+	err := dc.client.post(ctx, fmt.Sprintf("/api/cluster/%s/db/%s/tables/%s/truncate", dc.clusterID, dbID, table), nil, &response)
+	if err != nil {
+		return 0, fmt.Errorf("failed to truncate table: %w", err)
+	}
+
+	return response.Deleted, nil
+}
+
+// SchemaDiff previews replacing a table's schema: added, removed and changed columns,
+// and which of a sample of existing rows the proposed schema would reject. Nothing is
+// written; pk may be empty to keep the current primary key.
+func (dc *DatabaseClient) SchemaDiff(ctx context.Context, dbID, table string, schema []model.ColumnDef, pk string) (*model.SchemaDiff, error) {
+	body := map[string]any{"schema": schema, "primary_key": pk}
+	var diff model.SchemaDiff
+	if err := dc.client.post(ctx, fmt.Sprintf("/api/cluster/%s/db/%s/tables/%s/schema:diff", dc.clusterID, dbID, table), body, &diff); err != nil {
+		return nil, fmt.Errorf("failed to diff table schema: %w", err)
+	}
+	return &diff, nil
+}
+
+// SetAuditReads turns read auditing on or off for a table. While on, every row query,
+// get and batch get writes an audit event with the caller and row count (no values).
+func (dc *DatabaseClient) SetAuditReads(ctx context.Context, dbID, table string, on bool) error {
+	body := map[string]any{"audit_reads": on}
+	if err := dc.client.patch(ctx, fmt.Sprintf("/api/cluster/%s/db/%s/tables/%s", dc.clusterID, dbID, table), body, nil); err != nil {
+		return fmt.Errorf("failed to set audit_reads: %w", err)
+	}
+	return nil
+}
+
 // Query queries rows from a table
 func (dc *DatabaseClient) Query(ctx context.Context, dbID, table, orderBy string, limit int, cursor string, forward bool) ([]map[string]any, string, error) {
-	path := fmt.Sprintf("/api/cluster/%s/db/%s/tables/%s/rows?limit=%d", dc.clusterID, dbID, table, limit)
+	return QueryInto[map[string]any](ctx, dc, dbID, table, QueryOptions{OrderBy: orderBy, Limit: limit, Cursor: cursor, Descending: !forward})
+}
+
+// QueryOptions configures QueryInto.
+type QueryOptions struct {
+	OrderBy string
+	Limit   int
+	// Cursor is the NextCursor of a previous page.
+	Cursor     string
+	Descending bool
+	// Where keeps rows whose field equals the value. Keys may be dotted paths into a json
+	// column ("meta.region"); nested paths are not indexed, so they scan the table.
+	Where map[string]string
+	// Fields limits each row to these top-level columns plus the primary key. Masked
+	// columns stay masked.
+	Fields []string
+}
 
-	if orderBy != "" {
-		path += "&orderBy=" + orderBy
+// QueryInto queries rows from a table and decodes each one into T (typically a struct with
+// json tags), returning the rows and the cursor for the next page:
+//
+//	type User struct {
+//		Name string  `json:"name"`
+//		Age  float64 `json:"age"`
+//	}
+//	users, next, err := client.QueryInto[User](ctx, c.Databases(clusterID), dbID, "users", client.QueryOptions{Limit: 10})
+func QueryInto[T any](ctx context.Context, dc *DatabaseClient, dbID, table string, opts QueryOptions) ([]T, string, error) {
+	path := fmt.Sprintf("/api/cluster/%s/db/%s/tables/%s/rows?limit=%d", dc.clusterID, dbID, table, opts.Limit)
+
+	if opts.OrderBy != "" {
+		path += "&orderBy=" + url.QueryEscape(opts.OrderBy)
 	}
-	if cursor != "" {
-		path += "&cursor=" + cursor
+	if opts.Cursor != "" {
+		path += "&cursor=" + url.QueryEscape(opts.Cursor)
 	}
-	if !forward {
+	if opts.Descending {
 		path += "&forward=false"
 	}
+	if len(opts.Fields) > 0 {
+		path += "&fields=" + url.QueryEscape(strings.Join(opts.Fields, ","))
+	}
+	keys := make([]string, 0, len(opts.Where))
+	for k := range opts.Where {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path += "&" + url.QueryEscape("where["+k+"]") + "=" + url.QueryEscape(opts.Where[k])
+	}
 
+	// The server returns a model.QueryPage; rows/nextCursor are accepted from older servers.
 	var response struct {
-		Rows       []map[string]any `json:"rows"`
-		NextCursor string           `json:"nextCursor"`
+		Items            []json.RawMessage `json:"items"`
+		NextCursor       string            `json:"next_cursor"`
+		Rows             []json.RawMessage `json:"rows"`
+		LegacyNextCursor string            `json:"nextCursor"`
 	}
 
 	err := dc.client.get(ctx, path, &response)
@@ -130,7 +212,20 @@ func (dc *DatabaseClient) Query(ctx context.Context, dbID, table, orderBy string
 		return nil, "", fmt.Errorf("failed to query rows: %w", err)
 	}
 
-	return response.Rows, response.NextCursor, nil
+	raw, next := response.Items, response.NextCursor
+	if raw == nil {
+		raw, next = response.Rows, response.LegacyNextCursor
+	}
+	out := make([]T, 0, len(raw))
+	for i, b := range raw {
+		var row T
+		if err := json.Unmarshal(b, &row); err != nil {
+			return nil, "", fmt.Errorf("failed to decode row %d: %w", i, err)
+		}
+		out = append(out, row)
+	}
+
+	return out, next, nil
 }
 
 // InsertRows inserts multiple rows into a table
@@ -147,9 +242,9 @@ func (dc *DatabaseClient) InsertRows(ctx context.Context, dbID, table string, ro
 	return response.IDs, nil
 }
 
-// UpdateRow updates a single row
+// UpdateRow updates a single row. Returns an error wrapping ErrNotFound when the row does not exist.
 func (dc *DatabaseClient) UpdateRow(ctx context.Context, dbID, table, id string, patch map[string]any) error {
-	err := dc.client.put(ctx, fmt.Sprintf("/api/cluster/%s/db/%s/tables/%s/rows/%s", dc.clusterID, dbID, table, id), patch, nil)
+	err := dc.client.patch(ctx, fmt.Sprintf("/api/cluster/%s/db/%s/tables/%s/rows/%s", dc.clusterID, dbID, table, id), patch, nil)
 	if err != nil {
 		return fmt.Errorf("failed to update row: %w", err)
 	}
@@ -157,7 +252,7 @@ func (dc *DatabaseClient) UpdateRow(ctx context.Context, dbID, table, id string,
 	return nil
 }
 
-// DeleteRow deletes a single row
+// DeleteRow deletes a single row. Returns an error wrapping ErrNotFound when the row does not exist.
 func (dc *DatabaseClient) DeleteRow(ctx context.Context, dbID, table, id string) error {
 	err := dc.client.delete(ctx, fmt.Sprintf("/api/cluster/%s/db/%s/tables/%s/rows/%s", dc.clusterID, dbID, table, id))
 	if err != nil {
@@ -167,7 +262,18 @@ func (dc *DatabaseClient) DeleteRow(ctx context.Context, dbID, table, id string)
 	return nil
 }
 
-// GetRow retrieves a single row by ID
+// RestoreRow undoes a soft delete on a table created with SoftDelete. Returns an error
+// wrapping ErrNotFound when the row does not exist or is not deleted.
+func (dc *DatabaseClient) RestoreRow(ctx context.Context, dbID, table, id string) error {
+	err := dc.client.post(ctx, fmt.Sprintf("/api/cluster/%s/db/%s/tables/%s/rows/%s/restore", dc.clusterID, dbID, table, id), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to restore row: %w", err)
+	}
+
+	return nil
+}
+
+// GetRow retrieves a single row by ID. Returns an error wrapping ErrNotFound when the row does not exist.
 func (dc *DatabaseClient) GetRow(ctx context.Context, dbID, table, id string) (map[string]any, error) {
 	var row map[string]any
 
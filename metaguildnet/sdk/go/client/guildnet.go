@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -20,6 +22,41 @@ var (
 	ErrServerError  = errors.New("server error")
 )
 
+// APIError is returned for non-2xx responses. It unwraps to the matching sentinel
+// (ErrNotFound, ErrUnauthorized, ErrServerError) so errors.Is keeps working.
+type APIError struct {
+	StatusCode int               `json:"-"`
+	Code       string            `json:"code"`
+	Message    string            `json:"message"`
+	RequestID  string            `json:"request_id,omitempty"`
+	Details    any               `json:"details,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if len(e.Fields) > 0 {
+		return fmt.Sprintf("status %d (%s): %s %v", e.StatusCode, e.Code, msg, e.Fields)
+	}
+	return fmt.Sprintf("status %d (%s): %s", e.StatusCode, e.Code, msg)
+}
+
+// Unwrap maps the status code to the package sentinel errors.
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode >= 500:
+		return ErrServerError
+	}
+	return nil
+}
+
 // Client is the main MetaGuildNet SDK client
 type Client struct {
 	baseURL    string
@@ -27,6 +64,16 @@ type Client struct {
 	httpClient *http.Client
 	maxRetries int
 	retryDelay time.Duration
+
+	clusterCacheTTL time.Duration
+	clusterCache    clusterListCache
+}
+
+// clusterListCache holds the last Clusters().List result when caching is enabled
+type clusterListCache struct {
+	mu       sync.Mutex
+	clusters []Cluster
+	fetched  time.Time
 }
 
 // ClientOption configures a Client
@@ -60,6 +107,13 @@ func WithRetryBackoff(d time.Duration) ClientOption {
 	}
 }
 
+// WithClusterCacheTTL caches Clusters().List results for d. Zero (default) disables caching.
+func WithClusterCacheTTL(d time.Duration) ClientOption {
+	return func(client *Client) {
+		client.clusterCacheTTL = d
+	}
+}
+
 // NewClient creates a new MetaGuildNet client
 func NewClient(baseURL, token string, opts ...ClientOption) *Client {
 	c := &Client{
@@ -99,6 +153,11 @@ func (c *Client) Databases(clusterID string) *DatabaseClient {
 	return &DatabaseClient{client: c, clusterID: clusterID}
 }
 
+// Jobs returns a job operations client
+func (c *Client) Jobs() *JobClient {
+	return &JobClient{client: c}
+}
+
 // Health returns a health operations client
 func (c *Client) Health() *HealthClient {
 	return &HealthClient{client: c}
@@ -106,6 +165,11 @@ func (c *Client) Health() *HealthClient {
 
 // doRequest executes an HTTP request with retries
 func (c *Client) doRequest(ctx context.Context, method, path string, body any, result any) error {
+	return c.doRequestWithHeaders(ctx, method, path, nil, body, result)
+}
+
+// doRequestWithHeaders is doRequest with extra headers sent on every attempt.
+func (c *Client) doRequestWithHeaders(ctx context.Context, method, path string, header http.Header, body any, result any) error {
 	var lastErr error
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
@@ -117,7 +181,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any, r
 			}
 		}
 
-		err := c.doRequestOnce(ctx, method, path, body, result)
+		err := c.doRequestOnce(ctx, method, path, header, body, result)
 		if err == nil {
 			return nil
 		}
@@ -128,12 +192,16 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any, r
 		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthorized) {
 			return err
 		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusTooManyRequests {
+			return err
+		}
 	}
 
 	return lastErr
 }
 
-func (c *Client) doRequestOnce(ctx context.Context, method, path string, body any, result any) error {
+func (c *Client) doRequestOnce(ctx context.Context, method, path string, header http.Header, body any, result any) error {
 	url := c.baseURL + path
 
 	var bodyReader io.Reader
@@ -150,6 +218,11 @@ func (c *Client) doRequestOnce(ctx context.Context, method, path string, body an
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -178,19 +251,44 @@ func (c *Client) doRequestOnce(ctx context.Context, method, path string, body an
 		}
 		return nil
 
-	case resp.StatusCode == http.StatusNotFound:
-		return ErrNotFound
-
-	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
-		return ErrUnauthorized
+	default:
+		return apiErrorFromResponse(resp)
+	}
+}
 
-	case resp.StatusCode >= 500:
-		return ErrServerError
+// apiErrorFromResponse decodes the JSON error envelope of a non-2xx response, falling
+// back to the raw body as the message.
+func apiErrorFromResponse(resp *http.Response) *APIError {
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if err := json.Unmarshal(bodyBytes, apiErr); err != nil || (apiErr.Code == "" && apiErr.Message == "") {
+		apiErr.Message = strings.TrimSpace(string(bodyBytes))
+	}
+	apiErr.StatusCode = resp.StatusCode
+	return apiErr
+}
 
-	default:
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(bodyBytes))
+// getRaw performs a single GET and returns the raw response body (for non-JSON payloads)
+func (c *Client) getRaw(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ErrTimeout
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, apiErrorFromResponse(resp)
+	}
+	return io.ReadAll(resp.Body)
 }
 
 // get is a convenience method for GET requests
@@ -208,6 +306,11 @@ func (c *Client) put(ctx context.Context, path string, body any, result any) err
 	return c.doRequest(ctx, http.MethodPut, path, body, result)
 }
 
+// patch is a convenience method for PATCH requests
+func (c *Client) patch(ctx context.Context, path string, body any, result any) error {
+	return c.doRequest(ctx, http.MethodPatch, path, body, result)
+}
+
 // delete is a convenience method for DELETE requests
 func (c *Client) delete(ctx context.Context, path string) error {
 	return c.doRequest(ctx, http.MethodDelete, path, nil, nil)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/docxology/GuildNet/internal/model"
 )
 
 // HealthClient handles health and status operations
@@ -34,6 +36,26 @@ type PublishedService struct {
 	AddedAt   time.Time `json:"added_at"`
 }
 
+// TSNetStatus summarizes the hostapp's tailnet node and peers.
+type TSNetStatus = model.TSNetStatus
+
+// TSNet returns the hostapp's tsnet node, peers and exit-node/subnet-route info. Only
+// online peers are listed unless all is set.
+func (hc *HealthClient) TSNet(ctx context.Context, all bool) (*TSNetStatus, error) {
+	var st TSNetStatus
+
+	path := "/api/tsnet/status"
+	if all {
+		path += "?all=1"
+	}
+	err := hc.client.get(ctx, path, &st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tsnet status: %w", err)
+	}
+
+	return &st, nil
+}
+
 // Global returns overall system health
 func (hc *HealthClient) Global(ctx context.Context) (*HealthSummary, error) {
 	var health HealthSummary
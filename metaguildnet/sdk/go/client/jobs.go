@@ -0,0 +1,161 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+// JobClient handles orchestration job operations
+type JobClient struct {
+	client *Client
+}
+
+// Job is a job record as returned by /api/jobs/{id}. Progress is 0-1.
+type Job struct {
+	ID       string          `json:"id"`
+	Kind     string          `json:"kind"`
+	Status   string          `json:"status"`
+	Progress float64         `json:"progress"`
+	Created  time.Time       `json:"created"`
+	Updated  time.Time       `json:"updated"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	// Retriable is set on failed jobs that Retry may resubmit.
+	Retriable bool   `json:"retriable,omitempty"`
+	RetryOf   string `json:"retryOf,omitempty"`
+	RetriedBy string `json:"retriedBy,omitempty"`
+}
+
+// Done reports whether the job reached a terminal status.
+func (j *Job) Done() bool {
+	switch j.Status {
+	case "succeeded", "failed", "canceled":
+		return true
+	}
+	return false
+}
+
+// JobLogEvent is a structured job log line. Progress updates have Step "progress" and
+// carry Percent (0-100) with Message describing the current step.
+type JobLogEvent struct {
+	Timestamp time.Time      `json:"ts"`
+	Job       string         `json:"job"`
+	Step      string         `json:"step,omitempty"`
+	Message   string         `json:"msg"`
+	KV        map[string]any `json:"kv,omitempty"`
+	Err       string         `json:"err,omitempty"`
+	Percent   int            `json:"percent,omitempty"`
+	Seq       int64          `json:"seq,omitempty"`
+}
+
+// IsProgress reports whether the event is a handler progress update.
+func (e JobLogEvent) IsProgress() bool { return e.Step == "progress" }
+
+// IsTruncated reports whether the event is the sentinel heading a trimmed job log.
+func (e JobLogEvent) IsTruncated() bool { return e.Step == "truncated" }
+
+// Dropped returns how many earlier lines the server trimmed (truncation sentinels only).
+func (e JobLogEvent) Dropped() int {
+	n, _ := e.KV["dropped"].(float64)
+	return int(n)
+}
+
+// Get returns a job by ID
+func (jc *JobClient) Get(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := jc.client.get(ctx, "/api/jobs/"+url.PathEscape(id), &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Retry resubmits a failed, retriable job with the same kind and spec and returns the
+// new job's ID. A job can be retried once.
+func (jc *JobClient) Retry(ctx context.Context, id string) (string, error) {
+	var resp struct {
+		JobID string `json:"jobId"`
+	}
+	if err := jc.client.post(ctx, "/api/jobs/"+url.PathEscape(id)+"?action=retry", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.JobID, nil
+}
+
+// Logs returns the job's recorded log events. The server caps stored logs; a trimmed log
+// starts with an IsTruncated event reporting how many earlier lines were dropped.
+func (jc *JobClient) Logs(ctx context.Context, id string) ([]JobLogEvent, error) {
+	b, err := jc.client.getRaw(ctx, "/api/jobs-logs/"+url.PathEscape(id))
+	if err != nil {
+		return nil, err
+	}
+	var out []JobLogEvent
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e JobLogEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// StreamLogs streams a job's log and progress events over /ws/jobs. The server replays
+// the recorded log first (headed by the IsTruncated sentinel when it was trimmed) and then
+// follows live events. The channel is closed once the job finishes and its remaining
+// events have been sent, or when ctx is cancelled.
+func (jc *JobClient) StreamLogs(ctx context.Context, id string) (<-chan JobLogEvent, error) {
+	if _, err := jc.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	hdr := http.Header{}
+	if jc.client.token != "" {
+		hdr.Set("Authorization", "Bearer "+jc.client.token)
+	}
+	// The client timeout would cut long-running jobs; rely on ctx instead.
+	hc := *jc.client.httpClient
+	hc.Timeout = 0
+	u := jc.client.baseURL + "/ws/jobs?" + url.Values{"id": {id}}.Encode()
+	conn, _, err := websocket.Dial(ctx, u, &websocket.DialOptions{HTTPClient: &hc, HTTPHeader: hdr})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream job logs: %w", err)
+	}
+	conn.SetReadLimit(1 << 20)
+	ch := make(chan JobLogEvent, 100)
+
+	go func() {
+		defer close(ch)
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		for {
+			// The server closes the connection once the job finishes
+			_, b, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var e JobLogEvent
+			if err := json.Unmarshal(b, &e); err != nil {
+				continue
+			}
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
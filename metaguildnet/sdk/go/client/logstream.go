@@ -0,0 +1,168 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// LogStream is a live log follow started by FollowLogs.
+type LogStream struct {
+	// C delivers log lines; it is closed when the server ends the stream or ctx is
+	// cancelled.
+	C         <-chan LogEvent
+	requestID string
+	eof       *model.LogStreamEOF
+}
+
+// RequestID returns the server's X-Request-Id for this stream. Quote it when reporting a
+// stream that stopped; the server logs it when the stream opens and closes.
+func (ls *LogStream) RequestID() string { return ls.requestID }
+
+// EOF reports how the stream ended; read it after C is closed. It is non-nil when the
+// server said the container log ended, e.g. because the container exited, with the exit
+// reason when known. Nil means the connection dropped or ctx ended. Follow again to pick
+// up a restarted container or a replacement pod.
+func (ls *LogStream) EOF() *model.LogStreamEOF { return ls.eof }
+
+// FollowLogs follows workspace logs over SSE. opts.TailLines asks for that many earlier
+// lines first and opts.Container selects the container; the other options are ignored.
+func (wc *WorkspaceClient) FollowLogs(ctx context.Context, name string, opts LogOptions) (*LogStream, error) {
+	u := fmt.Sprintf("%s/api/cluster/%s/workspaces/%s/logs/stream", wc.client.baseURL, wc.clusterID, url.PathEscape(name))
+	q := url.Values{}
+	if opts.TailLines > 0 {
+		q.Set("tail", strconv.Itoa(opts.TailLines))
+	}
+	if opts.Container != "" {
+		q.Set("container", opts.Container)
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if wc.client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+wc.client.token)
+	}
+	// The client timeout would cut long-lived streams; rely on ctx instead.
+	hc := *wc.client.httpClient
+	hc.Timeout = 0
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, apiErrorFromResponse(resp)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	ls := &LogStream{requestID: resp.Header.Get("X-Request-Id")}
+	// The meta frame comes first; read it before returning so RequestID is set.
+	var pendingKind, pending string
+	if kind, data, ok := readSSEEvent(sc); ok {
+		if kind == "meta" {
+			var meta model.LogStreamMeta
+			if json.Unmarshal([]byte(data), &meta) == nil && meta.RequestID != "" {
+				ls.requestID = meta.RequestID
+			}
+		} else {
+			pendingKind, pending = kind, data
+		}
+	}
+
+	ch := make(chan LogEvent, 100)
+	ls.C = ch
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		// handle reports whether to keep reading. Untyped data frames are log lines from
+		// servers that predate typed events.
+		handle := func(kind, data string) bool {
+			switch kind {
+			case "eof":
+				var eof model.LogStreamEOF
+				if json.Unmarshal([]byte(data), &eof) == nil {
+					ls.eof = &eof
+				}
+				return false
+			case "", "log":
+			default:
+				return true
+			}
+			var line struct {
+				T         string `json:"t"`
+				LVL       string `json:"lvl"`
+				MSG       string `json:"msg"`
+				Pod       string `json:"pod"`
+				Container string `json:"container"`
+			}
+			if json.Unmarshal([]byte(data), &line) != nil {
+				return true
+			}
+			ev := LogEvent{Message: line.MSG, Level: line.LVL, Pod: line.Pod, Container: line.Container}
+			ev.Timestamp, _ = time.Parse(time.RFC3339, line.T)
+			select {
+			case ch <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if pending != "" && !handle(pendingKind, pending) {
+			return
+		}
+		for {
+			kind, data, ok := readSSEEvent(sc)
+			if !ok || !handle(kind, data) {
+				return
+			}
+		}
+	}()
+	return ls, nil
+}
+
+// readSSEEvent returns the next event with data, skipping comments and heartbeats.
+func readSSEEvent(sc *bufio.Scanner) (kind, data string, ok bool) {
+	var b strings.Builder
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if b.Len() > 0 {
+				return kind, b.String(), true
+			}
+			kind = ""
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(value)
+		case "event":
+			kind = value
+		}
+	}
+	if b.Len() > 0 {
+		return kind, b.String(), true
+	}
+	return "", "", false
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+// WorkspaceTemplate is a named workspace spec stored on the host.
+type WorkspaceTemplate = model.WorkspaceTemplate
+
+// TemplateClient manages workspace creation templates
+type TemplateClient struct {
+	client *Client
+}
+
+// Templates returns a workspace template client
+func (c *Client) Templates() *TemplateClient {
+	return &TemplateClient{client: c}
+}
+
+// List returns all templates sorted by name
+func (tc *TemplateClient) List(ctx context.Context) ([]WorkspaceTemplate, error) {
+	var out []WorkspaceTemplate
+
+	err := tc.client.get(ctx, "/api/templates", &out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	return out, nil
+}
+
+// Get returns one template; ErrNotFound when it does not exist
+func (tc *TemplateClient) Get(ctx context.Context, name string) (*WorkspaceTemplate, error) {
+	var out WorkspaceTemplate
+
+	err := tc.client.get(ctx, "/api/templates/"+url.PathEscape(name), &out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return &out, nil
+}
+
+// Put creates or replaces the template named t.Name
+func (tc *TemplateClient) Put(ctx context.Context, t WorkspaceTemplate) (*WorkspaceTemplate, error) {
+	var out WorkspaceTemplate
+
+	err := tc.client.post(ctx, "/api/templates", t, &out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save template: %w", err)
+	}
+
+	return &out, nil
+}
+
+// Delete removes a template
+func (tc *TemplateClient) Delete(ctx context.Context, name string) error {
+	err := tc.client.delete(ctx, "/api/templates/"+url.PathEscape(name))
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+// CreateFromTemplate creates a workspace from a stored template. Non-empty fields of
+// overrides replace the template's (labels merge key by key); an empty Name lets the
+// server generate one.
+func (wc *WorkspaceClient) CreateFromTemplate(ctx context.Context, template string, overrides WorkspaceSpec) (*Workspace, error) {
+	var response struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+
+	body, err := templateOverrides(overrides)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set("Idempotency-Key", newIdempotencyKey())
+	path := fmt.Sprintf("/api/cluster/%s/workspaces?template=%s", wc.clusterID, url.QueryEscape(template))
+	err = wc.client.doRequestWithHeaders(ctx, http.MethodPost, path, header, body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace from template: %w", err)
+	}
+
+	return &Workspace{
+		ID:     response.ID,
+		Name:   response.ID,
+		Image:  overrides.Image,
+		Status: response.Status,
+	}, nil
+}
+
+// templateOverrides encodes spec without the empty name/image fields, which would
+// otherwise blank out the template's values.
+func templateOverrides(spec WorkspaceSpec) (map[string]any, error) {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]any{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	for _, k := range []string{"name", "image"} {
+		if out[k] == "" {
+			delete(out, k)
+		}
+	}
+	return out, nil
+}
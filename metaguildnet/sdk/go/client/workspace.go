@@ -2,7 +2,14 @@ package client
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,7 +24,7 @@ type Workspace struct {
 	ID            string            `json:"id"`
 	Name          string            `json:"name"`
 	Image         string            `json:"image"`
-	Status        string            `json:"status"` // Pending, Running, Failed, Terminating
+	Status        string            `json:"status"` // Pending, Running, Succeeded, Failed, Terminating
 	ReadyReplicas int32             `json:"readyReplicas"`
 	ServiceDNS    string            `json:"serviceDNS,omitempty"`
 	ServiceIP     string            `json:"serviceIP,omitempty"`
@@ -26,6 +33,23 @@ type Workspace struct {
 	Ports         []WorkspacePort   `json:"ports,omitempty"`
 	Labels        map[string]string `json:"labels,omitempty"`
 	CreatedAt     time.Time         `json:"createdAt,omitempty"`
+	LastError     string            `json:"lastError,omitempty"`
+	// Endpoints lists one proxy entry point per declared port (filled by Get). The first
+	// is the default port, which ProxyURL also reaches.
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+	// ProxyAuth is true when the proxy injects the app's credentials itself (filled by Get)
+	ProxyAuth bool `json:"proxyAuth,omitempty"`
+	// Containers names the containers of the workspace pods, for LogOptions.Container
+	// (filled by Get; empty before pods exist)
+	Containers []string `json:"containers,omitempty"`
+}
+
+// Endpoint is a proxy entry point for one workspace port
+type Endpoint struct {
+	PortName string `json:"portName,omitempty"`
+	Port     int32  `json:"port"`
+	Scheme   string `json:"scheme"` // scheme the app serves on this port: http or https
+	ProxyURL string `json:"proxyURL"`
 }
 
 // WorkspaceSpec defines workspace creation parameters
@@ -37,6 +61,16 @@ type WorkspaceSpec struct {
 	Args   []string          `json:"args,omitempty"`
 	Labels map[string]string `json:"labels,omitempty"`
 	Notes  string            `json:"notes,omitempty"`
+	// RunMode "Job" runs the workspace once to completion instead of as a service.
+	RunMode string        `json:"runMode,omitempty"`
+	Job     *WorkspaceJob `json:"job,omitempty"`
+}
+
+// WorkspaceJob tunes a RunMode "Job" workspace
+type WorkspaceJob struct {
+	RestartPolicy         string `json:"restartPolicy,omitempty"` // Never (default) or OnFailure
+	BackoffLimit          *int32 `json:"backoffLimit,omitempty"`
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
 }
 
 // EnvVar represents an environment variable
@@ -64,6 +98,9 @@ type LogOptions struct {
 	TailLines int
 	Follow    bool
 	Since     time.Time
+	// Container selects a container of the workspace pods (see Workspace.Containers);
+	// empty means the main workspace container.
+	Container string
 }
 
 // LogEvent represents a streaming log event
@@ -72,6 +109,7 @@ type LogEvent struct {
 	Message   string    `json:"msg"`
 	Level     string    `json:"level,omitempty"`
 	Pod       string    `json:"pod,omitempty"`
+	Container string    `json:"container,omitempty"`
 }
 
 // List returns all workspaces in the cluster
@@ -116,22 +154,76 @@ func (wc *WorkspaceClient) List(ctx context.Context) ([]Workspace, error) {
 	return workspaces, nil
 }
 
-// Create creates a new workspace
-func (wc *WorkspaceClient) Create(ctx context.Context, spec WorkspaceSpec) (*Workspace, error) {
+// Create creates a new workspace. Each call uses a fresh Idempotency-Key that is reused
+// across the client's retries, so a retried create never produces a duplicate. When
+// spec.Name is already taken the server appends a random suffix; the returned
+// Workspace.Name is the name actually created. Pass WithWaitReady to block until the
+// workspace is ready.
+func (wc *WorkspaceClient) Create(ctx context.Context, spec WorkspaceSpec, opts ...CreateOption) (*Workspace, error) {
+	return wc.CreateWithIdempotencyKey(ctx, spec, newIdempotencyKey(), opts...)
+}
+
+// CreateOption configures Create
+type CreateOption func(*createOptions)
+
+type createOptions struct {
+	waitTimeout time.Duration
+}
+
+// WithWaitReady makes Create wait up to timeout for the workspace to become ready, as
+// Wait does, and return it as fetched once ready. A Failed phase ends the wait early.
+// When waiting fails, the created workspace is returned along with the error so the
+// caller can clean it up.
+func WithWaitReady(timeout time.Duration) CreateOption {
+	return func(o *createOptions) { o.waitTimeout = timeout }
+}
+
+// CreateWithIdempotencyKey creates a workspace using a caller-chosen Idempotency-Key.
+// Repeating the call with the same key and spec returns the original result.
+func (wc *WorkspaceClient) CreateWithIdempotencyKey(ctx context.Context, spec WorkspaceSpec, key string, opts ...CreateOption) (*Workspace, error) {
+	var o createOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ws, err := wc.create(ctx, spec, key)
+	if err != nil || o.waitTimeout <= 0 {
+		return ws, err
+	}
+	if err := wc.Wait(ctx, ws.Name, o.waitTimeout); err != nil {
+		return ws, err
+	}
+	ready, err := wc.Get(ctx, ws.Name)
+	if err != nil {
+		return ws, err
+	}
+	ready.ID = ws.ID
+	return ready, nil
+}
+
+func (wc *WorkspaceClient) create(ctx context.Context, spec WorkspaceSpec, key string) (*Workspace, error) {
 	var response struct {
 		ID     string `json:"id"`
+		Name   string `json:"name"`
 		Status string `json:"status"`
 	}
 
-	err := wc.client.post(ctx, fmt.Sprintf("/api/cluster/%s/workspaces", wc.clusterID), spec, &response)
+	header := http.Header{}
+	if key != "" {
+		header.Set("Idempotency-Key", key)
+	}
+	err := wc.client.doRequestWithHeaders(ctx, http.MethodPost, fmt.Sprintf("/api/cluster/%s/workspaces", wc.clusterID), header, spec, &response)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create workspace: %w", err)
 	}
 
-	// Return minimal workspace info
+	// Return minimal workspace info; older servers only report the id
+	name := response.Name
+	if name == "" {
+		name = response.ID
+	}
 	return &Workspace{
 		ID:     response.ID,
-		Name:   spec.Name,
+		Name:   name,
 		Image:  spec.Image,
 		Status: response.Status,
 	}, nil
@@ -170,6 +262,30 @@ func (wc *WorkspaceClient) Get(ctx context.Context, name string) (*Workspace, er
 		if externalURL, ok := status["externalURL"].(string); ok {
 			ws.ExternalURL = externalURL
 		}
+		if lastError, ok := status["lastError"].(string); ok {
+			ws.LastError = lastError
+		}
+	}
+
+	// Older servers report no endpoints
+	if raw, ok := response["endpoints"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			_ = json.Unmarshal(b, &ws.Endpoints)
+		}
+	}
+	for i, ep := range ws.Endpoints {
+		// Relative links are served by the Host App this client talks to
+		if strings.HasPrefix(ep.ProxyURL, "/") {
+			ws.Endpoints[i].ProxyURL = strings.TrimRight(wc.client.baseURL, "/") + ep.ProxyURL
+		}
+	}
+	ws.ProxyAuth, _ = response["proxyAuth"].(bool)
+	if list, ok := response["containers"].([]interface{}); ok {
+		for _, c := range list {
+			if name, ok := c.(string); ok {
+				ws.Containers = append(ws.Containers, name)
+			}
+		}
 	}
 
 	return ws, nil
@@ -185,13 +301,112 @@ func (wc *WorkspaceClient) Delete(ctx context.Context, name string) error {
 	return nil
 }
 
+// DeleteByLabel deletes all workspaces matching a label selector (e.g. "e2e=scale-test")
+// and returns the names of the deleted workspaces.
+func (wc *WorkspaceClient) DeleteByLabel(ctx context.Context, selector string) ([]string, error) {
+	var response struct {
+		Deleted []string          `json:"deleted"`
+		Failed  map[string]string `json:"failed,omitempty"`
+	}
+
+	path := fmt.Sprintf("/api/cluster/%s/workspaces?labelSelector=%s", wc.clusterID, url.QueryEscape(selector))
+	err := wc.client.doRequest(ctx, http.MethodDelete, path, nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete workspaces: %w", err)
+	}
+	if len(response.Failed) > 0 {
+		return response.Deleted, fmt.Errorf("failed to delete %d workspaces: %v", len(response.Failed), response.Failed)
+	}
+
+	return response.Deleted, nil
+}
+
+// WorkspaceDescription combines spec, status, recent events, pod statuses and log tail
+type WorkspaceDescription struct {
+	Name   string                 `json:"name"`
+	Spec   map[string]interface{} `json:"spec,omitempty"`
+	Status map[string]interface{} `json:"status,omitempty"`
+	Labels map[string]string      `json:"labels,omitempty"`
+	Events []WorkspaceEvent       `json:"events"`
+	Pods   []WorkspacePod         `json:"pods"`
+	Logs   []string               `json:"logs"`
+	Errors map[string]string      `json:"errors,omitempty"` // partial failures (events, pods, logs/<pod>)
+}
+
+// WorkspaceEvent is a Kubernetes event related to a workspace
+type WorkspaceEvent struct {
+	Type     string    `json:"type"`
+	Reason   string    `json:"reason"`
+	Message  string    `json:"message"`
+	Object   string    `json:"object"`
+	Count    int32     `json:"count,omitempty"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// WorkspacePod summarizes a pod backing a workspace
+type WorkspacePod struct {
+	Name       string            `json:"name"`
+	Phase      string            `json:"phase"`
+	Node       string            `json:"node,omitempty"`
+	PodIP      string            `json:"podIP,omitempty"`
+	StartTime  *time.Time        `json:"startTime,omitempty"`
+	Containers []ContainerStatus `json:"containers"`
+	Conditions map[string]string `json:"conditions,omitempty"`
+}
+
+// ContainerStatus is the state of a single container in a workspace pod
+type ContainerStatus struct {
+	Name     string `json:"name"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	State    string `json:"state"` // running, waiting, terminated
+	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Describe returns a combined debugging view of a workspace, like `kubectl describe`
+func (wc *WorkspaceClient) Describe(ctx context.Context, name string) (*WorkspaceDescription, error) {
+	var desc WorkspaceDescription
+	err := wc.client.get(ctx, fmt.Sprintf("/api/cluster/%s/workspaces/%s/describe", wc.clusterID, name), &desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe workspace: %w", err)
+	}
+
+	return &desc, nil
+}
+
+// WorkspaceCredentials is the password the operator generated for a code-server workspace
+type WorkspaceCredentials struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// Credentials returns the generated login password of a code-server workspace. Requires
+// the Host App token (or a loopback connection).
+func (wc *WorkspaceClient) Credentials(ctx context.Context, name string) (*WorkspaceCredentials, error) {
+	var creds WorkspaceCredentials
+	err := wc.client.get(ctx, fmt.Sprintf("/api/cluster/%s/workspaces/%s/credentials", wc.clusterID, name), &creds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace credentials: %w", err)
+	}
+
+	return &creds, nil
+}
+
 // Logs retrieves workspace logs
 func (wc *WorkspaceClient) Logs(ctx context.Context, name string, opts LogOptions) ([]LogLine, error) {
 	path := fmt.Sprintf("/api/cluster/%s/workspaces/%s/logs", wc.clusterID, name)
 
 	// Add query parameters
+	q := url.Values{}
 	if opts.TailLines > 0 {
-		path += fmt.Sprintf("?tail=%d", opts.TailLines)
+		q.Set("tail", strconv.Itoa(opts.TailLines))
+	}
+	if opts.Container != "" {
+		q.Set("container", opts.Container)
+	}
+	if len(q) > 0 {
+		path += "?" + q.Encode()
 	}
 
 	var response []struct {
@@ -264,7 +479,8 @@ func (wc *WorkspaceClient) StreamLogs(ctx context.Context, name string) (<-chan
 	return ch, nil
 }
 
-// Wait waits for workspace to reach Running status
+// Wait waits for workspace to reach Running status (or Succeeded, for a job that
+// finished before it was seen running)
 func (wc *WorkspaceClient) Wait(ctx context.Context, name string, timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -283,17 +499,61 @@ func (wc *WorkspaceClient) Wait(ctx context.Context, name string, timeout time.D
 			}
 
 			switch ws.Status {
-			case "Running":
+			case "Running", "Succeeded":
 				return nil
 			case "Failed":
+				if ws.LastError != "" {
+					return fmt.Errorf("workspace failed: %s", ws.LastError)
+				}
 				return fmt.Errorf("workspace failed")
 			}
 		}
 	}
 }
 
+// WaitComplete waits for a RunMode "Job" workspace to finish. It returns nil when the
+// job succeeded and an error carrying the failure reason when it failed.
+func (wc *WorkspaceClient) WaitComplete(ctx context.Context, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for workspace job: %w", ctx.Err())
+		case <-ticker.C:
+			ws, err := wc.Get(ctx, name)
+			if err != nil {
+				continue
+			}
+
+			switch ws.Status {
+			case "Succeeded":
+				return nil
+			case "Failed":
+				if ws.LastError != "" {
+					return fmt.Errorf("workspace job failed: %s", ws.LastError)
+				}
+				return fmt.Errorf("workspace job failed")
+			}
+		}
+	}
+}
+
 // ProxyURL returns the proxy URL for accessing the workspace
 func (wc *WorkspaceClient) ProxyURL(name string) string {
 	return fmt.Sprintf("%s/api/cluster/%s/proxy/server/%s/",
 		wc.client.baseURL, wc.clusterID, name)
 }
+
+// newIdempotencyKey returns a random key for a single logical create.
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
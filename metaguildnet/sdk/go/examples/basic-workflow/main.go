@@ -74,27 +74,13 @@ func main() {
 		},
 	}
 
-	ws, err := c.Workspaces(clusterID).Create(ctx, spec)
-	if err != nil {
-		log.Fatalf("Failed to create workspace: %v", err)
-	}
-
-	fmt.Printf("Workspace created: %s (ID: %s)\n", ws.Name, ws.ID)
-
-	// Wait for workspace to be ready
-	fmt.Println("\nWaiting for workspace to be ready...")
-	err = c.Workspaces(clusterID).Wait(ctx, workspaceName, 5*time.Minute)
+	// Create and wait for the workspace to be ready
+	ws, err := c.Workspaces(clusterID).Create(ctx, spec, client.WithWaitReady(5*time.Minute))
 	if err != nil {
 		log.Fatalf("Workspace failed to start: %v", err)
 	}
 
-	fmt.Println("Workspace is ready!")
-
-	// Get workspace details
-	ws, err = c.Workspaces(clusterID).Get(ctx, workspaceName)
-	if err != nil {
-		log.Fatalf("Failed to get workspace: %v", err)
-	}
+	fmt.Printf("Workspace is ready: %s (ID: %s)\n", ws.Name, ws.ID)
 
 	fmt.Printf("\nWorkspace details:\n")
 	fmt.Printf("  Status: %s\n", ws.Status)
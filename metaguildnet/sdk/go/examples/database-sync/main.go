@@ -18,6 +18,14 @@ import (
 // 4. Query data
 // 5. Optionally sync data between clusters
 
+// user is a row of the example "users" table.
+type user struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Age   int    `json:"age"`
+}
+
 func main() {
 	apiURL := os.Getenv("MGN_API_URL")
 	if apiURL == "" {
@@ -119,14 +127,14 @@ func main() {
 	// Query data
 	fmt.Println("\nQuerying data...")
 
-	results, nextCursor, err := c.Databases(clusterID).Query(ctx, db.ID, "users", "name", 10, "", true)
+	results, nextCursor, err := client.QueryInto[user](ctx, c.Databases(clusterID), db.ID, "users", client.QueryOptions{OrderBy: "name", Limit: 10})
 	if err != nil {
 		log.Fatalf("Failed to query rows: %v", err)
 	}
 
 	fmt.Printf("Query returned %d rows:\n", len(results))
-	for _, row := range results {
-		fmt.Printf("  - %s <%s> (age: %.0f)\n", row["name"], row["email"], row["age"])
+	for _, u := range results {
+		fmt.Printf("  - %s <%s> (age: %d)\n", u.Name, u.Email, u.Age)
 	}
 
 	if nextCursor != "" {
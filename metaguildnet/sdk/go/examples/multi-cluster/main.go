@@ -28,9 +28,9 @@ func main() {
 	c := client.NewClient(apiURL, token)
 	ctx := context.Background()
 
-	// List all clusters
+	// List all clusters along with their cached health in one call
 	fmt.Println("Discovering clusters...")
-	clusters, err := c.Clusters().List(ctx)
+	clusters, err := c.Clusters().ListWithHealth(ctx)
 	if err != nil {
 		log.Fatalf("Failed to list clusters: %v", err)
 	}
@@ -49,17 +49,13 @@ func main() {
 	healthyClusters := []client.Cluster{}
 
 	for _, cluster := range clusters {
-		health, err := c.Health().Cluster(ctx, cluster.ID)
-		if err != nil {
-			fmt.Printf("  ✗ %s: failed to check health: %v\n", cluster.Name, err)
-			continue
-		}
-
-		if health.K8sReachable {
+		if cluster.Health.Healthy() {
 			fmt.Printf("  ✓ %s: healthy\n", cluster.Name)
 			healthyClusters = append(healthyClusters, cluster)
+		} else if cluster.Health != nil {
+			fmt.Printf("  ✗ %s: unhealthy (%s %s)\n", cluster.Name, cluster.Health.Code, cluster.Health.Error)
 		} else {
-			fmt.Printf("  ✗ %s: unhealthy (%s)\n", cluster.Name, health.K8sError)
+			fmt.Printf("  ✗ %s: health unknown\n", cluster.Name)
 		}
 	}
 
@@ -166,21 +162,10 @@ func deployToCluster(c *client.Client, ctx context.Context, cluster client.Clust
 		clusterName: cluster.Name,
 	}
 
-	// Create workspace
-	ws, err := c.Workspaces(cluster.ID).Create(ctx, spec)
-	if err != nil {
-		result.err = fmt.Errorf("create failed: %w", err)
-		results <- result
-		return
-	}
-
-	// Wait for ready (with timeout)
-	waitCtx, cancel := context.WithTimeout(ctx, 3*time.Minute)
-	defer cancel()
-
-	err = c.Workspaces(cluster.ID).Wait(waitCtx, spec.Name, 3*time.Minute)
+	// Create workspace and wait for it to be ready
+	ws, err := c.Workspaces(cluster.ID).Create(ctx, spec, client.WithWaitReady(3*time.Minute))
 	if err != nil {
-		result.err = fmt.Errorf("wait failed: %w", err)
+		result.err = fmt.Errorf("deploy failed: %w", err)
 		results <- result
 		return
 	}
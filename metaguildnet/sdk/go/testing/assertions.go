@@ -111,14 +111,13 @@ func AssertTableExists(t *testing.T, c *client.Client, clusterID, dbID, table st
 func AssertRowCount(t *testing.T, c *client.Client, clusterID, dbID, table string, expected int) {
 	t.Helper()
 
-	ctx := context.Background()
-	rows, _, err := c.Databases(clusterID).Query(ctx, dbID, table, "", 1000, "", true)
+	n, err := CountRows(context.Background(), c, clusterID, dbID, table)
 	if err != nil {
 		t.Fatalf("failed to query rows: %v", err)
 	}
 
-	if len(rows) != expected {
-		t.Errorf("expected %d rows in table %s, got %d", expected, table, len(rows))
+	if n != expected {
+		t.Errorf("expected %d rows in table %s, got %d", expected, table, n)
 	}
 }
 
@@ -184,6 +184,42 @@ func WaitForWorkspaceReady(c *client.Client, clusterID, name string, timeout tim
 	return c.Workspaces(clusterID).Wait(context.Background(), name, timeout)
 }
 
+// CountRows counts the rows of a table, following pagination cursors
+func CountRows(ctx context.Context, c *client.Client, clusterID, dbID, table string) (int, error) {
+	n := 0
+	cursor := ""
+	for {
+		rows, next, err := c.Databases(clusterID).Query(ctx, dbID, table, "", 1000, cursor, true)
+		if err != nil {
+			return 0, err
+		}
+		n += len(rows)
+		if next == "" || len(rows) == 0 {
+			return n, nil
+		}
+		cursor = next
+	}
+}
+
+// WaitForRowCount waits until a table holds exactly n rows. Query errors are retried until
+// the timeout, since the table may not exist yet; the last count or error is reported.
+func WaitForRowCount(c *client.Client, clusterID, dbID, table string, n int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		got, err := CountRows(context.Background(), c, clusterID, dbID, table)
+		if err == nil && got == n {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("timeout waiting for %d rows in %s/%s: %w", n, dbID, table, err)
+			}
+			return fmt.Errorf("timeout waiting for %d rows in %s/%s: have %d", n, dbID, table, got)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
 // CreateTestWorkspace creates a workspace and returns cleanup function
 func CreateTestWorkspace(t *testing.T, c *client.Client, clusterID string) (*client.Workspace, func()) {
 	t.Helper()
@@ -35,14 +35,12 @@ func TestGoSDKIntegration(t *testing.T) {
 			Image: "nginx:alpine",
 		}
 
-		ws, err := c.Workspaces(tc.ID).Create(ctx, spec)
-		mgntesting.AssertNoError(t, err, "failed to create workspace")
+		ws, err := c.Workspaces(tc.ID).Create(ctx, spec, client.WithWaitReady(2*time.Minute))
 
 		// Cleanup
-		defer c.Workspaces(tc.ID).Delete(ctx, ws.Name)
-
-		// Wait for ready
-		err = c.Workspaces(tc.ID).Wait(ctx, ws.Name, 2*time.Minute)
+		if ws != nil {
+			defer c.Workspaces(tc.ID).Delete(ctx, ws.Name)
+		}
 		mgntesting.AssertNoError(t, err, "workspace did not become ready")
 
 		// Assert running
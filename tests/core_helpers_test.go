@@ -88,3 +88,22 @@ func TestJSONErrorShape(t *testing.T) {
 		t.Fatalf("expected request_id key present")
 	}
 }
+
+func TestJSONFieldErrorsShape(t *testing.T) {
+	r := httptest.NewRecorder()
+	httpx.JSONFieldErrors(r, 400, "invalid workspace spec", "invalid_spec", map[string]string{"spec.image": "required"})
+	if r.Code != 400 {
+		t.Fatalf("expected 400 code, got %d", r.Code)
+	}
+	var body struct {
+		Code    string            `json:"code"`
+		Message string            `json:"message"`
+		Fields  map[string]string `json:"fields"`
+	}
+	if err := json.Unmarshal(r.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if body.Code != "invalid_spec" || body.Fields["spec.image"] != "required" {
+		t.Fatalf("unexpected body %+v", body)
+	}
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/settings"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	var reached bool
+	h := httpx.CORSWithOptions(httpx.CORSOptions{
+		AllowedOrigin:  "https://ui.example",
+		AllowedHeaders: []string{"x-tenant"},
+		MaxAge:         time.Hour,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/db/db1/tables", nil)
+	req.Header.Set("Origin", "https://ui.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "x-debug-principal")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || reached {
+		t.Fatalf("preflight status=%d reached=%v", rec.Code, reached)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "3600" {
+		t.Fatalf("Max-Age=%q want 3600", got)
+	}
+	allow := rec.Header().Get("Access-Control-Allow-Headers")
+	for _, want := range []string{"X-Debug-Principal", "X-Tenant", "Content-Type"} {
+		if !strings.Contains(allow, want) {
+			t.Fatalf("Allow-Headers %q missing %s", allow, want)
+		}
+	}
+
+	// Other origins get no CORS headers; simple requests pass through without Max-Age.
+	req = httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if !reached || rec.Header().Get("Access-Control-Allow-Origin") != "" || rec.Header().Get("Access-Control-Max-Age") != "" {
+		t.Fatalf("unexpected headers for disallowed origin: %v", rec.Header())
+	}
+}
+
+func TestCORSSettingsRoundTrip(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m := settings.Manager{DB: db}
+	in := settings.Global{CORSAllowedMethods: []string{"GET", " POST "}, CORSAllowedHeaders: []string{"X-Tenant", ""}, CORSMaxAge: 120}
+	if err := m.PutGlobal(in); err != nil {
+		t.Fatal(err)
+	}
+	var out settings.Global
+	_ = m.GetGlobal(&out)
+	if strings.Join(out.CORSAllowedMethods, ",") != "GET,POST" || strings.Join(out.CORSAllowedHeaders, ",") != "X-Tenant" || out.CORSMaxAge != 120 {
+		t.Fatalf("round trip mismatch: %+v", out)
+	}
+}
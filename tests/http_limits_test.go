@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+)
+
+func TestLimitBody(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		if httpx.BodyTooLarge(w, err) {
+			return
+		}
+		_, _ = w.Write(b)
+	})
+	h := httpx.LimitBody(httpx.BodyLimitOptions{Max: 16, Limit: func(r *http.Request) int64 {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/proxy/"):
+			return -1
+		case strings.HasSuffix(r.URL.Path, "/import"):
+			return 64
+		}
+		return 0
+	}})(echo)
+
+	do := func(path, body string, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	big := strings.Repeat("x", 32)
+
+	if rec := do("/api/jobs", "small", false); rec.Code != http.StatusOK || rec.Body.String() != "small" {
+		t.Fatalf("small body: %d %q", rec.Code, rec.Body.String())
+	}
+	for _, chunked := range []bool{false, true} {
+		rec := do("/api/jobs", big, chunked)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("chunked=%v: status %d want 413", chunked, rec.Code)
+		}
+		var body struct {
+			Code    string         `json:"code"`
+			Details map[string]any `json:"details"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		if body.Code != "body_too_large" || body.Details["limit"] != float64(16) {
+			t.Fatalf("chunked=%v: body %s", chunked, rec.Body.String())
+		}
+	}
+	if rec := do("/api/db/d1/tables/t1/import", big, false); rec.Code != http.StatusOK {
+		t.Fatalf("import override: status %d", rec.Code)
+	}
+	if rec := do("/proxy/server/ws1/upload", strings.Repeat("x", 1024), true); rec.Code != http.StatusOK || rec.Body.Len() != 1024 {
+		t.Fatalf("proxy path limited: status %d len %d", rec.Code, rec.Body.Len())
+	}
+
+	srv := httpx.HardenServer(&http.Server{MaxHeaderBytes: 1 << 10})
+	if srv.ReadHeaderTimeout != httpx.DefaultReadHeaderTimeout || srv.MaxHeaderBytes != 1<<10 {
+		t.Fatalf("HardenServer: timeout=%v maxHeader=%d", srv.ReadHeaderTimeout, srv.MaxHeaderBytes)
+	}
+}
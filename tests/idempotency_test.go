@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestIdempotentReplay(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var creates atomic.Int32
+	h := httpx.Idempotent(db, time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := creates.Add(1)
+		httpx.JSON(w, http.StatusAccepted, map[string]any{"id": fmt.Sprintf("ws-%d", n), "status": "pending"})
+	}))
+	do := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/cluster/c1/workspaces", strings.NewReader(body))
+		req.Header.Set(httpx.IdempotencyKeyHeader, key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := do("k1", `{"image":"nginx"}`)
+	replay := do("k1", `{"image":"nginx"}`)
+	if first.Code != http.StatusAccepted || replay.Code != http.StatusAccepted || creates.Load() != 1 {
+		t.Fatalf("codes=%d/%d creates=%d", first.Code, replay.Code, creates.Load())
+	}
+	if replay.Body.String() != first.Body.String() || replay.Header().Get(httpx.IdempotentReplayHeader) != "true" {
+		t.Fatalf("replay body=%q header=%v", replay.Body.String(), replay.Header())
+	}
+	if rec := do("k1", `{"image":"redis"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with new body: status=%d", rec.Code)
+	}
+	if rec := do("k2", `{"image":"nginx"}`); rec.Code != http.StatusAccepted || creates.Load() != 2 {
+		t.Fatalf("new key: status=%d creates=%d", rec.Code, creates.Load())
+	}
+}
+
+func TestIdempotentKeysAreScopedToCaller(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var creates atomic.Int32
+	h := httpx.Idempotent(db, time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := creates.Add(1)
+		httpx.JSON(w, http.StatusAccepted, map[string]any{"id": fmt.Sprintf("ws-%d", n)})
+		// Streamed responses still reach the client as they are written
+		w.(http.Flusher).Flush()
+	}))
+	do := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/cluster/c1/workspaces", strings.NewReader(`{"image":"nginx"}`))
+		req.Header.Set(httpx.IdempotencyKeyHeader, "k1")
+		req.Header.Set(header, value)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, c := range [][2]string{
+		{"X-Debug-Principal", "user:alice"},
+		{"X-Debug-Principal", "user:bob"},
+		{"Authorization", "Bearer token-a"},
+		{"X-API-Token", "token-b"},
+	} {
+		rec := do(c[0], c[1])
+		if rec.Code != http.StatusAccepted || rec.Header().Get(httpx.IdempotentReplayHeader) != "" || !rec.Flushed {
+			t.Fatalf("%s=%s: status=%d replayed=%q flushed=%v", c[0], c[1], rec.Code, rec.Header().Get(httpx.IdempotentReplayHeader), rec.Flushed)
+		}
+		if int(creates.Load()) != i+1 {
+			t.Fatalf("%s=%s replayed another caller's result", c[0], c[1])
+		}
+	}
+	if rec := do("X-Debug-Principal", "user:alice"); rec.Header().Get(httpx.IdempotentReplayHeader) != "true" || creates.Load() != 4 {
+		t.Fatalf("same caller was not replayed: %v creates=%d", rec.Header(), creates.Load())
+	}
+}
+
+func TestSDKWorkspaceCreateRetryIsIdempotent(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var creates, attempts atomic.Int32
+	keys := make(chan string, 4)
+	inner := httpx.Idempotent(db, time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		creates.Add(1)
+		httpx.JSON(w, http.StatusAccepted, map[string]any{"id": "ws-abc", "status": "pending"})
+	}))
+	// The first response is lost after the workspace was created, as with a dropped connection.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys <- r.Header.Get(httpx.IdempotencyKeyHeader)
+		if attempts.Add(1) == 1 {
+			inner.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		inner.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	c := client.NewClient(srv.URL, "", client.WithRetryBackoff(time.Millisecond))
+	ws, err := c.Workspaces("c1").Create(context.Background(), client.WorkspaceSpec{Image: "nginx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.ID != "ws-abc" || creates.Load() != 1 || attempts.Load() != 2 {
+		t.Fatalf("id=%s creates=%d attempts=%d", ws.ID, creates.Load(), attempts.Load())
+	}
+	if k1, k2 := <-keys, <-keys; k1 == "" || k1 != k2 {
+		t.Fatalf("idempotency keys %q / %q should match and be non-empty", k1, k2)
+	}
+}
+
+func TestIdempotentConcurrentRetryWaitsAndReplays(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var creates atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	h := httpx.Idempotent(db, time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if creates.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		httpx.JSON(w, http.StatusAccepted, map[string]any{"id": "ws-1"})
+	}))
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/cluster/c1/workspaces", strings.NewReader(body))
+		req.Header.Set(httpx.IdempotencyKeyHeader, "k1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := make(chan *httptest.ResponseRecorder, 1)
+	go func() { first <- do(`{"image":"nginx"}`) }()
+	<-started
+	// A different body is rejected right away, without waiting
+	if rec := do(`{"image":"redis"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with new body while in flight: status=%d", rec.Code)
+	}
+	retry := make(chan *httptest.ResponseRecorder, 1)
+	go func() { retry <- do(`{"image":"nginx"}`) }()
+	select {
+	case rec := <-retry:
+		t.Fatalf("retry answered %d before the original finished", rec.Code)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	orig, again := <-first, <-retry
+	if orig.Code != http.StatusAccepted || again.Code != http.StatusAccepted || creates.Load() != 1 {
+		t.Fatalf("codes=%d/%d creates=%d", orig.Code, again.Code, creates.Load())
+	}
+	if again.Header().Get(httpx.IdempotentReplayHeader) != "true" || again.Body.String() != orig.Body.String() {
+		t.Fatalf("retry was not a replay: %v %q", again.Header(), again.Body.String())
+	}
+}
+
+func TestSweepIdempotencyRemovesExpired(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	h := httpx.Idempotent(db, 10*time.Millisecond, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	for _, key := range []string{"a", "b"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/workspace-jobs", strings.NewReader(`{}`))
+		req.Header.Set(httpx.IdempotencyKeyHeader, key)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := httpx.SweepIdempotency(db); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := db.Keys("idempotency"); len(keys) != 0 {
+		t.Fatalf("expired records left: %v", keys)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/model"
+)
+
+func TestJobSpecValidate(t *testing.T) {
+	ok := model.JobSpec{Name: "code-1", Image: "codercom/code-server:latest", Env: map[string]string{"A": "1"}, Expose: []model.Port{{Port: 8080}}}
+	if errs := ok.Validate(); errs != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	bad := model.JobSpec{
+		Name:   "Bad_Name",
+		Env:    map[string]string{"": "x", "EMPTY": " "},
+		Expose: []model.Port{{Port: 0}, {Port: 70000}},
+	}
+	want := map[string]bool{"name": true, "image": true, "env": true, "env.EMPTY": true, "expose[0].port": true, "expose[1].port": true}
+	errs := bad.Validate()
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for _, fe := range errs {
+		if !want[fe.Field] {
+			t.Fatalf("unexpected field %q", fe.Field)
+		}
+	}
+}
+
+func TestJobSpecArgsValidation(t *testing.T) {
+	fields := func(errs []model.FieldError) map[string]bool {
+		out := map[string]bool{}
+		for _, fe := range errs {
+			out[fe.Field] = true
+		}
+		return out
+	}
+	spec := model.JobSpec{Image: "busybox", Args: []string{"--port", "8080", "echo $HOME; rm -rf /"}}
+	if errs := spec.Validate(); errs != nil {
+		t.Fatalf("default rules rejected plain args: %v", errs)
+	}
+	if got := fields(spec.ValidateWith(model.ValidationOptions{RejectShellMetachars: true})); len(got) != 1 || !got["args[2]"] {
+		t.Fatalf("strict mode errors=%v want args[2]", got)
+	}
+
+	spec.Args = []string{"ok", "nul\x00byte", strings.Repeat("a", model.DefaultMaxArgLength+1)}
+	if got := fields(spec.Validate()); len(got) != 2 || !got["args[1]"] || !got["args[2]"] {
+		t.Fatalf("errors=%v want args[1] (NUL) and args[2] (length)", got)
+	}
+
+	spec.Args = make([]string, model.DefaultMaxArgs+1)
+	for i := range spec.Args {
+		spec.Args[i] = "x"
+	}
+	if got := fields(spec.Validate()); !got["args"] {
+		t.Fatalf("errors=%v want args count error", got)
+	}
+	if errs := spec.ValidateWith(model.ValidationOptions{MaxArgs: 100}); errs != nil {
+		t.Fatalf("raised MaxArgs still rejected: %v", errs)
+	}
+}
@@ -0,0 +1,383 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/api"
+	"github.com/docxology/GuildNet/internal/jobs"
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/orch"
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestRunnerConcurrencyAndQueue(t *testing.T) {
+	r := jobs.New(jobs.WithMaxConcurrency(1), jobs.WithQueueSize(2))
+	release := make(chan struct{})
+	started := make(chan string, 4)
+	h := func(ctx context.Context, rec *jobs.Record, logf func(step, msg string, kv map[string]any)) {
+		started <- rec.ID
+		<-release
+	}
+
+	first, err := r.Submit("cluster.create", nil, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-started; got != first {
+		t.Fatalf("started %s want %s", got, first)
+	}
+	second, _ := r.Submit("cluster.create", nil, h)
+	third, _ := r.Submit("cluster.create", nil, h)
+	if _, err := r.Submit("cluster.create", nil, h); !errors.Is(err, jobs.ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	if st := r.Stats(); st.Running != 1 || st.Queued != 2 {
+		t.Fatalf("stats=%+v want 1 running, 2 queued", st)
+	}
+	if rec := r.Get(second); rec == nil || rec.Status != jobs.Queued {
+		t.Fatalf("second job should be queued: %+v", rec)
+	}
+
+	// Canceling a queued job removes it; it never runs.
+	r.Cancel(second)
+	if st := r.Stats(); st.Queued != 1 {
+		t.Fatalf("queued=%d after cancel, want 1", st.Queued)
+	}
+	close(release)
+	select {
+	case got := <-started:
+		if got != third {
+			t.Fatalf("next started %s want %s", got, third)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued job never started")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Stats().Running != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("runner did not drain: %+v", r.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rec := r.Get(second); rec.Status != jobs.Canceled {
+		t.Fatalf("canceled job status=%s", rec.Status)
+	}
+	if rec := r.Get(third); rec.Status != jobs.Succeeded {
+		t.Fatalf("third job status=%s", rec.Status)
+	}
+}
+
+func TestRunnerProgressReporter(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Put("clusters", "c1", map[string]any{"id": "c1", "state": "pending"}); err != nil {
+		t.Fatal(err)
+	}
+	r := jobs.New(jobs.WithPersist(jobs.LocalPersist{DB: db}))
+	srv := httptest.NewServer(api.Router(api.Deps{DB: db, Runner: r}))
+	defer srv.Close()
+	id, err := r.Submit("cluster.create", map[string]any{"id": "c1", "name": "one"}, orch.HandlerFor("cluster.create", orch.Deps{DB: db}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := client.NewClient(srv.URL, "")
+	ch, err := c.Jobs().StreamLogs(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var percents []int
+	for e := range ch {
+		if e.IsProgress() {
+			percents = append(percents, e.Percent)
+		}
+	}
+	if ctx.Err() != nil {
+		t.Fatal("stream did not close after the job finished")
+	}
+	if len(percents) < 2 || percents[len(percents)-1] != 100 {
+		t.Fatalf("progress events=%v, want steps ending at 100", percents)
+	}
+	for i := 1; i < len(percents); i++ {
+		if percents[i] < percents[i-1] {
+			t.Fatalf("progress went backwards: %v", percents)
+		}
+	}
+	if rec := r.Get(id); rec.Status != jobs.Succeeded || rec.Progress != 1 {
+		t.Fatalf("job=%+v", rec)
+	}
+	if _, err := c.Jobs().StreamLogs(ctx, "missing"); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("missing job err=%v", err)
+	}
+}
+
+func TestJobStreamReplaysThenFollowsEveryEvent(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	r := jobs.New(jobs.WithPersist(jobs.LocalPersist{DB: db}))
+	srv := httptest.NewServer(api.Router(api.Deps{DB: db, Runner: r}))
+	defer srv.Close()
+	// Bursts of events land within the same clock tick; the stream must neither drop
+	// the ones sharing a timestamp nor repeat the replayed ones
+	const burst = 50
+	started, release := make(chan struct{}), make(chan struct{})
+	id, err := r.Submit("test.burst", nil, func(ctx context.Context, rec *jobs.Record, logf func(step, msg string, kv map[string]any)) {
+		for i := 0; i < burst; i++ {
+			logf("burst", "first", map[string]any{"i": i})
+		}
+		close(started)
+		<-release
+		for i := burst; i < 2*burst; i++ {
+			logf("burst", "second", map[string]any{"i": i})
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch, err := client.NewClient(srv.URL, "").Jobs().StreamLogs(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	seen := map[int]int{}
+	var lastSeq int64
+	for e := range ch {
+		if e.Seq <= lastSeq {
+			t.Fatalf("event seq %d after %d", e.Seq, lastSeq)
+		}
+		lastSeq = e.Seq
+		if e.Step == "burst" {
+			i, _ := e.KV["i"].(float64)
+			seen[int(i)]++
+		}
+	}
+	if ctx.Err() != nil {
+		t.Fatal("stream did not close after the job finished")
+	}
+	for i := 0; i < 2*burst; i++ {
+		if seen[i] != 1 {
+			t.Fatalf("event %d streamed %d times", i, seen[i])
+		}
+	}
+}
+
+func TestReporterFromWithoutRunnerIsNoop(t *testing.T) {
+	jobs.ReporterFrom(context.Background())(50, "ignored")
+}
+
+func TestLocalPersistTrimsJobLogs(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	p := jobs.LocalPersist{DB: db, MaxLogBytes: 2048}
+	const total = 200
+	for i := 0; i < total; i++ {
+		if err := p.AppendLog("j1", jobs.LogEvent{TS: time.Now(), Job: "j1", Msg: strings.Repeat("x", 20), KV: map[string]any{"i": i}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, _ := db.ReadLog("joblogs", "j1")
+	if len(b) > 2048 {
+		t.Fatalf("log is %d bytes, cap 2048", len(b))
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	var head, tail jobs.LogEvent
+	_ = json.Unmarshal([]byte(lines[0]), &head)
+	_ = json.Unmarshal([]byte(lines[len(lines)-1]), &tail)
+	dropped, _ := head.KV["dropped"].(float64)
+	if head.Step != jobs.TruncatedStep || int(dropped)+len(lines)-1 != total {
+		t.Fatalf("sentinel=%+v with %d kept lines, want dropped+kept=%d", head, len(lines)-1, total)
+	}
+	if i, _ := tail.KV["i"].(float64); int(i) != total-1 {
+		t.Fatalf("last kept line=%+v, want the newest event", tail)
+	}
+
+	// The stream replays the trimmed log, sentinel first, and ends for a finished job
+	if err := p.SaveJob(jobs.Record{ID: "j1", Status: jobs.Succeeded}); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(api.Router(api.Deps{DB: db, Runner: jobs.New(jobs.WithPersist(p))}))
+	defer srv.Close()
+	ch, err := client.NewClient(srv.URL, "").Jobs().StreamLogs(context.Background(), "j1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []client.JobLogEvent
+	for e := range ch {
+		got = append(got, e)
+	}
+	if len(got) != len(lines) || !got[0].IsTruncated() || got[0].Dropped() != int(dropped) {
+		t.Fatalf("streamed %d events (first %+v), want sentinel + %d", len(got), got[0], len(lines)-1)
+	}
+}
+
+// waitJob polls until the job reaches a terminal status.
+func waitJob(t *testing.T, r *jobs.Runner, id string) *jobs.Record {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if rec := r.Get(id); rec != nil && rec.Status != jobs.Queued && rec.Status != jobs.Running {
+			return rec
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not finish", id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunnerFailedRetentionAndRetry(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	r := jobs.New(jobs.WithPersist(jobs.LocalPersist{DB: db}), jobs.WithSucceededRetention(time.Hour))
+	defer r.Close()
+	attempts := 0
+	h := func(ctx context.Context, rec *jobs.Record, logf func(step, msg string, kv map[string]any)) {
+		if attempts++; attempts == 1 {
+			rec.SetFailed(errors.New("registry unreachable"), true)
+		}
+	}
+
+	id, err := r.Submit("cluster.create", map[string]any{"id": "c1"}, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := waitJob(t, r, id)
+	if failed.Status != jobs.Failed || failed.Error != "registry unreachable" || !failed.Retriable {
+		t.Fatalf("failed job=%+v", failed)
+	}
+	b, _ := db.ReadLog("joblogs", id)
+	if !strings.Contains(string(b), `"err":"registry unreachable"`) {
+		t.Fatalf("failure not logged: %s", b)
+	}
+
+	retryID, err := r.Retry(id, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Retry(id, h); !errors.Is(err, jobs.ErrNotRetriable) {
+		t.Fatalf("second retry err=%v", err)
+	}
+	retried := waitJob(t, r, retryID)
+	if retried.Status != jobs.Succeeded || retried.RetryOf != id || retried.SpecJSON != failed.SpecJSON {
+		t.Fatalf("retried job=%+v", retried)
+	}
+	if _, err := r.Retry(retryID, h); !errors.Is(err, jobs.ErrNotRetriable) {
+		t.Fatalf("retry of succeeded job err=%v", err)
+	}
+	if _, err := r.Retry("missing", h); !errors.Is(err, jobs.ErrJobNotFound) {
+		t.Fatalf("retry of missing job err=%v", err)
+	}
+
+	// A panic is recorded as a non-retriable failure
+	panicID, _ := r.Submit("cluster.create", nil, func(context.Context, *jobs.Record, func(string, string, map[string]any)) { panic("boom") })
+	if rec := waitJob(t, r, panicID); rec.Status != jobs.Failed || rec.Retriable || !strings.Contains(rec.Error, "boom") {
+		t.Fatalf("panicked job=%+v", rec)
+	}
+
+	// Only the succeeded job ages out; the failed ones stay, in memory and on disk
+	if n := r.Prune(time.Now().Add(2 * time.Hour)); n != 1 {
+		t.Fatalf("pruned %d, want 1", n)
+	}
+	if r.Get(retryID) != nil {
+		t.Fatal("succeeded job survived pruning")
+	}
+	if b, _ := db.ReadLog("joblogs", retryID); len(b) != 0 {
+		t.Fatalf("pruned job log kept: %s", b)
+	}
+	stored, _ := jobs.LocalPersist{DB: db}.ListJobs()
+	if len(stored) != 2 {
+		t.Fatalf("stored jobs=%d, want the 2 failed ones", len(stored))
+	}
+	for _, rec := range stored {
+		if rec.ID == id && rec.RetriedBy != retryID {
+			t.Fatalf("retriedBy not persisted: %+v", rec)
+		}
+	}
+}
+
+func TestRunnerPrunesSucceededJobsInBackground(t *testing.T) {
+	r := jobs.New(jobs.WithSucceededRetention(40*time.Millisecond), jobs.WithPruneInterval(10*time.Millisecond))
+	defer r.Close()
+	// A retention too short for a ticker interval is clamped rather than panicking
+	tiny := jobs.New(jobs.WithSucceededRetention(time.Nanosecond))
+	defer tiny.Close()
+	id, err := r.Submit("noop", nil, func(context.Context, *jobs.Record, func(string, string, map[string]any)) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitJob(t, r, id)
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Get(id) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("succeeded job not pruned")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Without a retention nothing is pruned
+	keep := jobs.New()
+	id, _ = keep.Submit("noop", nil, func(context.Context, *jobs.Record, func(string, string, map[string]any)) {})
+	waitJob(t, keep, id)
+	if n := keep.Prune(time.Now().Add(365 * 24 * time.Hour)); n != 0 || keep.Get(id) == nil {
+		t.Fatalf("pruned %d jobs without a retention", n)
+	}
+}
+
+func TestRunnerCancelKeepsTerminalStatus(t *testing.T) {
+	r := jobs.New()
+	// A handler that fails after being canceled leaves the job canceled
+	started, release := make(chan struct{}), make(chan struct{})
+	id, _ := r.Submit("slow", nil, func(ctx context.Context, rec *jobs.Record, logf func(string, string, map[string]any)) {
+		close(started)
+		<-release
+		rec.SetFailed(errors.New("interrupted"), true)
+	})
+	<-started
+	logs, stop := r.SubscribeLogs(id)
+	defer stop()
+	r.Cancel(id)
+	close(release)
+	for range logs {
+		// closed once the runner recorded the handler's outcome
+	}
+	if rec := r.Get(id); rec.Status != jobs.Canceled {
+		t.Fatalf("canceled job=%+v", rec)
+	}
+
+	// Canceling a finished job does not rewrite its status
+	id, _ = r.Submit("noop", nil, func(context.Context, *jobs.Record, func(string, string, map[string]any)) {})
+	waitJob(t, r, id)
+	r.Cancel(id)
+	if rec := r.Get(id); rec.Status != jobs.Succeeded {
+		t.Fatalf("finished job=%+v after cancel", rec)
+	}
+
+	rec := &jobs.Record{Status: jobs.Canceled}
+	rec.SetFailed(errors.New("late"), true)
+	if rec.Status != jobs.Canceled || rec.Error != "" {
+		t.Fatalf("SetFailed overwrote a terminal status: %+v", rec)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/docxology/GuildNet/internal/k8s"
+)
+
+func TestObjectCacheServesFromInformers(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}, Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-b", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+	)
+	c := k8s.NewObjectCache(cli, 0)
+	defer c.Close()
+	ctx := context.Background()
+
+	// directReads counts reads made on behalf of callers, ignoring the informers'
+	// own unfiltered list and watch.
+	directReads := func() int {
+		n := 0
+		for _, a := range cli.Actions() {
+			switch act := a.(type) {
+			case k8stesting.GetAction:
+				n++
+			case k8stesting.ListAction:
+				if !act.GetListRestrictions().Labels.Empty() {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	// The first read starts the informers and is served by the API server.
+	if svc, err := c.Service(ctx, "default", "web"); err != nil || svc.Name != "web" {
+		t.Fatalf("first read: svc=%v err=%v", svc, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		before := directReads()
+		if _, err := c.Service(ctx, "default", "web"); err != nil {
+			t.Fatal(err)
+		}
+		if directReads() == before {
+			break // served from cache
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cache never synced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	before := directReads()
+	pods, err := c.Pods(ctx, "default", "app=web")
+	if err != nil || len(pods) != 2 || pods[0].Name != "web-a" {
+		t.Fatalf("pods=%v err=%v", pods, err)
+	}
+	if directReads() != before {
+		t.Fatal("synced pod list hit the API server")
+	}
+
+	// A miss falls back to the API server.
+	if _, err := c.Service(ctx, "default", "missing"); err == nil {
+		t.Fatal("expected not found")
+	}
+	if directReads() == before {
+		t.Fatal("cache miss did not fall back to the API server")
+	}
+}
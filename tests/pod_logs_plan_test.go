@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/docxology/GuildNet/internal/k8s"
+)
+
+func TestPlanPodLogs(t *testing.T) {
+	pod := func(name string, ready bool) corev1.Pod {
+		st := corev1.ConditionFalse
+		if ready {
+			st = corev1.ConditionTrue
+		}
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: st}}}}
+	}
+	pods := []corev1.Pod{pod("c", false), pod("b", true), pod("e", true), pod("a", false), pod("d", true), pod("f", false), pod("g", false)}
+	names := func(ps []corev1.Pod) string {
+		s := ""
+		for _, p := range ps {
+			s += p.Name
+		}
+		return s
+	}
+
+	cases := []struct {
+		limit, maxPods int
+		pods           string
+		tail           int64
+	}{
+		{200, 0, "bdeac", 40},    // default cap of 5, ready first then by name
+		{200, 10, "bdeacfg", 29}, // all seven pods, ceil(200/7)
+		{20, 0, "bdeac", 4},      // small limits no longer pull the full limit per pod
+		{3, 0, "bde", 1},         // never more pods than lines
+		{0, 0, "", 0},            // nothing requested
+	}
+	for _, c := range cases {
+		got, tail := k8s.PlanPodLogs(pods, c.limit, c.maxPods)
+		if names(got) != c.pods || tail != c.tail {
+			t.Fatalf("PlanPodLogs(limit=%d, max=%d) = %q tail=%d, want %q tail=%d", c.limit, c.maxPods, names(got), tail, c.pods, c.tail)
+		}
+		if total := int64(len(got)) * tail; c.limit > 0 && total-int64(c.limit) >= int64(len(got)) {
+			t.Fatalf("limit=%d: %d lines requested overshoots by a pod or more", c.limit, total)
+		}
+	}
+	if pods[0].Name != "c" {
+		t.Fatal("PlanPodLogs reordered the caller's slice")
+	}
+}
+
+func TestLogContainer(t *testing.T) {
+	pod := func(name string, containers ...string) corev1.Pod {
+		p := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for _, c := range containers {
+			p.Spec.Containers = append(p.Spec.Containers, corev1.Container{Name: c})
+		}
+		return p
+	}
+	cases := []struct {
+		pod     corev1.Pod
+		want    string
+		got     string
+		wantErr bool
+	}{
+		{pod("p", "proxy", "workspace"), "", "workspace", false},
+		{pod("p", "sidecar", "app"), "", "app", false},
+		{pod("p", "only"), "", "only", false},
+		{pod("p", "workspace", "sidecar"), "sidecar", "sidecar", false},
+		{pod("p", "workspace"), "sidecar", "", true},
+		{pod("p"), "", "", false},
+	}
+	for i, tc := range cases {
+		got, err := k8s.LogContainer(&tc.pod, tc.want)
+		if got != tc.got || (err != nil) != tc.wantErr {
+			t.Fatalf("case %d: got %q err=%v, want %q err=%v", i, got, err, tc.got, tc.wantErr)
+		}
+	}
+	names := k8s.ContainerNames([]corev1.Pod{pod("a", "workspace", "sidecar"), pod("b", "workspace", "metrics")})
+	if len(names) != 3 || names[0] != "workspace" || names[1] != "sidecar" || names[2] != "metrics" {
+		t.Fatalf("ContainerNames = %v", names)
+	}
+}
+
+func TestNewestPodLogsTrimsGlobally(t *testing.T) {
+	// pod a is read first but its lines are older; trimming must drop them, not pod b's
+	a := k8s.ParsePodLogs("a", []byte("2024-05-01T10:00:00.000000001Z a1\n2024-05-01T10:00:02Z a2\n"))
+	b := k8s.ParsePodLogs("b", []byte("2024-05-01T10:00:01Z b1\n2024-05-01T10:00:03Z b2 with spaces\nno timestamp\n"))
+	if len(b) != 3 || b[1].Text != "b2 with spaces" || !b[2].TS.IsZero() || b[2].Text != "no timestamp" {
+		t.Fatalf("parsed %+v", b)
+	}
+	got := k8s.NewestPodLogs(append(a, b[:2]...), 3)
+	var texts []string
+	for _, l := range got {
+		texts = append(texts, l.Pod+":"+l.Text)
+	}
+	if strings.Join(texts, ",") != "b:b1,a:a2,b:b2 with spaces" {
+		t.Fatalf("newest = %v", texts)
+	}
+}
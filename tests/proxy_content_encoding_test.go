@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestProxyPassesGzipThroughUntouched(t *testing.T) {
+	const body = "hello from a gzip upstream"
+	var upstreamAE []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamAE = append(upstreamAE, r.Header.Get("Accept-Encoding"))
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		_, _ = zw.Write([]byte(body))
+		_ = zw.Close()
+	}))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().String()
+
+	rp := proxy.NewReverseProxy(proxy.Options{
+		Timeout: 5 * time.Second,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+	})
+	ts := httptest.NewServer(rp)
+	defer ts.Close()
+	// Disable client-side decoding so we see exactly what the proxy sent.
+	cli := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	// Client negotiates gzip: the upstream's encoding must arrive once, unchanged.
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/proxy/server/ws1/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := cli.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ce := resp.Header.Get("Content-Encoding"); ce != "gzip" {
+		t.Fatalf("Content-Encoding=%q want gzip", ce)
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	got, err := io.ReadAll(zr)
+	resp.Body.Close()
+	if err != nil || string(got) != body {
+		t.Fatalf("decoded body=%q err=%v", got, err)
+	}
+
+	// Client does not negotiate: the proxy must not add its own Accept-Encoding.
+	resp, err = cli.Get(ts.URL + "/proxy/server/ws1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "" || string(got) != body {
+		t.Fatalf("identity response: encoding=%q body=%q", resp.Header.Get("Content-Encoding"), got)
+	}
+	if len(upstreamAE) != 2 || upstreamAE[1] != "" {
+		t.Fatalf("upstream Accept-Encoding=%q, want the proxy not to add one", upstreamAE)
+	}
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func errorPageProxy(page proxy.ErrorPageFunc) *httptest.Server {
+	return httptest.NewServer(proxy.NewReverseProxy(proxy.Options{
+		Timeout: 2 * time.Second,
+		Retries: -1,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			return nil, errors.New("connection refused")
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			if serverID == "gone" {
+				return "", "", "", errors.New("workspace gone not found")
+			}
+			return "http", "10.0.0.9:8080", subPath, nil
+		},
+		ErrorPage: page,
+	}))
+}
+
+func getWithAccept(t *testing.T, url, accept string) (*http.Response, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	req.Header.Set("X-Request-Id", "req-42")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var b strings.Builder
+	buf := make([]byte, 4096)
+	for {
+		n, err := resp.Body.Read(buf)
+		b.Write(buf[:n])
+		if err != nil {
+			break
+		}
+	}
+	return resp, b.String()
+}
+
+func TestProxyErrorPageNegotiation(t *testing.T) {
+	ts := errorPageProxy(nil)
+	defer ts.Close()
+
+	resp, body := getWithAccept(t, ts.URL+"/proxy/server/ws1/app?x=<script>", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	if resp.StatusCode != http.StatusBadGateway || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("browser got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(strings.ToLower(body), `href="/proxy/server/ws1/app?x=%3cscript%3e"`) || strings.Contains(body, "<script>") {
+		t.Fatalf("retry link missing or unescaped:\n%s", body)
+	}
+	if !strings.Contains(body, "req-42") || !strings.Contains(body, "connection refused") {
+		t.Fatalf("page lacks request id or cause:\n%s", body)
+	}
+
+	for _, accept := range []string{"", "application/json", "*/*", "text/html;q=0.5, application/json"} {
+		resp, body = getWithAccept(t, ts.URL+"/proxy/server/ws1/", accept)
+		var out map[string]any
+		if resp.StatusCode != http.StatusBadGateway || json.Unmarshal([]byte(body), &out) != nil {
+			t.Fatalf("Accept %q: got %d %q", accept, resp.StatusCode, body)
+		}
+		if out["code"] != "upstream_error" || out["request_id"] != "req-42" {
+			t.Fatalf("Accept %q: payload %v", accept, out)
+		}
+	}
+
+	resp, body = getWithAccept(t, ts.URL+"/proxy/server/gone/", "application/json")
+	if resp.StatusCode != http.StatusBadGateway || !strings.Contains(body, `"resolve_failed"`) {
+		t.Fatalf("resolution failure got %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestProxyCustomErrorPage(t *testing.T) {
+	var got proxy.ProxyError
+	ts := errorPageProxy(func(w http.ResponseWriter, r *http.Request, e proxy.ProxyError) {
+		got = e
+		w.WriteHeader(e.Status)
+		_, _ = w.Write([]byte("custom"))
+	})
+	defer ts.Close()
+
+	_, body := getWithAccept(t, ts.URL+"/proxy/server/ws1/x", "text/html")
+	if body != "custom" || got.Status != http.StatusBadGateway || got.Code != "upstream_error" || got.RetryURL != "/proxy/server/ws1/x" {
+		t.Fatalf("custom page not used: body=%q err=%+v", body, got)
+	}
+}
+
+func TestProxyEarlyRejectionsUseErrorPage(t *testing.T) {
+	var codes []string
+	ts := httptest.NewServer(proxy.NewReverseProxy(proxy.Options{
+		Timeout: 2 * time.Second,
+		MaxBody: 10,
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", "10.0.0.9:8080", subPath, nil
+		},
+		ResolvePathRules: func(ctx context.Context, serverID string) (proxy.PathRules, error) {
+			return proxy.PathRules{Deny: []string{"/admin"}}, nil
+		},
+		ErrorPage: func(w http.ResponseWriter, r *http.Request, e proxy.ProxyError) {
+			codes = append(codes, e.Code)
+			w.WriteHeader(e.Status)
+		},
+	}))
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/proxy/server/ws1/upload", "text/plain", strings.NewReader(strings.Repeat("x", 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversize status=%d", resp.StatusCode)
+	}
+	if resp, _ := getWithAccept(t, ts.URL+"/proxy/server/ws1/admin/users", "text/html"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("denied path status=%d", resp.StatusCode)
+	}
+	if strings.Join(codes, ",") != "body_too_large,path_denied" {
+		t.Fatalf("error page codes=%v", codes)
+	}
+}
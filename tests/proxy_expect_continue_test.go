@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// readFlagBody records whether the client transport started sending the body.
+type readFlagBody struct {
+	r    io.Reader
+	read atomic.Bool
+}
+
+func (b *readFlagBody) Read(p []byte) (int, error) {
+	b.read.Store(true)
+	return b.r.Read(p)
+}
+
+func expectContinuePut(t *testing.T, url string, body *readFlagBody, size int64) (*http.Response, bool) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPut, url, body)
+	req.ContentLength = size
+	req.Header.Set("Expect", "100-continue")
+	var got100 atomic.Bool
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		Got100Continue: func() { got100.Store(true) },
+	}))
+	// A long client-side timeout: the body must only be sent once a 100 arrives
+	client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: 10 * time.Second}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, got100.Load()
+}
+
+func TestProxyExpectContinueAccepted(t *testing.T) {
+	const size = 1 << 20
+	var expect string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expect = r.Header.Get("Expect")
+		n, _ := io.Copy(io.Discard, r.Body)
+		if n != size {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer upstream.Close()
+	srv := uploadProxy(upstream, 0, 5*time.Second)
+	defer srv.Close()
+
+	body := &readFlagBody{r: bytes.NewReader(make([]byte, size))}
+	resp, got100 := expectContinuePut(t, srv.URL+"/proxy/server/ws1/upload", body, size)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status=%d, want 201", resp.StatusCode)
+	}
+	if expect != "100-continue" {
+		t.Fatalf("upstream Expect=%q, want forwarded", expect)
+	}
+	if !got100 {
+		t.Fatal("client never got the interim 100 Continue")
+	}
+}
+
+func TestProxyExpectContinueRejectedBeforeUpload(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Refuse without touching the body, as an upstream enforcing a size cap would
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer upstream.Close()
+	srv := uploadProxy(upstream, 0, 5*time.Second)
+	defer srv.Close()
+
+	const size = 8 << 20
+	body := &readFlagBody{r: bytes.NewReader(make([]byte, size))}
+	resp, got100 := expectContinuePut(t, srv.URL+"/proxy/server/ws1/upload", body, size)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status=%d, want 413", resp.StatusCode)
+	}
+	if got100 || body.read.Load() {
+		t.Fatalf("client was told to continue (100=%v, body read=%v); the upstream refused first", got100, body.read.Load())
+	}
+}
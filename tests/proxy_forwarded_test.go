@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestProxyForwardedForChain(t *testing.T) {
+	type seen struct{ xff, real string }
+	got := make(chan seen, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- seen{r.Header.Get("X-Forwarded-For"), r.Header.Get("X-Real-IP")}
+	}))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().String()
+
+	rp := proxy.NewReverseProxy(proxy.Options{
+		Timeout:        5 * time.Second,
+		TrustedProxies: []string{"10.0.0.0/8"},
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+	})
+	do := func(remote, xff, real string) seen {
+		req := httptest.NewRequest(http.MethodGet, "/proxy/server/ws1/", nil)
+		req.RemoteAddr = remote
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		if real != "" {
+			req.Header.Set("X-Real-IP", real)
+		}
+		rp.ServeHTTP(httptest.NewRecorder(), req)
+		select {
+		case s := <-got:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("upstream not reached")
+			return seen{}
+		}
+	}
+
+	// Untrusted (e.g. tailnet) peer: spoofed headers are dropped and replaced by the peer IP.
+	if s := do("100.64.0.7:5555", "1.2.3.4", "1.2.3.4"); s.xff != "100.64.0.7" || s.real != "100.64.0.7" {
+		t.Fatalf("untrusted peer: %+v", s)
+	}
+	// Chained through trusted proxies: append the peer, real IP is the nearest untrusted hop.
+	if s := do("10.0.0.3:5555", "198.51.100.1, 203.0.113.9, 10.0.0.2", ""); s.xff != "198.51.100.1, 203.0.113.9, 10.0.0.2, 10.0.0.3" || s.real != "203.0.113.9" {
+		t.Fatalf("chained: %+v", s)
+	}
+	// A trusted peer's X-Real-IP is kept.
+	if s := do("127.0.0.1:5555", "203.0.113.9", "203.0.113.9"); s.xff != "203.0.113.9, 127.0.0.1" || s.real != "203.0.113.9" {
+		t.Fatalf("trusted real ip: %+v", s)
+	}
+}
+
+func TestProxyForwardedHostAndPrefixTrust(t *testing.T) {
+	type seen struct{ host, proto, prefix string }
+	got := make(chan seen, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- seen{r.Header.Get("X-Forwarded-Host"), r.Header.Get("X-Forwarded-Proto"), r.Header.Get("X-Forwarded-Prefix")}
+	}))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().String()
+
+	rp := proxy.NewReverseProxy(proxy.Options{
+		Timeout: 5 * time.Second,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+	})
+	do := func(remote string, ctx context.Context) seen {
+		req := httptest.NewRequest(http.MethodGet, "/proxy/server/ws1/", nil).WithContext(ctx)
+		req.RemoteAddr = remote
+		req.Host = "hostapp:8090"
+		req.Header.Set("X-Forwarded-Host", "evil.example")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("X-Forwarded-Prefix", "/evil")
+		rp.ServeHTTP(httptest.NewRecorder(), req)
+		select {
+		case s := <-got:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("upstream not reached")
+			return seen{}
+		}
+	}
+
+	// Default list: loopback and tailnet peers are trusted, others are overwritten.
+	for _, remote := range []string{"127.0.0.1:5555", "100.101.102.103:5555", "[fd7a:115c:a1e0::1]:5555"} {
+		if s := do(remote, context.Background()); s != (seen{"evil.example", "https", "/evil"}) {
+			t.Fatalf("trusted %s: %+v", remote, s)
+		}
+	}
+	if s := do("203.0.113.9:5555", context.Background()); s != (seen{"hostapp:8090", "http", "/proxy/server/ws1"}) {
+		t.Fatalf("untrusted: %+v", s)
+	}
+	// A router-supplied prefix wins regardless of the peer.
+	ctx := proxy.WithForwardedPrefix(context.Background(), "/api/cluster/c1/proxy/server/ws1")
+	if s := do("203.0.113.9:5555", ctx); s.prefix != "/api/cluster/c1/proxy/server/ws1" {
+		t.Fatalf("router prefix: %+v", s)
+	}
+}
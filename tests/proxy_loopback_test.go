@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+// loopbackProxy fronts upstream with an API-proxy director that counts its calls.
+func loopbackProxy(upstream *httptest.Server, apiCalls *int32) http.Handler {
+	addr := upstream.Listener.Addr().String()
+	return proxy.NewReverseProxy(proxy.Options{
+		Timeout: 5 * time.Second,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+		APIProxy: func() (http.RoundTripper, func(*http.Request, string, string, string), bool) {
+			set := func(req *http.Request, scheme, hostport, subPath string) {
+				atomic.AddInt32(apiCalls, 1)
+				// Simulate service/pod discovery against the API server
+				time.Sleep(200 * time.Microsecond)
+				req.URL.Scheme = "http"
+				req.URL.Host = hostport
+				req.URL.Path = subPath
+				req.Host = hostport
+			}
+			return http.DefaultTransport, set, true
+		},
+	})
+}
+
+func TestProxyLoopbackSkipsAPIProxy(t *testing.T) {
+	var sawServerID string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sawServerID = r.Header.Get("X-Guild-Server-ID")
+		_, _ = io.WriteString(w, r.URL.Path)
+	}))
+	defer upstream.Close()
+	var apiCalls int32
+	ts := httptest.NewServer(loopbackProxy(upstream, &apiCalls))
+	defer ts.Close()
+
+	for _, path := range []string{"/proxy/server/ws1/", "/proxy/server/ws1/api/items"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", path, resp.StatusCode, b)
+		}
+	}
+	if n := atomic.LoadInt32(&apiCalls); n != 0 {
+		t.Fatalf("API proxy director called %d times for a loopback target", n)
+	}
+	if sawServerID != "" {
+		t.Fatalf("loopback upstream got X-Guild-Server-ID=%q", sawServerID)
+	}
+
+	for host, want := range map[string]bool{
+		"127.0.0.1:8080": true, "localhost": true, "LOCALHOST.:80": true, "[::1]:3000": true, "::1": true,
+		"10.0.0.5:8080": false, "example.com": false, "": false,
+	} {
+		if got := proxy.IsLoopbackHost(host); got != want {
+			t.Fatalf("IsLoopbackHost(%q) = %v", host, got)
+		}
+	}
+}
+
+func BenchmarkProxyLoopbackFastPath(b *testing.B) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+	var apiCalls int32
+	h := loopbackProxy(upstream, &apiCalls)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/proxy/server/ws1/", nil))
+		if rec.Code != http.StatusOK {
+			b.Fatalf("status %d", rec.Code)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestPathRules(t *testing.T) {
+	rules := proxy.PathRulesFromAnnotations(map[string]string{
+		proxy.AnnotationAllowPaths: "/app, /static/",
+		proxy.AnnotationDenyPaths:  "/app/admin",
+	})
+	cases := []struct {
+		path string
+		ok   bool
+	}{
+		{"/app", true},
+		{"/app/index.html", true},
+		{"/static/x.js", true},
+		{"/app/admin", false},
+		{"/app/admin/users", false},
+		{"//app/admin", false},
+		{"/app/x/../admin", false},
+		{"/application", false},
+		{"/", false},
+	}
+	for _, c := range cases {
+		if got := rules.Allowed(c.path); got != c.ok {
+			t.Fatalf("allowed(%q)=%v want %v", c.path, got, c.ok)
+		}
+	}
+	if !(proxy.PathRules{}).Allowed("/anything") {
+		t.Fatalf("empty rules should allow all paths")
+	}
+}
+
+func TestProxyPathRulesDeny(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().String()
+
+	rp := proxy.NewReverseProxy(proxy.Options{
+		Timeout: 5 * time.Second,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+		ResolvePathRules: func(ctx context.Context, serverID string) (proxy.PathRules, error) {
+			return proxy.PathRules{Deny: []string{"/admin"}}, nil
+		},
+	})
+	ts := httptest.NewServer(rp)
+	defer ts.Close()
+
+	for path, want := range map[string]int{
+		"/proxy/server/ws1/":          http.StatusOK,
+		"/proxy/server/ws1/admin":     http.StatusForbidden,
+		"/proxy/server/ws1/admin/cfg": http.StatusForbidden,
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("get %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("%s: status=%d want %d", path, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestProxyRangeRequestPassesThrough(t *testing.T) {
+	blob := make([]byte, 64<<10)
+	for i := range blob {
+		blob[i] = byte(i * 7)
+	}
+	var upstreamRange string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamRange = r.Header.Get("Range")
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "blob.bin", time.Unix(0, 0), bytes.NewReader(blob))
+	}))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().String()
+
+	rp := proxy.NewReverseProxy(proxy.Options{
+		Timeout: 5 * time.Second,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+	})
+	ts := httptest.NewServer(rp)
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/proxy/server/ws1/blob.bin", nil)
+	req.Header.Set("Range", "bytes=1000-4999")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upstreamRange != "bytes=1000-4999" {
+		t.Fatalf("upstream Range=%q", upstreamRange)
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("status=%d want 206", resp.StatusCode)
+	}
+	if cr := resp.Header.Get("Content-Range"); cr != fmt.Sprintf("bytes 1000-4999/%d", len(blob)) {
+		t.Fatalf("Content-Range=%q", cr)
+	}
+	if resp.Header.Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("Accept-Ranges=%q", resp.Header.Get("Accept-Ranges"))
+	}
+	if !bytes.Equal(got, blob[1000:5000]) {
+		t.Fatalf("partial body mismatch: got %d bytes", len(got))
+	}
+	if csp := resp.Header.Get("Content-Security-Policy"); csp != "" {
+		t.Fatalf("binary response should not get an HTML CSP, got %q", csp)
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestProxyRedirectStaysUnderClusterPrefix(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", r.URL.Query().Get("loc"))
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().String()
+
+	rp := proxy.NewReverseProxy(proxy.Options{
+		Timeout: 5 * time.Second,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+	})
+	ts := httptest.NewServer(rp)
+	defer ts.Close()
+	cl := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	const prefix = "/api/cluster/c1/proxy/server/ws1"
+	for loc, want := range map[string]string{
+		"/":                       prefix + "/",
+		"/app":                    prefix + "/app",
+		"/app/?folder=x":          prefix + "/app/?folder=x",
+		prefix:                    prefix + "/",
+		prefix + "/app":           prefix + "/app",
+		"http://upstream:8080":    prefix + "/",
+		"http://upstream:8080/ok": prefix + "/ok",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/proxy/server/ws1/app?loc="+url.QueryEscape(loc), nil)
+		req.Header.Set("X-Forwarded-Prefix", prefix)
+		resp, err := cl.Do(req)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Location"); got != want {
+			t.Fatalf("Location %q rewritten to %q, want %q", loc, got, want)
+		}
+	}
+}
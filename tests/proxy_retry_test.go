@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestProxyRetriesIdempotentOnConnectionError(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok " + r.Method))
+	}))
+	defer upstream.Close()
+	// A listener that is closed right away stands in for a pod going away mid-rollout.
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadAddr := dead.Addr().String()
+	dead.Close()
+
+	newProxy := func(retries int) (*httptest.Server, *int) {
+		resolves := 0
+		rp := proxy.NewReverseProxy(proxy.Options{
+			Timeout: 5 * time.Second,
+			Retries: retries,
+			Dial: func(ctx context.Context, network, address string) (any, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+			ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+				resolves++
+				if resolves == 1 {
+					return "http", deadAddr, subPath, nil
+				}
+				return "http", upstream.Listener.Addr().String(), subPath, nil
+			},
+		})
+		return httptest.NewServer(rp), &resolves
+	}
+
+	ts, resolves := newProxy(0)
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/proxy/server/ws1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok GET" || *resolves != 2 {
+		t.Fatalf("GET status=%d body=%q resolves=%d", resp.StatusCode, body, *resolves)
+	}
+
+	// Non-idempotent methods are not retried.
+	ts2, resolves2 := newProxy(0)
+	defer ts2.Close()
+	resp, err = http.Post(ts2.URL+"/proxy/server/ws1/", "text/plain", strings.NewReader("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || *resolves2 != 1 {
+		t.Fatalf("POST status=%d resolves=%d", resp.StatusCode, *resolves2)
+	}
+
+	// Negative Retries disables retrying.
+	ts3, resolves3 := newProxy(-1)
+	defer ts3.Close()
+	resp, err = http.Get(ts3.URL + "/proxy/server/ws1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || *resolves3 != 1 {
+		t.Fatalf("disabled status=%d resolves=%d", resp.StatusCode, *resolves3)
+	}
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+// schemeProxy serves /proxy/server/{id}/ against addr, declaring scheme for every server.
+func schemeProxy(t *testing.T, scheme, addr string, disable bool) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(proxy.NewReverseProxy(proxy.Options{
+		Timeout: 5 * time.Second,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return scheme, addr, subPath, nil
+		},
+		DisableSchemeFallback: disable,
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func getBody(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestProxySchemeFallbackHTTPSToHTTP(t *testing.T) {
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte("plain"))
+	}))
+	defer upstream.Close()
+	ts := schemeProxy(t, "https", upstream.Listener.Addr().String(), false)
+
+	for i := 0; i < 2; i++ {
+		if code, body := getBody(t, ts.URL+"/proxy/server/ws1/"); code != http.StatusOK || body != "plain" {
+			t.Fatalf("request %d: status=%d body=%q", i, code, body)
+		}
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("upstream hits=%d, want 2", hits.Load())
+	}
+
+	off := schemeProxy(t, "https", upstream.Listener.Addr().String(), true)
+	if code, _ := getBody(t, off.URL+"/proxy/server/ws1/"); code == http.StatusOK {
+		t.Fatal("fallback ran with DisableSchemeFallback")
+	}
+}
+
+func TestProxySchemeFallbackHTTPToHTTPS(t *testing.T) {
+	var hits atomic.Int32
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte("tls"))
+	}))
+	defer upstream.Close()
+	ts := schemeProxy(t, "http", upstream.Listener.Addr().String(), false)
+
+	// Go's TLS server answers a plain request with a 400 "HTTP request to an HTTPS server"
+	for i := 0; i < 2; i++ {
+		if code, body := getBody(t, ts.URL+"/proxy/server/ws1/"); code != http.StatusOK || body != "tls" {
+			t.Fatalf("request %d: status=%d body=%q", i, code, body)
+		}
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("upstream hits=%d, want 2", hits.Load())
+	}
+}
+
+func TestProxySchemeFallbackIgnoresCrossHostRedirect(t *testing.T) {
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	defer tlsSrv.Close()
+	addr := tlsSrv.Listener.Addr().String()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://"+addr+r.URL.Path, http.StatusMovedPermanently)
+	}))
+	defer redirect.Close()
+
+	// Only a redirect back to the same host:port means "use https"; this one is passed through
+	ts := schemeProxy(t, "http", redirect.Listener.Addr().String(), false)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(ts.URL + "/proxy/server/ws1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMovedPermanently {
+		t.Fatalf("cross-host redirect: status=%d, want 301", resp.StatusCode)
+	}
+}
+
+func TestProxySchemeFallbackViaAPIServerSharedAcrossProxies(t *testing.T) {
+	var paths []string
+	// The API server relays a failed TLS handshake with a plain-HTTP workspace as a 503
+	apiRT := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.Path)
+		if strings.Contains(req.URL.Path, "/services/https:") {
+			body := `{"kind":"Status","message":"error trying to reach service: tls: first record does not look like a TLS handshake","code":503}`
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("plain")), Request: req}, nil
+	})
+	schemes := proxy.NewSchemeCache()
+	// Like the router, build a proxy per request and share the scheme cache
+	serve := func() *httptest.ResponseRecorder {
+		rp := proxy.NewReverseProxy(proxy.Options{
+			Timeout: 5 * time.Second,
+			Retries: -1,
+			Schemes: schemes,
+			Dial: func(ctx context.Context, network, address string) (any, error) {
+				return nil, errors.New("unexpected direct dial to " + address)
+			},
+			ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+				return "https", "10.0.0.5:8443", subPath, nil
+			},
+			APIProxy: func() (http.RoundTripper, func(*http.Request, string, string, string), bool) {
+				return apiRT, func(req *http.Request, scheme, hostport, subPath string) {
+					req.URL.Scheme = "https"
+					req.URL.Host = "apiserver.example:6443"
+					req.URL.Path = "/api/v1/namespaces/default/services/" + scheme + ":ws1:8443/proxy" + subPath
+					req.Host = req.URL.Host
+				}, true
+			},
+		})
+		rec := httptest.NewRecorder()
+		rp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/proxy/server/ws1/", nil))
+		return rec
+	}
+
+	if rec := serve(); rec.Code != http.StatusOK || rec.Body.String() != "plain" {
+		t.Fatalf("first request: status=%d body=%q", rec.Code, rec.Body.String())
+	}
+	if len(paths) != 2 || !strings.Contains(paths[1], "/services/http:ws1:8443/proxy/") {
+		t.Fatalf("first request hit %v, want https then http", paths)
+	}
+	paths = nil
+	if rec := serve(); rec.Code != http.StatusOK {
+		t.Fatalf("second request: status=%d", rec.Code)
+	}
+	if len(paths) != 1 || !strings.Contains(paths[0], "/services/http:") {
+		t.Fatalf("second proxy hit %v, want the learned http scheme only", paths)
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestProxyServerTiming(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.Header().Set("Server-Timing", "app;dur=5")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().String()
+	newProxy := func(enabled bool) *httptest.Server {
+		return httptest.NewServer(proxy.NewReverseProxy(proxy.Options{
+			Timeout: 5 * time.Second,
+			Dial: func(ctx context.Context, network, address string) (any, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+			ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+				return "http", addr, subPath, nil
+			},
+			ServerTiming: enabled,
+		}))
+	}
+
+	ts := newProxy(true)
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/proxy/server/ws1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	got := resp.Header.Values("Server-Timing")
+	if len(got) != 2 || got[0] != "app;dur=5" {
+		t.Fatalf("Server-Timing = %q, want upstream metric kept plus the proxy's", got)
+	}
+	durs := map[string]float64{}
+	for _, m := range regexp.MustCompile(`(\w+);dur=([0-9.]+)`).FindAllStringSubmatch(got[1], -1) {
+		durs[m[1]], _ = strconv.ParseFloat(m[2], 64)
+	}
+	for _, k := range []string{"resolve", "dial", "upstream"} {
+		if _, ok := durs[k]; !ok {
+			t.Fatalf("missing %s in %q", k, got[1])
+		}
+	}
+	if durs["upstream"] < 30 {
+		t.Fatalf("upstream first byte %.1fms, want >= 30ms", durs["upstream"])
+	}
+
+	off := newProxy(false)
+	defer off.Close()
+	resp, err = http.Get(off.URL + "/proxy/server/ws1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if v := strings.Join(resp.Header.Values("Server-Timing"), ","); v != "app;dur=5" {
+		t.Fatalf("disabled Server-Timing = %q", v)
+	}
+}
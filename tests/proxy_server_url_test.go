@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestServerURL(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/cluster/c1/servers", nil)
+	r.Host = "127.0.0.1:8090"
+	fwd := httptest.NewRequest("GET", "/api/cluster/c1/servers", nil)
+	fwd.Header.Set("X-Forwarded-Proto", "https")
+	fwd.Header.Set("X-Forwarded-Host", "guild.example")
+	fwd.RemoteAddr = "127.0.0.1:40000"
+	spoofed := fwd.Clone(fwd.Context())
+	spoofed.RemoteAddr = "203.0.113.9:40000"
+	spoofed.Host = "127.0.0.1:8090"
+
+	cases := []struct {
+		base, cluster, name string
+		want                string
+	}{
+		{"", "c1", "ws-1", "/api/cluster/c1/proxy/server/ws-1/"},
+		{"", "", "ws-1", "/proxy/server/ws-1/"},
+		{"https://guild.example/", "c1", "ws-1", "https://guild.example/api/cluster/c1/proxy/server/ws-1/"},
+	}
+	for _, c := range cases {
+		if got := proxy.ServerURL(c.base, r, c.cluster, c.name); got != c.want {
+			t.Fatalf("ServerURL(%q,%q,%q)=%q want %q", c.base, c.cluster, c.name, got, c.want)
+		}
+	}
+	if got := proxy.ServerURL(proxy.ServerURLBaseRequest, r, "c1", "ws-1"); got != "http://127.0.0.1:8090/api/cluster/c1/proxy/server/ws-1/" {
+		t.Fatalf("request origin: got %q", got)
+	}
+	if got := proxy.ServerURL(proxy.ServerURLBaseRequest, fwd, "c1", "ws-1"); got != "https://guild.example/api/cluster/c1/proxy/server/ws-1/" {
+		t.Fatalf("forwarded origin: got %q", got)
+	}
+	if got := proxy.ServerURL(proxy.ServerURLBaseRequest, spoofed, "c1", "ws-1"); got != "http://127.0.0.1:8090/api/cluster/c1/proxy/server/ws-1/" {
+		t.Fatalf("untrusted forwarded origin: got %q", got)
+	}
+}
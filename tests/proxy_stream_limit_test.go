@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestProxyStreamLimitPerServer(t *testing.T) {
+	started := make(chan struct{}, 8)
+	unblock := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			started <- struct{}{}
+			select {
+			case <-unblock:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+	defer close(unblock)
+	addr := upstream.Listener.Addr().String()
+
+	limiter := proxy.NewStreamLimiter()
+	p := proxy.NewReverseProxy(proxy.Options{
+		Timeout: 5 * time.Second,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+		MaxStreamsPerServer: 2,
+		Streams:             limiter,
+	})
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/proxy/server/ws1/events", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("stream %d: %v", i, err)
+		}
+		defer resp.Body.Close()
+		<-started
+	}
+	if n := limiter.Active("ws1"); n != 2 {
+		t.Fatalf("active streams = %d, want 2", n)
+	}
+
+	resp, err := http.Get(srv.URL + "/proxy/server/ws1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || body["code"] != "too_many_streams" {
+		t.Fatalf("over limit: status=%d body=%v, want 429 too_many_streams", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Fatal("429 without Retry-After")
+	}
+
+	// Other servers have their own budget
+	resp, err = http.Get(srv.URL + "/proxy/server/ws2/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("other server: status=%d, want 200", resp.StatusCode)
+	}
+
+	// Closing the streams frees their slots
+	cancel()
+	deadline := time.Now().Add(3 * time.Second)
+	for limiter.Active("ws1") != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("active streams = %d after close, want 0", limiter.Active("ws1"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	resp, err = http.Get(srv.URL + "/proxy/server/ws1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("after release: status=%d, want 200", resp.StatusCode)
+	}
+}
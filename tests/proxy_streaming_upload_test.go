@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func uploadProxy(upstream *httptest.Server, maxBody int64, timeout time.Duration) *httptest.Server {
+	return httptest.NewServer(uploadHandler(upstream, maxBody, timeout))
+}
+
+func uploadHandler(upstream *httptest.Server, maxBody int64, timeout time.Duration) http.Handler {
+	addr := upstream.Listener.Addr().String()
+	return proxy.NewReverseProxy(proxy.Options{
+		MaxBody: maxBody,
+		Timeout: timeout,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+	})
+}
+
+func TestProxyStreamsChunkedUpload(t *testing.T) {
+	const chunk = 256 << 10
+	firstChunk := make(chan struct{})
+	type result struct {
+		te     []string
+		cl     int64
+		n      int64
+		sawAll bool
+	}
+	done := make(chan result, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := result{te: r.TransferEncoding, cl: r.ContentLength}
+		buf := make([]byte, 32<<10)
+		signalled := false
+		for {
+			n, err := r.Body.Read(buf)
+			res.n += int64(n)
+			if !signalled && res.n >= chunk {
+				signalled = true
+				close(firstChunk)
+			}
+			if err == io.EOF {
+				res.sawAll = true
+				break
+			}
+			if err != nil {
+				break
+			}
+		}
+		done <- res
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	// The upload outlasts Timeout and the server's read/write timeouts, like the Host App
+	// listeners': only bodiless requests get a total deadline
+	ts := httptest.NewUnstartedServer(httpx.Logging(uploadHandler(upstream, 64<<20, 300*time.Millisecond)))
+	ts.Config.ReadTimeout = 300 * time.Millisecond
+	ts.Config.WriteTimeout = 300 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		data := bytes.Repeat([]byte("x"), chunk)
+		if _, err := pw.Write(data); err != nil {
+			return
+		}
+		// The rest is only sent once the upstream has seen the first chunk, so a proxy
+		// that buffered the whole body would deadlock here
+		select {
+		case <-firstChunk:
+		case <-time.After(5 * time.Second):
+			pw.CloseWithError(io.ErrUnexpectedEOF)
+			return
+		}
+		for i := 0; i < 7; i++ {
+			time.Sleep(100 * time.Millisecond)
+			if _, err := pw.Write(data); err != nil {
+				return
+			}
+		}
+		pw.Close()
+	}()
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/proxy/server/ws1/upload", pr)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status=%d", resp.StatusCode)
+	}
+	res := <-done
+	if !res.sawAll || res.n != 8*chunk {
+		t.Fatalf("upstream got %d bytes (complete=%v), want %d", res.n, res.sawAll, 8*chunk)
+	}
+	if res.cl != -1 || len(res.te) != 1 || res.te[0] != "chunked" {
+		t.Fatalf("upstream framing: content-length=%d transfer-encoding=%v", res.cl, res.te)
+	}
+}
+
+func TestProxyUploadContentLengthAndMaxBody(t *testing.T) {
+	got := make(chan int64, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		if r.ContentLength != n {
+			n = -n
+		}
+		got <- n
+	}))
+	defer upstream.Close()
+	ts := uploadProxy(upstream, 1<<20, 5*time.Second)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/proxy/server/ws1/upload", "application/octet-stream", bytes.NewReader(make([]byte, 1000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := <-got; n != 1000 {
+		t.Fatalf("upstream Content-Length/body mismatch: %d", n)
+	}
+
+	// Declared too large: rejected before reaching the upstream
+	resp, err = http.Post(ts.URL+"/proxy/server/ws1/upload", "application/octet-stream", bytes.NewReader(make([]byte, 2<<20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("declared oversize: status=%d", resp.StatusCode)
+	}
+	// Chunked and too large: cut off at the cap (MultiReader hides the length)
+	body := io.MultiReader(strings.NewReader(strings.Repeat("y", 2<<20)))
+	resp, err = http.Post(ts.URL+"/proxy/server/ws1/upload", "application/octet-stream", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("chunked oversize: status=%d", resp.StatusCode)
+	}
+	select {
+	case n := <-got:
+		if n == 2<<20 || n == -(2<<20) {
+			t.Fatalf("upstream received the whole oversized body")
+		}
+	case <-time.After(5 * time.Second):
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestProxyStripPrefixRewritesBodies(t *testing.T) {
+	var sawPrefix, sawEncoding string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sawPrefix = r.Header.Get("X-Forwarded-Prefix")
+		sawEncoding = r.Header.Get("Accept-Encoding")
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = io.WriteString(w, `<a href="/docs">d</a><img src=/logo.png><script src="//cdn.example/x.js"></script><a href="/proxy/server/ws1/ok">k</a><div style="background:url(/bg.png)"></div>`)
+		case "/app.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			_, _ = io.WriteString(w, `fetch("/api/items"); const re = /a\/b/g; x.replace(/\//g, ""); p.split("/"); u = "/" + id; m = '/ is the root'; l = '/login?next=1'`)
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"path":"/api/items"}`)
+		}
+	}))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().String()
+
+	newProxy := func(strip bool) *httptest.Server {
+		return httptest.NewServer(proxy.NewReverseProxy(proxy.Options{
+			Timeout: 5 * time.Second,
+			Dial: func(ctx context.Context, network, address string) (any, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+			ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+				return "http", addr, subPath, nil
+			},
+			ResolveStripPrefix: func(ctx context.Context, serverID string) (bool, error) { return strip, nil },
+		}))
+	}
+	get := func(ts *httptest.Server, path string) string {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		if resp.ContentLength >= 0 && resp.ContentLength != int64(len(b)) {
+			t.Fatalf("%s: content-length %d for %d bytes", path, resp.ContentLength, len(b))
+		}
+		return string(b)
+	}
+
+	ts := newProxy(true)
+	defer ts.Close()
+	html := get(ts, "/proxy/server/ws1/")
+	if sawPrefix != "" || sawEncoding != "" {
+		t.Fatalf("strip-prefix upstream got prefix=%q accept-encoding=%q", sawPrefix, sawEncoding)
+	}
+	for _, want := range []string{
+		`href="/proxy/server/ws1/docs"`,
+		`src=/proxy/server/ws1/logo.png`,
+		`src="//cdn.example/x.js"`,
+		`href="/proxy/server/ws1/ok"`,
+		`url(/proxy/server/ws1/bg.png)`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("html missing %s:\n%s", want, html)
+		}
+	}
+	if js := get(ts, "/proxy/server/ws1/app.js"); js != `fetch("/proxy/server/ws1/api/items"); const re = /a\/b/g; x.replace(/\//g, ""); p.split("/"); u = "/" + id; m = '/ is the root'; l = '/proxy/server/ws1/login?next=1'` {
+		t.Fatalf("js rewritten to %s", js)
+	}
+	if js := get(ts, "/proxy/server/ws1/data.json"); js != `{"path":"/api/items"}` {
+		t.Fatalf("json outside the allowlist was rewritten: %s", js)
+	}
+
+	plain := newProxy(false)
+	defer plain.Close()
+	if html := get(plain, "/proxy/server/ws1/"); !strings.Contains(html, `href="/docs"`) || sawPrefix != "/proxy/server/ws1" {
+		t.Fatalf("default mode rewrote body or dropped prefix (prefix=%q): %s", sawPrefix, html)
+	}
+
+	if !proxy.StripPrefixFromAnnotations(map[string]string{proxy.AnnotationStripPrefix: "true"}) || proxy.StripPrefixFromAnnotations(nil) {
+		t.Fatal("annotation parsing")
+	}
+}
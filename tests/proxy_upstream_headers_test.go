@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestProxyStripsControlHeaders(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+	addr := upstream.Listener.Addr().String()
+	dial := func(ctx context.Context, network, address string) (any, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, address)
+	}
+
+	for _, viaAPI := range []bool{false, true} {
+		got = nil
+		var directorSaw http.Header
+		opts := proxy.Options{
+			Timeout: 5 * time.Second,
+			Dial:    dial,
+			ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+				if viaAPI {
+					return "http", "ws1.svc:8080", subPath, nil
+				}
+				return "http", addr, subPath, nil
+			},
+			StripHeaders: []string{"X-Internal-*", "x-debug-principal"},
+		}
+		if viaAPI {
+			opts.APIProxy = func() (http.RoundTripper, func(req *http.Request, scheme, hostport, path string), bool) {
+				return http.DefaultTransport, func(req *http.Request, scheme, hostport, path string) {
+					directorSaw = req.Header.Clone()
+					// Like the hostapp director: consumes and adds control headers
+					req.Header.Set("X-Guild-Fallback-Hostport", "10.0.0.1:8080")
+					req.URL.Scheme, req.URL.Host, req.Host, req.URL.Path = "http", addr, addr, path
+				}, true
+			}
+		}
+		ts := httptest.NewServer(proxy.NewReverseProxy(opts))
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/proxy/server/ws1/", nil)
+		req.Header.Set("X-Guild-Prefer-Pod", "1")
+		req.Header.Set("X-Guild-Use-PortForward", "1")
+		req.Header.Set("X-Internal-Token", "secret")
+		req.Header.Set("X-Debug-Principal", "user:admin")
+		req.Header.Set("X-App-Header", "keep")
+		resp, err := http.DefaultClient.Do(req)
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || got == nil {
+			t.Fatalf("viaAPI=%v: status=%d, upstream not reached", viaAPI, resp.StatusCode)
+		}
+		for k := range got {
+			if strings.HasPrefix(k, "X-Guild-") || strings.HasPrefix(k, "X-Internal-") || k == "X-Debug-Principal" {
+				t.Fatalf("viaAPI=%v: %s reached the upstream", viaAPI, k)
+			}
+		}
+		if got.Get("X-App-Header") != "keep" {
+			t.Fatalf("viaAPI=%v: unrelated header dropped: %v", viaAPI, got)
+		}
+		if viaAPI && (directorSaw.Get("X-Guild-Prefer-Pod") != "1" || directorSaw.Get("X-Guild-Server-ID") != "ws1") {
+			t.Fatalf("API director did not see control headers: %v", directorSaw)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+func TestInsecureUpstreamAllowed(t *testing.T) {
+	cases := []struct {
+		host         string
+		serverTarget bool
+		want         bool
+	}{
+		{"127.0.0.1", false, true},
+		{"::1", false, true},
+		{"localhost", false, true},
+		{"web.default.svc", false, true},
+		{"web.default.svc.cluster.local.", false, true},
+		{"10.96.0.12", true, true},
+		{"10.96.0.12", false, false},
+		{"100.64.1.2", true, false},
+		{"example.com", true, false},
+		{"", true, false},
+	}
+	for _, c := range cases {
+		if got := proxy.InsecureUpstreamAllowed(c.host, c.serverTarget); got != c.want {
+			t.Fatalf("InsecureUpstreamAllowed(%q,%v)=%v want %v", c.host, c.serverTarget, got, c.want)
+		}
+	}
+	if cfg := proxy.UpstreamTLSConfig("example.com", false, nil); cfg.InsecureSkipVerify {
+		t.Fatal("public upstream must be verified")
+	}
+	if cfg := proxy.UpstreamTLSConfig("127.0.0.1", false, x509.NewCertPool()); cfg.InsecureSkipVerify || cfg.RootCAs == nil {
+		t.Fatal("configured CA must be enforced even for loopback")
+	}
+	if _, err := proxy.CertPoolFromPEM("not a cert"); err == nil {
+		t.Fatal("expected error for invalid PEM")
+	}
+}
+
+func TestProxyUpstreamCA(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+	trusted := x509.NewCertPool()
+	trusted.AddCert(upstream.Certificate())
+
+	get := func(roots *x509.CertPool) int {
+		p := proxy.NewReverseProxy(proxy.Options{
+			Timeout: 5 * time.Second,
+			Retries: -1,
+			RootCAs: roots,
+			Dial: func(ctx context.Context, network, address string) (any, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+		})
+		host := upstream.Listener.Addr().String()
+		req := httptest.NewRequest(http.MethodGet, "/proxy?to="+host+"&path=/&scheme=https", nil)
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := get(nil); code != http.StatusOK {
+		t.Fatalf("loopback without CA: status=%d want 200", code)
+	}
+	if code := get(trusted); code != http.StatusOK {
+		t.Fatalf("trusted CA: status=%d want 200", code)
+	}
+	if code := get(x509.NewCertPool()); code != http.StatusBadGateway {
+		t.Fatalf("untrusted CA: status=%d want 502", code)
+	}
+}
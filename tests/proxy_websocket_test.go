@@ -0,0 +1,238 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/proxy"
+)
+
+type wsFrame struct {
+	op      byte
+	payload []byte
+}
+
+// readWSFrame reads one unfragmented frame, unmasking it when needed.
+func readWSFrame(r *bufio.Reader) (wsFrame, error) {
+	var h [2]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return wsFrame{}, err
+	}
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return wsFrame{}, err
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return wsFrame{}, err
+		}
+		n = binary.BigEndian.Uint64(b[:])
+	}
+	var key [4]byte
+	masked := h[1]&0x80 != 0
+	if masked {
+		if _, err := io.ReadFull(r, key[:]); err != nil {
+			return wsFrame{}, err
+		}
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(r, p); err != nil {
+		return wsFrame{}, err
+	}
+	if masked {
+		for i := range p {
+			p[i] ^= key[i%4]
+		}
+	}
+	return wsFrame{op: h[0] & 0x0f, payload: p}, nil
+}
+
+// writeWSFrame writes a final frame (payload < 126 bytes), masked as a client must.
+func writeWSFrame(w io.Writer, op byte, payload []byte, mask bool) error {
+	b := []byte{0x80 | op, byte(len(payload))}
+	if !mask {
+		_, err := w.Write(append(b, payload...))
+		return err
+	}
+	key := []byte{1, 2, 3, 4}
+	b[1] |= 0x80
+	b = append(b, key...)
+	for i, c := range payload {
+		b = append(b, c^key[i%4])
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// wsEchoUpstream accepts WebSocket upgrades, answers pings and echoes text frames. It
+// counts the pings and pongs it receives.
+func wsEchoUpstream(t *testing.T, pings, pongs *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(brw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(sum[:]))
+		_ = brw.Flush()
+		for {
+			f, err := readWSFrame(brw.Reader)
+			if err != nil {
+				return
+			}
+			switch f.op {
+			case 0x9:
+				atomic.AddInt32(pings, 1)
+				_ = writeWSFrame(conn, 0xA, f.payload, false)
+			case 0xA:
+				atomic.AddInt32(pongs, 1)
+			case 0x1:
+				_ = writeWSFrame(conn, 0x1, f.payload, false)
+			case 0x8:
+				_ = writeWSFrame(conn, 0x8, f.payload, false)
+				return
+			}
+		}
+	}))
+}
+
+func wsProxy(upstream *httptest.Server, interval time.Duration) *httptest.Server {
+	addr := upstream.Listener.Addr().String()
+	return httptest.NewServer(proxy.NewReverseProxy(proxy.Options{
+		Timeout: 100 * time.Millisecond,
+		Dial: func(ctx context.Context, network, address string) (any, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+		ResolveServer: func(ctx context.Context, serverID, subPath string) (string, string, string, error) {
+			return "http", addr, subPath, nil
+		},
+		WSPingInterval: interval,
+		WSPongTimeout:  interval,
+	}))
+}
+
+// dialWS performs a client handshake against the proxy.
+func dialWS(t *testing.T, ts *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(conn, "GET /proxy/server/ws1/socket HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", ts.Listener.Addr())
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept = %q", got)
+	}
+	return conn, br
+}
+
+func TestProxyWebSocketKeepalive(t *testing.T) {
+	var pings, pongs int32
+	upstream := wsEchoUpstream(t, &pings, &pongs)
+	defer upstream.Close()
+	ts := wsProxy(upstream, 50*time.Millisecond)
+	defer ts.Close()
+	conn, br := dialWS(t, ts)
+	defer conn.Close()
+
+	// Answer pings for longer than both the handshake timeout and several intervals
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	seen := 0
+	for start := time.Now(); time.Since(start) < 400*time.Millisecond; {
+		f, err := readWSFrame(br)
+		if err != nil {
+			t.Fatalf("connection dropped while answering pings: %v", err)
+		}
+		if f.op != 0x9 {
+			t.Fatalf("unexpected opcode %#x", f.op)
+		}
+		seen++
+		if err := writeWSFrame(conn, 0xA, f.payload, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if seen < 3 {
+		t.Fatalf("client saw %d pings, want >= 3", seen)
+	}
+	if atomic.LoadInt32(&pings) == 0 {
+		t.Fatalf("upstream was never pinged")
+	}
+	if n := atomic.LoadInt32(&pongs); n != 0 {
+		t.Fatalf("upstream received %d keepalive pongs meant for the proxy", n)
+	}
+
+	// Data still flows in both directions
+	if err := writeWSFrame(conn, 0x1, []byte("hello"), true); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		f, err := readWSFrame(br)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.op == 0x9 {
+			_ = writeWSFrame(conn, 0xA, f.payload, true)
+			continue
+		}
+		if f.op != 0x1 || string(f.payload) != "hello" {
+			t.Fatalf("got op=%#x payload=%q, want echo", f.op, f.payload)
+		}
+		break
+	}
+}
+
+func TestProxyWebSocketDropsSilentClient(t *testing.T) {
+	var pings, pongs int32
+	upstream := wsEchoUpstream(t, &pings, &pongs)
+	defer upstream.Close()
+	ts := wsProxy(upstream, 50*time.Millisecond)
+	defer ts.Close()
+	conn, br := dialWS(t, ts)
+	defer conn.Close()
+
+	// Never answer: the proxy must give up after a ping interval plus the pong timeout
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	start := time.Now()
+	for {
+		if _, err := readWSFrame(br); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatalf("proxy kept a silent client connected")
+			}
+			break
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("silent client dropped after %v", d)
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestSDKSubscribeReconnectsWithLastEventID(t *testing.T) {
+	var conns atomic.Int32
+	lastIDs := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sse/cluster/c1/db/db1/tables/users/changes" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if got := r.URL.Query().Get("ops"); got != "insert,update" {
+			t.Errorf("ops = %q", got)
+		}
+		lastIDs <- r.Header.Get("Last-Event-ID")
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"init\",\"table_id\":\"users\"}\n\n: ping\n\n")
+		if conns.Add(1) == 1 {
+			fmt.Fprint(w, "data: {\"type\":\"insert\",\"table_id\":\"users\",\"row_id\":\"r1\",\"cursor\":\"c-1\"}\n\n")
+			return // drop the connection
+		}
+		fmt.Fprint(w, "data: {\"type\":\"update\",\"table_id\":\"users\",\"row_id\":\"r1\"}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := client.NewClient(srv.URL, "")
+	ch, err := c.Databases("c1").Subscribe(ctx, "db1", "users", client.SubscribeOptions{Ops: []string{"insert", "update"}, ReconnectDelay: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	timeout := time.After(5 * time.Second)
+	for len(types) < 4 {
+		select {
+		case ev := <-ch:
+			types = append(types, ev.Type)
+		case <-timeout:
+			t.Fatalf("timed out, got %v", types)
+		}
+	}
+	want := []string{"init", "insert", "init", "update"}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("events = %v, want %v", types, want)
+		}
+	}
+	if first, second := <-lastIDs, <-lastIDs; first != "" || second != "c-1" {
+		t.Fatalf("Last-Event-ID = %q then %q, want \"\" then \"c-1\"", first, second)
+	}
+	cancel()
+	closed := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestSDKSubscribeInitialError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"invalid_filter","message":"invalid changefeed filter"}`))
+	}))
+	defer srv.Close()
+
+	c := client.NewClient(srv.URL, "")
+	_, err := c.Databases("c1").Subscribe(context.Background(), "db1", "users", client.SubscribeOptions{Ops: []string{"upsert"}})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_filter" {
+		t.Fatalf("expected invalid_filter APIError, got %v", err)
+	}
+}
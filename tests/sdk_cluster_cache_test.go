@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestSDKClusterListCache(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/deploy/clusters" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"clusters":[{"id":"c1","name":"one"}]}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := client.NewClient(srv.URL, "", client.WithClusterCacheTTL(time.Minute))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Clusters().List(ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("expected 1 request with cache, got %d", n)
+	}
+
+	list, _ := c.Clusters().List(ctx)
+	list[0].Name = "mutated"
+	again, _ := c.Clusters().List(ctx)
+	if again[0].Name != "one" {
+		t.Fatalf("cached copy was mutated by caller")
+	}
+
+	if _, err := c.Clusters().Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("expected refresh to reload, got %d requests", n)
+	}
+
+	uncached := client.NewClient(srv.URL, "")
+	_, _ = uncached.Clusters().List(ctx)
+	_, _ = uncached.Clusters().List(ctx)
+	if n := hits.Load(); n != 4 {
+		t.Fatalf("expected no caching by default, got %d requests", n)
+	}
+}
@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestSDKClusterEvents(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/cluster/c1/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		gotQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"namespace":"default","type":"Warning","reason":"FailedScheduling","message":"0/3 nodes are available","object":"Pod/ws-1","count":4,"lastSeen":"2024-01-01T12:00:00Z"}]`))
+	}))
+	defer srv.Close()
+
+	c := client.NewClient(srv.URL, "")
+	since := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	evs, err := c.Clusters().Events(context.Background(), "c1", client.EventOptions{AllNamespaces: true, Since: since, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotQuery != "allNamespaces=1&limit=10&since=2024-01-01T11%3A00%3A00Z" {
+		t.Fatalf("query = %q", gotQuery)
+	}
+	if len(evs) != 1 || evs[0].Reason != "FailedScheduling" || evs[0].Count != 4 || evs[0].LastSeen.IsZero() {
+		t.Fatalf("events = %+v", evs)
+	}
+
+	if _, err := c.Clusters().Events(context.Background(), "c1", client.EventOptions{}); err != nil || gotQuery != "" {
+		t.Fatalf("default query = %q, err = %v", gotQuery, err)
+	}
+}
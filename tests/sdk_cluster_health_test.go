@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestSDKClusterListWithHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/deploy/clusters" || r.URL.Query().Get("withHealth") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// The hostapp returns a bare array of cluster records
+		_, _ = w.Write([]byte(`[{"id":"c1","name":"one","health":{"status":"ok","checkedAt":"2026-01-02T03:04:05Z"}},` +
+			`{"id":"c2","name":"two","health":{"status":"error","code":"cluster_unreachable","error":"timeout"}},{"id":"c3"}]`))
+	}))
+	defer srv.Close()
+
+	clusters, err := client.NewClient(srv.URL, "").Clusters().ListWithHealth(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 3 {
+		t.Fatalf("got %d clusters", len(clusters))
+	}
+	if !clusters[0].Health.Healthy() || clusters[0].Health.CheckedAt.IsZero() {
+		t.Fatalf("c1 health=%+v", clusters[0].Health)
+	}
+	if clusters[1].Health.Healthy() || clusters[1].Health.Code != "cluster_unreachable" {
+		t.Fatalf("c2 health=%+v", clusters[1].Health)
+	}
+	if clusters[2].Health.Healthy() {
+		t.Fatal("missing health reported healthy")
+	}
+}
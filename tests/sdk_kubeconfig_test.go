@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/api"
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/secrets"
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: c1
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: c1
+  context:
+    cluster: c1
+    user: u1
+current-context: c1
+users:
+- name: u1
+  user:
+    token: abc
+`
+
+func TestSDKClusterKubeconfig(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sec, _ := secrets.New("master-key")
+	enc, err := sec.Encrypt(testKubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Put("credentials", "cl:c1:kubeconfig", map[string]any{"value": enc, "encrypted": true})
+
+	srv := httptest.NewServer(api.Router(api.Deps{DB: db, Secrets: sec, Token: "s3cret"}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	kc, err := client.NewClient(srv.URL, "s3cret").Clusters().Kubeconfig(ctx, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kc != testKubeconfig {
+		t.Fatalf("kubeconfig mismatch:\n%s", kc)
+	}
+	if _, err := client.NewClient(srv.URL, "wrong").Clusters().Kubeconfig(ctx, "c1"); !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("wrong token err=%v", err)
+	}
+	if _, err := client.NewClient(srv.URL, "s3cret").Clusters().Kubeconfig(ctx, "missing"); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("missing cluster err=%v", err)
+	}
+}
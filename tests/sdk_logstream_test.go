@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/model"
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestSDKFollowLogsSurfacesRequestID(t *testing.T) {
+	var gotTail string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/cluster/c1/workspaces/ws1/logs/stream" {
+			http.NotFound(w, r)
+			return
+		}
+		gotTail = r.URL.Query().Get("tail")
+		w.Header().Set("Content-Type", "text/event-stream")
+		_ = httpx.SSEEvent(w, "meta", model.LogStreamMeta{RequestID: r.Header.Get("X-Request-Id"), Target: "ws1", Tail: 5})
+		fmt.Fprint(w, ": ping\n\n")
+		fmt.Fprint(w, "data: {\"t\":\"2026-01-02T03:04:05Z\",\"msg\":\"[ws1-0] hello\"}\n\n")
+		fmt.Fprint(w, "data: {\"t\":\"2026-01-02T03:04:06Z\",\"msg\":\"[ws1-0] world\"}\n\n")
+	})
+	srv := httptest.NewServer(httpx.RequestID(h))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ls, err := client.NewClient(srv.URL, "").Workspaces("c1").FollowLogs(ctx, "ws1", client.LogOptions{TailLines: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls.RequestID() == "" {
+		t.Fatal("request id not surfaced")
+	}
+	if gotTail != "5" {
+		t.Fatalf("tail=%q", gotTail)
+	}
+	var msgs []string
+	for ev := range ls.C {
+		if ev.Timestamp.IsZero() {
+			t.Fatalf("event without timestamp: %+v", ev)
+		}
+		msgs = append(msgs, ev.Message)
+	}
+	if len(msgs) != 2 || msgs[0] != "[ws1-0] hello" || msgs[1] != "[ws1-0] world" {
+		t.Fatalf("messages=%v", msgs)
+	}
+}
+
+func TestSDKFollowLogsTypedEvents(t *testing.T) {
+	exit := int32(137)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_ = httpx.SSEEvent(w, "meta", model.LogStreamMeta{Target: "ws1"})
+		_ = httpx.SSEEvent(w, "log", model.LogStreamLine{T: "2026-01-02T03:04:05Z", Msg: "[ws1-0] hello", Pod: "ws1-0", Container: "app"})
+		_ = httpx.SSEEvent(w, "heartbeat", map[string]string{"t": "2026-01-02T03:04:06Z"})
+		_ = httpx.SSEEvent(w, "eof", model.LogStreamEOF{T: "2026-01-02T03:04:07Z", Pod: "ws1-0", Container: "app", Reason: "OOMKilled", ExitCode: &exit})
+		_ = httpx.SSEEvent(w, "log", model.LogStreamLine{T: "2026-01-02T03:04:08Z", Msg: "after eof"})
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ls, err := client.NewClient(srv.URL, "").Workspaces("c1").FollowLogs(ctx, "ws1", client.LogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var evs []client.LogEvent
+	for ev := range ls.C {
+		evs = append(evs, ev)
+	}
+	if len(evs) != 1 || evs[0].Message != "[ws1-0] hello" || evs[0].Pod != "ws1-0" || evs[0].Container != "app" {
+		t.Fatalf("events=%+v", evs)
+	}
+	eof := ls.EOF()
+	if eof == nil || eof.Pod != "ws1-0" || eof.Reason != "OOMKilled" || eof.ExitCode == nil || *eof.ExitCode != 137 {
+		t.Fatalf("eof=%+v", eof)
+	}
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/model"
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+type queryUser struct {
+	ID   string  `json:"id"`
+	Name string  `json:"name"`
+	Age  float64 `json:"age"`
+}
+
+func TestSDKQueryIntoTypedRows(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/cluster/c1/db/app/tables/users/rows" {
+			http.NotFound(w, r)
+			return
+		}
+		gotQuery = r.URL.RawQuery
+		httpx.JSON(w, http.StatusOK, model.QueryPage[map[string]any]{
+			Items:      []map[string]any{{"id": "u1", "name": "Ada", "age": 36}, {"id": "u2", "name": "Bob", "age": 41.5}},
+			NextCursor: "u2",
+		})
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	dc := client.NewClient(srv.URL, "").Databases("c1")
+
+	users, next, err := client.QueryInto[queryUser](ctx, dc, "app", "users", client.QueryOptions{OrderBy: "name", Limit: 2, Cursor: "u0", Descending: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0] != (queryUser{ID: "u1", Name: "Ada", Age: 36}) || users[1].Age != 41.5 || next != "u2" {
+		t.Fatalf("users=%+v next=%q", users, next)
+	}
+	for _, want := range []string{"limit=2", "orderBy=name", "cursor=u0", "forward=false"} {
+		if !strings.Contains(gotQuery, want) {
+			t.Fatalf("query %q missing %s", gotQuery, want)
+		}
+	}
+
+	rows, next, err := dc.Query(ctx, "app", "users", "", 10, "", true)
+	if err != nil || len(rows) != 2 || rows[0]["name"] != "Ada" || next != "u2" {
+		t.Fatalf("map rows=%v next=%q err=%v", rows, next, err)
+	}
+
+	if _, _, err := client.QueryInto[struct{ Name int }](ctx, dc, "app", "users", client.QueryOptions{}); err == nil {
+		t.Fatal("expected a decode error for mismatched field types")
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/api"
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/model"
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestWorkspaceTemplateApply(t *testing.T) {
+	tmpl := model.WorkspaceTemplate{Name: "code", Spec: map[string]any{
+		"image":  "codercom/code-server:latest",
+		"ports":  []any{map[string]any{"containerPort": 8080}},
+		"labels": map[string]any{"team": "a", "tier": "dev"},
+	}}
+	got := tmpl.Apply(map[string]any{
+		"name":   "ws-1",
+		"ports":  []any{map[string]any{"containerPort": 9000}},
+		"labels": map[string]any{"tier": "prod"},
+	})
+	want := map[string]any{
+		"name":   "ws-1",
+		"image":  "codercom/code-server:latest",
+		"ports":  []any{map[string]any{"containerPort": 9000}},
+		"labels": map[string]any{"team": "a", "tier": "prod"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Apply=%v want %v", got, want)
+	}
+	if tmpl.Spec["labels"].(map[string]any)["tier"] != "dev" {
+		t.Fatal("Apply modified the template")
+	}
+	if errs := (model.WorkspaceTemplate{Name: "x", Spec: map[string]any{"name": "fixed"}}).Validate(); len(errs) != 1 || errs[0].Field != "spec.name" {
+		t.Fatalf("errs=%v want spec.name", errs)
+	}
+}
+
+func TestSDKTemplates(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	srv := httptest.NewServer(api.Router(api.Deps{DB: db, Token: "s3cret"}))
+	defer srv.Close()
+	ctx := context.Background()
+	tc := client.NewClient(srv.URL, "s3cret").Templates()
+
+	saved, err := tc.Put(ctx, client.WorkspaceTemplate{Name: "code", Spec: map[string]any{"image": "nginx"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.CreatedAt == "" {
+		t.Fatal("createdAt not set")
+	}
+	if _, err := tc.Put(ctx, client.WorkspaceTemplate{Name: "Bad Name", Spec: map[string]any{}}); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if _, err := client.NewClient(srv.URL, "").Templates().Put(ctx, client.WorkspaceTemplate{Name: "anon", Spec: map[string]any{}}); !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("unauthenticated put err=%v", err)
+	}
+
+	list, err := tc.List(ctx)
+	if err != nil || len(list) != 1 || list[0].Name != "code" {
+		t.Fatalf("list=%v err=%v", list, err)
+	}
+	got, err := tc.Get(ctx, "code")
+	if err != nil || got.Spec["image"] != "nginx" {
+		t.Fatalf("get=%v err=%v", got, err)
+	}
+	if err := tc.Delete(ctx, "code"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.Get(ctx, "code"); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("get after delete err=%v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+	mgntesting "github.com/docxology/GuildNet/metaguildnet/sdk/go/testing"
+)
+
+func TestSDKTestingWaitForRowCount(t *testing.T) {
+	// Each poll sees one more row, served as two pages of at most two rows
+	var polls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/cluster/c1/db/app/tables":
+			_, _ = w.Write([]byte(`[{"id":"users","name":"users"}]`))
+		case "/api/cluster/c1/db/app/tables/users/rows":
+			// The first page of a poll bumps the count; its continuation reuses it
+			total := int(atomic.LoadInt32(&polls))
+			if r.URL.Query().Get("cursor") == "" {
+				total = int(atomic.AddInt32(&polls, 1)) - 1
+				if total > 2 {
+					fmt.Fprint(w, `{"items":[{"id":"1"},{"id":"2"}],"next_cursor":"2"}`)
+					return
+				}
+			} else {
+				total -= 3
+			}
+			items := ""
+			for i := 0; i < total; i++ {
+				if i > 0 {
+					items += ","
+				}
+				items += fmt.Sprintf(`{"id":"r%d"}`, i)
+			}
+			fmt.Fprintf(w, `{"items":[%s]}`, items)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	c := client.NewClient(ts.URL, "")
+
+	if err := mgntesting.WaitForRowCount(c, "c1", "app", "users", 3, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := mgntesting.CountRows(context.Background(), c, "c1", "app", "users"); err != nil || n != 4 {
+		t.Fatalf("CountRows = %d, %v (want the next poll's 4 rows across pages)", n, err)
+	}
+	if err := mgntesting.WaitForRowCount(c, "c1", "app", "missing", 1, time.Second); err == nil {
+		t.Fatal("expected a timeout for a missing table")
+	}
+	mgntesting.AssertTableExists(t, c, "c1", "app", "users")
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+// createWaitServer accepts creates (renaming them like a taken name would) and reports
+// phase for every get.
+func createWaitServer(phase string, gets *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/cluster/c1/workspaces":
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"id":"demo-x1","name":"demo-x1","status":"pending"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/cluster/c1/workspaces/demo-x1":
+			atomic.AddInt32(gets, 1)
+			_, _ = w.Write([]byte(`{"spec":{"image":"nginx"},"status":{"phase":"` + phase + `","lastError":"ImagePullBackOff","serviceDNS":"demo-x1.default.svc"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestSDKWorkspaceCreateWaitReady(t *testing.T) {
+	var gets int32
+	ts := createWaitServer("Running", &gets)
+	defer ts.Close()
+	wc := client.NewClient(ts.URL, "").Workspaces("c1")
+
+	ws, err := wc.Create(context.Background(), client.WorkspaceSpec{Name: "demo", Image: "nginx"})
+	if err != nil || ws.Status != "pending" || atomic.LoadInt32(&gets) != 0 {
+		t.Fatalf("default create should not wait: ws=%+v err=%v gets=%d", ws, err, gets)
+	}
+
+	ws, err = wc.Create(context.Background(), client.WorkspaceSpec{Name: "demo", Image: "nginx"}, client.WithWaitReady(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Name != "demo-x1" || ws.ID != "demo-x1" || ws.Status != "Running" || ws.ServiceDNS != "demo-x1.default.svc" {
+		t.Fatalf("ready workspace = %+v", ws)
+	}
+}
+
+func TestSDKWorkspaceCreateWaitFailsFast(t *testing.T) {
+	var gets int32
+	ts := createWaitServer("Failed", &gets)
+	defer ts.Close()
+	wc := client.NewClient(ts.URL, "").Workspaces("c1")
+
+	start := time.Now()
+	ws, err := wc.Create(context.Background(), client.WorkspaceSpec{Name: "demo", Image: "nginx"}, client.WithWaitReady(time.Minute))
+	if err == nil || !strings.Contains(err.Error(), "ImagePullBackOff") {
+		t.Fatalf("err = %v, want failure reason", err)
+	}
+	if ws == nil || ws.Name != "demo-x1" {
+		t.Fatalf("created workspace should be returned for cleanup, got %+v", ws)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Fatalf("failed workspace waited %v", d)
+	}
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestSDKWorkspaceGetEndpoints(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/cluster/c1/workspaces/ide" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"spec": {"image": "codercom/code-server"},
+			"status": {"phase": "Running"},
+			"endpoints": [
+				{"portName": "http", "port": 8080, "scheme": "http", "proxyURL": "/api/cluster/c1/proxy/server/ide/"},
+				{"portName": "https", "port": 8443, "scheme": "https", "proxyURL": "https://guild.example/api/cluster/c1/proxy/server/ide:https/"}
+			],
+			"proxyAuth": true,
+			"containers": ["workspace", "sidecar"]
+		}`))
+	}))
+	defer ts.Close()
+
+	ws, err := client.NewClient(ts.URL, "").Workspaces("c1").Get(context.Background(), "ide")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws.Endpoints) != 2 {
+		t.Fatalf("endpoints = %+v", ws.Endpoints)
+	}
+	if got := ws.Endpoints[0]; got.PortName != "http" || got.Port != 8080 || got.Scheme != "http" || got.ProxyURL != ts.URL+"/api/cluster/c1/proxy/server/ide/" {
+		t.Fatalf("relative endpoint not resolved against the client base: %+v", got)
+	}
+	if got := ws.Endpoints[1]; got.Scheme != "https" || got.ProxyURL != "https://guild.example/api/cluster/c1/proxy/server/ide:https/" {
+		t.Fatalf("absolute endpoint = %+v", got)
+	}
+	if !ws.ProxyAuth {
+		t.Fatalf("ProxyAuth not reported")
+	}
+	if len(ws.Containers) != 2 || ws.Containers[1] != "sidecar" {
+		t.Fatalf("containers = %v", ws.Containers)
+	}
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/api"
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/secrets"
+	"github.com/docxology/GuildNet/metaguildnet/sdk/go/client"
+)
+
+func TestSecretsWithoutMasterKey(t *testing.T) {
+	disabled, _ := secrets.New("")
+	for name, m := range map[string]*secrets.Manager{"empty key": disabled, "nil": nil, "zero value": {}} {
+		if m.Enabled() {
+			t.Fatalf("%s: manager enabled", name)
+		}
+		v, enc, err := m.Seal("kc")
+		if err != nil || enc || v != "kc" {
+			t.Fatalf("%s: Seal=%q,%v,%v want plaintext", name, v, enc, err)
+		}
+		if v, err := m.Open("kc", false); err != nil || v != "kc" {
+			t.Fatalf("%s: Open plaintext=%q,%v", name, v, err)
+		}
+		if _, err := m.Open("c2VhbGVk", true); !errors.Is(err, secrets.ErrNoMasterKey) {
+			t.Fatalf("%s: Open encrypted err=%v", name, err)
+		}
+	}
+}
+
+func TestClusterKubeconfigRoundTripWithoutMasterKey(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	disabled, _ := secrets.New("")
+
+	for i, sec := range []*secrets.Manager{nil, disabled} {
+		id := []string{"c-nil", "c-disabled"}[i]
+		if err := api.StoreClusterKubeconfig(db, sec, id, testKubeconfig); err != nil {
+			t.Fatal(err)
+		}
+		var cred map[string]any
+		_ = db.Get("credentials", "cl:"+id+":kubeconfig", &cred)
+		if enc, ok := cred["encrypted"].(bool); !ok || enc || cred["value"] != testKubeconfig {
+			t.Fatalf("%s: stored record %v, want plaintext with encrypted=false", id, cred)
+		}
+		if kc, err := api.ClusterKubeconfig(db, sec, id); err != nil || kc != testKubeconfig {
+			t.Fatalf("%s: read back %q, %v", id, kc, err)
+		}
+	}
+
+	// Unencrypted records stay readable after a master key is configured, and the API
+	// serves them
+	sec, _ := secrets.New("master-key")
+	if kc, err := api.ClusterKubeconfig(db, sec, "c-disabled"); err != nil || kc != testKubeconfig {
+		t.Fatalf("plaintext record with key: %q, %v", kc, err)
+	}
+	srv := httptest.NewServer(api.Router(api.Deps{DB: db, Token: "s3cret"}))
+	defer srv.Close()
+	if kc, err := client.NewClient(srv.URL, "s3cret").Clusters().Kubeconfig(context.Background(), "c-nil"); err != nil || kc != testKubeconfig {
+		t.Fatalf("API read with nil secrets: %q, %v", kc, err)
+	}
+
+	// Encrypted records are never handed out without the key
+	if err := api.StoreClusterKubeconfig(db, sec, "c-enc", testKubeconfig); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.ClusterKubeconfig(db, disabled, "c-enc"); !errors.Is(err, secrets.ErrNoMasterKey) {
+		t.Fatalf("encrypted record without key err=%v", err)
+	}
+	if kc, err := api.ClusterKubeconfig(db, sec, "c-enc"); err != nil || kc != testKubeconfig {
+		t.Fatalf("encrypted record with key: %q, %v", kc, err)
+	}
+
+	// Legacy records without a marker are plaintext unless they decrypt to a kubeconfig
+	_ = db.Put("credentials", "cl:c-legacy:kubeconfig", map[string]any{"value": testKubeconfig})
+	if kc, err := api.ClusterKubeconfig(db, sec, "c-legacy"); err != nil || kc != testKubeconfig {
+		t.Fatalf("legacy record: %q, %v", kc, err)
+	}
+}
+
+func TestClusterKubeconfigSealedWithBaselineZeroKey(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// Earlier releases turned New("") into an enabled Manager with an all-zero key and
+	// stored credentials encrypted with it
+	baseline, _ := secrets.New(string(make([]byte, 32)))
+	enc, err := baseline.Encrypt(testKubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Put("credentials", "cl:c-old:kubeconfig", map[string]any{"value": enc, "encrypted": true})
+	_ = db.Put("credentials", "cl:c-old-unmarked:kubeconfig", map[string]any{"value": enc})
+
+	disabled, _ := secrets.New("")
+	withKey, _ := secrets.New("master-key")
+	for name, sec := range map[string]*secrets.Manager{"nil": nil, "disabled": disabled, "master key": withKey} {
+		for _, id := range []string{"c-old", "c-old-unmarked"} {
+			if kc, err := api.ClusterKubeconfig(db, sec, id); err != nil || kc != testKubeconfig {
+				t.Fatalf("%s %s: read back %q, %v", name, id, kc, err)
+			}
+		}
+	}
+
+	// The zero key only decrypts: new values are still stored in plaintext
+	v, encrypted, err := disabled.Seal(testKubeconfig)
+	if err != nil || encrypted || v != testKubeconfig {
+		t.Fatalf("Seal=%q,%v,%v want plaintext", v, encrypted, err)
+	}
+}
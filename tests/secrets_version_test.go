@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/secrets"
+)
+
+// legacyEncrypt reproduces the pre-versioning format: base64(nonce || ct).
+func legacyEncrypt(t *testing.T, key, plaintext string) string {
+	t.Helper()
+	k := make([]byte, 32)
+	copy(k, key)
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestSecretsVersionedRoundTrip(t *testing.T) {
+	m, _ := secrets.New("master-key")
+	ct, err := m.Encrypt("kubeconfig-data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(ct)
+	if raw[0] != 1 {
+		t.Fatalf("expected version byte 1, got %d", raw[0])
+	}
+	if v, _ := m.Version(ct); v != 1 {
+		t.Fatalf("Version()=%d want 1", v)
+	}
+	pt, err := m.DecryptAny(ct)
+	if err != nil || pt != "kubeconfig-data" {
+		t.Fatalf("DecryptAny=%q err=%v", pt, err)
+	}
+}
+
+func TestSecretsDecryptLegacy(t *testing.T) {
+	m, _ := secrets.New("master-key")
+	// Many samples so some legacy nonces start with the v1 byte and exercise the fallback.
+	for i := 0; i < 512; i++ {
+		ct := legacyEncrypt(t, "master-key", "old-value")
+		pt, err := m.Decrypt(ct)
+		if err != nil || pt != "old-value" {
+			t.Fatalf("legacy decrypt=%q err=%v", pt, err)
+		}
+	}
+	other, _ := secrets.New("other-key")
+	ct, _ := m.Encrypt("x")
+	if _, err := other.DecryptAny(ct); err == nil {
+		t.Fatalf("decrypt with wrong key should fail")
+	}
+}
+
+func TestSecretsStreamRoundTrip(t *testing.T) {
+	m, _ := secrets.New("master-key")
+	for _, size := range []int{0, 10, secrets.StreamChunkSize, 3*secrets.StreamChunkSize + 17} {
+		pt := make([]byte, size)
+		_, _ = rand.Read(pt)
+		var ct, out bytes.Buffer
+		if err := m.EncryptStream(bytes.NewReader(pt), &ct); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.DecryptStream(bytes.NewReader(ct.Bytes()), &out); err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(out.Bytes(), pt) {
+			t.Fatalf("size %d: plaintext mismatch", size)
+		}
+	}
+
+	pt := make([]byte, 2*secrets.StreamChunkSize+5)
+	var ct bytes.Buffer
+	if err := m.EncryptStream(bytes.NewReader(pt), &ct); err != nil {
+		t.Fatal(err)
+	}
+	full := ct.Bytes()
+	// Dropping the final chunk must be detected, not silently yield a prefix.
+	lastChunk := 4 + 5 + 16
+	if err := m.DecryptStream(bytes.NewReader(full[:len(full)-lastChunk]), io.Discard); err == nil {
+		t.Fatal("truncated stream decrypted")
+	}
+	tampered := append([]byte(nil), full...)
+	tampered[len(tampered)/2] ^= 1
+	if err := m.DecryptStream(bytes.NewReader(tampered), io.Discard); err == nil {
+		t.Fatal("tampered stream decrypted")
+	}
+	other, _ := secrets.New("other-key")
+	if err := other.DecryptStream(bytes.NewReader(full), io.Discard); err == nil {
+		t.Fatal("stream decrypted with the wrong key")
+	}
+}
+
+func TestSecretsLargeValueUsesStream(t *testing.T) {
+	m, _ := secrets.New("master-key")
+	large := strings.Repeat("tls-bundle-", secrets.StreamThreshold/8)
+	ct, err := m.Encrypt(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m.Version(ct); v != 2 {
+		t.Fatalf("large value version=%d want 2", v)
+	}
+	if pt, err := m.Decrypt(ct); err != nil || pt != large {
+		t.Fatalf("large round trip failed: %v", err)
+	}
+	small, _ := m.Encrypt("kubeconfig")
+	if v, _ := m.Version(small); v != 1 {
+		t.Fatalf("small value version=%d want 1", v)
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/httpx"
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/settings"
+)
+
+func TestSettingsMigrateLegacyLayout(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m := settings.Manager{DB: db}
+
+	// Unversioned records as written by older builds
+	_ = db.Put("settings", "global", map[string]any{"org_id": "o1", "frontend_origin": "https://ui.example", "listen_local": "0.0.0.0:9000"})
+	_ = db.Put("cluster-settings", "c1", map[string]any{"name": "one"})
+
+	var g settings.Global
+	_ = m.GetGlobal(&g)
+	if len(g.FrontendOrigins) != 1 || g.FrontendOrigins[0] != "https://ui.example" || g.FrontendOrigin != "https://ui.example" {
+		t.Fatalf("legacy origin not migrated on read: %+v", g)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := m.Migrate(); err != nil {
+			t.Fatalf("migrate #%d: %v", i+1, err)
+		}
+	}
+	var raw map[string]any
+	_ = db.Get("settings", "global", &raw)
+	if raw["schema_version"] != float64(settings.SchemaVersion) || raw["frontend_origin"] != nil || raw["org_id"] != "o1" {
+		t.Fatalf("stored global after migrate: %v", raw)
+	}
+	origins, _ := raw["frontend_origins"].([]any)
+	if len(origins) != 1 || origins[0] != "https://ui.example" {
+		t.Fatalf("stored origins: %v", raw["frontend_origins"])
+	}
+	raw = nil
+	_ = db.Get("cluster-settings", "c1", &raw)
+	if raw["schema_version"] != float64(settings.SchemaVersion) || raw["name"] != "one" {
+		t.Fatalf("stored cluster after migrate: %v", raw)
+	}
+
+	// Legacy single-origin writes still work
+	if err := m.PutGlobal(settings.Global{FrontendOrigin: "https://other.example"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = m.GetGlobal(&g)
+	if len(g.FrontendOrigins) != 1 || g.FrontendOrigins[0] != "https://other.example" {
+		t.Fatalf("single origin write: %+v", g)
+	}
+}
+
+func TestSettingsNewerSchemaNotOverwritten(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m := settings.Manager{DB: db}
+
+	future := map[string]any{"schema_version": settings.SchemaVersion + 1, "org_id": "o1", "listen_local": "0.0.0.0:9000"}
+	_ = db.Put("settings", "global", future)
+	if err := m.Migrate(); !errors.Is(err, settings.ErrNewerSchema) {
+		t.Fatalf("migrate err=%v want ErrNewerSchema", err)
+	}
+	var g settings.Global
+	_ = m.GetGlobal(&g)
+	if g.OrgID != "o1" {
+		t.Fatalf("newer record not readable: %+v", g)
+	}
+	g.DefaultNamespace = "default"
+	if err := m.PutGlobal(g); !errors.Is(err, settings.ErrNewerSchema) {
+		t.Fatalf("put err=%v want ErrNewerSchema", err)
+	}
+	var raw map[string]any
+	_ = db.Get("settings", "global", &raw)
+	if raw["default_namespace"] != nil || raw["listen_local"] != "0.0.0.0:9000" {
+		t.Fatalf("newer record overwritten: %v", raw)
+	}
+}
+
+func TestCORSAllowsEveryConfiguredOrigin(t *testing.T) {
+	h := httpx.CORSWithOptions(httpx.CORSOptions{AllowedOrigins: []string{"https://a.example", "https://b.example/"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for origin, want := range map[string]bool{"https://a.example": true, "https://b.example": true, "https://c.example": false} {
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Access-Control-Allow-Origin") == origin; got != want {
+			t.Fatalf("origin %s allowed=%v", origin, got)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/docxology/GuildNet/internal/localdb"
+	"github.com/docxology/GuildNet/internal/settings"
+)
+
+func TestEffectiveNamespace(t *testing.T) {
+	db, err := localdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m := settings.Manager{DB: db}
+
+	if got := m.EffectiveNamespace("c1"); got != "default" {
+		t.Fatalf("nothing set = %q, want default", got)
+	}
+	if err := m.PutGlobal(settings.Global{DefaultNamespace: "team-a"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.EffectiveNamespace("c1"); got != "team-a" {
+		t.Fatalf("global default = %q, want team-a", got)
+	}
+	if err := m.PutCluster("c1", settings.Cluster{Namespace: " workspaces "}); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.EffectiveNamespace("c1"); got != "workspaces" {
+		t.Fatalf("cluster override = %q, want workspaces", got)
+	}
+	if got := m.EffectiveNamespace(""); got != "team-a" {
+		t.Fatalf("no cluster = %q, want team-a", got)
+	}
+
+	// A stored invalid value is skipped rather than used in API paths
+	if got := settings.ResolveNamespace(settings.Cluster{Namespace: "Bad_NS"}, settings.Global{DefaultNamespace: "team-a"}); got != "team-a" {
+		t.Fatalf("invalid override = %q, want team-a", got)
+	}
+	if got := settings.ResolveNamespace(settings.Cluster{}, settings.Global{DefaultNamespace: "no/slash"}); got != "default" {
+		t.Fatalf("invalid global = %q, want default", got)
+	}
+}
+
+func TestValidateNamespace(t *testing.T) {
+	for _, ns := range []string{"", "default", "team-a", "a1"} {
+		if err := settings.ValidateNamespace(ns); err != nil {
+			t.Errorf("ValidateNamespace(%q) = %v", ns, err)
+		}
+	}
+	for _, ns := range []string{"Team", "-a", "a_b", "a.b", "x123456789012345678901234567890123456789012345678901234567890123"} {
+		if err := settings.ValidateNamespace(ns); err == nil {
+			t.Errorf("ValidateNamespace(%q) accepted", ns)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/types/key"
+	"tailscale.com/types/views"
+
+	"github.com/docxology/GuildNet/internal/ts"
+)
+
+func TestTSNetStatusSummarize(t *testing.T) {
+	selfKey, k1, k2 := key.NewNode().Public(), key.NewNode().Public(), key.NewNode().Public()
+	routes := views.SliceOf([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")})
+	st := &ipnstate.Status{
+		BackendState: "Running",
+		AuthURL:      "https://login.example/secret-auth",
+		TailscaleIPs: []netip.Addr{netip.MustParseAddr("100.64.0.1")},
+		Self:         &ipnstate.PeerStatus{ID: "self", PublicKey: selfKey, HostName: "hostapp", DNSName: "hostapp.tail.net.", Online: true},
+		ExitNodeStatus: &ipnstate.ExitNodeStatus{
+			ID: "n1", Online: true, TailscaleIPs: []netip.Prefix{netip.MustParsePrefix("100.64.0.2/32")},
+		},
+		Peer: map[key.NodePublic]*ipnstate.PeerStatus{
+			k1: {ID: "n1", PublicKey: k1, HostName: "router", TailscaleIPs: []netip.Addr{netip.MustParseAddr("100.64.0.2")}, Online: true, ExitNode: true, PrimaryRoutes: &routes},
+			k2: {ID: "n2", PublicKey: k2, HostName: "agent", Online: false, LastSeen: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		},
+	}
+
+	got := ts.Summarize(st, false)
+	if got.Self.FQDN != "hostapp.tail.net" || got.Self.Addresses[0] != "100.64.0.1" {
+		t.Fatalf("self=%+v", got.Self)
+	}
+	if got.PeersTotal != 2 || got.PeersOnline != 1 || len(got.Peers) != 1 || got.Peers[0].Hostname != "router" {
+		t.Fatalf("online peers=%+v total=%d online=%d", got.Peers, got.PeersTotal, got.PeersOnline)
+	}
+	if r := got.Peers[0].SubnetRoutes; len(r) != 1 || r[0] != "10.0.0.0/24" {
+		t.Fatalf("subnet routes=%v", r)
+	}
+	if got.ExitNode == nil || got.ExitNode.ID != "n1" || got.ExitNode.Addresses[0] != "100.64.0.2" {
+		t.Fatalf("exit node=%+v", got.ExitNode)
+	}
+
+	all := ts.Summarize(st, true)
+	if len(all.Peers) != 2 || all.Peers[0].Hostname != "agent" || all.Peers[0].LastSeen != "2026-01-02T03:04:05Z" {
+		t.Fatalf("all peers=%+v", all.Peers)
+	}
+	b, _ := json.Marshal(all)
+	for _, secret := range []string{selfKey.String(), k1.String(), strings.TrimPrefix(k2.String(), "nodekey:"), "secret-auth"} {
+		if strings.Contains(string(b), secret) {
+			t.Fatalf("status leaks %q: %s", secret, b)
+		}
+	}
+}
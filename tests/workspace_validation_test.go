@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	apiv1alpha1 "github.com/docxology/GuildNet/api/v1alpha1"
+)
+
+func TestValidateHostAliases(t *testing.T) {
+	ok := []corev1.HostAlias{{IP: "10.0.0.5", Hostnames: []string{"git.internal", "registry.corp.local"}}}
+	if err := apiv1alpha1.ValidateHostAliases(ok); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bad := [][]corev1.HostAlias{
+		{{IP: "not-an-ip", Hostnames: []string{"a"}}},
+		{{IP: "10.0.0.5"}},
+		{{IP: "10.0.0.5", Hostnames: []string{"Bad_Host"}}},
+	}
+	for i, b := range bad {
+		if err := apiv1alpha1.ValidateHostAliases(b); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestValidateEnvFrom(t *testing.T) {
+	ok := []corev1.EnvFromSource{
+		{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}}},
+		{Prefix: "DB_", SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "db.creds"}}},
+	}
+	if err := apiv1alpha1.ValidateEnvFrom(ok); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cm := &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "cfg"}}
+	bad := []struct {
+		src   corev1.EnvFromSource
+		field string
+	}{
+		{corev1.EnvFromSource{}, "envFrom[0]"},
+		{corev1.EnvFromSource{ConfigMapRef: cm, SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "s"}}}, "envFrom[0]"},
+		{corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "Bad_Name"}}}, "envFrom[0].secretRef.name"},
+		{corev1.EnvFromSource{ConfigMapRef: cm, Prefix: "1BAD"}, "envFrom[0].prefix"},
+	}
+	for i, b := range bad {
+		err := apiv1alpha1.ValidateEnvFrom([]corev1.EnvFromSource{b.src})
+		fe, ok := err.(*apiv1alpha1.FieldError)
+		if !ok {
+			t.Fatalf("case %d: expected *FieldError, got %v", i, err)
+		}
+		if fe.Field != b.field {
+			t.Fatalf("case %d: field = %q, want %q", i, fe.Field, b.field)
+		}
+	}
+}
+
+func TestValidateDNS(t *testing.T) {
+	if err := apiv1alpha1.ValidateDNS("", nil); err != nil {
+		t.Fatalf("empty dns should be valid: %v", err)
+	}
+	cfg := &corev1.PodDNSConfig{Nameservers: []string{"1.1.1.1"}, Searches: []string{"corp.local"}}
+	if err := apiv1alpha1.ValidateDNS(corev1.DNSNone, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := apiv1alpha1.ValidateDNS(corev1.DNSNone, nil); err == nil {
+		t.Fatalf("policy None without nameservers should fail")
+	}
+	if err := apiv1alpha1.ValidateDNS("", &corev1.PodDNSConfig{Nameservers: []string{"dns.example"}}); err == nil {
+		t.Fatalf("non-IP nameserver should fail")
+	}
+	if err := apiv1alpha1.ValidateDNS("Bogus", nil); err == nil {
+		t.Fatalf("unknown policy should fail")
+	}
+}
+
+func TestValidateStrategy(t *testing.T) {
+	zero := intstr.FromInt(0)
+	pct := intstr.FromString("25%")
+	one := intstr.FromInt(1)
+	cases := []struct {
+		name string
+		st   *apiv1alpha1.WorkspaceStrategy
+		ok   bool
+	}{
+		{"unset", nil, true},
+		{"recreate", &apiv1alpha1.WorkspaceStrategy{Type: appsv1.RecreateDeploymentStrategyType}, true},
+		{"recreate with surge", &apiv1alpha1.WorkspaceStrategy{Type: appsv1.RecreateDeploymentStrategyType, MaxSurge: &one}, false},
+		{"rolling surge only", &apiv1alpha1.WorkspaceStrategy{MaxSurge: &one, MaxUnavailable: &zero}, true},
+		{"rolling stalls", &apiv1alpha1.WorkspaceStrategy{MaxSurge: &zero, MaxUnavailable: &pct}, false},
+		{"bogus type", &apiv1alpha1.WorkspaceStrategy{Type: "BlueGreen"}, false},
+	}
+	for _, c := range cases {
+		if err := apiv1alpha1.ValidateStrategy(c.st); (err == nil) != c.ok {
+			t.Fatalf("%s: err=%v want ok=%v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestValidateAutoscale(t *testing.T) {
+	i32 := func(v int32) *int32 { return &v }
+	cases := []struct {
+		name string
+		as   *apiv1alpha1.WorkspaceAutoscale
+		ok   bool
+	}{
+		{"unset", nil, true},
+		{"defaults", &apiv1alpha1.WorkspaceAutoscale{MaxReplicas: 3}, true},
+		{"max below min", &apiv1alpha1.WorkspaceAutoscale{MinReplicas: i32(4), MaxReplicas: 2}, false},
+		{"zero min", &apiv1alpha1.WorkspaceAutoscale{MinReplicas: i32(0), MaxReplicas: 2}, false},
+		{"cpu out of range", &apiv1alpha1.WorkspaceAutoscale{MaxReplicas: 2, TargetCPUUtilization: i32(150)}, false},
+	}
+	for _, c := range cases {
+		if err := apiv1alpha1.ValidateAutoscale(c.as); (err == nil) != c.ok {
+			t.Fatalf("%s: err=%v want ok=%v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestValidateServiceAccountName(t *testing.T) {
+	for _, ok := range []string{"", "default", "ws-runner", "team.a.runner"} {
+		if err := apiv1alpha1.ValidateServiceAccountName(ok); err != nil {
+			t.Fatalf("%q: unexpected error: %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"Runner", "under_score", "-lead", "a/b"} {
+		if err := apiv1alpha1.ValidateServiceAccountName(bad); err == nil {
+			t.Fatalf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestWorkspaceDeepCopyServiceAccount(t *testing.T) {
+	mount := true
+	in := &apiv1alpha1.Workspace{Spec: apiv1alpha1.WorkspaceSpec{Image: "x", ServiceAccountName: "runner", AutomountServiceAccountToken: &mount}}
+	out := in.DeepCopy()
+	if out.Spec.ServiceAccountName != "runner" || out.Spec.AutomountServiceAccountToken == nil || !*out.Spec.AutomountServiceAccountToken {
+		t.Fatalf("deep copy lost service account fields: %+v", out.Spec)
+	}
+	if out.Spec.AutomountServiceAccountToken == in.Spec.AutomountServiceAccountToken {
+		t.Fatalf("automount pointer shared between copies")
+	}
+}
+
+func TestValidatePriorityClassName(t *testing.T) {
+	for _, ok := range []string{"", "high-priority", "system.critical"} {
+		if err := apiv1alpha1.ValidatePriorityClassName(ok); err != nil {
+			t.Fatalf("%q: unexpected error: %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"High", "under_score", "-lead"} {
+		if err := apiv1alpha1.ValidatePriorityClassName(bad); err == nil {
+			t.Fatalf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestValidateDisruption(t *testing.T) {
+	i := func(v int) *intstr.IntOrString { x := intstr.FromInt(v); return &x }
+	s := func(v string) *intstr.IntOrString { x := intstr.FromString(v); return &x }
+	cases := []struct {
+		name     string
+		min, max *intstr.IntOrString
+		ok       bool
+	}{
+		{"unset", nil, nil, true},
+		{"min int", i(1), nil, true},
+		{"max percent", nil, s("50%"), true},
+		{"both", i(1), i(1), false},
+		{"negative", i(-1), nil, false},
+		{"over 100%", nil, s("150%"), false},
+		{"not a percent", s("two"), nil, false},
+	}
+	for _, c := range cases {
+		if err := apiv1alpha1.ValidateDisruption(c.min, c.max); (err == nil) != c.ok {
+			t.Fatalf("%s: err=%v want ok=%v", c.name, err, c.ok)
+		}
+	}
+	in := &apiv1alpha1.Workspace{Spec: apiv1alpha1.WorkspaceSpec{Image: "x", PriorityClassName: "high", MinAvailable: i(1)}}
+	out := in.DeepCopy()
+	if out.Spec.PriorityClassName != "high" || out.Spec.MinAvailable == nil || out.Spec.MinAvailable == in.Spec.MinAvailable {
+		t.Fatalf("deep copy mishandled disruption fields: %+v", out.Spec)
+	}
+}
+
+func TestValidateIngress(t *testing.T) {
+	cases := []struct {
+		name   string
+		ing    *apiv1alpha1.WorkspaceIngress
+		domain string
+		ok     bool
+	}{
+		{"unset", nil, "apps.example.com", true},
+		{"path and annotations", &apiv1alpha1.WorkspaceIngress{Path: "/ide", PathType: networkingv1.PathTypeImplementationSpecific, Annotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/$2"}}, "", true},
+		{"host in domain", &apiv1alpha1.WorkspaceIngress{Host: "ws1.apps.example.com"}, "apps.example.com", true},
+		{"host is domain", &apiv1alpha1.WorkspaceIngress{Host: "apps.example.com"}, "apps.example.com.", true},
+		{"host outside domain", &apiv1alpha1.WorkspaceIngress{Host: "evil.com"}, "apps.example.com", false},
+		{"suffix without dot", &apiv1alpha1.WorkspaceIngress{Host: "notapps.example.com"}, "apps.example.com", false},
+		{"unrestricted host", &apiv1alpha1.WorkspaceIngress{Host: "any.example.org"}, "", true},
+		{"bad host", &apiv1alpha1.WorkspaceIngress{Host: "Bad_Host"}, "", false},
+		{"relative path", &apiv1alpha1.WorkspaceIngress{Path: "ide"}, "", false},
+		{"bad path type", &apiv1alpha1.WorkspaceIngress{PathType: "Regex"}, "", false},
+		{"bad annotation key", &apiv1alpha1.WorkspaceIngress{Annotations: map[string]string{"bad key": "x"}}, "", false},
+	}
+	for _, c := range cases {
+		if err := apiv1alpha1.ValidateIngress(c.ing, c.domain); (err == nil) != c.ok {
+			t.Fatalf("%s: err=%v want ok=%v", c.name, err, c.ok)
+		}
+	}
+	in := &apiv1alpha1.Workspace{Spec: apiv1alpha1.WorkspaceSpec{Image: "x", Ingress: &apiv1alpha1.WorkspaceIngress{Annotations: map[string]string{"a": "1"}}}}
+	out := in.DeepCopy()
+	out.Spec.Ingress.Annotations["a"] = "2"
+	if in.Spec.Ingress.Annotations["a"] != "1" {
+		t.Fatal("deep copy shares ingress annotations")
+	}
+}
+
+func TestValidateRunMode(t *testing.T) {
+	n := func(v int32) *int32 { return &v }
+	cases := []struct {
+		name string
+		mode apiv1alpha1.RunMode
+		job  *apiv1alpha1.WorkspaceJob
+		ok   bool
+	}{
+		{"default", "", nil, true},
+		{"service", apiv1alpha1.RunModeService, nil, true},
+		{"job defaults", apiv1alpha1.RunModeJob, nil, true},
+		{"job tuned", apiv1alpha1.RunModeJob, &apiv1alpha1.WorkspaceJob{RestartPolicy: corev1.RestartPolicyOnFailure, BackoffLimit: n(3)}, true},
+		{"unknown mode", "CronJob", nil, false},
+		{"job tuning on service", "", &apiv1alpha1.WorkspaceJob{BackoffLimit: n(1)}, false},
+		{"restart always", apiv1alpha1.RunModeJob, &apiv1alpha1.WorkspaceJob{RestartPolicy: corev1.RestartPolicyAlways}, false},
+		{"negative backoff", apiv1alpha1.RunModeJob, &apiv1alpha1.WorkspaceJob{BackoffLimit: n(-1)}, false},
+	}
+	for _, c := range cases {
+		if err := apiv1alpha1.ValidateRunMode(c.mode, c.job); (err == nil) != c.ok {
+			t.Fatalf("%s: err=%v want ok=%v", c.name, err, c.ok)
+		}
+	}
+	in := &apiv1alpha1.Workspace{Spec: apiv1alpha1.WorkspaceSpec{Image: "x", RunMode: apiv1alpha1.RunModeJob, Job: &apiv1alpha1.WorkspaceJob{BackoffLimit: n(2)}}}
+	out := in.DeepCopy()
+	*out.Spec.Job.BackoffLimit = 5
+	if out.Spec.RunMode != apiv1alpha1.RunModeJob || *in.Spec.Job.BackoffLimit != 2 {
+		t.Fatalf("deep copy mishandled job fields: %+v", out.Spec)
+	}
+}
@@ -5,6 +5,8 @@ export type Server = {
   name: string
   image: string
   status: 'pending' | 'running' | 'failed' | 'stopped'
+  // why a failed server failed, e.g. ImagePullBackOff, CrashLoopBackOff, OOMKilled
+  statusReason?: string
   node?: string
   created_at?: string
   updated_at?: string
@@ -50,4 +52,6 @@ export type DeployImage = {
   label: string
   image: string
   description?: string
+  ports?: Port[]
+  env?: Record<string, string>
 }
@@ -31,16 +31,18 @@ export class WSManager extends Emitter {
   private connectTimer?: number
   private lastPong = Date.now()
   private didProbe = false // single-shot status probe on error
+  private events: string[] = [] // typed SSE events emitted under their own name
 
   constructor(
     url: string | (() => string),
-    opts?: { maxRetries?: number; heartbeatInterval?: number }
+    opts?: { maxRetries?: number; heartbeatInterval?: number; events?: string[] }
   ) {
     super()
     this.urlOrFn = url
     if (opts?.maxRetries != null) this.maxRetries = opts.maxRetries
     if (opts?.heartbeatInterval != null)
       this.heartbeatInterval = opts.heartbeatInterval
+    if (opts?.events) this.events = opts.events
   }
 
   getState() {
@@ -92,6 +94,18 @@ export class WSManager extends Emitter {
           } catch {}
         }
       }
+    // Frames with an `event:` line do not reach onmessage
+    for (const name of this.events) {
+      this.es?.addEventListener(name, (ev) => {
+        markOpenOnce()
+        const data = (ev as MessageEvent).data
+        if (typeof data === 'string') {
+          try {
+            this.emit(name, JSON.parse(data))
+          } catch {}
+        }
+      })
+    }
     if (this.es)
       this.es.onerror = async (ev) => {
         try {
@@ -24,6 +24,10 @@ export default function Deploy() {
     await fetchJSON('/api/deploy/headscale', { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify({ name: hsName() || undefined }) })
     refetch()
   }
+  const retryJob = async (id: string) => {
+    await fetchJSON(`/api/jobs/${encodeURIComponent(id)}?action=retry`, { method: 'POST' })
+    refetch()
+  }
   const createCluster = async () => {
     await fetchJSON('/api/deploy/clusters', { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify({ name: clName() || undefined }) })
     refetch()
@@ -102,11 +106,19 @@ export default function Deploy() {
         <h2 class="text-lg font-semibold">Jobs</h2>
         <div class="border rounded divide-y">
           <For each={jobs()?.slice().reverse()}>{j => (
-            <div class="p-3 flex items-center gap-4">
-              <div class="text-xs w-40 truncate">{j.id}</div>
-              <div class="w-40">{j.kind}</div>
-              <div class="w-32">{j.status}</div>
-              <div class="w-32">{Math.round((j.progress || 0) * 100)}%</div>
+            <div class="p-3">
+              <div class="flex items-center gap-4">
+                <div class="text-xs w-40 truncate">{j.id}</div>
+                <div class="w-40">{j.kind}</div>
+                <div class="w-32" classList={{ 'text-red-600 font-semibold': j.status === 'failed' }}>{j.status}</div>
+                <div class="w-32">{Math.round((j.progress || 0) * 100)}%</div>
+                <Show when={j.status === 'failed' && j.retriable && !j.retriedBy}>
+                  <button class="px-2 py-1 text-sm border rounded" onClick={() => retryJob(j.id)}>Retry</button>
+                </Show>
+              </div>
+              <Show when={j.status === 'failed' && j.error}>
+                <div class="mt-1 text-sm text-red-600 break-words">{j.error}</div>
+              </Show>
             </div>
           )}</For>
         </div>
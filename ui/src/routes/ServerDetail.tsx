@@ -90,7 +90,8 @@ export default function ServerDetail() {
       return apiUrl(`/api/cluster/${encodeURIComponent(cid)}/proxy/server/${encodeURIComponent(id)}/`)
     }
     const s = srv()
-    if (s?.url) return s.url
+    // Relative server URLs are proxy paths on the API host
+    if (s?.url) return s.url.startsWith('/') ? apiUrl(s.url) : s.url
     return apiUrl(`/proxy/server/${encodeURIComponent(id)}/`)
   })
 
@@ -194,19 +195,28 @@ export default function ServerDetail() {
     setWsLogs([])
 
     const url = apiUrl(`/api/cluster/${encodeURIComponent(cid)}/workspaces/${encodeURIComponent(name)}/logs/stream`)
-    sse = new WSManager(url)
+    sse = new WSManager(url, { events: ['log', 'eof'] })
     const offState = sse.on('state', () => {})
-    const offMsg = sse.on('message', (obj: any) => {
+    const onLine = (obj: any) => {
       if (obj && obj.t && obj.msg) {
         logBuf.push({ t: obj.t, msg: obj.msg })
         setWsLogs([...logBuf.get()])
       }
+    }
+    // untyped frames come from servers that predate typed log events
+    const offMsg = sse.on('message', onLine)
+    const offLog = sse.on('log', onLine)
+    const offEOF = sse.on('eof', (obj: any) => {
+      const why = [obj?.reason, obj?.exitCode != null ? `exit ${obj.exitCode}` : ''].filter(Boolean).join(', ')
+      onLine({ t: obj?.t || new Date().toISOString(), msg: `[${obj?.pod || name}] -- log ended${why ? ` (${why})` : ''} --` })
     })
     sse.open()
 
     onCleanup(() => {
       offState()
       offMsg()
+      offLog()
+      offEOF()
       sse?.close()
       sse = undefined
     })
@@ -221,7 +231,14 @@ export default function ServerDetail() {
           <>
             <Card
               title={server().name}
-              actions={<StatusPill status={server().status as any} />}
+              actions={
+                <div class="flex items-center gap-2">
+                  <StatusPill status={server().status as any} />
+                  <Show when={(server() as any).statusReason}>
+                    <span class="text-xs text-red-600 dark:text-red-400">{(server() as any).statusReason}</span>
+                  </Show>
+                </div>
+              }
             >
               <div class="grid sm:grid-cols-2 gap-4">
                 <div>
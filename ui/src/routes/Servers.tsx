@@ -61,6 +61,9 @@ export default function Servers() {
         <td class="py-2 pr-4">{srv.image}</td>
         <td class="py-2 pr-4">
           <StatusPill status={srv.status} />
+          {srv.statusReason && (
+            <div class="text-xs text-red-600 dark:text-red-400">{srv.statusReason}</div>
+          )}
         </td>
         <td class="py-2 pr-4">{srv.node ?? '-'}</td>
         <td class="py-2 pr-4">{timeAgo(srv.created_at)}</td>